@@ -4,19 +4,25 @@ MCP Document to Markdown Server
 Supports PDF and Microsoft Word documents
 """
 import asyncio
+import contextvars
 import json
+import os
 import sys
 import signal
 import logging
+import threading
 from pathlib import Path
-from typing import Any, Dict
+from typing import Any, Callable, Dict, List, Optional
 
 # Add python directory to path
 sys.path.insert(0, str(Path(__file__).parent / "python"))
 
 # MCP imports
+import anyio
 from mcp.server import Server
-from mcp.types import Tool, TextContent, CallToolResult, ListToolsResult
+from mcp.types import Tool, TextContent, ImageContent, CallToolResult, ListToolsResult, Resource
+from mcp.types import ListToolsRequest, ServerResult, ErrorData, INVALID_PARAMS
+from mcp.shared.exceptions import McpError
 import mcp.server.stdio
 
 # Configure logging
@@ -64,7 +70,7 @@ async def list_tools():
                     "properties": {
                         "pdf_path": {
                             "type": "string",
-                            "description": "Path to the PDF file to convert"
+                            "description": "Path to the PDF file to convert, or an http(s) URL to download it from (size limit: MAX_DOWNLOAD_MB, default 100)"
                         },
                         "output_dir": {
                             "type": "string", 
@@ -79,358 +85,2664 @@ async def list_tools():
                             "type": "boolean", 
                             "description": "Extract and reference images within relevant sections",
                             "default": True
+                        },
+                        "use_document_captions": {
+                            "type": "boolean",
+                            "description": "Use nearby 'Figure N: ...' text from the document as each image's caption and alt text",
+                            "default": True
+                        },
+                        "image_alt_mode": {
+                            "type": "string",
+                            "enum": ["caption", "generic", "placeholder"],
+                            "description": "Alt text of extracted images. caption: the nearby 'Figure N: ...' caption's description, else 'Image from page N'. generic: always 'Image from page N'. placeholder: 'ALT-TEXT-TODO page N, caption: ...' for a later step (a person or a vision model) to replace with a description",
+                            "default": "caption"
+                        },
+                        "section_naming": {
+                            "type": "string",
+                            "enum": ["numbered_slug", "numbered", "slug", "hashed"],
+                            "description": "Section file names: numbered_slug (01-overview.md), numbered (01.md), slug (overview.md), or hashed (3f9a0c1d2e4b.md, stable while the section is unchanged). Numbers are padded to the section count so files sort in document order; manifest.json records the scheme",
+                            "default": "numbered_slug"
+                        },
+                        "output_layout": {
+                            "type": "string",
+                            "description": "Directory layout template using {output_dir}, {doc_id}, {artifact_type}, {doc_type}, {date}, {year}, {month}, or a preset (nested, flat, by_date, by_type, by_artifact). Default: {output_dir}/{doc_id}/{artifact_type}"
+                        },
+                        "corpus_index_path": {
+                            "type": "string",
+                            "description": "Optional shared corpus-index.json to record this conversion in (safe under concurrent batch conversions)"
+                        },
+                        "extract_keywords": {
+                            "type": "boolean",
+                            "description": "Write keywords.json: bold/italic terms with occurrence counts and first-appearance page",
+                            "default": False
+                        },
+                        "on_conflict": {
+                            "type": "string",
+                            "enum": ["error", "overwrite", "merge"],
+                            "description": "What to do when the output location already holds a different document's conversion: error (default), overwrite (remove the previous files first), or merge (keep both)",
+                            "default": "error"
+                        },
+                        "clean_output": {
+                            "type": "boolean",
+                            "description": "Remove the previous conversion's generated directories (sections, chunked, images, tables) once the new conversion has succeeded, just before its files are moved into place, so no stale files from a longer earlier run remain. Other files in the output directory are left alone",
+                            "default": False
+                        },
+                        "extract_signatures": {
+                            "type": "boolean",
+                            "description": "Record digital signature metadata (signer, time, presence flags; not cryptographically verified) in manifest.json",
+                            "default": False
+                        },
+                        "extract_form_fields": {
+                            "type": "boolean",
+                            "description": "Read interactive form (AcroForm) fields, whose names and filled-in values are not part of the page text: a \"Form Fields\" section lists each field's name, label, type, value, and page, and conversion-metadata.json has them as form_fields. PDFs without form fields get no section. Not available with streaming",
+                            "default": False
+                        },
+                        "section_links": {
+                            "type": "boolean",
+                            "description": "Add prev/next/parent section file names to each section's front-matter for site navigation",
+                            "default": True
+                        },
+                        "frontmatter": {
+                            "type": "boolean",
+                            "description": "Record the source in front-matter: a README block (title, source_pdf, page_start, page_end) and source_pdf/page_start/page_end in each section file. false leaves those out but keeps the other section fields. The fields written are listed in the result and in manifest.json as front_matter_fields",
+                            "default": True
+                        },
+                        "order_by": {
+                            "type": "string",
+                            "enum": ["appearance", "outline"],
+                            "description": "Section order: appearance (physical page order, default) or outline (bookmark tree order, for reassembled/annexed documents; sections without an outline entry keep appearance order)",
+                            "default": "appearance"
+                        },
+                        "wrap_width": {
+                            "type": "integer",
+                            "description": "Soft-wrap prose lines at this column at word boundaries (0 = off). Code blocks, tables, and URLs are never wrapped",
+                            "default": 0
+                        },
+                        "normalize_headings": {
+                            "type": "boolean",
+                            "description": "Give each section file a single H1 (its title) and demote the headings below it, keeping their nesting",
+                            "default": True
+                        },
+                        "heading_offset": {
+                            "type": "integer",
+                            "description": "Shift every heading in section files down this many levels (0-5, capped at H6), for embedding them under another document's headings. Applies with normalize_headings",
+                            "default": 0
+                        },
+                        "render_full_pages": {
+                            "type": "boolean",
+                            "description": "Render each source page to images/page-NNN.png and link it from every section file's front-matter as source_page_image (for \"view original\" links)",
+                            "default": False
+                        },
+                        "drop_empty_sections": {
+                            "type": "boolean",
+                            "description": "Fold sections that contain only a heading into the next section (listed as merged_headings in its front-matter); a trailing heading-only section is dropped",
+                            "default": True
+                        },
+                        "preserve_line_numbers": {
+                            "type": "boolean",
+                            "description": "Legal documents: keep margin line numbers as [L12] anchors at the start of the lines they label instead of stripping them",
+                            "default": False
+                        },
+                        "page_range": {
+                            "type": "string",
+                            "description": "Convert only these pages: pages and inclusive ranges such as \"100-140\" or \"1,5,9-12\". Pages outside the range are never read; malformed or out-of-range specs are rejected before conversion"
+                        },
+                        "sample_pages": {
+                            "type": ["integer", "string"],
+                            "description": "Convert only a sample of pages spread across the document: a page count (e.g. 20) or a percentage (e.g. \"10%\"). Output is marked as a sample"
+                        },
+                        "sample_seed": {
+                            "type": "integer",
+                            "description": "Seed for sample_pages; the same seed picks the same pages",
+                            "default": 0
+                        },
+                        "reflow_paragraphs": {
+                            "type": "boolean",
+                            "description": "Join words cut without a hyphen at column and page breaks (e.g. \"internatio\" / \"nal\") when the joined word appears elsewhere in the document or in the system word list",
+                            "default": False
+                        },
+                        "column_layout": {
+                            "type": "string",
+                            "enum": ["auto", "single", "double"],
+                            "description": "Reading order for multi-column pages. auto: detect two-column pages (academic papers) and read them column by column instead of interleaving lines across columns; titles, tables, and other full-width blocks stay whole. single: keep the PDF's order. double: read every page as two columns",
+                            "default": "auto"
+                        },
+                        "reading_order": {
+                            "type": "string",
+                            "enum": ["auto", "raw", "ltr", "rtl"],
+                            "description": "Order of text blocks on a page. ltr: top to bottom, blocks side by side left to right (fixes sidebars or text boxes drawn before the main text). rtl: rows read right to left and each line rebuilt in logical order for Arabic and Hebrew, keeping embedded Latin words and numbers in order. raw: keep the PDF's order. auto: rtl for pages mostly in right-to-left scripts, otherwise ltr only where the PDF's order is clearly wrong",
+                            "default": "auto"
+                        },
+                        "page_markers": {
+                            "type": "string",
+                            "enum": ["comment", "anchor", "none"],
+                            "description": "Line marking where each PDF page starts. comment: <!-- Page N -->, hidden by markdown renderers. anchor: <a id=\"page-N\"></a>, kept in the text an LLM reads so chunks carry their page numbers, and linkable as section-file.md#page-N (internal page links point at it). none: no markers. Chunking never splits a marker and keeps it with the text that follows, and chunk front-matter gives the pages each chunk covers",
+                            "default": "comment"
+                        },
+                        "page_marker_labels": {
+                            "type": "boolean",
+                            "description": "With page_markers anchor, follow each anchor with a small superscript page label (<sup>p. N</sup>) for human readers",
+                            "default": False
+                        },
+                        "strip_headers_footers": {
+                            "type": "boolean",
+                            "description": "Remove running headers and footers (page numbers, \"Confidential\", the chapter name): lines repeated at the same place in the top or bottom margin of at least half the pages. Conservative: long lines and text elsewhere on the page are kept. The result says how many lines were removed",
+                            "default": False
+                        },
+                        "image_variants": {
+                            "type": "string",
+                            "enum": ["highest_resolution", "keep_all"],
+                            "description": "highest_resolution: when the same picture is embedded at several resolutions (e.g. a preview and the original), keep only the largest file and link it wherever a smaller copy appeared; consolidations are listed in manifest.json. keep_all: extract every copy",
+                            "default": "highest_resolution"
+                        },
+                        "image_dedup": {
+                            "type": "boolean",
+                            "description": "Write byte-identical images (a logo or watermark on every page) once and link every occurrence to that file. false writes one file per occurrence, for when each image's position matters",
+                            "default": True
+                        },
+                        "image_format": {
+                            "type": "string",
+                            "enum": ["original", "png", "jpeg", "webp"],
+                            "description": "Format of extracted image files. original keeps each image's embedded encoding; jpeg or webp makes photo-heavy PDFs much smaller. Transparent images are flattened onto white for jpeg, CMYK is converted to RGB, and images that can't be decoded keep their original format. Bytes saved are reported in manifest.json",
+                            "default": "original"
+                        },
+                        "image_quality": {
+                            "type": "integer",
+                            "description": "Quality (1-100) for image_format jpeg or webp",
+                            "default": 85
+                        },
+                        "preserve_links": {
+                            "type": "boolean",
+                            "description": "Keep the PDF's clickable links: URLs become [text](url) and internal jumps link to the section file covering the target page",
+                            "default": True
+                        },
+                        "max_output_bytes": {
+                            "type": "integer",
+                            "description": "Abort the conversion, removing what it wrote, once it has written more than this many bytes (default: MAX_OUTPUT_MB env, 2 GB; 0 = no limit). The error names the limit that was hit"
+                        },
+                        "max_images_bytes": {
+                            "type": "integer",
+                            "description": "Abort once the extracted images together pass this many bytes (default: MAX_IMAGES_MB env, 1 GB; 0 = no limit)"
+                        },
+                        "max_image_bytes": {
+                            "type": "integer",
+                            "description": "Skip, with a warning, any single image larger than this many bytes (default: MAX_IMAGE_MB env, 50 MB; 0 = no limit)"
+                        },
+                        "detect_code_blocks": {
+                            "type": "boolean",
+                            "description": "Wrap runs of lines set in a monospaced font (code samples) in fenced code blocks, keeping their line breaks and indentation; the fence names the language when it is clear",
+                            "default": True
+                        },
+                        "preserve_text_decorations": {
+                            "type": "boolean",
+                            "description": "Keep revision marks: text struck through (a line drawn across it, or a StrikeOut annotation) becomes ~~text~~ and underlined text is wrapped in underline_marker, so deleted text in a redline doesn't read like kept text. Off by default since underline markers are HTML. The result and README note how many passages were marked",
+                            "default": False
+                        },
+                        "underline_marker": {
+                            "type": "string",
+                            "description": "Marker around underlined text with preserve_text_decorations: an HTML tag such as <u> or <ins> (closed with its end tag) or a symbol repeated on both sides such as ++; empty leaves underlined text unmarked",
+                            "default": "<u>"
+                        },
+                        "math_mode": {
+                            "type": "string",
+                            "enum": ["off", "image", "latex"],
+                            "description": "Display equations (lines set in math fonts or dense with math symbols): 'image' crops each into images/ and replaces its garbled text with a $$...$$ placeholder linking the crop; 'latex' converts the crop to LaTeX with pix2tex (optional dependency, checked only in this mode; without it, same as 'image'). Uncertain detections and conversions are marked ⚠️ with a link to the crop",
+                            "default": "off"
+                        },
+                        "detect_lists": {
+                            "type": "boolean",
+                            "description": "Write bulleted (•, -, *) and numbered (1. or 1)) lines as markdown lists, nested by their indent on the page, with items that wrap onto several lines joined; two or more items make a list",
+                            "default": True
+                        },
+                        "extract_api_endpoints": {
+                            "type": "boolean",
+                            "description": "Find HTTP endpoints (GET /v1/users, POST https://…/orders) in the text and write one file per endpoint to api-endpoints/ with its description and request/response schemas, plus a README index; manifest.json gives the count. Turn off for documents that are not API references",
+                            "default": True
+                        },
+                        "min_header_confidence": {
+                            "type": "number",
+                            "description": "Detect headings from font size and weight instead of text patterns: a line becomes a heading only when its font is at least 1.1× the page's body text and its confidence (size gain, bold, short line) reaches this value (0-1, e.g. 0.5). Unset keeps pattern-based detection. Bookmarks, when the PDF has them, still take precedence"
+                        },
+                        "export_tables_csv": {
+                            "type": "boolean",
+                            "description": "Also write each detected table as tables/table_p<page>_<n>.csv for spreadsheets and data tools; the section that holds a table links its CSV and manifest.json lists them",
+                            "default": False
+                        },
+                        "min_table_rows": {
+                            "type": "integer",
+                            "description": "With export_tables_csv: skip detected tables with fewer rows (header included)",
+                            "default": 2
+                        },
+                        "min_table_cols": {
+                            "type": "integer",
+                            "description": "With export_tables_csv: skip detected tables with fewer columns",
+                            "default": 2
+                        },
+                        "table_min_confidence": {
+                            "type": "number",
+                            "description": "With export_tables_csv: skip detected tables scoring below this confidence (0-1, mean of cell fill ratio and column alignment consistency). Each skipped table is listed in warnings with its reason",
+                            "default": 0.5
+                        },
+                        "streaming": {
+                            "type": "boolean",
+                            "description": "For very large PDFs (thousands of pages): write each section file, plus a record in sections.jsonl, as soon as its pages are extracted instead of holding the whole document in memory. Sections follow the top-level bookmarks, or 25-page groups without them. Only section text is written (no images, tables, chunks, or section links); progress is reported as each file lands. Run reprocess afterwards for chunks",
+                            "default": False
+                        },
+                        "output_mode": {
+                            "type": "string",
+                            "enum": ["standard", "canonical"],
+                            "description": "canonical: normalized, diff-friendly markdown for version control (stable heading spacing, content-hash image names, no timestamps or page-marker noise)",
+                            "default": "standard"
+                        },
+                        "output_format": {
+                            "type": "string",
+                            "enum": ["markdown", "asciidoc", "rst"],
+                            "description": "Markup for README and section files: markdown (.md, default), asciidoc (.adoc) or rst (.rst). Tables, images, code blocks, and front-matter use each format's native syntax; links between files follow the new extension",
+                            "default": "markdown"
+                        },
+                        "chunk_tokens": {
+                            "type": "integer",
+                            "description": "Also write chunked/ files of at most this many tokens per section (minimum 50), for retrieval pipelines. Chunks end at paragraph or sentence boundaries; tables and code blocks are never split. Not set: no chunk files"
+                        },
+                        "chunk_overlap": {
+                            "type": "integer",
+                            "description": "Tokens of trailing sentences repeated at the start of the next chunk in the same section (at most half of chunk_tokens)",
+                            "default": 0
+                        },
+                        "chunks_jsonl": {
+                            "type": "boolean",
+                            "description": "Also write chunked/chunks.jsonl (needs chunk_tokens): one JSON object per chunk with its text and metadata (source, section, heading_path, page_start/page_end) for loading straight into a vector store",
+                            "default": False
+                        },
+                        "vector_db_format": {
+                            "type": "string",
+                            "enum": ["generic", "pinecone", "chromadb", "weaviate", "qdrant"],
+                            "description": "Also write the chunks.jsonl records as chunked/<format>_format.json, the import format prepare_pdf_for_rag writes (needs chunks_jsonl). Not set: none"
+                        },
+                        "chunk_layout": {
+                            "type": "string",
+                            "enum": ["flat", "by_section", "by_size"],
+                            "description": "How chunk files are laid out under chunked/ (needs chunk_tokens). by_size: small/, medium/, large/, xlarge/ by the smallest model context each chunk fits. by_section: one folder per section (NN-title/chunk-NNN.md). flat: all in chunked/. chunk-manifest.json gives each file's path, and manifest.json records the layout",
+                            "default": "by_size"
+                        },
+                        "password": {
+                            "type": "string",
+                            "description": "Password for an encrypted PDF (user or owner password). Without it, or with a wrong one, the conversion fails with error_code password_required or wrong_password. Never logged"
+                        },
+                        "dry_run": {
+                            "type": "boolean",
+                            "description": "Preview without converting or writing anything: read the page tree, bookmarks, image lists, and a sample of page text, and return an estimated JSON plan (sections, chunks per size bucket, images, files, total bytes) to tune options before the real run",
+                            "default": False
+                        },
+                        "use_cache": {
+                            "type": "boolean",
+                            "description": "Skip the conversion when the output already holds a conversion of this exact PDF (SHA-256) with the same options, and return the existing files",
+                            "default": False
+                        },
+                        "tokenizer": {
+                            "type": "string",
+                            "enum": ["cl100k_base", "o200k_base", "p50k_base", "claude"],
+                            "description": "Tokenizer for chunk budgets and token estimates: a tiktoken encoding (cl100k_base: GPT-4/3.5, o200k_base: GPT-4o, p50k_base) or claude (approximate, 3.5 characters per token). Recorded in manifest.json",
+                            "default": "cl100k_base"
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit). Partial output is removed",
+                            "default": 300
                         }
                     },
                     "required": ["pdf_path"]
                 }
             ),
             Tool(
-                name="analyze_pdf_structure", 
-                description="Analyze PDF structure without converting",
+                name="convert_pdf_set",
+                description="Convert several PDFs that form one manual as a single document: files are combined in order, so chapters, sections, and navigation span all of them",
                 inputSchema={
                     "type": "object",
                     "properties": {
-                        "pdf_path": {
+                        "pdf_paths": {
+                            "type": "array",
+                            "items": {"type": "string"},
+                            "description": "PDF files in reading order"
+                        },
+                        "output_dir": {
                             "type": "string",
-                            "description": "Path to the PDF file to analyze"
+                            "description": "Directory to save the converted files (default: ./docs)"
+                        },
+                        "document_name": {
+                            "type": "string",
+                            "description": "Name for the combined document and its output folder (default: the first file's name)"
+                        },
+                        "options": {
+                            "type": "object",
+                            "description": "convert_pdf options (same names and defaults, e.g. order_by, page_range, output_format). page_range counts pages across the combined document"
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)",
+                            "default": 300
                         }
                     },
-                    "required": ["pdf_path"]
+                    "required": ["pdf_paths"]
                 }
             ),
             Tool(
-                name="prepare_pdf_for_rag",
-                description="Prepare PDF content for RAG workflows",
+                name="convert_pdf_batch",
+                description="Convert many independent PDFs, each into its own output folder, several at a time. One failing PDF does not stop the rest; returns a result per file and the totals",
                 inputSchema={
-                    "type": "object", 
+                    "type": "object",
                     "properties": {
-                        "pdf_path": {
-                            "type": "string",
-                            "description": "Path to the PDF file to process"
+                        "pdf_paths": {
+                            "type": "array",
+                            "items": {"type": "string"},
+                            "description": "PDF files to convert (or use directory)"
                         },
-                        "vector_db_format": {
+                        "directory": {
                             "type": "string",
-                            "description": "Vector database format (chromadb, pinecone, weaviate, qdrant)",
-                            "default": "chromadb"
+                            "description": "Convert the files in this directory matching pattern instead of listing pdf_paths"
                         },
-                        "chunk_size": {
-                            "type": "integer",
-                            "description": "Target chunk size in tokens",
-                            "default": 768
+                        "pattern": {
+                            "type": "string",
+                            "description": "Glob for directory, e.g. \"*.pdf\" or \"**/*.pdf\" to include subdirectories (converted into the same subfolders of output_dir)",
+                            "default": "*.pdf"
                         },
                         "output_dir": {
                             "type": "string",
-                            "description": "Directory to save RAG-prepared files (default: ./rag_output)"
+                            "description": "Directory to save the converted files (default: ./docs)"
+                        },
+                        "concurrency": {
+                            "type": "integer",
+                            "description": "Converter processes running at once (1-8); all batches together stay within MAX_PROCESSES",
+                            "default": 2
+                        },
+                        "options": {
+                            "type": "object",
+                            "description": "convert_pdf options applied to every file (same names and defaults, e.g. output_format, extract_images)"
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Per-file limit: a PDF still converting after this many seconds is stopped and reported as failed (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)",
+                            "default": 300
                         }
-                    },
-                    "required": ["pdf_path"]
+                    }
                 }
             ),
             Tool(
-                name="convert_docx",
-                description="Convert Word document to LLM-optimized markdown with semantic navigation structure",
+                name="convert_pdf_inline",
+                description="Convert a PDF and return everything in one JSON document: sections, chunks, and images as base64. For stateless clients that can't read files or resources; capped in size with truncation notices",
                 inputSchema={
                     "type": "object",
                     "properties": {
-                        "docx_path": {
+                        "pdf_path": {
                             "type": "string",
-                            "description": "Path to the Word document (.docx) to convert"
+                            "description": "Path to the PDF file to convert, or an http(s) URL to download it from"
                         },
-                        "output_dir": {
-                            "type": "string", 
-                            "description": "Directory to save the converted files (default: ./docs)"
+                        "options": {
+                            "type": "object",
+                            "description": "convert_pdf options (same names and defaults, e.g. sample_pages, order_by, wrap_width)"
                         },
-                        "preserve_tables": {
-                            "type": "boolean",
-                            "description": "Embed tables within sections as both markdown and JSON",
-                            "default": True
+                        "max_inline_bytes": {
+                            "type": "integer",
+                            "description": "Cap on the JSON document size; the last section that fits is truncated and later sections, images, chunks, and warnings omitted, one notice per kind of cut",
+                            "default": 4000000
                         },
-                        "extract_images": {
+                        "chunk_tokens": {
+                            "type": "integer",
+                            "description": "Maximum tokens per chunk",
+                            "default": 768
+                        },
+                        "include_images": {
                             "type": "boolean",
-                            "description": "Extract and reference images within relevant sections",
+                            "description": "Embed extracted images as base64",
                             "default": True
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)",
+                            "default": 300
                         }
                     },
-                    "required": ["docx_path"]
+                    "required": ["pdf_path"]
                 }
             ),
             Tool(
-                name="extract_docx_content",
-                description="Extract structured content from any Word document (direct approach)",
+                name="validate_pdf",
+                description="Pre-flight check before converting: is this a PDF that opens, how many pages, is it encrypted, is it scanned (no text layer), and roughly how long conversion will take, as JSON with warnings. Much cheaper than analyze_pdf_structure",
                 inputSchema={
                     "type": "object",
                     "properties": {
-                        "docx_path": {
-                            "type": "string", 
-                            "description": "Path to the Word document to extract content from"
+                        "pdf_path": {
+                            "type": "string",
+                            "description": "Path to the PDF file to check, or an http(s) URL to download it from"
                         },
-                        "config": {
-                            "type": "object",
-                            "description": "Optional configuration for customizing extraction behavior",
-                            "properties": {
-                                "preserve_formatting": {
-                                    "type": "boolean",
-                                    "description": "Preserve document formatting during extraction",
-                                    "default": True
-                                }
-                            }
+                        "password": {
+                            "type": "string",
+                            "description": "Password for an encrypted PDF, to check that it opens it. Never logged"
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)",
+                            "default": 300
                         }
                     },
-                    "required": ["docx_path"]
+                    "required": ["pdf_path"]
                 }
             ),
             Tool(
-                name="analyze_docx_structure", 
-                description="Analyze Word document structure without converting",
+                name="analyze_pdf_structure", 
+                description="Analyze PDF structure without converting",
                 inputSchema={
                     "type": "object",
                     "properties": {
-                        "docx_path": {
+                        "pdf_path": {
                             "type": "string",
-                            "description": "Path to the Word document to analyze"
+                            "description": "Path to the PDF file to analyze, or an http(s) URL to download it from"
+                        },
+                        "chapter_limit": {
+                            "type": "integer",
+                            "description": "Chapters to list in the text summary (at least 1). The JSON result always has every chapter with level and page",
+                            "minimum": 1,
+                            "default": 10
+                        },
+                        "output_format": {
+                            "type": "string",
+                            "enum": ["text", "ndjson"],
+                            "description": "text: summary plus one JSON document. ndjson: one JSON record per line (document, chapter, page_images, page_tables, summary) for incremental processing of large outlines",
+                            "default": "text"
+                        },
+                        "output_path": {
+                            "type": "string",
+                            "description": "With ndjson, stream the records to this file instead of returning them inline"
+                        },
+                        "password": {
+                            "type": "string",
+                            "description": "Password for an encrypted PDF. Without it, or with a wrong one, the analysis fails with error_code password_required or wrong_password. Never logged"
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit). A partial output_path file is removed",
+                            "default": 300
                         }
                     },
-                    "required": ["docx_path"]
+                    "required": ["pdf_path"]
                 }
             ),
             Tool(
-                name="prepare_docx_for_rag",
-                description="Prepare Word document content for RAG workflows",
+                name="extract_outline",
+                description="Return only the PDF's outline (bookmarks) as a nested JSON tree of title, level, and page, to decide whether to convert it. Without bookmarks, an outline is synthesized from numbered heading lines and flagged synthesized",
                 inputSchema={
                     "type": "object",
                     "properties": {
-                        "docx_path": {
+                        "pdf_path": {
                             "type": "string",
-                            "description": "Path to the Word document to prepare for RAG"
+                            "description": "Path to the PDF file, or an http(s) URL to download it from"
                         },
-                        "vector_db_format": {
+                        "password": {
                             "type": "string",
-                            "description": "Target vector database format",
-                            "enum": ["chromadb", "pinecone", "weaviate", "qdrant"],
-                            "default": "chromadb"
+                            "description": "Password for an encrypted PDF. Never logged"
                         },
-                        "chunk_size": {
-                            "type": "integer",
-                            "description": "Target tokens per chunk for embedding",
-                            "default": 768
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)",
+                            "default": 300
+                        }
+                    },
+                    "required": ["pdf_path"]
+                }
+            ),
+            Tool(
+                name="extract_tables_schema",
+                description="Extract PDF tables with inferred column types, a JSON schema, and typed JSON rows for database ingestion",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "pdf_path": {
+                            "type": "string",
+                            "description": "Path to the PDF file to extract tables from"
                         },
                         "output_dir": {
                             "type": "string",
-                            "description": "Directory to save RAG-ready files (default: ./rag_output)"
+                            "description": "Optional directory to also save tables_schema.json"
+                        },
+                        "min_table_rows": {
+                            "type": "integer",
+                            "description": "Discard detected tables with fewer rows (header included)",
+                            "default": 2
+                        },
+                        "min_table_cols": {
+                            "type": "integer",
+                            "description": "Discard detected tables with fewer columns",
+                            "default": 2
+                        },
+                        "table_min_confidence": {
+                            "type": "number",
+                            "description": "Discard detected tables scoring below this confidence (0-1, mean of cell fill ratio and column alignment consistency). 0 keeps everything that passes the size limits",
+                            "default": 0.5
+                        },
+                        "page_range": {
+                            "type": "string",
+                            "description": "Only look for tables on these pages, e.g. \"100-140\" or \"1,5,9-12\""
+                        }
+                    },
+                    "required": ["pdf_path"]
+                }
+            ),
+            Tool(
+                name="compare_fingerprint",
+                description="Compare a PDF against a prior fingerprint (from manifest.json) and report which pages changed",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "pdf_path": {
+                            "type": "string",
+                            "description": "Path to the current PDF file"
+                        },
+                        "fingerprint": {
+                            "type": ["object", "string"],
+                            "description": "Prior fingerprint object, its JSON text, or a path to a manifest.json or fingerprint JSON file"
+                        }
+                    },
+                    "required": ["pdf_path", "fingerprint"]
+                }
+            ),
+            Tool(
+                name="compare_pdfs",
+                description="Compare the structure of two PDFs (e.g. two revisions of a spec): added, removed, renamed, and moved chapters and sections, and page, table, and image count changes, as a markdown report and JSON",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "pdf_path_a": {
+                            "type": "string",
+                            "description": "Path to the earlier PDF"
+                        },
+                        "pdf_path_b": {
+                            "type": "string",
+                            "description": "Path to the later PDF"
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds, per PDF (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)"
+                        }
+                    },
+                    "required": ["pdf_path_a", "pdf_path_b"]
+                }
+            ),
+            Tool(
+                name="get_thumbnail",
+                description="Render a PDF page (default: page 1) to a small PNG thumbnail for previews",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "pdf_path": {
+                            "type": "string",
+                            "description": "Path to the PDF file"
+                        },
+                        "page": {
+                            "type": "integer",
+                            "description": "1-based page number to render",
+                            "default": 1
+                        },
+                        "max_dimension": {
+                            "type": "integer",
+                            "description": "Longest side of the thumbnail in pixels (16-2048)",
+                            "default": 256
+                        }
+                    },
+                    "required": ["pdf_path"]
+                }
+            ),
+            Tool(
+                name="extract_images",
+                description="Return the images embedded in a PDF as image content so the model can look at figures directly",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "pdf_path": {
+                            "type": "string",
+                            "description": "Path to the PDF file"
+                        },
+                        "page_range": {
+                            "type": "string",
+                            "description": "Only these 1-based pages, e.g. \"3\", \"100-140\" or \"1,5,9-12\" (default: all pages)"
+                        },
+                        "max_images": {
+                            "type": "integer",
+                            "description": "Return at most this many images, in page order (1-50); the rest are counted as omitted",
+                            "default": 10
+                        },
+                        "max_dimension": {
+                            "type": "integer",
+                            "description": "Downscale images whose longest side is larger than this many pixels (16-4096)",
+                            "default": 1024
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)",
+                            "default": 300
+                        }
+                    },
+                    "required": ["pdf_path"]
+                }
+            ),
+            Tool(
+                name="extract_references",
+                description="Extract the bibliography/references section as structured citations (authors, title, year, venue, DOI/URL); handles numbered and author-year styles",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "pdf_path": {
+                            "type": "string",
+                            "description": "Path to the PDF file"
+                        },
+                        "output_dir": {
+                            "type": "string",
+                            "description": "Optional directory to also save references.json and references.md"
+                        }
+                    },
+                    "required": ["pdf_path"]
+                }
+            ),
+            Tool(
+                name="extract_xmp_metadata",
+                description="Extract XMP metadata from a PDF: Dublin Core (title, creator, subject keywords, rights), XMP basic dates, PDF producer, and custom namespace fields",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "pdf_path": {
+                            "type": "string",
+                            "description": "Path to the PDF file"
+                        }
+                    },
+                    "required": ["pdf_path"]
+                }
+            ),
+            Tool(
+                name="canonicalize_markdown",
+                description="Rewrite the markdown files of an existing conversion in canonical, diff-friendly form for version control",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "directory": {
+                            "type": "string",
+                            "description": "Output folder of a conversion (holding its manifest.json); the README, sections, and chunks it lists are normalized in place"
+                        }
+                    },
+                    "required": ["directory"]
+                }
+            ),
+            Tool(
+                name="prepare_pdf_for_rag",
+                description="Prepare PDF content for RAG workflows",
+                inputSchema={
+                    "type": "object", 
+                    "properties": {
+                        "pdf_path": {
+                            "type": "string",
+                            "description": "Path to the PDF file to process"
+                        },
+                        "vector_db_format": {
+                            "type": "string",
+                            "description": "Vector database format (chromadb, pinecone, weaviate, qdrant)",
+                            "default": "chromadb"
+                        },
+                        "chunk_size": {
+                            "type": "integer",
+                            "description": "Target chunk size in tokens",
+                            "default": 768
+                        },
+                        "output_dir": {
+                            "type": "string",
+                            "description": "Directory to save RAG-prepared files (default: ./rag_output)"
+                        }
+                    },
+                    "required": ["pdf_path"]
+                }
+            ),
+            Tool(
+                name="convert_pdf_rag",
+                description="Convert a PDF for retrieval: convert_pdf with token-budget chunks plus chunked/chunks.jsonl, one JSON object per chunk with its text and metadata (source, section, heading path, page span), ready to embed and load into a vector store",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "pdf_path": {
+                            "type": "string",
+                            "description": "Path to the PDF file, or an http(s) URL to download it from"
+                        },
+                        "output_dir": {
+                            "type": "string",
+                            "description": "Directory to save the converted files (default: ./docs)"
+                        },
+                        "chunk_tokens": {
+                            "type": "integer",
+                            "description": "Maximum tokens per chunk (minimum 50)",
+                            "default": 768
+                        },
+                        "chunk_overlap": {
+                            "type": "integer",
+                            "description": "Tokens of trailing sentences repeated at the start of the next chunk in the same section (at most half of chunk_tokens)",
+                            "default": 128
+                        },
+                        "tokenizer": {
+                            "type": "string",
+                            "description": "Tokenizer for the chunk budget, as for convert_pdf (default: the one in options, else cl100k_base)"
+                        },
+                        "vector_db_format": {
+                            "type": "string",
+                            "enum": ["generic", "pinecone", "chromadb", "weaviate", "qdrant"],
+                            "description": "Also write the chunks as chunked/<format>_format.json, the import format prepare_pdf_for_rag writes. Not set: chunks.jsonl only"
+                        },
+                        "options": {
+                            "type": "object",
+                            "description": "Other convert_pdf options (same names and defaults, e.g. page_range, section_naming)"
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)",
+                            "default": 300
+                        }
+                    },
+                    "required": ["pdf_path"]
+                }
+            ),
+            Tool(
+                name="reprocess",
+                description="Re-chunk an existing conversion with a new chunk_tokens, chunk_overlap, or tokenizer without re-extracting the PDF: regenerates only the chunk files, chunk-manifest.json, and chunks.jsonl from the section files already written, and updates manifest.json. Much faster than convert_pdf",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "output_dir": {
+                            "type": "string",
+                            "description": "A converted document's folder (the one holding manifest.json), or the output_dir it was converted to"
+                        },
+                        "document": {
+                            "type": "string",
+                            "description": "Document folder name inside output_dir, when it holds several conversions"
+                        },
+                        "chunk_tokens": {
+                            "type": "integer",
+                            "description": "Maximum tokens per chunk (minimum 50)"
+                        },
+                        "chunk_overlap": {
+                            "type": "integer",
+                            "description": "Tokens of trailing sentences repeated at the start of the next chunk in the same section (at most half of chunk_tokens)",
+                            "default": 0
+                        },
+                        "tokenizer": {
+                            "type": "string",
+                            "enum": ["cl100k_base", "o200k_base", "p50k_base", "claude"],
+                            "description": "Tokenizer for the chunk budget (default: the one the conversion used, from manifest.json)"
+                        },
+                        "chunks_jsonl": {
+                            "type": "boolean",
+                            "description": "Also write chunks.jsonl (default: if the conversion wrote one)"
+                        },
+                        "chunk_layout": {
+                            "type": "string",
+                            "enum": ["flat", "by_section", "by_size"],
+                            "description": "Layout of the chunk files under chunked/, as for convert_pdf (default: the one the conversion used)"
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)",
+                            "default": 300
+                        }
+                    },
+                    "required": ["output_dir", "chunk_tokens"]
+                }
+            ),
+            Tool(
+                name="package_output",
+                description="Package a converted document as one ZIP archive for download: sections, images, chunks, and metadata, with paths inside prefixed by the document folder so it extracts into the same layout. Returns the archive path and size",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "output_dir": {
+                            "type": "string",
+                            "description": "A converted document's folder (the one holding manifest.json), or the output_dir it was converted to"
+                        },
+                        "document": {
+                            "type": "string",
+                            "description": "Document folder name inside output_dir, when it holds several conversions"
+                        },
+                        "output_path": {
+                            "type": "string",
+                            "description": "Where to write the archive (default: <document folder>.zip next to the folder; must be outside it)"
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)",
+                            "default": 300
+                        }
+                    },
+                    "required": ["output_dir"]
+                }
+            ),
+            Tool(
+                name="features_status",
+                description="Report which optional features are usable right now (the Python packages each one needs) with remediation hints",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Timeout for each endpoint reachability probe",
+                            "default": 3
+                        }
+                    }
+                }
+            ),
+            Tool(
+                name="check_environment",
+                description="Report whether the server is usable: the Python interpreter used for converter processes, its version, and missing or outdated core packages",
+                inputSchema={
+                    "type": "object",
+                    "properties": {}
+                }
+            ),
+            Tool(
+                name="server_info",
+                description="Report which build is running, for bug reports: version, git commit, build date, whether the scripts come from an installed build or a development checkout, and the Python interpreter",
+                inputSchema={
+                    "type": "object",
+                    "properties": {}
+                }
+            ),
+            Tool(
+                name="self_test",
+                description="Check the installation end to end: analyze and convert a generated two-page sample PDF in a temporary directory and report pass/fail for each stage (dependencies, analysis, extraction, tables, chunking, cleanup)",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)"
+                        }
+                    }
+                }
+            ),
+            Tool(
+                name="convert_docx",
+                description="Convert Word document to LLM-optimized markdown with semantic navigation structure",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "docx_path": {
+                            "type": "string",
+                            "description": "Path to the Word document (.docx) to convert"
+                        },
+                        "output_dir": {
+                            "type": "string", 
+                            "description": "Directory to save the converted files (default: ./docs)"
+                        },
+                        "preserve_tables": {
+                            "type": "boolean",
+                            "description": "Embed tables within sections as both markdown and JSON",
+                            "default": True
+                        },
+                        "extract_images": {
+                            "type": "boolean",
+                            "description": "Extract and reference images within relevant sections",
+                            "default": True
+                        },
+                        "output_layout": {
+                            "type": "string",
+                            "description": "Directory layout template or preset, as for convert_pdf ({doc_type} is docx)"
+                        },
+                        "section_naming": {
+                            "type": "string",
+                            "enum": ["numbered_slug", "numbered", "slug", "hashed"],
+                            "description": "Section file names, as for convert_pdf (slugs come from the section titles)",
+                            "default": "numbered_slug"
+                        },
+                        "section_links": {
+                            "type": "boolean",
+                            "description": "Add prev/next/parent section file names to each section's front-matter",
+                            "default": True
+                        }
+                    },
+                    "required": ["docx_path"]
+                }
+            ),
+            Tool(
+                name="extract_docx_content",
+                description="Extract structured content from any Word document (direct approach)",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "docx_path": {
+                            "type": "string", 
+                            "description": "Path to the Word document to extract content from"
+                        },
+                        "config": {
+                            "type": "object",
+                            "description": "Optional configuration for customizing extraction behavior",
+                            "properties": {
+                                "preserve_formatting": {
+                                    "type": "boolean",
+                                    "description": "Preserve document formatting during extraction",
+                                    "default": True
+                                }
+                            }
+                        }
+                    },
+                    "required": ["docx_path"]
+                }
+            ),
+            Tool(
+                name="analyze_docx_structure", 
+                description="Analyze Word document structure without converting",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "docx_path": {
+                            "type": "string",
+                            "description": "Path to the Word document to analyze"
+                        }
+                    },
+                    "required": ["docx_path"]
+                }
+            ),
+            Tool(
+                name="prepare_docx_for_rag",
+                description="Prepare Word document content for RAG workflows",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "docx_path": {
+                            "type": "string",
+                            "description": "Path to the Word document to prepare for RAG"
+                        },
+                        "vector_db_format": {
+                            "type": "string",
+                            "description": "Target vector database format",
+                            "enum": ["chromadb", "pinecone", "weaviate", "qdrant"],
+                            "default": "chromadb"
+                        },
+                        "chunk_size": {
+                            "type": "integer",
+                            "description": "Target tokens per chunk for embedding",
+                            "default": 768
+                        },
+                        "output_dir": {
+                            "type": "string",
+                            "description": "Directory to save RAG-ready files (default: ./rag_output)"
                         }
                     },
                     "required": ["docx_path"]
                 }
+            ),
+            Tool(
+                name="organize_markdown",
+                description="Organize already-extracted markdown (from markitdown, pandoc, or an earlier conversion) into the same sections/README/manifest layout as convert_pdf, without extracting anything",
+                inputSchema={
+                    "type": "object",
+                    "properties": {
+                        "markdown_path": {
+                            "type": "string",
+                            "description": "Path to the markdown file to organize"
+                        },
+                        "content": {
+                            "type": "string",
+                            "description": "Markdown to organize instead of a file"
+                        },
+                        "document_name": {
+                            "type": "string",
+                            "description": "Name for inline content, used for the output folder and README title (default: document.md)"
+                        },
+                        "output_dir": {
+                            "type": "string",
+                            "description": "Directory to save the organized files (default: ./docs)"
+                        },
+                        "split_by_chapters": {
+                            "type": "boolean",
+                            "description": "Start a section file at every heading; false keeps the document as one section",
+                            "default": True
+                        },
+                        "chunk_tokens": {
+                            "type": "integer",
+                            "description": "Also write chunked/ files of at most this many tokens per section (minimum 50), as for convert_pdf"
+                        },
+                        "chunk_overlap": {
+                            "type": "integer",
+                            "description": "Tokens of trailing sentences repeated at the start of the next chunk in the same section (at most half of chunk_tokens)",
+                            "default": 0
+                        },
+                        "extract_images": {
+                            "type": "boolean",
+                            "description": "Write embedded data: URI images to images/ and link them",
+                            "default": True
+                        },
+                        "output_layout": {
+                            "type": "string",
+                            "description": "Directory layout template or preset, as for convert_pdf ({doc_type} is markdown)"
+                        },
+                        "section_naming": {
+                            "type": "string",
+                            "enum": ["numbered_slug", "numbered", "slug", "hashed"],
+                            "description": "Section file names, as for convert_pdf (slugs come from the section titles)",
+                            "default": "numbered_slug"
+                        },
+                        "section_links": {
+                            "type": "boolean",
+                            "description": "Add prev/next/parent section file names to each section's front-matter",
+                            "default": True
+                        },
+                        "timeout_seconds": {
+                            "type": "number",
+                            "description": "Stop and return a timeout error after this many seconds (default: CONVERSION_TIMEOUT env or 300; 0 = no limit)",
+                            "default": 300
+                        }
+                    }
+                }
             )
         ]
 
-@app.call_tool()
-async def call_tool(name: str, arguments: Dict[str, Any]):
-    """Handle tool calls"""
+# Argument names whose values never reach the log
+SECRET_ARGUMENTS = ("password",)
+
+# Default tool arguments from MCP_CONFIG or ./mcp-markdown.json (loaded in main)
+SERVER_CONFIG = None
+# Input schema properties by tool name, for applying SERVER_CONFIG
+TOOL_PROPERTIES: Dict[str, Dict[str, Any]] = {}
+# Worker threads allowed at once across all tool calls (see worker_limit)
+WORKER_LIMIT = None
+# Converter processes allowed at once across all batches (see process_limit)
+PROCESS_LIMIT = None
+# One conversion at a time per output directory (see output_locks)
+OUTPUT_LOCKS = None
+# Root that path arguments resolve against and must stay inside (WORKSPACE_ROOT, loaded in main)
+WORKSPACE = None
+
+def redact_arguments(arguments: Dict[str, Any]) -> Dict[str, Any]:
+    """Tool arguments for logging, with secrets masked (also inside nested options)"""
+    return {key: "***" if key in SECRET_ARGUMENTS else redact_arguments(value) if isinstance(value, dict) else value
+            for key, value in arguments.items()}
+
+def tool_error(text: str) -> CallToolResult:
+    """
+    Tool result for a failed call (isError: true)
+    
+    Expected failures (missing file, bad option, missing library, a failed
+    conversion) are tool results the model can read and act on; only
+    protocol problems are left to the MCP layer.
+    """
+    return CallToolResult(content=[TextContent(type="text", text=text)], isError=True)
+
+@app.call_tool()
+async def call_tool(name: str, arguments: Dict[str, Any]):
+    """Handle tool calls"""
+    try:
+        logger.info(f"Tool called: {name} with args: {redact_arguments(arguments)}")
+        if SERVER_CONFIG is not None:
+            # Configured defaults fill in what the call leaves out
+            arguments = SERVER_CONFIG.apply(name, arguments, TOOL_PROPERTIES.get(name))
+        if WORKSPACE is not None:
+            # Relative paths resolve against WORKSPACE_ROOT; paths outside it are refused
+            arguments = WORKSPACE.resolve_arguments(arguments)
+        
+        if name == "extract_pdf_content":
+            return await handle_extract_pdf_content(arguments)
+        elif name == "convert_pdf":
+            return await with_downloaded_pdf(arguments, handle_convert_pdf)
+        elif name == "convert_pdf_set":
+            return await handle_convert_pdf_set(arguments)
+        elif name == "convert_pdf_batch":
+            return await handle_convert_pdf_batch(arguments)
+        elif name == "convert_pdf_inline":
+            return await with_downloaded_pdf(arguments, handle_convert_pdf_inline)
+        elif name == "validate_pdf":
+            return await with_downloaded_pdf(arguments, handle_validate_pdf)
+        elif name == "analyze_pdf_structure":
+            return await with_downloaded_pdf(arguments, handle_analyze_pdf)
+        elif name == "extract_outline":
+            return await with_downloaded_pdf(arguments, handle_extract_outline)
+        elif name == "extract_tables_schema":
+            return await handle_extract_tables_schema(arguments)
+        elif name == "compare_fingerprint":
+            return await handle_compare_fingerprint(arguments)
+        elif name == "compare_pdfs":
+            return await handle_compare_pdfs(arguments)
+        elif name == "get_thumbnail":
+            return await handle_get_thumbnail(arguments)
+        elif name == "extract_images":
+            return await handle_extract_images(arguments)
+        elif name == "extract_references":
+            return await handle_extract_references(arguments)
+        elif name == "extract_xmp_metadata":
+            return await handle_extract_xmp(arguments)
+        elif name == "canonicalize_markdown":
+            return await handle_canonicalize_markdown(arguments)
+        elif name == "prepare_pdf_for_rag":
+            return await handle_prepare_rag(arguments)
+        elif name == "convert_pdf_rag":
+            return await with_downloaded_pdf(arguments, handle_convert_pdf_rag)
+        elif name == "reprocess":
+            return await handle_reprocess(arguments)
+        elif name == "package_output":
+            return await handle_package_output(arguments)
+        elif name == "features_status":
+            return await handle_features_status(arguments)
+        elif name == "check_environment":
+            return await handle_check_environment(arguments)
+        elif name == "server_info":
+            return await handle_server_info(arguments)
+        elif name == "self_test":
+            return await handle_self_test(arguments)
+        elif name == "extract_docx_content":
+            return await handle_extract_docx_content(arguments)
+        elif name == "convert_docx":
+            return await handle_convert_docx(arguments)
+        elif name == "analyze_docx_structure":
+            return await handle_analyze_docx(arguments)
+        elif name == "prepare_docx_for_rag":
+            return await handle_prepare_docx_rag(arguments)
+        elif name == "organize_markdown":
+            return await handle_organize_markdown(arguments)
+        else:
+            raise ValueError(f"Unknown tool: {name}")
+            
+    except Exception as e:
+        from utils.output_capture import truncate_middle
+        logger.error(f"Tool execution failed: {e}")
+        return tool_error(f"Error: {truncate_middle(str(e))}")
+
+sdk_tools_list_handler = app.request_handlers[ListToolsRequest]
+
+async def handle_tools_list_request(request: Optional[ListToolsRequest]) -> ServerResult:
+    """
+    tools/list with cursor paging and brief or full descriptions
+    
+    Wraps the handler @app.list_tools() registers, which returns every tool
+    in full and fills the SDK's tool cache (used to validate tools/call
+    arguments against the full schemas): TOOLS_PAGE_SIZE sets the page
+    size, TOOLS_LIST_DETAIL or the request's _meta detail chooses brief or
+    full (see utils.tool_listing). The SDK itself calls this with no request
+    to refill its cache; that gets the full, unpaged list.
+    """
+    from utils.tool_listing import brief_schema, brief_text, paginate, tools_list_detail, tools_page_size
+    
+    result = await sdk_tools_list_handler(request)
+    if request is None:
+        return result
+    params = request.params
+    cursor = getattr(params, "cursor", None)
+    meta = getattr(params, "meta", None)
+    tools = result.root.tools
+    if tools_list_detail(getattr(meta, "detail", None)) == "brief":
+        tools = [tool.model_copy(update={"description": brief_text(tool.description),
+                                         "inputSchema": brief_schema(tool.inputSchema)})
+                 for tool in tools]
+    try:
+        page, next_cursor = paginate(tools, cursor, tools_page_size())
+    except ValueError as e:
+        raise McpError(ErrorData(code=INVALID_PARAMS, message=str(e)))
+    return ServerResult(ListToolsResult(tools=page, nextCursor=next_cursor))
+
+app.request_handlers[ListToolsRequest] = handle_tools_list_request
+
+# Registering these handlers advertises the resources capability on initialize
+@app.list_resources()
+async def list_resources():
+    """List generated files under the output directories as file:// resources"""
+    from utils.doc_resources import list_resources as list_doc_resources, remembered_files, resource_roots
+    
+    entries = await asyncio.get_running_loop().run_in_executor(
+        None, lambda: list_doc_resources(resource_roots(), remembered_files()))
+    return [
+        Resource(uri=entry['uri'], name=entry['name'], mimeType=entry['mime_type'], size=entry['size'])
+        for entry in entries
+    ]
+
+@app.read_resource()
+async def read_resource(uri):
+    """Return the text of a generated file"""
+    from utils.doc_resources import read_resource as read_doc_resource, remembered_files, resource_roots
+    
+    logger.info(f"Resource read: {uri}")
+    text, _ = read_doc_resource(str(uri), resource_roots(), remembered_files())
+    return text
+
+async def with_downloaded_pdf(args: Dict[str, Any], handler: Callable[[Dict[str, Any]], Any]):
+    """
+    Run a handler on args, first downloading pdf_path when it is an http(s) URL
+    
+    The download goes to a temporary directory that is removed once the
+    handler returns; the downloaded file's name names the output folder.
+    """
+    from utils.pdf_download import is_pdf_url, download_pdf
+    from utils.temp_files import TEMP_FILES
+    
+    url = args.get("pdf_path")
+    if not is_pdf_url(url):
+        return await handler(args)
+    
+    with TEMP_FILES.directory(prefix="pdf-download-") as temp_dir:
+        logger.info(f"Downloading PDF: {url}")
+        pdf_file = await asyncio.get_running_loop().run_in_executor(None, download_pdf, url, Path(temp_dir))
+        return await handler({**args, "pdf_path": str(pdf_file)})
+
+async def handle_extract_pdf_content(args: Dict[str, Any]):
+    """Handle generic PDF content extraction"""
+    try:
+        from processors.pdf_extractor import extract_pdf
+        
+        pdf_path = args["pdf_path"]
+        config = args.get("config", {})
+        
+        if not Path(pdf_path).exists():
+            raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        
+        logger.info(f"Extracting content from PDF: {pdf_path}")
+        
+        # Extract using generic approach
+        results = extract_pdf(pdf_path, config)
+        
+        # Format output for MCP response
+        output = []
+        output.append(f"# PDF Content Extraction Results\n")
+        output.append(f"**File:** {pdf_path}")
+        output.append(f"**Document Type:** {results['metadata']['document_type']}")
+        output.append(f"**Total Fields:** {results['metadata']['total_fields']}")
+        output.append(f"**Total Lines:** {results['summary']['total_lines']}")
+        output.append(f"**Has Tables:** {results['metadata']['has_tables']}")
+        output.append(f"**Has Lists:** {results['metadata']['has_lists']}")
+        
+        # Field type distribution
+        output.append(f"\n## Field Type Distribution")
+        for field_type, count in results['summary']['field_types'].items():
+            output.append(f"- **{field_type}:** {count}")
+        
+        # Show extracted fields
+        output.append(f"\n## Extracted Fields ({len(results['fields'])} total)")
+        
+        for i, field in enumerate(results['fields'][:20]):  # Show first 20 fields
+            output.append(f"\n### {i+1}. {field['name']}")
+            output.append(f"**Type:** {field['type']}")
+            if field['content']:
+                # Truncate very long content
+                content = field['content'][:300] + "..." if len(field['content']) > 300 else field['content']
+                output.append(f"**Content:** {content}")
+            if field['metadata']:
+                output.append(f"**Metadata:** {field['metadata']}")
+        
+        if len(results['fields']) > 20:
+            output.append(f"\n*... and {len(results['fields']) - 20} more fields*")
+        
+        # Show sections if detected
+        if results['structure']['sections']:
+            output.append(f"\n## Detected Sections ({len(results['structure']['sections'])})")
+            for section in results['structure']['sections'][:10]:
+                output.append(f"- {section}")
+            if len(results['structure']['sections']) > 10:
+                output.append(f"- *... and {len(results['structure']['sections']) - 10} more sections*")
+        
+        response_text = "\n".join(output)
+        
+        return CallToolResult(
+            content=[
+                TextContent(
+                    type="text", 
+                    text=response_text
+                )
+            ]
+        )
+        
+    except Exception as e:
+        logger.error(f"Error in PDF extraction: {str(e)}", exc_info=True)
+        return CallToolResult(
+            content=[
+                TextContent(
+                    type="text", 
+                    text=f" Error extracting PDF content: {str(e)}"
+                )
+            ],
+            isError=True
+        )
+
+
+# MCP log levels, least to most severe
+LOG_LEVELS = ("debug", "info", "notice", "warning", "error", "critical", "alert", "emergency")
+
+# Least severe converter output sent as notifications/message (client sets it with logging/setLevel)
+client_log_level = "info"
+
+@app.set_logging_level()
+async def set_logging_level(level: str) -> None:
+    """Handle logging/setLevel (registering it advertises the logging capability)"""
+    global client_log_level
+    client_log_level = level
+    logger.info(f"Client log level: {level}")
+
+def output_line_forwarder(loop: asyncio.AbstractEventLoop) -> Optional[Callable[[str], None]]:
+    """
+    Line listener forwarding converter output to the client while it runs
+    
+    PROGRESS lines go out as notifications/progress when the client asked for
+    progress with a progressToken in the tools/call _meta. Other lines go out
+    as notifications/message at the level their prefix gives (info, warning,
+    error) when it is at least the client's log level. Returns None outside a
+    request. The listener runs on the converter's thread and hands each
+    notification to the event loop.
+    """
+    from utils.progress import parse_progress_line, parse_log_line
+    
+    try:
+        ctx = app.request_context
+    except LookupError:
+        return None
+    token = getattr(ctx.meta, "progressToken", None) if ctx.meta else None
+    
+    def on_line(line: str) -> None:
+        update = parse_progress_line(line)
+        if update:
+            if token is not None:
+                asyncio.run_coroutine_threadsafe(send_progress(ctx.session, token, update), loop)
+            return
+        entry = parse_log_line(line)
+        if entry and LOG_LEVELS.index(entry["level"]) >= LOG_LEVELS.index(client_log_level):
+            asyncio.run_coroutine_threadsafe(send_log(ctx.session, entry), loop)
+    
+    return on_line
+
+async def send_log(session, entry: Dict[str, str]) -> None:
+    """Send one converter output line as a log message notification"""
+    try:
+        await session.send_log_message(level=entry["level"], data=entry["message"], logger="converter")
+    except Exception as e:
+        logger.warning(f"Could not send log notification: {e}")
+
+async def send_progress(session, token, update: Dict[str, Any]) -> None:
+    """Send one progress notification (with its message where the MCP library supports it)"""
+    try:
+        try:
+            await session.send_progress_notification(token, update["progress"], update["total"], update["message"])
+        except TypeError:
+            await session.send_progress_notification(token, update["progress"], update["total"])
+    except Exception as e:
+        logger.warning(f"Could not send progress notification: {e}")
+
+def worker_limit():
+    """The server's WorkerLimit (MAX_CONCURRENCY workers, default 1), created on first use"""
+    global WORKER_LIMIT
+    if WORKER_LIMIT is None:
+        from utils.concurrency import WorkerLimit
+        WORKER_LIMIT = WorkerLimit()
+    return WORKER_LIMIT
+
+def process_limit():
+    """The server's WorkerLimit for converter processes (MAX_PROCESSES, default 4), created on first use"""
+    global PROCESS_LIMIT
+    if PROCESS_LIMIT is None:
+        from utils.concurrency import WorkerLimit, max_processes
+        PROCESS_LIMIT = WorkerLimit(max_processes())
+    return PROCESS_LIMIT
+
+def output_locks():
+    """The server's OutputLocks, created on first use"""
+    global OUTPUT_LOCKS
+    if OUTPUT_LOCKS is None:
+        from utils.output_locks import OutputLocks
+        OUTPUT_LOCKS = OutputLocks()
+    return OUTPUT_LOCKS
+
+async def run_cancellable(work: Callable[[threading.Event], Any], timeout: Optional[float] = None,
+                          output_dir: Optional[str] = None) -> Any:
+    """
+    Run blocking work in a worker thread, stopping it if the tool call is cancelled
+    
+    The MCP library cancels the handler task when the client sends
+    notifications/cancelled or disconnects. A thread can't be killed, so
+    work receives a threading.Event to check between units of work; on
+    cancellation the event is set and the handler waits (shielded) for the
+    worker to finish cleaning up before the cancellation propagates.
+    
+    After timeout seconds the event is set the same way and the handler
+    again waits (shielded) for the worker to stop before raising
+    ConversionTimeout, so a timed-out worker never prints after the
+    caller's OutputCapture has exited or writes into a temporary directory
+    the caller has already removed. A worker stuck inside a library call
+    past TIMEOUT_GRACE_SECONDS is logged and still waited for.
+    
+    At most MAX_CONCURRENCY workers run at once; beyond that the call
+    waits for a free slot (cancellable, and not counted against timeout).
+    The slot is held until the worker thread itself finishes.
+    
+    work runs in a copy of the caller's context, so its output reaches
+    the caller's OutputCapture and no other.
+    
+    With output_dir, the call first waits for any other conversion writing
+    to that directory (see utils.output_locks), and holds the directory
+    the same way as the slot: until the worker thread finishes.
+    """
+    from utils.cancellation import ConversionTimeout, TIMEOUT_GRACE_SECONDS
+    
+    locks = output_locks()
+    output_key = None
+    if output_dir is not None:
+        if locks.locked(output_dir):
+            logger.info(f"Another conversion is writing to {output_dir}; waiting for it to finish")
+        output_key = await locks.acquire(output_dir)
+    
+    def release_output():
+        if output_key is not None:
+            locks.release(output_key)
+    
+    limit = worker_limit()
+    if limit.active >= limit.limit:
+        logger.info(f"{limit.active} tool calls already running (MAX_CONCURRENCY {limit.limit}); waiting for one to finish")
+    try:
+        await limit.acquire()
+    except BaseException:
+        release_output()
+        raise
+    loop = asyncio.get_running_loop()
+    cancel_event = threading.Event()
+    try:
+        future = loop.run_in_executor(None, contextvars.copy_context().run, work, cancel_event)
+    except BaseException:
+        limit.release()
+        release_output()
+        raise
+    future.add_done_callback(lambda _: (limit.release(), release_output()))
+    try:
+        return await asyncio.wait_for(asyncio.shield(future), timeout)
+    except asyncio.TimeoutError:
+        cancel_event.set()
+        logger.warning(f"Tool call timed out after {timeout:g}s; stopping the worker")
+        with anyio.CancelScope(shield=True):
+            done, _ = await asyncio.wait({future}, timeout=TIMEOUT_GRACE_SECONDS)
+            if not done:
+                logger.warning("Timed-out worker is still inside a library call; waiting for it to stop at its next page")
+                await asyncio.wait({future})
+        future.exception()  # Retrieved: the worker's own ConversionCancelled is expected
+        raise ConversionTimeout(
+            f"Timed out after {timeout:g}s (stopped, partial output removed). The PDF may be corrupt or too large: "
+            f"raise timeout_seconds or CONVERSION_TIMEOUT, or convert part of it with page_range")
+    except asyncio.CancelledError:
+        cancel_event.set()
+        logger.info("Tool call cancelled; waiting for the worker to stop")
+        with anyio.CancelScope(shield=True):
+            try:
+                await future
+            except Exception as e:
+                logger.warning(f"Cancelled worker ended with an error: {e}")
+        raise
+
+def pdf_convert_options(args: Dict[str, Any]) -> Dict[str, Any]:
+    """Converter options from convert_pdf / convert_pdf_inline arguments"""
+    return {
+        "split_by_chapters": args.get("split_by_chapters", True),
+        "preserve_tables": args.get("preserve_tables", True), 
+        "extract_images": args.get("extract_images", True),
+        "generate_summaries": args.get("generate_summaries", True),
+        "generate_concept_map": args.get("generate_concept_map", True),
+        "resolve_cross_references": args.get("resolve_cross_references", True),
+        "structured_tables": args.get("structured_tables", True),
+        "chunk_size_optimization": args.get("chunk_size_optimization", True),
+        "output_layout": args.get("output_layout"),
+        "section_naming": args.get("section_naming", "numbered_slug"),
+        "corpus_index_path": args.get("corpus_index_path"),
+        "extract_keywords": args.get("extract_keywords", False),
+        "on_conflict": args.get("on_conflict", "error"),
+        "clean_output": args.get("clean_output", False),
+        "extract_signatures": args.get("extract_signatures", False),
+        "extract_form_fields": args.get("extract_form_fields", False),
+        "use_document_captions": args.get("use_document_captions", True),
+        "image_alt_mode": args.get("image_alt_mode", "caption"),
+        "section_links": args.get("section_links", True),
+        "frontmatter": args.get("frontmatter", True),
+        "order_by": args.get("order_by", "appearance"),
+        "wrap_width": args.get("wrap_width", 0),
+        "normalize_headings": args.get("normalize_headings", True),
+        "heading_offset": args.get("heading_offset", 0),
+        "render_full_pages": args.get("render_full_pages", False),
+        "drop_empty_sections": args.get("drop_empty_sections", True),
+        "preserve_line_numbers": args.get("preserve_line_numbers", False),
+        "page_range": args.get("page_range"),
+        "sample_pages": args.get("sample_pages"),
+        "sample_seed": args.get("sample_seed", 0),
+        "reflow_paragraphs": args.get("reflow_paragraphs", False),
+        "column_layout": args.get("column_layout", "auto"),
+        "reading_order": args.get("reading_order", "auto"),
+        "page_markers": args.get("page_markers", "comment"),
+        "page_marker_labels": args.get("page_marker_labels", False),
+        "strip_headers_footers": args.get("strip_headers_footers", False),
+        "streaming": args.get("streaming", False),
+        "image_variants": args.get("image_variants", "highest_resolution"),
+        "image_dedup": args.get("image_dedup", True),
+        "image_format": args.get("image_format", "original"),
+        "image_quality": args.get("image_quality", 85),
+        "preserve_links": args.get("preserve_links", True),
+        "detect_code_blocks": args.get("detect_code_blocks", True),
+        "detect_lists": args.get("detect_lists", True),
+        "preserve_text_decorations": args.get("preserve_text_decorations", False),
+        "underline_marker": args.get("underline_marker", "<u>"),
+        "math_mode": args.get("math_mode", "off"),
+        "extract_api_endpoints": args.get("extract_api_endpoints", True),
+        "max_output_bytes": args.get("max_output_bytes"),
+        "max_images_bytes": args.get("max_images_bytes"),
+        "max_image_bytes": args.get("max_image_bytes"),
+        "export_tables_csv": args.get("export_tables_csv", False),
+        "min_table_rows": args.get("min_table_rows"),
+        "min_table_cols": args.get("min_table_cols"),
+        "table_min_confidence": args.get("table_min_confidence"),
+        "min_header_confidence": args.get("min_header_confidence"),
+        "output_mode": args.get("output_mode", "standard"),
+        "output_format": args.get("output_format", "markdown"),
+        "chunk_tokens": args.get("chunk_tokens"),
+        "chunk_overlap": args.get("chunk_overlap", 0),
+        "chunks_jsonl": args.get("chunks_jsonl", False),
+        "vector_db_format": args.get("vector_db_format"),
+        "chunk_layout": args.get("chunk_layout", "by_size"),
+        "tokenizer": args.get("tokenizer"),
+        "password": args.get("password"),
+    }
+
+def validate_pdf_convert_options(options: Dict[str, Any]) -> None:
+    """Reject bad converter options before any work starts (raises ValueError)"""
+    from utils.markup_formats import OUTPUT_FORMATS
+    from utils.page_range import page_range_spans
+    from processors.chunking_engine import validate_chunk_budget, validate_chunk_layout
+    from processors.rag_export import validate_vector_db_format
+    from utils.token_counter import TOKENIZERS
+    from processors.header_detection import validate_header_confidence
+    from utils.heading_levels import validate_heading_offset
+    from utils.output_limits import LIMIT_DEFAULTS, resolve_limit
+    from processors.image_format import validate_image_format
+    from processors.columns import validate_column_layout
+    from processors.reading_order import validate_reading_order
+    from processors.page_markers import validate_page_markers
+    from utils.section_naming import validate_section_naming
+    from processors.alt_text import validate_alt_mode
+    from processors.equations import validate_math_mode
+    from processors.streaming import validate_streaming_options
+    from processors.table_processor import TableProcessor
+    
+    if options["output_format"] not in OUTPUT_FORMATS:
+        raise ValueError(f"Unknown output_format '{options['output_format']}' "
+                         f"(expected one of: {', '.join(OUTPUT_FORMATS)})")
+    if options["page_range"]:
+        # Syntax now; pages past the end are rejected by the converter before extraction
+        page_range_spans(options["page_range"])
+    if options["chunk_tokens"]:
+        validate_chunk_budget(options["chunk_tokens"], options["chunk_overlap"])
+    elif options["chunk_overlap"]:
+        raise ValueError("chunk_overlap requires chunk_tokens")
+    if options["chunks_jsonl"] and not options["chunk_tokens"]:
+        raise ValueError("chunks_jsonl requires chunk_tokens")
+    if validate_vector_db_format(options["vector_db_format"]) and not options["chunks_jsonl"]:
+        raise ValueError("vector_db_format requires chunks_jsonl")
+    validate_chunk_layout(options["chunk_layout"])
+    if options["tokenizer"] and options["tokenizer"] not in TOKENIZERS:
+        raise ValueError(f"Unknown tokenizer '{options['tokenizer']}' (expected one of: {', '.join(TOKENIZERS)})")
+    if options["min_header_confidence"] is not None:
+        validate_header_confidence(options["min_header_confidence"])
+    validate_heading_offset(options["heading_offset"])
+    validate_image_format(options["image_format"], options["image_quality"])
+    validate_column_layout(options["column_layout"])
+    validate_reading_order(options["reading_order"])
+    validate_page_markers(options["page_markers"])
+    if options["page_marker_labels"] and options["page_markers"] != "anchor":
+        raise ValueError("page_marker_labels requires page_markers 'anchor'")
+    validate_section_naming(options["section_naming"])
+    TableProcessor.table_thresholds(options)
+    validate_alt_mode(options["image_alt_mode"])
+    validate_math_mode(options["math_mode"])
+    if options["streaming"]:
+        validate_streaming_options(options)
+    for limit in LIMIT_DEFAULTS:
+        resolve_limit(limit, options[limit])
+
+async def handle_convert_pdf(args: Dict[str, Any], source_files: Optional[List[Dict[str, Any]]] = None):
+    """Handle PDF to markdown conversion (source_files: page spans of the files a combined PDF was built from)"""
+    try:
+        from modular_pdf_converter import ModularPDFConverter, cached_conversion
+        from utils.file_utils import FileUtils
+        from utils.output_capture import OutputCapture
+        from utils.markup_formats import output_filename
+        from utils.cancellation import conversion_timeout
+        from utils.doc_resources import remember_output
+        
+        pdf_path = args["pdf_path"]
+        output_dir = args.get("output_dir", "./docs")
+        
+        if not Path(pdf_path).exists():
+            raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        
+        options = pdf_convert_options(args)
+        options["source_files"] = source_files
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+        validate_pdf_convert_options(options)
+        
+        if args.get("dry_run"):
+            return await handle_convert_pdf_dry_run(pdf_path, output_dir, options, timeout)
+        
+        if args.get("use_cache"):
+            # Hashing a large PDF takes a moment; keep the event loop free
+            cached = await asyncio.get_running_loop().run_in_executor(
+                None, cached_conversion, pdf_path, output_dir, options)
+            if cached:
+                logger.info(f"Cache hit, not reconverting: {pdf_path}")
+                message = f" ♻️ Cache Hit: {Path(pdf_path).name}\n"
+                message += f"📁 Location: {cached['output_directory']}\n"
+                message += f"📄 Files: {cached['file_count']:,} (unchanged)\n"
+                message += "The PDF and options match the conversion recorded in manifest.json; nothing was reconverted.\n"
+                return [
+                    TextContent(type="text", text=message),
+                    TextContent(type="text", text=json.dumps(cached['conversion_manifest'], indent=2, ensure_ascii=False))
+                ]
+        
+        logger.info(f"Converting PDF: {pdf_path} to {output_dir}")
+        
+        loop = asyncio.get_running_loop()
+        with OutputCapture(on_line=output_line_forwarder(loop)) as capture:
+            # In a worker thread so progress notifications go out while it runs
+            result = await run_cancellable(
+                lambda cancel_event: ModularPDFConverter(pdf_path, output_dir, options, cancel_event).convert(),
+                timeout, output_dir=output_dir)
+        
+        if result.get("success"):
+            remember_output(output_dir, result)
+            
+            # Get actual file count from generated_files
+            total_files = result.get('file_count', len(result.get('generated_files', [])))
+            
+            # Get the actual output path (resolved from the output layout)
+            actual_output_path = result.get('output_directory') or \
+                f"{output_dir}/{FileUtils.sanitize_folder_name(Path(pdf_path).name)}"
+            
+            # Lead with agent training - this is the critical action
+            message = f"🤖 **AGENT TRAINING REQUIRED**\n"
+            message += f"Visit: https://github.com/wadearnold/mcp-document-markdown/blob/main/AGENT_INSTRUCTIONS.md\n"
+            message += f"Replace [FOLDER_NAME] with: {actual_output_path}\n\n"
+            
+            message += f"✅ Conversion complete: {Path(pdf_path).name}\n"
+            message += f"📁 Location: {actual_output_path}\n" 
+            message += f"📄 Files: {total_files:,} generated\n"
+            message += f"⏱️ Time: {result.get('processing_time_seconds', 0):.1f}s\n\n"
+            
+            # LLM-optimized structure for agent use
+            message += f"**Agent Navigation Structure:**\n"
+            sections_path = result.get('sections_directory') or f"{actual_output_path}/sections"
+            message += f"• `{actual_output_path}/{output_filename('README.md', options['output_format'])}` - Document map\n"
+            message += f"• `{sections_path}/` - Content sections\n"
+            if result.get('front_matter_fields'):
+                message += f"  Front-matter: {', '.join(result['front_matter_fields'])}\n"
+            if 'keywords' in result.get('processing_stats', {}):
+                message += f"• `{actual_output_path}/keywords.json` - Emphasized terms index\n"
+            chunking = result.get('chunking')
+            if chunking:
+                message += f"• `{actual_output_path}/{chunking['manifest']}` - {chunking['total_chunks']} chunks of ≤{chunking['chunk_tokens']} tokens (overlap {chunking['chunk_overlap']}, {chunking['tokenizer']}, {chunking['layout']} layout)\n"
+                if chunking.get('jsonl'):
+                    message += f"• `{actual_output_path}/{chunking['jsonl']}` - {chunking['jsonl_chunks']} chunk records for a vector store (JSON Lines)\n"
+            streaming = result.get('streaming')
+            if streaming:
+                message += f"• `{actual_output_path}/{streaming['jsonl']}` - {streaming['sections']} section records, written as pages were extracted (JSON Lines)\n"
+                message += f"  Streaming: {streaming['skipped']}\n"
+            api_endpoints = result.get('api_endpoints')
+            if api_endpoints and api_endpoints.get('index'):
+                message += f"• `{actual_output_path}/{api_endpoints['index']}` - {api_endpoints['count']} API endpoints, one file each\n"
+            message += "\n"
+            
+            # Brief stats for agent context
+            stats = result.get('processing_stats', {})
+            if stats:
+                pdf_stats = stats.get('pdf_extraction', {})
+                if pdf_stats:
+                    pages = pdf_stats.get('pages', 0)
+                    sections = stats.get('sections', 0)
+                    message += f"Processed: {pages} pages → {sections} sections\n"
+                    if pdf_stats.get('column_pages'):
+                        message += f"Columns: {len(pdf_stats['column_pages'])} two-column page(s) read column by column\n"
+                    if pdf_stats.get('reordered_pages') or pdf_stats.get('rtl_pages'):
+                        message += f"Reading order: {len(pdf_stats.get('reordered_pages', []))} page(s) re-sorted, "
+                        message += f"{len(pdf_stats.get('rtl_pages', []))} read right to left\n"
+                    if pdf_stats.get('furniture_lines'):
+                        message += f"Headers/footers: {pdf_stats['furniture_lines']} repeated line(s) stripped\n"
+                image_dedup = stats.get('image_dedup')
+                if image_dedup:
+                    message += f"Images: {image_dedup['duplicates']} repeats linked to existing files ({image_dedup['files_written']} written)\n"
+                image_output = stats.get('image_output')
+                if image_output and image_output['format'] != 'original':
+                    message += f"Images: written as {image_output['format']}, {image_output['bytes']:,} bytes ({image_output['saved_bytes']:,} saved)\n"
+                tables_csv = stats.get('tables_csv')
+                if tables_csv:
+                    message += f"Tables: {tables_csv['exported']} exported as CSV to tables/\n"
+                links = stats.get('links')
+                if links and links['links']:
+                    message += f"Links: {links['links']} kept as markdown links ({links['internal_resolved']} to sections)\n"
+                if stats.get('code_blocks'):
+                    message += f"Code blocks: {stats['code_blocks']} monospaced samples fenced\n"
+                text_decorations = stats.get('text_decorations')
+                if text_decorations:
+                    message += f"Revision marks: {text_decorations['strikethrough']} struck-through and {text_decorations['underline']} underlined passage(s) marked; struck text is usually deleted\n"
+                equations = stats.get('equations')
+                if equations:
+                    low_confidence = f"; {equations['low_confidence']} low-confidence, marked ⚠️" if equations['low_confidence'] else ""
+                    message += f"Equations: {equations['count']} in $$ blocks, {equations['latex']} as LaTeX{low_confidence}\n"
+            
+            page_range = result.get('page_range')
+            if page_range:
+                message += f"Page range: {page_range['page_range']} ({len(page_range['pages'])} of {page_range['page_count']} pages)\n"
+            
+            sample = result.get('sample')
+            if sample:
+                message += f"⚠️ SAMPLE: {len(sample['pages'])} of {sample['page_count']} pages converted (seed {sample['seed']}), not the full document\n"
+            
+            pdf_stats = result.get('processing_stats', {}).get('pdf_extraction', {})
+            failed_pages = pdf_stats.get('failed_pages')
+            if failed_pages:
+                message += f"⚠️ PARTIAL: {len(failed_pages)} of {pdf_stats.get('pages', 0)} pages could not be extracted; their text is missing\n"
+            
+            signatures = result.get('signatures')
+            if signatures:
+                if signatures['signed']:
+                    signers = ", ".join(sig['signer'] or sig['field_name'] or 'unknown' for sig in signatures['signatures'] if sig['signed'])
+                    message += f"Signatures: {signatures['signature_count']} ({signers}), not verified\n"
+                else:
+                    message += "Signatures: none\n"
+            form_fields = result.get('form_fields')
+            if form_fields is not None:
+                if form_fields['fields']:
+                    message += f"Form fields: {form_fields['fields']} ({form_fields['filled']} filled in), listed in the Form Fields section\n"
+                else:
+                    message += "Form fields: none\n"
+            
+            warnings = result.get('warnings', [])
+            if warnings:
+                message += f"\n**Warnings:**\n"
+                for warning in warnings:
+                    message += f"• {warning}\n"
+            
+            message += f"\n⚠️ This content is optimized for AI agent consumption, not human reading."
+            
+            # Typed list of every file written, for agents (no parsing of the summary)
+            return [
+                TextContent(type="text", text=message),
+                TextContent(type="text", text=json.dumps(result['conversion_manifest'], indent=2, ensure_ascii=False))
+            ]
+        elif result.get("limit"):
+            # Size limit: the error names it; partial output was removed
+            return tool_error(f"📦 Conversion stopped: {result['error']}\n"
+                              f"Removed {len(result.get('removed_files', []))} partial files")
+        elif result.get("error_code"):
+            # Encrypted PDF: the reason is the whole story, no captured output
+            return tool_error(f"🔒 Conversion failed: {result['error']}")
+        else:
+            error = capture.format_error(result.get('error', 'Unknown error'), Path(output_dir))
+            error_msg = f"❌ Conversion failed: {error}"
+            return tool_error(error_msg)
+        
+    except Exception as e:
+        logger.error(f"Convert PDF failed: {e}")
+        raise
+
+async def handle_convert_pdf_rag(args: Dict[str, Any]):
+    """Handle conversion for retrieval: convert_pdf with chunk_tokens chunks and chunks.jsonl"""
+    convert_args = {
+        **(args.get("options") or {}),
+        "pdf_path": args["pdf_path"],
+        "output_dir": args.get("output_dir", "./docs"),
+        "chunk_tokens": args.get("chunk_tokens", 768),
+        "chunk_overlap": args.get("chunk_overlap", 128),
+        "timeout_seconds": args.get("timeout_seconds"),
+        "chunks_jsonl": True
+    }
+    # Only what was passed: otherwise options' tokenizer (or the converter's default) applies
+    for key in ("tokenizer", "vector_db_format"):
+        if args.get(key) is not None:
+            convert_args[key] = args[key]
+    return await handle_convert_pdf(convert_args)
+
+async def handle_reprocess(args: Dict[str, Any]):
+    """Handle re-chunking an existing conversion"""
+    try:
+        from processors.reprocess import reprocess_chunks
+        from utils.cancellation import conversion_timeout
+        
+        output_dir = args["output_dir"]
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+        
+        logger.info(f"Re-chunking conversion in {output_dir}")
+        
+        result = await run_cancellable(
+            lambda cancel_event: reprocess_chunks(output_dir, args["chunk_tokens"], args.get("chunk_overlap", 0),
+                                                  args.get("tokenizer"), args.get("chunks_jsonl"),
+                                                  args.get("document"), args.get("chunk_layout"), cancel_event),
+            timeout, output_dir=output_dir)
+        
+        message = f" ✂️ Re-chunked: {result['document_dir']}\n"
+        message += f"Sections: {result['sections']}\n"
+        message += f"Chunks: {result['total_chunks']} of ≤{result['chunk_tokens']} tokens ({result['tokenizer']}), "
+        message += f"overlap {result['chunk_overlap']}, {result['layout']} layout, replacing {result['removed']} old files\n"
+        message += f"Chunk manifest: {result['manifest']}"
+        if result.get('jsonl'):
+            message += f"\nJSON Lines: {result['jsonl']} ({result['jsonl_chunks']} records)"
+        if result['warnings']:
+            message += f"\n\n**Warnings:**\n"
+            for warning in result['warnings']:
+                message += f"• {warning}\n"
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(result, indent=2, ensure_ascii=False))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Re-chunking failed: {e}")
+        raise
+
+async def handle_package_output(args: Dict[str, Any]):
+    """Handle packaging a conversion as a ZIP archive"""
     try:
-        logger.info(f"Tool called: {name} with args: {arguments}")
+        from processors.output_package import package_output
+        from utils.cancellation import conversion_timeout
         
-        if name == "extract_pdf_content":
-            return await handle_extract_pdf_content(arguments)
-        elif name == "convert_pdf":
-            return await handle_convert_pdf(arguments)
-        elif name == "analyze_pdf_structure":
-            return await handle_analyze_pdf(arguments)  
-        elif name == "prepare_pdf_for_rag":
-            return await handle_prepare_rag(arguments)
-        elif name == "extract_docx_content":
-            return await handle_extract_docx_content(arguments)
-        elif name == "convert_docx":
-            return await handle_convert_docx(arguments)
-        elif name == "analyze_docx_structure":
-            return await handle_analyze_docx(arguments)
-        elif name == "prepare_docx_for_rag":
-            return await handle_prepare_docx_rag(arguments)
-        else:
-            raise ValueError(f"Unknown tool: {name}")
+        output_dir = args["output_dir"]
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+        
+        logger.info(f"Packaging conversion in {output_dir}")
+        
+        result = await run_cancellable(
+            lambda cancel_event: package_output(output_dir, args.get("document"), args.get("output_path"),
+                                                cancel_event),
+            timeout, output_dir=output_dir)
+        
+        message = f" 📦 Packaged: {result['document_dir']}\n"
+        message += f"Archive: {result['archive']}\n"
+        message += f"Files: {result['files']:,} ({result['bytes']:,} bytes), archive size {result['size']:,} bytes"
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(result, indent=2, ensure_ascii=False))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Packaging failed: {e}")
+        raise
+
+async def handle_convert_pdf_dry_run(pdf_path: str, output_dir: str, options: Dict[str, Any],
+                                     timeout: Optional[float]):
+    """Handle convert_pdf with dry_run: report the conversion plan, write nothing to output_dir"""
+    try:
+        import tempfile
+        from modular_pdf_converter import plan_conversion
+        from utils.output_capture import OutputCapture
+        
+        logger.info(f"Planning PDF conversion (dry run): {pdf_path}")
+        
+        loop = asyncio.get_running_loop()
+        with OutputCapture(on_line=output_line_forwarder(loop)) as capture:
+            plan = await run_cancellable(
+                lambda cancel_event: plan_conversion(pdf_path, output_dir, options, cancel_event), timeout)
+        
+        if plan.get("error_code"):
+            return tool_error(f"🔒 Dry run failed: {plan['error']}")
+        if not plan.get("success"):
+            # The error log goes to the temp directory; output_dir stays untouched
+            error = capture.format_error(plan.get('error', 'Unknown error'), Path(tempfile.gettempdir()))
+            return tool_error(f"❌ Dry run failed: {error}")
+        
+        message = f" 🔍 Dry Run: {Path(pdf_path).name}\n"
+        message += (f"Would write about {plan['file_count']:,} files, {plan['total_bytes']:,} bytes "
+                    f"to {plan['output_directory']}")
+        message += " (exists)\n" if plan['output_exists'] else "\n"
+        message += f"Pages: {plan['pages']} → {plan['sections']} sections, {plan['images']} images\n"
+        message += "Chunks per size: " + ", ".join(f"{name} {count}" for name, count in plan['chunks'].items()) + "\n"
+        if plan.get('chunking'):
+            message += f"Chunks of ≤{plan['chunking']['chunk_tokens']} tokens: {plan['chunking']['total_chunks']}\n"
+        
+        if plan['warnings']:
+            message += f"\n**Warnings:**\n"
+            for warning in plan['warnings']:
+                message += f"• {warning}\n"
+        
+        message += (f"\nEstimated from the page tree, bookmarks, and the text of {plan['text_pages_read']} pages; "
+                    f"nothing was written. Run again without dry_run to convert.")
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(plan, indent=2, ensure_ascii=False))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Dry run failed: {e}")
+        raise
+
+async def handle_convert_pdf_set(args: Dict[str, Any]):
+    """Handle conversion of several PDFs combined into one document"""
+    try:
+        from processors.pdf_merge import merge_pdfs
+        from utils.temp_files import TEMP_FILES
+        from utils.file_utils import FileUtils
+        from utils.cancellation import conversion_timeout
+        
+        pdf_paths = args["pdf_paths"]
+        if not isinstance(pdf_paths, list) or not pdf_paths:
+            raise ValueError("pdf_paths must be a non-empty list of PDF files")
+        for pdf_path in pdf_paths:
+            if not Path(pdf_path).exists():
+                raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        
+        document_name = args.get("document_name") or Path(pdf_paths[0]).stem
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+        
+        logger.info(f"Converting PDF set: {len(pdf_paths)} files as {document_name}")
+        
+        # The combined PDF only exists for the conversion; its name becomes the output folder name
+        with TEMP_FILES.directory(prefix="pdf-set-") as temp_dir:
+            merged_path = str(Path(temp_dir) / f"{FileUtils.safe_filename(document_name)}.pdf")
+            source_files = await run_cancellable(
+                lambda cancel_event: merge_pdfs(pdf_paths, merged_path, cancel_event), timeout)
+            contents = await handle_convert_pdf({
+                **(args.get("options") or {}),
+                "pdf_path": merged_path,
+                "output_dir": args.get("output_dir", "./docs"),
+                "timeout_seconds": args.get("timeout_seconds")
+            }, source_files)
+        if isinstance(contents, CallToolResult):
+            return contents
+        
+        message = f" 📚 PDF Set: {len(source_files)} files, {source_files[-1]['page_end']} pages\n"
+        for part in source_files:
+            message += f"• {part['file']}: pages {part['page_start']}-{part['page_end']}\n"
+        contents[0] = TextContent(type="text", text=message + "\n" + contents[0].text)
+        return contents
+        
+    except Exception as e:
+        logger.error(f"Convert PDF set failed: {e}")
+        raise
+
+async def convert_pdf_in_subprocess(pdf_path: str, output_dir: str, options: Dict[str, Any],
+                                    timeout: Optional[float] = None) -> Dict[str, Any]:
+    """
+    Convert one PDF in a separate converter process and return its results
+    
+    The process is killed on timeout or when the tool call is cancelled; a
+    killed conversion may leave its .staging-* folder in output_dir, but the
+    previous output is untouched. A start
+    that fails for lack of resources is retried (ConverterStartError once it
+    gives up); a conversion that ran and failed raises ConverterFailed.
+    """
+    from utils.batch_conversion import (
+        ConverterFailed, converter_command, converter_input, parse_converter_output, start_converter
+    )
+    from utils.output_capture import truncate_middle
+    
+    command = converter_command(pdf_path, output_dir)
+    process = await start_converter(
+        lambda: asyncio.create_subprocess_exec(*command, stdin=asyncio.subprocess.PIPE,
+                                               stdout=asyncio.subprocess.PIPE, stderr=asyncio.subprocess.PIPE),
+        on_retry=lambda e, delay: logger.warning(f"Could not start the converter for {pdf_path} ({e}); "
+                                                 f"retrying in {delay:g}s"))
+    try:
+        stdout, stderr = await asyncio.wait_for(process.communicate(converter_input(options)), timeout)
+    except asyncio.TimeoutError:
+        process.kill()
+        await process.wait()
+        raise TimeoutError(f"Timed out after {timeout:g}s; raise timeout_seconds or convert it on its own")
+    except asyncio.CancelledError:
+        process.kill()
+        with anyio.CancelScope(shield=True):
+            await process.wait()
+        raise
+    
+    output = stdout.decode('utf-8', errors='replace')
+    try:
+        return parse_converter_output(output)
+    except ValueError as e:
+        detail = stderr.decode('utf-8', errors='replace').strip() or output.strip()
+        raise ConverterFailed(f"{e} (exit code {process.returncode}): {truncate_middle(detail, 2048)}")
+
+async def handle_convert_pdf_batch(args: Dict[str, Any]):
+    """Handle conversion of many independent PDFs, several at a time"""
+    try:
+        from utils.batch_conversion import (
+            DEFAULT_BATCH_CONCURRENCY, batch_file_result, batch_output_dirs, resolve_batch_pdfs, run_batch,
+            summarize_batch, validate_concurrency
+        )
+        from utils.cancellation import conversion_timeout
+        from utils.doc_resources import remember_output
+        from utils.environment import converter_interpreter
+        
+        # One clear error up front instead of the same one for every file
+        converter_interpreter()
+        pdf_paths = resolve_batch_pdfs(args.get("pdf_paths"), args.get("directory"), args.get("pattern", "*.pdf"))
+        output_dir = args.get("output_dir", "./docs")
+        concurrency = validate_concurrency(args.get("concurrency", DEFAULT_BATCH_CONCURRENCY))
+        output_dirs = batch_output_dirs(pdf_paths, output_dir, args.get("directory"))
+        
+        options = pdf_convert_options(args.get("options") or {})
+        validate_pdf_convert_options(options)
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+        
+        logger.info(f"Converting PDF batch: {len(pdf_paths)} files, {concurrency} at a time, to {output_dir}")
+        
+        async def convert_one(pdf_path: str) -> Dict[str, Any]:
+            results = await convert_pdf_in_subprocess(pdf_path, output_dirs[pdf_path], options, timeout)
+            entry = batch_file_result(pdf_path, results)
+            if entry['success']:
+                remember_output(output_dirs[pdf_path], results)
+            logger.info(f"Batch: {'converted' if entry['success'] else 'failed'} {pdf_path}")
+            return entry
+        
+        # The batch's own files run in parallel; other conversions to output_dir wait for the batch
+        if output_locks().locked(output_dir):
+            logger.info(f"Another conversion is writing to {output_dir}; waiting for it to finish")
+        async with output_locks().hold(output_dir):
+            entries = await run_batch(pdf_paths, convert_one, concurrency, process_limit())
+        totals = summarize_batch(entries)
+        
+        message = f" 📦 PDF Batch: {totals['succeeded']} of {totals['total']} converted"
+        message += f", {totals['failed']} failed\n" if totals['failed'] else "\n"
+        message += f"📁 Location: {output_dir}\n"
+        for entry in entries:
+            if entry['success']:
+                message += f"✅ {Path(entry['pdf_path']).name}: {entry['manifest']['file_count'] or 0:,} files in {entry['output_directory']}\n"
+            else:
+                message += f"❌ {Path(entry['pdf_path']).name}: {entry['error']}\n"
+        
+        result = [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps({**totals, 'results': entries}, indent=2, ensure_ascii=False))
+        ]
+        if not totals['succeeded']:
+            return CallToolResult(content=result, isError=True)
+        return result
+        
+    except Exception as e:
+        logger.error(f"Convert PDF batch failed: {e}")
+        raise
+
+async def handle_convert_pdf_inline(args: Dict[str, Any]):
+    """Handle PDF conversion returned as one self-contained JSON document"""
+    try:
+        from modular_pdf_converter import ModularPDFConverter
+        from utils.temp_files import TEMP_FILES
+        from utils.inline_bundle import build_inline_bundle, DEFAULT_MAX_INLINE_BYTES, DEFAULT_CHUNK_TOKENS
+        from utils.output_capture import OutputCapture
+        from utils.cancellation import conversion_timeout
+        
+        pdf_path = args["pdf_path"]
+        max_inline_bytes = args.get("max_inline_bytes", DEFAULT_MAX_INLINE_BYTES)
+        chunk_tokens = args.get("chunk_tokens", DEFAULT_CHUNK_TOKENS)
+        include_images = args.get("include_images", True)
+        
+        if not Path(pdf_path).exists():
+            raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        
+        options = pdf_convert_options(args.get("options") or {})
+        options["extract_images"] = options["extract_images"] and include_images
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+        # The bundle is built from the markdown files and their front-matter
+        options["output_format"] = "markdown"
+        options["frontmatter"] = True
+        validate_pdf_convert_options(options)
+        
+        logger.info(f"Converting PDF inline: {pdf_path}")
+        
+        # Nothing is left on disk: the conversion lives in a temporary directory
+        with TEMP_FILES.directory(prefix="pdf-inline-") as temp_dir:
+            loop = asyncio.get_running_loop()
+            with OutputCapture(on_line=output_line_forwarder(loop)) as capture:
+                result = await run_cancellable(
+                    lambda cancel_event: ModularPDFConverter(pdf_path, temp_dir, options, cancel_event).convert(),
+                    timeout)
+            
+            if not result.get("success"):
+                error = capture.format_error(result.get('error', 'Unknown error'), Path(temp_dir))
+                return tool_error(f"❌ Conversion failed: {error}")
+            
+            bundle = build_inline_bundle(Path(result['output_directory']), max_inline_bytes,
+                                         chunk_tokens, include_images, result.get('warnings', []))
+        
+        message = f" 📦 Inline Conversion: {Path(pdf_path).name}\n"
+        message += f"Sections: {len(bundle['sections'])}, chunks: {len(bundle['chunks'])}, images: {len(bundle['images'])}\n"
+        message += f"Size: {bundle['size_bytes']:,} of {bundle['max_bytes']:,} bytes\n"
+        if bundle.get('sample'):
+            sample = bundle['sample']
+            message += f"⚠️ SAMPLE: {len(sample['pages'])} of {sample['page_count']} pages converted (seed {sample['seed']}), not the full document\n"
+        # Counts only: the lists are in the bundle, whose size is capped
+        if bundle['notices'] or bundle['warnings']:
+            message += f"Notices: {len(bundle['notices'])}, warnings: {len(bundle['warnings'])} (listed in the JSON)\n"
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(bundle, ensure_ascii=False))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Inline PDF conversion failed: {e}")
+        raise
+
+async def handle_validate_pdf(args: Dict[str, Any]):
+    """Handle the pre-flight check"""
+    try:
+        from utils.cancellation import conversion_timeout
+        from utils.preflight import validate_pdf
+        
+        pdf_path = args["pdf_path"]
+        
+        if not Path(pdf_path).exists():
+            raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+        
+        logger.info(f"Validating PDF: {pdf_path}")
+        
+        result = await run_cancellable(
+            lambda cancel_event: validate_pdf(pdf_path, args.get("password"), cancel_event), timeout)
+        
+        message = f" ✅ PDF Check: {Path(pdf_path).name}\n" if result['openable'] else f" ❌ PDF Check: {Path(pdf_path).name}\n"
+        if result['error']:
+            message += f"Problem: {result['error']}\n"
+        if result['pdf_version']:
+            message += f"PDF version: {result['pdf_version']}\n"
+        message += f"Size: {result['file_size_bytes'] / (1024 * 1024):.2f} MB\n"
+        if result['page_count'] is not None:
+            message += f"Pages: {result['page_count']}\n"
+        message += f"Encrypted: {result['encrypted']}"
+        if result['text_layer']:
+            layer = result['text_layer']
+            message += f"\nText layer: {layer['pages_with_text']} of {layer['sampled_pages']} sampled pages"
+            message += " (looks scanned)" if result['scanned'] else ""
+        if result['estimated_time']:
+            message += f"\nEstimated conversion time: {result['estimated_time'].replace('_', ' ')}"
+        if result['warnings']:
+            message += f"\n\n**Warnings:**\n"
+            for warning in result['warnings']:
+                message += f"• {warning}\n"
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(result, indent=2, ensure_ascii=False))
+        ]
+        
+    except Exception as e:
+        logger.error(f"PDF validation failed: {e}")
+        raise
+
+async def handle_analyze_pdf(args: Dict[str, Any]):
+    """Handle PDF structure analysis"""
+    try:
+        from pdf_analyzer import analyze_pdf, format_chapter_listing, format_page_list, validate_chapter_limit
+        from utils.analysis_output import AnalysisResult
+        from utils.cancellation import conversion_timeout
+        
+        pdf_path = args["pdf_path"]
+        chapter_limit = validate_chapter_limit(args.get("chapter_limit"))
+        output_format = args.get("output_format", "text")
+        
+        if not Path(pdf_path).exists():
+            raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        if output_format not in ("text", "ndjson"):
+            raise ValueError(f"Unknown output_format: {output_format}")
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+            
+        logger.info(f"Analyzing PDF structure: {pdf_path}")
+        
+        if output_format == "ndjson":
+            return await analyze_pdf_ndjson(pdf_path, args.get("output_path"), timeout, args.get("password"))
+        
+        analysis = await run_cancellable(
+            lambda cancel_event: analyze_pdf(pdf_path, cancel_event, args.get("password")), timeout)
+        # Fixed field set and types for clients reading the JSON content
+        analysis = AnalysisResult.from_dict(analysis).to_dict()
+        
+        # Get file size
+        file_size_mb = Path(pdf_path).stat().st_size / (1024 * 1024)
+        
+        message = f" 📊 PDF Analysis: {Path(pdf_path).name}\n"
+        message += f"Pages: {analysis.get('pages', 'unknown')}\n"
+        message += f"Size: {file_size_mb:.2f} MB\n"
+        message += f"Has TOC: {analysis.get('has_toc', False)}\n"
+        message += f"Tables: {analysis.get('table_count', 0)}\n"
+        message += f"Images: {analysis.get('image_count', 0)}"
+        if analysis['suspect_pages']:
+            message += f"\nPages likely needing OCR (weak text layer): {format_page_list(analysis['suspect_pages'])}"
+        
+        xmp = analysis.get('xmp') or {}
+        dublin_core = xmp.get('dublin_core', {})
+        if dublin_core.get('subject'):
+            subject = dublin_core['subject']
+            message += f"\nKeywords: {', '.join(subject) if isinstance(subject, list) else subject}"
+        if dublin_core.get('rights'):
+            message += f"\nRights: {dublin_core['rights']}"
+        if xmp.get('custom'):
+            message += f"\nCustom XMP fields: {len(xmp['custom'])}"
+        
+        if analysis.get('chapters'):
+            message += "\n\n" + format_chapter_listing(analysis['chapters'], chapter_limit)
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(analysis, indent=2, default=str))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Analyze PDF failed: {e}")
+        raise
+
+async def handle_extract_outline(args: Dict[str, Any]):
+    """Handle outline-only extraction"""
+    try:
+        from pdf_analyzer import extract_outline
+        from utils.cancellation import conversion_timeout
+        
+        pdf_path = args["pdf_path"]
+        
+        if not Path(pdf_path).exists():
+            raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+        
+        logger.info(f"Extracting outline: {pdf_path}")
+        
+        result = await run_cancellable(
+            lambda cancel_event: extract_outline(pdf_path, cancel_event, args.get("password")), timeout)
+        
+        message = f" 🗂️ PDF Outline: {Path(pdf_path).name}\n"
+        message += f"Pages: {result['pages']}\n"
+        message += f"Entries: {result['entry_count']} ({'synthesized from heading lines' if result['synthesized'] else 'embedded bookmarks'})\n"
+        message += f"Top-level entries: {len(result['outline'])}"
+        if result['synthesized']:
+            message += f"\n\n**Warnings:**\n"
+            message += "• The PDF has no bookmarks; the outline was synthesized from numbered and Chapter/Part/Appendix heading lines and may be incomplete\n"
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(result, indent=2, ensure_ascii=False))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Outline extraction failed: {e}")
+        raise
+
+def write_analysis_ndjson(pdf_path: str, stream, cancel_event: Optional[threading.Event] = None,
+                          password: Optional[str] = None) -> Dict[str, int]:
+    """Write analysis records to a text stream, one JSON object per line; returns counts by record type"""
+    from pdf_analyzer import iter_analysis_records
+    
+    counts: Dict[str, int] = {}
+    for record in iter_analysis_records(pdf_path, cancel_event, password):
+        stream.write(json.dumps(record, default=str) + "\n")
+        counts[record['type']] = counts.get(record['type'], 0) + 1
+    return counts
+
+async def analyze_pdf_ndjson(pdf_path: str, output_path: Optional[str], timeout: Optional[float] = None,
+                             password: Optional[str] = None):
+    """Stream the analysis as NDJSON to a file, or return it inline"""
+    import io
+    
+    from utils.cancellation import ConversionCancelled
+    from utils.pdf_password import PDFPasswordError
+    
+    if output_path:
+        out = Path(output_path)
+        out.parent.mkdir(parents=True, exist_ok=True)
+        
+        def write_file(cancel_event):
+            try:
+                with open(out, 'w', encoding='utf-8') as f:
+                    return write_analysis_ndjson(pdf_path, f, cancel_event, password)
+            except (ConversionCancelled, PDFPasswordError):
+                out.unlink()  # Don't leave a partial NDJSON file behind
+                raise
+        
+        counts = await run_cancellable(write_file, timeout)
+        message = f" 📊 PDF Analysis (NDJSON): {Path(pdf_path).name}\n"
+        message += f"Records: {sum(counts.values())} written to {out}\n"
+        message += f"Chapters: {counts.get('chapter', 0)}"
+        return [TextContent(type="text", text=message)]
+    
+    buffer = io.StringIO()
+    await run_cancellable(lambda cancel_event: write_analysis_ndjson(pdf_path, buffer, cancel_event, password), timeout)
+    return [TextContent(type="text", text=buffer.getvalue())]
+
+async def handle_extract_tables_schema(args: Dict[str, Any]):
+    """Handle table extraction into a relational schema"""
+    try:
+        from processors.pdf_extractor import extract_tables_with_pdfplumber
+        from processors.table_processor import TableProcessor
+        from utils.file_utils import FileUtils
+        from utils.page_range import page_range_spans
+        
+        pdf_path = args["pdf_path"]
+        output_dir = args.get("output_dir")
+        min_rows, min_cols, min_confidence = TableProcessor.table_thresholds(args)
+        
+        if not Path(pdf_path).exists():
+            raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        # Syntax now; the pages are expanded against the page count once the PDF is open
+        page_range = args.get("page_range")
+        if page_range:
+            page_range_spans(page_range)
             
+        logger.info(f"Extracting table schemas: {pdf_path}")
+        
+        candidates, discarded = TableProcessor.filter_table_candidates(
+            extract_tables_with_pdfplumber(pdf_path, page_range=page_range), min_rows, min_cols, min_confidence)
+        
+        tables = []
+        for table_info in candidates:
+            structured = TableProcessor.build_relational_schema(table_info)
+            if structured:
+                tables.append(structured)
+        
+        document = {
+            'source_file': Path(pdf_path).name,
+            'table_count': len(tables),
+            'tables': tables,
+            'discarded_tables': discarded
+        }
+        
+        message = f" 🗃️ Table Schemas: {Path(pdf_path).name}\n"
+        message += f"Tables: {len(tables)}\n"
+        for table in tables:
+            types = ", ".join(f"{c['name']}:{c['type']}" for c in table['columns'])
+            message += f"- {table['table_id']} ({table['row_count']} rows): {types}\n"
+        
+        if discarded:
+            message += f"\n**Warnings:**\n"
+            for candidate in discarded:
+                message += f"• Discarded candidate table on page {candidate['page']} (#{candidate['index'] + 1}): {candidate['reason']}\n"
+        
+        if output_dir:
+            schema_file = Path(output_dir) / "tables_schema.json"
+            FileUtils.ensure_directory(schema_file.parent)
+            FileUtils.write_json(document, schema_file)
+            message += f"Saved: {schema_file}\n"
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(document, indent=2, ensure_ascii=False))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Table schema extraction failed: {e}")
+        raise
+
+def load_prior_fingerprint(value: Any) -> Dict[str, Any]:
+    """Accept a fingerprint object, JSON text, or a path to a manifest/fingerprint file"""
+    if isinstance(value, str):
+        candidate = Path(value)
+        if candidate.suffix == '.json' and candidate.exists():
+            value = json.loads(candidate.read_text(encoding='utf-8'))
+        else:
+            try:
+                value = json.loads(value)
+            except ValueError:
+                raise ValueError("fingerprint must be an object, JSON text, or a path to a JSON file")
+    
+    if not isinstance(value, dict):
+        raise ValueError("fingerprint must be a JSON object")
+    
+    # A manifest carries the fingerprint under its own key
+    if 'page_hashes' not in value and isinstance(value.get('fingerprint'), dict):
+        value = value['fingerprint']
+    if 'page_hashes' not in value:
+        raise ValueError("fingerprint is missing page_hashes")
+    return value
+
+async def handle_compare_fingerprint(args: Dict[str, Any]):
+    """Handle fingerprint comparison for change detection"""
+    try:
+        from utils.fingerprint import compute_fingerprint, compare_fingerprints
+        
+        pdf_path = args["pdf_path"]
+        
+        if not Path(pdf_path).exists():
+            raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        
+        prior = load_prior_fingerprint(args["fingerprint"])
+        
+        logger.info(f"Comparing fingerprint: {pdf_path}")
+        
+        current = compute_fingerprint(pdf_path)
+        comparison = compare_fingerprints(prior, current)
+        
+        message = f" 🔍 Fingerprint Comparison: {Path(pdf_path).name}\n"
+        if not comparison['changed']:
+            message += "No page text changed"
+            message += " (file is byte-identical)\n" if comparison['identical_file'] else " (file bytes differ)\n"
+        else:
+            message += f"Pages: {comparison['prior_page_count']} → {comparison['current_page_count']}\n"
+            message += f"Changed pages: {comparison['changed_pages'] or 'none'}\n"
+            if comparison['added_pages']:
+                message += f"Added pages: {comparison['added_pages']}\n"
+            if comparison['removed_pages']:
+                message += f"Removed pages: {comparison['removed_pages']}\n"
+            message += f"Unchanged pages: {comparison['unchanged_page_count']}\n"
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps({'comparison': comparison, 'fingerprint': current}, indent=2))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Fingerprint comparison failed: {e}")
+        raise
+
+async def handle_compare_pdfs(args: Dict[str, Any]):
+    """Handle structural comparison of two PDFs"""
+    try:
+        from pdf_analyzer import analyze_structure
+        from utils.cancellation import conversion_timeout
+        from utils.structure_diff import diff_structures, format_structure_diff
+        
+        pdf_path_a = args["pdf_path_a"]
+        pdf_path_b = args["pdf_path_b"]
+        
+        for pdf_path in (pdf_path_a, pdf_path_b):
+            if not Path(pdf_path).exists():
+                raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+        
+        logger.info(f"Comparing PDF structure: {pdf_path_a} → {pdf_path_b}")
+        
+        analyses = []
+        for pdf_path in (pdf_path_a, pdf_path_b):
+            analysis = await run_cancellable(lambda cancel_event, path=pdf_path: analyze_structure(path, cancel_event), timeout)
+            analyses.append(analysis)
+        diff = diff_structures(*analyses)
+        
+        name_a, name_b = Path(pdf_path_a).name, Path(pdf_path_b).name
+        report = format_structure_diff(diff, name_a, name_b)
+        synthesized = [name for name, analysis in zip((name_a, name_b), analyses) if analysis['synthesized_outline']]
+        if synthesized:
+            report += f"\n⚠️ No bookmarks in {', '.join(synthesized)}: sections were taken from numbered and Chapter/Part/Appendix heading lines and may be incomplete\n"
+        
+        result = {
+            'pdf_a': str(pdf_path_a),
+            'pdf_b': str(pdf_path_b),
+            'synthesized_outline': {'a': analyses[0]['synthesized_outline'], 'b': analyses[1]['synthesized_outline']},
+            **diff
+        }
+        return [
+            TextContent(type="text", text=report),
+            TextContent(type="text", text=json.dumps(result, indent=2, ensure_ascii=False))
+        ]
+        
     except Exception as e:
-        logger.error(f"Tool execution failed: {e}")
-        return [TextContent(type="text", text=f"Error: {str(e)}")]
+        logger.error(f"PDF comparison failed: {e}")
+        raise
 
-async def handle_extract_pdf_content(args: Dict[str, Any]):
-    """Handle generic PDF content extraction"""
+async def handle_get_thumbnail(args: Dict[str, Any]):
+    """Handle page thumbnail rendering"""
     try:
-        from processors.pdf_extractor import extract_pdf
+        import base64
+        from utils.thumbnail import render_thumbnail, DEFAULT_MAX_DIMENSION
         
         pdf_path = args["pdf_path"]
-        config = args.get("config", {})
+        page = args.get("page", 1)
+        max_dimension = args.get("max_dimension", DEFAULT_MAX_DIMENSION)
         
         if not Path(pdf_path).exists():
             raise FileNotFoundError(f"PDF file not found: {pdf_path}")
         
-        logger.info(f"Extracting content from PDF: {pdf_path}")
+        logger.info(f"Rendering thumbnail: {pdf_path} page {page}")
         
-        # Extract using generic approach
-        results = extract_pdf(pdf_path, config)
+        png, info = render_thumbnail(pdf_path, page, max_dimension)
         
-        # Format output for MCP response
-        output = []
-        output.append(f"# PDF Content Extraction Results\n")
-        output.append(f"**File:** {pdf_path}")
-        output.append(f"**Document Type:** {results['metadata']['document_type']}")
-        output.append(f"**Total Fields:** {results['metadata']['total_fields']}")
-        output.append(f"**Total Lines:** {results['summary']['total_lines']}")
-        output.append(f"**Has Tables:** {results['metadata']['has_tables']}")
-        output.append(f"**Has Lists:** {results['metadata']['has_lists']}")
+        message = f" 🖼️ Thumbnail: {Path(pdf_path).name}\n"
+        message += f"Page: {info['page']} of {info['page_count']}\n"
+        message += f"Size: {info['width']}×{info['height']} px"
+        if info['cached']:
+            message += " (cached)"
         
-        # Field type distribution
-        output.append(f"\n## Field Type Distribution")
-        for field_type, count in results['summary']['field_types'].items():
-            output.append(f"- **{field_type}:** {count}")
+        return [
+            ImageContent(type="image", data=base64.b64encode(png).decode('ascii'), mimeType="image/png"),
+            TextContent(type="text", text=message)
+        ]
         
-        # Show extracted fields
-        output.append(f"\n## Extracted Fields ({len(results['fields'])} total)")
+    except Exception as e:
+        logger.error(f"Thumbnail rendering failed: {e}")
+        raise
+
+async def handle_extract_images(args: Dict[str, Any]):
+    """Handle returning a PDF's images as image content"""
+    try:
+        import base64
+        from processors.image_content import extract_inline_images, DEFAULT_MAX_IMAGES, DEFAULT_MAX_DIMENSION
+        from utils.page_range import page_range_spans
+        from utils.cancellation import conversion_timeout
         
-        for i, field in enumerate(results['fields'][:20]):  # Show first 20 fields
-            output.append(f"\n### {i+1}. {field['name']}")
-            output.append(f"**Type:** {field['type']}")
-            if field['content']:
-                # Truncate very long content
-                content = field['content'][:300] + "..." if len(field['content']) > 300 else field['content']
-                output.append(f"**Content:** {content}")
-            if field['metadata']:
-                output.append(f"**Metadata:** {field['metadata']}")
+        pdf_path = args["pdf_path"]
+        max_images = args.get("max_images", DEFAULT_MAX_IMAGES)
+        max_dimension = args.get("max_dimension", DEFAULT_MAX_DIMENSION)
+        timeout = conversion_timeout(args.get("timeout_seconds"))
         
-        if len(results['fields']) > 20:
-            output.append(f"\n*... and {len(results['fields']) - 20} more fields*")
+        if not Path(pdf_path).exists():
+            raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        # Syntax now; the pages are expanded against the page count once the PDF is open
+        page_range = args.get("page_range")
+        if page_range:
+            page_range_spans(page_range)
         
-        # Show sections if detected
-        if results['structure']['sections']:
-            output.append(f"\n## Detected Sections ({len(results['structure']['sections'])})")
-            for section in results['structure']['sections'][:10]:
-                output.append(f"- {section}")
-            if len(results['structure']['sections']) > 10:
-                output.append(f"- *... and {len(results['structure']['sections']) - 10} more sections*")
+        logger.info(f"Extracting images as content: {pdf_path}")
         
-        response_text = "\n".join(output)
+        # In a worker thread: a large page range would otherwise block every other request
+        result = await run_cancellable(
+            lambda cancel_event: extract_inline_images(pdf_path, None, max_images, max_dimension, cancel_event,
+                                                       page_range),
+            timeout)
+        images = result['images']
         
-        return CallToolResult(
-            content=[
-                TextContent(
-                    type="text", 
-                    text=response_text
-                )
-            ]
-        )
+        message = f" 🖼️ Images: {Path(pdf_path).name}\n"
+        message += f"Returned: {len(images)}"
+        if args.get("page_range"):
+            message += f" from pages {args['page_range']}"
+        message += "\n"
+        for number, image in enumerate(images, 1):
+            message += f"{number}. Page {image['page']}, image {image['index']}: {image['width']}×{image['height']} px {image['mime_type']}"
+            if image['downscaled']:
+                message += f" (downscaled from {image['original_width']}×{image['original_height']})"
+            message += "\n"
+        
+        if result['omitted']:
+            message += f"\n**Warnings:**\n"
+            message += f"• {result['omitted']} more images not returned (max_images: {max_images}); narrow page_range to see them\n"
+        
+        return [TextContent(type="text", text=message)] + [
+            ImageContent(type="image", data=base64.b64encode(image['data']).decode('ascii'), mimeType=image['mime_type'])
+            for image in images
+        ]
         
     except Exception as e:
-        logger.error(f"Error in PDF extraction: {str(e)}", exc_info=True)
-        return CallToolResult(
-            content=[
-                TextContent(
-                    type="text", 
-                    text=f" Error extracting PDF content: {str(e)}"
-                )
-            ],
-            isError=True
-        )
-
+        logger.error(f"Image extraction failed: {e}")
+        raise
 
-async def handle_convert_pdf(args: Dict[str, Any]):
-    """Handle PDF to markdown conversion"""
+async def handle_extract_references(args: Dict[str, Any]):
+    """Handle bibliography extraction"""
     try:
-        from modular_pdf_converter import ModularPDFConverter
+        from processors.reference_extractor import extract_references, format_references_markdown
         from utils.file_utils import FileUtils
         
         pdf_path = args["pdf_path"]
-        output_dir = args.get("output_dir", "./docs")
+        output_dir = args.get("output_dir")
         
         if not Path(pdf_path).exists():
             raise FileNotFoundError(f"PDF file not found: {pdf_path}")
         
-        options = {
-            "split_by_chapters": args.get("split_by_chapters", True),
-            "preserve_tables": args.get("preserve_tables", True), 
-            "extract_images": args.get("extract_images", True),
-            "generate_summaries": args.get("generate_summaries", True),
-            "generate_concept_map": args.get("generate_concept_map", True),
-            "resolve_cross_references": args.get("resolve_cross_references", True),
-            "structured_tables": args.get("structured_tables", True),
-            "chunk_size_optimization": args.get("chunk_size_optimization", True),
-        }
-        
-        logger.info(f"Converting PDF: {pdf_path} to {output_dir}")
+        logger.info(f"Extracting references: {pdf_path}")
         
-        converter = ModularPDFConverter(pdf_path, output_dir, options)
-        result = converter.convert()
+        result = extract_references(pdf_path)
+        document = {'source_file': Path(pdf_path).name, **result}
+        references_md = format_references_markdown(result)
         
-        if result.get("success"):
-            # Get actual file count from generated_files
-            total_files = result.get('file_count', len(result.get('generated_files', [])))
-            
-            # Get the actual output path (with sanitized PDF folder name)
-            pdf_folder_name = FileUtils.sanitize_folder_name(Path(pdf_path).name)
-            actual_output_path = f"{output_dir}/{pdf_folder_name}"
-            
-            # Lead with agent training - this is the critical action
-            message = f"🤖 **AGENT TRAINING REQUIRED**\n"
-            message += f"Visit: https://github.com/wadearnold/mcp-document-markdown/blob/main/AGENT_INSTRUCTIONS.md\n"
-            message += f"Replace [FOLDER_NAME] with: {actual_output_path}\n\n"
-            
-            message += f"✅ Conversion complete: {Path(pdf_path).name}\n"
-            message += f"📁 Location: {actual_output_path}\n" 
-            message += f"📄 Files: {total_files:,} generated\n"
-            message += f"⏱️ Time: {result.get('processing_time_seconds', 0):.1f}s\n\n"
-            
-            # LLM-optimized structure for agent use
-            message += f"**Agent Navigation Structure:**\n"
-            message += f"• `{actual_output_path}/README.md` - Document map\n"
-            message += f"• `{actual_output_path}/sections/` - Content sections\n\n"
-            
-            # Brief stats for agent context
-            stats = result.get('processing_stats', {})
-            if stats:
-                pdf_stats = stats.get('pdf_extraction', {})
-                if pdf_stats:
-                    pages = pdf_stats.get('pages', 0)
-                    sections = stats.get('sections', 0)
-                    message += f"Processed: {pages} pages → {sections} sections\n"
-            
-            message += f"\n⚠️ This content is optimized for AI agent consumption, not human reading."
-            
+        message = f" 📚 References: {Path(pdf_path).name}\n"
+        if not result['found']:
+            message += "No references section found"
             return [TextContent(type="text", text=message)]
-        else:
-            error_msg = f"❌ Conversion failed: {result.get('error', 'Unknown error')}"
-            return [TextContent(type="text", text=error_msg)]
+        
+        parsed = sum(1 for ref in result['references'] if ref['title'])
+        message += f"Entries: {result['reference_count']} ({result['style'].replace('_', '-')} style, {parsed} with a parsed title)\n"
+        message += f"With DOI: {sum(1 for ref in result['references'] if ref['doi'])}\n"
+        
+        if output_dir:
+            json_file = Path(output_dir) / "references.json"
+            md_file = Path(output_dir) / "references.md"
+            FileUtils.ensure_directory(json_file.parent)
+            FileUtils.write_json(document, json_file)
+            FileUtils.write_markdown(references_md, md_file)
+            message += f"Saved: {json_file}, {md_file}\n"
+        
+        return [
+            TextContent(type="text", text=message + "\n" + references_md),
+            TextContent(type="text", text=json.dumps(document, indent=2, ensure_ascii=False))
+        ]
         
     except Exception as e:
-        logger.error(f"Convert PDF failed: {e}")
+        logger.error(f"Reference extraction failed: {e}")
         raise
 
-async def handle_analyze_pdf(args: Dict[str, Any]):
-    """Handle PDF structure analysis"""
+async def handle_extract_xmp(args: Dict[str, Any]):
+    """Handle XMP metadata extraction"""
     try:
-        from pdf_analyzer import analyze_pdf
+        import pypdf
+        from utils.xmp import read_pdf_xmp, format_xmp
         
         pdf_path = args["pdf_path"]
         
         if not Path(pdf_path).exists():
             raise FileNotFoundError(f"PDF file not found: {pdf_path}")
-            
-        logger.info(f"Analyzing PDF structure: {pdf_path}")
         
-        analysis = analyze_pdf(pdf_path)
+        logger.info(f"Extracting XMP metadata: {pdf_path}")
         
-        # Get file size
-        file_size_mb = Path(pdf_path).stat().st_size / (1024 * 1024)
+        xmp = read_pdf_xmp(pypdf.PdfReader(pdf_path, strict=False))
         
-        message = f" 📊 PDF Analysis: {Path(pdf_path).name}\n"
-        message += f"Pages: {analysis.get('pages', 'unknown')}\n"
-        message += f"Size: {file_size_mb:.2f} MB\n"
-        message += f"Has TOC: {analysis.get('has_toc', False)}\n"
-        message += f"Tables: {analysis.get('table_count', 0)}\n"
-        message += f"Images: {analysis.get('image_count', 0)}"
+        message = f" 🏷️ XMP Metadata: {Path(pdf_path).name}\n"
+        message += format_xmp(xmp)
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(xmp, indent=2, ensure_ascii=False))
+        ]
+        
+    except Exception as e:
+        logger.error(f"XMP extraction failed: {e}")
+        raise
+
+async def handle_canonicalize_markdown(args: Dict[str, Any]):
+    """Handle in-place canonical normalization of markdown files"""
+    try:
+        from utils.canonical import canonicalize_conversion
+        
+        directory = args["directory"]
+        
+        if not Path(directory).is_dir():
+            raise FileNotFoundError(f"Directory not found: {directory}")
+        
+        logger.info(f"Canonicalizing markdown: {directory}")
+        
+        # Reading and rewriting every section is blocking file work
+        result = await asyncio.get_running_loop().run_in_executor(None, canonicalize_conversion, Path(directory))
+        
+        message = f" 🧾 Canonical Markdown: {directory}\n"
+        message += f"Files: {result['files']} markdown, {result['changed']} rewritten"
         
         return [TextContent(type="text", text=message)]
         
     except Exception as e:
-        logger.error(f"Analyze PDF failed: {e}")
+        logger.error(f"Markdown canonicalization failed: {e}")
         raise
 
 async def handle_prepare_rag(args: Dict[str, Any]):
@@ -469,11 +2781,120 @@ async def handle_prepare_rag(args: Dict[str, Any]):
         logger.error(f"RAG preparation failed: {e}")
         raise
 
+async def handle_features_status(args: Dict[str, Any]):
+    """Handle feature availability diagnostics"""
+    try:
+        from utils.features import features_status, format_features_status, DEFAULT_PROBE_TIMEOUT
+        
+        timeout = args.get("timeout_seconds", DEFAULT_PROBE_TIMEOUT)
+        
+        # Endpoint probes block on the network; keep them off the event loop
+        loop = asyncio.get_running_loop()
+        status = await loop.run_in_executor(None, features_status, timeout)
+        
+        summary = ", ".join(f"{count} {state}" for state, count in sorted(status['summary'].items()))
+        message = f" 🩺 Feature Status ({summary})\n\n"
+        message += format_features_status(status)
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(status, indent=2))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Feature status failed: {e}")
+        raise
+
+async def handle_check_environment(args: Dict[str, Any]):
+    """Handle the interpreter and core package report"""
+    try:
+        from utils.environment import check_environment, format_environment
+        
+        # Reading package metadata touches the filesystem; keep it off the event loop
+        loop = asyncio.get_running_loop()
+        report = await loop.run_in_executor(None, check_environment)
+        
+        title = "Environment Usable" if report['usable'] else "Environment Not Usable"
+        message = f" {'🐍' if report['usable'] else '❌'} {title}\n\n"
+        message += format_environment(report)
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(report, indent=2, ensure_ascii=False))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Environment check failed: {e}")
+        raise
+
+async def handle_server_info(args: Dict[str, Any]):
+    """Handle the build details report"""
+    try:
+        import platform
+        from utils.build_info import build_info, format_build_info
+        from utils.environment import resolve_interpreter
+        
+        # Dev mode asks git for the commit; keep it off the event loop
+        loop = asyncio.get_running_loop()
+        info = await loop.run_in_executor(None, build_info)
+        info['python'] = {
+            'executable': sys.executable or None,
+            'version': platform.python_version(),
+            'converter_interpreter': resolve_interpreter()
+        }
+        
+        message = f" 🏷️ document-markdown {info['version']}\n\n"
+        message += format_build_info(info) + "\n"
+        message += f"Python: {info['python']['version']} at {info['python']['executable'] or 'unknown path'}\n"
+        message += f"Converter processes: {info['python']['converter_interpreter'] or 'no interpreter found'}"
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(info, indent=2, ensure_ascii=False))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Server info failed: {e}")
+        raise
+
+async def handle_self_test(args: Dict[str, Any]):
+    """Handle the end-to-end self-test on a generated sample PDF"""
+    try:
+        from utils.self_test import run_self_test, format_self_test
+        from utils.output_capture import OutputCapture
+        from utils.cancellation import conversion_timeout
+        
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+        
+        logger.info("Running self-test")
+        
+        # The converter prints progress; keep it off the protocol stream
+        with OutputCapture():
+            report = await run_cancellable(lambda cancel_event: run_self_test(cancel_event), timeout)
+        
+        passed = sum(1 for stage in report['stages'] if stage['status'] == 'passed')
+        title = "Self-Test Passed" if report['passed'] else "Self-Test Failed"
+        message = f" {'✅' if report['passed'] else '❌'} {title} ({passed}/{len(report['stages'])} stages, {report['seconds']:.1f}s)\n\n"
+        message += format_self_test(report)
+        if not report['passed']:
+            message += "\nRun features_status for install hints.\n"
+        
+        return [
+            TextContent(type="text", text=message),
+            TextContent(type="text", text=json.dumps(report, indent=2, ensure_ascii=False))
+        ]
+        
+    except Exception as e:
+        logger.error(f"Self-test failed: {e}")
+        raise
+
 async def handle_convert_docx(args: Dict[str, Any]):
     """Handle Word document to markdown conversion"""
     try:
-        from modular_docx_converter import ModularDocxConverter
+        from utils.features import feature_by_name, probe_feature
         from utils.file_utils import FileUtils
+        from utils.output_capture import OutputCapture
+        from utils.doc_resources import remember_output
         
         docx_path = args["docx_path"]
         output_dir = args.get("output_dir", "./docs")
@@ -481,6 +2902,15 @@ async def handle_convert_docx(args: Dict[str, Any]):
         if not Path(docx_path).exists():
             raise FileNotFoundError(f"Word document not found: {docx_path}")
         
+        # Missing docx libraries: say how to install them instead of failing on import
+        report = probe_feature(feature_by_name("docx_conversion"))
+        if report["status"] == "unavailable":
+            message = f"❌ Word conversion is unavailable: {'; '.join(report['problems'])}\n"
+            message += f"Install with: {report['remediation']}"
+            return [TextContent(type="text", text=message)]
+        
+        from modular_docx_converter import ModularDocxConverter
+        
         options = {
             "split_by_chapters": args.get("split_by_chapters", True),
             "preserve_tables": args.get("preserve_tables", True), 
@@ -490,20 +2920,27 @@ async def handle_convert_docx(args: Dict[str, Any]):
             "resolve_cross_references": args.get("resolve_cross_references", True),
             "structured_tables": args.get("structured_tables", True),
             "chunk_size_optimization": args.get("chunk_size_optimization", True),
+            "output_layout": args.get("output_layout"),
+            "section_naming": args.get("section_naming"),
+            "section_links": args.get("section_links", True),
         }
         
         logger.info(f"Converting Word document: {docx_path} to {output_dir}")
         
-        converter = ModularDocxConverter(docx_path, output_dir, options)
-        result = converter.convert()
+        async with output_locks().hold(output_dir):
+            with OutputCapture() as capture:
+                converter = ModularDocxConverter(docx_path, output_dir, options)
+                result = converter.convert()
         
         if result.get("success"):
+            remember_output(output_dir, result)
+            
             # Get actual file count from generated_files
             total_files = result.get('file_count', len(result.get('generated_files', [])))
             
-            # Get the actual output path (with sanitized folder name)
-            docx_folder_name = FileUtils.sanitize_folder_name(Path(docx_path).name)
-            actual_output_path = f"{output_dir}/{docx_folder_name}"
+            # Get the actual output path (resolved from the output layout)
+            actual_output_path = result.get('output_directory') or \
+                f"{output_dir}/{FileUtils.sanitize_folder_name(Path(docx_path).name)}"
             
             # Lead with agent training - this is the critical action
             message = f"🤖 **AGENT TRAINING REQUIRED**\n"
@@ -517,8 +2954,10 @@ async def handle_convert_docx(args: Dict[str, Any]):
             
             # LLM-optimized structure for agent use
             message += f"**Agent Navigation Structure:**\n"
+            sections_path = result.get('sections_directory') or f"{actual_output_path}/sections"
             message += f"• `{actual_output_path}/README.md` - Document map\n"
-            message += f"• `{actual_output_path}/sections/` - Content sections\n\n"
+            message += f"• `{sections_path}/` - Content sections\n"
+            message += f"• `{actual_output_path}/manifest.json` - Section and image list\n\n"
             
             # Brief stats for agent context
             stats = result.get('processing_stats', {})
@@ -533,13 +2972,94 @@ async def handle_convert_docx(args: Dict[str, Any]):
             
             return [TextContent(type="text", text=message)]
         else:
-            error_msg = f"❌ Conversion failed: {result.get('error', 'Unknown error')}"
-            return [TextContent(type="text", text=error_msg)]
+            error = capture.format_error(result.get('error', 'Unknown error'), Path(output_dir))
+            error_msg = f"❌ Conversion failed: {error}"
+            return tool_error(error_msg)
         
     except Exception as e:
         logger.error(f"Convert Word document failed: {e}")
         raise
 
+async def handle_organize_markdown(args: Dict[str, Any]):
+    """Handle organizing extracted markdown into the conversion layout"""
+    try:
+        from utils.file_utils import FileUtils
+        from utils.output_capture import OutputCapture
+        from utils.doc_resources import remember_output
+        from utils.cancellation import conversion_timeout
+        from modular_markdown_converter import ModularMarkdownConverter
+        
+        markdown_path = args.get("markdown_path")
+        content = args.get("content")
+        output_dir = args.get("output_dir", "./docs")
+        timeout = conversion_timeout(args.get("timeout_seconds"))
+        
+        if (markdown_path is None) == (content is None):
+            return tool_error("❌ Pass exactly one of markdown_path or content")
+        if markdown_path and not Path(markdown_path).exists():
+            raise FileNotFoundError(f"Markdown file not found: {markdown_path}")
+        
+        options = {
+            "split_by_chapters": args.get("split_by_chapters", True),
+            "chunk_tokens": args.get("chunk_tokens"),
+            "chunk_overlap": args.get("chunk_overlap", 0),
+            "extract_images": args.get("extract_images", True),
+            "output_layout": args.get("output_layout"),
+            "section_naming": args.get("section_naming"),
+            "section_links": args.get("section_links", True),
+            "document_name": args.get("document_name"),
+        }
+        
+        logger.info(f"Organizing markdown: {markdown_path or 'inline content'} to {output_dir}")
+        
+        converter = ModularMarkdownConverter(markdown_path, output_dir, options, content=content)
+        
+        def organize(cancel_event: threading.Event) -> Dict[str, Any]:
+            converter.cancel_event = cancel_event
+            return converter.convert()
+        
+        with OutputCapture() as capture:
+            # In a worker thread, holding output_dir like the other converters
+            result = await run_cancellable(organize, timeout, output_dir=output_dir)
+        
+        if result.get("success"):
+            remember_output(output_dir, result)
+            
+            source_name = converter.source_path.name
+            total_files = result.get('file_count', len(result.get('generated_files', [])))
+            actual_output_path = result.get('output_directory') or \
+                f"{output_dir}/{FileUtils.sanitize_folder_name(source_name)}"
+            
+            message = f"✅ Organized: {source_name}\n"
+            message += f"📁 Location: {actual_output_path}\n"
+            message += f"📄 Files: {total_files:,} generated\n"
+            message += f"⏱️ Time: {result.get('processing_time_seconds', 0):.1f}s\n\n"
+            
+            message += f"**Agent Navigation Structure:**\n"
+            sections_path = result.get('sections_directory') or f"{actual_output_path}/sections"
+            message += f"• `{actual_output_path}/README.md` - Document map\n"
+            message += f"• `{sections_path}/` - Content sections\n"
+            message += f"• `{actual_output_path}/manifest.json` - Section and image list\n"
+            chunking = result.get('chunking')
+            if chunking:
+                message += f"• `{actual_output_path}/{chunking['manifest']}` - {chunking['total_chunks']} chunks of ≤{chunking['chunk_tokens']} tokens (overlap {chunking['chunk_overlap']}, {chunking['tokenizer']})\n"
+            
+            stats = result.get('processing_stats', {})
+            markdown_stats = stats.get('markdown_extraction', {})
+            if markdown_stats:
+                message += f"\nProcessed: {markdown_stats.get('total_words', 0):,} words → {stats.get('sections', 0)} sections\n"
+            for warning in result.get('warnings', []):
+                message += f"⚠️ {warning}\n"
+            
+            return [TextContent(type="text", text=message)]
+        else:
+            error = capture.format_error(result.get('error', 'Unknown error'), Path(output_dir))
+            return tool_error(f"❌ Organizing failed: {error}")
+        
+    except Exception as e:
+        logger.error(f"Organize markdown failed: {e}")
+        raise
+
 async def handle_analyze_docx(args: Dict[str, Any]):
     """Handle Word document structure analysis"""
     try:
@@ -621,7 +3141,8 @@ async def handle_extract_docx_content(args: Dict[str, Any]):
                         type="text",
                         text=f" Failed to extract content from Word document: {result.get('error', 'Unknown error')}"
                     )
-                ]
+                ],
+                isError=True
             )
             
     except Exception as e:
@@ -632,7 +3153,8 @@ async def handle_extract_docx_content(args: Dict[str, Any]):
                     type="text", 
                     text=f" Error extracting Word document content: {str(e)}"
                 )
-            ]
+            ],
+            isError=True
         )
 
 
@@ -682,7 +3204,8 @@ async def handle_prepare_docx_rag(args: Dict[str, Any]):
                     type="text",
                     text=f" Error preparing Word document for RAG: {str(e)}"
                 )
-            ]
+            ],
+            isError=True
         )
 
 
@@ -693,6 +3216,66 @@ async def main():
     print(f" Working directory: {Path.cwd()}", file=sys.stderr, flush=True)
     print(f"🛤️  Python path: {sys.path[:3]}...", file=sys.stderr, flush=True)
     
+    # Exact versions of the core libraries, logged and sent to clients on initialize
+    from utils.dependencies import check_dependencies
+    dependencies = check_dependencies()
+    versions = ", ".join(f"{name} {version or 'missing'}" for name, version in dependencies.versions().items())
+    logger.info(f"Dependencies: {versions}")
+    for problem in dependencies.problems:
+        logger.warning(problem)
+    
+    # Resolve the converter interpreter now rather than on the first batch; without one the
+    # server still starts (single conversions run in-process) and says so on initialize
+    from utils.environment import check_environment
+    environment = check_environment()
+    if environment['converter_interpreter']:
+        logger.info(f"Converter interpreter: {environment['converter_interpreter']}")
+    for problem in environment['problems']:
+        if problem not in dependencies.problems:
+            logger.warning(problem)
+    
+    # Which build is running: serverInfo carries the version with the commit as build metadata
+    from utils.build_info import build_info, server_version
+    build = build_info()
+    app.version = server_version(build)
+    logger.info(f"Version: {app.version} ({build['mode']}, built {build['build_date'] or 'unknown'})")
+    
+    # Shared default arguments; a bad config file stops the server here rather than on every call
+    global SERVER_CONFIG, TOOL_PROPERTIES
+    from utils.server_config import load_server_config, ServerConfigError
+    import contextlib
+    with contextlib.redirect_stdout(sys.stderr):
+        TOOL_PROPERTIES = {tool.name: tool.inputSchema.get("properties", {}) for tool in await list_tools()}
+    try:
+        SERVER_CONFIG = load_server_config(TOOL_PROPERTIES)
+        if SERVER_CONFIG.path:
+            validate_pdf_convert_options(pdf_convert_options(
+                SERVER_CONFIG.apply("convert_pdf", {}, TOOL_PROPERTIES["convert_pdf"])))
+    except ServerConfigError as e:
+        logger.error(f"Invalid server config: {e}")
+        sys.exit(1)
+    except ValueError as e:
+        # Raised by the option checks, after the file itself loaded
+        logger.error(f"Invalid server config: {SERVER_CONFIG.path}: {e}")
+        sys.exit(1)
+    if SERVER_CONFIG.path:
+        configured = len(SERVER_CONFIG.defaults) + sum(len(arguments) for arguments in SERVER_CONFIG.tools.values())
+        logger.info(f"Config: {SERVER_CONFIG.path} ({configured} default argument(s))")
+    
+    # Work from WORKSPACE_ROOT so default output directories (./docs) land inside it too
+    global WORKSPACE
+    from utils.workspace import load_workspace, WorkspacePathError
+    try:
+        WORKSPACE = load_workspace()
+    except WorkspacePathError as e:
+        logger.error(str(e))
+        sys.exit(1)
+    if WORKSPACE.root:
+        os.chdir(WORKSPACE.root)
+        logger.info(f"Workspace: {WORKSPACE.root} (paths outside it are refused)")
+    logger.info(f"Concurrency: up to {worker_limit().limit} conversions at once (MAX_CONCURRENCY), "
+                f"{process_limit().limit} batch converter processes (MAX_PROCESSES)")
+    
     # Add debugging for request handling
     original_run = app.run
     async def debug_run(*args, **kwargs):
@@ -700,13 +3283,23 @@ async def main():
         return await original_run(*args, **kwargs)
     app.run = debug_run
     
+    # SIGINT/SIGTERM cancel the server; the finally below removes temporary files once tool calls have stopped
+    from utils.temp_files import TEMP_FILES, install_shutdown_handlers
+    install_shutdown_handlers(TEMP_FILES, on_signal=lambda sig: logger.info(f"Received {sig.name}, shutting down"))
+    
     try:
         async with mcp.server.stdio.stdio_server() as (read_stream, write_stream):
             print(f"📡 Starting stdio server", file=sys.stderr, flush=True)
             await app.run(
                 read_stream,
                 write_stream,
-                app.create_initialization_options()
+                app.create_initialization_options(
+                    experimental_capabilities={
+                        "dependencies": dependencies.to_dict(),
+                        "environment": {key: environment[key] for key in ("usable", "converter_interpreter", "problems")},
+                        "build": {key: build[key] for key in ("version", "commit", "build_date", "mode")}
+                    }
+                )
             )
     except asyncio.CancelledError:
         # This is expected when shutting down
@@ -715,8 +3308,26 @@ async def main():
         # Handle Ctrl+C gracefully
         print("\n👋 Server stopped by user", file=sys.stderr)
         return
+    finally:
+        removed = TEMP_FILES.cleanup()
+        if removed:
+            logger.info(f"Removed {removed} temporary path(s) left by unfinished tool calls")
+
+def self_test_command() -> int:
+    """python mcp_document_markdown.py self-test: print the stage report, exit 1 on failure"""
+    import contextlib
+    from utils.self_test import run_self_test, format_self_test
+    
+    # Conversion progress goes to stderr so stdout is just the report
+    with contextlib.redirect_stdout(sys.stderr):
+        report = run_self_test()
+    print(format_self_test(report), end="")
+    print("Self-test passed" if report['passed'] else "Self-test failed")
+    return 0 if report['passed'] else 1
 
 if __name__ == "__main__":
+    if sys.argv[1:2] == ["self-test"]:
+        sys.exit(self_test_command())
     try:
         asyncio.run(main())
     except KeyboardInterrupt:
@@ -0,0 +1,447 @@
+#!/usr/bin/env python3
+"""
+Modular Markdown Organizer
+Organizes markdown that is already extracted (from markitdown, pandoc, or a
+converter run elsewhere) into AI-optimized markdown documentation
+
+Only the organization steps run: sections split at headings, large sections
+split into parts, optional token-budget chunks, the README.md document map,
+and manifest.json. Output uses the same layout as PDF conversions, and Word
+conversion runs through the same pipeline after its own extraction step.
+"""
+import re
+import threading
+import time
+from pathlib import Path
+from typing import Dict, List, Any, Optional
+from datetime import datetime
+
+# Import processors
+from processors.markdown_sections import HEADING, split_markdown_sections
+from processors.chunking_engine import ChunkingEngine, validate_chunk_budget
+
+# Import utilities
+from utils.token_counter import TokenCounter
+from utils.text_utils import TextUtils
+from utils.file_utils import FileUtils
+from utils.output_layout import OutputLayout
+from utils.frontmatter import render_front_matter
+from utils.navigation import link_section_files
+from utils.embedded_images import save_data_uri_images
+from utils.inline_bundle import chunk_markdown
+from utils.section_naming import DEFAULT_SECTION_NAMING, section_filenames, validate_section_naming
+from utils.cancellation import ConversionCancelled, check_cancelled
+from utils.staging import discard_staged_output, install_staged_output, rebase_staged_paths, staging_layout
+
+# Sections above this many tokens are split into parts (matches PDF conversion)
+MAX_SECTION_TOKENS = 32000
+PART_TARGET_TOKENS = 28000
+
+# Document name for inline content when none is given
+DEFAULT_DOCUMENT_NAME = 'document.md'
+
+# Delimiter row of a markdown table
+TABLE_DELIMITER = re.compile(r'^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)+\|?\s*$', re.M)
+
+
+class ModularMarkdownConverter:
+    """Orchestrates organizing extracted markdown into structured markdown"""
+    
+    doc_type = 'markdown'
+    source_label = 'markdown'
+    
+    def __init__(self, source_path: Optional[str], output_dir: str, options: Optional[Dict] = None,
+                 content: Optional[str] = None, cancel_event: Optional[threading.Event] = None):
+        """
+        Initialize the markdown organizer
+        
+        Args:
+            source_path: Path to the markdown file (None with content)
+            output_dir: Directory to save organized files
+            options: Conversion options
+            content: Inline markdown to organize instead of a file
+            cancel_event: When set, the conversion stops at the next section and
+                discards the files it wrote (see utils.staging)
+        """
+        # Default options
+        self.options = {
+            'split_by_chapters': True,
+            'preserve_tables': True,
+            'extract_images': True,
+            'generate_summaries': True,
+            'generate_concept_map': True,
+            'resolve_cross_references': True,
+            'structured_tables': True,
+            'chunk_size_optimization': True,
+            'chunk_tokens': None,
+            'chunk_overlap': 0,
+            'section_links': True,
+            'output_layout': None,
+            'document_name': None,
+            'section_naming': DEFAULT_SECTION_NAMING,
+        }
+        
+        if options:
+            self.options.update(options)
+        
+        self.content = content
+        self.cancel_event = cancel_event
+        self.source_path = Path(source_path or self.options.get('document_name') or DEFAULT_DOCUMENT_NAME)
+        
+        # Same folder naming and placement as PDF conversions
+        folder_name = FileUtils.sanitize_folder_name(self.source_path.name)
+        self.layout = OutputLayout(self.options.get('output_layout'), str(output_dir),
+                                   folder_name, doc_type=self.doc_type)
+        self.output_dir = self.layout.document_root()
+        # layout and output_dir point into a staging directory while convert() runs (utils.staging)
+        self.final_layout = self.layout
+        
+        # Initialize components
+        self.token_counter = TokenCounter()
+        
+        # Tracking
+        self.generated_files = []
+        self.processing_stats = {}
+        self.warnings: List[str] = []
+        self.images: List[Dict[str, Any]] = []
+        self.chunk_budget = None
+        self.chunking = None
+    
+    def convert(self) -> Dict[str, Any]:
+        """
+        Main organization pipeline
+        
+        Returns:
+            Dictionary with conversion results
+        """
+        start_time = time.time()
+        
+        try:
+            if self.options.get('chunk_tokens'):
+                self.chunk_budget = validate_chunk_budget(self.options['chunk_tokens'],
+                                                          self.options.get('chunk_overlap', 0))
+            elif self.options.get('chunk_overlap'):
+                raise ValueError("chunk_overlap requires chunk_tokens")
+            self.options['section_naming'] = validate_section_naming(self.options.get('section_naming'))
+            
+            # Files go to a staging directory, moved into place once the conversion succeeds
+            self.layout = staging_layout(self.final_layout)
+            self.output_dir = self.layout.document_root()
+            FileUtils.ensure_directory(self.output_dir)
+            
+            print(f"\n🚀 Starting {self.source_label} conversion: {self.source_path.name}")
+            print(f"📁 Output directory: {self.final_layout.document_root()}")
+            
+            # Step 1: Extract content
+            print(f"\n📄 Step 1: Extracting {self.source_label} content...")
+            extraction_result = self.extract()
+            
+            if not extraction_result['success']:
+                raise Exception(f"Failed to extract {self.source_label}: {extraction_result.get('error')}")
+            
+            # Store stats
+            self.processing_stats[f"{self.doc_type}_extraction"] = extraction_result['stats']
+            
+            # Step 2: Structure content into sections
+            check_cancelled(self.cancel_event)
+            sections = self.prepare_sections(extraction_result['sections'])
+            
+            # Step 3: Generate LLM-optimized markdown files
+            print("\n📝 Step 2: Generating LLM-optimized markdown files...")
+            markdown_files = self.generate_main_markdown_files(sections, extraction_result)
+            self.generated_files.extend(markdown_files)
+            
+            # Step 4: Token-budget chunks
+            if self.chunk_budget:
+                print("\n✂️ Step 3: Creating token-budget chunks...")
+                self.generated_files.extend(self.create_budget_chunks(sections))
+            
+            # Calculate processing time
+            processing_time = time.time() - start_time
+            
+            # Store final stats
+            self.processing_stats['sections'] = len(sections)
+            self.processing_stats['images'] = len(self.images)
+            self.processing_stats['files_created'] = len(self.generated_files)
+            self.processing_stats['processing_time'] = processing_time
+            
+            # Success result
+            result = {
+                'success': True,
+                'output_dir': str(self.output_dir),
+                'output_directory': str(self.output_dir),
+                'sections_directory': str(self.layout.directory_for('sections')),
+                'generated_files': self.generated_files,
+                'file_count': len(self.generated_files),
+                'processing_stats': self.processing_stats,
+                'processing_time_seconds': processing_time
+            }
+            if self.chunking:
+                result['chunking'] = self.chunking
+            if self.warnings:
+                result['warnings'] = self.warnings
+            
+            staged = self.layout
+            install_staged_output(staged, self.final_layout)
+            self.layout, self.output_dir = self.final_layout, self.final_layout.document_root()
+            print(f"\n✅ Conversion complete! Generated {len(self.generated_files)} files in {processing_time:.1f}s")
+            
+            return rebase_staged_paths(result, staged, self.final_layout)
+        
+        except ConversionCancelled:
+            processing_time = time.time() - start_time
+            removed = self.discard_output()
+            print(f"Conversion cancelled after {processing_time:.2f} seconds; removed {len(removed)} partial files")
+            return {
+                'success': False,
+                'cancelled': True,
+                'error': 'Conversion cancelled',
+                'error_type': 'ConversionCancelled',
+                'removed_files': removed,
+                'processing_time_seconds': processing_time
+            }
+        except Exception as e:
+            self.discard_output()
+            print(f"\n❌ Conversion failed: {e}")
+            return {
+                'success': False,
+                'error': str(e),
+                'processing_time_seconds': time.time() - start_time
+            }
+    
+    def discard_output(self) -> List[str]:
+        """Delete the staged files of a run that did not succeed; the previous output stays as it was"""
+        if self.layout is self.final_layout:
+            return []
+        removed = discard_staged_output(self.layout)
+        self.layout, self.output_dir = self.final_layout, self.final_layout.document_root()
+        return removed
+    
+    def extract(self) -> Dict[str, Any]:
+        """
+        Sections of the markdown file or inline content
+        
+        Returns:
+            Dictionary with success, sections, and stats (as DocxExtractor.extract_from_file)
+        """
+        try:
+            if self.content is not None:
+                markdown = self.content
+            else:
+                if not self.source_path.exists():
+                    raise FileNotFoundError(f"Markdown file not found: {self.source_path}")
+                markdown = self.source_path.read_text(encoding='utf-8')
+        except (OSError, UnicodeDecodeError) as e:
+            return {'success': False, 'error': str(e)}
+        
+        if self.options.get('split_by_chapters', True):
+            sections = split_markdown_sections(markdown)
+        else:
+            # One section; a leading H1 becomes its title
+            title, body = self.source_path.stem, markdown.strip()
+            first_line, _, rest = body.partition('\n')
+            heading = HEADING.match(first_line)
+            if heading and len(heading.group(1)) == 1:
+                title, body = heading.group(2).strip(), rest.strip()
+            sections = [{'title': title, 'level': 1, 'content': body, 'section_type': 'content'}] if body else []
+        
+        return {
+            'success': True,
+            'sections': sections,
+            'stats': {
+                'total_words': len(markdown.split()),
+                'total_sections': len(sections),
+                'total_tables': len(TABLE_DELIMITER.findall(markdown))
+            }
+        }
+    
+    def prepare_sections(self, sections: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+        """Number sections, write their embedded images to files, and label content types"""
+        images_dir = self.layout.directory_for('images')
+        images_link = self.layout.relative_path(images_dir, self.layout.directory_for('sections'))
+        
+        for i, section in enumerate(sections):
+            section['section_id'] = i + 1
+            content = section.get('content', '')
+            if self.options['extract_images']:
+                content, images = save_data_uri_images(content, images_dir, images_link,
+                                                       name_prefix=f"section{i + 1}_img")
+                for image in images:
+                    image['section_id'] = section['section_id']
+                self.images.extend(images)
+            section['content'] = content
+            section['content_type'] = TextUtils.classify_content_type(content)
+            section['token_count'] = self.token_counter.count_tokens(content)
+        return sections
+    
+    def assign_section_filenames(self, sections: List[Dict[str, Any]]) -> None:
+        """Name every section file from its title with section_naming, stored as section['filename']"""
+        filenames = section_filenames(self.options['section_naming'],
+                                      [FileUtils.safe_filename(section.get('title') or '') for section in sections],
+                                      [section.get('title') or '' for section in sections],
+                                      [section.get('content', '') for section in sections])
+        for section, filename in zip(sections, filenames):
+            section['filename'] = filename
+    
+    def section_filename(self, section: Dict[str, Any]) -> str:
+        """The section's assigned file name"""
+        return section['filename']
+    
+    def generate_main_markdown_files(self, sections: List[Dict[str, Any]],
+                                     extraction_result: Dict[str, Any]) -> List[str]:
+        """Generate README.md, section files, and manifest.json"""
+        generated_files = []
+        self.assign_section_filenames(sections)
+        
+        readme_file = self.layout.path_for('root', "README.md")
+        FileUtils.write_markdown(self.create_document_map(sections, extraction_result), readme_file)
+        generated_files.append(str(readme_file))
+        
+        FileUtils.ensure_directory(self.layout.directory_for('sections'))
+        
+        # Large sections become several part files
+        section_outputs = []
+        for section in sections:
+            filename = self.section_filename(section)
+            parts = self.split_large_section(section.get('content', ''))
+            if len(parts) == 1:
+                section_outputs.append([(filename, parts[0])])
+            else:
+                base_name = filename[:-len('.md')]
+                section_outputs.append([(f"{base_name}-part{index:02d}.md", part)
+                                        for index, part in enumerate(parts, 1)])
+        
+        links = [[{} for _ in files] for files in section_outputs]
+        if self.options.get('section_links', True):
+            links = link_section_files([[name for name, _ in files] for files in section_outputs],
+                                       [section.get('level', 1) for section in sections])
+        
+        manifest_sections = []
+        for section, files, section_links in zip(sections, section_outputs, links):
+            for index, ((filename, content), file_links) in enumerate(zip(files, section_links), 1):
+                check_cancelled(self.cancel_event)
+                part = (index, len(files)) if len(files) > 1 else None
+                section_md = self.create_section_markdown(section, content, file_links, part)
+                section_file = self.layout.path_for('sections', filename)
+                FileUtils.write_markdown(section_md, section_file)
+                generated_files.append(str(section_file))
+                manifest_sections.append({
+                    'file': self.layout.relative_path(section_file),
+                    'section_id': section['section_id'],
+                    'title': section.get('title', ''),
+                    'section_type': section.get('section_type', 'content'),
+                    'content_type': section.get('content_type', 'mixed'),
+                    'token_count': self.token_counter.count_tokens(content)
+                })
+        
+        generated_files.extend(image['file'] for image in self.images)
+        
+        manifest_file = self.write_manifest(manifest_sections)
+        generated_files.append(str(manifest_file))
+        
+        return generated_files
+    
+    def split_large_section(self, content: str) -> List[str]:
+        """Split section content above MAX_SECTION_TOKENS at paragraph breaks"""
+        if self.token_counter.count_tokens(content) <= MAX_SECTION_TOKENS:
+            return [content]
+        return chunk_markdown(content, PART_TARGET_TOKENS, self.token_counter) or [content]
+    
+    def create_budget_chunks(self, sections: List[Dict[str, Any]]) -> List[str]:
+        """Cut sections into chunk_tokens-sized chunks under chunked/"""
+        chunk_tokens, chunk_overlap = self.chunk_budget
+        chunked_dir = self.layout.directory_for('chunked')
+        engine = ChunkingEngine(str(self.output_dir), self.token_counter, chunked_dir=str(chunked_dir))
+        # Section text links images relative to sections/; chunks rebase them
+        result = engine.create_budget_chunks(sections, chunk_tokens, chunk_overlap, cancel_event=self.cancel_event,
+                                             links_dir=self.layout.relative_path(self.layout.directory_for('sections'),
+                                                                                 chunked_dir))
+        self.chunking = {key: value for key, value in result.items() if key not in ('chunks', 'chunk_files', 'manifest_file')}
+        self.chunking['manifest'] = self.layout.relative_path(Path(result['manifest_file']))
+        self.processing_stats['chunks'] = result['total_chunks']
+        
+        if result['over_budget']:
+            self.warnings.append(f"{result['over_budget']} chunks exceed chunk_tokens: tables and code blocks are never split")
+        return result['chunk_files'] + [result['manifest_file']]
+    
+    def create_section_markdown(self, section: Dict[str, Any], content: str,
+                                links: Dict[str, Optional[str]], part: Optional[tuple] = None) -> str:
+        """Section file: front-matter (as in PDF conversions), heading, and content"""
+        title = section.get('title', 'Untitled Section')
+        front_matter = {
+            'title': title,
+            'section_id': section['section_id'],
+            'content_type': section.get('content_type', 'mixed'),
+            **links
+        }
+        heading = f"# {title} (Part {part[0]}/{part[1]})" if part else f"# {title}"
+        return render_front_matter(front_matter) + f"{heading}\n\n{content}\n"
+    
+    def create_document_map(self, sections: List[Dict[str, Any]], extraction_result: Dict[str, Any]) -> str:
+        """README.md: the navigation entry point"""
+        stats = extraction_result.get('stats') or {}
+        content = f"# {self.source_path.stem}\n\nDocument navigation and section directory.\n\n"
+        content += "## Document Summary\n\n"
+        content += f"- **Source Document**: {self.source_path.name}\n"
+        content += f"- **Total Words**: {stats.get('total_words', 0):,}\n"
+        content += f"- **Total Sections**: {len(sections)}\n"
+        content += f"- **Total Tables**: {stats.get('total_tables', 0)}\n"
+        content += f"- **Total Images**: {len(self.images)}\n\n"
+        content += "## Section Navigation\n\n"
+        
+        sections_link = self.layout.relative_path(self.layout.directory_for('sections'))
+        for section in sections:
+            indent = "  " * (max(section.get('level', 1), 1) - 1)
+            filename = self.section_filename(section)
+            if section.get('token_count', 0) > MAX_SECTION_TOKENS:
+                filename = filename[:-len('.md')] + "-part01.md"
+            content += f"{indent}- [{section.get('title', 'Untitled Section')}]({sections_link}/{filename})\n"
+        
+        return content
+    
+    def write_manifest(self, manifest_sections: List[Dict[str, Any]]) -> Path:
+        """Write manifest.json in the same shape as PDF conversions"""
+        manifest = {
+            'document_id': FileUtils.document_id(self.source_path.name),
+            'source_file': self.source_path.name,
+            'generated_at': datetime.now().isoformat(),
+            'fingerprint': None,
+            'section_naming': self.options['section_naming'],
+            'sections': manifest_sections,
+            'images': [{
+                'file': self.layout.relative_path(Path(image['file'])),
+                'page': None,
+                'section_id': image['section_id'],
+                'alt_text': image['alt_text'],
+                'mime_type': image['mime_type']
+            } for image in self.images]
+        }
+        manifest_file = self.layout.path_for('root', "manifest.json")
+        FileUtils.write_json(manifest, manifest_file)
+        return manifest_file
+
+
+def main():
+    """Command-line interface for the markdown organizer"""
+    import sys
+    
+    if len(sys.argv) < 2:
+        print("Usage: python modular_markdown_converter.py <markdown_file> [output_dir]")
+        sys.exit(1)
+    
+    markdown_path = sys.argv[1]
+    output_dir = sys.argv[2] if len(sys.argv) > 2 else "./docs"
+    
+    # Run conversion
+    converter = ModularMarkdownConverter(markdown_path, output_dir)
+    result = converter.convert()
+    
+    if result['success']:
+        print(f"\n✅ Success! Files saved to: {result['output_dir']}")
+    else:
+        print(f"\n❌ Conversion failed: {result['error']}")
+        sys.exit(1)
+
+
+if __name__ == "__main__":
+    main()
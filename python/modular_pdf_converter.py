@@ -2,18 +2,71 @@
 Modular PDF to Markdown converter - main orchestrator
 """
 import json
+import logging
 import sys
 from pathlib import Path
-from typing import Dict, List, Any, Optional
+from typing import Dict, List, Any, Optional, Tuple
 from datetime import datetime
 
 # Import core extraction functionality
-from processors.pdf_extractor import extract_all_content
+from processors.pdf_extractor import (PDFExtractor, extract_all_content, estimate_memory_usage, extract_outline,
+                                      extract_tables_with_pdfplumber)
+from processors.table_processor import TableProcessor
+from processors.keyword_extractor import KeywordExtractor
+from processors.signature_extractor import extract_signatures
+from processors.form_fields import FORM_FIELDS_TITLE, extract_form_fields, form_fields_markdown, form_fields_summary
+from processors.pdf_merge import source_file_for_page
+from processors.chunking_engine import (DEFAULT_CHUNK_LAYOUT, ChunkingEngine, validate_chunk_budget,
+                                        validate_chunk_layout, estimate_bucket_chunks)
+from processors.header_detection import detect_font_headers, normalize_header_text, validate_header_confidence
+from processors.links import resolve_page_links
+from processors.api_endpoints import collect_endpoints, endpoint_slug, render_endpoint, render_endpoint_index
 
 # Import utilities
-from utils.token_counter import TokenCounter
+from utils.token_counter import TokenCounter, TOKENIZERS, TIKTOKEN_ENCODINGS
 from utils.text_utils import TextUtils
 from utils.file_utils import FileUtils
+from utils.output_layout import OutputLayout
+from utils.frontmatter import SOURCE_FIELDS, drop_front_matter_fields, render_front_matter, split_front_matter
+from utils.corpus_index import corpus_document_id, update_corpus_index, relative_to_index
+from utils.output_conflict import (clean_managed_output, read_existing_manifest, remove_previous_output,
+                                   resolve_output_conflict)
+from utils.navigation import link_section_files
+from utils.temp_files import TEMP_FILES
+from utils.section_naming import DEFAULT_SECTION_NAMING, section_filename, section_filenames, validate_section_naming
+from utils.section_order import ORDER_MODES, order_sections_by_outline, section_pages, section_page_spans
+from utils.thumbnail import render_page_images
+from utils.section_cleanup import merge_empty_sections, trim_section_content
+from utils.sampling import select_sample_pages
+from utils.page_range import parse_page_range
+from utils.conversion_manifest import build_conversion_manifest
+from utils.heading_levels import normalize_headings, validate_heading_offset
+from utils.section_stats import format_section_size, section_stats
+from utils.output_limits import (LIMIT_DEFAULTS, MAX_IMAGE_BYTES, MAX_IMAGES_BYTES, MAX_OUTPUT_BYTES,
+                                 OutputLimitExceeded, bytes_written_since, check_limit, resolve_limit)
+from processors.image_variants import IMAGE_VARIANT_MODES, consolidate_image_variants, remove_replaced_files
+from processors.image_format import validate_image_format
+from processors.columns import validate_column_layout
+from processors.reading_order import validate_reading_order
+from processors.page_markers import (carry_trailing_markers, chunk_page_spans, mark_page, marker_page,
+                                     validate_page_markers)
+from processors.decorations import DEFAULT_UNDERLINE_MARKER, underline_markers
+from processors.equations import LOW_CONFIDENCE_NOTE, validate_math_mode
+from processors.rag_export import CHUNKS_JSONL, chunk_records, validate_vector_db_format, write_chunks_jsonl
+from processors.alt_text import apply_alt_text, validate_alt_mode
+from processors.streaming import (SECTIONS_JSONL, STREAMING_NOTE, SectionStreamWriter, stream_index, stream_sections,
+                                  validate_streaming_options)
+from utils.canonical import OUTPUT_MODES, canonicalize_markdown, rename_images_by_content
+from utils.cancellation import ConversionCancelled, check_cancelled
+from utils.staging import discard_staged_output, install_staged_output, rebase_staged_paths, staging_layout
+from utils.markup_formats import OUTPUT_FORMATS, output_filename, render_document
+from utils.pdf_password import PDFPasswordError, check_pdf_password, decrypt_pdf
+from utils.conversion_cache import conversion_cache_key, find_cached_conversion
+from utils.dependencies import MATH_PACKAGES, check_dependencies
+from utils.fingerprint import compute_fingerprint, hash_file
+from utils.progress import format_progress_line
+
+logger = logging.getLogger(__name__)
 
 class ModularPDFConverter:
     """
@@ -23,7 +76,8 @@ class ModularPDFConverter:
     into well-structured, LLM-optimized markdown with comprehensive analysis.
     """
     
-    def __init__(self, pdf_path: str, output_dir: str, options: Optional[Dict[str, Any]] = None):
+    def __init__(self, pdf_path: str, output_dir: str, options: Optional[Dict[str, Any]] = None,
+                 cancel_event=None):
         """
         Initialize the modular PDF converter
         
@@ -31,29 +85,82 @@ class ModularPDFConverter:
             pdf_path: Path to the PDF file
             output_dir: Output directory for all generated files
             options: Conversion options and settings
+            cancel_event: threading.Event; once set, convert() stops at the
+                next page or section and discards the files it wrote
         """
         self.pdf_path = Path(pdf_path)
         base_output_dir = Path(output_dir)
-        self.options = options or {}
-        
-        # Create a subdirectory based on the PDF filename
+        # The password is kept apart so it never travels with the options
+        self.options = {key: value for key, value in (options or {}).items() if key != 'password'}
+        self.password = (options or {}).get('password')
+        # File read during conversion: pdf_path, or a decrypted copy of it
+        self.source_path = self.pdf_path
+        self.decrypted_dir: Optional[str] = None
+        
+        # Create a subdirectory based on the PDF filename (placement controlled by output_layout)
         pdf_folder_name = FileUtils.sanitize_folder_name(self.pdf_path.name)
-        self.output_dir = base_output_dir / pdf_folder_name
-        
-        # Ensure output directory exists
-        FileUtils.ensure_directory(self.output_dir)
+        self.layout = OutputLayout(self.options.get('output_layout'), str(base_output_dir),
+                                   pdf_folder_name, doc_type='pdf')
+        self.output_dir = self.layout.document_root()
+        
+        # While convert() runs, layout and output_dir point into a staging directory
+        # that replaces the previous output only once the conversion succeeds (utils.staging)
+        self.cancel_event = cancel_event
+        self.final_layout = self.layout
+        self.staged: Optional[OutputLayout] = None
+        # The previous conversion is removed with the staged output installed (on_conflict='overwrite')
+        self.replace_previous = False
         
         # Initialize core utilities
-        self.token_counter = TokenCounter()
+        self.token_counter = TokenCounter(tokenizer=self.options.get('tokenizer'))
         
         # Store options for extraction
         self.extract_images = self.options.get('extract_images', True)
+        self.use_document_captions = self.options.get('use_document_captions', True)
+        self.canonical = self.options.get('output_mode', 'standard') == 'canonical'
+        self.output_format = self.options.get('output_format', 'markdown')
+        self.front_matter = self.options.get('frontmatter', True)
+        # File name convention for section files (utils.section_naming)
+        self.section_naming = self.options.get('section_naming') or DEFAULT_SECTION_NAMING
+        # Files combined into this PDF by convert_pdf_set, with their page spans
+        self.source_files: List[Dict[str, Any]] = self.options.get('source_files') or []
         
         # Skip processor initialization - using embedded approach for LLM optimization
         
         # Conversion state
         self.conversion_results = {}
         self.processing_stats = {}
+        self.warnings: List[str] = []
+        self.fingerprint: Optional[Dict[str, Any]] = None
+        self.signatures: Optional[Dict[str, Any]] = None
+        # AcroForm fields on the converted pages (extract_form_fields), None when not read
+        self.form_fields: Optional[List[Dict[str, Any]]] = None
+        self.sample: Optional[Dict[str, Any]] = None
+        self.page_range: Optional[Dict[str, Any]] = None
+        self.image_variants: List[Dict[str, Any]] = []
+        self.image_output: Optional[Dict[str, Any]] = None
+        # Struck-through and underlined passages marked (preserve_text_decorations), when any were found
+        self.text_decorations: Optional[Dict[str, int]] = None
+        # Display equations replaced by math_mode: mode, counts, and one entry per equation
+        self.equations: Optional[Dict[str, Any]] = None
+        self.front_matter_fields: List[str] = []
+        self.chunk_budget: Optional[tuple] = None
+        self.chunk_layout = DEFAULT_CHUNK_LAYOUT
+        # Import format chunks.jsonl records are also written in (pdf_to_rag.py), None for none
+        self.vector_db_format: Optional[str] = None
+        # Line before each page's text: 'comment', 'anchor', or 'none' (processors.page_markers)
+        self.page_markers = 'comment'
+        self.chunking: Optional[Dict[str, Any]] = None
+        self.csv_tables: List[Dict[str, Any]] = []
+        self.api_endpoints: Optional[Dict[str, Any]] = None
+        # Section files written as pages were extracted (streaming), with sections.jsonl
+        self.streaming: Optional[Dict[str, Any]] = None
+        self.min_header_confidence: Optional[float] = None
+        self.font_headers: Optional[Dict[str, Dict[str, Any]]] = None
+        # Size limits (set in convert) and bytes written so far against max_output_bytes
+        self.output_limits: Dict[str, Optional[int]] = {name: None for name in LIMIT_DEFAULTS}
+        self.output_bytes = 0
+        self.started_at = datetime.now().timestamp()
         
     def convert(self) -> Dict[str, Any]:
         """
@@ -64,36 +171,287 @@ class ModularPDFConverter:
         """
         print(f"Starting modular PDF conversion: {self.pdf_path.name}")
         start_time = datetime.now()
+        self.started_at = start_time.timestamp()
         
         try:
+            order_by = self.options.get('order_by', 'appearance')
+            if order_by not in ORDER_MODES:
+                raise ValueError(f"Unknown order_by '{order_by}' (expected one of: {', '.join(ORDER_MODES)})")
+            image_variants = self.options.get('image_variants', 'highest_resolution')
+            if image_variants not in IMAGE_VARIANT_MODES:
+                raise ValueError(f"Unknown image_variants '{image_variants}' "
+                                 f"(expected one of: {', '.join(IMAGE_VARIANT_MODES)})")
+            TableProcessor.table_thresholds(self.options)
+            column_layout = validate_column_layout(self.options.get('column_layout'))
+            reading_order = validate_reading_order(self.options.get('reading_order'))
+            self.page_markers = validate_page_markers(self.options.get('page_markers'))
+            if self.options.get('page_marker_labels') and self.page_markers != 'anchor':
+                raise ValueError("page_marker_labels requires page_markers 'anchor'")
+            math_mode = validate_math_mode(self.options.get('math_mode'))
+            if math_mode == 'latex':
+                # The image-to-LaTeX model is an extra dependency, only needed here
+                report = check_dependencies(MATH_PACKAGES)
+                if not report.ok:
+                    math_mode = 'image'
+                    self.warnings.append(f"math_mode 'latex' needs pix2tex, so equations are cropped as images "
+                                         f"instead: {'; '.join(report.problems)}")
+            validate_section_naming(self.section_naming)
+            alt_mode = validate_alt_mode(self.options.get('image_alt_mode'))
+            image_format, image_quality = validate_image_format(self.options.get('image_format'),
+                                                                self.options.get('image_quality'))
+            output_mode = self.options.get('output_mode', 'standard')
+            if output_mode not in OUTPUT_MODES:
+                raise ValueError(f"Unknown output_mode '{output_mode}' (expected one of: {', '.join(OUTPUT_MODES)})")
+            if self.output_format not in OUTPUT_FORMATS:
+                raise ValueError(f"Unknown output_format '{self.output_format}' "
+                                 f"(expected one of: {', '.join(OUTPUT_FORMATS)})")
+            if self.options.get('streaming'):
+                validate_streaming_options(self.options)
+            if self.options.get('chunk_tokens'):
+                self.chunk_budget = validate_chunk_budget(self.options['chunk_tokens'],
+                                                          self.options.get('chunk_overlap', 0))
+            elif self.options.get('chunk_overlap'):
+                raise ValueError("chunk_overlap requires chunk_tokens")
+            if self.options.get('chunks_jsonl') and not self.chunk_budget:
+                raise ValueError("chunks_jsonl requires chunk_tokens")
+            self.vector_db_format = validate_vector_db_format(self.options.get('vector_db_format'))
+            if self.vector_db_format and not self.options.get('chunks_jsonl'):
+                raise ValueError("vector_db_format requires chunks_jsonl")
+            self.chunk_layout = validate_chunk_layout(self.options.get('chunk_layout'))
+            tokenizer = self.options.get('tokenizer')
+            if tokenizer and tokenizer not in TOKENIZERS:
+                raise ValueError(f"Unknown tokenizer '{tokenizer}' (expected one of: {', '.join(TOKENIZERS)})")
+            if (tokenizer or self.chunk_budget) and self.token_counter.approximate \
+                    and self.token_counter.name in TIKTOKEN_ENCODINGS:
+                self.warnings.append(f"{self.token_counter.name} could not be loaded (is tiktoken installed?); "
+                                     f"token counts use {self.token_counter.tokenizer_name()}")
+            if self.options.get('min_header_confidence') is not None:
+                self.min_header_confidence = validate_header_confidence(self.options['min_header_confidence'])
+            validate_heading_offset(self.options.get('heading_offset', 0))
+            # Size limits in bytes (None: off), from the options or their environment variables
+            self.output_limits = {name: resolve_limit(name, self.options.get(name)) for name in LIMIT_DEFAULTS}
+            
+            # Encrypted PDFs are read from a decrypted copy outside the output directory
+            self.decrypt_source()
+            
+            # Refuse to mix this document's artifacts into another document's output
+            conflict = resolve_output_conflict(self.layout, FileUtils.document_id(self.pdf_path.name),
+                                               self.pdf_path.name, self.options.get('on_conflict'), remove=False)
+            if conflict:
+                self.warnings.append(conflict)
+                print(f"⚠️ {conflict}")
+                self.replace_previous = self.options.get('on_conflict') == 'overwrite'
+            
+            # Everything from here on is written to staging; clean_output applies once it succeeds
+            self.stage_output()
+            
+            # Memory guardrail: warn before extraction on constrained servers
+            memory_estimate = estimate_memory_usage(str(self.source_path))
+            self.processing_stats['memory_estimate'] = memory_estimate
+            if memory_estimate.get('warning'):
+                self.warnings.append(memory_estimate['warning'])
+                logger.warning(memory_estimate['warning'])
+            
+            # Only part of the document (optional); validated before anything is extracted
+            range_pages = None
+            if self.options.get('page_range'):
+                range_pages = parse_page_range(self.options['page_range'], memory_estimate['page_count'])
+                self.page_range = {
+                    'page_range': str(self.options['page_range']),
+                    'pages': range_pages,
+                    'page_count': memory_estimate['page_count']
+                }
+                print(f"Page range: {len(range_pages)} of {memory_estimate['page_count']} pages")
+            
+            # Trial run on a seeded, evenly spread subset of pages (optional; drawn from page_range when set)
+            if self.options.get('sample_pages'):
+                seed = int(self.options.get('sample_seed', 0) or 0)
+                if range_pages:
+                    pages = [range_pages[index - 1] for index in
+                             select_sample_pages(len(range_pages), self.options['sample_pages'], seed)]
+                else:
+                    pages = select_sample_pages(memory_estimate['page_count'], self.options['sample_pages'], seed)
+                self.sample = {
+                    'sample_pages': str(self.options['sample_pages']),
+                    'seed': seed,
+                    'pages': pages,
+                    'page_count': memory_estimate['page_count']
+                }
+                message = (f"Sample conversion: {len(pages)} of {memory_estimate['page_count']} pages "
+                           f"(seed {seed}) - not the full document")
+                self.warnings.append(message)
+                print(f"⚠️ {message}")
+            
+            # Bounded memory for very large documents: sections written as their pages are extracted
+            if self.options.get('streaming'):
+                self.convert_streaming(self.sample['pages'] if self.sample else range_pages,
+                                       memory_estimate['page_count'], column_layout, reading_order)
+                processing_time = (datetime.now() - start_time).total_seconds()
+                print(f"✅ Streaming conversion completed in {processing_time:.2f} seconds")
+                return self.finish(processing_time)
+            
             # Step 1: Extract content from PDF
+            check_cancelled(self.cancel_event)
             print("Step 1: Extracting PDF content...")
-            pdf_content = extract_all_content(str(self.pdf_path), str(self.output_dir), self.extract_images)
+            pdf_content = extract_all_content(str(self.source_path), str(self.layout.directory_for('images')),
+                                              self.extract_images, self.use_document_captions,
+                                              'preserve' if self.options.get('preserve_line_numbers') else 'strip',
+                                              self.sample['pages'] if self.sample else range_pages,
+                                              bool(self.options.get('reflow_paragraphs', False)),
+                                              progress=True, cancel_event=self.cancel_event,
+                                              image_dedup=bool(self.options.get('image_dedup', True)),
+                                              preserve_links=bool(self.options.get('preserve_links', True)),
+                                              detect_code_blocks=bool(self.options.get('detect_code_blocks', True)),
+                                              detect_lists=bool(self.options.get('detect_lists', True)),
+                                              preserve_text_decorations=bool(
+                                                  self.options.get('preserve_text_decorations', False)),
+                                              underline_marker=self.options.get('underline_marker',
+                                                                                DEFAULT_UNDERLINE_MARKER),
+                                              max_image_bytes=self.output_limits[MAX_IMAGE_BYTES],
+                                              max_images_bytes=self.output_limits[MAX_IMAGES_BYTES],
+                                              image_format=image_format, image_quality=image_quality,
+                                              column_layout=column_layout, reading_order=reading_order,
+                                              page_markers=self.page_markers,
+                                              page_marker_labels=bool(self.options.get('page_marker_labels', False)),
+                                              math_mode=math_mode,
+                                              equation_link=self.layout.relative_path(
+                                                  self.layout.directory_for('images'),
+                                                  self.layout.directory_for('sections')),
+                                              strip_headers_footers=bool(
+                                                  self.options.get('strip_headers_footers', False)))
+            self.check_output_size()
+            skipped_images = pdf_content.get('metadata', {}).get('skipped_images', [])
+            if skipped_images:
+                pages = ", ".join(str(page) for page in dict.fromkeys(image['page'] for image in skipped_images))
+                self.warnings.append(f"Skipped {len(skipped_images)} image(s) larger than max_image_bytes "
+                                     f"({self.output_limits[MAX_IMAGE_BYTES]:,} bytes) on page(s) {pages}")
+            
+            # Headings from font size and weight instead of text patterns (optional)
+            if self.min_header_confidence is not None:
+                self.font_headers = detect_font_headers(str(self.source_path),
+                                                        self.sample['pages'] if self.sample else range_pages,
+                                                        self.cancel_event)
+            
+            # Tables as CSV sidecar files for spreadsheets (optional)
+            if self.options.get('export_tables_csv'):
+                check_cancelled(self.cancel_event)
+                pdf_content['tables'] = self.export_tables_csv(self.sample['pages'] if self.sample else range_pages)
+            reflow_joins = pdf_content.get('metadata', {}).get('reflow_joins', [])
+            page_errors = pdf_content.get('metadata', {}).get('page_errors', [])
             self.processing_stats['pdf_extraction'] = {
                 'pages': len(pdf_content.get('pages', [])),
                 'images': len(pdf_content.get('images', [])),
                 'tables': len(pdf_content.get('tables', [])),
-                'characters': len(pdf_content.get('text', ''))
+                'characters': len(pdf_content.get('text', '')),
+                'line_numbered_pages': len(pdf_content.get('metadata', {}).get('line_number_pages', [])),
+                'reflow_joins': reflow_joins,
+                'failed_pages': [error['page'] for error in page_errors],
+                'column_pages': pdf_content.get('metadata', {}).get('column_pages', []),
+                'reordered_pages': pdf_content.get('metadata', {}).get('reordered_pages', []),
+                'rtl_pages': pdf_content.get('metadata', {}).get('rtl_pages', []),
+                'furniture_lines': pdf_content.get('metadata', {}).get('furniture_lines', 0),
+                'extraction_method': pdf_content.get('metadata', {}).get('extraction_method', 'pymupdf')
             }
+            if self.processing_stats['pdf_extraction']['extraction_method'] == 'basic':
+                self.warnings.append("Most pages failed full extraction, so plain text extraction was used "
+                                     "(no code blocks, lists, revision marks, links, reflow, or line-number handling)")
+            if page_errors:
+                pages = ", ".join(str(error['page']) for error in page_errors[:10])
+                more = f" and {len(page_errors) - 10} more" if len(page_errors) > 10 else ""
+                self.warnings.append(f"Could not extract {len(page_errors)} page(s): {pages}{more}; their text is "
+                                     f"missing (marked <!-- extraction error on page N -->). First error: "
+                                     f"{page_errors[0]['error']}")
+            if reflow_joins:
+                words = ", ".join(join['word'] for join in reflow_joins[:5])
+                more = f" and {len(reflow_joins) - 5} more" if len(reflow_joins) > 5 else ""
+                self.warnings.append(f"Joined {len(reflow_joins)} word(s) split across column/page breaks: {words}{more}")
+            self.fingerprint = pdf_content.get('metadata', {}).get('fingerprint')
+            code_blocks = pdf_content.get('metadata', {}).get('code_block_count', 0)
+            if code_blocks:
+                self.processing_stats['code_blocks'] = code_blocks
+            lists = pdf_content.get('metadata', {}).get('list_count', 0)
+            if lists:
+                self.processing_stats['lists'] = lists
+            decorations = pdf_content.get('metadata', {}).get('decorations') or {}
+            if any(decorations.values()):
+                self.text_decorations = dict(decorations)
+                self.processing_stats['text_decorations'] = self.text_decorations
+            self.record_equations(math_mode, pdf_content.get('metadata', {}))
+            duplicates = sum(1 for image in pdf_content.get('images', []) if image.get('duplicate'))
+            if duplicates:
+                self.processing_stats['image_dedup'] = {
+                    'duplicates': duplicates,
+                    'files_written': len({image['file'] for image in pdf_content['images']})
+                }
+            image_bytes = pdf_content.get('metadata', {}).get('image_bytes')
+            if image_bytes and image_bytes['original']:
+                self.image_output = {
+                    'format': image_format,
+                    'quality': image_quality if image_format in ('jpeg', 'webp') else None,
+                    'bytes': image_bytes['written'],
+                    'original_bytes': image_bytes['original'],
+                    'saved_bytes': image_bytes['original'] - image_bytes['written'],
+                    'kept_original': image_bytes.get('kept_smaller', 0)
+                }
+                self.processing_stats['image_output'] = self.image_output
+                if image_bytes['unconverted']:
+                    self.warnings.append(f"{image_bytes['unconverted']} image(s) could not be decoded and were kept "
+                                         f"in their embedded format instead of {image_format}")
+            
+            # One file per picture when the PDF embeds it at several resolutions
+            if image_variants == 'highest_resolution' and pdf_content.get('images'):
+                self.consolidate_image_variants(pdf_content)
+            
+            # Canonical output: image names follow content, not page position
+            if self.canonical and pdf_content.get('images'):
+                rename_images_by_content(pdf_content['images'])
+            for image in pdf_content.get('images', []):
+                apply_alt_text(image, alt_mode)
+            
+            # Signature metadata (presence only - no cryptographic verification)
+            if self.options.get('extract_signatures'):
+                try:
+                    self.signatures = extract_signatures(str(self.source_path))
+                except Exception as e:
+                    self.warnings.append(f"Could not read signature metadata: {e}")
+            
+            # Form field names and values, which the page text does not carry
+            if self.options.get('extract_form_fields'):
+                converted_pages = self.sample['pages'] if self.sample else range_pages
+                try:
+                    self.form_fields = [field for field in extract_form_fields(str(self.source_path))
+                                        if not converted_pages or field['page'] in (None, *converted_pages)]
+                except Exception as e:
+                    self.warnings.append(f"Could not read form fields: {e}")
             
             # Step 2: Structure content into sections
+            check_cancelled(self.cancel_event)
             print("Step 2: Structuring content into sections...")
             sections = self.structure_content_into_sections(pdf_content)
             self.processing_stats['sections'] = len(sections)
             
             # Skip complex processors - tables, concepts, cross-refs now embedded in sections
             # Skip separate chunking - handled during section generation if needed
-            self.conversion_results['tables'] = {'processed_tables': [], 'table_files': []}
+            self.conversion_results.setdefault('tables', {'processed_tables': [], 'table_files': []})
             self.conversion_results['concepts'] = {}
             self.conversion_results['cross_references'] = {}
             self.conversion_results['summaries'] = {}
             self.conversion_results['chunks'] = {'chunk_files': [], 'total_chunks': 0}
             
             # Step 3: Generate LLM-optimized markdown files  
+            check_cancelled(self.cancel_event)
             print("Step 3: Generating LLM-optimized markdown files...")
             markdown_files = self.generate_main_markdown_files(sections, pdf_content)
             self.conversion_results['markdown_files'] = markdown_files
             
+            # Keyword index from bold/italic terms (optional)
+            if self.options.get('extract_keywords'):
+                print("Extracting emphasized keywords...")
+                keywords_file = self.generate_keywords_file(self.sample['pages'] if self.sample else range_pages)
+                self.conversion_results['markdown_files'].append(str(keywords_file))
+            
+            self.check_output_size()
+            
             # Skip master index - replaced with document map
             
             # Skip metadata generation - not needed for LLM-optimized content
@@ -104,24 +462,63 @@ class ModularPDFConverter:
             print(f"✅ Conversion completed in {processing_time:.2f} seconds")
             print(f"📄 Generated {len(self.get_all_generated_files()):,} files total")
             
-            # Final results
-            final_results = {
-                'success': True,
+            return self.finish(processing_time)
+            
+        except ConversionCancelled:
+            processing_time = (datetime.now() - start_time).total_seconds()
+            removed = self.discard_output()
+            print(f"Conversion cancelled after {processing_time:.2f} seconds; removed {len(removed)} partial files")
+            
+            return {
+                'success': False,
+                'cancelled': True,
                 'pdf_file': str(self.pdf_path),
                 'output_directory': str(self.output_dir),
                 'processing_time_seconds': processing_time,
-                'conversion_results': self.conversion_results,
-                'processing_stats': self.processing_stats,
-                'generated_files': self.get_all_generated_files(),
-                'file_count': len(self.get_all_generated_files())
+                'error': 'Conversion cancelled',
+                'error_type': 'ConversionCancelled',
+                'removed_files': removed,
+                'warnings': self.warnings
+            }
+            
+        except OutputLimitExceeded as e:
+            processing_time = (datetime.now() - start_time).total_seconds()
+            removed = self.discard_output()
+            print(f"Conversion stopped: {e}; removed {len(removed)} partial files")
+            
+            return {
+                'success': False,
+                'pdf_file': str(self.pdf_path),
+                'output_directory': str(self.output_dir),
+                'processing_time_seconds': processing_time,
+                'error': str(e),
+                'error_type': type(e).__name__,
+                'error_code': e.code,
+                'limit': e.limit,
+                'limit_bytes': e.limit_bytes,
+                'removed_files': removed,
+                'warnings': self.warnings
             }
             
-            return final_results
+        except PDFPasswordError as e:
+            print(f"Conversion failed: {e}")
+            self.discard_output()
+            return {
+                'success': False,
+                'pdf_file': str(self.pdf_path),
+                'output_directory': str(self.output_dir),
+                'processing_time_seconds': (datetime.now() - start_time).total_seconds(),
+                'error': str(e),
+                'error_type': type(e).__name__,
+                'error_code': e.code,
+                'warnings': self.warnings
+            }
             
         except Exception as e:
             import traceback
             error_time = datetime.now()
             processing_time = (error_time - start_time).total_seconds()
+            self.discard_output()
             
             print(f"Conversion failed after {processing_time:.2f} seconds: {str(e)}")
             traceback.print_exc()
@@ -134,8 +531,192 @@ class ModularPDFConverter:
                 'error': str(e),
                 'error_type': type(e).__name__,
                 'partial_results': self.conversion_results,
-                'processing_stats': self.processing_stats
+                'processing_stats': self.processing_stats,
+                'warnings': self.warnings
+            }
+        
+        finally:
+            if self.staged:
+                self.discard_output()
+            if self.decrypted_dir:
+                TEMP_FILES.release(self.decrypted_dir)
+    
+    def stage_output(self) -> None:
+        """Point layout and output_dir at a new staging directory for this run's files"""
+        self.staged = staging_layout(self.final_layout)
+        self.layout = self.staged
+        self.output_dir = self.layout.document_root()
+        FileUtils.ensure_directory(self.output_dir)
+    
+    def discard_output(self) -> List[str]:
+        """Delete the staged files of a run that did not succeed; the previous output stays as it was"""
+        removed = discard_staged_output(self.staged) if self.staged else []
+        self.layout, self.output_dir, self.staged = self.final_layout, self.final_layout.document_root(), None
+        return removed
+    
+    def finish(self, processing_time: float) -> Dict[str, Any]:
+        """
+        Install the staged output and return the result of the successful conversion
+        
+        Only now is the previous output removed (on_conflict='overwrite',
+        clean_output); the staged files then take their place, and the
+        corpus index is updated to point at them.
+        """
+        result = self.conversion_result(processing_time)
+        staged, layout = self.staged, self.final_layout
+        
+        if self.replace_previous:
+            removed = remove_previous_output(layout, read_existing_manifest(layout) or {})
+            print(f"Removed {removed} files of the previous conversion (on_conflict=overwrite)")
+        # Start from empty artifact directories so no stale files from a previous run remain (optional)
+        if self.options.get('clean_output'):
+            cleaned = clean_managed_output(layout)
+            self.processing_stats['clean_output'] = {
+                'directories_removed': len(cleaned['directories']),
+                'files_removed': cleaned['files']
             }
+            print(f"Removed previous output: {len(cleaned['directories'])} directories, {cleaned['files']} files")
+        install_staged_output(staged, layout)
+        self.layout, self.output_dir, self.staged = layout, layout.document_root(), None
+        
+        # Record this document in the shared corpus index (optional)
+        if self.options.get('corpus_index_path'):
+            self.record_in_corpus_index(self.options['corpus_index_path'], self.processing_stats.get('sections', 0))
+        
+        return rebase_staged_paths(result, staged, layout)
+    
+    def conversion_result(self, processing_time: float) -> Dict[str, Any]:
+        """Result of a successful conversion"""
+        final_results = {
+            'success': True,
+            'pdf_file': str(self.pdf_path),
+            'output_directory': str(self.output_dir),
+            'sections_directory': str(self.layout.directory_for('sections')),
+            'processing_time_seconds': processing_time,
+            'conversion_results': self.conversion_results,
+            'processing_stats': self.processing_stats,
+            'warnings': self.warnings,
+            'fingerprint': self.fingerprint,
+            'signatures': self.signatures,
+            'form_fields': form_fields_summary(self.form_fields) if self.form_fields is not None else None,
+            'sample': self.sample,
+            'page_range': self.page_range,
+            'front_matter_fields': self.front_matter_fields,
+            'chunking': self.chunking,
+            'api_endpoints': self.api_endpoints,
+            'streaming': self.streaming,
+            'generated_files': self.get_all_generated_files(),
+            'file_count': len(self.get_all_generated_files()),
+            # Every file this run wrote (kind, size, token estimate); the staging directory holds only those
+            'conversion_manifest': build_conversion_manifest(
+                self.layout, self.get_all_generated_files(), self.token_counter).to_dict()
+        }
+        if self.chunking and self.chunking.get('jsonl'):
+            final_results['conversion_manifest']['chunks_jsonl'] = {'path': self.chunking['jsonl'],
+                                                                    'chunks': self.chunking['jsonl_chunks']}
+            if self.chunking.get('vector_db_format'):
+                final_results['conversion_manifest']['chunks_jsonl']['vector_db_format'] = \
+                    self.chunking['vector_db_format']
+        
+        return final_results
+    
+    def convert_streaming(self, pages: Optional[List[int]], page_count: int, column_layout: str,
+                          reading_order: str) -> None:
+        """
+        Extract and write in one pass, each section file as soon as its last page is in
+        
+        Memory holds one section's text at a time (see processors.streaming).
+        Writes README.md, manifest.json, the section files, and sections.jsonl;
+        a PROGRESS line goes out as each section file lands.
+        """
+        check_cancelled(self.cancel_event)
+        print("Streaming: extracting pages and writing each section as it completes...")
+        pages = sorted(pages) if pages else list(range(1, page_count + 1))
+        sections = stream_sections(extract_outline(str(self.source_path)), pages)
+        extractor = PDFExtractor({
+            'line_numbers': 'preserve' if self.options.get('preserve_line_numbers') else 'strip',
+            'pages': pages,
+            'cancel': self.cancel_event,
+            'column_layout': column_layout,
+            'reading_order': reading_order,
+            'preserve_links': bool(self.options.get('preserve_links', True)),
+            'detect_code_blocks': bool(self.options.get('detect_code_blocks', True)),
+            'detect_lists': bool(self.options.get('detect_lists', True)),
+            'preserve_text_decorations': bool(self.options.get('preserve_text_decorations', False)),
+            'underline_marker': self.options.get('underline_marker', DEFAULT_UNDERLINE_MARKER),
+            'strip_headers_footers': bool(self.options.get('strip_headers_footers', False))
+        })
+        
+        def on_file(entry: Dict[str, Any]) -> None:
+            self.check_output_size(entry['bytes'])
+            print(format_progress_line(writer.pages_added, len(pages), f"wrote {entry['file']}"), flush=True)
+        
+        jsonl_path = self.layout.path_for('root', SECTIONS_JSONL)
+        writer = SectionStreamWriter(self.layout.directory_for('sections'), jsonl_path, sections,
+                                     self.section_naming, self.pdf_path.name, self.layout.relative_path,
+                                     self.token_counter, self.front_matter, on_file)
+        text_hashes = []
+        characters = 0
+        for page_num, text, _, text_hash in extractor.iter_page_texts(str(self.source_path)):
+            # No other section file is known yet, so #page-N links become their text
+            text, _, _ = resolve_page_links(extractor.process_text(text), {})
+            writer.add_page(page_num, mark_page(text, page_num, self.page_markers,
+                                                bool(self.options.get('page_marker_labels', False))))
+            text_hashes.append(text_hash)
+            characters += len(text)
+        entries = writer.close()
+        
+        if not self.sample and not self.page_range:
+            self.fingerprint = compute_fingerprint(str(self.source_path), text_hashes)
+        page_errors = extractor.page_errors
+        self.processing_stats['pdf_extraction'] = {
+            'pages': writer.pages_added,
+            'images': 0,
+            'tables': 0,
+            'characters': characters,
+            'failed_pages': [error['page'] for error in page_errors],
+            'column_pages': extractor.column_pages,
+            'reordered_pages': extractor.reordered_pages,
+            'rtl_pages': extractor.rtl_pages,
+            'furniture_lines': extractor.furniture_lines,
+            'extraction_method': 'pymupdf'
+        }
+        self.processing_stats['sections'] = len(entries)
+        if page_errors:
+            pages_failed = ", ".join(str(error['page']) for error in page_errors[:10])
+            more = f" and {len(page_errors) - 10} more" if len(page_errors) > 10 else ""
+            self.warnings.append(f"Could not extract {len(page_errors)} page(s): {pages_failed}{more}; their text is "
+                                 f"missing (marked <!-- extraction error on page N -->). First error: "
+                                 f"{page_errors[0]['error']}")
+        if entries:
+            self.front_matter_fields = list(split_front_matter(
+                (self.output_dir / entries[0]['file']).read_text(encoding='utf-8'))[0])
+        self.streaming = {
+            'sections': len(entries),
+            'jsonl': self.layout.relative_path(jsonl_path),
+            'skipped': STREAMING_NOTE
+        }
+        
+        readme = stream_index(self.pdf_path.stem, entries)
+        if self.front_matter:
+            readme = render_front_matter(self.document_front_matter({}, pages[0], pages[-1])) + readme
+        readme_file = self.layout.path_for('root', "README.md")
+        FileUtils.write_markdown(readme, readme_file)
+        manifest_file = self.write_manifest(entries)
+        self.conversion_results['markdown_files'] = (
+            [str(readme_file)] + [str(self.output_dir / entry['file']) for entry in entries]
+            + [str(jsonl_path), str(manifest_file)])
+        self.check_output_size()
+    
+    def decrypt_source(self) -> None:
+        """Point source_path at a decrypted copy when the PDF needs a password (raises PDFPasswordError)"""
+        self.decrypted_dir = TEMP_FILES.mkdtemp(prefix="pdf-decrypted-")
+        decrypted_path = Path(self.decrypted_dir) / self.pdf_path.name
+        if decrypt_pdf(str(self.pdf_path), self.password, str(decrypted_path)):
+            self.source_path = decrypted_path
+            print("Encrypted PDF: reading a decrypted copy")
+        elif self.password:
+            self.warnings.append("password ignored: the PDF is not encrypted")
     
     def structure_content_into_sections(self, pdf_content: Dict[str, Any]) -> List[Dict[str, Any]]:
         """Structure the extracted PDF content into logical sections"""
@@ -149,6 +730,8 @@ class ModularPDFConverter:
         outline = structure.get('outline', [])
         if outline:
             sections = self.structure_by_outline(text, outline, pages)
+        elif self.font_headers is not None:
+            sections = self.structure_by_font_headers(text)
         else:
             # Fallback to header-based structuring
             sections = self.structure_by_headers(text, pages)
@@ -157,14 +740,128 @@ class ModularPDFConverter:
         if not sections or len(sections) < 2:
             sections = self.structure_by_pages(pages)
         
+        # A page that starts with a heading starts with that section, not at the end of the one before
+        if self.page_markers != 'none':
+            carry_trailing_markers(sections)
+        
+        # Follow the bookmark tree instead of physical page order (optional)
+        if self.options.get('order_by', 'appearance') == 'outline':
+            sections = self.order_sections_by_outline(sections, outline)
+        
+        # Trim whitespace; fold heading-only sections into the next one (optional)
+        if self.options.get('drop_empty_sections', True):
+            media_pages = {item['page'] for item in pdf_content.get('images', []) + pdf_content.get('tables', [])
+                           if item.get('page')}
+            sections = self.merge_empty_sections(sections, media_pages)
+        else:
+            for section in sections:
+                section['content'] = trim_section_content(section.get('content', ''))
+        
+        # Form fields get a section of their own; a PDF without any gets none
+        if self.form_fields:
+            sections.append({
+                'title': FORM_FIELDS_TITLE,
+                'content': form_fields_markdown(self.form_fields),
+                'level': 1,
+                'source': 'form_fields'
+            })
+        
         # Add section metadata
         for i, section in enumerate(sections):
             section['section_id'] = i + 1
-            section['token_count'] = self.token_counter.count_tokens(section.get('content', ''))
+            section.update(section_stats(section.get('content', ''), self.token_counter))
             section['section_type'] = self.classify_section_type(section)
+            section['content_type'] = TextUtils.classify_content_type(section.get('content', ''))
+        
+        self.assign_images_to_sections(sections, pdf_content.get('images', []))
+        self.assign_images_to_sections(sections, pdf_content.get('tables', []), key='tables')
         
         return sections
     
+    def order_sections_by_outline(self, sections: List[Dict[str, Any]], outline: List[Dict]) -> List[Dict[str, Any]]:
+        """Reorder sections to the outline; unmapped sections keep appearance order"""
+        if not outline:
+            try:
+                outline = extract_outline(str(self.source_path))
+            except Exception as e:
+                self.warnings.append(f"Could not read the outline, keeping appearance order: {e}")
+                return sections
+        if not outline:
+            self.warnings.append("order_by=outline: the PDF has no outline, keeping appearance order")
+            return sections
+        
+        ordered, unmapped = order_sections_by_outline(sections, outline)
+        for section in unmapped:
+            self.warnings.append(f"Section '{section.get('title', 'Untitled')}' matches no outline entry, kept in appearance order")
+        self.processing_stats['section_order'] = {
+            'order_by': 'outline',
+            'outline_entries': len(outline),
+            'unmapped_sections': len(unmapped)
+        }
+        return ordered
+    
+    def merge_empty_sections(self, sections: List[Dict[str, Any]], media_pages=()) -> List[Dict[str, Any]]:
+        """Merge heading-only sections forward and report what changed"""
+        kept, report = merge_empty_sections(sections, media_pages)
+        if not kept:
+            return sections  # Nothing but headings: keep them rather than emit no sections
+        for entry in report:
+            if entry['action'] == 'merged':
+                self.warnings.append(f"Merged heading-only section '{entry['title']}' into '{entry['into']}'")
+            else:
+                self.warnings.append(f"Dropped heading-only section '{entry['title']}'")
+        self.processing_stats['empty_sections'] = {
+            'merged': sum(1 for entry in report if entry['action'] == 'merged'),
+            'dropped': sum(1 for entry in report if entry['action'] == 'dropped')
+        }
+        return kept
+    
+    def export_tables_csv(self, pages: Optional[List[int]]) -> List[Dict[str, Any]]:
+        """Write each detected table to tables/table_pN_M.csv; returns the tables with their file"""
+        tables, discarded = TableProcessor.filter_table_candidates(
+            extract_tables_with_pdfplumber(str(self.source_path), pages), *TableProcessor.table_thresholds(self.options))
+        for candidate in discarded:
+            self.warnings.append(f"Discarded candidate table on page {candidate['page']} "
+                                 f"(#{candidate['index'] + 1}): {candidate['reason']}")
+        
+        if tables:
+            FileUtils.ensure_directory(self.layout.directory_for('tables'))
+        for table in tables:
+            csv_file = self.layout.path_for('tables', TableProcessor.csv_filename(table))
+            table['file'] = str(TableProcessor.write_table_csv(table, csv_file))
+        
+        self.csv_tables = tables
+        self.conversion_results['tables'] = {'processed_tables': [], 'table_files': [table['file'] for table in tables]}
+        self.processing_stats['tables_csv'] = {'exported': len(tables), 'discarded': len(discarded),
+                                               'discarded_tables': discarded}
+        return tables
+    
+    def consolidate_image_variants(self, pdf_content: Dict[str, Any]) -> None:
+        """Replace lower-resolution copies of an image with the highest-resolution one"""
+        images, self.image_variants = consolidate_image_variants(pdf_content['images'])
+        if not self.image_variants:
+            return
+        pdf_content['images'] = images
+        removed = remove_replaced_files(self.image_variants)
+        self.processing_stats['image_variants'] = {
+            'consolidated': len(self.image_variants),
+            'files_removed': removed
+        }
+    
+    def assign_images_to_sections(self, sections: List[Dict[str, Any]], images: List[Dict[str, Any]],
+                                  key: str = 'images') -> None:
+        """Attach each extracted image (or table, with key='tables') to the first section covering its page"""
+        for section in sections:
+            section[key] = []
+        
+        for image in images:
+            for section in sections:
+                section_pages = section.get('pages') or ([section['page']] if section.get('page') else [])
+                if image['page'] in section_pages:
+                    image['section_id'] = section['section_id']
+                    section[key].append(image)
+                    break
+    
     def structure_by_outline(self, text: str, outline: List[Dict], pages: List[Dict]) -> List[Dict[str, Any]]:
         """Structure content using PDF outline/bookmarks"""
         sections = []
@@ -226,6 +923,41 @@ class ModularPDFConverter:
         
         return sections
     
+    def structure_by_font_headers(self, text: str) -> List[Dict[str, Any]]:
+        """Structure content at lines whose font marks them as headings (min_header_confidence)"""
+        sections = []
+        current_section = {
+            'title': 'Introduction',
+            'content': '',
+            'level': 1,
+            'source': 'font_headers'
+        }
+        
+        for line in text.split('\n'):
+            header = self.font_headers.get(normalize_header_text(line))
+            if header and header['confidence'] >= self.min_header_confidence:
+                if current_section['content'].strip():
+                    sections.append(current_section)
+                current_section = {
+                    'title': normalize_header_text(line),
+                    'content': '',
+                    'level': header['level'],
+                    'page': header['page'],
+                    'header_confidence': header['confidence'],
+                    'source': 'font_headers'
+                }
+            else:
+                current_section['content'] += line + '\n'
+        
+        if current_section['content'].strip():
+            sections.append(current_section)
+        
+        self.processing_stats['font_headers'] = {
+            'candidates': len(self.font_headers),
+            'headings': sum(1 for section in sections if 'header_confidence' in section)
+        }
+        return sections
+    
     def structure_by_pages(self, pages: List[Dict]) -> List[Dict[str, Any]]:
         """Fallback: create sections based on pages"""
         sections = []
@@ -274,6 +1006,10 @@ class ModularPDFConverter:
         title = section.get('title', '').lower()
         content = section.get('content', '').lower()
         
+        # The form field table would otherwise read as a data format
+        if section.get('source') == 'form_fields':
+            return 'form_fields'
+        
         # Classification based on title keywords
         if any(term in title for term in ['introduction', 'overview', 'getting started']):
             return 'introduction'
@@ -305,7 +1041,13 @@ class ModularPDFConverter:
         return 'content'
     
     def generate_semantic_filename(self, section: Dict[str, Any], section_index: int) -> str:
-        """Generate semantic filename based on section type and content"""
+        """Filename for one section with section_naming (assign_section_filenames makes them unique)"""
+        return section_filename(self.section_naming, section_index, section_index,
+                                self.semantic_slug(section, section_index),
+                                section.get('title', ''), section.get('content', ''))
+    
+    def semantic_slug(self, section: Dict[str, Any], section_index: int) -> str:
+        """Semantic base name from the section type, else the title"""
         section_type = self.classify_section_type(section)
         title = section.get('title', f'section-{section_index}')
         
@@ -321,57 +1063,477 @@ class ModularPDFConverter:
             'reference': 'reference',
             'data_formats': 'data-formats',
             'configuration': 'configuration',
+            'form_fields': 'form-fields',
             'content': FileUtils.safe_filename(title)
         }
         
-        base_name = semantic_names.get(section_type, FileUtils.safe_filename(title))
-        return f"{section_index:02d}-{base_name}.md"
+        return semantic_names.get(section_type) or FileUtils.safe_filename(title) or f"section-{section_index}"
+    
+    def assign_section_filenames(self, sections: List[Dict[str, Any]]) -> None:
+        """
+        Give each section a file name, unique within the sections directory
+        
+        Stored as section['filename'] so the section files, the README
+        navigation, and related-section links all use the same name.
+        """
+        filenames = section_filenames(self.section_naming,
+                                      [self.semantic_slug(section, i + 1) for i, section in enumerate(sections)],
+                                      [section.get('title', '') for section in sections],
+                                      [section.get('content', '') for section in sections])
+        for section, filename in zip(sections, filenames):
+            section['filename'] = filename
+    
+    def resolve_internal_links(self, sections: List[Dict[str, Any]], link_count: int) -> None:
+        """Point internal [text](#page-N) links at the section file covering page N (at its anchor, if any)"""
+        page_files = {}
+        for section in sections:
+            if section.get('page_start'):
+                for page in range(section['page_start'], (section.get('page_end') or section['page_start']) + 1):
+                    page_files.setdefault(page, f"{section['filename']}#page-{page}"
+                                          if self.page_markers == 'anchor' else section['filename'])
+        
+        resolved = unwrapped = 0
+        for section in sections:
+            section['content'], section_resolved, section_unwrapped = resolve_page_links(
+                section.get('content', ''), page_files)
+            resolved += section_resolved
+            unwrapped += section_unwrapped
+        self.processing_stats['links'] = {
+            'links': link_count,
+            'internal_resolved': resolved,
+            'internal_unconverted_pages': unwrapped
+        }
+    
+    def check_output_size(self, added: int = 0) -> None:
+        """
+        Stop once this conversion has written more than max_output_bytes
+        
+        Without added, the output directories are measured (files written
+        since the conversion started); with it, added bytes just written are
+        counted on top of the last measurement.
+        """
+        limit = self.output_limits[MAX_OUTPUT_BYTES]
+        if limit is None:
+            return
+        if added:
+            self.output_bytes += added
+        else:
+            directories = [self.output_dir] + [self.layout.directory_for(kind) for kind in OutputLayout.ARTIFACT_TYPES]
+            self.output_bytes = bytes_written_since(directories, self.started_at)
+        check_limit(MAX_OUTPUT_BYTES, limit, self.output_bytes)
+    
+    def section_filename(self, section: Dict[str, Any], section_index: int) -> str:
+        """The section's assigned file name, else its generated one"""
+        return section.get('filename') or self.generate_semantic_filename(section, section_index)
     
     def generate_main_markdown_files(self, sections: List[Dict[str, Any]], 
                                    pdf_content: Dict[str, Any]) -> List[str]:
         """Generate the main markdown files for LLM agents"""
         generated_files = []
         
+        # Page spans for front-matter (sections found by heading only know their first page)
+        converted_pages = [page['page_num'] for page in pdf_content.get('pages', []) if page.get('page_num')]
+        for section, (page_start, page_end) in zip(sections, section_page_spans(
+                sections, max(converted_pages) if converted_pages else None)):
+            section['page_start'], section['page_end'] = page_start, page_end
+        self.assign_section_filenames(sections)
+        if self.options.get('preserve_links', True):
+            self.resolve_internal_links(sections, pdf_content.get('metadata', {}).get('link_count', 0))
+        
         # Generate README as the navigation entry point (standard convention)
         document_map = self.create_document_map(sections, pdf_content)
-        readme_file = self.output_dir / "README.md"
-        FileUtils.write_markdown(document_map, readme_file)
+        if self.front_matter:
+            document_map = render_front_matter(self.document_front_matter(
+                pdf_content, min(converted_pages) if converted_pages else None,
+                max(converted_pages) if converted_pages else None)) + document_map
+        if self.canonical:
+            document_map = canonicalize_markdown(document_map)
+        readme_file = self.layout.path_for('root', output_filename("README.md", self.output_format))
+        FileUtils.write_markdown(render_document(document_map, self.output_format), readme_file)
         generated_files.append(str(readme_file))
         
         # Generate individual section files (optimized for LLM processing)
-        sections_dir = self.output_dir / "sections"
-        FileUtils.ensure_directory(sections_dir)
-        
+        FileUtils.ensure_directory(self.layout.directory_for('sections'))
+        manifest_sections = []
+        # First file of each section, for links back from other artifacts
+        section_files: Dict[Any, Path] = {}
+        
+        # Render every section first so navigation links can name the files that follow
+        section_outputs = []
+        # Pages each file's text came from (a split part covers only its own)
+        file_pages = []
         for i, section in enumerate(sections):
             section_md = self.create_section_markdown(section, i + 1, sections)
-            semantic_filename = self.generate_semantic_filename(section, i + 1)
+            semantic_filename = self.section_filename(section, i + 1)
             
             # Check if section is too large (>32k tokens - modern LLM context window)
             token_count = self.token_counter.count_tokens(section_md)
             if token_count > 32000:
                 # Split large section into multiple parts
-                section_parts = self.split_large_section(section_md, section.get('title', f'Section {i+1}'))
-                for part_idx, part_content in enumerate(section_parts):
-                    base_name = semantic_filename.replace('.md', '')
-                    part_file = sections_dir / f"{base_name}-part{part_idx+1:02d}.md"
-                    FileUtils.write_markdown(part_content, part_file)
-                    generated_files.append(str(part_file))
+                section_parts = self.split_large_section(section_md, section.get('title', f'Section {i+1}'), section)
+                base_name = semantic_filename.replace('.md', '')
+                section_outputs.append([(f"{base_name}-part{part_idx+1:02d}.md", part_content)
+                                        for part_idx, (part_content, _) in enumerate(section_parts)])
+                file_pages.append([part_pages for _, part_pages in section_parts])
             else:
                 # Section is manageable size
-                section_file = sections_dir / semantic_filename
-                FileUtils.write_markdown(section_md, section_file)
+                section_outputs.append([(semantic_filename, section_md)])
+                file_pages.append([section_pages(section)])
+        
+        # Extra front-matter per file: navigation links and source page renders
+        extra_fields = [[{} for _ in files] for files in section_outputs]
+        if self.options.get('section_links', True):
+            links = link_section_files([[name for name, _ in files] for files in section_outputs],
+                                       [section.get('level', 1) for section in sections])
+            for fields, section_links in zip(extra_fields, links):
+                for file_fields, file_links in zip(fields, section_links):
+                    file_fields.update(file_links)
+        
+        page_images: Dict[int, Path] = {}
+        if self.options.get('render_full_pages'):
+            page_images = self.render_source_pages(sections)
+            for fields, pages in zip(extra_fields, file_pages):
+                for file_fields, pages_of_file in zip(fields, pages):
+                    file_fields.update(self.source_page_fields(pages_of_file, page_images))
+        
+        if any(file_fields for fields in extra_fields for file_fields in fields):
+            section_outputs = [
+                [(name, self.add_front_matter_fields(content, file_fields))
+                 for (name, content), file_fields in zip(files, fields)]
+                for files, fields in zip(section_outputs, extra_fields)
+            ]
+        
+        for section, files in zip(sections, section_outputs):
+            for filename, content in files:
+                check_cancelled(self.cancel_event)
+                if not self.front_matter:
+                    content = drop_front_matter_fields(content, SOURCE_FIELDS)
+                for key in split_front_matter(content)[0]:
+                    if key not in self.front_matter_fields:
+                        self.front_matter_fields.append(key)
+                # One H1 per file: the section title; page-text headings are demoted under it
+                if self.options.get('normalize_headings', True):
+                    content = normalize_headings(content, self.options.get('heading_offset', 0))
+                if self.canonical:
+                    content = canonicalize_markdown(content)
+                content = render_document(content, self.output_format)
+                section_file = self.layout.path_for('sections', output_filename(filename, self.output_format))
+                FileUtils.write_markdown(content, section_file)
+                self.check_output_size(len(content.encode('utf-8')))
                 generated_files.append(str(section_file))
+                manifest_sections.append(self.create_manifest_entry(section, section_file))
+                section_files.setdefault(section.get('section_id'), section_file)
+        
+        # One file per API endpoint the text describes, with an index (optional)
+        if self.options.get('extract_api_endpoints', True):
+            generated_files.extend(self.write_api_endpoints(sections, section_files))
+        
+        # Fixed token-budget chunks for retrieval pipelines (optional)
+        if self.chunk_budget:
+            self.create_budget_chunks(sections)
+        
+        generated_files.extend(dict.fromkeys(image['file'] for image in pdf_content.get('images', [])))
+        generated_files.extend(str(page_file) for page_file in page_images.values())
+        
+        manifest_file = self.write_manifest(manifest_sections, pdf_content.get('images', []), page_images)
+        generated_files.append(str(manifest_file))
         
         return generated_files
     
-    def split_large_section(self, section_md: str, section_title: str) -> List[str]:
-        """Split a large section into smaller, manageable parts for modern LLMs"""
+    def write_api_endpoints(self, sections: List[Dict[str, Any]], section_files: Dict[Any, Path]) -> List[str]:
+        """Write api-endpoints/ files for the endpoints (METHOD /path) found in the sections"""
+        endpoints = collect_endpoints(sections)
+        self.api_endpoints = {'count': len(endpoints)}
+        self.processing_stats['api_endpoints'] = len(endpoints)
+        if not endpoints:
+            return []
+        
+        api_dir = self.layout.directory_for('api-endpoints')
+        FileUtils.ensure_directory(api_dir)
+        written = []
+        filenames = []
+        entries = []
+        
+        def write(filename: str, content: str) -> Path:
+            if not self.front_matter:
+                content = drop_front_matter_fields(content, SOURCE_FIELDS)
+            if self.canonical:
+                content = canonicalize_markdown(content)
+            content = render_document(content, self.output_format)
+            path = self.layout.path_for('api-endpoints', output_filename(filename, self.output_format))
+            FileUtils.write_markdown(content, path)
+            self.check_output_size(len(content.encode('utf-8')))
+            written.append(str(path))
+            return path
+        
+        for index, endpoint in enumerate(endpoints, 1):
+            check_cancelled(self.cancel_event)
+            filename = f"{index:02d}-{endpoint_slug(endpoint['method'], endpoint['path'])}.md"
+            section_file = section_files.get(endpoint.get('section_id'))
+            link = self.layout.relative_path(section_file, api_dir) if section_file else None
+            endpoint_file = write(filename, render_endpoint(endpoint, link))
+            filenames.append(filename)
+            entries.append({
+                'file': self.layout.relative_path(endpoint_file),
+                'method': endpoint['method'],
+                'path': endpoint['path'],
+                'section_id': endpoint.get('section_id'),
+                'has_request': endpoint['request'] is not None,
+                'has_response': endpoint['response'] is not None
+            })
+        
+        # With a flat layout the directory is the document root, which already has a README
+        index_name = "api-endpoints.md" if api_dir.resolve() == self.output_dir.resolve() else "README.md"
+        index_file = write(index_name, render_endpoint_index(endpoints, filenames, self.pdf_path.name))
+        self.api_endpoints.update({'index': self.layout.relative_path(index_file), 'endpoints': entries})
+        return written
+    
+    def create_budget_chunks(self, sections: List[Dict[str, Any]]) -> None:
+        """Cut sections into chunk_tokens-sized chunks under chunked/"""
+        chunk_tokens, chunk_overlap = self.chunk_budget
+        chunked_dir = self.layout.directory_for('chunked')
+        engine = ChunkingEngine(str(self.output_dir), self.token_counter, chunked_dir=str(chunked_dir))
+        # Section text links images and other sections relative to sections/; chunks rebase them
+        result = engine.create_budget_chunks(sections, chunk_tokens, chunk_overlap, self.chunk_layout,
+                                             self.cancel_event,
+                                             self.layout.relative_path(self.layout.directory_for('sections'),
+                                                                       chunked_dir))
+        self.conversion_results['chunks'] = {'chunk_files': result['chunk_files'] + [result['manifest_file']],
+                                             'total_chunks': result['total_chunks']}
+        self.chunking = {key: value for key, value in result.items()
+                         if key not in ('chunks', 'chunk_files', 'manifest_file', 'records')}
+        self.chunking['manifest'] = self.layout.relative_path(Path(result['manifest_file']))
+        if self.options.get('chunks_jsonl'):
+            # One record per chunk with its metadata, for loading straight into a vector store
+            jsonl_file = self.layout.directory_for('chunked') / CHUNKS_JSONL
+            # Keyed by the PDF's bytes: a different file with the same name gets different record ids
+            document_id = hash_file(self.pdf_path)[:16]
+            records = chunk_records(result['records'], self.pdf_path.name, document_id)
+            self.chunking['jsonl'] = self.layout.relative_path(jsonl_file)
+            self.chunking['jsonl_chunks'] = write_chunks_jsonl(records, jsonl_file)
+            self.chunking['jsonl_document_id'] = document_id
+            self.conversion_results['chunks']['chunk_files'].append(str(jsonl_file))
+            if self.vector_db_format:
+                vector_file = self.write_vector_db_format(records, document_id)
+                self.chunking['vector_db_format'] = {'format': self.vector_db_format,
+                                                     'path': self.layout.relative_path(vector_file)}
+                self.conversion_results['chunks']['chunk_files'].append(str(vector_file))
+        self.processing_stats['chunks'] = result['total_chunks']
+        
+        if result['over_budget']:
+            self.warnings.append(f"{result['over_budget']} chunks exceed chunk_tokens: tables and code blocks are never split")
+    
+    def write_vector_db_format(self, records: List[Dict[str, Any]], document_id: str) -> Path:
+        """Write chunks.jsonl records in vector_db_format through the prepare_pdf_for_rag pipeline"""
+        from pdf_to_rag import PDFToRAGProcessor
+        
+        chunk_tokens, chunk_overlap = self.chunk_budget
+        processor = PDFToRAGProcessor(str(self.pdf_path), str(self.layout.directory_for('chunked')),
+                                      chunk_size=chunk_tokens, chunk_overlap=chunk_overlap,
+                                      vector_db_format=self.vector_db_format)
+        processor.doc_id = processor.doc_metadata['doc_id'] = document_id
+        return processor.write_vector_db_format(processor.chunks_from_records(records))
+    
+    def render_source_pages(self, sections: List[Dict[str, Any]]) -> Dict[int, Path]:
+        """Render every page a section covers to images/page-NNN.png (once per page)"""
+        pages = {page for section in sections for page in section_pages(section)}
+        try:
+            page_images = render_page_images(str(self.source_path), pages, self.layout.directory_for('images'))
+        except Exception as e:
+            self.warnings.append(f"Could not render source pages: {e}")
+            return {}
+        self.processing_stats['page_images'] = len(page_images)
+        return page_images
+    
+    def source_page_fields(self, pages: List[int], page_images: Dict[int, Path]) -> Dict[str, Any]:
+        """Front-matter pointing a section file at the renders of the pages its text came from"""
+        sections_dir = self.layout.directory_for('sections')
+        images = [self.layout.relative_path(page_images[page], sections_dir)
+                  for page in pages if page in page_images]
+        if not images:
+            return {}
+        fields = {'source_page_image': images[0]}
+        if len(images) > 1:
+            fields['source_page_images'] = images
+        return fields
+    
+    def source_pdf_name(self, page: Optional[int]) -> str:
+        """Name of the PDF a page came from (the merged file for convert_pdf_set)"""
+        part = source_file_for_page(self.source_files, page)
+        return part['file'] if part else self.pdf_path.name
+    
+    def document_front_matter(self, pdf_content: Dict[str, Any], page_start: Optional[int],
+                              page_end: Optional[int]) -> Dict[str, Any]:
+        """Front-matter for the document map: the same core fields as the section files"""
+        fields = {
+            'title': pdf_content.get('metadata', {}).get('title') or self.pdf_path.stem,
+            'source_pdf': self.pdf_path.name,
+            'page_start': page_start,
+            'page_end': page_end
+        }
+        if self.source_files:
+            fields['source_pdfs'] = [part['file'] for part in self.source_files]
+        return fields
+    
+    def add_front_matter_fields(self, section_md: str, fields: Dict[str, Any]) -> str:
+        """Add fields to a section file's existing front-matter"""
+        existing, body = split_front_matter(section_md)
+        return render_front_matter({**existing, **fields}) + body
+    
+    def create_manifest_entry(self, section: Dict[str, Any], section_file: Path) -> Dict[str, Any]:
+        """Describe a written section file for manifest.json"""
+        return {
+            'file': self.layout.relative_path(section_file),
+            'section_id': section.get('section_id'),
+            'title': section.get('title', ''),
+            'section_type': section.get('section_type', 'content'),
+            'content_type': section.get('content_type', 'mixed'),
+            'token_count': section.get('token_count', 0),
+            'word_count': section.get('word_count', 0),
+            'reading_minutes': section.get('reading_minutes', 0)
+        }
+    
+    def create_image_manifest_entry(self, image: Dict[str, Any]) -> Dict[str, Any]:
+        """Describe an extracted image for manifest.json"""
+        return {
+            'file': self.layout.relative_path(Path(image['file'])),
+            'page': image.get('page'),
+            'section_id': image.get('section_id'),
+            'width': image.get('width'),
+            'height': image.get('height'),
+            'caption': image.get('caption'),
+            'caption_source': image.get('caption_source'),
+            'alt_text': image.get('alt_text'),
+            'alt_text_source': image.get('alt_text_source'),
+            'replaced_file': self.layout.relative_path(Path(image['replaced_file'])) if image.get('replaced_file') else None,
+            'duplicate': bool(image.get('duplicate'))
+        }
+    
+    def write_manifest(self, manifest_sections: List[Dict[str, Any]],
+                       images: Optional[List[Dict[str, Any]]] = None,
+                       page_images: Optional[Dict[int, Path]] = None) -> Path:
+        """Write the machine-readable manifest describing every generated section and image"""
+        manifest = {
+            'document_id': FileUtils.document_id(self.pdf_path.name),
+            'source_file': self.pdf_path.name,
+            'generated_at': datetime.now().isoformat(),
+            'fingerprint': self.fingerprint,
+            'sections': manifest_sections,
+            # Size of each section file, keyed by its path (as in sections[].file)
+            'section_stats': {entry['file']: {key: entry[key] for key in ('token_count', 'word_count', 'reading_minutes')}
+                              for entry in manifest_sections},
+            'images': [self.create_image_manifest_entry(image) for image in images or []]
+        }
+        if self.canonical:
+            # Nothing that changes between runs of the same document
+            del manifest['generated_at']
+            manifest['output_mode'] = 'canonical'
+        if self.sample:
+            manifest['sample'] = self.sample
+        if self.source_files:
+            manifest['source_files'] = [{key: part[key] for key in ('file', 'page_start', 'page_end', 'page_count')}
+                                        for part in self.source_files]
+        # Tokenizer behind every token count (chunks, conversion manifest)
+        manifest['tokenizer'] = self.token_counter.name
+        # How sections[].file names were formed (utils.section_naming)
+        manifest['section_naming'] = self.section_naming
+        # Keys present in section front-matter (empty with frontmatter off)
+        manifest['front_matter_fields'] = self.front_matter_fields
+        if self.page_range:
+            manifest['page_range'] = self.page_range
+        if self.chunking:
+            manifest['chunking'] = self.chunking
+        if self.api_endpoints is not None:
+            manifest['api_endpoints'] = self.api_endpoints
+        if self.streaming:
+            manifest['streaming'] = self.streaming
+        if page_images:
+            manifest['page_images'] = [{'file': self.layout.relative_path(page_file), 'page': page}
+                                       for page, page_file in sorted(page_images.items())]
+        if self.image_output:
+            manifest['image_output'] = self.image_output
+        if self.text_decorations:
+            manifest['text_decorations'] = self.text_decorations
+        if self.equations:
+            manifest['equations'] = self.equations
+        if self.image_variants:
+            manifest['image_variants'] = [{
+                'kept': self.layout.relative_path(Path(group['kept']['file'])),
+                'width': group['kept']['width'],
+                'height': group['kept']['height'],
+                'replaced': [{**variant, 'file': self.layout.relative_path(Path(variant['file']))}
+                             for variant in group['replaced']]
+            } for group in self.image_variants]
+        if self.csv_tables:
+            manifest['tables'] = [{
+                'file': self.layout.relative_path(Path(table['file'])),
+                'page': table['page'],
+                'index': table['index'],
+                'rows': len(table['data']),
+                'cols': max((len(row) for row in table['data']), default=0),
+                'section_id': table.get('section_id')
+            } for table in self.csv_tables]
+        if self.signatures is not None:
+            manifest['signatures'] = self.signatures
+        # PDF and option hashes, so use_cache can skip reconverting an unchanged document
+        manifest['cache_key'] = conversion_cache_key(str(self.pdf_path), self.options)
+        
+        manifest_file = self.layout.path_for('root', "manifest.json")
+        FileUtils.write_json(manifest, manifest_file)
+        return manifest_file
+    
+    def generate_keywords_file(self, pages: Optional[List[int]] = None) -> Path:
+        """Aggregate emphasized terms across the converted pages (default: all) into keywords.json"""
+        extractor = KeywordExtractor()
+        spans = PDFExtractor().iter_emphasized_spans(str(self.source_path), pages=pages)
+        keywords = extractor.aggregate(spans)
+        self.processing_stats['keywords'] = len(keywords)
+        
+        keywords_file = self.layout.path_for('root', "keywords.json")
+        return extractor.write_keywords_json(keywords, keywords_file, self.pdf_path.name,
+                                             include_timestamp=not self.canonical)
+    
+    def record_in_corpus_index(self, index_path: str, section_count: int) -> None:
+        """Add this conversion's summary to the shared corpus index"""
+        fingerprint = self.fingerprint or {}
+        entry = {
+            'document_id': corpus_document_id(str(self.pdf_path)),
+            'source_file': self.pdf_path.name,
+            'source_path': str(self.pdf_path.resolve()),
+            'doc_type': 'pdf',
+            'output_directory': relative_to_index(index_path, self.output_dir),
+            'manifest': relative_to_index(index_path, self.output_dir / "manifest.json"),
+            'page_count': fingerprint.get('page_count'),
+            'content_hash': fingerprint.get('content_hash'),
+            'section_count': section_count,
+            'converted_at': datetime.now().isoformat()
+        }
+        try:
+            update_corpus_index(index_path, entry)
+        except (OSError, ValueError, TimeoutError) as e:
+            # The conversion itself succeeded; a catalog failure shouldn't undo it
+            self.warnings.append(f"Could not update corpus index {index_path}: {e}")
+    
+    def split_large_section(self, section_md: str, section_title: str,
+                            section: Optional[Dict[str, Any]] = None) -> List[Tuple[str, List[int]]]:
+        """
+        Split a large section into smaller, manageable parts for modern LLMs
+        
+        Returns:
+            (markdown, pages) per part: the pages each part's text came from,
+            read from its page markers (the whole section's pages without them),
+            also given as the part's page_start and page_end
+        """
+        section = section or {}
         # First check if section actually needs splitting
         total_tokens = self.token_counter.count_tokens(section_md)
         if total_tokens <= 32000:
-            return [section_md]
+            return [(section_md, section_pages(section))]
         
-        lines = section_md.split('\n')
+        # Front-matter is repeated on every part rather than split as content
+        front_matter_fields, body = split_front_matter(section_md)
+        
+        lines = body.split('\n')
         parts = []
         current_part = []
         current_tokens = 0
@@ -396,23 +1558,68 @@ class ModularPDFConverter:
             
             # If adding this line would exceed target, start new part
             if current_tokens + line_tokens > target_tokens and current_part:
-                # Finish current part
-                part_header = header_lines + [f"\n**Part {len(parts)+1} of Section**\n---\n"]
-                part_content = '\n'.join(part_header + current_part)
-                parts.append(part_content)
-                current_part = []
-                current_tokens = header_tokens + 50  # Account for part header
+                # A page marker (and the blank lines around it) opens the next part, never ends this one
+                end = len(current_part)
+                while end > 1 and (not current_part[end - 1].strip() or marker_page(current_part[end - 1]) is not None):
+                    end -= 1
+                carried = current_part[end:] if any(marker_page(cl) is not None for cl in current_part[end:]) else []
+                parts.append(current_part[:len(current_part) - len(carried)])
+                current_part = carried
+                current_tokens = header_tokens + 50 + sum(self.token_counter.count_tokens(cl) for cl in carried)
             
             current_part.append(line)
             current_tokens += line_tokens
         
         # Add final part
         if current_part:
-            part_header = header_lines + [f"\n**Part {len(parts)+1} of Section**\n---\n"] 
-            part_content = '\n'.join(part_header + current_part)
-            parts.append(part_content)
-        
-        return parts if parts else [section_md]
+            parts.append(current_part)
+        if not parts:
+            return [(section_md, section_pages(section))]
+        
+        spans = chunk_page_spans(['\n'.join(part) for part in parts], section.get('page_start'), section.get('page_end'))
+        split = []
+        for part_idx, (part, (page_start, page_end)) in enumerate(zip(parts, spans)):
+            pages = (list(range(page_start, page_end + 1)) if page_start and page_end
+                     else section_pages(section))
+            fields = dict(front_matter_fields)
+            if 'page_start' in fields:
+                fields['page_start'], fields['page_end'] = page_start, page_end
+            part_header = header_lines + [f"\n**Part {part_idx+1} of Section**\n---\n"]
+            front_matter = render_front_matter(fields) if fields else ""
+            split.append((front_matter + '\n'.join(part_header + part), pages))
+        return split
+    
+    def record_equations(self, math_mode: str, metadata: Dict[str, Any]) -> None:
+        """Summarize the equations math_mode replaced, with warnings for what needs checking"""
+        equations = metadata.get('equations') or []
+        if math_mode == 'latex' and equations and not metadata.get('latex_model_loaded'):
+            self.warnings.append("pix2tex is installed but its model could not be loaded, so equations are "
+                                 "cropped as images instead of converted to LaTeX")
+        if not equations:
+            return
+        low_confidence = [equation for equation in equations if equation['low_confidence']]
+        self.equations = {
+            'math_mode': math_mode,
+            'count': len(equations),
+            'latex': sum(1 for equation in equations if equation['latex']),
+            'low_confidence': len(low_confidence),
+            'items': [{**equation, 'file': self.layout.relative_path(Path(equation['file']))}
+                      for equation in equations]
+        }
+        self.processing_stats['equations'] = {key: self.equations[key]
+                                              for key in ('math_mode', 'count', 'latex', 'low_confidence')}
+        if low_confidence:
+            pages = ", ".join(str(page) for page in dict.fromkeys(equation['page'] for equation in low_confidence))
+            self.warnings.append(f"{len(low_confidence)} equation(s) converted with low confidence on page(s) "
+                                 f"{pages}; each is marked \"{LOW_CONFIDENCE_NOTE}\" with a link to its image")
+    
+    def underline_note(self) -> str:
+        """How underlined text is marked, for the README notice"""
+        marker = self.options.get('underline_marker', DEFAULT_UNDERLINE_MARKER)
+        if not marker:
+            return "left unmarked (underline_marker is empty)"
+        opening, closing = underline_markers(marker)
+        return f"shown as {opening}text{closing}"
     
     def create_document_map(self, sections: List[Dict[str, Any]], 
                           pdf_content: Dict[str, Any]) -> str:
@@ -422,7 +1629,24 @@ class ModularPDFConverter:
         content = f"""# {metadata.get('title', 'Document')}
 
 Document navigation and section directory.
-
+"""
+        if self.sample:
+            content += (f"\n> ⚠️ **Sample conversion** - only pages {', '.join(map(str, self.sample['pages']))} "
+                        f"of {self.sample['page_count']} were converted (sample_pages={self.sample['sample_pages']}, "
+                        f"seed {self.sample['seed']}).\n")
+        elif self.page_range:
+            content += (f"\n> Pages {self.page_range['page_range']} of {self.page_range['page_count']} "
+                        f"(page_range).\n")
+        if self.text_decorations:
+            content += (f"\n> Revision marks: {self.text_decorations['strikethrough']} struck-through passage(s) "
+                        f"shown as ~~text~~ and {self.text_decorations['underline']} underlined passage(s) "
+                        f"{self.underline_note()}.\n")
+        if self.equations:
+            content += (f"\n> Equations: {self.equations['count']} display equation(s) in $$...$$ blocks, "
+                        f"{self.equations['latex']} converted to LaTeX and the rest shown as images"
+                        + (f"; {self.equations['low_confidence']} low-confidence conversion(s) are marked ⚠️"
+                           if self.equations['low_confidence'] else "") + ".\n")
+        content += f"""
 ## Document Summary
 
 {self.generate_consolidated_summary(sections, metadata)}
@@ -432,10 +1656,11 @@ Document navigation and section directory.
 """
         
         # Add clean navigation with semantic filenames and purposes
+        sections_link = self.layout.relative_path(self.layout.directory_for('sections'))
         for i, section in enumerate(sections):
             title = section.get('title', 'Untitled Section')
             section_type = self.classify_section_type(section)
-            filename = self.generate_semantic_filename(section, i + 1)
+            filename = self.section_filename(section, i + 1)
             
             # Add purpose description for better LLM understanding
             purpose_descriptions = {
@@ -446,11 +1671,12 @@ Document navigation and section directory.
                 'error_handling': 'Error codes and troubleshooting procedures',
                 'data_formats': 'Data structures and format specifications',
                 'configuration': 'Setup and configuration procedures',
-                'reference': 'Reference material and lookup tables'
+                'reference': 'Reference material and lookup tables',
+                'form_fields': 'Form field names and the values filled in'
             }
             
             purpose = purpose_descriptions.get(section_type, 'Content section')
-            content += f"- [{title}](sections/{filename}) - {purpose}\n"
+            content += f"- [{title}]({sections_link}/{filename}) - {purpose} ({format_section_size(section)})\n"
         
         return content
     
@@ -508,8 +1734,23 @@ Document navigation and section directory.
         content = section.get('content', '')
         section_type = self.classify_section_type(section)
         
+        # Machine-readable front-matter for routing, navigation, and static-site generators
+        front_matter = {
+            'title': title,
+            'source_pdf': self.source_pdf_name(section.get('page_start')),
+            'page_start': section.get('page_start'),
+            'page_end': section.get('page_end')
+        }
+        front_matter['section_id'] = section.get('section_id', section_num)
+        front_matter['content_type'] = section.get('content_type', 'mixed')
+        if section.get('merged_headings'):
+            front_matter['merged_headings'] = section['merged_headings']
+        if self.sample:
+            front_matter['sample'] = True
+        markdown = render_front_matter(front_matter)
+        
         # Clean, focused header with just the essential information
-        markdown = f"# {title}\n\n"
+        markdown += f"# {title}\n\n"
         
         # Add section purpose/scope if it can be determined
         purpose_descriptions = {
@@ -520,14 +1761,27 @@ Document navigation and section directory.
             'error_handling': 'Error codes, troubleshooting, and debugging information',
             'data_formats': 'Data structures, schemas, and format specifications',
             'configuration': 'Setup, configuration, and installation instructions',
-            'reference': 'Reference material and appendices'
+            'reference': 'Reference material and appendices',
+            'form_fields': 'Interactive form fields and their filled-in values'
         }
         
         if section_type in purpose_descriptions:
             markdown += f"{purpose_descriptions[section_type]}\n\n---\n\n"
         
-        # Main content without metadata clutter
-        markdown += content
+        # Main content without metadata clutter (optionally soft-wrapped for readability)
+        markdown += TextUtils.soft_wrap_markdown(content, self.options.get('wrap_width', 0) or 0)
+        
+        # Figures from this section's pages, captioned with the document's own captions
+        for image in section.get('images', []):
+            image_link = self.layout.relative_path(Path(image['file']), self.layout.directory_for('sections'))
+            markdown += f"\n\n![{image['alt_text']}]({image_link})"
+            if image.get('caption'):
+                markdown += f"\n\n*{image['caption']}*"
+        
+        # Tables exported as CSV (export_tables_csv), linked where they appear
+        for table in section.get('tables', []):
+            table_link = self.layout.relative_path(Path(table['file']), self.layout.directory_for('sections'))
+            markdown += f"\n\n[Table {table['index'] + 1} on page {table['page']} (CSV)]({table_link})"
         
         # Add explicit cross-references if we have access to all sections
         if all_sections:
@@ -568,7 +1822,7 @@ Document navigation and section directory.
             
             # Check if this section type is related to current section
             if section_type in target_types:
-                filename = self.generate_semantic_filename(section, i + 1)
+                filename = self.section_filename(section, i + 1)
                 related_sections.append(f"- [{section_title}]({filename}) - {section_type.replace('_', ' ').title()}")
         
         # Also check for content-based relationships (mentions, references)
@@ -582,7 +1836,7 @@ Document navigation and section directory.
             # Check if current section mentions this section or vice versa
             if (current_title in section_content or 
                 section_title.lower() in current_content):
-                filename = self.generate_semantic_filename(section, i + 1)
+                filename = self.section_filename(section, i + 1)
                 if f"[{section_title}]({filename})" not in '\n'.join(related_sections):
                     related_sections.append(f"- [{section_title}]({filename}) - Referenced content")
         
@@ -683,7 +1937,8 @@ Document navigation and section directory.
             'output_info': {
                 'output_directory': str(self.output_dir),
                 'total_files_generated': len(self.get_all_generated_files()),
-                'file_categories': self.categorize_generated_files(self.get_all_generated_files())
+                'file_categories': self.categorize_generated_files(self.get_all_generated_files()),
+                'chunk_layout': self.chunk_layout if self.chunking else None
             },
             'processing_options': self.options,
             'processing_stats': self.processing_stats,
@@ -696,6 +1951,9 @@ Document navigation and section directory.
             }
         }
         
+        if self.form_fields is not None:
+            metadata['form_fields'] = self.form_fields
+        
         metadata_file = self.output_dir / "conversion-metadata.json"
         FileUtils.write_json(metadata, metadata_file)
         return metadata_file
@@ -764,6 +2022,9 @@ Document navigation and section directory.
             'references': [],
             'metadata': []
         }
+        # Chunk files by what was written rather than their folder, which depends on chunk_layout
+        chunk_results = self.conversion_results.get('chunks') or {}
+        chunk_files = set(chunk_results.get('chunk_files', []) if isinstance(chunk_results, dict) else chunk_results)
         
         for file_path in file_list:
             file_obj = Path(file_path)
@@ -776,13 +2037,13 @@ Document navigation and section directory.
                 categories['concepts'].append(file_path)
             elif parent_dir == 'tables':
                 categories['tables'].append(file_path)
-            elif parent_dir == 'chunked':
+            elif parent_dir == 'chunked' or file_path in chunk_files:
                 categories['chunks'].append(file_path)
             elif parent_dir == 'references':
                 categories['references'].append(file_path)
             elif parent_dir == 'sections':
                 categories['sections'].append(file_path)
-            elif file_name.endswith('-metadata.json') or file_name == 'README.md':
+            elif file_name.endswith('-metadata.json') or file_name in ('README.md', 'README.adoc', 'README.rst'):
                 categories['metadata'].append(file_path)
             else:
                 categories['main_documents'].append(file_path)
@@ -790,20 +2051,180 @@ Document navigation and section directory.
         return categories
 
 
+# Pages whose text a dry run reads to estimate tokens and bytes
+PLAN_TEXT_PAGES = 20
+
+# Document-level files every conversion writes (README.md, manifest.json)
+PLAN_INDEX_FILES = 2
+
+
+def plan_conversion(pdf_path: str, output_dir: str, options: Optional[Dict[str, Any]] = None,
+                    cancel_event=None) -> Dict[str, Any]:
+    """
+    Dry run: estimate what a conversion would write, without converting
+    
+    Only the page tree, the bookmarks, and each converted page's image list
+    are read; text is read from at most PLAN_TEXT_PAGES evenly spread pages
+    and scaled up to the pages being converted. Sections are the top-level
+    bookmarks, or runs of STREAM_PAGES_PER_SECTION pages without them (what
+    streaming would write), so the counts are estimates: a full conversion
+    also finds headings in the text. Nothing is written.
+    
+    Returns:
+        success, and on success the plan: would-be output directory, page,
+        section, and image counts, estimated file count, bytes, and tokens,
+        and chunks per size bucket (plus chunk_tokens chunks when set); on
+        failure error and error_type (and error_code for password problems)
+    """
+    import fitz
+    
+    options = options or {}
+    start_time = datetime.now()
+    layout = OutputLayout(options.get('output_layout'), str(output_dir),
+                          FileUtils.sanitize_folder_name(Path(pdf_path).name), doc_type='pdf')
+    token_counter = TokenCounter(tokenizer=options.get('tokenizer'))
+    warnings: List[str] = []
+    
+    try:
+        encrypted = check_pdf_password(pdf_path, options.get('password'))
+        if options.get('password') and not encrypted:
+            warnings.append("password ignored: the PDF is not encrypted")
+        doc = fitz.open(pdf_path)
+        try:
+            if encrypted:
+                doc.authenticate(options['password'])
+            page_count = doc.page_count
+            pages = (parse_page_range(options['page_range'], page_count) if options.get('page_range')
+                     else list(range(1, page_count + 1)))
+            range_pages = pages
+            sample = None
+            if options.get('sample_pages') and pages:
+                seed = int(options.get('sample_seed', 0) or 0)
+                pages = [pages[index - 1] for index in select_sample_pages(len(pages), options['sample_pages'], seed)]
+                sample = {'sample_pages': str(options['sample_pages']), 'seed': seed, 'pages': pages,
+                          'page_count': page_count}
+                warnings.append(f"Sample conversion: {len(pages)} of {page_count} pages (seed {seed}) "
+                                f"- not the full document")
+            
+            # Images by xref, so one drawn on every page counts once (as image_dedup writes it)
+            image_bytes: Dict[int, int] = {}
+            if options.get('extract_images', True):
+                for page_num in pages:
+                    check_cancelled(cancel_event)
+                    for image in doc[page_num - 1].get_images(full=True):
+                        if image[0] not in image_bytes:
+                            kind, length = doc.xref_get_key(image[0], 'Length')
+                            image_bytes[image[0]] = int(length) if kind == 'int' else 0
+            
+            text_pages = ([pages[index - 1] for index in select_sample_pages(len(pages), PLAN_TEXT_PAGES)]
+                          if pages else [])
+            text_chars = text_tokens = 0
+            for page_num in text_pages:
+                check_cancelled(cancel_event)
+                text = doc[page_num - 1].get_text()
+                text_chars += len(text.encode('utf-8'))
+                text_tokens += token_counter.count_tokens(text)
+            
+            outline = [{'title': title, 'level': level, 'page': page if page > 0 else None}
+                       for level, title, page in doc.get_toc(simple=True)]
+        finally:
+            doc.close()
+    except PDFPasswordError as e:
+        return {'success': False, 'pdf_file': str(pdf_path), 'error': str(e), 'error_type': type(e).__name__,
+                'error_code': e.code, 'warnings': warnings}
+    except ConversionCancelled:
+        raise
+    except Exception as e:
+        return {'success': False, 'pdf_file': str(pdf_path), 'error': str(e), 'error_type': type(e).__name__,
+                'warnings': warnings}
+    
+    # Per-page averages from the pages read, spread over each section's pages
+    scale = len(pages) / len(text_pages) if text_pages else 0
+    tokens_per_page = text_tokens / len(text_pages) if text_pages else 0
+    sections = stream_sections(outline, pages)
+    starts = [section['page_start'] for section in sections] + [pages[-1] + 1 if pages else 0]
+    section_tokens = [round(tokens_per_page * sum(1 for page in pages if starts[index] <= page < starts[index + 1]))
+                      for index in range(len(sections))]
+    
+    kinds = {'index': PLAN_INDEX_FILES, 'section': len(sections)}
+    if image_bytes:
+        kinds['image'] = len(image_bytes)
+    plan = {
+        'success': True,
+        'dry_run': True,
+        'estimated': True,
+        'pdf_file': str(pdf_path),
+        'output_directory': str(layout.document_root()),
+        'output_exists': layout.document_root().exists(),
+        'pages': len(pages),
+        'sections': len(sections),
+        'images': len(image_bytes),
+        'text_pages_read': len(text_pages),
+        'total_bytes': round(text_chars * scale) + sum(image_bytes.values()),
+        'total_tokens': round(text_tokens * scale),
+        'chunks': estimate_bucket_chunks(section_tokens),
+        'warnings': warnings
+    }
+    if options.get('chunk_tokens'):
+        total_chunks = sum(max(1, -(-tokens // options['chunk_tokens'])) for tokens in section_tokens)
+        kinds['chunk'] = total_chunks
+        plan['chunking'] = {'chunk_tokens': options['chunk_tokens'], 'chunk_overlap': options.get('chunk_overlap', 0),
+                            'tokenizer': token_counter.name, 'total_chunks': total_chunks}
+    plan['kinds'] = kinds
+    plan['file_count'] = sum(kinds.values())
+    plan['processing_time_seconds'] = (datetime.now() - start_time).total_seconds()
+    if sample:
+        plan['sample'] = sample
+    if options.get('page_range'):
+        plan['page_range'] = {'page_range': str(options['page_range']), 'pages': range_pages, 'page_count': page_count}
+    return plan
+
+
+def cached_conversion(pdf_path: str, output_dir: str, options: Optional[Dict[str, Any]] = None) -> Optional[Dict[str, Any]]:
+    """
+    The result of an earlier conversion of this PDF with these options, if its output is still there
+    
+    Compares the PDF's SHA-256 and the options hash with the cache_key in the
+    existing manifest.json; nothing is extracted or written.
+    
+    Returns:
+        A conversion result with cache_hit set (output directory, file
+        count, conversion manifest), or None on a cache miss
+    """
+    layout = OutputLayout((options or {}).get('output_layout'), str(output_dir),
+                          FileUtils.sanitize_folder_name(Path(pdf_path).name), doc_type='pdf')
+    manifest = find_cached_conversion(layout, conversion_cache_key(pdf_path, options or {}))
+    if manifest is None:
+        return None
+    
+    conversion_manifest = build_conversion_manifest(layout).to_dict()
+    return {
+        'success': True,
+        'cache_hit': True,
+        'pdf_file': str(pdf_path),
+        'output_directory': str(layout.document_root()),
+        'sections_directory': str(layout.directory_for('sections')),
+        'cache_key': manifest['cache_key'],
+        'fingerprint': manifest.get('fingerprint'),
+        'file_count': conversion_manifest['file_count'],
+        'conversion_manifest': conversion_manifest
+    }
+
+
 def main():
     """Command-line interface for the modular PDF converter"""
     if len(sys.argv) < 3:
-        print("Usage: python modular_pdf_converter.py <pdf_path> <output_dir> [options_json]")
+        print("Usage: python modular_pdf_converter.py <pdf_path> <output_dir> [options_json | -]")
         sys.exit(1)
     
     pdf_path = sys.argv[1]
     output_dir = sys.argv[2]
     
-    # Parse options if provided
+    # Parse options if provided; "-" reads them from stdin, keeping a password off the command line
     options = {}
     if len(sys.argv) > 3:
         try:
-            options = json.loads(sys.argv[3])
+            options = json.loads(sys.stdin.read() if sys.argv[3] == '-' else sys.argv[3])
         except json.JSONDecodeError:
             print("Warning: Invalid JSON options provided, using defaults")
     
@@ -823,7 +2244,7 @@ def main():
     
     # Output full results as JSON for programmatic use
     print("\\n=== CONVERSION_RESULTS_JSON ===")
-    print(json.dumps(results, indent=2))
+    print(json.dumps(results, indent=2, default=str))
 
 
 if __name__ == "__main__":
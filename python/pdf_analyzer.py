@@ -2,88 +2,314 @@ import sys
 import pypdf
 import pdfplumber
 import json
+import argparse
 
-def analyze_pdf(pdf_path):
+from utils.xmp import read_pdf_xmp, format_xmp
+from utils.cancellation import ConversionCancelled, check_cancelled
+from utils.analysis_output import JSON_MARKER
+from utils.pdf_password import check_pdf_password
+from utils.outline import nest_outline, synthesize_outline
+from utils.text_confidence import page_fonts, page_text_confidence, readable_chars
+
+# Chapters shown in the human-readable listing; the JSON always has all of them
+DEFAULT_CHAPTER_LIMIT = 10
+
+def validate_chapter_limit(limit):
+    """chapter_limit, defaulting to DEFAULT_CHAPTER_LIMIT (raises ValueError below 1)"""
+    if limit is None:
+        return DEFAULT_CHAPTER_LIMIT
+    if isinstance(limit, bool) or not isinstance(limit, int) or limit < 1:
+        raise ValueError(f"chapter_limit must be an integer of at least 1, got {limit!r}")
+    return limit
+
+def analyze_pdf(pdf_path, cancel_event=None, password=None):
     """Analyze PDF structure and return information"""
-    analysis = {
+    return collect_analysis(iter_analysis_records(pdf_path, cancel_event, password))
+
+def iter_analysis_records(pdf_path, cancel_event=None, password=None):
+    """
+    Analyze a PDF as a stream of records
+    
+    Yields one dict per line of NDJSON output, so callers can process huge
+    outlines without holding the whole structure in memory:
+    
+    - {"type": "document", "pages", "has_toc", "metadata", "xmp"}
+    - {"type": "chapter", "title", "level", "page"} per outline entry
+    - {"type": "page_images", "page", "images"} per page with images
+    - {"type": "page_tables", "page", "tables"} per page with tables
+    - {"type": "page_text", "page", "confidence", "chars", "fonts",
+      "embedded_fonts", "images", "suspect"} per page (utils.text_confidence)
+    - {"type": "summary", ...totals} last
+    
+    With a cancel_event (threading.Event), ConversionCancelled is raised
+    before the next page once the event is set. An encrypted PDF without its
+    password raises PDFPasswordError before the first record.
+    """
+    if not check_pdf_password(pdf_path, password):
+        password = None
+    
+    summary = {
+        'type': 'summary',
         'pages': 0,
         'has_toc': False,
         'has_tables': False,
         'has_images': False,
-        'chapters': [],
-        'metadata': {},
+        'chapter_count': 0,
         'table_count': 0,
-        'image_count': 0
+        'image_count': 0,
+        'suspect_page_count': 0
     }
+    # Per page from the pypdf pass, for text confidence in the pdfplumber pass
+    fonts_by_page = {}
+    images_by_page = {}
     
     # Analyze with pypdf
     try:
         with open(pdf_path, 'rb') as f:
-            reader = pypdf.PdfReader(f)
-            analysis['pages'] = len(reader.pages)
-            
-            # Check for TOC
-            if reader.outline:
-                analysis['has_toc'] = True
-                analysis['chapters'] = extract_chapter_info(reader.outline)
+            reader = pypdf.PdfReader(f, password=password or None)
+            summary['pages'] = len(reader.pages)
+            summary['has_toc'] = bool(reader.outline)
             
             # Get metadata
+            metadata = {}
             if reader.metadata:
-                analysis['metadata'] = {
+                metadata = {
                     'title': reader.metadata.get('/Title', ''),
                     'author': reader.metadata.get('/Author', ''),
                     'subject': reader.metadata.get('/Subject', ''),
                     'creator': reader.metadata.get('/Creator', ''),
                 }
+            # XMP packet (Dublin Core, rights, custom fields) beyond the Info dictionary
+            try:
+                xmp = read_pdf_xmp(reader)
+            except Exception as e:
+                print(f"Error reading XMP metadata: {e}", file=sys.stderr)
+                xmp = None
+            yield {'type': 'document', 'pages': summary['pages'], 'has_toc': summary['has_toc'],
+                   'metadata': metadata, 'xmp': xmp}
+            
+            # Walk the TOC one entry at a time
+            if reader.outline:
+                for chapter in iter_chapter_info(reader.outline, reader=reader):
+                    summary['chapter_count'] += 1
+                    yield {'type': 'chapter', **chapter}
             
             # Check for images
-            for page in reader.pages:
-                if '/XObject' in page['/Resources']:
-                    xObject = page['/Resources']['/XObject'].get_object()
+            for page_num, page in enumerate(reader.pages, 1):
+                check_cancelled(cancel_event)
+                # Pages without /Resources (seen in some incrementally updated, signed PDFs) have no images
+                resources = page.get('/Resources')
+                resources = resources.get_object() if resources is not None else {}
+                fonts_by_page[page_num] = page_fonts(resources)
+                images = 0
+                if '/XObject' in resources:
+                    xObject = resources['/XObject'].get_object()
                     for obj in xObject:
                         if xObject[obj]['/Subtype'] == '/Image':
-                            analysis['image_count'] += 1
-                            analysis['has_images'] = True
+                            images += 1
+                if images:
+                    images_by_page[page_num] = images
+                    summary['image_count'] += images
+                    summary['has_images'] = True
+                    yield {'type': 'page_images', 'page': page_num, 'images': images}
     
+    except ConversionCancelled:
+        raise
     except Exception as e:
         print(f"Error with pypdf analysis: {e}", file=sys.stderr)
     
     # Analyze tables with pdfplumber
     try:
-        with pdfplumber.open(pdf_path) as pdf:
-            for page in pdf.pages:
+        with pdfplumber.open(pdf_path, password=password or '') as pdf:
+            for page_num, page in enumerate(pdf.pages, 1):
+                check_cancelled(cancel_event)
                 tables = page.extract_tables()
                 if tables:
-                    analysis['has_tables'] = True
-                    analysis['table_count'] += len(tables)
+                    summary['has_tables'] = True
+                    summary['table_count'] += len(tables)
+                    yield {'type': 'page_tables', 'page': page_num, 'tables': len(tables)}
+                chars = readable_chars(char['text'] for char in page.chars)
+                # Without the pypdf pass, assume extracted text came from an embedded font
+                fonts, embedded = fonts_by_page.get(page_num, (1, 1) if chars else (0, 0))
+                text = page_text_confidence(page_num, chars, float(page.width), float(page.height), fonts, embedded,
+                                            images_by_page.get(page_num, 0))
+                if text['suspect']:
+                    summary['suspect_page_count'] += 1
+                yield {'type': 'page_text', **text}
+                # Drop cached layout objects so memory stays flat across pages
+                page.flush_cache()
     
+    except ConversionCancelled:
+        raise
     except Exception as e:
         print(f"Error with pdfplumber analysis: {e}", file=sys.stderr)
     
+    yield summary
+
+def collect_analysis(records):
+    """Fold analysis records into the single analysis dictionary"""
+    analysis = {
+        'pages': 0,
+        'has_toc': False,
+        'has_tables': False,
+        'has_images': False,
+        'chapters': [],
+        'metadata': {},
+        'xmp': None,
+        'table_count': 0,
+        'image_count': 0,
+        'text_confidence': [],
+        'suspect_pages': []
+    }
+    for record in records:
+        if record['type'] == 'document':
+            analysis['metadata'] = record['metadata']
+            analysis['xmp'] = record.get('xmp')
+        elif record['type'] == 'chapter':
+            analysis['chapters'].append({k: v for k, v in record.items() if k != 'type'})
+        elif record['type'] == 'page_text':
+            analysis['text_confidence'].append({k: v for k, v in record.items() if k not in ('type', 'suspect')})
+            if record['suspect']:
+                analysis['suspect_pages'].append(record['page'])
+        elif record['type'] == 'summary':
+            for key in ('pages', 'has_toc', 'has_tables', 'has_images', 'table_count', 'image_count'):
+                analysis[key] = record[key]
     return analysis
 
-def extract_chapter_info(outline, chapters=None, level=0):
-    """Extract chapter information from outline"""
-    if chapters is None:
-        chapters = []
+def iter_chapter_info(outline, level=0, reader=None):
+    """
+    Yield chapter information from outline in document order
     
+    Every outline entry is kept, with its nesting level and 1-based
+    destination page (None when the destination can't be resolved).
+    """
     for item in outline:
         if isinstance(item, list):
-            extract_chapter_info(item, chapters, level + 1)
+            yield from iter_chapter_info(item, level + 1, reader)
         else:
-            chapters.append({
+            yield {
                 'title': item.title,
-                'level': level
-            })
-    
+                'level': level,
+                'page': destination_page(reader, item)
+            }
+
+def extract_chapter_info(outline, chapters=None, level=0, reader=None):
+    """Extract chapter information from outline as a list"""
+    if chapters is None:
+        chapters = []
+    chapters.extend(iter_chapter_info(outline, level, reader))
     return chapters
 
+def outline_entries(pdf_path, cancel_event=None, password=None):
+    """
+    Flat outline entries (title, level, page) of a PDF
+    
+    Uses the embedded bookmarks; a PDF without them gets entries from the
+    heading lines of a quick text pass.
+    
+    Returns:
+        (entries, synthesized, page count)
+    """
+    if not check_pdf_password(pdf_path, password):
+        password = None
+    
+    with open(pdf_path, 'rb') as f:
+        reader = pypdf.PdfReader(f, password=password or None)
+        synthesized = not reader.outline
+        if synthesized:
+            def page_texts():
+                for page in reader.pages:
+                    check_cancelled(cancel_event)
+                    yield page.extract_text() or ''
+            chapters = synthesize_outline(page_texts())
+        else:
+            chapters = extract_chapter_info(reader.outline, reader=reader)
+        pages = len(reader.pages)
+    
+    return chapters, synthesized, pages
+
+def extract_outline(pdf_path, cancel_event=None, password=None):
+    """
+    Outline of a PDF as a nested tree, without the rest of the analysis
+    
+    Uses the embedded bookmarks; a PDF without them gets an outline from
+    the heading lines of a quick text pass, flagged synthesized.
+    
+    Returns:
+        Dictionary with pages, synthesized, entry_count, and outline (nested
+        title, level, page, children)
+    """
+    chapters, synthesized, pages = outline_entries(pdf_path, cancel_event, password)
+    return {
+        'pages': pages,
+        'synthesized': synthesized,
+        'entry_count': len(chapters),
+        'outline': nest_outline(chapters)
+    }
+
+def analyze_structure(pdf_path, cancel_event=None, password=None):
+    """
+    Analysis for comparing revisions: analyze_pdf, with chapters from
+    heading lines (synthesized_outline) when the PDF has no bookmarks
+    """
+    analysis = analyze_pdf(pdf_path, cancel_event, password)
+    analysis['synthesized_outline'] = False
+    if not analysis['chapters']:
+        chapters, synthesized, _ = outline_entries(pdf_path, cancel_event, password)
+        analysis['chapters'] = chapters
+        analysis['synthesized_outline'] = synthesized
+    return analysis
+
+def destination_page(reader, item):
+    """1-based page number an outline item points to, or None"""
+    if reader is None:
+        return None
+    try:
+        page_index = reader.get_destination_page_number(item)
+    except Exception:
+        return None
+    return page_index + 1 if page_index is not None and page_index >= 0 else None
+
+def format_chapter_listing(chapters, limit=DEFAULT_CHAPTER_LIMIT):
+    """
+    Human-readable chapter listing
+    
+    Args:
+        chapters: Chapter dictionaries from extract_chapter_info
+        limit: Maximum chapters to list (None lists all)
+    """
+    shown = chapters if limit is None else chapters[:limit]
+    lines = [f"Chapters/Sections ({len(chapters)} items):"]
+    for chapter in shown:
+        indent = "  " * chapter['level']
+        page = f" (p. {chapter['page']})" if chapter.get('page') else ""
+        lines.append(f"{indent}- {chapter['title']}{page}")
+    if len(chapters) > len(shown):
+        lines.append(f"  ... and {len(chapters) - len(shown)} more (full list in JSON)")
+    return "\n".join(lines)
+
+def format_page_list(pages, limit=20):
+    """Comma-separated page numbers, cut after limit with a count of the rest"""
+    listed = ", ".join(str(page) for page in pages[:limit])
+    return listed + (f" and {len(pages) - limit} more" if len(pages) > limit else "")
+
 def main():
-    if len(sys.argv) < 2:
-        print("Usage: python analyze.py <pdf_path>", file=sys.stderr)
-        sys.exit(1)
+    parser = argparse.ArgumentParser(description="Analyze PDF structure")
+    parser.add_argument("pdf_path", help="Path to the PDF file")
+    parser.add_argument("--chapter-limit", type=lambda value: validate_chapter_limit(int(value)),
+                        default=DEFAULT_CHAPTER_LIMIT,
+                        help=f"Chapters to list in the text output, at least 1 (default: {DEFAULT_CHAPTER_LIMIT})")
+    parser.add_argument("--ndjson", action="store_true",
+                        help="Stream the analysis as NDJSON, one record per line")
+    args = parser.parse_args()
+    
+    pdf_path = args.pdf_path
+    
+    if args.ndjson:
+        for record in iter_analysis_records(pdf_path):
+            print(json.dumps(record, default=str), flush=True)
+        return
     
-    pdf_path = sys.argv[1]
     analysis = analyze_pdf(pdf_path)
     
     # Format output
@@ -99,16 +325,18 @@ def main():
             if value:
                 print(f"  {key}: {value}")
     
+    if analysis['xmp']:
+        print("\nXMP Metadata:")
+        print(format_xmp(analysis['xmp']))
+    
+    if analysis['suspect_pages']:
+        print(f"Pages likely needing OCR: {format_page_list(analysis['suspect_pages'])}")
+    
     if analysis['chapters']:
-        print(f"\nChapters/Sections ({len(analysis['chapters'])} items):")
-        for chapter in analysis['chapters'][:10]:  # Show first 10
-            indent = "  " * chapter['level']
-            print(f"{indent}- {chapter['title']}")
-        if len(analysis['chapters']) > 10:
-            print(f"  ... and {len(analysis['chapters']) - 10} more")
-    
-    # Output JSON for parsing
-    print("\n---JSON---")
+        print("\n" + format_chapter_listing(analysis['chapters'], args.chapter_limit))
+    
+    # Output JSON for parsing (see utils.analysis_output.parse_analysis_output)
+    print(f"\n{JSON_MARKER}")
     print(json.dumps(analysis))
 
 if __name__ == "__main__":
@@ -17,6 +17,8 @@ from typing import List, Dict, Any, Tuple
 import pypdf
 import pdfplumber
 
+from processors.rag_export import VECTOR_DB_FORMATS
+
 # Optional but recommended for accurate token counting
 try:
     import tiktoken
@@ -102,6 +104,9 @@ class PDFToRAGProcessor:
                         'char_count': len(text),
                         'has_tables': bool(tables)
                     })
+                    
+                    # Drop cached layout objects so memory stays flat across pages
+                    page.flush_cache()
         except Exception as e:
             print(f"pdfplumber failed, falling back to pypdf: {e}")
             # Fallback to pypdf
@@ -407,6 +412,33 @@ class PDFToRAGProcessor:
         
         return max(0.0, min(1.0, score))
     
+    def chunks_from_records(self, records: List[Dict[str, Any]]) -> List[Dict]:
+        """
+        Chunk objects for chunks.jsonl records (processors.rag_export)
+        
+        A converted document is chunked along its sections; this gives those
+        chunks the same vector database formats as chunks cut from page text,
+        keeping each record's id and its source, section, and heading path.
+        """
+        chunks = []
+        for index, record in enumerate(records):
+            metadata = record['metadata']
+            page_start, page_end = metadata.get('page_start'), metadata.get('page_end')
+            pages = list(range(page_start, page_end + 1)) if page_start and page_end else []
+            chunk = self.create_chunk_object(index, record['text'], pages, 'section')
+            chunk['chunk_id'] = record['id']
+            chunk['metadata'].update({key: metadata.get(key) for key in ('source', 'section', 'heading_path')})
+            chunk['metadata']['token_count'] = metadata.get('tokens', chunk['metadata']['token_count'])
+            chunks.append(chunk)
+        return chunks
+    
+    def write_vector_db_format(self, chunks: List[Dict]) -> Path:
+        """Write chunks in the target vector database format to <format>_format.json"""
+        vector_file = self.output_dir / f"{self.vector_db_format}_format.json"
+        with open(vector_file, 'w', encoding='utf-8') as f:
+            json.dump(self.generate_vector_db_format(chunks), f, indent=2, ensure_ascii=False)
+        return vector_file
+    
     def generate_vector_db_format(self, chunks: List[Dict]) -> Dict:
         """Generate format specific to target vector database"""
         if self.vector_db_format == "pinecone":
@@ -709,7 +741,7 @@ Examples:
                        help='Target chunk size in tokens (default: 768)')
     parser.add_argument('--chunk-overlap', type=int, default=128,
                        help='Overlap between chunks in tokens (default: 128)')
-    parser.add_argument('--format', choices=['generic', 'pinecone', 'chromadb', 'weaviate', 'qdrant'],
+    parser.add_argument('--format', choices=VECTOR_DB_FORMATS,
                        default='generic', help='Vector database format (default: generic)')
     parser.add_argument('--model', default='text-embedding-ada-002',
                        help='Target embedding model (default: text-embedding-ada-002)')
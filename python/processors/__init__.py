@@ -8,4 +8,9 @@ This package contains specialized processors for different aspects of PDF conver
 - concept_mapper: Concept maps & glossaries
 - cross_referencer: Cross-reference resolution
 - summary_generator: Multi-level summaries
+- keyword_extractor: Keyword index from emphasized terms
+- reference_extractor: Bibliography entries as structured citations
+- line_numbers: Margin line numbers in legal documents
+- reflow: Words split across column and page breaks
+- image_variants: Same image embedded at several resolutions
 """
\ No newline at end of file
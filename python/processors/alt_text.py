@@ -0,0 +1,86 @@
+"""
+Alt text for extracted images
+
+image_alt_mode picks how each image's alt text is written:
+
+- caption (default): the document's own caption found next to the image
+  ("Figure 3: Network topology" gives "Network topology"), else the
+  generic text
+- generic: always "Image from page N"
+- placeholder: an ALT_TEXT_PLACEHOLDER marker with the page and any
+  nearby caption, for a later step (a person or a vision model) to find
+  and replace with a real description
+
+Each mode is a provider function registered in ALT_TEXT_PROVIDERS, so a
+describing service can be added as another mode without touching the
+converter. Alt text is kept on one line and its square brackets are
+escaped so it cannot end the markdown image early.
+"""
+import re
+from typing import Any, Callable, Dict, Optional
+
+try:
+    from .image_extractor import caption_alt_text
+except ImportError:
+    from processors.image_extractor import caption_alt_text
+
+# Searchable marker that starts every placeholder alt text
+ALT_TEXT_PLACEHOLDER = "ALT-TEXT-TODO"
+
+AltTextProvider = Callable[[Dict[str, Any]], Optional[str]]
+
+
+def generic_alt_text(image: Dict[str, Any]) -> str:
+    """'Image from page N'"""
+    return f"Image from page {image.get('page')}"
+
+
+def document_caption_alt_text(image: Dict[str, Any]) -> Optional[str]:
+    """The document caption's description, or None without a caption"""
+    return caption_alt_text(image['caption']) if image.get('caption') else None
+
+
+def placeholder_alt_text(image: Dict[str, Any]) -> str:
+    """ALT_TEXT_PLACEHOLDER with the page and nearby caption"""
+    text = f"{ALT_TEXT_PLACEHOLDER} page {image.get('page')}"
+    if image.get('caption'):
+        text += f", caption: {image['caption']}"
+    return text
+
+
+# Mode name -> provider; a provider returning None falls back to generic_alt_text
+ALT_TEXT_PROVIDERS: Dict[str, AltTextProvider] = {
+    'caption': document_caption_alt_text,
+    'generic': generic_alt_text,
+    'placeholder': placeholder_alt_text,
+}
+
+
+def register_alt_text_provider(mode: str, provider: AltTextProvider) -> None:
+    """Add (or replace) an image_alt_mode"""
+    ALT_TEXT_PROVIDERS[mode] = provider
+
+
+def validate_alt_mode(mode: Optional[str]) -> str:
+    """image_alt_mode, defaulting to 'caption' (raises ValueError for an unknown mode)"""
+    mode = mode or 'caption'
+    if mode not in ALT_TEXT_PROVIDERS:
+        raise ValueError(f"Unknown image_alt_mode '{mode}' (expected one of: {', '.join(ALT_TEXT_PROVIDERS)})")
+    return mode
+
+
+def markdown_alt_text(text: str) -> str:
+    """Alt text safe inside ![...]: one line, square brackets escaped"""
+    text = re.sub(r'\s+', ' ', text).strip()
+    return re.sub(r'([\[\]])', r'\\\1', text)
+
+
+def apply_alt_text(image: Dict[str, Any], mode: str = 'caption') -> Dict[str, Any]:
+    """Set image['alt_text'] and image['alt_text_source'] (the mode used, or 'generic' on fallback)"""
+    text = ALT_TEXT_PROVIDERS[mode](image)
+    source = mode
+    if not text:
+        text, source = generic_alt_text(image), 'generic'
+    image['alt_text'] = markdown_alt_text(text)
+    image['alt_text_source'] = source
+    return image
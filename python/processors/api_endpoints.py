@@ -0,0 +1,237 @@
+"""
+API endpoints described in a document
+
+With extract_api_endpoints, every "METHOD /path" in the section text (GET
+/v1/users, POST https://api.example.com/v2/orders) becomes an endpoint. Its
+text runs to the next endpoint or heading; the first sentence after it is
+the description, and a fenced block or JSON body after a "Request" or
+"Response" label (or an HTTP status such as "200 OK") is its request or
+response schema. Each endpoint gets its own markdown file under
+api-endpoints/, next to a README index.
+
+The method must be upper case and the path must start with "/" or a URL,
+so prose like "get the value" is not an endpoint. An endpoint mentioned
+more than once is listed once, with details filled in from later mentions.
+"""
+import json
+import re
+from typing import Any, Dict, List, Optional, Sequence
+
+try:
+    from ..utils.frontmatter import render_front_matter
+except ImportError:
+    # Handle running as script vs package
+    import sys
+    from pathlib import Path
+    sys.path.append(str(Path(__file__).parent.parent))
+    from utils.frontmatter import render_front_matter
+
+HTTP_METHODS = ('GET', 'POST', 'PUT', 'PATCH', 'DELETE', 'HEAD', 'OPTIONS')
+
+ENDPOINT = re.compile(r'(?<![\w/])(' + '|'.join(HTTP_METHODS) + r')[`*]*[ \t]+[`*]*'
+                      r'((?:https?://[\w.-]+(?::\d+)?)?/[\w\-./{}:~%@$]*)')
+HEADING = re.compile(r'^\s*#{1,6}\s')
+FENCE = re.compile(r'^\s*(`{3,}|~{3,})\s*([\w+-]*)')
+
+# Words in a label that names what the next code block or JSON body is
+REQUEST_LABEL = re.compile(r'\b(request|body|payload)\b', re.I)
+RESPONSE_LABEL = re.compile(r'\bresponses?\b|^\W*[1-5]\d\d\b', re.I)
+
+# Longest label line (longer lines, or lines ending a sentence, are prose)
+MAX_LABEL = 40
+
+# Longest description kept, and longest slug of a path in a file name
+MAX_DESCRIPTION = 200
+MAX_SLUG = 60
+
+
+def schema_label(line: str) -> Optional[str]:
+    """'request' or 'response' when the line labels the block after it, else None"""
+    text = line.strip()
+    if not text or len(text) > MAX_LABEL or text.endswith('.'):
+        return None
+    if RESPONSE_LABEL.search(text):
+        return 'response'
+    if REQUEST_LABEL.search(text):
+        return 'request'
+    return None
+
+
+def endpoint_slug(method: str, path: str) -> str:
+    """File name stem for an endpoint: method and path, lower case and dashed"""
+    path = re.sub(r'^https?://[^/]+', '', path)
+    slug = re.sub(r'[^a-z0-9]+', '-', path.lower()).strip('-')[:MAX_SLUG].strip('-')
+    return f"{method.lower()}-{slug or 'root'}"
+
+
+def json_body(lines: Sequence[str], start: int) -> Optional[int]:
+    """End (exclusive) of a JSON object or array starting at lines[start], or None if it never closes"""
+    depth = 0
+    in_string = escaped = False
+    for index in range(start, len(lines)):
+        for char in lines[index]:
+            if in_string:
+                if escaped:
+                    escaped = False
+                elif char == '\\':
+                    escaped = True
+                elif char == '"':
+                    in_string = False
+            elif char == '"':
+                in_string = True
+            elif char in '{[':
+                depth += 1
+            elif char in '}]':
+                depth -= 1
+                if depth == 0:
+                    return index + 1
+    return None
+
+
+def schema_language(code: str, language: str) -> str:
+    """Fence language of a schema: the block's own, else json when it parses"""
+    if language:
+        return language
+    try:
+        json.loads(code)
+        return 'json'
+    except ValueError:
+        return ''
+
+
+def describe_endpoint(method: str, path: str, trailing: str, lines: Sequence[str]) -> Dict[str, Any]:
+    """
+    Description and schemas from an endpoint's text
+
+    Args:
+        trailing: Text after the path on the endpoint's own line
+        lines: The lines after the endpoint line, up to the next endpoint or heading
+    """
+    endpoint = {'method': method, 'path': path, 'description': '', 'request': None, 'response': None}
+    trailing = trailing.strip(' \t`*:-–—')
+    if trailing:
+        endpoint['description'] = trailing[:MAX_DESCRIPTION]
+
+    label = None
+    index = 0
+    while index < len(lines):
+        line = lines[index]
+        fence = FENCE.match(line)
+        if fence:
+            end = next((i for i in range(index + 1, len(lines)) if lines[i].strip().startswith(fence.group(1))), None)
+            if end is None:
+                break
+            code = '\n'.join(lines[index + 1:end])
+            language = fence.group(2)
+            index = end + 1
+        elif line.lstrip()[:1] in ('{', '['):
+            end = json_body(lines, index)
+            if end is None:
+                break
+            code = '\n'.join(lines[index:end])
+            language = ''
+            index = end
+        else:
+            if schema_label(line):
+                label = schema_label(line)
+            elif not endpoint['description'] and line.strip():
+                sentence = re.split(r'(?<=[.!?])\s', line.strip(), maxsplit=1)[0]
+                endpoint['description'] = sentence[:MAX_DESCRIPTION]
+            index += 1
+            continue
+        # An unlabelled block: a request for methods that send a body, else the response
+        target = label or ('request' if method in ('POST', 'PUT', 'PATCH') and not endpoint['request'] else 'response')
+        if not endpoint[target]:
+            endpoint[target] = {'language': schema_language(code.strip(), language), 'code': code.strip('\n')}
+        label = None
+    return endpoint
+
+
+def find_endpoints(content: str) -> List[Dict[str, Any]]:
+    """
+    Endpoints in one section's text, in order of appearance
+
+    Returns:
+        Dictionaries with method, path, description, request, and response
+        (None or a dictionary with language and code)
+    """
+    lines = content.split('\n')
+    starts = []
+    fence = None
+    for index, line in enumerate(lines):
+        match = FENCE.match(line)
+        if match:
+            marker = match.group(1)
+            if fence is None:
+                fence = marker
+            elif marker[0] == fence[0] and len(marker) >= len(fence):
+                fence = None
+            continue
+        if fence is None:
+            found = ENDPOINT.search(line)
+            if found:
+                starts.append((index, found))
+
+    endpoints = []
+    for position, (index, match) in enumerate(starts):
+        end = starts[position + 1][0] if position + 1 < len(starts) else len(lines)
+        heading = next((i for i in range(index + 1, end) if HEADING.match(lines[i])), end)
+        path = match.group(2).rstrip('.,;:')
+        # Mentioned mid-sentence: the whole line describes it, not just what follows the path
+        mid_sentence = lines[index][:match.start()].strip(' \t>*_`#|-')
+        trailing = lines[index].strip() if mid_sentence else lines[index][match.end():]
+        endpoints.append(describe_endpoint(match.group(1), path, trailing, lines[index + 1:heading]))
+    return endpoints
+
+
+def collect_endpoints(sections: Sequence[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """
+    Endpoints across sections, each (method, path) once
+
+    Returns:
+        find_endpoints dictionaries plus section_id and section_title of
+        the first mention; later mentions fill in missing fields
+    """
+    endpoints: Dict[tuple, Dict[str, Any]] = {}
+    for section in sections:
+        for endpoint in find_endpoints(section.get('content', '')):
+            key = (endpoint['method'], endpoint['path'])
+            if key not in endpoints:
+                endpoints[key] = {**endpoint, 'section_id': section.get('section_id'),
+                                  'section_title': section.get('title', '')}
+                continue
+            known = endpoints[key]
+            for field in ('description', 'request', 'response'):
+                if not known[field] and endpoint[field]:
+                    known[field] = endpoint[field]
+    return list(endpoints.values())
+
+
+def render_endpoint(endpoint: Dict[str, Any], section_link: Optional[str] = None) -> str:
+    """An endpoint's markdown file: front-matter, description, request, response, and source section"""
+    front_matter = {
+        'title': f"{endpoint['method']} {endpoint['path']}",
+        'method': endpoint['method'],
+        'path': endpoint['path'],
+        'section_id': endpoint.get('section_id'),
+    }
+    content = render_front_matter(front_matter) + f"# {endpoint['method']} `{endpoint['path']}`\n\n"
+    if endpoint['description']:
+        content += f"{endpoint['description']}\n\n"
+    for field, heading in (('request', 'Request'), ('response', 'Response')):
+        schema = endpoint[field]
+        if schema:
+            content += f"## {heading}\n\n```{schema['language']}\n{schema['code']}\n```\n\n"
+    if section_link:
+        content += f"Source: [{endpoint.get('section_title') or 'Section'}]({section_link})\n"
+    return content.rstrip('\n') + '\n'
+
+
+def render_endpoint_index(endpoints: Sequence[Dict[str, Any]], files: Sequence[str], source_file: str) -> str:
+    """README index of the endpoint files: method, path, and description"""
+    content = f"# API Endpoints\n\n{len(endpoints)} endpoints found in {source_file}.\n\n"
+    content += "| Method | Path | Description |\n|--------|------|-------------|\n"
+    for endpoint, filename in zip(endpoints, files):
+        description = endpoint['description'].replace('|', '\\|')
+        content += f"| {endpoint['method']} | [`{endpoint['path']}`]({filename}) | {description} |\n"
+    return content
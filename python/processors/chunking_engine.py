@@ -1,47 +1,327 @@
 try:
+    from ..utils.cancellation import check_cancelled
     from ..utils.token_counter import TokenCounter
     from ..utils.text_utils import TextUtils
     from ..utils.file_utils import FileUtils
+    from ..utils.frontmatter import render_front_matter
+    from .rag_export import chunk_heading_paths, push_heading
+    from .page_markers import chunk_page_spans, marker_page, split_page_markers
+    from .links import rebase_links
 except ImportError:
     # Handle running as script vs package
     import sys
     from pathlib import Path
     sys.path.append(str(Path(__file__).parent.parent))
+    from utils.cancellation import check_cancelled
     from utils.token_counter import TokenCounter
     from utils.text_utils import TextUtils
     from utils.file_utils import FileUtils
+    from utils.frontmatter import render_front_matter
+    from processors.rag_export import chunk_heading_paths, push_heading
+    from processors.page_markers import chunk_page_spans, marker_page, split_page_markers
+    from processors.links import rebase_links
 """
 Smart chunking engine for optimal LLM context window utilization
+
+Besides the fixed small/medium/large/xlarge buckets, sections can be cut
+into chunks of an exact token budget (chunk_tokens) for retrieval
+pipelines. Chunks end at paragraph boundaries where the next paragraph
+would not fit, fall back to sentence (then word) boundaries only for
+paragraphs larger than the budget, and never split a markdown table or
+fenced code block. Page marker lines (processors.page_markers) are never
+split and stay with the text after them. With chunk_overlap, each chunk after the first in a
+section starts with the trailing whole sentences of the chunk before it.
+Every chunk also carries its heading_path: the titles of the enclosing
+sections (by section level) down to the deepest heading in effect where it
+starts.
+
+Budget chunks are laid out under chunked/ by chunk_layout: 'by_size' (the
+default, the tree chunked/ has always had) groups NN-title-chunk-NNN.md
+files into small/, medium/, large/, and xlarge/ by the smallest CHUNK_SIZES
+bucket each chunk fits, 'by_section' gives each section a NN-title/ folder
+of chunk-NNN.md files, and 'flat' puts every file directly in chunked/.
+Manifest entries give each file's path relative to chunked/, so readers
+need not know the layout. Relative links in a chunk are rebased for the
+folder its file lands in.
 """
 from pathlib import Path
-from typing import Dict, List, Any, Tuple
+from typing import Callable, Dict, List, Any, Optional, Tuple
 from datetime import datetime
+import posixpath
 import re
 
+# Target token limits for different models
+CHUNK_SIZES = {
+    'small': 3500,   # GPT-3.5 (4K context)
+    'medium': 7500,  # GPT-4 (8K context)
+    'large': 30000,  # GPT-4-32K (32K context)
+    'xlarge': 95000  # Claude-2 (100K context)
+}
+
+# Smallest budget that leaves room for more than a heading
+MIN_CHUNK_TOKENS = 50
+
+CHUNK_LAYOUTS = ('flat', 'by_section', 'by_size')
+DEFAULT_CHUNK_LAYOUT = 'by_size'
+
+CHUNK_OVERLAP_DESCRIPTION = (
+    "Each chunk after the first in a section starts with the trailing whole sentences of the "
+    "previous chunk, up to chunk_overlap tokens; overlap_tokens is the size of that repeated "
+    "part and is included in tokens. Overlap never crosses sections and never repeats tables "
+    "or code blocks, and it is dropped when a single sentence needs the room."
+)
+
+# (text placed before the unit when it follows another unit, unit text, atomic)
+ChunkUnit = Tuple[str, str, bool]
+
+
+def validate_chunk_budget(chunk_tokens: Any, chunk_overlap: Any = 0) -> Tuple[int, int]:
+    """
+    Check chunk_tokens / chunk_overlap arguments
+
+    Returns:
+        (chunk_tokens, chunk_overlap) as integers
+
+    Raises:
+        ValueError: Non-integer values, a budget below MIN_CHUNK_TOKENS, or an
+            overlap that is negative or more than half the budget
+    """
+    try:
+        tokens, overlap = int(chunk_tokens), int(chunk_overlap or 0)
+    except (TypeError, ValueError):
+        raise ValueError(f"chunk_tokens and chunk_overlap must be integers "
+                         f"(got {chunk_tokens!r}, {chunk_overlap!r})")
+    if tokens < MIN_CHUNK_TOKENS:
+        raise ValueError(f"chunk_tokens must be at least {MIN_CHUNK_TOKENS} (got {tokens})")
+    if overlap < 0 or overlap > tokens // 2:
+        raise ValueError(f"chunk_overlap must be between 0 and half of chunk_tokens ({tokens // 2}), got {overlap}")
+    return tokens, overlap
+
+
+def validate_chunk_layout(chunk_layout: Optional[str]) -> str:
+    """chunk_layout, defaulting to DEFAULT_CHUNK_LAYOUT (raises ValueError for an unknown one)"""
+    chunk_layout = chunk_layout or DEFAULT_CHUNK_LAYOUT
+    if chunk_layout not in CHUNK_LAYOUTS:
+        raise ValueError(f"Unknown chunk_layout '{chunk_layout}' (expected one of: {', '.join(CHUNK_LAYOUTS)})")
+    return chunk_layout
+
+
+def size_bucket(tokens: int) -> str:
+    """Smallest CHUNK_SIZES bucket a chunk of this many tokens fits (the largest when none does)"""
+    for name, limit in CHUNK_SIZES.items():
+        if tokens <= limit:
+            return name
+    return list(CHUNK_SIZES)[-1]
+
+
+def chunk_file_path(chunk_layout: str, section_index: int, title: str, chunk_num: int, tokens: int) -> str:
+    """Path of a budget chunk file relative to chunked/ under chunk_layout"""
+    section = f"{section_index:02d}-{FileUtils.safe_filename(title) or 'section'}"
+    if chunk_layout == 'by_section':
+        return f"{section}/chunk-{chunk_num:03d}.md"
+    filename = f"{section}-chunk-{chunk_num:03d}.md"
+    if chunk_layout == 'by_size':
+        return f"{size_bucket(tokens)}/{filename}"
+    return filename
+
+
+def estimate_bucket_chunks(token_counts: List[int]) -> Dict[str, int]:
+    """Chunks per CHUNK_SIZES bucket for sections of the given token counts (ceiling division per section)"""
+    return {name: sum(max(1, -(-tokens // limit)) for tokens in token_counts)
+            for name, limit in CHUNK_SIZES.items()}
+
+
+def split_markdown_blocks(text: str) -> List[Tuple[str, bool]]:
+    """
+    Split markdown into blocks separated by blank lines
+
+    Table rows and fenced code blocks are kept together as one block even
+    without surrounding blank lines, and a heading or page marker line is
+    kept with the block that follows it.
+
+    Returns:
+        (block, atomic) pairs; atomic blocks (tables, code) are never split
+    """
+    blocks: List[Tuple[str, bool]] = []
+    current: List[str] = []
+    kind = None
+
+    def flush():
+        nonlocal current, kind
+        if current:
+            blocks.append(('\n'.join(current), kind in ('table', 'code')))
+        current, kind = [], None
+
+    for line in text.split('\n'):
+        stripped = line.strip()
+        if kind == 'code':
+            current.append(line)
+            if stripped.startswith('```'):
+                flush()
+        elif stripped.startswith('```'):
+            flush()
+            current, kind = [line], 'code'
+        elif TextUtils.is_table_row(line):
+            if kind != 'table':
+                flush()
+                kind = 'table'
+            current.append(line)
+        elif not stripped:
+            flush()
+        elif marker_page(line) is not None:
+            flush()
+            blocks.append((stripped, False))
+        else:
+            if kind == 'table':
+                flush()
+            kind = 'text'
+            current.append(line)
+    flush()
+
+    # Headings and page markers travel with the next block so no chunk ends on one
+    merged: List[Tuple[str, bool]] = []
+    pending = ''
+    for block, atomic in blocks:
+        if not atomic and (re.fullmatch(r'#{1,6}\s+.+', block) or marker_page(block) is not None):
+            pending = f"{pending}\n\n{block}" if pending else block
+            continue
+        if pending:
+            block = f"{pending}\n\n{block}"
+            pending = ''
+        merged.append((block, atomic))
+    if pending:
+        merged.append((pending, False))
+    return merged
+
+
+def split_sentences(text: str) -> List[Tuple[str, str]]:
+    """
+    Split a paragraph into sentences, keeping their punctuation
+
+    Returns:
+        (whitespace before the sentence, sentence) pairs, so list items and
+        line breaks survive being joined back together
+    """
+    parts = re.split(r'(?<=[.!?])(\s+)', text.strip())
+    return [(parts[index - 1] if index else '', parts[index]) for index in range(0, len(parts), 2) if parts[index]]
+
+
+def pack_token_chunks(text: str, chunk_tokens: int, chunk_overlap: int,
+                      count_tokens: Callable[[str], int]) -> List[Dict[str, Any]]:
+    """
+    Cut text into chunks of at most chunk_tokens tokens
+
+    Args:
+        text: Markdown to chunk (one section)
+        chunk_tokens: Token budget per chunk, overlap included
+        chunk_overlap: Tokens of trailing sentences repeated at the start of the next chunk
+        count_tokens: Tokenizer used for every budget decision
+
+    Returns:
+        Chunks with text, tokens, overlap_tokens, and over_budget (True only for
+        a table or code block that is larger than the budget on its own)
+    """
+    chunks: List[Dict[str, Any]] = []
+    current: List[ChunkUnit] = []
+    overlap_count = 0
+
+    def render(units: List[ChunkUnit]) -> str:
+        return ''.join((joiner if index else '') + unit for index, (joiner, unit, _) in enumerate(units))
+
+    def fits(units: List[ChunkUnit]) -> bool:
+        return count_tokens(render(units)) <= chunk_tokens
+
+    def emit():
+        nonlocal current, overlap_count
+        body = render(current)
+        tokens = count_tokens(body)
+        chunks.append({
+            'text': body,
+            'tokens': tokens,
+            'overlap_tokens': count_tokens(render(current[:overlap_count])) if overlap_count else 0,
+            'over_budget': tokens > chunk_tokens
+        })
+        # Trailing sentences for the next chunk, stopping at a table or code block
+        tail: List[ChunkUnit] = []
+        for unit in reversed(current):
+            if unit[2] or not chunk_overlap or count_tokens(render([unit] + tail)) > chunk_overlap:
+                break
+            tail.insert(0, unit)
+        current, overlap_count = tail, len(tail)
+
+    def add(unit: ChunkUnit):
+        nonlocal overlap_count
+        if fits(current + [unit]):
+            current.append(unit)
+            return
+        if len(current) > overlap_count:
+            emit()
+        while current and not fits(current + [unit]):
+            current.pop(0)
+            overlap_count -= 1
+        current.append(unit)
+
+    for block, atomic in split_markdown_blocks(text):
+        if atomic:
+            group = [('\n\n', block, True)]
+        else:
+            group = []
+            for markers, part in split_page_markers(block):
+                if not part:
+                    group.append(('\n\n', markers, False))
+                    continue
+                for index, (separator, sentence) in enumerate(split_sentences(part)):
+                    joiner = separator or '\n\n'
+                    # Page markers go in front of their text's first sentence, whole
+                    lead = markers if markers and not index else ''
+                    if count_tokens(f"{lead}\n{sentence}" if lead else sentence) <= chunk_tokens:
+                        group.append((joiner, f"{lead}\n{sentence}" if lead else sentence, False))
+                        continue
+                    # A sentence larger than the budget: word boundaries
+                    piece = ''
+                    for word in ([lead] if lead else []) + sentence.split():
+                        candidate = f"{piece} {word}" if piece else word
+                        if piece and count_tokens(candidate) > chunk_tokens:
+                            group.append((joiner, piece, False))
+                            joiner, piece = ' ', word
+                        else:
+                            piece = candidate
+                    if piece:
+                        group.append((joiner, piece, False))
+
+        # Start a new chunk at the paragraph boundary rather than splitting the paragraph
+        if not fits(current + group) and len(current) > overlap_count:
+            emit()
+        if fits(current + group):
+            current.extend(group)
+        else:
+            for unit in group:
+                add(unit)
+
+    if len(current) > overlap_count:
+        emit()
+    return chunks
+
 
 class ChunkingEngine:
     """Handles smart chunking of content for different LLM context windows"""
     
-    def __init__(self, output_dir: str, token_counter: TokenCounter):
+    def __init__(self, output_dir: str, token_counter: TokenCounter, chunked_dir: Optional[str] = None):
         """
         Initialize chunking engine
         
         Args:
             output_dir: Output directory for chunked content
             token_counter: Token counter for optimization
+            chunked_dir: Directory for chunk files (default: output_dir/chunked)
         """
         self.output_dir = Path(output_dir)
         self.token_counter = token_counter
-        self.chunked_dir = self.output_dir / "chunked"
+        self.chunked_dir = Path(chunked_dir) if chunked_dir else self.output_dir / "chunked"
         FileUtils.ensure_directory(self.chunked_dir)
         
         # Target token limits for different models
-        self.chunk_sizes = {
-            'small': 3500,   # GPT-3.5 (4K context)
-            'medium': 7500,  # GPT-4 (8K context)  
-            'large': 30000,  # GPT-4-32K (32K context)
-            'xlarge': 95000  # Claude-2 (100K context)
-        }
+        self.chunk_sizes = dict(CHUNK_SIZES)
     
     def process_sections_for_chunking(self, sections: List[Dict[str, Any]]) -> List[str]:
         """
@@ -80,6 +360,95 @@ class ChunkingEngine:
         
         return created_files
     
+    def create_budget_chunks(self, sections: List[Dict[str, Any]], chunk_tokens: int,
+                             chunk_overlap: int = 0, chunk_layout: str = DEFAULT_CHUNK_LAYOUT,
+                             cancel_event=None, links_dir: str = '.') -> Dict[str, Any]:
+        """
+        Cut every section into chunks of at most chunk_tokens tokens
+        
+        Writes one file per chunk, placed by chunk_layout (front-matter names
+        the section, heading path, chunk position, and token counts; the budget
+        applies to the body below it) and chunk-manifest.json describing all of them.
+        
+        Args:
+            sections: Document sections with title and content
+            chunk_tokens: Token budget per chunk, overlap included
+            chunk_overlap: Tokens repeated from the end of the previous chunk
+            chunk_layout: 'flat', 'by_section', or 'by_size' (see CHUNK_LAYOUTS)
+            cancel_event: threading.Event checked before each section
+            links_dir: Folder the sections' relative links point from, as a
+                path from chunked/ (e.g. "../sections"); each chunk file's
+                links are rebased from there to the file's own folder
+            
+        Returns:
+            Chunking summary as written to chunk-manifest.json, plus chunk_files
+            and manifest_file paths and records (each chunk's entry with its
+            text and page span, for processors.rag_export)
+        """
+        FileUtils.ensure_directory(self.chunked_dir)
+        entries = []
+        chunk_files = []
+        records = []
+        # Enclosing sections as (level, title), walked in document order
+        section_stack = []
+        
+        for index, section in enumerate(sections, 1):
+            check_cancelled(cancel_event)
+            title = section.get('title', f'Section {index}')
+            section_chunks = pack_token_chunks(section.get('content', ''), chunk_tokens, chunk_overlap,
+                                               self.token_counter.count_tokens)
+            push_heading(section_stack, section.get('level', 1), title)
+            heading_paths = chunk_heading_paths(title, [chunk['text'] for chunk in section_chunks],
+                                                [parent for _, parent in section_stack[:-1]])
+            page_spans = chunk_page_spans([chunk['text'] for chunk in section_chunks],
+                                          section.get('page_start'), section.get('page_end'))
+            for chunk_num, chunk in enumerate(section_chunks, 1):
+                filename = chunk_file_path(chunk_layout, index, title, chunk_num, chunk['tokens'])
+                fields = {
+                    'title': title,
+                    'section_id': section.get('section_id', index),
+                    'heading_path': heading_paths[chunk_num - 1],
+                    'chunk': chunk_num,
+                    'chunks': len(section_chunks),
+                    'tokens': chunk['tokens'],
+                    'overlap_tokens': chunk['overlap_tokens'],
+                    'page_start': page_spans[chunk_num - 1][0],
+                    'page_end': page_spans[chunk_num - 1][1]
+                }
+                chunk_file = self.chunked_dir / filename
+                FileUtils.ensure_directory(chunk_file.parent)
+                link_prefix = posixpath.join('../' * filename.count('/') or '.', links_dir)
+                FileUtils.write_markdown(render_front_matter(fields) + rebase_links(chunk['text'], link_prefix) + '\n',
+                                         chunk_file)
+                chunk_files.append(str(chunk_file))
+                entries.append({
+                    'file': filename,
+                    'section_id': fields['section_id'],
+                    'section_title': title,
+                    'heading_path': fields['heading_path'],
+                    'chunk': chunk_num,
+                    'tokens': chunk['tokens'],
+                    'overlap_tokens': chunk['overlap_tokens'],
+                    'over_budget': chunk['over_budget']
+                })
+                records.append({**entries[-1], 'chunks': len(section_chunks), 'page_start': fields['page_start'],
+                                'page_end': fields['page_end'], 'text': chunk['text']})
+        
+        summary = {
+            'chunk_tokens': chunk_tokens,
+            'chunk_overlap': chunk_overlap,
+            'layout': chunk_layout,
+            'tokenizer': self.token_counter.tokenizer_name(),
+            'overlap': CHUNK_OVERLAP_DESCRIPTION,
+            'total_chunks': len(entries),
+            'over_budget': sum(1 for entry in entries if entry['over_budget']),
+            'chunks': entries
+        }
+        manifest_file = self.chunked_dir / "chunk-manifest.json"
+        FileUtils.write_json(summary, manifest_file)
+        
+        return {**summary, 'chunk_files': chunk_files, 'manifest_file': str(manifest_file), 'records': records}
+    
     def analyze_sections_for_chunking(self, sections: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
         """Analyze sections to determine optimal chunking strategy"""
         chunk_plan = []
@@ -304,7 +673,7 @@ class ChunkingEngine:
     def create_single_chunk_file(self, section_id: int, title: str, content: str, 
                                 size_name: str, plan_item: Dict[str, Any]) -> Path:
         """Create a single chunk file for content that doesn't need splitting"""
-        safe_title = FileUtils.safe_filename(title)
+        safe_title = FileUtils.safe_filename(title) or f"section-{section_id}"
         filename = f"{section_id:02d}-{safe_title}-{size_name}.md"
         
         chunk_content = self.format_chunk_content(
@@ -319,7 +688,7 @@ class ChunkingEngine:
                          size_name: str, chunk_num: int, total_chunks: int,
                          plan_item: Dict[str, Any]) -> Path:
         """Create a chunk file with metadata"""
-        safe_title = FileUtils.safe_filename(title)
+        safe_title = FileUtils.safe_filename(title) or f"section-{section_id}"
         filename = f"{section_id:02d}-{safe_title}-chunk-{chunk_num}-{size_name}.md"
         
         chunk_content = self.format_chunk_content(
@@ -0,0 +1,232 @@
+"""
+Code samples set in monospaced fonts
+
+Page text from PyMuPDF loses what makes a code sample readable: its font
+and indentation. With detect_code_blocks, runs of consecutive lines set
+entirely in a monospaced font become fenced code blocks. Indentation is
+rebuilt from each line's x offset against the leftmost line of the run
+(in character widths), and vertical gaps wider than a line become blank
+lines. The fence's info string is a guessed language, or none when the
+guess is not clear.
+
+A monospaced word inside a prose line (an identifier in running text)
+is left alone; only whole lines count.
+"""
+import json
+import re
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+# PyMuPDF span flag bit for fixed-pitch fonts
+FLAG_MONOSPACED = 8
+
+# Font name fragments of common fixed-pitch fonts (subset prefixes like ABCDEF+ are ignored)
+MONOSPACE_FONTS = ('courier', 'mono', 'consol', 'menlo', 'monaco', 'inconsolata', 'lucidaconsole',
+                   'sourcecode', 'firacode', 'andale', 'typewriter', 'cmtt', 'letter gothic', 'fixed')
+
+# Fewest lines that make a code block; a lone monospaced line is usually a label or identifier
+MIN_CODE_LINES = 2
+
+# Lines further apart than this many line heights are separate blocks, not one with blank lines
+MAX_GAP_HEIGHTS = 4
+
+# (language, pattern, weight); a language needs a total weight of 2 to be chosen
+LANGUAGE_SIGNALS = [
+    ('bash', re.compile(r'^#!/bin/(ba|z)?sh', re.M), 2),
+    ('bash', re.compile(r'^\$ \S', re.M), 2),
+    ('bash', re.compile(r'^\s*(curl|wget|pip|npm|git|sudo|export|cd|echo|docker|apt-get) ', re.M), 1),
+    ('python', re.compile(r'^\s*def \w+\(.*\)\s*(->.*)?:\s*$', re.M), 2),
+    ('python', re.compile(r'^\s*(from [\w.]+ )?import \w', re.M), 1),
+    ('python', re.compile(r'^\s*class \w+(\(.*\))?:\s*$', re.M), 2),
+    ('python', re.compile(r'\bself\.|\bprint\(|^\s*elif\b|\bNone\b', re.M), 1),
+    ('javascript', re.compile(r'\bfunction\s*\w*\s*\(', re.M), 1),
+    ('javascript', re.compile(r'^\s*(const|let|var) \w+\s*=', re.M), 1),
+    ('javascript', re.compile(r'=>|console\.log\(|\brequire\(|\bawait fetch\(', re.M), 1),
+    ('java', re.compile(r'\bpublic (static )?(final )?(class|void|interface)\b', re.M), 2),
+    ('java', re.compile(r'System\.out\.print', re.M), 2),
+    ('go', re.compile(r'^package \w+\s*$', re.M), 2),
+    ('go', re.compile(r'\bfunc (\(\w+ \*?\w+\) )?\w*\(', re.M), 1),
+    ('go', re.compile(r':=|\bfmt\.\w+\(', re.M), 1),
+    ('c', re.compile(r'^#include\s*[<"]', re.M), 2),
+    ('c', re.compile(r'\bint main\s*\(|\bprintf\(', re.M), 1),
+    ('sql', re.compile(r'\bSELECT\b[\s\S]+\bFROM\b|\bINSERT INTO\b|\bCREATE TABLE\b|\bUPDATE \w+ SET\b', re.I), 2),
+    ('xml', re.compile(r'^<\?xml\b'), 2),
+    ('html', re.compile(r'<!DOCTYPE html|<html\b|<(div|span|body|head)\b', re.I), 2),
+]
+
+
+def is_monospaced(span: Dict[str, Any]) -> bool:
+    """Whether a PyMuPDF span is set in a fixed-pitch font (by flag or font name)"""
+    if span.get('flags', 0) & FLAG_MONOSPACED:
+        return True
+    font = (span.get('font') or '').split('+')[-1].lower().replace('-', '')
+    return any(name in font for name in MONOSPACE_FONTS)
+
+
+def page_lines(page_dict: Dict[str, Any]) -> List[Dict[str, Any]]:
+    """
+    Lines of a page's get_text("dict"), in reading order
+
+    Returns:
+        Dictionaries with text, x0, top, height, char_width, and monospaced
+        (every non-blank span is fixed-pitch)
+    """
+    lines = []
+    for block in page_dict.get('blocks', []):
+        if block.get('type', 0) != 0:
+            continue
+        for line in block.get('lines', []):
+            spans = [span for span in line.get('spans', []) if span.get('text')]
+            text = ''.join(span['text'] for span in spans)
+            if not text.strip():
+                continue
+            x0, top, x1, bottom = line['bbox']
+            lines.append({
+                'text': text,
+                'x0': x0,
+                'top': top,
+                'height': bottom - top,
+                'char_width': (x1 - x0) / len(text),
+                'monospaced': all(is_monospaced(span) for span in spans if span['text'].strip())
+            })
+    return lines
+
+
+def code_runs(lines: Sequence[Dict[str, Any]]) -> List[List[Dict[str, Any]]]:
+    """Runs of at least MIN_CODE_LINES consecutive monospaced lines"""
+    runs: List[List[Dict[str, Any]]] = []
+    current: List[Dict[str, Any]] = []
+    for line in lines:
+        far = current and line['top'] - current[-1]['top'] > MAX_GAP_HEIGHTS * current[-1]['height']
+        if line['monospaced'] and not far:
+            current.append(line)
+            continue
+        if len(current) >= MIN_CODE_LINES:
+            runs.append(current)
+        current = [line] if line['monospaced'] else []
+    if len(current) >= MIN_CODE_LINES:
+        runs.append(current)
+    return runs
+
+
+def gap_lines(previous: Dict[str, Any], line: Dict[str, Any], pitch: float) -> int:
+    """Blank lines between two lines of a run, given the run's line pitch"""
+    return max(0, round((line['top'] - previous['top']) / pitch) - 1)
+
+
+def code_text(run: Sequence[Dict[str, Any]]) -> str:
+    """The run's text with indentation and blank lines restored"""
+    left = min(line['x0'] for line in run)
+    widths = sorted(line['char_width'] for line in run)
+    char_width = widths[len(widths) // 2] or 1
+    # Line pitch: the smallest step from one line to the next
+    steps = [line['top'] - previous['top'] for previous, line in zip(run, run[1:]) if line['top'] > previous['top']]
+    pitch = min(steps) if steps else run[0]['height'] or 1
+    text_lines = []
+    for index, line in enumerate(run):
+        if index:
+            text_lines += [''] * gap_lines(run[index - 1], line, pitch)
+        indent = max(0, round((line['x0'] - left) / char_width))
+        text_lines.append((' ' * indent + line['text']).rstrip())
+    return '\n'.join(text_lines)
+
+
+def guess_language(code: str) -> str:
+    """Fence info string for a code sample, or '' when no language is clearly indicated"""
+    stripped = code.strip()
+    if stripped[:1] in '{[':
+        try:
+            json.loads(stripped)
+            return 'json'
+        except ValueError:
+            pass
+
+    scores: Dict[str, int] = {}
+    for language, pattern, weight in LANGUAGE_SIGNALS:
+        if pattern.search(code):
+            scores[language] = scores.get(language, 0) + weight
+    if not scores:
+        return ''
+    best = max(scores.values())
+    leaders = [language for language, score in scores.items() if score == best]
+    return leaders[0] if best >= 2 and len(leaders) == 1 else ''
+
+
+def fence_code(code: str, language: str = '') -> str:
+    """A fenced code block, with a fence longer than any backtick run in the code"""
+    longest = max((len(run) for run in re.findall(r'`+', code)), default=0)
+    fence = '`' * max(3, longest + 1)
+    return f"{fence}{language}\n{code}\n{fence}"
+
+
+def page_code_blocks(page_dict: Dict[str, Any]) -> List[Dict[str, Any]]:
+    """
+    Code blocks on a page
+
+    Returns:
+        Dictionaries with lines (the run's line texts, to find it in the
+        page text), code, and language
+    """
+    blocks = []
+    for run in code_runs(page_lines(page_dict)):
+        code = code_text(run)
+        blocks.append({'lines': [line['text'] for line in run], 'code': code, 'language': guess_language(code)})
+    return blocks
+
+
+def find_lines(text_lines: Sequence[str], wanted: Sequence[str], start: int) -> Optional[Tuple[int, int]]:
+    """First span of text_lines from start whose non-blank lines are wanted (ignoring outer whitespace)"""
+    wanted = [line.strip() for line in wanted]
+    for first in range(start, len(text_lines)):
+        if text_lines[first].strip() != wanted[0]:
+            continue
+        index, matched = first, 0
+        while index < len(text_lines) and matched < len(wanted):
+            current = text_lines[index].strip()
+            if current and current != wanted[matched]:
+                break
+            matched += 1 if current else 0
+            index += 1
+        if matched == len(wanted):
+            return first, index
+    return None
+
+
+def apply_code_blocks(text: str, blocks: Sequence[Dict[str, Any]]) -> Tuple[str, int]:
+    """
+    Replace each block's lines in the page text with its fenced code
+
+    Blocks are found in order; a block whose lines are not in the text
+    (reordered or altered by earlier steps) is skipped.
+
+    Returns:
+        The text and the number of blocks fenced
+    """
+    text_lines = text.split('\n')
+    applied = 0
+    position = 0
+    for block in blocks:
+        found = find_lines(text_lines, block['lines'], position)
+        if not found:
+            continue
+        first, end = found
+        fenced = fence_code(block['code'], block['language']).split('\n')
+        text_lines[first:end] = fenced
+        position = first + len(fenced)
+        applied += 1
+    return '\n'.join(text_lines), applied
+
+
+# A fenced block in page text (opening fence, content, same closing fence)
+FENCED_BLOCK = re.compile(r'(^(`{3,})[^\n`]*\n.*?\n\2[ \t]*$)', re.M | re.S)
+
+
+def split_fenced(text: str) -> List[Tuple[str, bool]]:
+    """Text cut into (part, is_code) pieces so prose fixes can skip code blocks"""
+    parts = FENCED_BLOCK.split(text)
+    # split() yields prose, block, fence, prose, ...; drop the captured fences
+    pieces = []
+    for index in range(0, len(parts), 3):
+        pieces.append((parts[index], False))
+        if index + 1 < len(parts):
+            pieces.append((parts[index + 1], True))
+    return pieces
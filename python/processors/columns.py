@@ -0,0 +1,145 @@
+"""
+Reading order for multi-column pages
+
+Academic papers set body text in two columns. When the PDF draws lines
+across both columns in turn, the extracted text interleaves them line by
+line. With column_layout 'auto' a page counts as two-column when several
+text blocks sit entirely on each side of the middle of the text area and
+the two sides run alongside each other; 'double' treats every page that
+way and 'single' keeps the PDF's own order.
+
+On a two-column page, blocks that cross the gutter (titles, abstracts,
+wide figures and tables) stay whole and split the page into bands; within
+each band the left column is read top to bottom, then the right. Blocks
+inside a detected table, and rows of three or more blocks on one line,
+count as full width, so table cells are not dealt out between columns.
+"""
+from typing import List, Optional, Sequence, Tuple
+
+COLUMN_LAYOUTS = ('auto', 'single', 'double')
+
+# (x0, y0, x1, y1, text) in page coordinates with y growing downwards
+Block = Tuple[float, float, float, float, str]
+Rect = Tuple[float, float, float, float]
+
+# Blocks needed on each side of the gutter before a page counts as two-column
+MIN_COLUMN_BLOCKS = 2
+
+# A column block is at most this fraction of the text area's width
+MAX_COLUMN_WIDTH = 0.6
+
+# Points a block may reach past the gutter and still belong to its column
+GUTTER_TOLERANCE = 2.0
+
+
+def validate_column_layout(column_layout: Optional[str]) -> str:
+    """column_layout, defaulting to 'auto' (raises ValueError for an unknown one)"""
+    column_layout = column_layout or 'auto'
+    if column_layout not in COLUMN_LAYOUTS:
+        raise ValueError(f"Unknown column_layout '{column_layout}' (expected one of: {', '.join(COLUMN_LAYOUTS)})")
+    return column_layout
+
+
+def gutter_x(blocks: Sequence[Block]) -> float:
+    """Middle of the text area the blocks cover"""
+    return (min(b[0] for b in blocks) + max(b[2] for b in blocks)) / 2
+
+
+def _inside(block: Block, region: Rect) -> bool:
+    x0, y0, x1, y1 = block[:4]
+    return x0 >= region[0] - 1 and y0 >= region[1] - 1 and x1 <= region[2] + 1 and y1 <= region[3] + 1
+
+
+def _same_line(a: Block, b: Block) -> bool:
+    """Whether two blocks share a line: each one's vertical middle lies within the other"""
+    return a[1] <= (b[1] + b[3]) / 2 <= a[3] and b[1] <= (a[1] + a[3]) / 2 <= b[3]
+
+
+def column_side(block: Block, gutter: float) -> Optional[str]:
+    """'left' or 'right' for a block on one side of the gutter, None for one crossing it"""
+    if block[2] <= gutter + GUTTER_TOLERANCE:
+        return 'left'
+    if block[0] >= gutter - GUTTER_TOLERANCE:
+        return 'right'
+    return None
+
+
+def full_width_flags(blocks: Sequence[Block], gutter: float, regions: Sequence[Rect] = ()) -> List[bool]:
+    """Per block: True when it crosses the gutter, lies in a table region, or shares its line with 2+ blocks"""
+    flags = []
+    for block in blocks:
+        row = sum(1 for other in blocks if other is not block and _same_line(block, other))
+        flags.append(column_side(block, gutter) is None or any(_inside(block, r) for r in regions) or row >= 2)
+    return flags
+
+
+def detect_columns(blocks: Sequence[Block], regions: Sequence[Rect] = ()) -> int:
+    """
+    Number of text columns on a page: 2 or 1
+
+    Two columns need MIN_COLUMN_BLOCKS narrow blocks on each side of the
+    gutter, with the left and right blocks overlapping vertically (side by
+    side rather than one under the other).
+    """
+    if len(blocks) < 2 * MIN_COLUMN_BLOCKS:
+        return 1
+    gutter = gutter_x(blocks)
+    width = max(b[2] for b in blocks) - min(b[0] for b in blocks)
+    sides = {'left': [], 'right': []}
+    for block, full_width in zip(blocks, full_width_flags(blocks, gutter, regions)):
+        if not full_width and block[2] - block[0] <= MAX_COLUMN_WIDTH * width:
+            sides[column_side(block, gutter)].append(block)
+    left, right = sides['left'], sides['right']
+    if len(left) < MIN_COLUMN_BLOCKS or len(right) < MIN_COLUMN_BLOCKS:
+        return 1
+    top, bottom = max(min(b[1] for b in left), min(b[1] for b in right)), min(max(b[3] for b in left),
+                                                                         max(b[3] for b in right))
+    return 2 if bottom > top else 1
+
+
+def order_blocks(blocks: Sequence[Block], columns: int, regions: Sequence[Rect] = (),
+                 right_to_left: bool = False) -> List[Block]:
+    """
+    Blocks in reading order
+
+    One column keeps the given order. Two columns: top to bottom, each
+    full-width block in place, and between them the left column's blocks
+    before the right column's (the right column's first with right_to_left).
+    """
+    if columns < 2 or not blocks:
+        return list(blocks)
+    gutter = gutter_x(blocks)
+    flags = full_width_flags(blocks, gutter, regions)
+    ordered: List[Block] = []
+    band: List[Block] = []
+
+    def flush():
+        for side in (('right', 'left') if right_to_left else ('left', 'right')):
+            ordered.extend(sorted((b for b in band if column_side(b, gutter) == side), key=lambda b: (b[1], b[0])))
+        band.clear()
+
+    for block, full_width in sorted(zip(blocks, flags), key=lambda item: (item[0][1], item[0][0])):
+        if full_width:
+            flush()
+            ordered.append(block)
+        else:
+            band.append(block)
+    flush()
+    return ordered
+
+
+def page_blocks_in_order(blocks: Sequence[Block], column_layout: str = 'auto',
+                         regions: Sequence[Rect] = (), right_to_left: bool = False) -> Tuple[List[Block], int]:
+    """
+    (blocks in reading order, columns) for column_layout
+
+    Args:
+        blocks: Text blocks of a page
+        column_layout: 'auto' detects, 'single' and 'double' force 1 or 2 columns
+        regions: Table bounding boxes; their blocks are kept together
+        right_to_left: Read the right column first (processors.reading_order)
+    """
+    if column_layout == 'single':
+        return list(blocks), 1
+    columns = 2 if column_layout == 'double' else detect_columns(blocks, regions)
+    return order_blocks(blocks, columns, regions, right_to_left), columns
@@ -0,0 +1,160 @@
+"""
+Strikethrough and underline (revision marks)
+
+Redlined contracts and specifications mark deletions with strikethrough
+and insertions with underline. Neither is a font property: the PDF draws a
+thin horizontal line across or under the glyphs, or carries a StrikeOut or
+Underline markup annotation, so plain page text shows deleted words exactly
+like kept ones. With preserve_text_decorations, each word crossed by a line
+at mid-height becomes ~~struck~~ and each word with a line along its
+baseline is wrapped in the underline marker (<u>...</u> by default).
+
+A line only decorates the words it spans end to end: a table border or a
+rule under a heading runs well past the text above it and is ignored.
+Words inside fenced code blocks are left unmarked (see links.wrap_word_runs).
+"""
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+try:
+    from .links import set_occurrences, words_in_rect, wrap_word_runs
+except ImportError:
+    from processors.links import set_occurrences, words_in_rect, wrap_word_runs
+
+DECORATIONS = ('strikethrough', 'underline')
+
+DEFAULT_UNDERLINE_MARKER = '<u>'
+
+# (x0, x1, y) of a horizontal line in page coordinates
+Rule = Tuple[float, float, float]
+
+# Thickest filled rectangle that still counts as a drawn line, in points
+MAX_RULE_THICKNESS = 2.0
+
+# Where a line crosses a word, as a fraction of the word box height from its top
+STRIKE_BAND = (0.4, 0.75)
+UNDERLINE_BAND = (0.75, 1.2)
+
+# Points a line may extend past the words it decorates
+RULE_SLACK = 4.0
+
+# Markup annotation types (PyMuPDF annot.type[1]) and the decoration they mark
+ANNOTATION_DECORATIONS = {'StrikeOut': 'strikethrough', 'Underline': 'underline'}
+
+
+def horizontal_rules(drawings: Sequence[Dict[str, Any]]) -> List[Rule]:
+    """Horizontal lines among a page's get_drawings() paths: stroked segments and hairline rectangles"""
+    rules = []
+    for path in drawings:
+        for item in path.get('items', []):
+            if item[0] == 'l':
+                (x0, y0), (x1, y1) = (item[1][0], item[1][1]), (item[2][0], item[2][1])
+                if abs(y1 - y0) <= 0.5 and abs(x1 - x0) > 1:
+                    rules.append((min(x0, x1), max(x0, x1), (y0 + y1) / 2))
+            elif item[0] == 're':
+                x0, y0, x1, y1 = item[1][0], item[1][1], item[1][2], item[1][3]
+                if 0 <= y1 - y0 <= MAX_RULE_THICKNESS and x1 - x0 > 3 * max(y1 - y0, 0.5):
+                    rules.append((x0, x1, (y0 + y1) / 2))
+    return rules
+
+
+def rule_decoration(word: Sequence[Any], rule: Rule) -> Optional[str]:
+    """'strikethrough' or 'underline' when the rule crosses most of the word at that height, else None"""
+    x0, y0, x1, y1 = word[:4]
+    overlap = min(x1, rule[1]) - max(x0, rule[0])
+    if x1 <= x0 or y1 <= y0 or overlap < 0.5 * (x1 - x0):
+        return None
+    position = (rule[2] - y0) / (y1 - y0)
+    if STRIKE_BAND[0] <= position < STRIKE_BAND[1]:
+        return 'strikethrough'
+    if UNDERLINE_BAND[0] <= position <= UNDERLINE_BAND[1]:
+        return 'underline'
+    return None
+
+
+def word_decorations(words: Sequence[Sequence[Any]], rules: Sequence[Rule],
+                     annotations: Sequence[Tuple[str, Sequence[float]]] = ()) -> Dict[int, str]:
+    """
+    Decoration of each decorated word
+
+    Args:
+        words: The page's get_text("words") tuples
+        rules: Horizontal lines from horizontal_rules
+        annotations: (annotation type, rect) of the page's markup annotations
+
+    Returns:
+        Index into words -> 'strikethrough' or 'underline'
+    """
+    decorated: Dict[int, str] = {}
+    for rule in rules:
+        for kind in DECORATIONS:
+            covered = [index for index, word in enumerate(words) if rule_decoration(word, rule) == kind]
+            if not covered:
+                continue
+            left = min(words[index][0] for index in covered)
+            right = max(words[index][2] for index in covered)
+            if rule[0] >= left - RULE_SLACK and rule[1] <= right + RULE_SLACK:
+                for index in covered:
+                    decorated.setdefault(index, kind)
+    positions = {tuple(word[5:8]): index for index, word in enumerate(words)}
+    for annotation_type, rect in annotations:
+        kind = ANNOTATION_DECORATIONS.get(annotation_type)
+        if kind:
+            for word in words_in_rect(words, rect):
+                decorated.setdefault(positions[tuple(word[5:8])], kind)
+    return decorated
+
+
+def decorated_runs(words: Sequence[Sequence[Any]], decorated: Dict[int, str]) -> List[Dict[str, Any]]:
+    """
+    Runs of consecutive words on one line with the same decoration, in reading order
+
+    Returns:
+        Dictionaries with kind, words, text, and occurrence (as links.page_links)
+    """
+    order = sorted(range(len(words)), key=lambda index: tuple(words[index][5:8]))
+    runs: List[Dict[str, Any]] = []
+    previous = None
+    for index in order:
+        kind = decorated.get(index)
+        if kind is None:
+            previous = None
+            continue
+        word = words[index]
+        if previous is not None and runs[-1]['kind'] == kind and tuple(word[5:7]) == tuple(words[previous][5:7]):
+            runs[-1]['words'].append(word)
+        else:
+            runs.append({'kind': kind, 'words': [word]})
+        previous = index
+    set_occurrences(runs, words)
+    return runs
+
+
+def underline_markers(marker: str) -> Tuple[str, str]:
+    """(opening, closing) for an underline marker: an HTML tag like <u> gets its closing tag, others repeat"""
+    if marker.startswith('<') and marker.endswith('>') and not marker.startswith('</'):
+        return marker, '</' + marker[1:].split()[0].rstrip('>') + '>'
+    return marker, marker
+
+
+def apply_decorations(text: str, runs: Sequence[Dict[str, Any]],
+                      underline_marker: str = DEFAULT_UNDERLINE_MARKER) -> Tuple[str, Dict[str, int]]:
+    """
+    Mark each run in the page text: ~~struck~~, underlined between underline_marker
+
+    An empty underline_marker leaves underlined text as it is.
+
+    Returns:
+        The text and the number of runs marked per decoration
+    """
+    opening, closing = underline_markers(underline_marker)
+    wanted = [run for run in runs if run['kind'] == 'strikethrough' or underline_marker]
+    counts = {kind: 0 for kind in DECORATIONS}
+
+    def render(matched: str, run: Dict[str, Any]) -> str:
+        counts[run['kind']] += 1
+        if run['kind'] == 'strikethrough':
+            return f"~~{matched}~~"
+        return f"{opening}{matched}{closing}"
+
+    text, _ = wrap_word_runs(text, wanted, render)
+    return text, counts
@@ -9,19 +9,28 @@ import re
 try:
     from ..utils.file_utils import FileUtils
     from ..utils.text_utils import TextUtils
+    from .markdown_sections import section_type_for_title, split_markdown_sections
 except ImportError:
     import sys
     sys.path.append(str(Path(__file__).parent.parent))
     from utils.file_utils import FileUtils
     from utils.text_utils import TextUtils
+    from processors.markdown_sections import section_type_for_title, split_markdown_sections
 
 
 class DocxExtractor:
     """Handles extraction of content from Microsoft Word documents"""
     
-    def __init__(self):
-        """Initialize the Word document extractor"""
+    def __init__(self, keep_data_uris: bool = False):
+        """
+        Initialize the Word document extractor
+        
+        Args:
+            keep_data_uris: Keep embedded images as full data URIs in the
+                markdown (markitdown otherwise truncates them)
+        """
         self.converter = MarkItDown()
+        self.keep_data_uris = keep_data_uris
     
     def extract_from_file(self, file_path: str) -> Dict[str, Any]:
         """
@@ -45,7 +54,10 @@ class DocxExtractor:
         
         try:
             # Convert Word document to markdown
-            result = self.converter.convert(str(file_path))
+            if self.keep_data_uris:
+                result = self.converter.convert(str(file_path), keep_data_uris=True)
+            else:
+                result = self.converter.convert(str(file_path))
             markdown_content = result.text_content
             
             # Extract document structure
@@ -86,54 +98,7 @@ class DocxExtractor:
     
     def _extract_sections(self, markdown_content: str) -> List[Dict[str, Any]]:
         """Extract sections from markdown content"""
-        sections = []
-        
-        # Split by headers (# ## ###)
-        header_pattern = r'^(#{1,6})\s+(.+)$'
-        lines = markdown_content.split('\n')
-        
-        current_section = None
-        current_content = []
-        
-        for line in lines:
-            header_match = re.match(header_pattern, line)
-            
-            if header_match:
-                # Save previous section if exists
-                if current_section:
-                    current_section['content'] = '\n'.join(current_content).strip()
-                    sections.append(current_section)
-                    current_content = []
-                
-                # Start new section
-                level = len(header_match.group(1))
-                title = header_match.group(2)
-                current_section = {
-                    'title': title,
-                    'level': level,
-                    'content': '',
-                    'section_type': self._determine_section_type(title)
-                }
-            elif current_section:
-                current_content.append(line)
-            elif line.strip():  # Content before first header
-                if not sections:
-                    sections.append({
-                        'title': 'Introduction',
-                        'level': 1,
-                        'content': '',
-                        'section_type': 'introduction'
-                    })
-                    current_section = sections[0]
-                current_content.append(line)
-        
-        # Save final section
-        if current_section:
-            current_section['content'] = '\n'.join(current_content).strip()
-            if current_section not in sections:
-                sections.append(current_section)
-        
-        return sections
+        return split_markdown_sections(markdown_content)
     
     def _extract_tables(self, markdown_content: str) -> List[Dict[str, Any]]:
         """Extract tables from markdown content"""
@@ -217,22 +182,7 @@ class DocxExtractor:
     
     def _determine_section_type(self, title: str) -> str:
         """Determine the type of section based on title"""
-        title_lower = title.lower()
-        
-        if any(word in title_lower for word in ['introduction', 'overview', 'summary', 'abstract']):
-            return 'introduction'
-        elif any(word in title_lower for word in ['conclusion', 'summary', 'final']):
-            return 'conclusion'
-        elif any(word in title_lower for word in ['method', 'approach', 'process']):
-            return 'methodology'
-        elif any(word in title_lower for word in ['result', 'finding', 'outcome']):
-            return 'results'
-        elif any(word in title_lower for word in ['reference', 'bibliography', 'citation']):
-            return 'references'
-        elif any(word in title_lower for word in ['appendix', 'annex', 'supplement']):
-            return 'appendix'
-        else:
-            return 'content'
+        return section_type_for_title(title)
     
     def _clean_text(self, text: str) -> str:
         """Clean and normalize text"""
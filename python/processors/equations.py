@@ -0,0 +1,247 @@
+"""
+Display equations
+
+Page text from PyMuPDF turns a typeset equation into a jumble of symbols,
+with fractions, sub- and superscripts flattened onto separate lines. With
+math_mode, whole lines set in math fonts (Computer Modern math, Cambria
+Math, STIX, Symbol) or dense with math symbols are grouped into equation
+regions, each region is cropped from the page as a PNG next to the other
+images, and its lines in the page text are replaced by a $$...$$ block:
+
+- image: a placeholder naming the equation, followed by the crop
+- latex: the LaTeX read from the crop by pix2tex (an image-to-LaTeX model,
+  an optional dependency); without it, the same as image
+
+An equation whose detection is uncertain, or whose LaTeX looks malformed,
+is marked with LOW_CONFIDENCE_NOTE and a link to its crop so it can be
+checked by eye. Symbols inside a prose line (inline math) are left alone;
+only whole lines count.
+"""
+import re
+from typing import Any, Callable, Dict, List, Optional, Sequence, Tuple
+
+try:
+    from .code_blocks import find_lines
+except ImportError:
+    from processors.code_blocks import find_lines
+
+MATH_MODES = ('off', 'image', 'latex')
+
+DEFAULT_MATH_MODE = 'off'
+
+# Font name fragments of math fonts (lower case, subset prefix and hyphens removed)
+MATH_FONTS = ('cmmi', 'cmsy', 'cmex', 'msam', 'msbm', 'rsfs', 'esint', 'math', 'symbol', 'stix', 'euclid',
+              'mtextra', 'mtsy', 'mtmi', 'txsy', 'pxsy')
+
+# Characters that rarely occur in prose outside mathematics
+MATH_SYMBOLS = set('=+−±∓×÷⋅∑∏∐∫∬∮√∞∂∇≤≥≠≈≡≅∼∝∈∉∋⊂⊃⊆⊇∪∩∧∨¬∀∃∅→←↔⇒⇐⇔↦⊕⊗⊥∥∠′″'
+                   'αβγδεϵζηθϑικλμνξπϖρϱσςτυφϕχψωΓΔΘΛΞΠΣΥΦΨΩ')
+
+# Prose words (four or more letters, not in a math font) that make a line text rather than an equation
+MAX_PROSE_WORDS = 3
+
+# Lowest line score (0-1) counted as math
+MIN_LINE_SCORE = 0.5
+
+# Equations detected with less confidence than this are marked for checking
+LOW_CONFIDENCE = 0.75
+
+# Line heights two lines of one equation may be apart (a fraction's numerator and denominator)
+MAX_GAP_HEIGHTS = 1.0
+
+# Fewest non-blank characters in an equation
+MIN_EQUATION_CHARS = 3
+
+# Points of margin around a crop
+CROP_MARGIN = 2.0
+
+# Resolution of equation crops
+EQUATION_DPI = 200
+
+LOW_CONFIDENCE_NOTE = '⚠️ Low-confidence equation conversion; check it against the original:'
+
+PROSE_WORD = re.compile(r'[A-Za-z]{4,}')
+
+
+def validate_math_mode(mode: Optional[str]) -> str:
+    """math_mode, defaulting to off (raises ValueError for an unknown one)"""
+    mode = mode or DEFAULT_MATH_MODE
+    if mode not in MATH_MODES:
+        raise ValueError(f"Unknown math_mode '{mode}' (expected one of: {', '.join(MATH_MODES)})")
+    return mode
+
+
+def is_math_font(span: Dict[str, Any]) -> bool:
+    """Whether a PyMuPDF span is set in a math font"""
+    font = (span.get('font') or '').split('+')[-1].lower().replace('-', '')
+    return any(name in font for name in MATH_FONTS)
+
+
+def line_score(spans: Sequence[Dict[str, Any]]) -> float:
+    """
+    How much a line looks like mathematics (0-1)
+
+    The larger of the share of its characters set in math fonts and its
+    math symbol density (one symbol in four characters scores 1); a line
+    with more than MAX_PROSE_WORDS prose words scores 0.
+    """
+    characters = math_font = symbols = prose = 0
+    for span in spans:
+        text = span.get('text') or ''
+        visible = len(text.replace(' ', ''))
+        characters += visible
+        if is_math_font(span):
+            math_font += visible
+        else:
+            prose += len(PROSE_WORD.findall(text))
+        symbols += sum(1 for char in text if char in MATH_SYMBOLS)
+    if not characters or prose > MAX_PROSE_WORDS:
+        return 0.0
+    return min(1.0, max(math_font / characters, 4 * symbols / characters))
+
+
+def page_equations(page_dict: Dict[str, Any]) -> List[Dict[str, Any]]:
+    """
+    Equation regions of a page's get_text("dict"), in reading order
+
+    Consecutive math lines less than MAX_GAP_HEIGHTS line heights apart
+    that overlap horizontally form one region.
+
+    Returns:
+        Dictionaries with lines (their text, for finding them in the page
+        text), bbox (the union of the lines' boxes), and confidence (the
+        mean line score)
+    """
+    regions: List[Dict[str, Any]] = []
+    current: Optional[Dict[str, Any]] = None
+    for block in page_dict.get('blocks', []):
+        if block.get('type', 0) != 0:
+            continue
+        for line in block.get('lines', []):
+            spans = [span for span in line.get('spans', []) if span.get('text')]
+            text = ''.join(span['text'] for span in spans)
+            if not text.strip():
+                continue
+            score = line_score(spans)
+            if score < MIN_LINE_SCORE:
+                current = None
+                continue
+            bbox = tuple(line['bbox'])
+            if current and joins_region(current, bbox):
+                current['lines'].append(text)
+                current['scores'].append(score)
+                current['bbox'] = union(current['bbox'], bbox)
+            else:
+                current = {'lines': [text], 'scores': [score], 'bbox': bbox}
+                regions.append(current)
+    equations = []
+    for region in regions:
+        if sum(len(line.replace(' ', '')) for line in region['lines']) < MIN_EQUATION_CHARS:
+            continue
+        equations.append({'lines': region['lines'], 'bbox': region['bbox'],
+                          'confidence': round(sum(region['scores']) / len(region['scores']), 2)})
+    return equations
+
+
+def joins_region(region: Dict[str, Any], bbox: Tuple[float, ...]) -> bool:
+    """Whether a math line at bbox continues the region above it"""
+    x0, top, x1, bottom = region['bbox']
+    height = max(bbox[3] - bbox[1], 1.0)
+    return bbox[1] - bottom <= MAX_GAP_HEIGHTS * height and bbox[0] < x1 and bbox[2] > x0
+
+
+def union(first: Tuple[float, ...], second: Tuple[float, ...]) -> Tuple[float, ...]:
+    return (min(first[0], second[0]), min(first[1], second[1]), max(first[2], second[2]), max(first[3], second[3]))
+
+
+def crop_rect(bbox: Tuple[float, ...]) -> Tuple[float, ...]:
+    """An equation's box with CROP_MARGIN on every side"""
+    return (bbox[0] - CROP_MARGIN, bbox[1] - CROP_MARGIN, bbox[2] + CROP_MARGIN, bbox[3] + CROP_MARGIN)
+
+
+def equation_filename(page: int, index: int) -> str:
+    return f"page{page:03d}-equation{index:02d}.png"
+
+
+def latex_looks_valid(latex: Optional[str]) -> bool:
+    """Whether model output is plausible LaTeX: non-empty, braces balanced, no runaway repetition"""
+    latex = (latex or '').strip()
+    if not latex or len(latex) > 1000:
+        return False
+    depth = 0
+    for char in latex.replace('\\{', '').replace('\\}', ''):
+        depth += {'{': 1, '}': -1}.get(char, 0)
+        if depth < 0:
+            return False
+    # Image-to-LaTeX models that lose track repeat one token over and over
+    return depth == 0 and not re.search(r'(\\?\w+|\S)(?:\s*\1){9,}', latex)
+
+
+def load_latex_model() -> Optional[Callable[[str], str]]:
+    """pix2tex's image-to-LaTeX model as a function of an image path, or None when it can't be loaded"""
+    try:
+        from PIL import Image
+        from pix2tex.cli import LatexOCR
+        model = LatexOCR()
+    except Exception:
+        return None
+
+    def to_latex(path: str) -> str:
+        with Image.open(path) as image:
+            return model(image)
+    return to_latex
+
+
+def equation_markdown(equation: Dict[str, Any], image_link: str) -> List[str]:
+    """
+    Markdown lines for one equation
+
+    Args:
+        equation: Dictionary with page, index, latex (None: use the
+            placeholder), and low_confidence
+        image_link: Link to the equation's crop
+    """
+    label = f"Equation {equation['index']} on page {equation['page']}"
+    image = f"![{label}]({image_link})"
+    if equation.get('latex'):
+        lines = ['$$', equation['latex'].strip(), '$$']
+        if equation['low_confidence']:
+            lines.append(f"{LOW_CONFIDENCE_NOTE} {image}")
+        return lines
+    lines = ['$$', f"\\text{{{label} (see image)}}", '$$']
+    if equation['low_confidence']:
+        return lines + [f"{LOW_CONFIDENCE_NOTE} {image}"]
+    return lines + [image]
+
+
+def apply_equations(text: str, equations: Sequence[Dict[str, Any]],
+                    render: Callable[[Dict[str, Any]], List[str]]) -> Tuple[str, int]:
+    """
+    Replace each equation's lines in the page text with its markdown
+
+    Equations are found in order, as in code_blocks.apply_code_blocks; one
+    whose lines are not in the text is skipped. render is only called for
+    the equations found, so nothing is cropped for the others.
+
+    Args:
+        equations: Regions from page_equations
+        render: Markdown lines for an equation (e.g. crop it and call equation_markdown)
+
+    Returns:
+        The text and the number of equations replaced
+    """
+    text_lines = text.split('\n')
+    applied = 0
+    position = 0
+    for equation in equations:
+        found = find_lines(text_lines, equation['lines'], position)
+        if not found:
+            continue
+        first, end = found
+        replacement = ([''] if first and text_lines[first - 1].strip() else []) + render(equation)
+        if end < len(text_lines) and text_lines[end].strip():
+            replacement.append('')
+        text_lines[first:end] = replacement
+        position = first + len(replacement)
+        applied += 1
+    return '\n'.join(text_lines), applied
@@ -0,0 +1,193 @@
+"""
+Interactive form (AcroForm) field extraction
+
+Government and legal forms carry their content in form fields: the page
+text holds only the labels, and the names and values filled in live in the
+AcroForm field tree, so text extraction loses them. This reads every
+terminal field - its fully qualified name (parent names joined by dots),
+type, value, tooltip label, choices, and the page its widget sits on - and
+renders them as a markdown table for a "Form Fields" section.
+
+The field tree is walked from /AcroForm /Fields as in signature_extractor,
+inheriting /FT, /Ff, /V, and /Opt from parent fields, so each field's
+widget annotations (and with them its page) stay known. Push buttons hold
+no data and are left out.
+"""
+from typing import Any, Dict, Iterator, List, Optional, Tuple
+
+FORM_FIELDS_TITLE = "Form Fields"
+
+# Field flags (/Ff bit positions, PDF 32000-1:2008 12.7.3 and 12.7.4)
+READ_ONLY_FLAG = 1 << 0
+RADIO_FLAG = 1 << 15
+PUSHBUTTON_FLAG = 1 << 16
+COMBO_FLAG = 1 << 17
+
+# Attributes a field takes from its parent when it has none of its own
+INHERITED = ('/FT', '/Ff', '/V', '/Opt')
+
+
+def field_type(ft: Optional[str], flags: int = 0) -> Optional[str]:
+    """Field type from /FT and /Ff: text, checkbox, radio, button, dropdown, list, or signature"""
+    if ft == '/Tx':
+        return 'text'
+    if ft == '/Btn':
+        if flags & PUSHBUTTON_FLAG:
+            return 'button'
+        return 'radio' if flags & RADIO_FLAG else 'checkbox'
+    if ft == '/Ch':
+        return 'dropdown' if flags & COMBO_FLAG else 'list'
+    if ft == '/Sig':
+        return 'signature'
+    return None
+
+
+def _name(value: Any) -> Optional[str]:
+    """A PDF name without its slash (/Yes -> Yes)"""
+    return str(value).lstrip('/') if value is not None else None
+
+
+def field_value(kind: str, value: Any) -> Any:
+    """
+    Field value as JSON: text, the checked state of a checkbox, the chosen
+    radio option, the selection of a choice (a list when several), or
+    whether a signature field is signed
+    """
+    if kind == 'checkbox':
+        return value is not None and _name(value) != 'Off'
+    if kind == 'radio':
+        return None if value is None or _name(value) == 'Off' else _name(value)
+    if kind == 'signature':
+        return value is not None
+    if value is None:
+        return None
+    if isinstance(value, list):
+        return [str(item) for item in value]
+    return str(value)
+
+
+def _choices(kind: str, attrs: Dict[str, Any], widgets: List[Any]) -> Optional[List[str]]:
+    """Display options of a choice field, or the on-states of a radio group's buttons"""
+    if kind in ('dropdown', 'list'):
+        options = attrs.get('/Opt') or []
+        # Each option is a display string or an [export value, display string] pair
+        return [str(option[1] if isinstance(option, list) and len(option) > 1 else option) for option in options]
+    if kind == 'radio':
+        states = []
+        for widget in widgets:
+            appearance = widget.get('/AP')
+            normal = appearance.get_object().get('/N') if appearance else None
+            for state in (normal.get_object().keys() if normal else []):
+                if _name(state) != 'Off' and _name(state) not in states:
+                    states.append(_name(state))
+        return states
+    return None
+
+
+def _iter_fields(refs: List[Any], parent_name: Optional[str] = None,
+                 inherited: Optional[Dict[str, Any]] = None) -> Iterator[Tuple[str, Dict[str, Any], List[Any]]]:
+    """Yield (qualified name, attributes, widget references) of each terminal field"""
+    for ref in refs or []:
+        field = ref.get_object()
+        partial = field.get('/T')
+        name = (f"{parent_name}.{partial}" if parent_name else str(partial)) if partial is not None else parent_name
+        attrs = {key: field.get(key, (inherited or {}).get(key)) for key in INHERITED}
+        kids = field.get('/Kids') or []
+        named_kids = [kid for kid in kids if '/T' in kid.get_object()]
+        if named_kids:
+            yield from _iter_fields(named_kids, name, attrs)
+        elif name:
+            # Terminal field: its kids (if any) are its widgets, else it is its own widget
+            yield name, {**attrs, '/TU': field.get('/TU')}, list(kids) or [ref]
+
+
+def _ref_id(ref: Any) -> Optional[int]:
+    return getattr(ref, 'idnum', None)
+
+
+def extract_form_fields(pdf_path: str) -> List[Dict[str, Any]]:
+    """
+    Read a PDF's form fields
+
+    Returns:
+        One entry per field in field-tree order: name, type, value, label
+        (tooltip, None when absent), page (of its first widget, None when
+        not placed), read_only, and options (choice fields and radio groups)
+        - an empty list for a PDF without a form
+    """
+    import pypdf
+
+    reader = pypdf.PdfReader(pdf_path, strict=False)
+    root = reader.trailer['/Root'].get_object()
+    acro_form = root.get('/AcroForm')
+    refs = acro_form.get_object().get('/Fields', []) if acro_form else []
+    if not refs:
+        return []
+
+    # Widget annotation -> page number
+    widget_pages = {}
+    for page_num, page in enumerate(reader.pages, 1):
+        annots = page.get('/Annots')
+        for annot in (annots.get_object() if annots else []):
+            widget_pages.setdefault(_ref_id(annot), page_num)
+
+    fields = []
+    for name, attrs, widgets in _iter_fields(refs):
+        kind = field_type(str(attrs['/FT']) if attrs['/FT'] is not None else None, int(attrs['/Ff'] or 0))
+        if kind is None or kind == 'button':
+            continue
+        value = attrs['/V']
+        value = value.get_object() if hasattr(value, 'get_object') else value
+        pages = [widget_pages[_ref_id(widget)] for widget in widgets if _ref_id(widget) in widget_pages]
+        entry = {
+            'name': name,
+            'type': kind,
+            'value': field_value(kind, value),
+            'label': str(attrs['/TU']) if attrs.get('/TU') is not None else None,
+            'page': min(pages) if pages else None,
+            'read_only': bool(int(attrs['/Ff'] or 0) & READ_ONLY_FLAG)
+        }
+        options = _choices(kind, attrs, [widget.get_object() for widget in widgets])
+        if options is not None:
+            entry['options'] = options
+        fields.append(entry)
+    return fields
+
+
+def _cell(text: Any) -> str:
+    """Table cell text: one line, pipes escaped"""
+    return ' '.join(str(text).split()).replace('|', '\\|')
+
+
+def format_field_value(field: Dict[str, Any]) -> str:
+    """A field's value as read in the markdown table"""
+    value = field['value']
+    if field['type'] == 'checkbox':
+        return 'checked' if value else 'unchecked'
+    if field['type'] == 'signature':
+        return 'signed' if value else 'not signed'
+    if isinstance(value, list):
+        return ', '.join(value)
+    return '' if value is None else str(value)
+
+
+def form_fields_markdown(fields: List[Dict[str, Any]]) -> str:
+    """Body of the Form Fields section: one table row per field"""
+    lines = [f"Interactive form fields in this PDF ({len(fields)}), with the values filled in.", "",
+             "| Field | Label | Type | Value | Page |", "|-------|-------|------|-------|------|"]
+    for field in fields:
+        lines.append(f"| {_cell(field['name'])} | {_cell(field['label'] or '')} | {field['type']} "
+                     f"| {_cell(format_field_value(field))} | {field['page'] or ''} |")
+    return '\n'.join(lines) + '\n'
+
+
+def form_fields_summary(fields: List[Dict[str, Any]]) -> Dict[str, Any]:
+    """Counts for the conversion result: fields, filled in, and per type"""
+    types: Dict[str, int] = {}
+    for field in fields:
+        types[field['type']] = types.get(field['type'], 0) + 1
+    return {
+        'fields': len(fields),
+        'filled': sum(1 for field in fields if field['value'] not in (None, '', False, [])),
+        'types': types
+    }
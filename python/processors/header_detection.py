@@ -0,0 +1,162 @@
+"""
+Header detection from font size and weight
+
+Pattern-based detection (TextUtils.is_header) promotes any short ALL-CAPS
+line to a heading, so acronym-heavy pages fill up with junk headings. With
+min_header_confidence set, headings are found from how lines are set
+instead: a line is a candidate only when its font is at least
+MIN_SIZE_RATIO times the page's body-text size (the character-weighted
+median span size). Its confidence grows with the size gain, bold weight,
+and heading-like shape (short, no closing period). Heading levels follow
+the distinct heading sizes across the document, largest first.
+"""
+import re
+import statistics
+import threading
+from typing import Any, Dict, List, Optional
+
+try:
+    from ..utils.cancellation import check_cancelled
+except ImportError:
+    from utils.cancellation import check_cancelled
+
+# Smallest font size gain over the page body text for a heading
+MIN_SIZE_RATIO = 1.1
+
+# Size gain at which the size part of the confidence is full
+FULL_SIZE_RATIO = 1.5
+
+# Longest line (words) that still reads as a heading
+MAX_HEADER_WORDS = 12
+
+# Confidence weights: size gain, bold, heading-like shape
+SIZE_WEIGHT = 0.6
+BOLD_WEIGHT = 0.25
+SHAPE_WEIGHT = 0.15
+
+# PyMuPDF span flag bit for bold
+FLAG_BOLD = 16
+
+
+def normalize_header_text(text: str) -> str:
+    """Line text as compared against extracted text: markdown marks dropped, whitespace collapsed"""
+    return re.sub(r'\s+', ' ', text.lstrip('#')).strip()
+
+
+def validate_header_confidence(value: Any) -> float:
+    """
+    Check min_header_confidence
+
+    Raises:
+        ValueError: If it is not a number between 0 and 1
+    """
+    try:
+        confidence = float(value)
+    except (TypeError, ValueError):
+        raise ValueError("min_header_confidence must be a number between 0 and 1")
+    if not 0 <= confidence <= 1:
+        raise ValueError("min_header_confidence must be between 0 and 1")
+    return confidence
+
+
+def span_is_bold(span: Dict[str, Any]) -> bool:
+    """Bold by flag or by font name (e.g. Helvetica-Bold)"""
+    font = span.get('font', '').lower()
+    return bool(span.get('flags', 0) & FLAG_BOLD) or any(w in font for w in ('bold', 'black', 'heavy'))
+
+
+def body_font_size(lines: List[Dict[str, Any]]) -> Optional[float]:
+    """Median font size of a page's text, weighted by characters"""
+    sizes = []
+    for line in lines:
+        sizes.extend([line['size']] * len(line['text']))
+    return statistics.median(sizes) if sizes else None
+
+
+def header_confidence(line: Dict[str, Any], body_size: float) -> float:
+    """
+    Confidence (0-1) that a line is a heading
+
+    0 unless the line's font is at least MIN_SIZE_RATIO times the body size.
+    """
+    if not body_size:
+        return 0.0
+    ratio = line['size'] / body_size
+    if ratio < MIN_SIZE_RATIO:
+        return 0.0
+
+    text = line['text'].strip()
+    size_score = min(1.0, (ratio - 1) / (FULL_SIZE_RATIO - 1))
+    heading_shape = len(text.split()) <= MAX_HEADER_WORDS and not text.endswith(('.', ',', ';'))
+    confidence = SIZE_WEIGHT * size_score + BOLD_WEIGHT * bool(line['bold']) + SHAPE_WEIGHT * heading_shape
+    return round(min(1.0, confidence), 2)
+
+
+def score_header_lines(pages: List[List[Dict[str, Any]]]) -> Dict[str, Dict[str, Any]]:
+    """
+    Score the lines of each page as headings
+
+    Args:
+        pages: Per page, its lines as dictionaries with page, text, size
+            (largest span size), and bold (every span bold)
+
+    Returns:
+        Normalized line text -> page, size, confidence, and level of its
+        first occurrence, for lines with a confidence above 0
+    """
+    headers: Dict[str, Dict[str, Any]] = {}
+    for lines in pages:
+        body_size = body_font_size(lines)
+        for line in lines:
+            confidence = header_confidence(line, body_size)
+            key = normalize_header_text(line['text'])
+            if confidence > 0 and key and key not in headers:
+                headers[key] = {'page': line['page'], 'size': line['size'], 'confidence': confidence}
+
+    # Largest heading size is level 1; sizes within half a point share a level
+    levels: List[float] = []
+    for size in sorted({header['size'] for header in headers.values()}, reverse=True):
+        if not levels or levels[-1] - size > 0.5:
+            levels.append(size)
+    for header in headers.values():
+        header['level'] = min(6, 1 + sum(1 for size in levels if size - header['size'] > 0.5))
+    return headers
+
+
+def detect_font_headers(pdf_path: str, pages: Optional[List[int]] = None,
+                        cancel_event: Optional[threading.Event] = None) -> Dict[str, Dict[str, Any]]:
+    """
+    Find heading lines from the font sizes and weights PyMuPDF reports
+
+    Args:
+        pdf_path: Path to the PDF
+        pages: Only these 1-based pages (default: all)
+        cancel_event: threading.Event checked between pages
+
+    Returns:
+        score_header_lines() result for the document
+    """
+    import fitz
+
+    page_lines = []
+    doc = fitz.open(pdf_path)
+    try:
+        for page_num in pages or range(1, doc.page_count + 1):
+            check_cancelled(cancel_event)
+            blocks = doc.load_page(page_num - 1).get_text("dict").get("blocks", [])
+            lines = []
+            for block in blocks:
+                for line in block.get("lines", []):
+                    spans = [span for span in line.get("spans", []) if span.get("text", "").strip()]
+                    if not spans:
+                        continue
+                    lines.append({
+                        'page': page_num,
+                        'text': "".join(span["text"] for span in line["spans"]).strip(),
+                        'size': round(max(span.get("size", 0) for span in spans), 1),
+                        'bold': all(span_is_bold(span) for span in spans)
+                    })
+            page_lines.append(lines)
+    finally:
+        doc.close()
+    return score_header_lines(page_lines)
@@ -0,0 +1,158 @@
+"""
+Images returned inline as MCP image content
+
+Conversions write images to disk and link them by path, which a model
+cannot open. extract_images returns the pictures themselves instead, as
+base64 data with a MIME type. The number of images and their size are
+capped so a figure-heavy document cannot flood the client: images larger
+than max_dimension are downscaled with PIL, and formats clients cannot
+display (JPEG 2000, JBIG2, CMYK JPEG, ...) are re-encoded as PNG.
+
+An image placed on several pages (a logo, a watermark) is returned once.
+"""
+import io
+import threading
+from typing import Any, Dict, List, Optional, Tuple
+
+try:
+    from .image_extractor import MIN_IMAGE_DIMENSION
+    from ..utils.cancellation import check_cancelled
+    from ..utils.page_range import parse_page_range
+except ImportError:
+    from processors.image_extractor import MIN_IMAGE_DIMENSION
+    from utils.cancellation import check_cancelled
+    from utils.page_range import parse_page_range
+
+DEFAULT_MAX_IMAGES = 10
+MAX_IMAGES_LIMIT = 50
+DEFAULT_MAX_DIMENSION = 1024
+MIN_DIMENSION = 16
+MAX_DIMENSION = 4096
+
+# Formats MCP clients display as-is
+INLINE_MIME_TYPES = {'png': 'image/png', 'jpeg': 'image/jpeg', 'jpg': 'image/jpeg'}
+
+
+def validate_image_limits(max_images: Any, max_dimension: Any) -> Tuple[int, int]:
+    """
+    Check max_images and max_dimension
+
+    Raises:
+        ValueError: If either is not an integer or is out of range
+    """
+    try:
+        max_images, max_dimension = int(max_images), int(max_dimension)
+    except (TypeError, ValueError):
+        raise ValueError("max_images and max_dimension must be integers")
+    if not 1 <= max_images <= MAX_IMAGES_LIMIT:
+        raise ValueError(f"max_images must be between 1 and {MAX_IMAGES_LIMIT}")
+    if not MIN_DIMENSION <= max_dimension <= MAX_DIMENSION:
+        raise ValueError(f"max_dimension must be between {MIN_DIMENSION} and {MAX_DIMENSION}")
+    return max_images, max_dimension
+
+
+def fit_image(data: bytes, ext: str, max_dimension: int) -> Tuple[bytes, str, int, int]:
+    """
+    Downscale an image so its longer side is at most max_dimension
+
+    Args:
+        data: Encoded image bytes
+        ext: Format of data as PyMuPDF reports it ('png', 'jpeg', 'jpx', ...)
+        max_dimension: Longest side in pixels
+
+    Returns:
+        (data, mime_type, width, height) - JPEGs stay JPEG, everything else is PNG
+    """
+    from PIL import Image
+
+    with Image.open(io.BytesIO(data)) as image:
+        image.load()
+        image.thumbnail((max_dimension, max_dimension))
+        keep_jpeg = ext in ('jpeg', 'jpg') and image.mode in ('RGB', 'L')
+        if not keep_jpeg and image.mode not in ('RGB', 'RGBA', 'L', 'LA'):
+            image = image.convert('RGBA' if 'A' in image.mode else 'RGB')
+        output = io.BytesIO()
+        if keep_jpeg:
+            image.save(output, format='JPEG', quality=85)
+        else:
+            image.save(output, format='PNG', optimize=True)
+        return output.getvalue(), 'image/jpeg' if keep_jpeg else 'image/png', image.width, image.height
+
+
+def extract_inline_images(pdf_path: str, pages: Optional[List[int]] = None,
+                          max_images: int = DEFAULT_MAX_IMAGES,
+                          max_dimension: int = DEFAULT_MAX_DIMENSION,
+                          cancel_event: Optional[threading.Event] = None,
+                          page_range: Optional[str] = None) -> Dict[str, Any]:
+    """
+    Extract images for returning as MCP image content
+
+    Args:
+        pdf_path: Path to the PDF
+        pages: Only these 1-based pages (default: all)
+        page_range: A page range spec instead of pages, checked against the page count
+        max_images: Return at most this many images, in page order
+        max_dimension: Longest side of a returned image in pixels
+        cancel_event: Stops between pages when set
+
+    Returns:
+        Dictionary with images (page, index, data, mime_type, width, height,
+        original_width, original_height, downscaled), page_count, and
+        omitted (images found beyond max_images)
+
+    Raises:
+        ValueError: If a limit or page is out of range
+    """
+    import fitz
+
+    max_images, max_dimension = validate_image_limits(max_images, max_dimension)
+
+    images: List[Dict[str, Any]] = []
+    seen = set()
+    omitted = 0
+    doc = fitz.open(pdf_path)
+    try:
+        if page_range:
+            pages = parse_page_range(page_range, doc.page_count)
+        if pages and max(pages) > doc.page_count:
+            raise ValueError(f"page_range: page {max(pages)} is past the end of the document ({doc.page_count} pages)")
+        for page_num in pages or range(1, doc.page_count + 1):
+            check_cancelled(cancel_event)
+            page = doc.load_page(page_num - 1)
+            for index, info in enumerate(page.get_images(full=True), 1):
+                xref = info[0]
+                if xref in seen:
+                    continue
+                seen.add(xref)
+                extracted = doc.extract_image(xref)
+                if not extracted or min(extracted.get('width', 0), extracted.get('height', 0)) < MIN_IMAGE_DIMENSION:
+                    continue
+                if len(images) >= max_images:
+                    omitted += 1
+                    continue
+
+                ext = extracted.get('ext', 'png')
+                width, height = extracted['width'], extracted['height']
+                data, mime_type = extracted['image'], INLINE_MIME_TYPES.get(ext)
+                # Re-encode when too large, in a format clients cannot show, or CMYK (colorspace 4)
+                downscaled = max(width, height) > max_dimension
+                if downscaled or mime_type is None or extracted.get('colorspace') == 4:
+                    data, mime_type, width, height = fit_image(data, ext, max_dimension)
+
+                images.append({
+                    'page': page_num,
+                    'index': index,
+                    'data': data,
+                    'mime_type': mime_type,
+                    'width': width,
+                    'height': height,
+                    'original_width': extracted['width'],
+                    'original_height': extracted['height'],
+                    'downscaled': downscaled
+                })
+            page = None  # Release the page before loading the next one
+        page_count = doc.page_count
+    finally:
+        doc.close()
+
+    return {'images': images, 'page_count': page_count, 'omitted': omitted}
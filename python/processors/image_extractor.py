@@ -0,0 +1,217 @@
+try:
+    from ..utils.file_utils import FileUtils
+    from .image_variants import image_hash
+    from .image_format import DEFAULT_IMAGE_QUALITY, encode_image
+    from ..utils.output_limits import MAX_IMAGES_BYTES, check_limit
+except ImportError:
+    # Handle running as script vs package
+    import sys
+    from pathlib import Path
+    sys.path.append(str(Path(__file__).parent.parent))
+    from utils.file_utils import FileUtils
+    from processors.image_variants import image_hash
+    from processors.image_format import DEFAULT_IMAGE_QUALITY, encode_image
+    from utils.output_limits import MAX_IMAGES_BYTES, check_limit
+"""
+Image extraction with document captions
+
+Identical images (a header logo or watermark on every page) are written
+once: each image's bytes are hashed and a repeat links to the file already
+saved instead of writing another copy.
+
+An image larger than max_image_bytes is skipped (listed in skipped), and
+once the images written pass max_images_bytes extraction stops with
+OutputLimitExceeded. Both limits apply to the image as embedded; the
+total counts the bytes written, after any image_format re-encoding.
+"""
+import hashlib
+import re
+from pathlib import Path
+from typing import Dict, List, Any, Optional, Sequence, Tuple
+
+# "Figure 3: ...", "Fig. 2.1 - ...", "Diagram A", "Exhibit IV." at the start of a text block
+CAPTION_PATTERN = re.compile(
+    r'^\s*(figure|fig\.?|image|illustration|diagram|chart|graph|exhibit|plate|photo|screenshot)'
+    r'\s*([0-9]+[a-z]?(?:[.\-][0-9]+)*|[IVXLC]+|[A-Z])\b\s*[:.\-–—]?\s*',
+    re.IGNORECASE
+)
+
+# Maximum vertical distance (points) between an image and its caption
+DEFAULT_CAPTION_GAP = 48.0
+
+# Images smaller than this (pixels, either side) are icons or rules, not figures
+MIN_IMAGE_DIMENSION = 16
+
+# (x0, y0, x1, y1, text) in page coordinates with y growing downwards
+TextBlock = Tuple[float, float, float, float, str]
+
+
+def find_document_caption(image_bbox: Sequence[float], text_blocks: List[TextBlock],
+                          max_gap: float = DEFAULT_CAPTION_GAP) -> Optional[Dict[str, Any]]:
+    """
+    Find the document's own caption for an image
+
+    A caption is a text block starting with a figure label (see
+    CAPTION_PATTERN) that overlaps the image horizontally and sits directly
+    below or above it. The closest candidate wins; below wins ties since
+    that is the usual placement.
+
+    Args:
+        image_bbox: (x0, y0, x1, y1) of the image on the page
+        text_blocks: Text blocks of the same page
+        max_gap: Maximum vertical gap in points
+
+    Returns:
+        Dictionary with text, position ('below' or 'above'), and gap, or None
+    """
+    ix0, iy0, ix1, iy1 = image_bbox
+    best = None
+    for bx0, by0, bx1, by1, text in text_blocks:
+        text = re.sub(r'\s+', ' ', text or '').strip()
+        if not text or not CAPTION_PATTERN.match(text):
+            continue
+        if min(ix1, bx1) - max(ix0, bx0) <= 0:
+            continue
+
+        for position, gap in (('below', by0 - iy1), ('above', iy0 - by1)):
+            # Allow a small overlap for captions set tight against the image
+            if -2.0 <= gap <= max_gap:
+                rank = (max(gap, 0.0), 0 if position == 'below' else 1)
+                if best is None or rank < best[0]:
+                    best = (rank, {'text': text, 'position': position, 'gap': round(max(gap, 0.0), 1)})
+
+    return best[1] if best else None
+
+
+def caption_alt_text(caption: str) -> str:
+    """Alt text from a caption: the description without its "Figure N:" label"""
+    description = CAPTION_PATTERN.sub('', caption, count=1).strip()
+    return description or caption.strip()
+
+
+class ImageExtractor:
+    """Extracts embedded images and pairs them with the document's captions"""
+
+    def __init__(self, images_dir: Path, use_document_captions: bool = True,
+                 caption_gap: float = DEFAULT_CAPTION_GAP, dedupe: bool = True,
+                 max_image_bytes: Optional[int] = None, max_images_bytes: Optional[int] = None,
+                 image_format: str = 'original', image_quality: int = DEFAULT_IMAGE_QUALITY):
+        """
+        Initialize image extractor
+
+        Args:
+            images_dir: Directory for extracted image files (created on first image)
+            use_document_captions: Look for "Figure N: ..." text near each image
+            caption_gap: Maximum image-to-caption distance in points
+            dedupe: Write byte-identical images once and link repeats to that file
+            max_image_bytes: Skip images larger than this (None: no limit)
+            max_images_bytes: Stop once images written pass this total (None: no limit)
+            image_format: 'original' keeps the embedded encoding, or 'png', 'jpeg', 'webp'
+            image_quality: Quality (1-100) for jpeg and webp
+        """
+        self.images_dir = Path(images_dir)
+        self.use_document_captions = use_document_captions
+        self.caption_gap = caption_gap
+        self.dedupe = dedupe
+        self.max_image_bytes = max_image_bytes
+        self.max_images_bytes = max_images_bytes
+        # Images left out for max_image_bytes: page, index, bytes
+        self.skipped: List[Dict[str, Any]] = []
+        self.image_format = image_format
+        self.image_quality = image_quality
+        # Bytes written, and the embedded size of the same files before re-encoding
+        self.bytes_written = 0
+        self.original_bytes = 0
+        # Files kept in their embedded encoding because PIL could not decode them,
+        # and because re-encoding would not have made them smaller
+        self.unconverted = 0
+        self.kept_smaller = 0
+
+    def extract(self, pdf_path: str, pages: Optional[List[int]] = None) -> List[Dict[str, Any]]:
+        """
+        Extract every image placed on a page
+        
+        Args:
+            pdf_path: Path to the PDF
+            pages: Only these 1-based pages (default: all)
+
+        Returns:
+            List of image dictionaries with page, index, file, width, height,
+            bbox, caption, alt_text, caption_source, phash (perceptual
+            hash for finding resolution variants), sha256, and duplicate
+            (True when file was written for an earlier, identical image)
+        """
+        import fitz
+
+        images = []
+        # sha256 -> (file, phash) of images already written
+        saved: Dict[str, Tuple[Path, Optional[str]]] = {}
+        doc = fitz.open(pdf_path)
+        try:
+            for page_index in range(doc.page_count):
+                page_num = page_index + 1
+                if pages and page_num not in pages:
+                    continue
+                page = doc.load_page(page_index)
+                text_blocks = None
+
+                for index, info in enumerate(page.get_images(full=True), 1):
+                    xref = info[0]
+                    extracted = doc.extract_image(xref)
+                    if not extracted or min(extracted.get('width', 0), extracted.get('height', 0)) < MIN_IMAGE_DIMENSION:
+                        continue
+                    if self.max_image_bytes is not None and len(extracted['image']) > self.max_image_bytes:
+                        self.skipped.append({'page': page_num, 'index': index, 'bytes': len(extracted['image'])})
+                        continue
+
+                    rects = page.get_image_rects(xref)
+                    bbox = tuple(round(v, 1) for v in rects[0]) if rects else None
+
+                    caption = None
+                    if self.use_document_captions and bbox:
+                        if text_blocks is None:
+                            text_blocks = [tuple(b[:5]) for b in page.get_text("blocks") if b[6] == 0]
+                        caption = find_document_caption(bbox, text_blocks, self.caption_gap)
+
+                    digest = hashlib.sha256(extracted['image']).hexdigest()
+                    duplicate = self.dedupe and digest in saved
+                    if duplicate:
+                        image_file, phash = saved[digest]
+                    else:
+                        FileUtils.ensure_directory(self.images_dir)
+                        ext = extracted.get('ext', 'png')
+                        data, output_ext, outcome = encode_image(extracted['image'], ext, self.image_format,
+                                                                 self.image_quality)
+                        if outcome == 'undecodable':
+                            self.unconverted += 1
+                        elif outcome == 'larger':
+                            self.kept_smaller += 1
+                        image_file = self.images_dir / f"page{page_num:03d}-img{index:02d}.{output_ext}"
+                        image_file.write_bytes(data)
+                        self.bytes_written += len(data)
+                        self.original_bytes += len(extracted['image'])
+                        check_limit(MAX_IMAGES_BYTES, self.max_images_bytes, self.bytes_written)
+                        phash = image_hash(doc, xref)
+                        saved.setdefault(digest, (image_file, phash))
+
+                    images.append({
+                        'page': page_num,
+                        'index': index,
+                        'file': str(image_file),
+                        'width': extracted.get('width'),
+                        'height': extracted.get('height'),
+                        'bbox': list(bbox) if bbox else None,
+                        'caption': caption['text'] if caption else None,
+                        'caption_position': caption['position'] if caption else None,
+                        'alt_text': caption_alt_text(caption['text']) if caption else f"Image from page {page_num}",
+                        'caption_source': 'document' if caption else None,
+                        'phash': phash,
+                        'sha256': digest,
+                        'duplicate': duplicate
+                    })
+
+                page = None  # Release the page before loading the next one
+        finally:
+            doc.close()
+
+        return images
@@ -0,0 +1,119 @@
+"""
+Output format of extracted images
+
+Images are written in the encoding the PDF embeds them in unless
+image_format asks for png, jpeg, or webp, in which case PIL re-encodes
+each one (jpeg and webp at image_quality). Scanned photos stored as
+lossless streams shrink several times over as jpeg or webp.
+
+JPEG has no alpha channel, so transparent images are flattened onto
+white; CMYK and other color spaces are converted to RGB first, since
+webp and png cannot hold them and CMYK JPEGs render inverted in many
+viewers. An image PIL cannot decode (JBIG2 masks, some JPEG 2000) keeps
+its original encoding, and so does one whose re-encoding comes out no
+smaller than the embedded stream (already well-compressed JPEGs, tiny
+icons) - unless the embedded format is one browsers can't show
+(JPEG 2000, JBIG2, ...), where the re-encoding is what makes it usable.
+"""
+import io
+from typing import Any, Optional, Tuple
+
+IMAGE_FORMATS = ('original', 'png', 'jpeg', 'webp')
+
+# File extension and PIL format name per image_format
+FORMAT_EXTENSIONS = {'png': 'png', 'jpeg': 'jpg', 'webp': 'webp'}
+PIL_FORMATS = {'png': 'PNG', 'jpeg': 'JPEG', 'webp': 'WEBP'}
+
+DEFAULT_IMAGE_QUALITY = 85
+
+# Embedded encodings markdown viewers display as they are
+WEB_EXTENSIONS = ('png', 'jpeg', 'jpg', 'webp', 'gif')
+
+
+def validate_image_format(image_format: Any, image_quality: Any = None) -> Tuple[str, int]:
+    """
+    Check image_format and image_quality (1-100, for jpeg and webp)
+
+    Returns:
+        (image_format, image_quality) with defaults filled in
+
+    Raises:
+        ValueError: For an unknown format or a quality out of range
+    """
+    image_format = image_format or 'original'
+    if image_format not in IMAGE_FORMATS:
+        raise ValueError(f"Unknown image_format '{image_format}' (expected one of: {', '.join(IMAGE_FORMATS)})")
+    if image_quality is None:
+        return image_format, DEFAULT_IMAGE_QUALITY
+    try:
+        image_quality = int(image_quality)
+    except (TypeError, ValueError):
+        raise ValueError("image_quality must be an integer")
+    if not 1 <= image_quality <= 100:
+        raise ValueError("image_quality must be between 1 and 100")
+    return image_format, image_quality
+
+
+def convert_image(data: bytes, ext: str, image_format: str,
+                  image_quality: int = DEFAULT_IMAGE_QUALITY) -> Tuple[bytes, str]:
+    """
+    Re-encode an extracted image in the requested format
+
+    Returns:
+        (data, ext) - see encode_image
+    """
+    data, ext, _ = encode_image(data, ext, image_format, image_quality)
+    return data, ext
+
+
+def encode_image(data: bytes, ext: str, image_format: str,
+                 image_quality: int = DEFAULT_IMAGE_QUALITY) -> Tuple[bytes, str, str]:
+    """
+    Re-encode an extracted image in the requested format, if that helps
+
+    Args:
+        data: Encoded image bytes as extracted
+        ext: Their format as PyMuPDF reports it ('png', 'jpeg', 'jpx', ...)
+        image_format: One of IMAGE_FORMATS
+        image_quality: Quality for jpeg and webp
+
+    Returns:
+        (data, ext, outcome) - outcome 'converted', or the input unchanged
+        with 'original' (image_format 'original'), 'undecodable' (PIL can't
+        read it), or 'larger' (the re-encoding was no smaller)
+    """
+    if image_format == 'original':
+        return data, ext, 'original'
+    converted = _reencode(data, image_format, image_quality)
+    if converted is None:
+        return data, ext, 'undecodable'
+    if len(converted) >= len(data) and ext.lower() in WEB_EXTENSIONS:
+        return data, ext, 'larger'
+    return converted, FORMAT_EXTENSIONS[image_format], 'converted'
+
+
+def _reencode(data: bytes, image_format: str, image_quality: int) -> Optional[bytes]:
+    """data encoded as image_format, or None if PIL is missing or can't read it"""
+    try:
+        from PIL import Image
+    except ImportError:
+        return None
+
+    try:
+        with Image.open(io.BytesIO(data)) as image:
+            image.load()
+            has_alpha = 'A' in image.mode or (image.mode == 'P' and 'transparency' in image.info)
+            if image.mode not in ('RGB', 'RGBA', 'L', 'LA'):
+                image = image.convert('RGBA' if has_alpha else 'RGB')
+            if image_format == 'jpeg' and image.mode in ('RGBA', 'LA'):
+                background = Image.new('RGB', image.size, (255, 255, 255))
+                background.paste(image.convert('RGBA'), mask=image.convert('RGBA').split()[-1])
+                image = background
+            output = io.BytesIO()
+            if image_format == 'png':
+                image.save(output, format='PNG', optimize=True)
+            else:
+                image.save(output, format=PIL_FORMATS[image_format], quality=image_quality)
+            return output.getvalue()
+    except Exception:
+        return None
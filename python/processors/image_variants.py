@@ -0,0 +1,186 @@
+"""
+Resolution variants of the same image
+
+Some PDFs embed a figure twice: a small preview and the full-resolution
+original. Both are extracted as separate files and a section may end up
+linking the blurry one. A difference hash (dHash) of each image's
+grayscale pixels stays nearly identical across resolutions, so variants
+are found by hash distance plus a matching aspect ratio; the largest
+variant is kept and every place a smaller one appeared links to it.
+"""
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+IMAGE_VARIANT_MODES = ('highest_resolution', 'keep_all')
+
+# dHash size: (HASH_SIZE + 1) x HASH_SIZE cells give a 64-bit hash
+HASH_SIZE = 8
+
+# Hashes at most this many bits apart are the same picture
+DEFAULT_MAX_HASH_DISTANCE = 6
+
+# Aspect ratios (width / height) within this fraction of each other match
+ASPECT_TOLERANCE = 0.05
+
+# Pixels sampled per cell side when averaging (keeps large images cheap)
+CELL_SAMPLES = 4
+
+
+def difference_hash(pixels: bytes, width: int, height: int, hash_size: int = HASH_SIZE) -> int:
+    """
+    dHash of a grayscale image
+
+    The image is averaged down to (hash_size + 1) x hash_size cells and each
+    bit records whether a cell is brighter than its right neighbour.
+
+    Args:
+        pixels: One byte per pixel, row by row
+        width: Image width in pixels
+        height: Image height in pixels
+    """
+    columns = hash_size + 1
+    cells = []
+    for row in range(hash_size):
+        y0, y1 = row * height // hash_size, max((row + 1) * height // hash_size, row * height // hash_size + 1)
+        for column in range(columns):
+            x0, x1 = column * width // columns, max((column + 1) * width // columns, column * width // columns + 1)
+            ys = range(y0, y1, max((y1 - y0) // CELL_SAMPLES, 1))
+            xs = range(x0, x1, max((x1 - x0) // CELL_SAMPLES, 1))
+            values = [pixels[min(y, height - 1) * width + min(x, width - 1)] for y in ys for x in xs]
+            cells.append(sum(values) / len(values))
+
+    value = 0
+    for row in range(hash_size):
+        for column in range(hash_size):
+            left = cells[row * columns + column]
+            right = cells[row * columns + column + 1]
+            value = (value << 1) | (1 if left > right else 0)
+    return value
+
+
+def hash_distance(a: int, b: int) -> int:
+    """Number of differing bits between two hashes"""
+    return bin(a ^ b).count('1')
+
+
+def image_hash(doc, xref: int) -> Optional[str]:
+    """dHash of an embedded image as 16 hex digits, or None if it can't be decoded"""
+    import fitz
+
+    try:
+        pixmap = fitz.Pixmap(doc, xref)
+        if pixmap.alpha:
+            pixmap = fitz.Pixmap(pixmap, 0)
+        if pixmap.n != 1:
+            pixmap = fitz.Pixmap(fitz.csGRAY, pixmap)
+        # Rows may be padded: copy exactly width bytes from each
+        samples = pixmap.samples
+        if pixmap.stride != pixmap.width:
+            samples = b''.join(samples[y * pixmap.stride:y * pixmap.stride + pixmap.width]
+                               for y in range(pixmap.height))
+        return f"{difference_hash(samples, pixmap.width, pixmap.height):016x}"
+    except Exception:
+        return None
+
+
+def _aspect(image: Dict[str, Any]) -> float:
+    return (image.get('width') or 1) / (image.get('height') or 1)
+
+
+def group_image_variants(images: List[Dict[str, Any]],
+                         max_distance: int = DEFAULT_MAX_HASH_DISTANCE) -> List[List[int]]:
+    """
+    Group images that are the same picture at different resolutions
+
+    Images match when their phash values are at most max_distance bits
+    apart and their aspect ratios agree; matches are transitive. Images
+    without a phash are never grouped.
+
+    Returns:
+        Groups of image indices (only groups with more than one image)
+    """
+    parent = list(range(len(images)))
+
+    def find(index: int) -> int:
+        while parent[index] != index:
+            parent[index] = parent[parent[index]]
+            index = parent[index]
+        return index
+
+    hashes = [int(image['phash'], 16) if image.get('phash') else None for image in images]
+    for i in range(len(images)):
+        for j in range(i + 1, len(images)):
+            if hashes[i] is None or hashes[j] is None:
+                continue
+            aspect_i, aspect_j = _aspect(images[i]), _aspect(images[j])
+            if abs(aspect_i - aspect_j) > ASPECT_TOLERANCE * max(aspect_i, aspect_j):
+                continue
+            if hash_distance(hashes[i], hashes[j]) <= max_distance:
+                parent[find(j)] = find(i)
+
+    groups: Dict[int, List[int]] = {}
+    for index in range(len(images)):
+        groups.setdefault(find(index), []).append(index)
+    return [group for group in groups.values() if len(group) > 1]
+
+
+def consolidate_image_variants(images: List[Dict[str, Any]],
+                               max_distance: int = DEFAULT_MAX_HASH_DISTANCE
+                               ) -> Tuple[List[Dict[str, Any]], List[Dict[str, Any]]]:
+    """
+    Point every resolution variant at the highest-resolution one
+
+    A smaller variant's entry keeps its page, caption, and alt text but takes
+    the kept image's file and size, with the file it replaced in
+    replaced_file. An entry is dropped only when the kept image already
+    appears on the same page.
+
+    Returns:
+        (images, consolidations) - each consolidation has kept (file, width,
+        height) and replaced [(file, page, width, height)]
+    """
+    replacement: Dict[int, int] = {}
+    consolidations = []
+    for group in group_image_variants(images, max_distance):
+        keeper = max(group, key=lambda i: ((images[i].get('width') or 0) * (images[i].get('height') or 0), -i))
+        kept = images[keeper]
+        # Deduplicated copies already share the kept file; they aren't variants
+        replaced = [i for i in group if images[i]['file'] != kept['file']]
+        if not replaced:
+            continue
+        for index in replaced:
+            replacement[index] = keeper
+        first_by_file: Dict[str, int] = {}
+        for index in sorted(replaced):
+            first_by_file.setdefault(images[index]['file'], index)
+        consolidations.append({
+            'kept': {'file': kept['file'], 'width': kept.get('width'), 'height': kept.get('height')},
+            'replaced': [{'file': images[i]['file'], 'page': images[i].get('page'),
+                          'width': images[i].get('width'), 'height': images[i].get('height')}
+                         for i in first_by_file.values()]
+        })
+
+    result = []
+    seen = set()
+    for index, image in enumerate(images):
+        if index in replacement:
+            kept = images[replacement[index]]
+            if (kept['file'], image.get('page')) in seen or kept.get('page') == image.get('page'):
+                continue
+            image = {**image, 'file': kept['file'], 'width': kept.get('width'), 'height': kept.get('height'),
+                     'replaced_file': image['file']}
+        seen.add((image['file'], image.get('page')))
+        result.append(image)
+    return result, consolidations
+
+
+def remove_replaced_files(consolidations: List[Dict[str, Any]]) -> int:
+    """Delete the image files of replaced variants; returns how many were removed"""
+    removed = 0
+    for consolidation in consolidations:
+        for variant in consolidation['replaced']:
+            path = Path(variant['file'])
+            if path.exists() and variant['file'] != consolidation['kept']['file']:
+                path.unlink()
+                removed += 1
+    return removed
@@ -0,0 +1,100 @@
+try:
+    from ..utils.text_utils import TextUtils
+    from ..utils.file_utils import FileUtils
+except ImportError:
+    # Handle running as script vs package
+    import sys
+    from pathlib import Path
+    sys.path.append(str(Path(__file__).parent.parent))
+    from utils.text_utils import TextUtils
+    from utils.file_utils import FileUtils
+"""
+Keyword index from emphasized text
+"""
+import re
+from pathlib import Path
+from typing import Dict, List, Any, Iterable, Optional, Tuple
+from datetime import datetime
+
+
+class KeywordExtractor:
+    """Aggregates bold/italic terms into a keyword index"""
+
+    MIN_TERM_LENGTH = 3
+    MAX_TERM_WORDS = 5
+
+    # Emphasized for attention rather than as terms
+    EMPHASIS_NOISE = {
+        'note', 'notes', 'warning', 'caution', 'important', 'tip', 'example',
+        'examples', 'required', 'optional', 'deprecated', 'must', 'should',
+        'never', 'always', 'only', 'not', 'yes', 'no', 'this', 'that', 'with',
+        'from', 'will', 'may', 'also', 'more', 'each', 'any', 'none', 'true', 'false'
+    }
+
+    def normalize_term(self, text: str) -> Optional[str]:
+        """Clean an emphasized run into a term, or None if it isn't keyword-like"""
+        term = re.sub(r'\s+', ' ', text).strip(" \t:;,.()[]\"'“”‘’-–—•*")
+        if len(term) < self.MIN_TERM_LENGTH or not re.search(r'[A-Za-z]', term):
+            return None
+
+        words = term.split(' ')
+        if len(words) > self.MAX_TERM_WORDS:
+            return None
+
+        common = TextUtils.STOP_WORDS | self.EMPHASIS_NOISE
+        if all(w.lower().strip(".,") in common for w in words):
+            return None
+
+        # Page references and numbering ("Page 4", "Figure 2", "1.2.3")
+        if re.match(r'^(page|figure|fig\.?|table|section|chapter)\s+[\d.]+$', term, re.IGNORECASE):
+            return None
+
+        return term
+
+    def aggregate(self, spans: Iterable[Tuple[int, str, str]]) -> List[Dict[str, Any]]:
+        """
+        Aggregate emphasized runs into distinct terms
+
+        Args:
+            spans: (page_num, text, style) tuples in document order
+
+        Returns:
+            Keyword entries sorted by occurrence count, then first appearance
+        """
+        keywords: Dict[str, Dict[str, Any]] = {}
+        for page_num, text, style in spans:
+            term = self.normalize_term(text)
+            if not term:
+                continue
+
+            key = term.lower()
+            entry = keywords.get(key)
+            if entry is None:
+                entry = keywords[key] = {
+                    'term': term,
+                    'count': 0,
+                    'first_page': page_num,
+                    'pages': [],
+                    'styles': []
+                }
+            entry['count'] += 1
+            if page_num not in entry['pages']:
+                entry['pages'].append(page_num)
+            if style not in entry['styles']:
+                entry['styles'].append(style)
+
+        return sorted(keywords.values(), key=lambda k: (-k['count'], k['first_page'], k['term'].lower()))
+
+    def write_keywords_json(self, keywords: List[Dict[str, Any]], output_file: Path, source_file: str,
+                            include_timestamp: bool = True) -> Path:
+        """Write the keyword index (without generated_at for canonical output)"""
+        document = {
+            'source_file': source_file,
+            'generated_at': datetime.now().isoformat(),
+            'keyword_count': len(keywords),
+            'keywords': keywords
+        }
+        if not include_timestamp:
+            del document['generated_at']
+        FileUtils.write_json(document, output_file)
+        return output_file
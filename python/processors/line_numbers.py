@@ -0,0 +1,139 @@
+"""
+Margin line numbers in legal and legislative documents
+
+Pleadings, bills, and statutes print a column of line numbers in the
+margin. Plain text extraction interleaves them with the body as stray
+numbers. These helpers find the column (ascending integers sharing an x
+position outside the text block) so the extractor can drop it, or turn it
+into [L12] anchors at the start of the lines it labels.
+"""
+import re
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+LINE_NUMBER_MODES = ('keep', 'strip', 'preserve')
+
+# Fewest numbers that make a column; a few page numbers or list markers don't
+MIN_LINE_NUMBERS = 5
+
+# Points two numbers' aligned edges may differ and still share a column
+ALIGN_TOLERANCE = 3.0
+
+# (x0, y0, x1, y1, text) as returned by page.get_text("words")[:5]
+Word = Tuple[float, float, float, float, str]
+
+
+def _column_key(value: float) -> int:
+    return int(round(value / ALIGN_TOLERANCE))
+
+
+def detect_margin_line_numbers(words: Sequence[Word]) -> Optional[Dict[str, Any]]:
+    """
+    Find a margin column of line numbers on a page
+
+    Numbers are grouped by their right edge (left margin, right-aligned) or
+    left edge (right margin). The largest group qualifies when it has at
+    least MIN_LINE_NUMBERS entries, the values ascend top to bottom, and
+    the whole column sits outside the body text.
+
+    Args:
+        words: Words of one page with their bounding boxes
+
+    Returns:
+        Dictionary with side ('left' or 'right'), boundary (x coordinate
+        separating numbers from text), and numbers [(value, y_center)], or
+        None if the page has no line-number column
+    """
+    numeric = [w for w in words if re.fullmatch(r'\d{1,4}', w[4].strip())]
+    if len(numeric) < MIN_LINE_NUMBERS:
+        return None
+
+    best = None
+    for side, edge in (('left', 2), ('right', 0)):
+        groups: Dict[int, List[Word]] = {}
+        for word in numeric:
+            groups.setdefault(_column_key(word[edge]), []).append(word)
+        for column in groups.values():
+            if best is None or len(column) > len(best[1]):
+                best = (side, column)
+
+    side, column = best
+    if len(column) < MIN_LINE_NUMBERS:
+        return None
+
+    column = sorted(column, key=lambda w: w[1])
+    values = [int(w[4]) for w in column]
+    if any(b <= a for a, b in zip(values, values[1:])):
+        return None
+
+    column_ids = {id(w) for w in column}
+    body = [w for w in words if id(w) not in column_ids]
+    if not body:
+        return None
+    if side == 'left':
+        boundary = max(w[2] for w in column)
+        if min(w[0] for w in body) <= boundary:
+            return None
+    else:
+        boundary = min(w[0] for w in column)
+        if max(w[2] for w in body) >= boundary:
+            return None
+
+    return {
+        'side': side,
+        'boundary': round(boundary, 1),
+        'numbers': [(int(w[4]), (w[1] + w[3]) / 2) for w in column]
+    }
+
+
+def anchor_lines(lines: Sequence[Tuple[float, float, str]], numbers: Sequence[Tuple[int, float]]) -> List[str]:
+    """
+    Prefix text lines with the [Ln] anchor of the line number beside them
+
+    Args:
+        lines: (y0, y1, text) of each body text line, top to bottom
+        numbers: (value, y_center) of each margin number
+
+    Returns:
+        Text lines; a line gets an anchor when a number's center falls
+        within its vertical extent
+    """
+    anchored = []
+    for y0, y1, text in lines:
+        label = next((value for value, y in numbers if y0 - 1 <= y <= y1 + 1), None)
+        anchored.append(f"[L{label}] {text}" if label is not None and text.strip() else text)
+    return anchored
+
+
+def page_text_without_line_numbers(page, mode: str = 'strip') -> Tuple[str, Optional[Dict[str, Any]]]:
+    """
+    Text of a PyMuPDF page with margin line numbers removed or anchored
+
+    Returns:
+        (text, detection) - detection is None (and the text is the plain
+        page text) when the page has no line-number column
+    """
+    import fitz
+
+    if mode == 'keep':
+        return page.get_text(), None
+
+    detection = detect_margin_line_numbers([tuple(w[:5]) for w in page.get_text("words")])
+    if not detection:
+        return page.get_text(), None
+
+    rect = page.rect
+    if detection['side'] == 'left':
+        clip = fitz.Rect(detection['boundary'] + 0.5, rect.y0, rect.x1, rect.y1)
+    else:
+        clip = fitz.Rect(rect.x0, rect.y0, detection['boundary'] - 0.5, rect.y1)
+
+    if mode == 'strip':
+        return page.get_text(clip=clip), detection
+
+    blocks = []
+    for block in page.get_text("dict", clip=clip).get("blocks", []):
+        lines = [(line['bbox'][1], line['bbox'][3], "".join(span.get('text', '') for span in line.get('spans', [])))
+                 for line in block.get('lines', [])]
+        if lines:
+            blocks.append("\n".join(anchor_lines(lines, detection['numbers'])))
+    return "\n".join(blocks) + "\n", detection
@@ -0,0 +1,235 @@
+"""
+Hyperlinks from PDF link annotations
+
+Page text from PyMuPDF is plain, so clickable URLs and internal jumps
+("see Section 4") lose their targets. With preserve_links, each link
+annotation's rectangle is matched to the words it covers, and that text is
+wrapped as a markdown link in the page text. External links keep their
+URI. Internal links first point at a #page-N placeholder; once section
+files are named, resolve_page_links points them at the section file that
+covers page N, or unwraps them when that page was not converted.
+
+A link rectangle may cover parts of several spans or lines; every word
+whose center lies inside it belongs to the link. A URL broken across lines
+usually has one annotation per line; consecutive annotations with the same
+target are merged into one link. Text inside fenced code blocks (from
+detect_code_blocks, which runs first) is never wrapped.
+
+Relative link and image targets are written for the folder of the file
+that holds them (sections/). Text copied into a file somewhere else, such
+as a chunk file, has them rebased with rebase_links.
+"""
+import posixpath
+import re
+from typing import Any, Callable, Dict, List, Optional, Sequence, Tuple
+
+try:
+    from .code_blocks import FENCED_BLOCK, split_fenced
+except ImportError:
+    from processors.code_blocks import FENCED_BLOCK, split_fenced
+
+# PyMuPDF link kinds (fitz.LINK_GOTO, fitz.LINK_URI, fitz.LINK_NAMED)
+LINK_GOTO = 1
+LINK_URI = 2
+LINK_NAMED = 4
+
+# Placeholder target of an internal link until section files are named
+PAGE_LINK = re.compile(r'\[((?:[^\]\\]|\\.)*)\]\(#page-(\d+)\)')
+
+# Points a word may stick out of a link rectangle and still count as inside
+RECT_TOLERANCE = 1.0
+
+# Characters that would end or break a markdown link target
+TARGET_ESCAPES = {' ': '%20', '(': '%28', ')': '%29', '<': '%3C', '>': '%3E'}
+
+# A markdown link or image: (opening through "(", target, ")")
+MARKDOWN_LINK = re.compile(r'(!?\[(?:[^\]\\]|\\.)*\]\()([^)\s]+)(\))')
+
+# Targets that are not relative paths: a scheme (https:, mailto:), an absolute path, or an anchor only
+NOT_RELATIVE = re.compile(r'^(?:[a-z][a-z0-9+.-]*:|/|#)', re.IGNORECASE)
+
+
+def link_target(link: Dict[str, Any]) -> Optional[str]:
+    """
+    Markdown target of a PyMuPDF link dictionary, or None to ignore it
+
+    URI links keep their URI (javascript: and empty ones are dropped);
+    go-to and named destinations with a page become #page-N (1-based).
+    """
+    kind = link.get('kind')
+    if kind == LINK_URI:
+        uri = (link.get('uri') or '').strip()
+        if not uri or uri.lower().startswith('javascript:'):
+            return None
+        return ''.join(TARGET_ESCAPES.get(char, char) for char in uri)
+    if kind in (LINK_GOTO, LINK_NAMED) and isinstance(link.get('page'), int) and link['page'] >= 0:
+        return f"#page-{link['page'] + 1}"
+    return None
+
+
+def words_in_rect(words: Sequence[Sequence[Any]], rect: Sequence[float]) -> List[Sequence[Any]]:
+    """
+    Words (PyMuPDF get_text("words") tuples) whose center lies inside rect, in reading order
+    """
+    x0, y0, x1, y1 = (rect[0] - RECT_TOLERANCE, rect[1] - RECT_TOLERANCE,
+                      rect[2] + RECT_TOLERANCE, rect[3] + RECT_TOLERANCE)
+    inside = [word for word in words
+              if x0 <= (word[0] + word[2]) / 2 <= x1 and y0 <= (word[1] + word[3]) / 2 <= y1]
+    return sorted(inside, key=lambda word: tuple(word[5:8]))
+
+
+def page_links(links: Sequence[Dict[str, Any]], words: Sequence[Sequence[Any]]) -> List[Dict[str, Any]]:
+    """
+    Anchor text and target of each link on a page, in reading order
+
+    Args:
+        links: PyMuPDF link dictionaries with 'from' as (x0, y0, x1, y1)
+        words: The page's get_text("words") tuples
+
+    Returns:
+        Dictionaries with text, target, words (the covered word tuples), and
+        occurrence (how many times the same words appear earlier on the page)
+    """
+    found = []
+    for link in links:
+        target = link_target(link)
+        if not target:
+            continue
+        covered = words_in_rect(words, link['from'])
+        if covered:
+            found.append({'target': target, 'words': covered})
+    found.sort(key=lambda link: tuple(link['words'][0][5:8]))
+
+    merged: List[Dict[str, Any]] = []
+    for link in found:
+        previous = merged[-1] if merged else None
+        if previous and previous['target'] == link['target'] and follows(previous['words'][-1], link['words'][0], words):
+            previous['words'] += [word for word in link['words'] if word not in previous['words']]
+        elif not previous or link['words'][0] not in previous['words']:
+            merged.append(link)
+    set_occurrences(merged, words)
+    return merged
+
+
+def set_occurrences(runs: Sequence[Dict[str, Any]], words: Sequence[Sequence[Any]]) -> None:
+    """Set each run's text and occurrence (how many times its words appear earlier on the page)"""
+    reading_order = sorted(words, key=lambda word: tuple(word[5:8]))
+    ordered = [word[4] for word in reading_order]
+    positions = [tuple(word[5:8]) for word in reading_order]
+    for run in runs:
+        texts = [word[4] for word in run['words']]
+        run['text'] = ' '.join(texts)
+        start = positions.index(tuple(run['words'][0][5:8]))
+        run['occurrence'] = sum(1 for index in range(start) if ordered[index:index + len(texts)] == texts)
+
+
+def follows(last: Sequence[Any], first: Sequence[Any], words: Sequence[Sequence[Any]]) -> bool:
+    """Whether first is the word right after last in reading order (or the same word)"""
+    order = sorted(tuple(word[5:8]) for word in words)
+    try:
+        return order.index(tuple(first[5:8])) - order.index(tuple(last[5:8])) in (0, 1)
+    except ValueError:
+        return False
+
+
+def escape_link_text(text: str) -> str:
+    """Link text with brackets escaped and line breaks folded"""
+    return re.sub(r'\s+', ' ', text).replace('[', '\\[').replace(']', '\\]').strip()
+
+
+def apply_links(text: str, links: Sequence[Dict[str, Any]]) -> Tuple[str, int]:
+    """
+    Wrap each link's anchor text in the page text as [text](target)
+
+    A link wraps the same occurrence of its text as on the page ("here"
+    the second time it appears); if the text has fewer occurrences, the
+    first one not already inside a link is used. Links whose text is not
+    found are skipped.
+
+    Returns:
+        The text and the number of links applied
+    """
+    return wrap_word_runs(text, links, lambda matched, link: f"[{escape_link_text(matched)}]({link['target']})")
+
+
+def wrap_word_runs(text: str, runs: Sequence[Dict[str, Any]],
+                   render: Callable[[str, Dict[str, Any]], str]) -> Tuple[str, int]:
+    """
+    Replace each run's words in the page text with render(matched text, run)
+
+    Runs are found as apply_links describes; replacements never overlap,
+    and never fall inside a fenced code block.
+
+    Returns:
+        The text and the number of runs replaced
+    """
+    applied = 0
+    # Spans already replaced, seeded with the code blocks so nothing lands in one
+    made: List[Tuple[int, int]] = [match.span() for match in FENCED_BLOCK.finditer(text)]
+    for run in runs:
+        pattern = re.compile(r'\s+'.join(re.escape(word[4]) for word in run['words']))
+        matches = list(pattern.finditer(text))
+        free = [m for m in matches if not any(start < m.end() and m.start() < end for start, end in made)]
+        occurrence = run.get('occurrence', 0)
+        if occurrence < len(matches) and matches[occurrence] in free:
+            match = matches[occurrence]
+        elif free:
+            match = free[0]
+        else:
+            continue
+        markdown = render(match.group(0), run)
+        text = text[:match.start()] + markdown + text[match.end():]
+        shift = len(markdown) - (match.end() - match.start())
+        made = [(start + shift if start >= match.end() else start, end + shift if end >= match.end() else end)
+                for start, end in made]
+        made.append((match.start(), match.start() + len(markdown)))
+        applied += 1
+    return text, applied
+
+
+def resolve_page_links(content: str, page_files: Dict[int, str]) -> Tuple[str, int, int]:
+    """
+    Point #page-N links at the file covering page N
+
+    Links to pages with no file (outside the converted pages) become
+    their plain text.
+
+    Returns:
+        The content, links resolved, links unwrapped
+    """
+    counts = {'resolved': 0, 'unwrapped': 0}
+
+    def replace(match):
+        target = page_files.get(int(match.group(2)))
+        if target:
+            counts['resolved'] += 1
+            return f"[{match.group(1)}]({target})"
+        counts['unwrapped'] += 1
+        return re.sub(r'\\([\[\]])', r'\1', match.group(1))
+
+    content = PAGE_LINK.sub(replace, content)
+    return content, counts['resolved'], counts['unwrapped']
+
+
+def rebase_links(text: str, prefix: str) -> str:
+    """
+    Rewrite relative link and image targets for a file in another folder
+
+    Args:
+        text: Markdown whose relative targets are relative to some folder
+        prefix: Path from the new file's folder to that folder (posix, e.g.
+            "../../sections"); "." leaves the text unchanged
+
+    Fenced code blocks are left alone.
+    """
+    if posixpath.normpath(prefix) == '.':
+        return text
+
+    def rebase(match):
+        target = match.group(2)
+        if NOT_RELATIVE.match(target):
+            return match.group(0)
+        path, hash_mark, anchor = target.partition('#')
+        return f"{match.group(1)}{posixpath.normpath(posixpath.join(prefix, path))}{hash_mark}{anchor}{match.group(3)}"
+
+    return ''.join(part if is_code else MARKDOWN_LINK.sub(rebase, part) for part, is_code in split_fenced(text))
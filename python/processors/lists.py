@@ -0,0 +1,174 @@
+"""
+Bulleted and numbered lists
+
+Page text from PyMuPDF keeps a list's bullet glyphs (•, ▪, -, *) and
+numbers but loses its indentation, and a long item is cut into several
+lines, so lists come out as run-on paragraphs. With detect_lists, lines
+starting with a bullet or a number followed by "." or ")" become markdown
+list items ("- " and "N."), nested by how far each item is indented on
+the page (its x offset from get_text("dict")).
+
+A line that continues an item (indented past the item's marker, starting
+in lower case, or following a line that ends in a comma or hyphen) is
+joined to it. A bullet glyph alone on its line starts the item on the
+next line. A single numbered or bulleted line between paragraphs (often
+a numbered heading) is left alone: a list needs MIN_LIST_ITEMS items.
+Fenced code blocks are never changed.
+"""
+import re
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+try:
+    from .code_blocks import FENCED_BLOCK, page_lines
+except ImportError:
+    from processors.code_blocks import FENCED_BLOCK, page_lines
+
+BULLETS = '•●◦○▪▫■□‣·–-*'
+
+# A list item: bullet or 1-3 digit number with "." or ")", a space, then its text
+LIST_ITEM = re.compile(r'^(?:(?P<bullet>[' + re.escape(BULLETS) + r'])|(?P<number>\d{1,3})[.)])\s+(?P<text>\S.*)$')
+
+# Fewest items that make a list
+MIN_LIST_ITEMS = 2
+
+# Points two x offsets may differ and still count as the same indent
+INDENT_TOLERANCE = 3.0
+
+# Spaces per nesting level (enough for markdown to nest under "- " and "10. ")
+NEST_INDENT = '    '
+
+
+def line_indents(text_lines: Sequence[str], lines: Sequence[Dict[str, Any]]) -> List[Optional[float]]:
+    """x offset of each text line, matched in order against the page's lines (None when not found)"""
+    indents: List[Optional[float]] = []
+    position = 0
+    for text_line in text_lines:
+        stripped = text_line.strip()
+        x0 = None
+        if stripped:
+            for index in range(position, len(lines)):
+                if lines[index]['text'].strip() == stripped:
+                    x0, position = lines[index]['x0'], index + 1
+                    break
+        indents.append(x0)
+    return indents
+
+
+def list_item(line: str) -> Optional[Tuple[str, str]]:
+    """(markdown marker, text) of a list item line, or None"""
+    match = LIST_ITEM.match(line.strip())
+    if not match:
+        return None
+    marker = '-' if match.group('bullet') else f"{int(match.group('number'))}."
+    return marker, match.group('text').strip()
+
+
+def is_continuation(line: str, x0: Optional[float], item: Dict[str, Any]) -> bool:
+    """Whether a non-item line continues the item before it"""
+    if x0 is not None and item['x0'] is not None and x0 > item['x0'] + INDENT_TOLERANCE:
+        return True
+    return line[:1].islower() or item['text'].endswith((',', '-'))
+
+
+def format_lists(text_lines: Sequence[str], indents: Sequence[Optional[float]],
+                 fenced: Sequence[bool] = ()) -> Tuple[List[str], int]:
+    """
+    Text lines with every list rewritten as markdown
+
+    Args:
+        text_lines: Lines of page text
+        indents: x offset of each line (None: unknown, kept at the previous item's level)
+        fenced: Per line, True inside a fenced code block (never part of a list)
+
+    Returns:
+        The lines and the number of lists formatted
+    """
+    fenced = list(fenced) or [False] * len(text_lines)
+    output: List[str] = []
+    lists = 0
+    index = 0
+    while index < len(text_lines):
+        items, end = read_list(text_lines, indents, fenced, index)
+        if len(items) < MIN_LIST_ITEMS:
+            output.append(text_lines[index])
+            index += 1
+            continue
+        if output and output[-1].strip():
+            output.append('')
+        output += [NEST_INDENT * item['level'] + f"{item['marker']} {item['text']}" for item in items]
+        if end < len(text_lines) and text_lines[end].strip():
+            output.append('')
+        lists += 1
+        index = end
+    return output, lists
+
+
+def read_list(text_lines: Sequence[str], indents: Sequence[Optional[float]], fenced: Sequence[bool],
+              start: int) -> Tuple[List[Dict[str, Any]], int]:
+    """Items of the list starting at text_lines[start] (none if it is not an item) and the line after it"""
+    items: List[Dict[str, Any]] = []
+    levels: List[float] = []
+    index = start
+    while index < len(text_lines) and not fenced[index]:
+        line = text_lines[index].strip()
+        x0 = indents[index]
+        if not line:
+            # A blank line inside a list only separates items
+            following = next((i for i in range(index + 1, len(text_lines)) if text_lines[i].strip()), None)
+            if not items or following is None or fenced[following] or not list_item(text_lines[following]):
+                break
+            index += 1
+            continue
+        if len(line) == 1 and line in BULLETS and index + 1 < len(text_lines) and text_lines[index + 1].strip() \
+                and not fenced[index + 1] and not list_item(text_lines[index + 1]):
+            # A bullet glyph on its own line: the item's text is the next line
+            parsed, consumed = ('-', text_lines[index + 1].strip()), 2
+        else:
+            parsed, consumed = list_item(line), 1
+        if parsed:
+            items.append({'marker': parsed[0], 'text': parsed[1], 'x0': x0, 'level': item_level(levels, x0, items)})
+        elif items and is_continuation(line, x0, items[-1]):
+            # A word hyphenated at the line end keeps its hyphen but not the break
+            items[-1]['text'] += ('' if items[-1]['text'].endswith('-') else ' ') + line
+        else:
+            break
+        index += consumed
+    return items, index
+
+
+def item_level(levels: List[float], x0: Optional[float], items: Sequence[Dict[str, Any]]) -> int:
+    """Nesting level of an item at x0, updating levels (the x offsets of the open levels)"""
+    if x0 is None:
+        return items[-1]['level'] if items else 0
+    while levels and x0 < levels[-1] - INDENT_TOLERANCE:
+        levels.pop()
+    if not levels or x0 > levels[-1] + INDENT_TOLERANCE:
+        levels.append(x0)
+    return len(levels) - 1
+
+
+def fenced_line_flags(text: str) -> List[bool]:
+    """Per line of text, True inside a fenced code block"""
+    flags = [False] * (text.count('\n') + 1)
+    for match in FENCED_BLOCK.finditer(text):
+        first = text.count('\n', 0, match.start())
+        for line in range(first, first + match.group(0).count('\n') + 1):
+            flags[line] = True
+    return flags
+
+
+def apply_lists(text: str, page_dict: Dict[str, Any]) -> Tuple[str, int]:
+    """
+    Rewrite the lists in a page's text as markdown lists
+
+    Args:
+        text: The page text
+        page_dict: The page's get_text("dict"), for each line's indent
+
+    Returns:
+        The text and the number of lists formatted
+    """
+    text_lines = text.split('\n')
+    indents = line_indents(text_lines, page_lines(page_dict))
+    lines, lists = format_lists(text_lines, indents, fenced_line_flags(text))
+    return '\n'.join(lines), lists
@@ -0,0 +1,95 @@
+"""
+Splitting markdown into sections at its headings
+
+Shared by Word conversion (markitdown output) and organize_markdown (markdown
+from any source): each heading starts a section holding the text up to the
+next heading, and text before the first heading becomes an "Introduction"
+section. With split_level, only headings at that level or above start a
+section; deeper headings stay in their section's content.
+"""
+import re
+from typing import Any, Dict, List
+
+HEADING = re.compile(r'^(#{1,6})\s+(.+)$')
+FENCE = re.compile(r'^\s*(`{3,}|~{3,})')
+
+
+def section_type_for_title(title: str) -> str:
+    """Section type from title keywords (introduction, methodology, appendix, ...)"""
+    title_lower = title.lower()
+    
+    if any(word in title_lower for word in ['introduction', 'overview', 'summary', 'abstract']):
+        return 'introduction'
+    elif any(word in title_lower for word in ['conclusion', 'summary', 'final']):
+        return 'conclusion'
+    elif any(word in title_lower for word in ['method', 'approach', 'process']):
+        return 'methodology'
+    elif any(word in title_lower for word in ['result', 'finding', 'outcome']):
+        return 'results'
+    elif any(word in title_lower for word in ['reference', 'bibliography', 'citation']):
+        return 'references'
+    elif any(word in title_lower for word in ['appendix', 'annex', 'supplement']):
+        return 'appendix'
+    else:
+        return 'content'
+
+
+def split_markdown_sections(markdown_content: str, split_level: int = 6) -> List[Dict[str, Any]]:
+    """
+    Sections of markdown content
+    
+    Args:
+        markdown_content: The markdown text
+        split_level: Deepest heading level that starts a section (1-6)
+    
+    Returns:
+        Dictionaries with title, level, content, and section_type
+    """
+    sections = []
+    current_section = None
+    current_content = []
+    fence = None
+    
+    for line in markdown_content.split('\n'):
+        # "# comment" lines inside code blocks are not headings
+        fence_match = FENCE.match(line)
+        if fence_match:
+            marker = fence_match.group(1)
+            if fence is None:
+                fence = marker
+            elif marker[0] == fence[0] and len(marker) >= len(fence):
+                fence = None
+        header_match = HEADING.match(line) if fence is None and not fence_match else None
+        
+        if header_match and len(header_match.group(1)) <= split_level:
+            # Save previous section if exists
+            if current_section:
+                current_section['content'] = '\n'.join(current_content).strip()
+                sections.append(current_section)
+                current_content = []
+            
+            # Start new section
+            title = header_match.group(2).strip()
+            current_section = {
+                'title': title,
+                'level': len(header_match.group(1)),
+                'content': '',
+                'section_type': section_type_for_title(title)
+            }
+        elif current_section:
+            current_content.append(line)
+        elif line.strip():  # Content before first header
+            current_section = {
+                'title': 'Introduction',
+                'level': 1,
+                'content': '',
+                'section_type': 'introduction'
+            }
+            current_content.append(line)
+    
+    # Save final section
+    if current_section:
+        current_section['content'] = '\n'.join(current_content).strip()
+        sections.append(current_section)
+    
+    return sections
@@ -0,0 +1,105 @@
+"""
+Package a conversion as one ZIP archive
+
+An agent handing a converted document to a user needs a single download
+rather than a folder of sections, images, chunks, and metadata.
+package_output walks a conversion's folder (found as reprocess finds it)
+and writes <folder>.zip next to it, every path inside prefixed with the
+folder name so the archive extracts into the same layout. Files are copied
+into the archive in blocks rather than read whole, so large images and
+JSON Lines files don't have to fit in memory, and the archive is written
+under a temporary name and renamed when complete, so a cancelled or failed
+run never leaves a truncated archive behind.
+"""
+import os
+import shutil
+import threading
+import zipfile
+from pathlib import Path
+from typing import Any, Dict, Optional
+
+try:
+    from ..utils.cancellation import check_cancelled
+    from .reprocess import find_document_dir
+except ImportError:
+    # Handle running as script vs package
+    import sys
+    sys.path.append(str(Path(__file__).parent.parent))
+    from utils.cancellation import check_cancelled
+    from processors.reprocess import find_document_dir
+
+# Already compressed: stored as is instead of deflated again
+STORED_SUFFIXES = {'.png', '.jpg', '.jpeg', '.gif', '.webp', '.zip', '.gz'}
+
+# Bytes copied into the archive at a time
+COPY_BLOCK = 1024 * 1024
+
+
+def default_archive_path(document_dir: Path) -> Path:
+    """<folder>.zip next to the conversion's folder"""
+    return document_dir.parent / f"{document_dir.name}.zip"
+
+
+def package_output(output_dir: str, document: Optional[str] = None, archive_path: Optional[str] = None,
+                   cancel_event: Optional[threading.Event] = None) -> Dict[str, Any]:
+    """
+    Write a conversion's folder to a ZIP archive
+
+    Args:
+        output_dir: The conversion's folder, or the output_dir it was converted to
+        document: Folder name inside output_dir, when it holds several conversions
+        archive_path: Where to write the archive (None: <folder>.zip next to the folder)
+        cancel_event: Stops between files when set
+
+    Returns:
+        Dictionary with archive, document_dir, files, bytes (uncompressed),
+        and size (of the archive)
+
+    Raises:
+        FileNotFoundError: No conversion found
+        ValueError: Several conversions and no document, or archive_path
+            inside the folder being packaged
+    """
+    document_dir = find_document_dir(output_dir, document)
+    archive = Path(archive_path) if archive_path else default_archive_path(document_dir)
+    if archive.suffix.lower() != '.zip':
+        archive = archive.with_name(archive.name + '.zip')
+    if document_dir.resolve() in archive.resolve().parents:
+        raise ValueError(f"archive_path {archive} is inside the folder being packaged; write it elsewhere")
+    archive.parent.mkdir(parents=True, exist_ok=True)
+
+    partial = archive.with_name(archive.name + '.partial')
+    files = 0
+    total_bytes = 0
+    try:
+        with zipfile.ZipFile(partial, 'w', compression=zipfile.ZIP_DEFLATED, allowZip64=True) as bundle:
+            for root, directories, names in os.walk(document_dir):
+                directories.sort()
+                for name in sorted(names):
+                    check_cancelled(cancel_event)
+                    path = Path(root) / name
+                    if not path.is_file():
+                        continue
+                    arcname = (Path(document_dir.name) / path.relative_to(document_dir)).as_posix()
+                    info = zipfile.ZipInfo.from_file(path, arcname)
+                    if path.suffix.lower() in STORED_SUFFIXES:
+                        info.compress_type = zipfile.ZIP_STORED
+                    else:
+                        info.compress_type = zipfile.ZIP_DEFLATED
+                    large = info.file_size > zipfile.ZIP64_LIMIT
+                    with open(path, 'rb') as source, bundle.open(info, 'w', force_zip64=large) as target:
+                        shutil.copyfileobj(source, target, COPY_BLOCK)
+                    files += 1
+                    total_bytes += info.file_size
+        partial.replace(archive)
+    finally:
+        if partial.exists():
+            partial.unlink()
+
+    return {
+        'archive': str(archive),
+        'document_dir': str(document_dir),
+        'files': files,
+        'bytes': total_bytes,
+        'size': archive.stat().st_size
+    }
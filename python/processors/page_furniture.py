@@ -0,0 +1,141 @@
+"""
+Running headers and footers ("page furniture")
+
+Page numbers, "Confidential" notices, and the chapter name repeated at the
+top or bottom of every page end up in the markdown and in every chunk. A
+line is furniture when it sits in the top or bottom band of the page and
+the same text (digits ignored, so "Page 3 of 40" matches "Page 4 of 40")
+recurs at the same distance from that edge on at least half the pages,
+and on no fewer than MIN_FURNITURE_PAGES. Detection is deliberately
+conservative: long lines, lines in the middle of the page, and text that
+moves around are never furniture, so a heading or sentence that happens to
+repeat is kept.
+"""
+import math
+import re
+from statistics import median
+from typing import Any, Dict, List, Sequence, Tuple
+
+# Share of the page height at the top and at the bottom searched for furniture
+EDGE_BAND = 0.12
+
+# Fewest pages a line must repeat on, and the share of pages it must be on
+MIN_FURNITURE_PAGES = 3
+MIN_PAGE_SHARE = 0.5
+
+# Points a repeat may sit from the others' distance to the page edge
+POSITION_TOLERANCE = 4.0
+
+# Longer lines are body text, not headers or footers
+MAX_FURNITURE_CHARS = 120
+
+# (y0, y1, text) of one text line, as in page.get_text("dict")
+Line = Tuple[float, float, str]
+
+
+def furniture_key(text: str) -> str:
+    """Text compared across pages: whitespace collapsed, lowercase, digit runs as #"""
+    return re.sub(r'\d+', '#', ' '.join(text.split()).lower())
+
+
+def page_lines(page_dict: Dict[str, Any]) -> List[Line]:
+    """Text lines of a page.get_text("dict") result with their vertical extent"""
+    lines = []
+    for block in page_dict.get('blocks', []):
+        if block.get('type', 0) != 0:
+            continue
+        for line in block.get('lines', []):
+            text = ''.join(span.get('text', '') for span in line.get('spans', []))
+            if text.strip():
+                lines.append((line['bbox'][1], line['bbox'][3], text))
+    return lines
+
+
+def band_lines(lines: Sequence[Line], height: float) -> List[Line]:
+    """
+    The lines in the top and bottom bands, short enough to be furniture
+
+    All detect_furniture needs of a page: keeping only these while
+    scanning a document leaves its body text out of memory.
+    """
+    band = height * EDGE_BAND
+    return [(y0, y1, text) for y0, y1, text in lines
+            if len(text.strip()) <= MAX_FURNITURE_CHARS and (y1 <= band or y0 >= height - band)]
+
+
+def edge_lines(lines: Sequence[Line], height: float) -> List[Tuple[str, float, str]]:
+    """
+    Candidate lines in the top and bottom bands of a page
+
+    Returns:
+        List of (edge, distance, text): edge is 'top' or 'bottom' and
+        distance is how far the line sits from that edge, in points
+    """
+    band = height * EDGE_BAND
+    candidates = []
+    for y0, y1, text in lines:
+        if len(text.strip()) > MAX_FURNITURE_CHARS:
+            continue
+        if y1 <= band:
+            candidates.append(('top', y0, text))
+        elif y0 >= height - band:
+            candidates.append(('bottom', height - y1, text))
+    return candidates
+
+
+def detect_furniture(pages: Sequence[Tuple[int, float, Sequence[Line]]]) -> Dict[int, List[Tuple[str, str]]]:
+    """
+    Find running headers and footers across pages
+
+    Args:
+        pages: (page number, page height, text lines) for each page
+
+    Returns:
+        Dictionary of page number to its furniture lines as (edge, text),
+        text as it appears on that page (pages without furniture are left out)
+    """
+    needed = max(MIN_FURNITURE_PAGES, math.ceil(len(pages) * MIN_PAGE_SHARE))
+    if len(pages) < needed:
+        return {}
+
+    groups: Dict[Tuple[str, str], List[Tuple[int, float, str]]] = {}
+    for page_num, height, lines in pages:
+        for edge, distance, text in edge_lines(lines, height):
+            groups.setdefault((edge, furniture_key(text)), []).append((page_num, distance, text))
+
+    furniture: Dict[int, List[Tuple[str, str]]] = {}
+    for (edge, _), occurrences in groups.items():
+        if len({page_num for page_num, _, _ in occurrences}) < needed:
+            continue
+        position = median(distance for _, distance, _ in occurrences)
+        aligned = [(page_num, text) for page_num, distance, text in occurrences
+                   if abs(distance - position) <= POSITION_TOLERANCE]
+        if len({page_num for page_num, _ in aligned}) < needed:
+            continue
+        for page_num, text in aligned:
+            furniture.setdefault(page_num, []).append((edge, text))
+    return furniture
+
+
+def strip_furniture(text: str, furniture: Sequence[Tuple[str, str]]) -> Tuple[str, int]:
+    """
+    Remove a page's furniture lines from its extracted text
+
+    Each furniture line removes one line of the same text (whitespace
+    collapsed): the first such line for a header, the last for a footer,
+    so body lines that happen to match are kept.
+
+    Returns:
+        (text, number of lines removed)
+    """
+    lines = text.split('\n')
+    keys = [' '.join(line.split()) for line in lines]
+    removed = set()
+    for edge, furniture_text in furniture:
+        key = ' '.join(furniture_text.split())
+        order = range(len(lines)) if edge == 'top' else range(len(lines) - 1, -1, -1)
+        for index in order:
+            if index not in removed and keys[index] == key:
+                removed.add(index)
+                break
+    return '\n'.join(line for index, line in enumerate(lines) if index not in removed), len(removed)
@@ -0,0 +1,132 @@
+"""
+Page markers: where each PDF page starts in the markdown
+
+Once pages are joined into sections, nothing in the text says which page
+a passage came from. page_markers puts a line of its own before each
+page's text: 'comment' (the default) an HTML comment, <!-- Page N -->,
+which renderers hide and tokenizers mostly skip past; 'anchor' an empty
+HTML anchor, <a id="page-N"></a>, that stays in the text an LLM reads,
+so chunks carry their page provenance and clients can deep-link to
+file.md#page-N (internal page links then point at the anchor too).
+page_marker_labels adds a small superscript page label after the anchor
+for human readers. 'none' adds nothing, and blank pages get no marker.
+
+Markers sit between blank lines. The chunker never splits one and keeps
+each with the text that follows it, so a chunk never ends on a marker,
+and a marker left at the end of a section moves to the start of the next.
+"""
+import re
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+PAGE_MARKERS = ('comment', 'anchor', 'none')
+
+# A whole marker line in any style; group 1 or 2 holds the page number
+PAGE_MARKER_LINE = re.compile(r'^\s*(?:<!-- Page (\d+) -->|<a id="page-(\d+)"></a>(?:<sup>p\. \d+</sup>)?)\s*$')
+
+
+def validate_page_markers(page_markers: Optional[str]) -> str:
+    """page_markers, defaulting to 'comment' (raises ValueError for an unknown one)"""
+    page_markers = page_markers or 'comment'
+    if page_markers not in PAGE_MARKERS:
+        raise ValueError(f"Unknown page_markers '{page_markers}' (expected one of: {', '.join(PAGE_MARKERS)})")
+    return page_markers
+
+
+def page_marker(page_num: int, page_markers: str, labels: bool = False) -> str:
+    """The marker line for page_num ('' with page_markers 'none')"""
+    if page_markers == 'anchor':
+        return f'<a id="page-{page_num}"></a>' + (f"<sup>p. {page_num}</sup>" if labels else '')
+    if page_markers == 'comment':
+        return f"<!-- Page {page_num} -->"
+    return ''
+
+
+def marker_page(line: str) -> Optional[int]:
+    """Page number of a marker line (None for any other line)"""
+    match = PAGE_MARKER_LINE.match(line)
+    return int(match.group(1) or match.group(2)) if match else None
+
+
+def mark_page(text: str, page_num: int, page_markers: str, labels: bool = False) -> str:
+    """A page's text with its marker line in front (blank text is left as it is)"""
+    marker = page_marker(page_num, page_markers, labels)
+    return f"{marker}\n\n{text}" if marker and text.strip() else text
+
+
+def mark_pages(pages: Sequence[Dict[str, Any]], page_markers: str, labels: bool = False) -> List[Dict[str, Any]]:
+    """Copies of page entries (page_num, text) with each text marked"""
+    return [{**page, 'text': mark_page(page['text'], page['page_num'], page_markers, labels)} for page in pages]
+
+
+def split_page_markers(text: str) -> List[Tuple[str, str]]:
+    """
+    Split text at its marker lines
+
+    Returns:
+        (markers, text) pairs: the marker lines (joined by newlines, '' for
+        none) standing before each stretch of text; a trailing pair has
+        empty text when text ends on markers
+    """
+    pieces: List[Tuple[str, str]] = []
+    markers: List[str] = []
+    lines: List[str] = []
+    for line in text.split('\n'):
+        if marker_page(line) is None:
+            lines.append(line)
+            continue
+        if '\n'.join(lines).strip():
+            pieces.append(('\n'.join(markers), '\n'.join(lines).strip('\n')))
+            markers = []
+        lines = []
+        markers.append(line.strip())
+    if markers or '\n'.join(lines).strip():
+        pieces.append(('\n'.join(markers), '\n'.join(lines).strip('\n')))
+    return pieces
+
+
+def carry_trailing_markers(sections: List[Dict[str, Any]]) -> int:
+    """
+    Move marker lines that end a section's content to the start of the next section
+
+    A page break just before a heading leaves the new page's marker at the
+    end of the section before it. The last section keeps its markers.
+
+    Returns:
+        Number of markers moved
+    """
+    moved = 0
+    for section, following in zip(sections, sections[1:]):
+        lines = section.get('content', '').rstrip().split('\n')
+        trailing = []
+        while lines and (marker_page(lines[-1]) is not None or (trailing and not lines[-1].strip())):
+            line = lines.pop()
+            if line.strip():
+                trailing.insert(0, line.strip())
+        if trailing:
+            section['content'] = '\n'.join(lines).rstrip() + '\n' if '\n'.join(lines).strip() else ''
+            following['content'] = '\n\n'.join(trailing) + '\n\n' + following.get('content', '').lstrip()
+            moved += len(trailing)
+    return moved
+
+
+def chunk_page_spans(chunk_texts: Sequence[str], page_start: Optional[int] = None,
+                     page_end: Optional[int] = None) -> List[Tuple[Optional[int], Optional[int]]]:
+    """
+    First and last page of each chunk of one section, from its page markers
+
+    A chunk starts on the page of the marker it opens with, else on the page
+    in effect where the previous chunk ended (page_start for the first), and
+    ends on the page of its last marker. Without markers in any chunk, every
+    chunk gets the section's span.
+    """
+    if not any(marker_page(line) is not None for text in chunk_texts for line in text.split('\n')):
+        return [(page_start, page_end) for _ in chunk_texts]
+    spans: List[Tuple[Optional[int], Optional[int]]] = []
+    current = page_start
+    for text in chunk_texts:
+        lines = text.strip().split('\n')
+        pages = [page for page in map(marker_page, lines) if page is not None]
+        start = pages[0] if marker_page(lines[0]) is not None else current
+        current = pages[-1] if pages else current
+        spans.append((start, current))
+    return spans
@@ -9,6 +9,53 @@ from typing import Dict, List, Any, Optional, Tuple, Set
 from dataclasses import dataclass, field
 import json
 
+try:
+    from ..utils.fingerprint import hash_page_text, compute_fingerprint
+    from ..utils.progress import format_progress_line
+    from ..utils.cancellation import check_cancelled
+    from ..utils.page_range import parse_page_range
+    from .image_extractor import ImageExtractor
+    from .image_format import DEFAULT_IMAGE_QUALITY
+    from .line_numbers import LINE_NUMBER_MODES, page_text_without_line_numbers
+    from .reflow import page_text_with_column_breaks, reflow_split_words
+    from .columns import detect_columns, page_blocks_in_order
+    from .reading_order import out_of_order, page_direction, rtl_blocks, sort_blocks
+    from .links import apply_links, page_links
+    from .page_markers import mark_pages
+    from .code_blocks import apply_code_blocks, page_code_blocks, split_fenced
+    from .lists import apply_lists
+    from .page_furniture import band_lines, detect_furniture, page_lines, strip_furniture
+    from .decorations import (DEFAULT_UNDERLINE_MARKER, apply_decorations, decorated_runs, horizontal_rules,
+                              word_decorations)
+    from .equations import (EQUATION_DPI, LOW_CONFIDENCE, apply_equations, crop_rect, equation_filename,
+                            equation_markdown, latex_looks_valid, load_latex_model, page_equations)
+except ImportError:
+    # Handle running as script vs package
+    import sys
+    sys.path.append(str(Path(__file__).parent.parent))
+    from utils.fingerprint import hash_page_text, compute_fingerprint
+    from utils.progress import format_progress_line
+    from utils.cancellation import check_cancelled
+    from utils.page_range import parse_page_range
+    from processors.image_extractor import ImageExtractor
+    from processors.image_format import DEFAULT_IMAGE_QUALITY
+    from processors.line_numbers import LINE_NUMBER_MODES, page_text_without_line_numbers
+    from processors.reflow import page_text_with_column_breaks, reflow_split_words
+    from processors.columns import detect_columns, page_blocks_in_order
+    from processors.reading_order import out_of_order, page_direction, rtl_blocks, sort_blocks
+    from processors.links import apply_links, page_links
+    from processors.page_markers import mark_pages
+    from processors.code_blocks import apply_code_blocks, page_code_blocks, split_fenced
+    from processors.lists import apply_lists
+    from processors.page_furniture import band_lines, detect_furniture, page_lines, strip_furniture
+    from processors.decorations import (DEFAULT_UNDERLINE_MARKER, apply_decorations, decorated_runs,
+                                        horizontal_rules, word_decorations)
+    from processors.equations import (EQUATION_DPI, LOW_CONFIDENCE, apply_equations, crop_rect, equation_filename,
+                                      equation_markdown, latex_looks_valid, load_latex_model, page_equations)
+
+# Stands in for the text of a page that could not be extracted
+PAGE_ERROR_MARKER = "<!-- extraction error on page {page} -->"
+
 
 @dataclass
 class ExtractedField:
@@ -33,6 +80,28 @@ class PDFExtractor:
     def __init__(self, config: Optional[Dict[str, Any]] = None):
         """Initialize with optional configuration for customization"""
         self.config = config or {}
+        # Links applied by preserve_links
+        self.link_count = 0
+        # Code blocks fenced by detect_code_blocks
+        self.code_block_count = 0
+        # Lists formatted by detect_lists
+        self.list_count = 0
+        # Passages marked by preserve_text_decorations, per decoration
+        self.decoration_counts = {'strikethrough': 0, 'underline': 0}
+        # Equations replaced by math_mode: page, index, file, confidence, low_confidence, latex
+        self.equations: List[Dict[str, Any]] = []
+        # pix2tex model for math_mode 'latex', loaded on first use (False: could not be loaded)
+        self.latex_model = None
+        # Pages whose extraction raised: page and error
+        self.page_errors: List[Dict[str, Any]] = []
+        # Pages read column by column (column_layout)
+        self.column_pages: List[int] = []
+        # Pages whose blocks were re-sorted, and pages read right to left (reading_order)
+        self.reordered_pages: List[int] = []
+        self.rtl_pages: List[int] = []
+        # Running header/footer lines per page (strip_headers_footers), and how many were removed
+        self.furniture: Dict[int, List[Tuple[str, str]]] = {}
+        self.furniture_lines = 0
         
         # Universal character encoding fixes
         self.char_fixes = {
@@ -52,19 +121,356 @@ class PDFExtractor:
             'one of', 'such as', 'examples', 'list'
         ])
     
-    def extract_from_pdf(self, pdf_path: str) -> Dict[str, Any]:
-        """Extract content from any PDF file"""
+    def iter_page_texts(self, pdf_path: str):
+        """
+        Yield (page_num, text, line_numbers, text_hash) one page at a time
+        
+        PyMuPDF opens documents lazily and only parses a page when it is loaded,
+        so holding just one page object at a time keeps memory proportional to
+        the largest page rather than the whole document.
+        
+        Margin line numbers (legal documents) are stripped, or kept as [Ln]
+        anchors with config line_numbers='preserve'; line_numbers is the
+        detected column for the page, or None. text_hash is always taken
+        from the unmodified page text so fingerprints match compute_fingerprint().
+        
+        With config reflow_paragraphs, text is rebuilt block by block with a
+        COLUMN_BREAK marker wherever reading jumps to the next column, for
+        reflow_split_words() to join words cut at the break.
+        
+        With config column_layout 'auto', pages set in two columns are read
+        column by column, keeping full-width titles and tables whole (see
+        processors.columns); 'double' does so on every page and 'single'
+        (the default) keeps the PDF's order. self.column_pages lists the
+        pages reordered.
+        
+        With config reading_order 'ltr' or 'rtl', a page's blocks are read
+        top to bottom, and blocks side by side from left to right or right
+        to left; on rtl pages lines are rebuilt from character positions in
+        logical order, keeping embedded left-to-right runs intact. 'auto'
+        reads pages mostly in right-to-left scripts as rtl and re-sorts
+        others only when a block comes before one drawn above it; 'raw' (the
+        default) keeps the PDF's order (see processors.reading_order).
+        self.reordered_pages and self.rtl_pages list the pages affected.
+        
+        With config preserve_links, text covered by link annotations becomes
+        [text](url) markdown links, or [text](#page-N) for internal jumps
+        (see processors.links); self.link_count counts them.
+        
+        With config detect_code_blocks, runs of monospaced lines become
+        fenced code blocks with their indentation restored (see
+        processors.code_blocks); self.code_block_count counts them.
+        
+        With config detect_lists, bulleted and numbered lines become markdown
+        list items nested by their indent, with wrapped lines joined (see
+        processors.lists); self.list_count counts the lists.
+        
+        With config preserve_text_decorations, struck-through words become
+        ~~text~~ and underlined ones are wrapped in config underline_marker
+        (default <u>; see processors.decorations); self.decoration_counts
+        counts the passages.
+        
+        With config strip_headers_footers, lines repeated at the same place
+        in the top or bottom band of most pages (page numbers, notices, the
+        chapter name) are found in a first pass over the pages and removed
+        (see processors.page_furniture); self.furniture_lines counts them.
+        
+        With config math_mode 'image' or 'latex', display equations are
+        cropped into config equation_dir and replaced by $$...$$ blocks
+        linking the crop through config equation_link (a path prefix), with
+        LaTeX from pix2tex in 'latex' mode when it loads (see
+        processors.equations); self.equations lists them.
+        
+        A page whose extraction raises (a corrupt content stream) yields
+        PAGE_ERROR_MARKER as its text and is recorded in self.page_errors;
+        the remaining pages are still extracted. With config
+        basic_extraction, pages are read as plain text with none of the
+        features above.
+        
+        With config progress, a PROGRESS line is printed after each page.
+        With config cancel (a threading.Event), ConversionCancelled is raised
+        before the next page once the event is set.
+        """
+        mode = self.config.get('line_numbers', 'strip')
+        reflow = self.config.get('reflow_paragraphs', False)
+        # Restrict extraction to these 1-based pages (page ranges and sample conversions)
+        selected = set(self.config['pages']) if self.config.get('pages') else None
+        if mode not in LINE_NUMBER_MODES:
+            raise ValueError(f"Unknown line_numbers mode '{mode}' (expected one of: {', '.join(LINE_NUMBER_MODES)})")
+        
         doc = fitz.open(pdf_path)
+        try:
+            page_indexes = (sorted(page - 1 for page in selected if 1 <= page <= doc.page_count)
+                            if selected is not None else range(doc.page_count))
+            total = len(page_indexes)
+            if self.config.get('strip_headers_footers') and not self.config.get('basic_extraction'):
+                self.furniture = self.detect_page_furniture(doc, page_indexes)
+            done = 0
+            for page_index in page_indexes:
+                check_cancelled(self.config.get('cancel'))
+                try:
+                    text, line_numbers, text_hash = self.page_text(doc, page_index, mode, reflow)
+                except Exception as e:
+                    # One bad page must not cost the rest of the document
+                    self.page_errors.append({'page': page_index + 1, 'error': f"{type(e).__name__}: {e}"})
+                    text = PAGE_ERROR_MARKER.format(page=page_index + 1) + "\n"
+                    line_numbers, text_hash = None, hash_page_text('')
+                done += 1
+                if self.config.get('progress'):
+                    print(format_progress_line(done, total, f"extracting page {page_index + 1}"), flush=True)
+                yield page_index + 1, text, line_numbers, text_hash
+        finally:
+            doc.close()
+    
+    def page_text(self, doc, page_index: int, mode: str, reflow: bool) -> Tuple[str, Optional[Dict[str, Any]], str]:
+        """(text, line_numbers, text_hash) of one page, with the configured features applied"""
+        page = doc.load_page(page_index)
+        if self.config.get('basic_extraction'):
+            text = page.get_text()
+            return text, None, hash_page_text(text)
+        text, line_numbers = page_text_without_line_numbers(page, mode)
+        text_hash = hash_page_text(page.get_text() if line_numbers else text)
+        column_layout = self.config.get('column_layout', 'single')
+        reading_order = self.config.get('reading_order', 'raw')
+        if (reflow or column_layout != 'single' or reading_order != 'raw') and not line_numbers:
+            direction = page_direction(reading_order, text)
+            if direction == 'rtl':
+                blocks = rtl_blocks(page.get_text("rawdict"))
+                self.rtl_pages.append(page_index + 1)
+            else:
+                blocks = [tuple(b[:5]) for b in page.get_text("blocks") if b[6] == 0]
+            columns = 1
+            if column_layout == 'double' or (column_layout == 'auto' and detect_columns(blocks) > 1):
+                blocks, columns = page_blocks_in_order(blocks, column_layout, self.page_table_regions(page),
+                                                       right_to_left=direction == 'rtl')
+            reordered = False
+            if columns > 1:
+                self.column_pages.append(page_index + 1)
+            elif direction and (reading_order != 'auto' or direction == 'rtl' or out_of_order(blocks)):
+                ordered = sort_blocks(blocks, direction)
+                reordered = ordered != blocks
+                blocks = ordered
+                if reordered:
+                    self.reordered_pages.append(page_index + 1)
+            if reflow:
+                text = page_text_with_column_breaks(blocks)
+            elif columns > 1 or reordered or direction == 'rtl':
+                text = ''.join(block[4].rstrip('\n') + '\n' for block in blocks)
+        if self.furniture.get(page_index + 1):
+            text, removed = strip_furniture(text, self.furniture[page_index + 1])
+            self.furniture_lines += removed
+        if self.config.get('math_mode', 'off') != 'off' and self.config.get('equation_dir'):
+            text = self.apply_page_equations(page, page_index + 1, text)
+        if self.config.get('detect_code_blocks'):
+            text = self.apply_page_code_blocks(page, text)
+        if self.config.get('detect_lists'):
+            text = self.apply_page_lists(page, text)
+        if self.config.get('preserve_text_decorations'):
+            text = self.apply_page_decorations(page, text)
+        if self.config.get('preserve_links'):
+            text = self.apply_page_links(page, text)
+        return text, line_numbers, text_hash
+    
+    def detect_page_furniture(self, doc, page_indexes) -> Dict[int, List[Tuple[str, str]]]:
+        """
+        Running header and footer lines of the pages, found by comparing their top and bottom bands
         
-        # Extract raw text
-        raw_text = ""
-        for page in doc:
-            raw_text += page.get_text()
+        Only each page's band lines are held, not its body text, so the scan
+        stays small on a document that is otherwise streamed page by page.
+        """
+        pages = []
+        for page_index in page_indexes:
+            check_cancelled(self.config.get('cancel'))
+            try:
+                page = doc.load_page(page_index)
+                height = page.rect.height
+                pages.append((page_index + 1, height, band_lines(page_lines(page.get_text("dict")), height)))
+            except Exception:
+                # The page's own extraction records the error
+                continue
+        return detect_furniture(pages)
+    
+    @staticmethod
+    def page_table_regions(page) -> List[Tuple[float, float, float, float]]:
+        """Bounding boxes of the page's tables, kept whole when ordering columns"""
+        try:
+            return [tuple(table.bbox) for table in page.find_tables().tables]
+        except Exception:
+            return []
+    
+    def apply_page_links(self, page, text: str) -> str:
+        """Wrap the text of the page's link annotations as markdown links"""
+        links = [{**link, 'from': tuple(link['from'])} for link in page.get_links() if link.get('from')]
+        if not links:
+            return text
+        text, applied = apply_links(text, page_links(links, page.get_text("words")))
+        self.link_count += applied
+        return text
+    
+    def apply_page_code_blocks(self, page, text: str) -> str:
+        """Fence the page's monospaced line runs as code blocks"""
+        blocks = page_code_blocks(page.get_text("dict"))
+        if not blocks:
+            return text
+        text, applied = apply_code_blocks(text, blocks)
+        self.code_block_count += applied
+        return text
+    
+    def apply_page_equations(self, page, page_num: int, text: str) -> str:
+        """Crop the page's display equations and replace their text with $$...$$ blocks"""
+        equations = page_equations(page.get_text("dict"))
+        if not equations:
+            return text
+        directory = Path(self.config['equation_dir'])
+        directory.mkdir(parents=True, exist_ok=True)
+        link = self.config.get('equation_link') or directory.name
+        if self.config['math_mode'] == 'latex' and self.latex_model is None:
+            self.latex_model = load_latex_model() or False
+        rendered: List[Dict[str, Any]] = []
         
-        doc.close()
+        def render(equation: Dict[str, Any]) -> List[str]:
+            filename = equation_filename(page_num, len(rendered) + 1)
+            path = directory / filename
+            page.get_pixmap(clip=fitz.Rect(crop_rect(equation['bbox'])), dpi=EQUATION_DPI).save(str(path))
+            latex = None
+            if self.latex_model:
+                try:
+                    latex = self.latex_model(str(path))
+                except Exception:
+                    latex = None
+            entry = {
+                'page': page_num,
+                'index': len(rendered) + 1,
+                'file': str(path),
+                'confidence': equation['confidence'],
+                'latex': latex if latex_looks_valid(latex) else None,
+                'low_confidence': equation['confidence'] < LOW_CONFIDENCE or (bool(self.latex_model)
+                                                                              and not latex_looks_valid(latex))
+            }
+            rendered.append(entry)
+            return equation_markdown(entry, f"{link}/{filename}")
         
-        # Process text generically
-        processed_text = self.process_text(raw_text)
+        text, _ = apply_equations(text, equations, render)
+        self.equations += rendered
+        return text
+    
+    def apply_page_lists(self, page, text: str) -> str:
+        """Rewrite the page's bulleted and numbered lists as markdown lists"""
+        text, applied = apply_lists(text, page.get_text("dict"))
+        self.list_count += applied
+        return text
+    
+    def apply_page_decorations(self, page, text: str) -> str:
+        """Mark the page's struck-through and underlined words"""
+        rules = horizontal_rules(page.get_drawings())
+        annotations = [(annot.type[1], tuple(annot.rect)) for annot in page.annots()]
+        if not rules and not annotations:
+            return text
+        words = page.get_text("words")
+        text, counts = apply_decorations(text, decorated_runs(words, word_decorations(words, rules, annotations)),
+                                         self.config.get('underline_marker', DEFAULT_UNDERLINE_MARKER))
+        for kind, count in counts.items():
+            self.decoration_counts[kind] += count
+        return text
+    
+    # PyMuPDF span flag bits
+    FLAG_ITALIC = 2
+    FLAG_BOLD = 16
+    
+    @classmethod
+    def span_emphasis(cls, span: Dict[str, Any]) -> Optional[str]:
+        """Emphasis style of a text span: 'bold', 'italic', 'bold-italic', or None"""
+        flags = span.get('flags', 0)
+        font = span.get('font', '').lower()
+        bold = bool(flags & cls.FLAG_BOLD) or any(w in font for w in ('bold', 'black', 'heavy'))
+        italic = bool(flags & cls.FLAG_ITALIC) or any(w in font for w in ('italic', 'oblique'))
+        if bold and italic:
+            return 'bold-italic'
+        return 'bold' if bold else 'italic' if italic else None
+    
+    def iter_emphasized_spans(self, pdf_path: str, inline_only: bool = True, pages: Optional[List[int]] = None):
+        """
+        Yield (page_num, text, style) for each run of emphasized text
+        
+        Adjacent spans with the same style are merged into one run. With
+        inline_only, runs covering a whole line are skipped - those are
+        headings or labels rather than terms emphasized within a sentence.
+        pages limits the scan to those 1-based pages (default: all).
+        """
+        doc = fitz.open(pdf_path)
+        try:
+            for page_index in ([page_num - 1 for page_num in pages] if pages else range(doc.page_count)):
+                page = doc.load_page(page_index)
+                blocks = page.get_text("dict").get("blocks", [])
+                page = None  # Release the page before loading the next one
+                
+                for block in blocks:
+                    for line in block.get("lines", []):
+                        runs = []
+                        for span in line.get("spans", []):
+                            text = span.get("text", "")
+                            if not text.strip():
+                                # Whitespace carries no visible style; keep the current run going
+                                if runs:
+                                    runs[-1][0] += text
+                                continue
+                            style = self.span_emphasis(span)
+                            if runs and runs[-1][1] == style:
+                                runs[-1][0] += text
+                            else:
+                                runs.append([text, style])
+                        
+                        if inline_only and all(style for _, style in runs):
+                            continue
+                        for text, style in runs:
+                            if style and text.strip():
+                                yield page_index + 1, text.strip(), style
+        finally:
+            doc.close()
+    
+    def extract_from_pdf(self, pdf_path: str) -> Dict[str, Any]:
+        """Extract content from any PDF file"""
+        # Extract and process text page by page
+        extracted = list(self.iter_page_texts(pdf_path))
+        extraction_method = 'basic' if self.config.get('basic_extraction') else 'pymupdf'
+        if extraction_method == 'pymupdf' and len(self.page_errors) * 2 > len(extracted):
+            # Most pages failed: something in the full pipeline, not single corrupt pages,
+            # so read plain text instead (still page by page)
+            basic = PDFExtractor({**self.config, 'basic_extraction': True, 'progress': False})
+            extracted = list(basic.iter_page_texts(pdf_path))
+            self.page_errors = basic.page_errors
+            self.link_count = self.code_block_count = self.list_count = 0
+            self.decoration_counts = {'strikethrough': 0, 'underline': 0}
+            self.equations = []
+            self.column_pages = []
+            self.reordered_pages = []
+            self.rtl_pages = []
+            self.furniture_lines = 0
+            extraction_method = 'basic'
+        raw_pages = [page_text for _, page_text, _, _ in extracted]
+        reflow_joins = []
+        if self.config.get('reflow_paragraphs', False):
+            raw_pages, reflow_joins = reflow_split_words(raw_pages)
+            # Joins report positions in the extracted list; map them to real page numbers
+            for join in reflow_joins:
+                join['page'] = extracted[join['page'] - 1][0]
+        
+        page_texts = []
+        line_number_pages = []
+        for (page_num, _, line_numbers, text_hash), page_text in zip(extracted, raw_pages):
+            page_entry = {
+                'page_num': page_num,
+                'text': self.process_text(page_text),
+                'text_hash': text_hash
+            }
+            if line_numbers:
+                values = [value for value, _ in line_numbers['numbers']]
+                page_entry['line_numbers'] = {'first': values[0], 'last': values[-1], 'side': line_numbers['side']}
+                line_number_pages.append(page_num)
+            page_texts.append(page_entry)
+        
+        raw_text = "".join(raw_pages)
+        processed_text = "\n".join(page['text'] for page in page_texts)
         
         # Auto-detect document structure
         structure = self.detect_document_structure(processed_text)
@@ -78,6 +484,7 @@ class PDFExtractor:
         return {
             'raw_text': raw_text,
             'processed_text': processed_text,
+            'page_texts': page_texts,
             'structure': structure,
             'fields': [field.to_dict() for field in fields],
             'summary': summary,
@@ -85,7 +492,21 @@ class PDFExtractor:
                 'total_fields': len(fields),
                 'document_type': structure.get('document_type', 'unknown'),
                 'has_tables': structure.get('has_tables', False),
-                'has_lists': structure.get('has_lists', False)
+                'has_lists': structure.get('has_lists', False),
+                'line_number_pages': line_number_pages,
+                'reflow_joins': reflow_joins,
+                'link_count': self.link_count,
+                'code_block_count': self.code_block_count,
+                'list_count': self.list_count,
+                'decorations': self.decoration_counts,
+                'equations': self.equations,
+                'latex_model_loaded': bool(self.latex_model),
+                'column_pages': self.column_pages,
+                'reordered_pages': self.reordered_pages,
+                'rtl_pages': self.rtl_pages,
+                'furniture_lines': self.furniture_lines,
+                'page_errors': self.page_errors,
+                'extraction_method': extraction_method
             }
         }
     
@@ -95,13 +516,22 @@ class PDFExtractor:
         for old, new in self.char_fixes.items():
             text = text.replace(old, new)
         
-        # Fix split bullet patterns generically
-        text = self._fix_split_bullets(text)
+        # detect_lists already wrote markdown list markers, which the bullet fixes would undo
+        if self.config.get('detect_lists') and not self.config.get('basic_extraction'):
+            return text
         
-        # Convert potential bullet markers
-        text = self._convert_bullet_markers(text)
+        # Bullet fixes are for prose; fenced code keeps its lines as they are
+        parts = []
+        for part, is_code in split_fenced(text):
+            if not is_code:
+                # Fix split bullet patterns generically
+                part = self._fix_split_bullets(part)
+                
+                # Convert potential bullet markers
+                part = self._convert_bullet_markers(part)
+            parts.append(part)
         
-        return text
+        return ''.join(parts)
     
     def _fix_split_bullets(self, text: str) -> str:
         """Fix split bullet patterns where marker and content are on separate lines"""
@@ -564,7 +994,18 @@ def extract_pdf(pdf_path: str, config: Optional[Dict[str, Any]] = None) -> Dict[
 
 
 # For backward compatibility and as main extraction method
-def extract_all_content(pdf_path: str, output_dir: str = None, extract_images: bool = True) -> Dict[str, Any]:
+def extract_all_content(pdf_path: str, output_dir: str = None, extract_images: bool = True,
+                        use_document_captions: bool = True, line_numbers: str = 'strip',
+                        pages: Optional[List[int]] = None, reflow_paragraphs: bool = False,
+                        progress: bool = False, cancel_event=None, image_dedup: bool = True,
+                        preserve_links: bool = False, detect_code_blocks: bool = False, detect_lists: bool = False,
+                        preserve_text_decorations: bool = False, underline_marker: str = DEFAULT_UNDERLINE_MARKER,
+                        max_image_bytes: Optional[int] = None, max_images_bytes: Optional[int] = None,
+                        image_format: str = 'original', image_quality: int = DEFAULT_IMAGE_QUALITY,
+                        column_layout: str = 'single', math_mode: str = 'off',
+                        equation_link: Optional[str] = None, strip_headers_footers: bool = False,
+                        reading_order: str = 'raw', page_markers: str = 'none',
+                        page_marker_labels: bool = False) -> Dict[str, Any]:
     """
     Extract all content from PDF with proper structure
     
@@ -572,31 +1013,209 @@ def extract_all_content(pdf_path: str, output_dir: str = None, extract_images: b
         pdf_path: Path to PDF file
         output_dir: Optional output directory for images
         extract_images: Whether to extract images
+        use_document_captions: Pair images with nearby "Figure N: ..." captions
+        line_numbers: Margin line numbers: 'strip', 'preserve' as [Ln] anchors, or 'keep'
+        pages: Only extract these 1-based pages (a sample); the fingerprint
+            is omitted since it would not describe the whole document
+        reflow_paragraphs: Join words split across column and page breaks
+        progress: Print a PROGRESS line per extracted page
+        cancel_event: threading.Event that stops extraction between pages
+        image_dedup: Write byte-identical images (repeated logos) once
+        preserve_links: Keep link annotations as markdown links
+        detect_code_blocks: Fence runs of monospaced lines as code blocks
+        detect_lists: Write bulleted and numbered lines as nested markdown lists
+        preserve_text_decorations: Mark strikethrough as ~~text~~ and underline with underline_marker
+        underline_marker: Opening marker for underlined text ('' leaves it unmarked)
+        max_image_bytes: Skip images larger than this (listed in metadata skipped_images)
+        max_images_bytes: Raise OutputLimitExceeded once images written pass this total
+        image_format: Write images as 'png', 'jpeg', or 'webp' ('original' keeps the embedded encoding)
+        image_quality: Quality (1-100) for jpeg and webp
+        column_layout: 'auto' reads detected two-column pages column by column, 'double' every page
+        math_mode: 'image' crops display equations into output_dir behind $$ placeholders, 'latex'
+            also converts them with pix2tex when it loads (listed in metadata equations)
+        equation_link: Path prefix linking equation crops from the markdown (default: output_dir's name)
+        strip_headers_footers: Remove running headers and footers repeated on most pages
+            (counted in metadata furniture_lines)
+        reading_order: 'ltr' or 'rtl' sorts each page's blocks into reading order, 'auto' picks the
+            direction per page and re-sorts only out-of-order pages, 'raw' keeps the PDF's order
+            (listed in metadata reordered_pages and rtl_pages)
+        page_markers: Line before each page's text in text and pages: 'comment', 'anchor', or 'none'
+            (see processors.page_markers); structure, fields, and summary use the unmarked text
+        page_marker_labels: Follow each anchor with a superscript page label
     
     Returns:
         Dictionary with text, pages, tables, images, fields, structure, metadata
     """
-    results = extract_pdf(pdf_path)
+    results = extract_pdf(pdf_path, {'line_numbers': line_numbers, 'pages': pages,
+                                     'reflow_paragraphs': reflow_paragraphs, 'progress': progress,
+                                     'cancel': cancel_event, 'preserve_links': preserve_links,
+                                     'detect_code_blocks': detect_code_blocks, 'detect_lists': detect_lists,
+                                     'preserve_text_decorations': preserve_text_decorations,
+                                     'underline_marker': underline_marker,
+                                     'column_layout': column_layout, 'math_mode': math_mode,
+                                     'strip_headers_footers': strip_headers_footers,
+                                     'reading_order': reading_order,
+                                     'equation_dir': output_dir,
+                                     'equation_link': equation_link or (Path(output_dir).name if output_dir else None)})
     
-    # Convert to expected format with proper structure
+    # Convert to expected format with proper structure (real PDF page numbers)
     text = results['processed_text']
-    pages = []
-    if text:
-        # Split into rough pages (approximately 3000 chars per page)
-        page_size = 3000
-        for i in range(0, len(text), page_size):
-            pages.append({
-                'page_num': (i // page_size) + 1,
-                'text': text[i:i+page_size]
-            })
+    text_pages = [page for page in results.get('page_texts', []) if page['text'].strip()]
+    if page_markers != 'none':
+        marked = mark_pages(results.get('page_texts', []), page_markers, page_marker_labels)
+        text = "\n".join(page['text'] for page in marked)
+        text_pages = [page for page in marked if page['text'].strip()]
+    fingerprint = None
+    if not pages:
+        fingerprint = compute_fingerprint(pdf_path, [page['text_hash'] for page in results.get('page_texts', [])])
+    
+    images = []
+    skipped_images = []
+    image_bytes = None
+    if extract_images and output_dir:
+        check_cancelled(cancel_event)
+        extractor = ImageExtractor(Path(output_dir), use_document_captions, dedupe=image_dedup,
+                                   max_image_bytes=max_image_bytes, max_images_bytes=max_images_bytes,
+                                   image_format=image_format, image_quality=image_quality)
+        images = extractor.extract(pdf_path, pages)
+        skipped_images = extractor.skipped
+        image_bytes = {'written': extractor.bytes_written, 'original': extractor.original_bytes,
+                       'unconverted': extractor.unconverted, 'kept_smaller': extractor.kept_smaller}
     
     return {
         'text': text,
-        'pages': pages if pages else [{'page_num': 1, 'text': text}],
+        'pages': text_pages if text_pages else [{'page_num': 1, 'text': text}],
         'tables': [],  # TODO: Extract tables separately if needed
-        'images': [],  # TODO: Extract images if needed
+        'images': images,
         'fields': results['fields'],
         'structure': results['structure'],
-        'metadata': results['metadata'],
+        'metadata': {**results['metadata'], 'fingerprint': fingerprint, 'skipped_images': skipped_images,
+                     'image_bytes': image_bytes},
         'summary': results['summary']
-    }
\ No newline at end of file
+    }
+
+def extract_outline(pdf_path: str) -> List[Dict[str, Any]]:
+    """
+    Read the bookmark tree in outline order
+    
+    Returns:
+        List of outline entries with title, level (1 = top), and 1-based
+        page (None when the bookmark has no page destination)
+    """
+    doc = fitz.open(pdf_path)
+    try:
+        return [
+            {'title': title, 'level': level, 'page': page if page > 0 else None}
+            for level, title, page in doc.get_toc(simple=True)
+        ]
+    finally:
+        doc.close()
+
+def extract_tables_with_pdfplumber(pdf_path: str, pages: Optional[List[int]] = None,
+                                   page_range: Optional[str] = None) -> List[Dict[str, Any]]:
+    """
+    Extract tables page by page with pdfplumber
+    
+    Args:
+        pdf_path: Path to PDF file
+        pages: Only read these 1-based pages (default: all)
+        page_range: A page range spec instead of pages, checked against the page count
+    
+    Returns:
+        List of table dictionaries with page, index (within the page), and
+        data (list of rows, each a list of cell strings)
+    """
+    import pdfplumber
+    
+    tables = []
+    with pdfplumber.open(pdf_path) as pdf:
+        if page_range:
+            pages = parse_page_range(page_range, len(pdf.pages))
+        if pages and max(pages) > len(pdf.pages):
+            raise ValueError(f"page_range: page {max(pages)} is past the end of the document ({len(pdf.pages)} pages)")
+        for page_num in pages or range(1, len(pdf.pages) + 1):
+            page = pdf.pages[page_num - 1]
+            for index, table in enumerate(page.extract_tables() or []):
+                rows = [[(cell or '').strip() for cell in row] for row in table if row]
+                if any(any(cell for cell in row) for row in rows):
+                    tables.append({'page': page_num, 'index': index, 'data': rows})
+            
+            # Drop cached layout objects so memory stays flat across pages
+            page.flush_cache()
+    
+    return tables
+
+
+# Expansion from on-disk page bytes to in-memory working set (decoded text,
+# layout objects, and images held while a page is processed)
+MEMORY_EXPANSION_FACTOR = 8
+# Memory kept for every page until sections are written (extracted text,
+# section and chunk copies of it, per-page metadata)
+HELD_BYTES_PER_PAGE = 64 * 1024
+DEFAULT_MEMORY_WARNING_MB = 1024
+
+
+def estimate_conversion_mb(page_count: int, file_size: int) -> float:
+    """
+    Peak conversion memory in MB for a PDF of page_count pages and file_size bytes
+
+    Pages are processed one at a time, so the page working set is the
+    average page's bytes expanded by MEMORY_EXPANSION_FACTOR; the text of
+    every page is held until sections are built, HELD_BYTES_PER_PAGE each.
+    """
+    avg_page_bytes = file_size / page_count if page_count else 0
+    return (avg_page_bytes * MEMORY_EXPANSION_FACTOR + page_count * HELD_BYTES_PER_PAGE) / (1024 * 1024)
+
+
+def estimate_memory_usage(pdf_path: str, warning_mb: Optional[int] = None) -> Dict[str, Any]:
+    """
+    Estimate peak memory for converting a PDF and warn when it looks high
+    
+    See estimate_conversion_mb. Opening the document only reads the page
+    tree, not page content, so this is cheap even for very large files.
+    
+    Args:
+        pdf_path: Path to PDF file
+        warning_mb: Threshold in MB (default: MEMORY_WARNING_MB env or 1024)
+    
+    Returns:
+        Dictionary with page_count, avg_page_bytes, estimated_mb, and an
+        optional warning message
+    """
+    import os
+    
+    if warning_mb is None:
+        try:
+            warning_mb = int(os.environ.get('MEMORY_WARNING_MB', DEFAULT_MEMORY_WARNING_MB))
+        except ValueError:
+            warning_mb = DEFAULT_MEMORY_WARNING_MB
+    
+    file_size = Path(pdf_path).stat().st_size
+    doc = fitz.open(pdf_path)
+    try:
+        page_count = doc.page_count
+        is_linearized = bool(getattr(doc, 'is_fast_webaccess', False))
+    finally:
+        doc.close()
+    
+    avg_page_bytes = file_size / page_count if page_count else 0
+    estimated_mb = estimate_conversion_mb(page_count, file_size)
+    
+    estimate = {
+        'page_count': page_count,
+        'file_size_bytes': file_size,
+        'avg_page_bytes': int(avg_page_bytes),
+        'linearized': is_linearized,
+        'estimated_mb': round(estimated_mb, 1),
+        'warning_threshold_mb': warning_mb,
+        'warning': None
+    }
+    
+    if estimated_mb > warning_mb:
+        estimate['warning'] = (
+            f"High memory use expected: {page_count} pages, {avg_page_bytes / 1024:.0f} KB/page on average "
+            f"≈ {estimated_mb:.0f} MB (threshold {warning_mb} MB). Consider converting on a larger "
+            f"host or raising MEMORY_WARNING_MB if this is expected."
+        )
+    
+    return estimate
@@ -0,0 +1,77 @@
+"""
+Combine several PDF files into one logical document
+
+A manual delivered as several PDFs (one per volume or chapter group) is
+concatenated in the given order before conversion, so chapter detection,
+section numbering, and navigation span the whole manual. Bookmarks are
+kept with their page numbers shifted by the pages that precede each file,
+and each file's page span in the combined document is recorded so
+sections can name the file they came from.
+"""
+import threading
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+try:
+    from ..utils.cancellation import check_cancelled
+except ImportError:
+    from utils.cancellation import check_cancelled
+
+
+def merge_pdfs(pdf_paths: List[str], output_path: str,
+               cancel_event: Optional[threading.Event] = None) -> List[Dict[str, Any]]:
+    """
+    Concatenate PDFs into output_path
+
+    Args:
+        pdf_paths: Files in reading order
+        output_path: Where to save the combined PDF
+        cancel_event: threading.Event checked between files
+
+    Returns:
+        One entry per file: file (name), path, page_start and page_end
+        (1-based pages in the combined document), and page_count
+    """
+    import fitz
+
+    merged = fitz.open()
+    toc: List[List[Any]] = []
+    parts: List[Dict[str, Any]] = []
+    try:
+        for pdf_path in pdf_paths:
+            check_cancelled(cancel_event)
+            source = fitz.open(pdf_path)
+            try:
+                offset = merged.page_count
+                merged.insert_pdf(source)
+                # Bookmarks without a page destination (page <= 0) keep it
+                toc.extend([level, title, page + offset if page > 0 else page]
+                           for level, title, page in source.get_toc(simple=True))
+                if not merged.metadata.get('title') and source.metadata.get('title'):
+                    merged.set_metadata({'title': source.metadata['title']})
+                parts.append({
+                    'file': Path(pdf_path).name,
+                    'path': str(pdf_path),
+                    'page_start': offset + 1,
+                    'page_end': offset + source.page_count,
+                    'page_count': source.page_count
+                })
+            finally:
+                source.close()
+
+        if toc:
+            merged.set_toc(toc)
+        merged.save(output_path, garbage=3, deflate=True)
+    finally:
+        merged.close()
+    return parts
+
+
+def source_file_for_page(parts: List[Dict[str, Any]], page: Optional[int]) -> Optional[Dict[str, Any]]:
+    """The merged file holding a page of the combined document, or None"""
+    if page is None:
+        return None
+    for part in parts:
+        if part['page_start'] <= page <= part['page_end']:
+            return part
+    return None
@@ -0,0 +1,135 @@
+"""
+Retrieval chunks as JSON Lines
+
+Vector stores ingest one record per chunk: the text to embed and flat
+metadata to filter and cite by. With chunks_jsonl, the chunk_tokens chunks
+are also written to chunked/chunks.jsonl, one JSON object per line:
+
+    {"id": "5e1f0c9a7b2d4e63:3:2", "text": "...", "metadata": {"source": "manual.pdf",
+     "document_id": "5e1f0c9a7b2d4e63", "section": "Authentication", "section_id": 3,
+     "heading_path": ["Authentication", "Tokens"], "page_start": 12,
+     "page_end": 14, "chunk": 2, "chunks": 5, "tokens": 742,
+     "file": "03-authentication-chunk-002.md"}}
+
+heading_path runs from the outermost enclosing section, through the
+section title, down to the deepest heading in effect where the chunk
+starts: headings the chunk opens with count, headings further into it do
+not. document_id is taken from the PDF's bytes, so two different files
+with the same name never share record ids.
+
+vector_db_format hands the records to pdf_to_rag.py, the pipeline behind
+prepare_pdf_for_rag, which writes them in that database's import format
+next to chunks.jsonl.
+"""
+import json
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+try:
+    from .page_markers import marker_page
+except ImportError:
+    # Handle running as script vs package
+    import sys
+    sys.path.append(str(Path(__file__).parent.parent))
+    from processors.page_markers import marker_page
+
+CHUNKS_JSONL = 'chunks.jsonl'
+
+# Import formats pdf_to_rag.py writes (PDFToRAGProcessor.generate_vector_db_format)
+VECTOR_DB_FORMATS = ('generic', 'pinecone', 'chromadb', 'weaviate', 'qdrant')
+
+# An ATX heading line ("## Title", optional closing #s)
+MARKDOWN_HEADING = re.compile(r'^(#{1,6})\s+(.+?)(?:\s+#+)?\s*$')
+
+
+def push_heading(stack: List[Tuple[int, str]], level: int, title: str) -> None:
+    """Make a heading the deepest open one, closing those at its level or below"""
+    while stack and stack[-1][0] >= level:
+        stack.pop()
+    stack.append((level, title))
+
+
+def chunk_heading_paths(section_title: str, chunk_texts: Sequence[str],
+                        parents: Sequence[str] = ()) -> List[List[str]]:
+    """
+    Heading path of each chunk of one section, in order
+
+    Headings inside fenced code blocks are ignored, as is a heading that
+    repeats the section title. Headings in the text nest under the section
+    whatever their level; parents are the titles of the enclosing sections,
+    outermost first.
+    """
+    stack: List[Tuple[int, str]] = []
+    root = list(parents) + [section_title]
+    paths = []
+    for text in chunk_texts:
+        path = None
+        fenced = False
+        for line in text.split('\n'):
+            stripped = line.strip()
+            if stripped.startswith('```'):
+                fenced = not fenced
+            match = None if fenced else MARKDOWN_HEADING.match(stripped)
+            if match and match.group(2) != section_title:
+                push_heading(stack, len(match.group(1)), match.group(2))
+            elif stripped and not match and path is None and marker_page(stripped) is None:
+                # The chunk's first body line: the path is whatever is open here
+                path = root + [title for _, title in stack]
+        paths.append(path if path is not None else root + [title for _, title in stack])
+    return paths
+
+
+def chunk_records(chunks: Sequence[Dict[str, Any]], source: str, document_id: str) -> List[Dict[str, Any]]:
+    """
+    One JSON Lines record per chunk
+
+    Args:
+        chunks: Chunks in document order with file, section_id,
+            section_title, chunk, chunks, tokens, page_start, page_end, and text
+            (and heading_path, computed from the section alone when missing)
+        source: Source file name
+        document_id: Stable document identifier, the prefix of every record id
+    """
+    paths: Dict[Any, List[List[str]]] = {}
+    for section_id in dict.fromkeys(chunk['section_id'] for chunk in chunks):
+        section_chunks = [chunk for chunk in chunks if chunk['section_id'] == section_id]
+        paths[section_id] = chunk_heading_paths(section_chunks[0]['section_title'],
+                                                [chunk['text'] for chunk in section_chunks])
+    records = []
+    for chunk in chunks:
+        records.append({
+            'id': f"{document_id}:{chunk['section_id']}:{chunk['chunk']}",
+            'text': chunk['text'],
+            'metadata': {
+                'source': source,
+                'document_id': document_id,
+                'section': chunk['section_title'],
+                'section_id': chunk['section_id'],
+                'heading_path': chunk.get('heading_path') or paths[chunk['section_id']][chunk['chunk'] - 1],
+                'page_start': chunk.get('page_start'),
+                'page_end': chunk.get('page_end'),
+                'chunk': chunk['chunk'],
+                'chunks': chunk['chunks'],
+                'tokens': chunk['tokens'],
+                'file': chunk['file']
+            }
+        })
+    return records
+
+
+def validate_vector_db_format(vector_db_format: Optional[str]) -> Optional[str]:
+    """vector_db_format, None when not set (raises ValueError for an unknown one)"""
+    if vector_db_format and vector_db_format not in VECTOR_DB_FORMATS:
+        raise ValueError(f"Unknown vector_db_format '{vector_db_format}' "
+                         f"(expected one of: {', '.join(VECTOR_DB_FORMATS)})")
+    return vector_db_format or None
+
+
+def write_chunks_jsonl(records: Sequence[Dict[str, Any]], path: Path) -> int:
+    """Write records one JSON object per line (UTF-8, newline-terminated); returns how many"""
+    path.parent.mkdir(parents=True, exist_ok=True)
+    with open(path, 'w', encoding='utf-8') as f:
+        for record in records:
+            f.write(json.dumps(record, ensure_ascii=False) + '\n')
+    return len(records)
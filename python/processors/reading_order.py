@@ -0,0 +1,168 @@
+"""
+Reading order of text blocks, left to right or right to left
+
+PDFs draw text in whatever order their producer chose, which is not
+always the order it is read in: a sidebar or a text box can be drawn
+before the page's main text, putting it first in the extracted text. With
+reading_order 'ltr' a page's blocks are read top to bottom, and blocks
+side by side on a row from left to right; 'rtl' reads the rows from right
+to left, for Arabic and Hebrew. 'raw' keeps the PDF's order, and 'auto'
+(the default) picks rtl for pages written mostly in right-to-left scripts
+and otherwise keeps the PDF's order unless a block comes before one drawn
+entirely above it. Pages set in two columns (processors.columns) are
+read a column at a time, so rows never interleave blocks from the two.
+
+Right-to-left text is often stored in visual order (glyphs left to right
+as they appear on the page), which reads backwards when extracted. On rtl
+pages each line is rebuilt from its characters' positions and turned from
+visual into logical order; runs of left-to-right text inside it (Latin
+words, numbers) keep their own order rather than being reversed too.
+"""
+import unicodedata
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+try:
+    from .columns import Rect, detect_columns, order_blocks
+except ImportError:
+    from processors.columns import Rect, detect_columns, order_blocks
+
+READING_ORDERS = ('auto', 'raw', 'ltr', 'rtl')
+
+# (x0, y0, x1, y1, text) in page coordinates with y growing downwards, as in processors.columns
+Block = Tuple[float, float, float, float, str]
+
+# Bidi classes of strong right-to-left characters, and of characters kept in left-to-right runs
+RTL_CLASSES = ('R', 'AL')
+LTR_CLASSES = ('L', 'EN', 'AN')
+
+# Brackets drawn mirrored in right-to-left text
+MIRRORED = {'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{', '<': '>', '>': '<'}
+
+# Points a block must sit above another to count as out of order
+ORDER_TOLERANCE = 2.0
+
+
+def validate_reading_order(reading_order: Optional[str]) -> str:
+    """reading_order, defaulting to 'auto' (raises ValueError for an unknown one)"""
+    reading_order = reading_order or 'auto'
+    if reading_order not in READING_ORDERS:
+        raise ValueError(f"Unknown reading_order '{reading_order}' (expected one of: {', '.join(READING_ORDERS)})")
+    return reading_order
+
+
+def is_rtl(text: str) -> bool:
+    """Whether most strong (letter) characters of text are right-to-left"""
+    rtl = ltr = 0
+    for char in text:
+        direction = unicodedata.bidirectional(char)
+        if direction in RTL_CLASSES:
+            rtl += 1
+        elif direction == 'L':
+            ltr += 1
+    return rtl > ltr
+
+
+def page_direction(reading_order: str, text: str) -> Optional[str]:
+    """'ltr' or 'rtl' for a page under reading_order ('auto' decides from its text), None for 'raw'"""
+    if reading_order == 'raw':
+        return None
+    if reading_order == 'auto':
+        return 'rtl' if is_rtl(text) else 'ltr'
+    return reading_order
+
+
+def visual_to_logical(visual: str) -> str:
+    """
+    Logical order of a right-to-left line given in visual (left to right) order
+
+    The line is reversed, then each run of left-to-right characters (with
+    the spaces and punctuation between them) is put back the right way
+    round, so "ABC 2024 xyz" stays readable inside Hebrew or Arabic.
+    Brackets in the reversed parts are mirrored. Lines mostly in a
+    left-to-right script are returned unchanged.
+    """
+    if not is_rtl(visual):
+        return visual
+    chars = [MIRRORED.get(char, char) for char in reversed(visual)]
+    classes = [unicodedata.bidirectional(char) for char in reversed(visual)]
+    index = 0
+    while index < len(chars):
+        if classes[index] not in LTR_CLASSES:
+            index += 1
+            continue
+        # The run ends at the last left-to-right character before the next right-to-left one
+        end = index
+        scan = index
+        while scan < len(chars) and classes[scan] not in RTL_CLASSES:
+            if classes[scan] in LTR_CLASSES:
+                end = scan
+            scan += 1
+        run = [MIRRORED.get(char, char) for char in reversed(chars[index:end + 1])]
+        chars[index:end + 1] = run
+        index = end + 1
+    return ''.join(chars)
+
+
+def rtl_blocks(page_dict: Dict[str, Any]) -> List[Block]:
+    """
+    Text blocks of a page.get_text("rawdict") result with each line in logical right-to-left order
+
+    Characters are placed by position rather than the order the PDF drew
+    them, so lines stored in either visual or logical order come out the same.
+    """
+    blocks = []
+    for block in page_dict.get('blocks', []):
+        if block.get('type', 0) != 0:
+            continue
+        lines = []
+        for line in block.get('lines', []):
+            chars = [char for span in line.get('spans', []) for char in span.get('chars', [])]
+            chars.sort(key=lambda char: (char['bbox'][0] + char['bbox'][2]) / 2)
+            text = visual_to_logical(''.join(char['c'] for char in chars)).strip()
+            if text:
+                lines.append(text)
+        if lines:
+            x0, y0, x1, y1 = block['bbox']
+            blocks.append((x0, y0, x1, y1, '\n'.join(lines) + '\n'))
+    return blocks
+
+
+def _same_row(a: Block, b: Block) -> bool:
+    """Whether two blocks sit side by side: each one's vertical middle lies within the other"""
+    return a[1] <= (b[1] + b[3]) / 2 <= a[3] and b[1] <= (a[1] + a[3]) / 2 <= b[3]
+
+
+def out_of_order(blocks: Sequence[Block]) -> bool:
+    """Whether a block comes before one drawn entirely above it in the same horizontal span"""
+    for index, block in enumerate(blocks):
+        for later in blocks[index + 1:]:
+            overlap = min(block[2], later[2]) - max(block[0], later[0])
+            if overlap > 0 and later[3] <= block[1] - ORDER_TOLERANCE:
+                return True
+    return False
+
+
+def sort_blocks(blocks: Sequence[Block], direction: str = 'ltr', regions: Sequence[Rect] = ()) -> List[Block]:
+    """
+    Blocks in reading order: rows top to bottom, blocks side by side on a
+    row left to right ('ltr') or right to left ('rtl')
+
+    A two-column page (detect_columns) is ordered by column first: each
+    column top to bottom, the right one first for 'rtl', with full-width
+    blocks in place between them.
+    """
+    if detect_columns(blocks, regions) > 1:
+        return order_blocks(blocks, 2, regions, right_to_left=direction == 'rtl')
+    rows: List[List[Block]] = []
+    for block in sorted(blocks, key=lambda b: (b[1], b[0])):
+        if rows and _same_row(rows[-1][0], block):
+            rows[-1].append(block)
+        else:
+            rows.append([block])
+    ordered: List[Block] = []
+    for row in rows:
+        if direction == 'rtl':
+            ordered.extend(sorted(row, key=lambda b: -b[2]))
+        else:
+            ordered.extend(sorted(row, key=lambda b: b[0]))
+    return ordered
@@ -0,0 +1,292 @@
+"""
+Bibliography extraction as structured citations
+
+Finds the references section at the end of a document, splits it into
+entries (numbered "[1]"/"1." lists or author-year paragraphs), and parses
+each entry with heuristics for the common styles (IEEE, APA, Harvard,
+Chicago). Fields that can't be found are left as None and the raw text is
+always kept, so nothing is lost when a heuristic misses.
+"""
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+# Heading that opens a references section (on its own line)
+REFERENCES_HEADING = re.compile(
+    r'^\s*(?:\d+\.?\s+|#+\s*)?(references|bibliography|works cited|literature cited|'
+    r'references and notes|cited works|sources)\s*:?\s*$',
+    re.IGNORECASE | re.MULTILINE
+)
+
+# Headings that end the references section
+SECTION_END_HEADING = re.compile(
+    r'^\s*(?:#+\s*)?(appendix\b|appendices\b|index\s*$|acknowledg(e)?ments?\s*$|about the authors?\s*$|'
+    r'supplementary material\s*$|glossary\s*$)',
+    re.IGNORECASE | re.MULTILINE
+)
+
+# "[12] ...", "12. ...", "(12) ...", "12 ..." at the start of an entry
+NUMBERED_ENTRY = re.compile(r'^\s*(?:\[(\d{1,4})\]|\((\d{1,4})\)|(\d{1,4})[.)])\s+')
+
+# "Surname, A." / "Surname, Alice" / "van der Berg, J." / "Surname A" at the start of an author-year entry
+AUTHOR_START = re.compile(r"^\s*(?:[a-z]{1,3}\s){0,2}[A-Z][A-Za-z'’\-]+,\s+(?:[A-Z]\.|[A-Z][a-z]+)")
+
+DOI_PATTERN = re.compile(r'\b(10\.\d{4,9}/[^\s"<>]+)', re.IGNORECASE)
+URL_PATTERN = re.compile(r'(https?://[^\s<>"]+|www\.[^\s<>"]+)')
+YEAR_IN_PARENS = re.compile(r'\((\d{4}[a-z]?|n\.d\.)(?:,\s*[^)]*)?\)')
+YEAR_ANYWHERE = re.compile(r'\b((?:1[5-9]|20)\d{2}[a-z]?)\b')
+# "Smith, J." / "van der Berg, Anna": surname first, then a comma
+INVERTED_NAME = re.compile(r"^(?:[a-z]{1,3}\s){0,2}[A-Z][^\s,.]*[a-z][^\s,.]*,\s")
+QUOTED_TITLE = re.compile(r'["“]([^"”]{3,})[,.]?["”]')
+
+
+def find_references_section(text: str) -> Optional[str]:
+    """
+    Text of the references section, or None if there is no references heading
+
+    The last matching heading wins (a table of contents also lists
+    "References"); the section runs to the next appendix-like heading or
+    the end of the document.
+    """
+    matches = list(REFERENCES_HEADING.finditer(text or ''))
+    if not matches:
+        return None
+    body = text[matches[-1].end():]
+    end = SECTION_END_HEADING.search(body)
+    if end:
+        body = body[:end.start()]
+    return body.strip() or None
+
+
+def split_reference_entries(section: str) -> Tuple[List[str], str]:
+    """
+    Split a references section into entries
+
+    Returns:
+        (entries, style) - style is 'numbered' or 'author_year'. Wrapped
+        lines are joined and hyphenation at line ends is undone.
+    """
+    lines = [line.rstrip() for line in section.splitlines()]
+    numbered = sum(1 for line in lines if NUMBERED_ENTRY.match(line))
+    style = 'numbered' if numbered >= max(2, len([l for l in lines if l.strip()]) // 6) else 'author_year'
+
+    entries: List[List[str]] = []
+    previous = ''
+    for line in lines:
+        if not line.strip():
+            # A blank line ends an author-year entry; numbered entries start explicitly
+            if style == 'author_year' and entries and entries[-1]:
+                entries.append([])
+            previous = ''
+            continue
+
+        if style == 'numbered':
+            starts_entry = bool(NUMBERED_ENTRY.match(line))
+        else:
+            starts_entry = bool(AUTHOR_START.match(line)) and (not previous or previous.rstrip().endswith('.'))
+
+        if not entries or (starts_entry and entries[-1]):
+            entries.append([line.strip()])
+        else:
+            # Continuation line, or the first line after a blank separator
+            entries[-1].append(line.strip())
+        previous = line
+
+    return [_join_lines(entry) for entry in entries if entry], style
+
+
+def _join_lines(lines: List[str]) -> str:
+    """Join wrapped lines, undoing "hyphen-\\nated" breaks"""
+    text = ''
+    for line in lines:
+        if text.endswith('-') and line[:1].islower():
+            text = text[:-1] + line
+        elif text:
+            text += ' ' + line
+        else:
+            text = line
+    return re.sub(r'\s+', ' ', text).strip()
+
+
+def split_authors(authors: str) -> List[str]:
+    """
+    Split an author list into names
+
+    Handles "Surname, I., Surname, I." (pairs of comma-separated parts),
+    "I. Surname, I. Surname", and ';', 'and', '&' separators.
+    """
+    authors = re.sub(r'\bet al\.?', '', authors)
+    authors = re.sub(r',?\s+(?:and|&)\s+', ', ', authors).strip(' ,;')
+    if not authors:
+        return []
+
+    if ';' in authors:
+        names = authors.split(';')
+    elif INVERTED_NAME.match(authors):
+        parts = [part.strip() for part in authors.split(',')]
+        names = [', '.join(parts[i:i + 2]) for i in range(0, len(parts), 2)]
+    else:
+        names = authors.split(',')
+
+    result = []
+    for name in names:
+        name = name.strip(' ,;')
+        # Keep the period of a trailing initial ("Smith, J."), drop a sentence period
+        if name.endswith('.') and not re.search(r'\b[A-Z]\.$', name):
+            name = name[:-1]
+        if name:
+            result.append(name)
+    return result
+
+
+def _is_name_like(chunk: str) -> bool:
+    """An author chunk carries an initial ("A. Smith", "Smith, J.")"""
+    return bool(re.search(r'\b[A-Z]\.', chunk))
+
+
+def parse_reference(entry: str, style: str = 'author_year') -> Dict[str, Any]:
+    """
+    Parse one bibliography entry into structured fields
+
+    Returns:
+        Dictionary with number, authors, title, year, venue, doi, url, and raw
+    """
+    raw = entry.strip()
+    text = raw
+    number = None
+    marker = NUMBERED_ENTRY.match(text)
+    if marker and style == 'numbered':
+        number = int(next(group for group in marker.groups() if group))
+        text = text[marker.end():]
+
+    doi = DOI_PATTERN.search(text)
+    doi_value = doi.group(1).rstrip('.,;)') if doi else None
+    url = URL_PATTERN.search(text)
+    url_value = url.group(1).rstrip('.,;)') if url else None
+    if url_value and doi_value and doi_value in url_value:
+        url_value = url_value if not url_value.lower().startswith(('https://doi.org', 'http://doi.org', 'https://dx.doi.org')) else None
+    # Drop identifiers before splitting the rest into fields
+    text = URL_PATTERN.sub('', text)
+    text = re.sub(r'\b(?:doi:\s*)?10\.\d{4,9}/[^\s"<>]+', '', text, flags=re.IGNORECASE)
+    text = re.sub(r'\[online\]\.?', '', text, flags=re.IGNORECASE)
+    text = re.sub(r'\b(?:available|retrieved)(?:\s+(?:at|from))?:?\s*$', '', text.strip(), flags=re.IGNORECASE)
+    text = re.sub(r'\s+', ' ', text).strip(' .,;')
+
+    authors: List[str] = []
+    title = venue = year = None
+
+    quoted = QUOTED_TITLE.search(text)
+    parens_year = YEAR_IN_PARENS.search(text)
+    if quoted:
+        # IEEE / Chicago: Authors, "Title," Venue, ..., year.
+        authors = split_authors(text[:quoted.start()])
+        title = quoted.group(1).strip(' ,.')
+        remainder = text[quoted.end():].strip(' ,.')
+        year_match = parens_year or YEAR_ANYWHERE.search(remainder)
+        year = year_match.group(1) if year_match else None
+        venue = re.split(r',\s*(?:vol\.|no\.|pp\.|\d)', remainder, maxsplit=1)[0].strip(' ,.') or None
+    elif parens_year:
+        # APA / Harvard: Authors (Year). Title. Venue, volume(issue), pages.
+        authors = split_authors(text[:parens_year.start()])
+        year = parens_year.group(1)
+        rest = text[parens_year.end():].strip(' .,')
+        title, venue = _split_title_venue(rest)
+    else:
+        # Authors. Year. Title. Venue.  /  Authors. Title. Venue, Year.
+        year_match = YEAR_ANYWHERE.search(text)
+        year = year_match.group(1) if year_match else None
+        parts = [p.strip() for p in re.split(r'(?<![A-Z])\.\s+', text) if p.strip()]
+        if parts:
+            # "R. Roe, Book Title" - trailing chunks without initials are the title
+            chunks = parts[0].split(', ')
+            while len(chunks) > 1 and not INVERTED_NAME.match(parts[0]) and not _is_name_like(chunks[-1]):
+                parts.insert(1, chunks.pop())
+            authors = split_authors(', '.join(chunks))
+            rest = [p for p in parts[1:] if p != year]
+            if rest:
+                title = rest[0].strip(' .,')
+                venue = '. '.join(rest[1:]).strip(' .,') or None
+        if venue and year:
+            venue = re.sub(r',?\s*\(?' + re.escape(year) + r'\)?\s*$', '', venue).strip(' .,') or None
+
+    if venue:
+        venue = re.sub(r',?\s*\(?(?:\d+\s*\(\d+\)|vol\.|pp\.|\d+[-–]\d+).*$', '', venue).strip(' .,')
+        venue = re.sub(r'^in:?\s+', '', venue, flags=re.IGNORECASE) or None
+
+    return {
+        'number': number,
+        'authors': authors,
+        'title': title or None,
+        'year': year if year != 'n.d.' else None,
+        'venue': venue,
+        'doi': doi_value,
+        'url': url_value,
+        'raw': raw
+    }
+
+
+def _split_title_venue(rest: str) -> Tuple[Optional[str], Optional[str]]:
+    """Split "Title. Venue, 12(3), 1-10" at the first sentence break"""
+    parts = re.split(r'(?<=[.?!])\s+(?=[A-Z])', rest, maxsplit=1)
+    title = parts[0].strip(' .') or None
+    venue = parts[1].strip(' .') if len(parts) > 1 else None
+    if venue:
+        venue = re.sub(r'^In:?\s+', '', venue)
+    return title, venue or None
+
+
+def extract_references_from_text(text: str) -> Dict[str, Any]:
+    """
+    Locate and parse the references section of a document's text
+
+    Returns:
+        Dictionary with found, style, reference_count, and references
+    """
+    section = find_references_section(text)
+    if not section:
+        return {'found': False, 'style': None, 'reference_count': 0, 'references': []}
+
+    entries, style = split_reference_entries(section)
+    references = [parse_reference(entry, style) for entry in entries]
+    return {
+        'found': True,
+        'style': style,
+        'reference_count': len(references),
+        'references': references
+    }
+
+
+def extract_references(pdf_path: str) -> Dict[str, Any]:
+    """Extract structured references from a PDF"""
+    import fitz
+
+    doc = fitz.open(pdf_path)
+    try:
+        text = '\n'.join(page.get_text() for page in doc)
+    finally:
+        doc.close()
+    return extract_references_from_text(text)
+
+
+def format_references_markdown(result: Dict[str, Any]) -> str:
+    """Cleaned markdown list of the parsed references"""
+    lines = ["# References", ""]
+    for position, ref in enumerate(result.get('references', []), 1):
+        marker = f"{ref['number']}." if ref.get('number') is not None else f"{position}."
+        if not ref.get('title'):
+            lines.append(f"{marker} {ref['raw']}")
+            continue
+        parts = []
+        if ref['authors']:
+            parts.append(", ".join(ref['authors']))
+        if ref['year']:
+            parts.append(f"({ref['year']})")
+        entry = " ".join(parts)
+        entry = f"{entry}. *{ref['title']}*" if entry else f"*{ref['title']}*"
+        if ref['venue']:
+            entry += f". {ref['venue']}"
+        if ref['doi']:
+            entry += f". [doi:{ref['doi']}](https://doi.org/{ref['doi']})"
+        elif ref['url']:
+            entry += f". <{ref['url']}>"
+        lines.append(f"{marker} {entry}")
+    return "\n".join(lines) + "\n"
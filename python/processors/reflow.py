@@ -0,0 +1,196 @@
+"""
+Joining words split across column and page boundaries
+
+When a sentence runs from the bottom of one column (or page) to the top of
+the next, the last word is sometimes cut without a hyphen ("internatio" /
+"nal"), or the cut fragment is repeated in full on the next line
+("internatio" / "international"). Page text is built block by block with a
+COLUMN_BREAK marker where the geometry shows a jump to the next column;
+those markers and page ends are the only places words are joined, and
+only when the document itself (or a system word list) confirms the joined
+word.
+"""
+import re
+from collections import Counter
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Sequence, Set, Tuple
+
+# Marks a column break inside page text until reflow removes it
+COLUMN_BREAK = '\f'
+
+# Word lists tried for the dictionary check (first one found wins)
+DICTIONARY_PATHS = ('/usr/share/dict/words', '/usr/dict/words')
+
+# Points a block may overlap the previous column and still count as the next column
+COLUMN_TOLERANCE = 4.0
+
+# Running headers/footers around page breaks: "12", "Page 3", "Page 3 of 10"
+PAGE_MARKER_LINE = re.compile(r'^\s*(page\s+)?\d+(\s+(of|/)\s+\d+)?\s*$', re.IGNORECASE)
+
+WORD = re.compile(r"[A-Za-z]+")
+
+Block = Tuple[float, float, float, float, str]
+
+_dictionary_cache: Optional[Set[str]] = None
+
+
+def load_dictionary() -> Set[str]:
+    """Lowercase words from the system word list (empty if there is none)"""
+    global _dictionary_cache
+    if _dictionary_cache is None:
+        _dictionary_cache = set()
+        for candidate in DICTIONARY_PATHS:
+            path = Path(candidate)
+            if path.is_file():
+                _dictionary_cache = {w.strip().lower() for w in path.read_text(errors='ignore').split() if w.strip()}
+                break
+    return _dictionary_cache
+
+
+def find_column_breaks(blocks: Sequence[Block]) -> Set[int]:
+    """
+    Indices of blocks that end a column
+
+    Block i ends a column when the next block in reading order starts to
+    its right (no horizontal overlap) and higher up the page - the text
+    jumped to the top of the next column.
+    """
+    breaks = set()
+    for index in range(len(blocks) - 1):
+        current, following = blocks[index], blocks[index + 1]
+        if following[0] >= current[2] - COLUMN_TOLERANCE and following[1] < current[1]:
+            breaks.add(index)
+    return breaks
+
+
+def page_text_with_column_breaks(blocks: Sequence[Block]) -> str:
+    """Join block texts in reading order, marking column breaks with COLUMN_BREAK"""
+    breaks = find_column_breaks(blocks)
+    parts = []
+    for index, block in enumerate(blocks):
+        parts.append(block[4].rstrip('\n'))
+        parts.append(COLUMN_BREAK if index in breaks else '\n')
+    return ''.join(parts)
+
+
+def build_vocabulary(texts: Iterable[str]) -> Counter:
+    """Lowercase word counts across the document"""
+    vocabulary: Counter = Counter()
+    for text in texts:
+        vocabulary.update(word.lower() for word in WORD.findall(text))
+    return vocabulary
+
+
+def _known(word: str, vocabulary: Counter, dictionary: Set[str], occurrences_here: int) -> bool:
+    """A word is known if the dictionary has it or it appears elsewhere in the document"""
+    word = word.lower()
+    return word in dictionary or vocabulary[word] > occurrences_here
+
+
+def join_split_word(left: str, right: str, vocabulary: Counter,
+                    dictionary: Optional[Set[str]] = None) -> Optional[str]:
+    """
+    Decide how two fragments on either side of a break combine
+
+    Returns:
+        'join' to concatenate them, 'drop_left' when the right word repeats
+        the cut fragment in full, or None to leave them alone
+    """
+    dictionary = dictionary or set()
+    if not left.isalpha() or not right.isalpha() or not right[0].islower():
+        return None
+
+    if len(right) > len(left) and right.lower().startswith(left.lower()) and _known(right, vocabulary, dictionary, 1):
+        return 'drop_left'
+
+    joined_known = _known(left + right, vocabulary, dictionary, 0)
+    fragment_unknown = not _known(left, vocabulary, dictionary, 1) or not _known(right, vocabulary, dictionary, 1)
+    return 'join' if joined_known and fragment_unknown else None
+
+
+def _trailing_word(text: str) -> Tuple[str, str]:
+    """Split text into (everything before the last word, last word); word is '' if text ends otherwise"""
+    match = re.search(r'([A-Za-z]+)\s*$', text)
+    if not match:
+        return text, ''
+    return text[:match.start(1)], match.group(1)
+
+
+def _leading_word(text: str) -> Tuple[str, str]:
+    """Split text into (first word, everything after it)"""
+    match = re.match(r'\s*([A-Za-z]+)', text)
+    if not match:
+        return '', text
+    return match.group(1), text[match.end(1):]
+
+
+def _strip_page_markers(text: str, at_end: bool) -> Tuple[str, str]:
+    """Separate running page-number lines at the end (or start) of a page from the body"""
+    lines = text.split('\n')
+    markers: List[str] = []
+    if at_end:
+        while lines and (not lines[-1].strip() or PAGE_MARKER_LINE.match(lines[-1])):
+            markers.insert(0, lines.pop())
+        return '\n'.join(lines), '\n'.join(markers)
+    while lines and (not lines[0].strip() or PAGE_MARKER_LINE.match(lines[0])):
+        markers.append(lines.pop(0))
+    return '\n'.join(lines), '\n'.join(markers)
+
+
+def _join_at(before: str, after: str, vocabulary: Counter,
+             dictionary: Set[str]) -> Tuple[str, str, Optional[str]]:
+    """Apply join_split_word to the words around one break"""
+    head, left = _trailing_word(before)
+    right, tail = _leading_word(after)
+    if not left or not right:
+        return before, after, None
+
+    action = join_split_word(left, right, vocabulary, dictionary)
+    if action == 'join':
+        return head + left + right, tail, left + right
+    if action == 'drop_left':
+        return head, after.lstrip(), right
+    return before, after, None
+
+
+def reflow_split_words(page_texts: List[str],
+                       dictionary: Optional[Set[str]] = None) -> Tuple[List[str], List[Dict[str, Any]]]:
+    """
+    Join words split across column and page breaks
+
+    Args:
+        page_texts: Text per page, with COLUMN_BREAK markers between columns
+        dictionary: Known words (default: the system word list, if any)
+
+    Returns:
+        (page texts without markers, joins) - each join has page, boundary
+        ('column' or 'page'), and word
+    """
+    dictionary = load_dictionary() if dictionary is None else dictionary
+    vocabulary = build_vocabulary(text.replace(COLUMN_BREAK, '\n') for text in page_texts)
+    joins: List[Dict[str, Any]] = []
+
+    # Column breaks within each page
+    pages = []
+    for page_num, text in enumerate(page_texts, 1):
+        parts = text.split(COLUMN_BREAK)
+        merged = parts[0]
+        for part in parts[1:]:
+            merged, part, word = _join_at(merged, part, vocabulary, dictionary)
+            if word:
+                joins.append({'page': page_num, 'boundary': 'column', 'word': word})
+                merged += part
+            else:
+                merged += '\n' + part
+        pages.append(merged)
+
+    # Page breaks, looking past running page numbers
+    for index in range(len(pages) - 1):
+        body, end_markers = _strip_page_markers(pages[index], at_end=True)
+        next_body, start_markers = _strip_page_markers(pages[index + 1], at_end=False)
+        body, next_body, word = _join_at(body, next_body, vocabulary, dictionary)
+        if word:
+            joins.append({'page': index + 1, 'boundary': 'page', 'word': word})
+            pages[index] = body + ('\n' + end_markers if end_markers else '')
+            pages[index + 1] = (start_markers + '\n' if start_markers else '') + next_body.lstrip(' ')
+    return pages, joins
@@ -0,0 +1,245 @@
+"""
+Re-chunk an existing conversion with new settings
+
+Extracting a PDF is the slow part of a conversion; cutting sections into
+chunk_tokens chunks is fast. reprocess_chunks reads the section files a
+conversion already wrote (listed in its manifest.json) and regenerates
+only the chunks: the chunk files and chunk-manifest.json (and chunks.jsonl)
+are replaced, and manifest.json's chunking entry is updated. Nothing else
+in the output is touched.
+
+The new chunks are written to a staging folder first and moved into place
+only once all of them are written, so a failed or cancelled run leaves the
+previous chunks as they were. Like a conversion's, the staging folder is
+registered with TEMP_FILES and never listed as a resource.
+
+Chunks are cut from the section files as written, so they include the
+title and scope lines the conversion added, and heading paths start at
+each section (the files don't record section levels).
+"""
+import os
+import tempfile
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional
+
+try:
+    from ..utils.cancellation import check_cancelled
+    from ..utils.file_utils import FileUtils
+    from ..utils.frontmatter import split_front_matter
+    from ..utils.staging import STAGING_PREFIX
+    from ..utils.temp_files import TEMP_FILES
+    from ..utils.token_counter import TIKTOKEN_ENCODINGS, TOKENIZERS, TokenCounter
+    from .chunking_engine import ChunkingEngine, validate_chunk_budget, validate_chunk_layout
+    from .rag_export import CHUNKS_JSONL, chunk_records, write_chunks_jsonl
+except ImportError:
+    # Handle running as script vs package
+    import sys
+    sys.path.append(str(Path(__file__).parent.parent))
+    from utils.cancellation import check_cancelled
+    from utils.file_utils import FileUtils
+    from utils.frontmatter import split_front_matter
+    from utils.staging import STAGING_PREFIX
+    from utils.temp_files import TEMP_FILES
+    from utils.token_counter import TIKTOKEN_ENCODINGS, TOKENIZERS, TokenCounter
+    from processors.chunking_engine import ChunkingEngine, validate_chunk_budget, validate_chunk_layout
+    from processors.rag_export import CHUNKS_JSONL, chunk_records, write_chunks_jsonl
+
+MANIFEST_FILE = 'manifest.json'
+CHUNK_MANIFEST_FILE = 'chunk-manifest.json'
+
+
+def find_document_dir(output_dir: str, document: Optional[str] = None) -> Path:
+    """
+    The folder holding a conversion's manifest.json
+
+    output_dir is either that folder or the output_dir the conversion was
+    given, with one folder per document below it; document picks one when
+    there are several.
+
+    Raises:
+        FileNotFoundError: No conversion found, or document names none
+        ValueError: Several conversions and no document to choose
+    """
+    base = Path(output_dir)
+    if not base.is_dir():
+        raise FileNotFoundError(f"Output directory not found: {output_dir}")
+    if document:
+        if Path(document).name != document or document in ('.', '..'):
+            raise ValueError(f"document must be a folder name inside output_dir, got '{document}'")
+        if not (base / document / MANIFEST_FILE).is_file():
+            raise FileNotFoundError(f"No conversion of '{document}' in {output_dir} ({document}/{MANIFEST_FILE} is missing)")
+        return base / document
+    if (base / MANIFEST_FILE).is_file():
+        return base
+    found = sorted(manifest.parent for manifest in base.glob(f"*/{MANIFEST_FILE}"))
+    if not found:
+        raise FileNotFoundError(f"No {MANIFEST_FILE} in {output_dir} or its subfolders; "
+                                "point output_dir at a converted document's folder")
+    if len(found) > 1:
+        raise ValueError(f"{output_dir} holds {len(found)} conversions ({', '.join(path.name for path in found)}); "
+                         "pass document to pick one")
+    return found[0]
+
+
+def load_sections(document_dir: Path, manifest: Dict[str, Any]) -> List[Dict[str, Any]]:
+    """
+    Sections for chunking from the section files in manifest.json
+
+    Files of one section (a large section split into parts) are joined
+    back together.
+
+    Raises:
+        FileNotFoundError: A listed section file is missing
+        ValueError: No sections, or they weren't written as markdown
+    """
+    entries = manifest.get('sections') or []
+    if not entries:
+        raise ValueError(f"{document_dir / MANIFEST_FILE} lists no section files to chunk")
+    sections: Dict[Any, Dict[str, Any]] = {}
+    for index, entry in enumerate(entries, 1):
+        section_file = document_dir / entry['file']
+        if section_file.suffix != '.md':
+            raise ValueError(f"Section files must be markdown to re-chunk; {entry['file']} was written in another "
+                             "output_format. Convert again with chunk_tokens instead")
+        if not section_file.is_file():
+            raise FileNotFoundError(f"Section file listed in {MANIFEST_FILE} is missing: {section_file}")
+        fields, body = split_front_matter(section_file.read_text(encoding='utf-8'))
+        section_id = entry.get('section_id') or fields.get('section_id') or index
+        if section_id in sections:
+            sections[section_id]['content'] += '\n\n' + body.strip()
+            continue
+        sections[section_id] = {
+            'title': fields.get('title') or entry.get('title') or f"Section {index}",
+            'section_id': section_id,
+            'page_start': fields.get('page_start'),
+            'page_end': fields.get('page_end'),
+            'content': body.strip()
+        }
+    return list(sections.values())
+
+
+def listed_chunk_files(chunked_dir: Path) -> List[Path]:
+    """chunks.jsonl, the chunk files a chunk-manifest.json lists, and the manifest, where they exist"""
+    chunk_manifest = chunked_dir / CHUNK_MANIFEST_FILE
+    paths = [chunked_dir / CHUNKS_JSONL]
+    if chunk_manifest.is_file():
+        paths += [chunked_dir / chunk['file'] for chunk in FileUtils.read_json(chunk_manifest).get('chunks', [])]
+        paths.append(chunk_manifest)
+    return [path for path in paths if path.is_file()]
+
+
+def remove_chunks(chunked_dir: Path, paths: Optional[List[Path]] = None, keep: Iterable[Path] = ()) -> int:
+    """
+    Delete chunk files (default: listed_chunk_files) other than those in keep;
+    returns files removed
+
+    Folders the chunk layout created (by_section, by_size) are removed once empty.
+    """
+    if paths is None:
+        paths = listed_chunk_files(chunked_dir)
+    keep = set(keep)
+    removed = 0
+    for path in paths:
+        if path not in keep and path.is_file():
+            path.unlink()
+            removed += 1
+    for folder in sorted({path.parent for path in paths if path.parent != chunked_dir}, reverse=True):
+        if folder.is_dir() and not any(folder.iterdir()):
+            folder.rmdir()
+    return removed
+
+
+def install_staged_chunks(staging_dir: Path, chunked_dir: Path) -> List[Path]:
+    """
+    Move every file under staging_dir to the same place under chunked_dir,
+    chunk-manifest.json last; returns the installed paths
+    """
+    staged = sorted((path for path in staging_dir.rglob('*') if path.is_file()),
+                    key=lambda path: path.name == CHUNK_MANIFEST_FILE)
+    installed = []
+    for path in staged:
+        target = chunked_dir / path.relative_to(staging_dir)
+        FileUtils.ensure_directory(target.parent)
+        os.replace(path, target)
+        installed.append(target)
+    return installed
+
+
+def reprocess_chunks(output_dir: str, chunk_tokens: Any, chunk_overlap: Any = 0, tokenizer: Optional[str] = None,
+                     chunks_jsonl: Optional[bool] = None, document: Optional[str] = None,
+                     chunk_layout: Optional[str] = None, cancel_event=None) -> Dict[str, Any]:
+    """
+    Regenerate a conversion's chunks with a new budget, overlap, or tokenizer
+
+    Args:
+        output_dir: Converted document folder, or the output_dir it was converted to
+        chunk_tokens: New token budget per chunk
+        chunk_overlap: Tokens repeated from the end of the previous chunk
+        tokenizer: Tokenizer for the budget (default: the one the conversion used)
+        chunks_jsonl: Also write chunks.jsonl (default: if the conversion did)
+        document: Document folder name when output_dir holds several
+        chunk_layout: Layout of the chunk files (default: the conversion's, else 'flat')
+        cancel_event: threading.Event; once set, stops before the next section
+            and leaves the previous chunks in place
+
+    Returns:
+        The new manifest.json chunking entry plus document_dir, sections,
+        removed (old chunk files replaced or deleted), and warnings
+    """
+    chunk_tokens, chunk_overlap = validate_chunk_budget(chunk_tokens, chunk_overlap)
+    if tokenizer and tokenizer not in TOKENIZERS:
+        raise ValueError(f"Unknown tokenizer '{tokenizer}' (expected one of: {', '.join(TOKENIZERS)})")
+    document_dir = find_document_dir(output_dir, document)
+    manifest_file = document_dir / MANIFEST_FILE
+    manifest = FileUtils.read_json(manifest_file)
+    sections = load_sections(document_dir, manifest)
+
+    previous = manifest.get('chunking') or {}
+    # Chunks go where the conversion put them (its output_layout), else chunked/
+    chunked_dir = (document_dir / previous['manifest']).parent if previous.get('manifest') else document_dir / 'chunked'
+    if chunks_jsonl is None:
+        chunks_jsonl = bool(previous.get('jsonl'))
+    # A conversion that recorded no layout predates chunk_layout, when budget chunks were flat
+    chunk_layout = validate_chunk_layout(chunk_layout or previous.get('layout') or 'flat')
+    token_counter = TokenCounter(tokenizer=tokenizer or manifest.get('tokenizer'))
+    warnings = []
+    if token_counter.approximate and token_counter.name in TIKTOKEN_ENCODINGS:
+        warnings.append(f"{token_counter.name} could not be loaded (is tiktoken installed?); "
+                        f"token counts use {token_counter.tokenizer_name()}")
+
+    old_files = listed_chunk_files(chunked_dir)
+    # A conversion's kind of staging folder: hidden from resources, removed if the server stops mid-run
+    staging_dir = TEMP_FILES.register(tempfile.mkdtemp(prefix=STAGING_PREFIX, dir=document_dir))
+    try:
+        engine = ChunkingEngine(str(document_dir), token_counter, chunked_dir=str(staging_dir))
+        # Links in the section files are relative to their folder; chunks rebase them from there
+        sections_dir = (document_dir / manifest['sections'][0]['file']).parent
+        links_dir = Path(os.path.relpath(sections_dir, chunked_dir)).as_posix()
+        result = engine.create_budget_chunks(sections, chunk_tokens, chunk_overlap, chunk_layout, cancel_event,
+                                             links_dir)
+
+        chunking = {key: value for key, value in result.items()
+                    if key not in ('chunks', 'chunk_files', 'manifest_file', 'records')}
+        chunking['manifest'] = Path(os.path.relpath(chunked_dir / CHUNK_MANIFEST_FILE, document_dir)).as_posix()
+        if chunks_jsonl:
+            # The record ids the conversion used (taken from the PDF's bytes), so re-chunking keeps them
+            document_id = previous.get('jsonl_document_id') or manifest.get('document_id', document_dir.name)
+            records = chunk_records(result['records'], manifest.get('source_file', document_dir.name), document_id)
+            chunking['jsonl'] = Path(os.path.relpath(chunked_dir / CHUNKS_JSONL, document_dir)).as_posix()
+            chunking['jsonl_chunks'] = write_chunks_jsonl(records, staging_dir / CHUNKS_JSONL)
+            chunking['jsonl_document_id'] = document_id
+        check_cancelled(cancel_event)
+
+        # Everything is written: swap the new chunks in, then drop old files they didn't replace
+        installed = install_staged_chunks(staging_dir, chunked_dir)
+    finally:
+        TEMP_FILES.release(staging_dir)
+    remove_chunks(chunked_dir, old_files, keep=installed)
+    removed = len(old_files)
+    manifest['chunking'] = chunking
+    FileUtils.write_json(manifest, manifest_file)
+    if result['over_budget']:
+        warnings.append(f"{result['over_budget']} chunks exceed chunk_tokens: tables and code blocks are never split")
+
+    return {**chunking, 'document_dir': str(document_dir), 'sections': len(sections), 'removed': removed,
+            'warnings': warnings}
@@ -0,0 +1,126 @@
+"""
+Digital signature metadata extraction
+
+Reports which signature fields a PDF carries and what their signature
+dictionaries claim (signer, time, reason). Nothing is verified
+cryptographically - the flags only say whether the pieces are present and
+whether the signed byte range still covers the whole file.
+
+Signed PDFs are usually saved with incremental updates: each signature
+appends a new revision after the previous %%EOF. pypdf follows the trailer
+/Prev chain from the last revision, so fields reflect the latest state.
+"""
+import re
+from datetime import datetime, timedelta, timezone
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+
+def parse_pdf_date(value: Any) -> Optional[str]:
+    """Convert a PDF date string (D:YYYYMMDDHHmmSS+HH'mm') to ISO 8601; None if unparseable"""
+    if not value:
+        return None
+    match = re.match(
+        r"^D?:?(\d{4})(\d{2})?(\d{2})?(\d{2})?(\d{2})?(\d{2})?([Zz+\-])?(\d{2})?'?(\d{2})?'?",
+        str(value).strip()
+    )
+    if not match:
+        return None
+
+    year, month, day, hour, minute, second, sign, tz_hour, tz_minute = match.groups()
+    try:
+        tz = None
+        if sign in ('Z', 'z'):
+            tz = timezone.utc
+        elif sign in ('+', '-'):
+            offset = timedelta(hours=int(tz_hour or 0), minutes=int(tz_minute or 0))
+            tz = timezone(offset if sign == '+' else -offset)
+        moment = datetime(int(year), int(month or 1), int(day or 1),
+                          int(hour or 0), int(minute or 0), int(second or 0), tzinfo=tz)
+    except ValueError:
+        return None
+    return moment.isoformat()
+
+
+def count_revisions(pdf_path: str) -> int:
+    """Number of saved revisions (each incremental update ends with its own %%EOF)"""
+    data = Path(pdf_path).read_bytes()
+    return max(1, len(re.findall(rb'%%EOF', data)))
+
+
+def _text(value: Any) -> Optional[str]:
+    return str(value) if value is not None else None
+
+
+def _iter_signature_fields(fields: List[Any], inherited_type: Optional[str] = None):
+    """Yield signature field dictionaries, walking /Kids and inheriting /FT"""
+    for field_ref in fields or []:
+        field = field_ref.get_object()
+        field_type = field.get('/FT', inherited_type)
+        kids = field.get('/Kids')
+        if kids:
+            yield from _iter_signature_fields(kids, field_type)
+        if field_type == '/Sig' and '/T' in field:
+            yield field
+
+
+def extract_signatures(pdf_path: str) -> Dict[str, Any]:
+    """
+    Extract signature metadata from a PDF
+
+    Returns:
+        Dictionary with signed, signature_count, revision_count, and
+        signatures (one entry per signature field)
+    """
+    import pypdf
+
+    file_size = Path(pdf_path).stat().st_size
+    reader = pypdf.PdfReader(pdf_path, strict=False)
+
+    root = reader.trailer['/Root'].get_object()
+    acro_form = root.get('/AcroForm')
+    fields = acro_form.get_object().get('/Fields', []) if acro_form else []
+
+    signatures = []
+    for field in _iter_signature_fields(fields):
+        value = field.get('/V')
+        value = value.get_object() if value is not None else None
+
+        entry = {
+            'field_name': _text(field.get('/T')),
+            'signed': bool(value and '/Contents' in value),
+            'signer': None,
+            'signed_at': None,
+            'reason': None,
+            'location': None,
+            'contact_info': None,
+            'sub_filter': None,
+            'has_byte_range': False,
+            'covers_whole_file': None,
+            'verified': False
+        }
+
+        if value:
+            entry['signer'] = _text(value.get('/Name'))
+            entry['signed_at'] = parse_pdf_date(value.get('/M'))
+            entry['reason'] = _text(value.get('/Reason'))
+            entry['location'] = _text(value.get('/Location'))
+            entry['contact_info'] = _text(value.get('/ContactInfo'))
+            sub_filter = value.get('/SubFilter')
+            entry['sub_filter'] = str(sub_filter).lstrip('/') if sub_filter else None
+
+            byte_range = value.get('/ByteRange')
+            if byte_range and len(byte_range) == 4:
+                offsets = [int(n) for n in byte_range]
+                entry['has_byte_range'] = True
+                # Content appended after signing (a later revision) falls outside the range
+                entry['covers_whole_file'] = offsets[2] + offsets[3] == file_size
+
+        signatures.append(entry)
+
+    return {
+        'signed': any(s['signed'] for s in signatures),
+        'signature_count': sum(1 for s in signatures if s['signed']),
+        'revision_count': count_revisions(pdf_path),
+        'signatures': signatures
+    }
@@ -0,0 +1,207 @@
+"""
+Streaming conversion: section files written while pages are extracted
+
+A normal conversion holds every page's text, the structured sections, and
+their rendered markdown in memory at once, which for a 2,000-page PDF can
+run a constrained server out of memory. With streaming, sections are
+fixed before any text is read - the PDF's top-level bookmarks, or runs
+of STREAM_PAGES_PER_SECTION pages without them - and each page's text is
+added to the open section as it is extracted. When the next section
+starts, the open one is written to sections/ and appended to
+sections.jsonl, then dropped, so memory holds one section at a time.
+
+Only section text is written: features that need the whole document
+(images, tables, chunks, cross-section links, API endpoint files) are
+skipped. Chunks can be added afterwards with the reprocess tool.
+"""
+import json
+from pathlib import Path
+from typing import Any, Callable, Dict, Iterable, List, Optional, Sequence
+
+try:
+    from ..utils.file_utils import FileUtils
+    from ..utils.frontmatter import SOURCE_FIELDS, render_front_matter
+    from ..utils.section_naming import section_filename
+    from ..utils.section_stats import reading_minutes, word_count
+except ImportError:
+    # Handle running as script vs package
+    import sys
+    sys.path.append(str(Path(__file__).parent.parent))
+    from utils.file_utils import FileUtils
+    from utils.frontmatter import SOURCE_FIELDS, render_front_matter
+    from utils.section_naming import section_filename
+    from utils.section_stats import reading_minutes, word_count
+
+# Pages per section when the PDF has no bookmarks
+STREAM_PAGES_PER_SECTION = 25
+
+# One JSON record per section file, appended as each file is written
+SECTIONS_JSONL = "sections.jsonl"
+
+# Noted in the result of every streaming conversion
+STREAMING_NOTE = ("streaming writes section text only: images, tables, chunks, section links, and API endpoint "
+                  "files are skipped (add chunks afterwards with reprocess)")
+
+
+def validate_streaming_options(options: Dict[str, Any]) -> None:
+    """Reject options streaming can't honour (raises ValueError)"""
+    if options.get('chunk_tokens'):
+        raise ValueError("streaming can't chunk while it writes; convert with streaming, then run reprocess "
+                         "with chunk_tokens")
+    if options.get('output_format', 'markdown') != 'markdown':
+        raise ValueError("streaming writes markdown only; leave output_format unset")
+    if options.get('output_mode', 'standard') != 'standard':
+        raise ValueError("streaming does not support output_mode 'canonical'")
+    if options.get('extract_form_fields'):
+        raise ValueError("streaming writes page text only; convert without streaming to extract_form_fields")
+    if options.get('math_mode', 'off') not in (None, 'off'):
+        raise ValueError("streaming writes page text only; convert without streaming to use math_mode")
+
+
+def stream_sections(outline: Sequence[Dict[str, Any]], pages: Sequence[int]) -> List[Dict[str, Any]]:
+    """
+    Sections to stream into, known before any text is extracted
+
+    Args:
+        outline: Bookmarks as returned by extract_outline
+        pages: 1-based pages being converted, ascending
+
+    Returns:
+        List of sections with title and page_start: one per top-level
+        bookmark starting within pages (plus "Front Matter" for pages
+        before the first), else one per STREAM_PAGES_PER_SECTION pages
+    """
+    if not pages:
+        return []
+    first, last = pages[0], pages[-1]
+    sections: List[Dict[str, Any]] = []
+    for entry in outline:
+        if entry.get('level') != 1 or not entry.get('page') or not first <= entry['page'] <= last:
+            continue
+        if sections and sections[-1]['page_start'] >= entry['page']:
+            # Several bookmarks on one page (or out of order): the first one names the section
+            continue
+        sections.append({'title': entry['title'].strip() or f"Page {entry['page']}", 'page_start': entry['page']})
+    if sections:
+        if sections[0]['page_start'] > first:
+            sections.insert(0, {'title': "Front Matter", 'page_start': first})
+        return sections
+    groups = [pages[index:index + STREAM_PAGES_PER_SECTION] for index in range(0, len(pages), STREAM_PAGES_PER_SECTION)]
+    return [{'title': f"Pages {group[0]}-{group[-1]}" if len(group) > 1 else f"Page {group[0]}",
+             'page_start': group[0]} for group in groups]
+
+
+class SectionStreamWriter:
+    """
+    Writes each section file as soon as its last page has been added
+
+    Pages go in with add_page() in ascending order; close() writes the
+    last section. Only the open section's text is held.
+    """
+
+    def __init__(self, sections_dir: Path, jsonl_path: Path, sections: Sequence[Dict[str, Any]],
+                 naming: str, source_pdf: str, relative_path: Callable[[Path], str],
+                 token_counter=None, front_matter: bool = True,
+                 on_file: Optional[Callable[[Dict[str, Any]], None]] = None):
+        """
+        Args:
+            sections_dir: Where section files go
+            jsonl_path: sections.jsonl, started empty
+            sections: From stream_sections
+            naming: One of utils.section_naming.SECTION_NAMINGS
+            source_pdf: Name written into front-matter and records
+            relative_path: Path of a written file as listed in manifest.json
+            token_counter: Counts each file's tokens (None: 0)
+            front_matter: Include source_pdf and the page span in each file's front-matter
+            on_file: Called with each file's manifest entry once it is written
+        """
+        self.sections_dir = Path(sections_dir)
+        self.jsonl_path = Path(jsonl_path)
+        self.sections = list(sections)
+        self.naming = naming
+        self.source_pdf = source_pdf
+        self.relative_path = relative_path
+        self.token_counter = token_counter
+        self.front_matter = front_matter
+        self.on_file = on_file
+        # Manifest entries of the files written so far
+        self.entries: List[Dict[str, Any]] = []
+        self.pages_added = 0
+        self._taken: set = set()
+        self._index = -1
+        self._texts: List[str] = []
+        self._pages: List[int] = []
+        self.sections_dir.mkdir(parents=True, exist_ok=True)
+        self.jsonl_path.parent.mkdir(parents=True, exist_ok=True)
+        self.jsonl_path.write_text('', encoding='utf-8')
+
+    def add_page(self, page_num: int, text: str) -> None:
+        """Add one page's text, writing the open section first when this page starts the next"""
+        while self._index + 1 < len(self.sections) and page_num >= self.sections[self._index + 1]['page_start']:
+            self._write_open_section()
+            self._index += 1
+        self._index = max(self._index, 0)
+        self._texts.append(text)
+        self._pages.append(page_num)
+        self.pages_added += 1
+
+    def close(self) -> List[Dict[str, Any]]:
+        """Write the last section; returns the manifest entries of every file written"""
+        self._write_open_section()
+        return self.entries
+
+    def _write_open_section(self) -> None:
+        if self._index < 0 or not self._pages:
+            # No section open yet, or none of its pages were converted
+            self._texts, self._pages = [], []
+            return
+        section = self.sections[self._index]
+        section_id = self._index + 1
+        title = section['title']
+        body = "\n\n".join(text.strip() for text in self._texts if text.strip())
+        page_start, page_end = self._pages[0], self._pages[-1]
+        self._texts, self._pages = [], []
+
+        filename = FileUtils.unique_filename(
+            section_filename(self.naming, section_id, len(self.sections),
+                             FileUtils.safe_filename(title), title, body), self._taken)
+        fields = {'title': title, 'source_pdf': self.source_pdf, 'page_start': page_start, 'page_end': page_end,
+                  'section_id': section_id}
+        if not self.front_matter:
+            fields = {key: value for key, value in fields.items() if key not in SOURCE_FIELDS}
+        content = render_front_matter(fields) + f"# {title}\n\n{body}\n"
+        section_file = self.sections_dir / filename
+        section_file.write_text(content, encoding='utf-8')
+
+        words = word_count(body)
+        entry = {
+            'file': self.relative_path(section_file),
+            'section_id': section_id,
+            'title': title,
+            'section_type': 'content',
+            'content_type': 'mixed',
+            'page_start': page_start,
+            'page_end': page_end,
+            'bytes': len(content.encode('utf-8')),
+            'token_count': self.token_counter.count_tokens(content) if self.token_counter else 0,
+            'word_count': words,
+            'reading_minutes': reading_minutes(words)
+        }
+        record = {'section_id': section_id, 'title': title, 'file': entry['file'], 'source_pdf': self.source_pdf,
+                  'page_start': page_start, 'page_end': page_end, 'text': body}
+        with open(self.jsonl_path, 'a', encoding='utf-8') as jsonl:
+            jsonl.write(json.dumps(record, ensure_ascii=False) + "\n")
+        self.entries.append(entry)
+        if self.on_file:
+            self.on_file(entry)
+
+
+def stream_index(title: str, entries: Iterable[Dict[str, Any]]) -> str:
+    """README.md for a streaming conversion: the section files (paths as in manifest.json) with their pages"""
+    lines = [f"# {title}", "", "Converted in streaming mode: one file per section, written as pages were extracted.",
+             "", "## Sections", ""]
+    for entry in entries:
+        pages = (f"page {entry['page_start']}" if entry['page_start'] == entry['page_end']
+                 else f"pages {entry['page_start']}-{entry['page_end']}")
+        lines.append(f"- [{entry['title']}]({entry['file']}) - {pages}, {entry['token_count']:,} tokens")
+    return "\n".join(lines) + "\n"
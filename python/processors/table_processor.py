@@ -11,10 +11,11 @@ except ImportError:
 """
 Table processing and structured format conversion
 """
+import csv
 import json
 import pandas as pd
 from pathlib import Path
-from typing import Dict, List, Any, Optional, Union
+from typing import Dict, List, Any, Optional, Tuple, Union
 from datetime import datetime
 import re
 
@@ -92,8 +93,10 @@ class TableProcessor:
             return None
         
         try:
-            # Create DataFrame
-            df = pd.DataFrame(table_data[1:], columns=table_data[0])
+            # Create DataFrame (rows padded so a merged header cell does not throw,
+            # header labels unique so no column is merged away)
+            table_data = self.normalize_table_rows(table_data)
+            df = pd.DataFrame(table_data[1:], columns=self.header_labels(table_data[0]))
             
             # Clean up the data
             df = df.fillna('')
@@ -115,7 +118,8 @@ class TableProcessor:
                     'rows': len(processed_df),
                     'columns': len(processed_df.columns),
                     'generated_at': datetime.now().isoformat(),
-                    'processing_notes': self.get_table_processing_notes(processed_df, stats)
+                    'processing_notes': self.get_table_processing_notes(processed_df, stats),
+                    'column_alignments': self.dataframe_alignments(processed_df)
                 },
                 'schema': self.generate_table_schema(processed_df, stats),
                 'data': {
@@ -391,7 +395,7 @@ class TableProcessor:
 
 ## Table Data
 
-{table_info.get('markdown', '')}
+{table_info.get('markdown') or self.table_to_markdown(table_info.get('data', []), metadata.get('column_alignments'))}
 
 ## Data Schema
 
@@ -465,4 +469,436 @@ class TableProcessor:
         
         index_file = self.tables_dir / "README.md"
         FileUtils.write_markdown(index_content, index_file)
-        return index_file
\ No newline at end of file
+        return index_file
+    
+    # Relational column types and their JSON Schema equivalents
+    JSON_SCHEMA_TYPES = {
+        'int': {'type': 'integer'},
+        'float': {'type': 'number'},
+        'date': {'type': 'string', 'format': 'date'},
+        'string': {'type': 'string'},
+    }
+    
+    DATE_FORMATS = (
+        '%Y-%m-%d', '%Y/%m/%d', '%m/%d/%Y', '%d-%m-%Y', '%m/%d/%y',
+        '%d %b %Y', '%d %B %Y', '%b %d, %Y', '%B %d, %Y'
+    )
+    
+    @classmethod
+    def coerce_typed_value(cls, value: str, column_type: str) -> Any:
+        """
+        Convert a cell string to a column type
+        
+        Returns:
+            Typed value (dates as ISO strings), or None for empty cells
+            
+        Raises:
+            ValueError: If the value cannot be represented as column_type,
+                including numbers with leading zeros (IDs, ZIP codes), which
+                only a string keeps intact
+        """
+        value_str = str(value).strip() if value is not None else ''
+        if not value_str:
+            return None
+        
+        if column_type == 'string':
+            return value_str
+        
+        if column_type in ('int', 'float'):
+            negative = value_str.startswith('(') and value_str.endswith(')')
+            cleaned = re.sub(r'[\s,$€£]', '', value_str.strip('()'))
+            if re.match(r'^[-+]?0\d', cleaned):
+                raise ValueError(f"Leading zero: {value_str}")
+            if column_type == 'int':
+                if not re.match(r'^[-+]?\d+$', cleaned):
+                    raise ValueError(f"Not an integer: {value_str}")
+                number = int(cleaned)
+            else:
+                if not re.match(r'^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$', cleaned):
+                    raise ValueError(f"Not a number: {value_str}")
+                number = float(cleaned)
+            return -number if negative else number
+        
+        if column_type == 'date':
+            for date_format in cls.DATE_FORMATS:
+                try:
+                    return datetime.strptime(value_str, date_format).date().isoformat()
+                except ValueError:
+                    continue
+            raise ValueError(f"Not a date: {value_str}")
+        
+        raise ValueError(f"Unknown column type: {column_type}")
+    
+    @classmethod
+    def infer_column_type(cls, values: List[str]) -> str:
+        """Infer the narrowest type (int, float, date, string) that fits every non-empty value"""
+        present = [v for v in values if v is not None and str(v).strip()]
+        if not present:
+            return 'string'
+        
+        for column_type in ('int', 'float', 'date'):
+            try:
+                for value in present:
+                    cls.coerce_typed_value(value, column_type)
+                return column_type
+            except ValueError:
+                continue
+        return 'string'
+    
+    @classmethod
+    def detect_header_row(cls, rows: List[List[str]]) -> bool:
+        """
+        Decide whether the first row of a table is a header
+        
+        A header has no numeric or date cells, and either sits above at least
+        one typed column or consists of distinct labels that do not reappear
+        in the body.
+        """
+        if len(rows) < 2:
+            return False
+        
+        first, body = rows[0], rows[1:]
+        if any(cls.infer_column_type([cell]) != 'string' for cell in first if cell):
+            return False
+        
+        for col in range(len(first)):
+            column = [row[col] for row in body if col < len(row)]
+            if cls.infer_column_type(column) != 'string':
+                return True
+        
+        labels = [cell for cell in first if cell]
+        if len(labels) != len(first) or len(set(labels)) != len(labels):
+            return False
+        return not any(first[col] in [row[col] for row in body if col < len(row)]
+                       for col in range(len(first)))
+    
+    @staticmethod
+    def relational_column_name(label: str, position: int, used: set) -> str:
+        """Turn a header label into a unique snake_case column name"""
+        name = re.sub(r'[^0-9a-zA-Z]+', '_', label or '').strip('_').lower()
+        if not name:
+            name = f"column_{position}"
+        elif name[0].isdigit():
+            name = f"col_{name}"
+        
+        unique, suffix = name, 2
+        while unique in used:
+            unique = f"{name}_{suffix}"
+            suffix += 1
+        used.add(unique)
+        return unique
+    
+    # Table strictness: pdfplumber also reports aligned body text as tables
+    DEFAULT_MIN_TABLE_ROWS = 2
+    DEFAULT_MIN_TABLE_COLS = 2
+    DEFAULT_TABLE_MIN_CONFIDENCE = 0.5
+    
+    @classmethod
+    def table_thresholds(cls, options: Dict[str, Any]) -> Tuple[int, int, float]:
+        """
+        (min_rows, min_cols, min_confidence) from min_table_rows, min_table_cols,
+        and table_min_confidence options, defaults for those unset (raises ValueError)
+        """
+        min_rows = options.get('min_table_rows')
+        min_cols = options.get('min_table_cols')
+        min_confidence = options.get('table_min_confidence')
+        min_rows = cls.DEFAULT_MIN_TABLE_ROWS if min_rows is None else min_rows
+        min_cols = cls.DEFAULT_MIN_TABLE_COLS if min_cols is None else min_cols
+        min_confidence = cls.DEFAULT_TABLE_MIN_CONFIDENCE if min_confidence is None else min_confidence
+        for name, value in (('min_table_rows', min_rows), ('min_table_cols', min_cols)):
+            if isinstance(value, bool) or not isinstance(value, int) or value < 1:
+                raise ValueError(f"{name} must be a positive integer")
+        if isinstance(min_confidence, bool) or not isinstance(min_confidence, (int, float)) \
+                or not 0 <= min_confidence <= 1:
+            raise ValueError("table_min_confidence must be between 0 and 1")
+        return min_rows, min_cols, min_confidence
+    
+    @staticmethod
+    def score_table_confidence(rows: List[List[str]]) -> Dict[str, float]:
+        """
+        Score how likely a detected table is a real table
+        
+        fill_ratio is the share of non-empty cells. alignment is the share of
+        rows with the most common cell count times the share of columns
+        filled in at least half of the rows - aligned prose tends to leave
+        ragged rows and mostly empty columns. confidence is their mean.
+        
+        Returns:
+            Dictionary with fill_ratio, alignment, and confidence (0.0 - 1.0)
+        """
+        rows = [row for row in rows if row]
+        if not rows:
+            return {'fill_ratio': 0.0, 'alignment': 0.0, 'confidence': 0.0}
+        
+        width = max(len(row) for row in rows)
+        filled = [[bool(str(cell).strip()) if cell is not None else False for cell in row] for row in rows]
+        fill_ratio = sum(sum(row) for row in filled) / (len(rows) * width)
+        
+        counts = [sum(row) for row in filled]
+        modal_count = max(set(counts), key=counts.count)
+        row_consistency = counts.count(modal_count) / len(rows)
+        
+        column_fill = [sum(1 for row in filled if col < len(row) and row[col]) / len(rows) for col in range(width)]
+        column_consistency = sum(1 for share in column_fill if share >= 0.5) / width
+        
+        alignment = row_consistency * column_consistency
+        return {
+            'fill_ratio': round(fill_ratio, 2),
+            'alignment': round(alignment, 2),
+            'confidence': round((fill_ratio + alignment) / 2, 2)
+        }
+    
+    @classmethod
+    def filter_table_candidates(cls, tables: List[Dict[str, Any]],
+                                min_rows: int = DEFAULT_MIN_TABLE_ROWS,
+                                min_cols: int = DEFAULT_MIN_TABLE_COLS,
+                                min_confidence: float = DEFAULT_TABLE_MIN_CONFIDENCE) -> Tuple[List[Dict[str, Any]], List[Dict[str, Any]]]:
+        """
+        Discard detected tables that are too small or low confidence
+        
+        Args:
+            tables: Table dictionaries with page, index, and data
+            min_rows: Minimum number of rows (header included)
+            min_cols: Minimum number of columns
+            min_confidence: Minimum score_table_confidence() confidence
+            
+        Returns:
+            (kept, discarded) - kept tables gain a 'confidence' entry;
+            discarded entries have page, index, rows, cols, confidence, and reason
+        """
+        kept, discarded = [], []
+        for table_info in tables:
+            rows = [row for row in table_info.get('data', []) if row]
+            row_count = len(rows)
+            col_count = max((len(row) for row in rows), default=0)
+            score = cls.score_table_confidence(rows)
+            
+            reason = None
+            if row_count < min_rows:
+                reason = f"{row_count} row(s), minimum is {min_rows}"
+            elif col_count < min_cols:
+                reason = f"{col_count} column(s), minimum is {min_cols}"
+            elif score['confidence'] < min_confidence:
+                reason = (f"confidence {score['confidence']:.2f} below {min_confidence:.2f} "
+                          f"(fill {score['fill_ratio']:.2f}, alignment {score['alignment']:.2f})")
+            
+            if reason:
+                discarded.append({
+                    'page': table_info.get('page'),
+                    'index': table_info.get('index'),
+                    'rows': row_count,
+                    'cols': col_count,
+                    'confidence': score['confidence'],
+                    'reason': reason
+                })
+            else:
+                kept.append({**table_info, 'confidence': score})
+        return kept, discarded
+    
+    @staticmethod
+    def normalize_table_rows(rows: List[List[Any]]) -> List[List[str]]:
+        """
+        Cells as stripped strings (None as ''), every row padded to the widest
+        
+        pdfplumber returns None for cells covered by a merged cell, and a merged
+        header leaves the first row shorter than the rows below it.
+        """
+        rows = [[str(cell).strip() if cell is not None else '' for cell in row] for row in rows if row]
+        width = max((len(row) for row in rows), default=0)
+        return [row + [''] * (width - len(row)) for row in rows]
+    
+    @staticmethod
+    def header_labels(header: List[str]) -> List[str]:
+        """
+        Unique column labels for a header row
+        
+        Empty cells (padding after a merged header, merged-away cells) become
+        column_N by 1-based position, and a repeated label gets a _2, _3, ...
+        suffix, so DataFrame columns never collide.
+        """
+        labels: List[str] = []
+        for position, cell in enumerate(header, 1):
+            label = cell or f"column_{position}"
+            unique, suffix = label, 2
+            while unique in labels:
+                unique = f"{label}_{suffix}"
+                suffix += 1
+            labels.append(unique)
+        return labels
+    
+    @staticmethod
+    def markdown_table_cell(value: Any) -> str:
+        """One cell's text for a pipe table: line breaks as <br>, stray pipes escaped"""
+        text = str(value).strip() if value is not None else ''
+        text = re.sub(r'\s*(?:\r\n|\r|\n)\s*', '<br>', text)
+        return re.sub(r'(?<!\\)\|', r'\\|', text)
+    
+    @classmethod
+    def column_alignments(cls, rows: List[List[Any]]) -> List[Optional[str]]:
+        """
+        Alignment per column from the cells below the header row
+        
+        Returns:
+            'right' for numeric columns, 'left' for text, None for empty columns
+        """
+        rows = cls.normalize_table_rows(rows)
+        if not rows:
+            return []
+        
+        alignments = []
+        for col in range(len(rows[0])):
+            values = [row[col] for row in rows[1:] if row[col]]
+            if not values:
+                alignments.append(None)
+            else:
+                alignments.append('right' if cls.infer_column_type(values) in ('int', 'float') else 'left')
+        return alignments
+    
+    @staticmethod
+    def dataframe_alignments(df: pd.DataFrame) -> List[Optional[str]]:
+        """
+        Alignment per column from converted DataFrame values
+        
+        Numbers align right and text left, as pandas' to_markdown does; a
+        column of only blanks gets None. detect_and_convert_cell_value reads
+        0 and 1 as booleans, so they count as numbers next to other numbers.
+        """
+        alignments = []
+        for col in range(len(df.columns)):
+            values = [value for value in df.iloc[:, col] if value is not None and not pd.isna(value) and value != '']
+            if not values:
+                alignments.append(None)
+            elif (all(pd.api.types.is_number(value) for value in values)
+                  and not all(pd.api.types.is_bool(value) for value in values)):
+                alignments.append('right')
+            else:
+                alignments.append('left')
+        return alignments
+    
+    @classmethod
+    def table_to_markdown(cls, rows: List[List[Any]], alignments: Optional[List[Optional[str]]] = None) -> str:
+        """
+        Render table rows as a markdown pipe table, the first row as header
+        
+        Multi-line cells keep their breaks as <br> and pipes inside cells are
+        escaped, so every row has the same number of columns. The separator
+        row aligns numeric columns right (---:) and text left (:---).
+        
+        Args:
+            rows: Table rows, the first one the header
+            alignments: Per column 'right', 'left', or None (default: column_alignments)
+        """
+        rows = [[cls.markdown_table_cell(cell) for cell in row] for row in cls.normalize_table_rows(rows)]
+        if not rows:
+            return ''
+        
+        if alignments is None or len(alignments) != len(rows[0]):
+            alignments = cls.column_alignments(rows)
+        separators = {'right': '---:', 'left': ':---'}
+        lines = ['| ' + ' | '.join(rows[0]) + ' |',
+                 '|' + '|'.join(separators.get(alignment, '---') for alignment in alignments) + '|']
+        lines.extend('| ' + ' | '.join(row) + ' |' for row in rows[1:])
+        return '\n'.join(lines)
+    
+    @staticmethod
+    def csv_filename(table_info: Dict[str, Any]) -> str:
+        """table_p<page>_<n>.csv, n counting from 1 within the page"""
+        return f"table_p{table_info.get('page')}_{table_info.get('index', 0) + 1}.csv"
+    
+    @staticmethod
+    def write_table_csv(table_info: Dict[str, Any], csv_path: Path) -> Path:
+        """
+        Write a table's cells as CSV, one line per row
+        
+        Rows are padded to the widest row so spreadsheets keep columns aligned;
+        the header (when there is one) stays the first line, with empty and
+        repeated labels made unique (see header_labels).
+        """
+        rows = TableProcessor.normalize_table_rows(table_info.get('data', []))
+        if TableProcessor.detect_header_row(rows):
+            rows[0] = TableProcessor.header_labels(rows[0])
+        with open(csv_path, 'w', encoding='utf-8', newline='') as f:
+            csv.writer(f).writerows(rows)
+        return csv_path
+    
+    @classmethod
+    def build_relational_schema(cls, table_info: Dict[str, Any]) -> Optional[Dict[str, Any]]:
+        """
+        Structure a table for database ingestion
+        
+        Detects the header, infers a type per column, and emits a JSON Schema
+        describing one row plus the data as typed JSON rows.
+        
+        Args:
+            table_info: Table dictionary with page, index, and data (rows of cells)
+            
+        Returns:
+            Relational table description, or None if the table has no data rows
+        """
+        rows = cls.normalize_table_rows(table_info.get('data', []))
+        if not rows:
+            return None
+        
+        width = len(rows[0])
+        
+        has_header = cls.detect_header_row(rows)
+        source_headers = rows[0] if has_header else [''] * width
+        body = rows[1:] if has_header else rows
+        if not body:
+            return None
+        
+        used: set = set()
+        names = [cls.relational_column_name(label, i + 1, used) for i, label in enumerate(source_headers)]
+        
+        df = pd.DataFrame(body, columns=names)
+        df = df.fillna('')
+        df = df.astype(str)
+        
+        columns = []
+        for name, source in zip(names, source_headers):
+            values = df[name].tolist()
+            columns.append({
+                'name': name,
+                'source_header': source or None,
+                'type': cls.infer_column_type(values),
+                'nullable': any(not v.strip() for v in values)
+            })
+        
+        typed_rows = []
+        for _, row in df.iterrows():
+            typed_rows.append({
+                column['name']: cls.coerce_typed_value(row[column['name']], column['type'])
+                for column in columns
+            })
+        
+        properties = {}
+        for column in columns:
+            prop = dict(cls.JSON_SCHEMA_TYPES[column['type']])
+            if column['nullable']:
+                prop['type'] = [prop['type'], 'null']
+            if column['source_header']:
+                prop['description'] = column['source_header']
+            properties[column['name']] = prop
+        
+        page = table_info.get('page', 0)
+        index = table_info.get('index', 0)
+        table_id = f"page{page}_table{index + 1}"
+        
+        return {
+            'table_id': table_id,
+            'page': page,
+            'index': index,
+            'has_header': has_header,
+            'columns': columns,
+            'json_schema': {
+                '$schema': 'https://json-schema.org/draft/2020-12/schema',
+                'title': table_id,
+                'type': 'object',
+                'properties': properties,
+                'required': [c['name'] for c in columns if not c['nullable']],
+                'additionalProperties': False
+            },
+            'row_count': len(typed_rows),
+            'confidence': table_info.get('confidence'),
+            'rows': typed_rows
+        }
@@ -14,7 +14,7 @@ import json
 def test_emv_extraction():
     """Test generic extraction with EMV PDF"""
     
-    emv_pdf_path = "/Users/wadearnold/Documents/GitHub/wadearnold/mcp-document-markdown/EMV_v4.4_Book_4_Appendix.pdf"
+    emv_pdf_path = str(Path(__file__).parent.parent / "EMV_v4.4_Book_4_Appendix.pdf")
     
     if not Path(emv_pdf_path).exists():
         print("❌ EMV PDF not found at expected location")
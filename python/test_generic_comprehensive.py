@@ -165,7 +165,7 @@ ttl: 3600
 def test_with_vts_pdf():
     """Test the generic extractor with actual VTS PDF"""
     
-    vts_pdf_path = "/Users/wadearnold/Documents/GitHub/wadearnold/mcp-document-markdown/VTS_chapter4.pdf"
+    vts_pdf_path = str(Path(__file__).parent.parent / "VTS_chapter4.pdf")
     
     if not Path(vts_pdf_path).exists():
         print("⚠️  VTS PDF not found, skipping real PDF test")
@@ -12,7 +12,7 @@ from processors.pdf_extractor_generic import extract_pdf
 
 def test_generic_with_vts():
     """Test generic extractor with VTS PDF"""
-    vts_pdf_path = "/Users/wadearnold/Documents/GitHub/wadearnold/mcp-document-markdown/VTS_chapter4.pdf"
+    vts_pdf_path = str(Path(__file__).parent.parent / "VTS_chapter4.pdf")
     
     print("=== TESTING GENERIC PDF EXTRACTOR WITH VTS DOCUMENT ===")
     
@@ -66,7 +66,7 @@ def test_generic_with_vts():
 
 def test_with_config():
     """Test with custom configuration"""
-    vts_pdf_path = "/Users/wadearnold/Documents/GitHub/wadearnold/mcp-document-markdown/VTS_chapter4.pdf"
+    vts_pdf_path = str(Path(__file__).parent.parent / "VTS_chapter4.pdf")
     
     # Custom config for better API document handling
     config = {
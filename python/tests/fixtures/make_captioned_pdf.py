@@ -0,0 +1,54 @@
+"""
+Generate captioned_figures.pdf, a fixture with captioned images
+
+One page with two 20x20 RGB images: the first captioned below
+("Figure 1: ..."), the second captioned above ("Figure 2 - ..."), plus a
+body paragraph that must not be taken as a caption.
+
+Usage: python make_captioned_pdf.py
+"""
+import sys
+from pathlib import Path
+
+# Make python/ importable (for utils.pdf_writer) when run as a script
+sys.path.insert(0, str(Path(__file__).resolve().parents[2]))
+
+from utils.pdf_writer import stream, write_pdf
+
+FIXTURE = Path(__file__).parent / "captioned_figures.pdf"
+
+IMAGE_SIZE = 20
+
+
+def _image_stream(rgb) -> bytes:
+    pixels = bytes(rgb) * (IMAGE_SIZE * IMAGE_SIZE)
+    return stream(pixels, f"/Type /XObject /Subtype /Image /Width {IMAGE_SIZE} /Height {IMAGE_SIZE} "
+                          f"/ColorSpace /DeviceRGB /BitsPerComponent 8")
+
+
+def build_captioned_pdf() -> bytes:
+    # PDF coordinates: origin bottom-left, page 612x792
+    content = b"\n".join([
+        b"BT /F1 12 Tf 72 740 Td (This report describes the deployment in detail.) Tj ET",
+        b"q 200 0 0 100 72 560 cm /Im1 Do Q",
+        b"BT /F1 10 Tf 72 545 Td (Figure 1: System architecture overview) Tj ET",
+        b"BT /F1 10 Tf 72 320 Td (Figure 2 - Request latency by region) Tj ET",
+        b"q 200 0 0 100 72 210 cm /Im2 Do Q",
+    ])
+    objects = [
+        b"<< /Type /Catalog /Pages 2 0 R >>",
+        b"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+        b"/Resources << /Font << /F1 5 0 R >> /XObject << /Im1 6 0 R /Im2 7 0 R >> >> /Contents 4 0 R >>",
+        stream(content),
+        b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+        _image_stream((30, 90, 200)),
+        _image_stream((200, 60, 30)),
+    ]
+
+    return write_pdf(objects)
+
+
+if __name__ == '__main__':
+    FIXTURE.write_bytes(build_captioned_pdf())
+    print(f"Wrote {FIXTURE}")
@@ -0,0 +1,77 @@
+"""
+Generate code_block.pdf, a fixture with a code sample set in Courier
+
+The page has Helvetica prose, then a Python function in Courier whose
+indentation comes only from each line's x position (4 characters of
+6 points at 10pt) and whose body has a blank line, then more prose with
+one Courier word inside a sentence, which must stay prose.
+
+Usage: python make_code_block_pdf.py
+"""
+import sys
+from pathlib import Path
+
+# Make python/ importable (for utils.pdf_writer) when run as a script
+sys.path.insert(0, str(Path(__file__).resolve().parents[2]))
+
+from utils.pdf_writer import stream, write_pdf
+
+FIXTURE = Path(__file__).parent / "code_block.pdf"
+
+INTRO = [
+    "The total of an order is the sum of its item prices.",
+    "The helper below computes it:",
+]
+# (indent in characters, text); None is a blank line
+CODE = [
+    (0, "def total(items):"),
+    (4, "result = 0"),
+    (4, "for item in items:"),
+    (8, "result += item.price"),
+    None,
+    (4, "return result"),
+]
+OUTRO_BEFORE = "Call it with the order's"
+OUTRO_WORD = "items"
+OUTRO_AFTER = "list before tax is added."
+
+LINE_HEIGHT = 14
+TOP = 720
+LEFT_X = 72
+CODE_CHAR_WIDTH = 6  # Courier glyphs are 600/1000 em wide
+
+
+def _text(font: str, size: int, x: float, y: float, text: str) -> bytes:
+    escaped = text.replace("\\", "\\\\").replace("(", "\\(").replace(")", "\\)")
+    return f"BT /{font} {size} Tf {x} {y} Td ({escaped}) Tj ET".encode()
+
+
+def build_code_block_pdf() -> bytes:
+    content = [_text("F1", 11, LEFT_X, TOP - i * LINE_HEIGHT, line) for i, line in enumerate(INTRO)]
+    y = TOP - (len(INTRO) + 1) * LINE_HEIGHT
+    for line in CODE:
+        if line is not None:
+            indent, text = line
+            content.append(_text("F2", 10, LEFT_X + indent * CODE_CHAR_WIDTH, y, text))
+        y -= LINE_HEIGHT
+    y -= LINE_HEIGHT
+    content.append(_text("F1", 11, LEFT_X, y, OUTRO_BEFORE))
+    content.append(_text("F2", 10, LEFT_X + 124, y, OUTRO_WORD))
+    content.append(_text("F1", 11, LEFT_X + 157, y, OUTRO_AFTER))
+
+    objects = [
+        b"<< /Type /Catalog /Pages 2 0 R >>",
+        b"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+        b"/Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> /Contents 4 0 R >>",
+        stream(b"\n".join(content)),
+        b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+        b"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+    ]
+
+    return write_pdf(objects)
+
+
+if __name__ == '__main__':
+    FIXTURE.write_bytes(build_code_block_pdf())
+    print(f"Wrote {FIXTURE}")
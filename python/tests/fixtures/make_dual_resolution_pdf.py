@@ -0,0 +1,75 @@
+"""
+Generate dual_resolution.pdf, a fixture with one figure at two resolutions
+
+Page 1 shows a 32x24 preview of a chart and page 2 the same chart at
+128x96; page 2 also has an unrelated 64x48 image. The pixels come from
+one function sampled at each size, as a PDF producer downscaling the
+original would. Grayscale, uncompressed.
+
+Usage: python make_dual_resolution_pdf.py
+"""
+import math
+import sys
+from pathlib import Path
+
+# Make python/ importable (for utils.pdf_writer) when run as a script
+sys.path.insert(0, str(Path(__file__).resolve().parents[2]))
+
+from utils.pdf_writer import stream, write_pdf
+
+FIXTURE = Path(__file__).parent / "dual_resolution.pdf"
+
+
+def chart(u: float, v: float) -> float:
+    """Bars of different heights on a light background"""
+    bar = int(u * 6)
+    height = (0.3, 0.8, 0.5, 0.95, 0.2, 0.6)[min(bar, 5)]
+    inside = (u * 6) % 1 < 0.7 and (1 - v) < height
+    return 0.15 if inside else 0.9
+
+
+def rings(u: float, v: float) -> float:
+    """Concentric rings, unrelated to the chart"""
+    return 0.5 + 0.5 * math.cos(18 * math.hypot(u - 0.3, v - 0.6))
+
+
+def sample(function, width: int, height: int) -> bytes:
+    return bytes(int(255 * function((x + 0.5) / width, (y + 0.5) / height))
+                 for y in range(height) for x in range(width))
+
+
+def _image_object(pixels: bytes, width: int, height: int) -> bytes:
+    return stream(pixels, f"/Type /XObject /Subtype /Image /Width {width} /Height {height} "
+                          f"/ColorSpace /DeviceGray /BitsPerComponent 8")
+
+
+def build_dual_resolution_pdf() -> bytes:
+    page_one = b"\n".join([
+        b"BT /F1 12 Tf 72 740 Td (Quarterly volume, preview.) Tj ET",
+        b"q 96 0 0 72 72 600 cm /Im1 Do Q",
+    ])
+    page_two = b"\n".join([
+        b"BT /F1 12 Tf 72 740 Td (Quarterly volume in full.) Tj ET",
+        b"q 384 0 0 288 72 400 cm /Im2 Do Q",
+        b"q 128 0 0 96 72 250 cm /Im3 Do Q",
+    ])
+    resources = b"/Resources << /Font << /F1 7 0 R >> /XObject << /Im1 8 0 R /Im2 9 0 R /Im3 10 0 R >> >>"
+    objects = [
+        b"<< /Type /Catalog /Pages 2 0 R >>",
+        b"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] " + resources + b" /Contents 5 0 R >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] " + resources + b" /Contents 6 0 R >>",
+        stream(page_one),
+        stream(page_two),
+        b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+        _image_object(sample(chart, 32, 24), 32, 24),
+        _image_object(sample(chart, 128, 96), 128, 96),
+        _image_object(sample(rings, 64, 48), 64, 48),
+    ]
+
+    return write_pdf(objects)
+
+
+if __name__ == '__main__':
+    FIXTURE.write_bytes(build_dual_resolution_pdf())
+    print(f"Wrote {FIXTURE}")
@@ -0,0 +1,70 @@
+"""
+Generate filled_form.pdf, an interactive-form (AcroForm) fixture
+
+Two pages. Page 1 holds a text field nested under a parent field
+(applicant.name, with a tooltip), a checked checkbox, a two-button radio
+group, a dropdown, and a push button; page 2 holds an empty text field.
+Only the labels are page text - the values live in the fields.
+
+Usage: python make_form_pdf.py
+"""
+import sys
+from pathlib import Path
+
+# Make python/ importable (for utils.pdf_writer) when run as a script
+sys.path.insert(0, str(Path(__file__).resolve().parents[2]))
+
+from utils.pdf_writer import stream, write_pdf
+
+FIXTURE = Path(__file__).parent / "filled_form.pdf"
+
+
+def _text(lines) -> bytes:
+    return b"\n".join(f"BT /F1 11 Tf {x} {y} Td ({text}) Tj ET".encode() for x, y, text in lines)
+
+
+def build_form_pdf() -> bytes:
+    page_1 = _text([(72, 720, "Application Form"), (72, 680, "Full name:"), (72, 650, "I agree to the terms"),
+                    (72, 620, "Marital status:"), (72, 590, "State:")])
+    page_2 = _text([(72, 720, "Additional notes:")])
+    objects = [
+        # 1-5: document, pages, font
+        b"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [6 0 R 8 0 R 9 0 R 12 0 R 13 0 R 14 0 R] >> >>",
+        b"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 5 0 R >> >> "
+        b"/Contents 15 0 R /Annots [7 0 R 8 0 R 10 0 R 11 0 R 12 0 R 13 0 R] >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 5 0 R >> >> "
+        b"/Contents 16 0 R /Annots [14 0 R] >>",
+        b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+        # 6-7: applicant.name - a parent field and its text field, merged with its widget
+        b"<< /T (applicant) /Kids [7 0 R] >>",
+        b"<< /Type /Annot /Subtype /Widget /Parent 6 0 R /T (name) /TU (Full name) /FT /Tx /V (Jane Doe) "
+        b"/Rect [160 675 400 695] /P 3 0 R >>",
+        # 8: checkbox, checked
+        b"<< /Type /Annot /Subtype /Widget /T (agree) /FT /Btn /V /Yes /AS /Yes "
+        b"/AP << /N << /Yes 17 0 R /Off 17 0 R >> >> /Rect [50 645 64 659] /P 3 0 R >>",
+        # 9-11: radio group with one widget per button
+        b"<< /T (status) /FT /Btn /Ff 49152 /V /married /Kids [10 0 R 11 0 R] >>",
+        b"<< /Type /Annot /Subtype /Widget /Parent 9 0 R /AS /Off "
+        b"/AP << /N << /single 17 0 R /Off 17 0 R >> >> /Rect [160 615 174 629] /P 3 0 R >>",
+        b"<< /Type /Annot /Subtype /Widget /Parent 9 0 R /AS /married "
+        b"/AP << /N << /married 17 0 R /Off 17 0 R >> >> /Rect [220 615 234 629] /P 3 0 R >>",
+        # 12: dropdown with [export display] options
+        b"<< /Type /Annot /Subtype /Widget /T (state) /FT /Ch /Ff 131072 /V (NY) "
+        b"/Opt [[(CA) (California)] [(NY) (New York)]] /Rect [160 585 300 605] /P 3 0 R >>",
+        # 13: push button (no data)
+        b"<< /Type /Annot /Subtype /Widget /T (print) /FT /Btn /Ff 65536 /Rect [72 540 140 560] /P 3 0 R >>",
+        # 14: empty text field on page 2
+        b"<< /Type /Annot /Subtype /Widget /T (notes) /FT /Tx /Rect [72 600 540 700] /P 4 0 R >>",
+        stream(page_1),
+        stream(page_2),
+        # 17: appearance shared by the buttons
+        stream(b""),
+    ]
+
+    return write_pdf(objects)
+
+
+if __name__ == '__main__':
+    FIXTURE.write_bytes(build_form_pdf())
+    print(f"Wrote {FIXTURE}")
@@ -0,0 +1,62 @@
+"""
+Generate line_numbered.pdf, a fixture shaped like a legal pleading
+
+One page with a left-margin column of line numbers 1-8, right-aligned at
+the same x position and set beside eight lines of body text. One body
+line mentions a number ("section 12") that must stay in the text.
+
+Usage: python make_line_numbered_pdf.py
+"""
+import sys
+from pathlib import Path
+
+# Make python/ importable (for utils.pdf_writer) when run as a script
+sys.path.insert(0, str(Path(__file__).resolve().parents[2]))
+
+from utils.pdf_writer import stream, write_pdf
+
+FIXTURE = Path(__file__).parent / "line_numbered.pdf"
+
+BODY_LINES = [
+    "Plaintiff alleges as follows:",
+    "The parties entered into the agreement on March 3.",
+    "Defendant failed to deliver the goods as agreed.",
+    "Plaintiff gave written notice of the breach.",
+    "Defendant did not cure within thirty days.",
+    "The agreement is governed by section 12 of the code.",
+    "Plaintiff has suffered damages as a result.",
+    "Wherefore, plaintiff requests relief as set out below.",
+]
+
+LINE_HEIGHT = 24
+TOP = 700
+NUMBER_RIGHT_EDGE = 60
+BODY_LEFT = 90
+
+
+def build_line_numbered_pdf() -> bytes:
+    # Helvetica digits are 0.556 em wide: right-align each number at NUMBER_RIGHT_EDGE
+    commands = []
+    for index, text in enumerate(BODY_LINES):
+        y = TOP - index * LINE_HEIGHT
+        number = str(index + 1)
+        x = NUMBER_RIGHT_EDGE - 0.556 * 12 * len(number)
+        commands.append(f"BT /F1 12 Tf {x:.2f} {y} Td ({number}) Tj ET".encode())
+        commands.append(f"BT /F1 12 Tf {BODY_LEFT} {y} Td ({text}) Tj ET".encode())
+    content = b"\n".join(commands)
+
+    objects = [
+        b"<< /Type /Catalog /Pages 2 0 R >>",
+        b"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+        b"/Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+        stream(content),
+        b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+    ]
+
+    return write_pdf(objects)
+
+
+if __name__ == '__main__':
+    FIXTURE.write_bytes(build_line_numbered_pdf())
+    print(f"Wrote {FIXTURE}")
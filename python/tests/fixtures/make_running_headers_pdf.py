@@ -0,0 +1,52 @@
+"""
+Generate running_headers.pdf, a fixture with a header and footer on every page
+
+Four pages, each with "ACME Corp - Confidential" at the top, "Page N of 4"
+at the bottom, and two lines of body text. Page 3's body repeats the
+header text, which must be kept.
+
+Usage: python make_running_headers_pdf.py
+"""
+import sys
+from pathlib import Path
+
+# Make python/ importable (for utils.pdf_writer) when run as a script
+sys.path.insert(0, str(Path(__file__).resolve().parents[2]))
+
+from utils.pdf_writer import stream, write_pdf
+
+FIXTURE = Path(__file__).parent / "running_headers.pdf"
+
+HEADER = "ACME Corp - Confidential"
+PAGES = 4
+
+
+def page_content(page_num: int) -> bytes:
+    # PDF coordinates: origin bottom-left, page 612x792
+    body = [f"Body text of page {page_num} explains the retention policy.",
+            HEADER if page_num == 3 else f"Second line of page {page_num}."]
+    lines = [(72, 760, 9, HEADER)]
+    lines += [(72, 600 - 16 * index, 11, line) for index, line in enumerate(body)]
+    lines.append((280, 36, 9, f"Page {page_num} of {PAGES}"))
+    return b"\n".join(f"BT /F1 {size} Tf {x} {y} Td ({text}) Tj ET".encode() for x, y, size, text in lines)
+
+
+def build_running_headers_pdf() -> bytes:
+    font = 3 + 2 * PAGES
+    objects = [
+        b"<< /Type /Catalog /Pages 2 0 R >>",
+        ("<< /Type /Pages /Kids [" + " ".join(f"{3 + 2 * index} 0 R" for index in range(PAGES))
+         + f"] /Count {PAGES} >>").encode(),
+    ]
+    for index in range(PAGES):
+        objects.append((f"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+                        f"/Resources << /Font << /F1 {font} 0 R >> >> /Contents {4 + 2 * index} 0 R >>").encode())
+        objects.append(stream(page_content(index + 1)))
+    objects.append(b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+
+    return write_pdf(objects)
+
+
+if __name__ == '__main__':
+    FIXTURE.write_bytes(build_running_headers_pdf())
+    print(f"Wrote {FIXTURE}")
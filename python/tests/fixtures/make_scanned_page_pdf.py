@@ -0,0 +1,59 @@
+"""
+Generate scanned_page.pdf, a fixture with a text page and an image-only page
+
+Page 1 is ordinary text in Helvetica. Page 2 is what a scanner produces: one
+full-page image and no text layer, so it needs OCR.
+
+Usage: python make_scanned_page_pdf.py
+"""
+import sys
+from pathlib import Path
+
+# Make python/ importable (for utils.pdf_writer) when run as a script
+sys.path.insert(0, str(Path(__file__).resolve().parents[2]))
+
+from utils.pdf_writer import stream, write_pdf
+
+FIXTURE = Path(__file__).parent / "scanned_page.pdf"
+
+IMAGE_SIZE = 40
+
+LINES = [
+    "Section 4 describes how clients authenticate against the gateway.",
+    "Every request carries a bearer token issued by the identity service.",
+    "Tokens expire after one hour; clients refresh them before expiry.",
+    "A refresh that fails with 401 means the grant was revoked upstream.",
+    "Retry transient 503 responses with exponential backoff and jitter.",
+    "Idempotency keys make retried payment requests safe to repeat.",
+    "Webhooks are signed with HMAC-SHA256 over the raw request body.",
+    "Verify the signature before parsing the payload or acting on it.",
+]
+
+
+def build_scanned_page_pdf() -> bytes:
+    # PDF coordinates: origin bottom-left, page 612x792
+    text = b"\n".join(f"BT /F1 11 Tf 72 {720 - 16 * index} Td ({line}) Tj ET".encode()
+                      for index, line in enumerate(LINES))
+    scan = b"q 612 0 0 792 0 0 cm /Im1 Do Q"
+    pixels = bytes([235]) * (IMAGE_SIZE * IMAGE_SIZE)
+    image = (f"/Type /XObject /Subtype /Image /Width {IMAGE_SIZE} /Height {IMAGE_SIZE} "
+             f"/ColorSpace /DeviceGray /BitsPerComponent 8")
+    objects = [
+        b"<< /Type /Catalog /Pages 2 0 R >>",
+        b"<< /Type /Pages /Kids [3 0 R 5 0 R] /Count 2 >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+        b"/Resources << /Font << /F1 7 0 R >> >> /Contents 4 0 R >>",
+        stream(text),
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+        b"/Resources << /XObject << /Im1 8 0 R >> >> /Contents 6 0 R >>",
+        stream(scan),
+        b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+        stream(pixels, image),
+    ]
+
+    return write_pdf(objects)
+
+
+if __name__ == '__main__':
+    FIXTURE.write_bytes(build_scanned_page_pdf())
+    print(f"Wrote {FIXTURE}")
@@ -0,0 +1,48 @@
+"""
+Generate sidebar_first.pdf, a fixture whose drawing order is not its reading order
+
+One page: a title, two lines of body text, and a sidebar note at the foot
+of the page. The content stream draws the sidebar first, so text read in
+the PDF's order starts with it; read by position it comes last.
+
+Usage: python make_sidebar_first_pdf.py
+"""
+import sys
+from pathlib import Path
+
+# Make python/ importable (for utils.pdf_writer) when run as a script
+sys.path.insert(0, str(Path(__file__).resolve().parents[2]))
+
+from utils.pdf_writer import stream, write_pdf
+
+FIXTURE = Path(__file__).parent / "sidebar_first.pdf"
+
+TITLE = "Records Policy"
+BODY = ["Records are kept for seven years after the end of the financial year.",
+        "Personal data is deleted as soon as it is no longer needed."]
+SIDEBAR = "Sidebar: retention periods for each record type are listed in Appendix B."
+
+
+def page_content() -> bytes:
+    # PDF coordinates: origin bottom-left, page 612x792; the sidebar is drawn first
+    lines = [(72, 160, 10, SIDEBAR), (72, 720, 18, TITLE)]
+    lines += [(72, 660 - 16 * index, 11, line) for index, line in enumerate(BODY)]
+    return b"\n".join(f"BT /F1 {size} Tf {x} {y} Td ({text}) Tj ET".encode() for x, y, size, text in lines)
+
+
+def build_sidebar_first_pdf() -> bytes:
+    objects = [
+        b"<< /Type /Catalog /Pages 2 0 R >>",
+        b"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+        b"/Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+        stream(page_content()),
+        b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+    ]
+
+    return write_pdf(objects)
+
+
+if __name__ == '__main__':
+    FIXTURE.write_bytes(build_sidebar_first_pdf())
+    print(f"Wrote {FIXTURE}")
@@ -0,0 +1,74 @@
+"""
+Generate signed_incremental.pdf, a signed-PDF fixture
+
+The file has two revisions: an unsigned one-page document, then an
+incremental update adding a signature field whose /ByteRange covers the
+whole file. The /Contents value is zero-filled - the fixture exercises
+metadata extraction, not cryptographic verification.
+
+Usage: python make_signed_pdf.py
+"""
+import sys
+from pathlib import Path
+
+# Make python/ importable (for utils.pdf_writer) when run as a script
+sys.path.insert(0, str(Path(__file__).resolve().parents[2]))
+
+from utils.pdf_writer import stream, write_pdf
+
+FIXTURE = Path(__file__).parent / "signed_incremental.pdf"
+
+CONTENTS_HEX_LENGTH = 64
+BYTE_RANGE_PLACEHOLDER = b"[0 0000000000 0000000000 0000000000]"
+
+
+def build_signed_pdf() -> bytes:
+    objects = [
+        b"<< /Type /Catalog /Pages 2 0 R >>",
+        b"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+        b"/Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+        stream(b"BT /F1 18 Tf 72 720 Td (Signed contract body text) Tj ET"),
+        b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+    ]
+
+    # Revision 1: unsigned document; the update's /Prev points at its xref table
+    data = bytearray(write_pdf(objects))
+    xref_1 = int(data.rsplit(b"startxref\n", 1)[1].split()[0])
+
+    # Revision 2: incremental update adding the signature
+    update = [
+        (1, b"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [6 0 R] /SigFlags 3 >> >>"),
+        (6, b"<< /FT /Sig /T (Signature1) /V 7 0 R /Type /Annot /Subtype /Widget "
+            b"/Rect [0 0 0 0] /P 3 0 R /F 132 >>"),
+        (7, b"<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /adbe.pkcs7.detached "
+            b"/Name (Jane Signer) /M (D:20240315103000+01'00') /Reason (Approval) "
+            b"/Location (Berlin) /ByteRange " + BYTE_RANGE_PLACEHOLDER +
+            b" /Contents <" + b"0" * CONTENTS_HEX_LENGTH + b"> >>"),
+    ]
+    update_offsets = {}
+    for number, body in update:
+        update_offsets[number] = len(data)
+        data += f"{number} 0 obj\n".encode() + body + b"\nendobj\n"
+
+    xref_2 = len(data)
+    data += b"xref\n0 1\n0000000000 65535 f \n"
+    data += f"1 1\n{update_offsets[1]:010d} 00000 n \n".encode()
+    data += f"6 2\n{update_offsets[6]:010d} 00000 n \n{update_offsets[7]:010d} 00000 n \n".encode()
+    data += f"trailer\n<< /Size 8 /Root 1 0 R /Prev {xref_1} >>\n".encode()
+    data += f"startxref\n{xref_2}\n%%EOF\n".encode()
+
+    # The signed range is everything except the /Contents hex string
+    contents_start = data.index(b"/Contents <", update_offsets[7]) + len(b"/Contents ")
+    contents_end = contents_start + CONTENTS_HEX_LENGTH + 2
+    byte_range = f"[0 {contents_start:010d} {contents_end:010d} {len(data) - contents_end:010d}]".encode()
+    assert len(byte_range) == len(BYTE_RANGE_PLACEHOLDER)
+    position = data.index(BYTE_RANGE_PLACEHOLDER)
+    data[position:position + len(byte_range)] = byte_range
+
+    return bytes(data)
+
+
+if __name__ == '__main__':
+    FIXTURE.write_bytes(build_signed_pdf())
+    print(f"Wrote {FIXTURE}")
@@ -0,0 +1,76 @@
+"""
+Generate split_words.pdf, a fixture with words cut at column and page breaks
+
+Page 1 has two columns: the left column ends with "internatio" and the
+right column starts with "nal", and the right column ends with "organiza"
+continued by "tion" at the top of page 2, past a running page number. The
+full words "international" and "organization" appear elsewhere in the text
+so the document's own vocabulary confirms the joins.
+
+Usage: python make_split_words_pdf.py
+"""
+import sys
+from pathlib import Path
+
+# Make python/ importable (for utils.pdf_writer) when run as a script
+sys.path.insert(0, str(Path(__file__).resolve().parents[2]))
+
+from utils.pdf_writer import stream, write_pdf
+
+FIXTURE = Path(__file__).parent / "split_words.pdf"
+
+LEFT_COLUMN = [
+    "Trade between the member states grew",
+    "steadily through the decade, and the",
+    "treaty set out rules for international",
+    "shipping. Disputes were referred to the",
+    "internatio",
+]
+RIGHT_COLUMN = [
+    "nal tribunal, which sat twice a year.",
+    "Each member sent two delegates, and",
+    "the secretariat of the organization",
+    "kept the records. Funding came from",
+    "the members of the organiza",
+]
+PAGE_TWO = [
+    "tion in proportion to their trade.",
+    "The tribunal published its decisions.",
+]
+
+LINE_HEIGHT = 16
+TOP = 720
+LEFT_X = 72
+RIGHT_X = 320
+
+
+def _text(x: float, y: float, text: str) -> bytes:
+    return f"BT /F1 11 Tf {x} {y} Td ({text}) Tj ET".encode()
+
+
+def build_split_words_pdf() -> bytes:
+    page_one = [_text(LEFT_X, TOP - i * LINE_HEIGHT, line) for i, line in enumerate(LEFT_COLUMN)]
+    page_one += [_text(RIGHT_X, TOP - i * LINE_HEIGHT, line) for i, line in enumerate(RIGHT_COLUMN)]
+    page_one.append(_text(300, 40, "1"))
+    page_two = [_text(300, 760, "2")]
+    page_two += [_text(LEFT_X, TOP - i * LINE_HEIGHT, line) for i, line in enumerate(PAGE_TWO)]
+    contents = [b"\n".join(page_one), b"\n".join(page_two)]
+
+    objects = [
+        b"<< /Type /Catalog /Pages 2 0 R >>",
+        b"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+        b"/Resources << /Font << /F1 7 0 R >> >> /Contents 5 0 R >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+        b"/Resources << /Font << /F1 7 0 R >> >> /Contents 6 0 R >>",
+    ]
+    for content in contents:
+        objects.append(stream(content))
+    objects.append(b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+
+    return write_pdf(objects)
+
+
+if __name__ == '__main__':
+    FIXTURE.write_bytes(build_split_words_pdf())
+    print(f"Wrote {FIXTURE}")
@@ -0,0 +1,76 @@
+"""
+Generate two_column.pdf, a fixture with an academic two-column page
+
+A full-width title, then two columns of three paragraphs each, then a
+full-width table row of three cells and a full-width closing line. The
+content stream draws the columns line by line in turn (left, right,
+left, ...), as many typesetters do, so the PDF's own order interleaves
+them.
+
+Usage: python make_two_column_pdf.py
+"""
+import sys
+from pathlib import Path
+
+# Make python/ importable (for utils.pdf_writer) when run as a script
+sys.path.insert(0, str(Path(__file__).resolve().parents[2]))
+
+from utils.pdf_writer import stream, write_pdf
+
+FIXTURE = Path(__file__).parent / "two_column.pdf"
+
+TITLE = "Column Ordering in Scanned Papers"
+LEFT = [
+    ["Left one first line.", "Left one second line."],
+    ["Left two first line.", "Left two second line."],
+    ["Left three first line.", "Left three second line."],
+]
+RIGHT = [
+    ["Right one first line.", "Right one second line."],
+    ["Right two first line.", "Right two second line."],
+    ["Right three first line.", "Right three second line."],
+]
+TABLE_ROW = ["Metric", "Baseline", "Ours"]
+CLOSING = "Closing remarks span the full width of the page below the table."
+
+LEFT_X = 72
+RIGHT_X = 320
+LINE_HEIGHT = 13
+PARAGRAPH_GAP = 24
+TOP = 700
+
+
+def _text(size: int, x: float, y: float, text: str) -> bytes:
+    escaped = text.replace("\\", "\\\\").replace("(", "\\(").replace(")", "\\)")
+    return f"BT /F1 {size} Tf {x} {y} Td ({escaped}) Tj ET".encode()
+
+
+def build_two_column_pdf() -> bytes:
+    content = [_text(16, 150, 740, TITLE)]
+    y = TOP
+    for left, right in zip(LEFT, RIGHT):
+        for left_line, right_line in zip(left, right):
+            content.append(_text(10, LEFT_X, y, left_line))
+            content.append(_text(10, RIGHT_X, y, right_line))
+            y -= LINE_HEIGHT
+        y -= PARAGRAPH_GAP
+    for index, cell in enumerate(TABLE_ROW):
+        content.append(_text(10, LEFT_X + index * 160, y, cell))
+    y -= LINE_HEIGHT + PARAGRAPH_GAP
+    content.append(_text(10, LEFT_X, y, CLOSING))
+
+    objects = [
+        b"<< /Type /Catalog /Pages 2 0 R >>",
+        b"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+        b"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+        b"/Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+        stream(b"\n".join(content)),
+        b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+    ]
+
+    return write_pdf(objects)
+
+
+if __name__ == '__main__':
+    FIXTURE.write_bytes(build_two_column_pdf())
+    print(f"Wrote {FIXTURE}")
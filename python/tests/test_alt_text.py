@@ -0,0 +1,60 @@
+"""
+Test alt text modes for extracted images
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.alt_text import (
+    ALT_TEXT_PROVIDERS, apply_alt_text, markdown_alt_text, register_alt_text_provider, validate_alt_mode
+)
+
+CAPTIONED = {'page': 4, 'caption': "Figure 3: Network topology"}
+BARE = {'page': 7, 'caption': None}
+
+
+class TestAltModes(unittest.TestCase):
+    """Test each mode and the generic fallback"""
+
+    def test_caption(self):
+        image = apply_alt_text(dict(CAPTIONED))
+        self.assertEqual((image['alt_text'], image['alt_text_source']), ("Network topology", 'caption'))
+
+    def test_caption_falls_back_to_generic(self):
+        image = apply_alt_text(dict(BARE), 'caption')
+        self.assertEqual((image['alt_text'], image['alt_text_source']), ("Image from page 7", 'generic'))
+
+    def test_generic(self):
+        self.assertEqual(apply_alt_text(dict(CAPTIONED), 'generic')['alt_text'], "Image from page 4")
+
+    def test_placeholder(self):
+        self.assertEqual(apply_alt_text(dict(CAPTIONED), 'placeholder')['alt_text'],
+                         "ALT-TEXT-TODO page 4, caption: Figure 3: Network topology")
+        self.assertEqual(apply_alt_text(dict(BARE), 'placeholder')['alt_text'], "ALT-TEXT-TODO page 7")
+
+    def test_registered_provider(self):
+        register_alt_text_provider('test_describer', lambda image: f"A diagram on page {image['page']}")
+        try:
+            self.assertEqual(apply_alt_text(dict(BARE), validate_alt_mode('test_describer'))['alt_text'],
+                             "A diagram on page 7")
+        finally:
+            del ALT_TEXT_PROVIDERS['test_describer']
+
+    def test_unknown_mode(self):
+        self.assertEqual(validate_alt_mode(None), 'caption')
+        with self.assertRaises(ValueError):
+            validate_alt_mode('vision')
+
+
+class TestMarkdownAltText(unittest.TestCase):
+    """Test alt text can't break the image markup"""
+
+    def test_brackets_and_newlines(self):
+        self.assertEqual(markdown_alt_text("Plot [a]\nof\tb"), "Plot \\[a\\] of b")
+
+
+if __name__ == '__main__':
+    unittest.main()
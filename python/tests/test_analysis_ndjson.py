@@ -0,0 +1,64 @@
+"""
+Test the streaming (NDJSON) analyzer records
+"""
+import unittest
+import json
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+try:
+    import pypdf  # noqa: F401
+    import pdfplumber  # noqa: F401
+    HAS_ANALYZER_DEPS = True
+except ImportError:
+    HAS_ANALYZER_DEPS = False
+
+if HAS_ANALYZER_DEPS:
+    from pdf_analyzer import iter_chapter_info, collect_analysis, iter_analysis_records, analyze_pdf
+
+FIXTURE = os.path.join(os.path.dirname(os.path.abspath(__file__)), "fixtures", "captioned_figures.pdf")
+
+
+class _OutlineItem:
+    def __init__(self, title):
+        self.title = title
+
+
+@unittest.skipUnless(HAS_ANALYZER_DEPS, "pypdf and pdfplumber are required")
+class TestAnalysisRecords(unittest.TestCase):
+    """Test analysis records stream in order and fold back into analyze_pdf's result"""
+
+    def test_chapters_stream_in_document_order_with_levels(self):
+        outline = [_OutlineItem("One"), [_OutlineItem("One.A"), [_OutlineItem("Deep")]], _OutlineItem("Two")]
+        chapters = list(iter_chapter_info(outline))
+        self.assertEqual([(c['title'], c['level']) for c in chapters],
+                         [("One", 0), ("One.A", 1), ("Deep", 2), ("Two", 0)])
+
+    def test_collect_analysis_folds_records(self):
+        records = [
+            {'type': 'document', 'pages': 3, 'has_toc': True, 'metadata': {'title': 'T'}},
+            {'type': 'chapter', 'title': 'Intro', 'level': 0, 'page': 1},
+            {'type': 'page_tables', 'page': 2, 'tables': 2},
+            {'type': 'summary', 'pages': 3, 'has_toc': True, 'has_tables': True, 'has_images': False,
+             'chapter_count': 1, 'table_count': 2, 'image_count': 0},
+        ]
+        analysis = collect_analysis(records)
+        self.assertEqual(analysis['chapters'], [{'title': 'Intro', 'level': 0, 'page': 1}])
+        self.assertEqual(analysis['metadata'], {'title': 'T'})
+        self.assertEqual(analysis['table_count'], 2)
+        self.assertTrue(analysis['has_tables'])
+
+    def test_records_are_json_lines_ending_in_summary(self):
+        records = list(iter_analysis_records(FIXTURE))
+        self.assertEqual(records[0]['type'], 'document')
+        self.assertEqual(records[-1]['type'], 'summary')
+        for record in records:
+            self.assertNotIn("\n", json.dumps(record, default=str))
+        self.assertEqual(collect_analysis(records), analyze_pdf(FIXTURE))
+
+
+if __name__ == '__main__':
+    unittest.main()
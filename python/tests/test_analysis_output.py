@@ -0,0 +1,80 @@
+"""
+Test splitting analyzer output into the report and the structured analysis
+"""
+import unittest
+import json
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.analysis_output import AnalysisResult, parse_analysis_output, JSON_MARKER
+
+ANALYSIS = {
+    'pages': 12,
+    'has_toc': True,
+    'has_tables': True,
+    'has_images': False,
+    'chapters': [{'title': 'Introduction', 'level': 0, 'page': 1}],
+    'metadata': {'title': 'Spec'},
+    'xmp': None,
+    'table_count': 3,
+    'image_count': 0,
+    'text_confidence': [{'page': 1, 'confidence': 0.9, 'chars': 1800, 'fonts': 2, 'embedded_fonts': 2, 'images': 0}],
+    'suspect_pages': []
+}
+
+REPORT = "PDF Analysis for: spec.pdf\nPages: 12\nHas Table of Contents: True"
+
+
+class TestParseAnalysisOutput(unittest.TestCase):
+    """Test parse_analysis_output on analyzer stdout"""
+
+    def test_report_and_json_split(self):
+        result = parse_analysis_output(f"{REPORT}\n\n{JSON_MARKER}\n{json.dumps(ANALYSIS)}\n")
+        self.assertEqual(result.pages, 12)
+        self.assertEqual(result.table_count, 3)
+        self.assertEqual(result.chapters[0]['title'], 'Introduction')
+        self.assertEqual(result.report, REPORT)
+        self.assertEqual(result.to_dict(), ANALYSIS)
+
+    def test_missing_marker(self):
+        with self.assertRaises(ValueError) as context:
+            parse_analysis_output(REPORT)
+        self.assertIn(JSON_MARKER, str(context.exception))
+
+    def test_marker_without_json(self):
+        with self.assertRaises(ValueError):
+            parse_analysis_output(f"{REPORT}\n{JSON_MARKER}\n")
+
+    def test_invalid_json(self):
+        with self.assertRaises(ValueError):
+            parse_analysis_output(f"{REPORT}\n{JSON_MARKER}\n{{'pages': 12}}")
+
+    def test_marker_text_inside_report(self):
+        # A chapter titled like the marker is indented in the listing, so only the last bare marker counts
+        raw = f"{REPORT}\n  - {JSON_MARKER}\n{JSON_MARKER}\r\n{json.dumps(ANALYSIS)}"
+        self.assertEqual(parse_analysis_output(raw).report, f"{REPORT}\n  - {JSON_MARKER}")
+
+
+class TestAnalysisResult(unittest.TestCase):
+    """Test typed fields built from analysis dictionaries"""
+
+    def test_missing_fields_default(self):
+        result = AnalysisResult.from_dict({'pages': 2})
+        self.assertEqual(result.pages, 2)
+        self.assertEqual(result.chapters, [])
+        self.assertFalse(result.has_toc)
+
+    def test_not_an_object(self):
+        with self.assertRaises(ValueError):
+            AnalysisResult.from_dict([1, 2, 3])
+
+    def test_wrong_type(self):
+        with self.assertRaises(ValueError):
+            AnalysisResult.from_dict({'pages': 'many'})
+
+
+if __name__ == '__main__':
+    unittest.main()
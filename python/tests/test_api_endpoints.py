@@ -0,0 +1,138 @@
+"""
+Test finding API endpoints and writing one file per endpoint
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.api_endpoints import (
+    collect_endpoints, endpoint_slug, find_endpoints, render_endpoint, render_endpoint_index, schema_label
+)
+from utils.frontmatter import split_front_matter
+
+try:
+    from modular_pdf_converter import ModularPDFConverter
+    HAS_CONVERTER = True
+except ImportError:
+    HAS_CONVERTER = False
+
+USERS_API = """The users service manages accounts.
+
+GET /v1/users - List users
+Results are paged.
+Response:
+```json
+[{"id": 1}]
+```
+
+POST /v1/users
+Creates a user.
+Request body:
+{
+  "name": "Ada"
+}
+201 Created
+{"id": 2}
+
+## Errors
+
+Call GET /v1/users/{id}. to fetch one user.
+"""
+
+
+class TestFindEndpoints(unittest.TestCase):
+    """Test method, path, description, and schemas"""
+
+    def test_endpoints_and_schemas(self):
+        endpoints = find_endpoints(USERS_API)
+        self.assertEqual([(e['method'], e['path']) for e in endpoints],
+                         [('GET', '/v1/users'), ('POST', '/v1/users'), ('GET', '/v1/users/{id}')])
+        listing, create, fetch = endpoints
+        self.assertEqual(listing['description'], "List users")
+        self.assertEqual(listing['response'], {'language': 'json', 'code': '[{"id": 1}]'})
+        self.assertIsNone(listing['request'])
+        self.assertEqual(create['description'], "Creates a user.")
+        self.assertEqual(create['request']['code'], '{\n  "name": "Ada"\n}')
+        self.assertEqual(create['response']['code'], '{"id": 2}')
+        self.assertEqual(fetch['description'], "Call GET /v1/users/{id}. to fetch one user.")
+
+    def test_prose_and_code_are_not_endpoints(self):
+        text = "Get the value from /tmp first.\n```\nGET /internal/debug\n```\nPOST data to the form."
+        self.assertEqual(find_endpoints(text), [])
+
+    def test_full_url(self):
+        endpoint = find_endpoints("POST https://api.example.com/v2/orders")[0]
+        self.assertEqual(endpoint['path'], "https://api.example.com/v2/orders")
+        self.assertEqual(endpoint_slug('POST', endpoint['path']), "post-v2-orders")
+
+    def test_labels(self):
+        self.assertEqual(schema_label("200 OK"), 'response')
+        self.assertEqual(schema_label("Example request body:"), 'request')
+        self.assertIsNone(schema_label("The response lists every user."))
+
+    def test_repeated_endpoint_listed_once(self):
+        sections = [{'section_id': 1, 'title': 'Overview', 'content': "GET /v1/users\n"},
+                    {'section_id': 2, 'title': 'Users', 'content': "GET /v1/users\nLists users.\n"}]
+        endpoints = collect_endpoints(sections)
+        self.assertEqual(len(endpoints), 1)
+        self.assertEqual(endpoints[0]['section_id'], 1)
+        self.assertEqual(endpoints[0]['description'], "Lists users.")
+
+
+class TestRenderEndpoints(unittest.TestCase):
+    """Test endpoint files and the index"""
+
+    def test_endpoint_file(self):
+        endpoint = collect_endpoints([{'section_id': 4, 'title': 'Users', 'content': USERS_API}])[1]
+        front_matter, body = split_front_matter(render_endpoint(endpoint, "../sections/04-users.md"))
+        self.assertEqual(front_matter, {'title': 'POST /v1/users', 'method': 'POST', 'path': '/v1/users',
+                                        'section_id': 4})
+        self.assertTrue(body.startswith("# POST `/v1/users`\n\nCreates a user.\n\n## Request\n\n```json\n"))
+        self.assertTrue(body.endswith("Source: [Users](../sections/04-users.md)\n"))
+
+    def test_index(self):
+        endpoints = find_endpoints(USERS_API)
+        index = render_endpoint_index(endpoints, ["01-a.md", "02-b.md", "03-c.md"], "users.pdf")
+        self.assertIn("3 endpoints found in users.pdf.", index)
+        self.assertIn("| GET | [`/v1/users`](01-a.md) | List users |", index)
+
+
+@unittest.skipUnless(HAS_CONVERTER, "Converter dependencies are required")
+class TestApiEndpointFiles(unittest.TestCase):
+    """Test the converter writes api-endpoints/ and the manifest count"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_files_written(self):
+        converter = ModularPDFConverter(str(self.temp_dir / "users.pdf"), str(self.temp_dir), {})
+        section_file = converter.layout.path_for('sections', "01-users.md")
+        sections = [{'section_id': 1, 'title': 'Users', 'content': USERS_API}]
+        written = converter.write_api_endpoints(sections, {1: section_file})
+
+        api_dir = converter.output_dir / "api-endpoints"
+        self.assertEqual(sorted(Path(path).name for path in written),
+                         ['01-get-v1-users.md', '02-post-v1-users.md', '03-get-v1-users-id.md', 'README.md'])
+        self.assertIn("](../sections/01-users.md)", (api_dir / "02-post-v1-users.md").read_text())
+        self.assertEqual(converter.api_endpoints['count'], 3)
+        self.assertEqual(converter.api_endpoints['index'], "api-endpoints/README.md")
+        self.assertEqual(converter.api_endpoints['endpoints'][1]['file'], "api-endpoints/02-post-v1-users.md")
+
+    def test_no_endpoints_no_directory(self):
+        converter = ModularPDFConverter(str(self.temp_dir / "essay.pdf"), str(self.temp_dir), {})
+        self.assertEqual(converter.write_api_endpoints([{'section_id': 1, 'content': "Plain prose."}], {}), [])
+        self.assertEqual(converter.api_endpoints, {'count': 0})
+        self.assertFalse((converter.output_dir / "api-endpoints").exists())
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,215 @@
+"""
+Test batch conversion of independent PDFs
+"""
+import unittest
+import asyncio
+import errno
+import tempfile
+import shutil
+import json
+from pathlib import Path
+from unittest.mock import patch
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.batch_conversion import (
+    ConverterFailed, ConverterStartError, batch_file_result, batch_output_dirs, converter_command, converter_input,
+    parse_converter_output, resolve_batch_pdfs, run_batch, start_converter, start_retries, summarize_batch,
+    validate_concurrency
+)
+from utils.concurrency import WorkerLimit
+
+SUCCESS = {
+    'success': True,
+    'output_directory': "docs/a",
+    'processing_time_seconds': 1.5,
+    'warnings': ["Page 3 has no text"],
+    'conversion_manifest': {'file_count': 4, 'total_bytes': 900, 'total_tokens': 200,
+                            'kinds': {'index': 1, 'section': 3}, 'files': []}
+}
+
+
+class TestRunBatch(unittest.TestCase):
+    """Test fan-out, the concurrency bound, and failure isolation"""
+
+    def test_failure_does_not_stop_the_rest(self):
+        running = {'now': 0, 'max': 0}
+
+        async def convert_one(pdf_path):
+            running['now'] += 1
+            running['max'] = max(running['max'], running['now'])
+            await asyncio.sleep(0.01)
+            running['now'] -= 1
+            if pdf_path == "b.pdf":
+                raise RuntimeError("corrupt xref table")
+            return batch_file_result(pdf_path, SUCCESS)
+
+        entries = asyncio.run(run_batch(["a.pdf", "b.pdf", "c.pdf", "d.pdf"], convert_one, concurrency=2))
+        self.assertEqual([entry['pdf_path'] for entry in entries], ["a.pdf", "b.pdf", "c.pdf", "d.pdf"])
+        self.assertEqual(entries[1], {'pdf_path': "b.pdf", 'success': False, 'error': "corrupt xref table"})
+        self.assertEqual(running['max'], 2)
+        self.assertEqual(summarize_batch(entries), {'total': 4, 'succeeded': 3, 'failed': 1})
+
+    def test_concurrent_batches_share_the_process_limit(self):
+        running = {'now': 0, 'max': 0}
+
+        async def convert_one(pdf_path):
+            running['now'] += 1
+            running['max'] = max(running['max'], running['now'])
+            await asyncio.sleep(0.01)
+            running['now'] -= 1
+            return batch_file_result(pdf_path, SUCCESS)
+
+        async def two_batches():
+            limit = WorkerLimit(3)
+            batches = [[f"{name}{i}.pdf" for i in range(4)] for name in "ab"]
+            results = await asyncio.gather(*(run_batch(paths, convert_one, 3, limit) for paths in batches))
+            return results, limit
+
+        results, limit = asyncio.run(two_batches())
+        self.assertEqual(running['max'], 3)
+        self.assertEqual([summarize_batch(entries)['succeeded'] for entries in results], [4, 4])
+        self.assertEqual((limit.active, limit.waiting), (0, 0))
+
+
+class TestResults(unittest.TestCase):
+    """Test reading converter output into result entries"""
+
+    def test_parse_converter_output(self):
+        output = "Extracting...\n\\n=== CONVERSION_RESULTS_JSON ===\n" + json.dumps(SUCCESS)
+        self.assertEqual(parse_converter_output(output)['output_directory'], "docs/a")
+
+    def test_crash_without_results(self):
+        with self.assertRaisesRegex(ValueError, "without reporting results"):
+            parse_converter_output("Traceback (most recent call last):\n  ...\nMemoryError")
+
+    def test_success_entry_has_manifest_summary(self):
+        entry = batch_file_result("a.pdf", SUCCESS)
+        self.assertTrue(entry['success'])
+        self.assertEqual(entry['warnings'], 1)
+        self.assertEqual(entry['manifest'], {'file_count': 4, 'total_bytes': 900, 'total_tokens': 200,
+                                             'kinds': {'index': 1, 'section': 3}})
+
+    def test_failed_conversion_entry(self):
+        entry = batch_file_result("a.pdf", {'success': False, 'error': "Password required",
+                                            'error_code': "password_required"})
+        self.assertEqual(entry, {'pdf_path': "a.pdf", 'success': False, 'error': "Password required",
+                                 'error_code': "password_required"})
+
+
+class TestConverterStart(unittest.TestCase):
+    """Test retrying transient failures to start the converter, and nothing else"""
+
+    def setUp(self):
+        self.delays = []
+
+    async def sleep(self, delay):
+        self.delays.append(delay)
+
+    def start(self, *outcomes):
+        """A start function failing or succeeding in turn, counting its calls"""
+        calls = []
+
+        async def start():
+            calls.append(len(calls))
+            outcome = outcomes[len(calls) - 1]
+            if isinstance(outcome, Exception):
+                raise outcome
+            return outcome
+        return start, calls
+
+    def test_transient_exec_error_retried_with_backoff(self):
+        start, calls = self.start(OSError(errno.EAGAIN, "Resource temporarily unavailable"),
+                                  BlockingIOError(errno.EAGAIN, "fork failed"), "process")
+        self.assertEqual(asyncio.run(start_converter(start, retries=3, backoff=0.5, sleep=self.sleep)), "process")
+        self.assertEqual(len(calls), 3)
+        self.assertEqual(self.delays, [0.5, 1.0])
+
+    def test_gives_up_after_retries(self):
+        start, calls = self.start(*[OSError(errno.ENOMEM, "Cannot allocate memory")] * 3)
+        with self.assertRaisesRegex(ConverterStartError, "after 3 attempt"):
+            asyncio.run(start_converter(start, retries=2, sleep=self.sleep))
+        self.assertEqual(len(calls), 3)
+
+    def test_missing_interpreter_not_retried(self):
+        start, calls = self.start(FileNotFoundError(errno.ENOENT, "No such file or directory"))
+        with self.assertRaises(ConverterStartError):
+            asyncio.run(start_converter(start, retries=2, sleep=self.sleep))
+        self.assertEqual((len(calls), self.delays), (1, []))
+
+    def test_conversion_failure_not_retried(self):
+        start, calls = self.start(ConverterFailed("Converter exited without reporting results (exit code 1)"))
+        with self.assertRaises(ConverterFailed):
+            asyncio.run(start_converter(start, retries=2, sleep=self.sleep))
+        self.assertEqual(len(calls), 1)
+        self.assertNotIsInstance(ConverterFailed("x"), ConverterStartError)
+
+    def test_retries_setting(self):
+        for value, expected in (("5", 5), ("-1", 0), ("50", 10), ("many", 2)):
+            with patch.dict(os.environ, {'CONVERTER_START_RETRIES': value}):
+                self.assertEqual(start_retries(), expected)
+
+
+class TestArguments(unittest.TestCase):
+    """Test resolving the PDFs and checking the concurrency"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+        for name in ("b.pdf", "a.pdf", "notes.txt"):
+            (self.temp_dir / name).write_bytes(b"%PDF-1.4")
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_directory_glob_sorted(self):
+        self.assertEqual([Path(path).name for path in resolve_batch_pdfs(directory=str(self.temp_dir))],
+                         ["a.pdf", "b.pdf"])
+
+    def test_paths_deduplicated(self):
+        path = str(self.temp_dir / "a.pdf")
+        self.assertEqual(resolve_batch_pdfs([path, path]), [path])
+
+    def test_bad_arguments(self):
+        with self.assertRaises(ValueError):
+            resolve_batch_pdfs()
+        with self.assertRaises(ValueError):
+            resolve_batch_pdfs(directory=str(self.temp_dir), pattern="*.docx")
+        with self.assertRaises(FileNotFoundError):
+            resolve_batch_pdfs([str(self.temp_dir / "missing.pdf")])
+        for value in (0, 9, "2", True):
+            with self.assertRaises(ValueError):
+                validate_concurrency(value)
+
+    def test_subfolders_kept_for_directory_batches(self):
+        for folder in ("q1", "q2"):
+            (self.temp_dir / folder).mkdir()
+            (self.temp_dir / folder / "report.pdf").write_bytes(b"%PDF-1.4")
+        pdf_paths = resolve_batch_pdfs(directory=str(self.temp_dir), pattern="**/*.pdf")
+        output_dirs = batch_output_dirs(pdf_paths, "docs", str(self.temp_dir))
+        self.assertEqual({Path(path).relative_to(self.temp_dir).as_posix(): output_dirs[path] for path in pdf_paths},
+                         {"a.pdf": "docs", "b.pdf": "docs", "q1/report.pdf": os.path.join("docs", "q1"),
+                          "q2/report.pdf": os.path.join("docs", "q2")})
+
+    def test_same_name_in_pdf_paths_refused(self):
+        (self.temp_dir / "q1").mkdir()
+        (self.temp_dir / "q1" / "a.pdf").write_bytes(b"%PDF-1.4")
+        with self.assertRaisesRegex(ValueError, "would both be converted"):
+            batch_output_dirs([str(self.temp_dir / "a.pdf"), str(self.temp_dir / "q1" / "a.pdf")], "docs")
+
+
+class TestConverterCommand(unittest.TestCase):
+    """Test that options stay off the converter's command line"""
+
+    def test_password_only_on_stdin(self):
+        options = {'password': "s3cret", 'extract_images': False}
+        command = converter_command("a.pdf", "docs")
+        self.assertEqual(command[-3:], ["a.pdf", "docs", "-"])
+        self.assertFalse(any("s3cret" in part for part in command))
+        self.assertEqual(json.loads(converter_input(options)), options)
+
+
+if __name__ == '__main__':
+    unittest.main()
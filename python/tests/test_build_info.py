@@ -0,0 +1,57 @@
+"""
+Test the version and build details the server reports
+"""
+import json
+import unittest
+from unittest import mock
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils import build_info as build
+from utils.build_info import BUILD_INFO_FILE, SERVER_VERSION, build_info, format_build_info, server_version
+
+COMMIT = "1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d"
+
+
+class TestBuildInfo(unittest.TestCase):
+    """Test release, dev, and unknown builds"""
+
+    def setUp(self):
+        self.scripts_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.scripts_dir, ignore_errors=True)
+
+    def test_release_from_stamp(self):
+        stamp = {'version': "1.2.0", 'commit': COMMIT, 'build_date': "2026-01-02T03:04:05Z"}
+        (self.scripts_dir / BUILD_INFO_FILE).write_text(json.dumps(stamp), encoding='utf-8')
+        with mock.patch.object(build, '_git', side_effect=AssertionError("git must not run")):
+            info = build_info(self.scripts_dir)
+        self.assertEqual((info['mode'], info['version'], info['build_date']), ("release", "1.2.0", stamp['build_date']))
+        self.assertEqual(server_version(info), "1.2.0+1a2b3c4d5e6f")
+        self.assertIn("Built: 2026-01-02T03:04:05Z", format_build_info(info))
+
+    def test_dev_checkout(self):
+        outputs = {'rev-parse': COMMIT, 'status': " M python/modular_pdf_converter.py"}
+        with mock.patch.object(build, '_git', lambda repo, command, *args: outputs[command]):
+            info = build_info(self.scripts_dir)
+        self.assertEqual((info['mode'], info['commit'], info['dirty']), ("dev", COMMIT, True))
+        self.assertEqual(server_version(info), f"{SERVER_VERSION}+1a2b3c4d5e6f.dirty")
+
+    def test_unknown_without_stamp_or_git(self):
+        (self.scripts_dir / BUILD_INFO_FILE).write_text("not json", encoding='utf-8')
+        with mock.patch.object(build, '_git', lambda *args: None):
+            info = build_info(self.scripts_dir)
+        self.assertEqual((info['mode'], info['commit']), ("unknown", None))
+        self.assertEqual(server_version(info), SERVER_VERSION)
+        self.assertIn("Commit: unknown", format_build_info(info))
+
+
+if __name__ == '__main__':
+    unittest.main()
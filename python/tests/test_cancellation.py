@@ -0,0 +1,198 @@
+"""
+Test cancelling a conversion and discarding its staged output
+"""
+import unittest
+import tempfile
+import shutil
+import threading
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from unittest.mock import patch
+
+from utils.cancellation import ConversionCancelled, check_cancelled, conversion_timeout, DEFAULT_CONVERSION_TIMEOUT
+from utils.output_layout import OutputLayout
+from utils.staging import (STAGING_PREFIX, discard_staged_output, install_staged_output, rebase_staged_paths,
+                           staging_layout)
+
+try:
+    import fitz
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+try:
+    from modular_pdf_converter import ModularPDFConverter
+    HAS_CONVERTER = True
+except ImportError:
+    HAS_CONVERTER = False
+
+SPLIT_WORDS_PDF = Path(__file__).parent / "fixtures" / "split_words.pdf"
+
+
+class TestCheckCancelled(unittest.TestCase):
+    """Test the cancel checkpoint"""
+
+    def test_no_event(self):
+        check_cancelled(None)
+
+    def test_event_not_set(self):
+        check_cancelled(threading.Event())
+
+    def test_event_set(self):
+        event = threading.Event()
+        event.set()
+        with self.assertRaises(ConversionCancelled):
+            check_cancelled(event)
+
+
+class TestConversionTimeout(unittest.TestCase):
+    """Test resolving the timeout from the argument and environment"""
+
+    def test_default(self):
+        with patch.dict(os.environ, {}, clear=True):
+            self.assertEqual(conversion_timeout(), DEFAULT_CONVERSION_TIMEOUT)
+
+    def test_environment_and_argument(self):
+        with patch.dict(os.environ, {'CONVERSION_TIMEOUT': '45'}):
+            self.assertEqual(conversion_timeout(), 45)
+            self.assertEqual(conversion_timeout(7.5), 7.5)
+
+    def test_zero_disables(self):
+        self.assertIsNone(conversion_timeout(0))
+
+    def test_invalid(self):
+        with self.assertRaises(ValueError):
+            conversion_timeout(-1)
+        with patch.dict(os.environ, {'CONVERSION_TIMEOUT': 'soon'}):
+            with self.assertRaises(ValueError) as context:
+                conversion_timeout()
+        self.assertIn("CONVERSION_TIMEOUT", str(context.exception))
+
+
+class TestStagedOutput(unittest.TestCase):
+    """Test that staged files replace the previous output only when installed"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+        self.layout = OutputLayout('by_artifact', str(self.temp_dir), "doc")
+        for artifact_type, filename in (('root', "README.md"), ('sections', "01-intro.md")):
+            path = self.layout.path_for(artifact_type, filename)
+            path.parent.mkdir(parents=True, exist_ok=True)
+            path.write_text("previous conversion")
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def stage(self):
+        staged = staging_layout(self.layout)
+        for artifact_type, filename in (('root', "README.md"), ('sections', "02-usage.md")):
+            path = staged.path_for(artifact_type, filename)
+            path.parent.mkdir(parents=True, exist_ok=True)
+            path.write_text("new conversion")
+        return staged
+
+    def test_staging_mirrors_the_layout(self):
+        staged = staging_layout(self.layout)
+        self.assertEqual(staged.output_dir.parent, self.temp_dir)
+        self.assertTrue(staged.output_dir.name.startswith(STAGING_PREFIX))
+        self.assertEqual(staged.directory_for('images').relative_to(staged.output_dir),
+                         self.layout.directory_for('images').relative_to(self.layout.output_dir))
+
+    def test_install(self):
+        staged = self.stage()
+        installed = install_staged_output(staged, self.layout)
+
+        self.assertEqual(len(installed), 2)
+        self.assertEqual(self.layout.path_for('root', "README.md").read_text(), "new conversion")
+        self.assertEqual(self.layout.path_for('sections', "02-usage.md").read_text(), "new conversion")
+        self.assertEqual(self.layout.path_for('sections', "01-intro.md").read_text(), "previous conversion")
+        self.assertFalse(staged.output_dir.exists())
+
+    def test_discard_leaves_previous_output(self):
+        staged = self.stage()
+        removed = discard_staged_output(staged)
+
+        self.assertEqual(sorted(Path(path).name for path in removed), ["02-usage.md", "README.md"])
+        self.assertEqual(self.layout.path_for('root', "README.md").read_text(), "previous conversion")
+        self.assertFalse(self.layout.path_for('sections', "02-usage.md").exists())
+        self.assertFalse(staged.output_dir.exists())
+
+    def test_result_paths_rebased(self):
+        staged = staging_layout(self.layout)
+        section = staged.path_for('sections', "01-intro.md")
+        result = rebase_staged_paths({'files': [str(section)], 'root': staged.output_dir,
+                                      'relative': "sections/doc/01-intro.md", 'count': 1}, staged, self.layout)
+        self.assertEqual(result['files'], [str(self.layout.path_for('sections', "01-intro.md"))])
+        self.assertEqual(result['root'], self.layout.output_dir)
+        self.assertEqual((result['relative'], result['count']), ("sections/doc/01-intro.md", 1))
+        discard_staged_output(staged)
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestCancelledExtraction(unittest.TestCase):
+    """Test that extraction stops between pages"""
+
+    def test_stops_after_first_page(self):
+        from processors.pdf_extractor import PDFExtractor
+        event = threading.Event()
+        pages = []
+        with self.assertRaises(ConversionCancelled):
+            for page_num, _text, _line_numbers, _hash in PDFExtractor({'cancel': event}).iter_page_texts(str(SPLIT_WORDS_PDF)):
+                pages.append(page_num)
+                event.set()
+        self.assertEqual(pages, [1])
+
+
+@unittest.skipUnless(HAS_PYMUPDF and HAS_CONVERTER, "PyMuPDF and converter dependencies are required")
+class TestCancelledConversion(unittest.TestCase):
+    """Test that a cancelled conversion leaves no partial output"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_cancelled_conversion_removes_output(self):
+        event = threading.Event()
+        event.set()
+        converter = ModularPDFConverter(str(SPLIT_WORDS_PDF), str(self.temp_dir), {}, event)
+        result = converter.convert()
+
+        self.assertFalse(result['success'])
+        self.assertTrue(result['cancelled'])
+        self.assertFalse(Path(result['output_directory']).exists())
+
+    def test_cancel_keeps_previous_output(self):
+        ModularPDFConverter(str(SPLIT_WORDS_PDF), str(self.temp_dir), {}).convert()
+        readme = Path(ModularPDFConverter(str(SPLIT_WORDS_PDF), str(self.temp_dir), {}).output_dir) / "README.md"
+        self.assertTrue(readme.exists())
+
+        event = threading.Event()
+        event.set()
+        result = ModularPDFConverter(str(SPLIT_WORDS_PDF), str(self.temp_dir),
+                                     {'on_conflict': 'overwrite'}, event).convert()
+
+        self.assertTrue(result['cancelled'])
+        self.assertTrue(readme.exists())
+
+    def test_cancelled_clean_output_keeps_previous_output(self):
+        first = ModularPDFConverter(str(SPLIT_WORDS_PDF), str(self.temp_dir), {}).convert()
+        sections = sorted(Path(first['sections_directory']).iterdir())
+
+        event = threading.Event()
+        event.set()
+        result = ModularPDFConverter(str(SPLIT_WORDS_PDF), str(self.temp_dir), {'clean_output': True}, event).convert()
+
+        self.assertTrue(result['cancelled'])
+        self.assertEqual(sorted(Path(first['sections_directory']).iterdir()), sections)
+        self.assertEqual(list(self.temp_dir.glob(STAGING_PREFIX + "*")), [])
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,108 @@
+"""
+Test the canonical, diff-friendly markdown normalization
+"""
+import unittest
+import tempfile
+import shutil
+import json
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.canonical import canonicalize_markdown, rename_images_by_content, canonicalize_conversion
+from utils.frontmatter import render_front_matter
+
+
+class TestCanonicalMarkdown(unittest.TestCase):
+    """Test the normalization rules"""
+
+    def test_heading_spacing(self):
+        text = "Intro text\n##   Setup  ##\nBody\n\n\n\n# Next\n"
+        self.assertEqual(canonicalize_markdown(text), "Intro text\n\n## Setup\n\nBody\n\n# Next\n")
+
+    def test_whitespace_and_line_endings(self):
+        text = "Café menu item   \r\n* first\r\n+ second\r\n\r\n\r\n"
+        self.assertEqual(canonicalize_markdown(text), "Café menu item\n- first\n- second\n")
+
+    def test_page_markers_and_comments_removed(self):
+        text = "End of page one.\n\nPage 3 of 10\n<!-- page 4 -->\n- 12 -\n4 of 10\nStart of page two.\n"
+        self.assertEqual(canonicalize_markdown(text), "End of page one.\n\nStart of page two.\n")
+
+    def test_bare_numbers_kept(self):
+        text = "Port numbers:\n\n443\n8080\n"
+        self.assertEqual(canonicalize_markdown(text), text)
+
+    def test_hard_breaks_kept(self):
+        text = "Acme Corp.   \n1 Main St.\t\nSpringfield  \n\n- item  \n- next\n"
+        self.assertEqual(canonicalize_markdown(text), "Acme Corp.  \n1 Main St.\nSpringfield\n\n- item\n- next\n")
+
+    def test_code_fences_untouched(self):
+        text = "Example:\n\n```\n* not a bullet  \n\n\n\n# not a heading\n<!-- kept -->\n```\n"
+        self.assertEqual(canonicalize_markdown(text), text)
+
+    def test_front_matter_preserved(self):
+        front_matter = render_front_matter({'title': "Setup", 'section_id': 2})
+        result = canonicalize_markdown(front_matter + "# Setup\n\n\nText  \n")
+        self.assertEqual(result, front_matter + "# Setup\n\nText\n")
+
+    def test_idempotent(self):
+        text = "# A\n* b\n\n\n## C ##\nPage 2\ntext here\n"
+        once = canonicalize_markdown(text)
+        self.assertEqual(canonicalize_markdown(once), once)
+
+    def test_hashtag_is_not_a_heading(self):
+        self.assertEqual(canonicalize_markdown("#1 priority\n"), "#1 priority\n")
+
+
+class TestCanonicalFiles(unittest.TestCase):
+    """Test content-hash image names and in-place directory normalization"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_images_named_by_content(self):
+        (self.temp_dir / "page001-img01.png").write_bytes(b"chart")
+        (self.temp_dir / "page004-img01.png").write_bytes(b"chart")
+        (self.temp_dir / "page005-img02.PNG").write_bytes(b"photo")
+        images = [{'file': str(self.temp_dir / name)}
+                  for name in ("page001-img01.png", "page004-img01.png", "page005-img02.PNG")]
+        rename_images_by_content(images)
+        names = [Path(image['file']).name for image in images]
+        self.assertEqual(names[0], names[1])
+        self.assertRegex(names[0], r'^img-[0-9a-f]{16}\.png$')
+        self.assertTrue(names[2].endswith('.png'))
+        self.assertEqual(sorted(p.name for p in self.temp_dir.iterdir()), sorted(set(names)))
+
+    def test_conversion_rewritten_in_place(self):
+        (self.temp_dir / "sections").mkdir()
+        (self.temp_dir / "sections" / "01-a.md").write_text("# A\n\n\n\nText\n")
+        (self.temp_dir / "README.md").write_text("# Map\n\n- [A](sections/01-a.md)\n")
+        (self.temp_dir / "chunked" / "01-a").mkdir(parents=True)
+        (self.temp_dir / "chunked" / "01-a" / "chunk-1.md").write_text("* one\n")
+        (self.temp_dir / "chunked" / "chunk-manifest.json").write_text(json.dumps(
+            {'chunks': [{'file': "01-a/chunk-1.md"}]}))
+        (self.temp_dir / "manifest.json").write_text(json.dumps(
+            {'sections': [{'file': "sections/01-a.md"}], 'chunking': {'manifest': "chunked/chunk-manifest.json"}}))
+        # Not the converter's: left exactly as written
+        (self.temp_dir / "notes.md").write_text("* mine\n\n\n")
+
+        result = canonicalize_conversion(self.temp_dir)
+        self.assertEqual(result, {'files': 3, 'changed': 2})
+        self.assertEqual((self.temp_dir / "sections" / "01-a.md").read_text(), "# A\n\nText\n")
+        self.assertEqual((self.temp_dir / "chunked" / "01-a" / "chunk-1.md").read_text(), "- one\n")
+        self.assertEqual((self.temp_dir / "notes.md").read_text(), "* mine\n\n\n")
+
+    def test_folder_without_manifest_refused(self):
+        (self.temp_dir / "notes.md").write_text("* mine\n")
+        with self.assertRaisesRegex(FileNotFoundError, "manifest.json"):
+            canonicalize_conversion(self.temp_dir)
+
+
+if __name__ == '__main__':
+    unittest.main()
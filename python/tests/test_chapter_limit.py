@@ -0,0 +1,48 @@
+"""
+Test the analyzer's chapter listing limit
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+try:
+    import pypdf  # noqa: F401
+    import pdfplumber  # noqa: F401
+    HAS_ANALYZER_DEPS = True
+except ImportError:
+    HAS_ANALYZER_DEPS = False
+
+if HAS_ANALYZER_DEPS:
+    from pdf_analyzer import DEFAULT_CHAPTER_LIMIT, format_chapter_listing, validate_chapter_limit
+
+CHAPTERS = [{'title': f"Chapter {number}", 'level': 0, 'page': number * 10} for number in range(1, 13)]
+
+
+@unittest.skipUnless(HAS_ANALYZER_DEPS, "pypdf and pdfplumber are required")
+class TestChapterLimit(unittest.TestCase):
+    """Test chapter_limit validation and the truncated listing"""
+
+    def test_validation(self):
+        self.assertEqual(validate_chapter_limit(None), DEFAULT_CHAPTER_LIMIT)
+        self.assertEqual(validate_chapter_limit(3), 3)
+        for limit in (0, -1, 2.5, "5", True):
+            with self.assertRaises(ValueError):
+                validate_chapter_limit(limit)
+
+    def test_listing_is_cut_at_the_limit(self):
+        listing = format_chapter_listing(CHAPTERS, 3).split("\n")
+        self.assertEqual(listing[0], "Chapters/Sections (12 items):")
+        self.assertEqual(listing[1:4], ["- Chapter 1 (p. 10)", "- Chapter 2 (p. 20)", "- Chapter 3 (p. 30)"])
+        self.assertEqual(listing[4], "  ... and 9 more (full list in JSON)")
+
+    def test_listing_without_limit_has_every_chapter(self):
+        listing = format_chapter_listing(CHAPTERS, None)
+        self.assertIn("- Chapter 12 (p. 120)", listing)
+        self.assertNotIn("more", listing)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,188 @@
+"""
+Test chunking sections to a fixed token budget
+"""
+import unittest
+import tempfile
+import shutil
+import json
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.chunking_engine import (
+    ChunkingEngine, pack_token_chunks, split_markdown_blocks, validate_chunk_budget, validate_chunk_layout
+)
+from utils.frontmatter import split_front_matter
+
+
+def words(text):
+    return len(text.split())
+
+
+class WordCounter:
+    """One token per word"""
+
+    def count_tokens(self, text):
+        return words(text)
+
+    def tokenizer_name(self):
+        return "words"
+
+
+TABLE = "| Code | Meaning |\n|------|---------|\n| 200 | OK |\n| 404 | Not found |"
+
+
+class TestBlocks(unittest.TestCase):
+    """Test splitting markdown into paragraphs, tables, and code"""
+
+    def test_tables_and_code_are_atomic(self):
+        text = f"# Errors\n\nIntro text.\n{TABLE}\nAfter.\n\n```\ncode\n\nmore\n```"
+        blocks = split_markdown_blocks(text)
+        self.assertEqual(blocks, [("# Errors\n\nIntro text.", False), (TABLE, True),
+                                  ("After.", False), ("```\ncode\n\nmore\n```", True)])
+
+
+class TestPackTokenChunks(unittest.TestCase):
+    """Test budgets, boundaries, and overlap"""
+
+    def test_chunks_respect_budget_and_paragraphs(self):
+        text = "\n\n".join(f"Paragraph {n} has exactly six words." for n in range(5))
+        chunks = pack_token_chunks(text, 12, 0, words)
+        self.assertEqual([chunk['tokens'] for chunk in chunks], [12, 12, 6])
+        self.assertTrue(all(chunk['text'].startswith("Paragraph") for chunk in chunks))
+        self.assertEqual(chunks[0]['text'], "Paragraph 0 has exactly six words.\n\nParagraph 1 has exactly six words.")
+
+    def test_long_paragraph_splits_at_sentences(self):
+        text = "One two three four. Five six seven eight. Nine ten eleven twelve."
+        chunks = pack_token_chunks(text, 8, 0, words)
+        self.assertEqual([chunk['text'] for chunk in chunks],
+                         ["One two three four. Five six seven eight.", "Nine ten eleven twelve."])
+
+    def test_overlap_repeats_trailing_sentences(self):
+        text = "A1 a2 a3. B1 b2 b3. C1 c2 c3. D1 d2 d3."
+        chunks = pack_token_chunks(text, 6, 3, words)
+        self.assertEqual([chunk['text'] for chunk in chunks],
+                         ["A1 a2 a3. B1 b2 b3.", "B1 b2 b3. C1 c2 c3.", "C1 c2 c3. D1 d2 d3."])
+        self.assertEqual([chunk['overlap_tokens'] for chunk in chunks], [0, 3, 3])
+        self.assertTrue(all(chunk['tokens'] <= 6 for chunk in chunks))
+
+    def test_table_never_split_or_repeated(self):
+        text = f"Before the table here.\n\n{TABLE}\n\nAfter the table here."
+        chunks = pack_token_chunks(text, 8, 4, words)
+        tables = [chunk for chunk in chunks if TABLE in chunk['text']]
+        self.assertEqual(len(tables), 1)
+        self.assertTrue(tables[0]['over_budget'])
+        self.assertEqual(chunks[-1]['overlap_tokens'], 0)
+
+    def test_list_items_keep_their_lines(self):
+        text = "- First item.\n- Second item."
+        self.assertEqual(pack_token_chunks(text, 50, 0, words)[0]['text'], text)
+
+
+class TestValidation(unittest.TestCase):
+    """Test chunk_tokens / chunk_overlap checks"""
+
+    def test_valid(self):
+        self.assertEqual(validate_chunk_budget("512", 64), (512, 64))
+
+    def test_invalid(self):
+        for tokens, overlap in ((10, 0), (100, 60), (100, -1), ("many", 0)):
+            with self.assertRaises(ValueError):
+                validate_chunk_budget(tokens, overlap)
+
+
+class TestBudgetChunkFiles(unittest.TestCase):
+    """Test the chunk files and chunk-manifest.json"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_files_and_manifest(self):
+        engine = ChunkingEngine(self.temp_dir, WordCounter())
+        sections = [{'title': "Overview", 'content': "One two three. Four five six.", 'page_start': 1, 'page_end': 2},
+                    {'title': "Errors", 'content': TABLE}]
+        result = engine.create_budget_chunks(sections, 50, 0, 'flat')
+
+        self.assertEqual(result['total_chunks'], 2)
+        self.assertEqual([Path(f).name for f in result['chunk_files']],
+                         ["01-Overview-chunk-001.md", "02-Errors-chunk-001.md"])
+        fields, body = split_front_matter(Path(result['chunk_files'][0]).read_text())
+        self.assertEqual((fields['section_id'], fields['chunk'], fields['chunks'], fields['tokens']), (1, 1, 1, 6))
+        self.assertEqual(body, "One two three. Four five six.\n")
+
+        manifest = json.loads(Path(result['manifest_file']).read_text())
+        self.assertEqual((manifest['chunk_tokens'], manifest['chunk_overlap'], manifest['tokenizer']), (50, 0, "words"))
+        self.assertIn("overlap", manifest['overlap'])
+        self.assertEqual(manifest['chunks'][1]['section_title'], "Errors")
+
+    def test_heading_paths_follow_section_levels(self):
+        engine = ChunkingEngine(self.temp_dir, WordCounter())
+        sections = [{'title': "Chapter 3", 'level': 1, 'content': "Intro."},
+                    {'title': "3.1 Setup", 'level': 2, 'content': "Install it."},
+                    {'title': "3.2 Authentication", 'level': 2,
+                     'content': "Sign in.\n\n## Token Refresh\n\nRefresh tokens hourly."},
+                    {'title': "Chapter 4", 'level': 1, 'content': "Next."}]
+        result = engine.create_budget_chunks(sections, 50, 0)
+
+        paths = [chunk['heading_path'] for chunk in result['chunks']]
+        self.assertEqual(paths, [["Chapter 3"],
+                                 ["Chapter 3", "3.1 Setup"],
+                                 ["Chapter 3", "3.2 Authentication"],
+                                 ["Chapter 4"]])
+        fields, _ = split_front_matter(Path(result['chunk_files'][2]).read_text())
+        self.assertEqual(fields['heading_path'], ["Chapter 3", "3.2 Authentication"])
+
+        # A chunk starting after the inner heading nests under it
+        result = engine.create_budget_chunks(sections[:3], 6, 0)
+        self.assertEqual(result['records'][-1]['heading_path'],
+                         ["Chapter 3", "3.2 Authentication", "Token Refresh"])
+
+    def test_links_rebased_for_nested_files(self):
+        engine = ChunkingEngine(self.temp_dir, WordCounter())
+        sections = [{'title': "Overview", 'content': "See ![figure](../images/eq-1.png) and [errors](#errors).\n\n"
+                                                     "```\n[kept](../images/raw.png)\n```"}]
+        chunked = Path(self.temp_dir) / "chunked"
+
+        engine.create_budget_chunks(sections, 50, 0, 'flat')
+        self.assertIn("![figure](../images/eq-1.png)", (chunked / "01-Overview-chunk-001.md").read_text())
+
+        result = engine.create_budget_chunks(sections, 50, 0, 'by_section')
+        text = (chunked / "01-Overview" / "chunk-001.md").read_text()
+        self.assertIn("![figure](../../images/eq-1.png) and [errors](#errors)", text)
+        self.assertIn("[kept](../images/raw.png)", text)
+        # The records keep the section text as written
+        self.assertIn("](../images/eq-1.png)", result['records'][0]['text'])
+
+        engine.create_budget_chunks(sections, 50, 0, 'by_size', links_dir="../sections")
+        self.assertIn("![figure](../../images/eq-1.png)", (chunked / "small" / "01-Overview-chunk-001.md").read_text())
+
+    def test_layouts(self):
+        engine = ChunkingEngine(self.temp_dir, WordCounter())
+        sections = [{'title': "Overview", 'content': "One two three.\n\nFour five six."},
+                    {'title': "Errors", 'content': " ".join(["word"] * 4000)}]
+
+        result = engine.create_budget_chunks(sections, 3, 0, 'by_section')
+        self.assertEqual([chunk['file'] for chunk in result['chunks']][:2],
+                         ["01-Overview/chunk-001.md", "01-Overview/chunk-002.md"])
+        self.assertTrue((Path(self.temp_dir) / "chunked" / "01-Overview" / "chunk-002.md").is_file())
+        self.assertEqual(result['layout'], "by_section")
+
+        result = engine.create_budget_chunks(sections, 5000, 0, 'by_size')
+        self.assertEqual([chunk['file'] for chunk in result['chunks']],
+                         ["small/01-Overview-chunk-001.md", "medium/02-Errors-chunk-001.md"])
+        manifest = json.loads(Path(result['manifest_file']).read_text())
+        self.assertEqual(manifest['layout'], "by_size")
+
+        self.assertEqual(validate_chunk_layout(None), "by_size")
+        with self.assertRaises(ValueError):
+            validate_chunk_layout("nested")
+
+
+if __name__ == '__main__':
+    unittest.main()
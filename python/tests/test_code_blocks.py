@@ -0,0 +1,128 @@
+"""
+Test fencing code samples set in monospaced fonts
+"""
+import unittest
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.code_blocks import (
+    apply_code_blocks, fence_code, guess_language, is_monospaced, page_code_blocks, split_fenced
+)
+
+try:
+    import fitz  # noqa: F401
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+CODE_BLOCK_PDF = Path(__file__).parent / "fixtures" / "code_block.pdf"
+
+
+def _line(x, top, *spans):
+    """A get_text("dict") line from (text, font) spans; Courier 10pt is 6 points per character"""
+    width = sum(len(text) * (6 if font == 'Courier' else 5) for text, font in spans)
+    return {'bbox': (x, top, x + width, top + 11),
+            'spans': [{'text': text, 'font': font, 'flags': 0} for text, font in spans]}
+
+
+def _page(*lines):
+    return {'blocks': [{'type': 0, 'lines': list(lines)}]}
+
+
+class TestMonospaceDetection(unittest.TestCase):
+    """Test fixed-pitch font recognition"""
+
+    def test_font_names_and_flag(self):
+        self.assertTrue(is_monospaced({'font': 'ABCDEF+Consolas-Bold', 'flags': 0}))
+        self.assertTrue(is_monospaced({'font': 'DejaVuSansMono', 'flags': 0}))
+        self.assertTrue(is_monospaced({'font': 'Unnamed', 'flags': 8}))
+        self.assertFalse(is_monospaced({'font': 'Helvetica', 'flags': 16}))
+
+
+class TestPageCodeBlocks(unittest.TestCase):
+    """Test grouping monospaced lines and restoring indentation"""
+
+    def test_indentation_and_blank_line_restored(self):
+        page = _page(
+            _line(72, 100, ("Sum the prices:", 'Helvetica')),
+            _line(72, 114, ("def total(items):", 'Courier')),
+            _line(96, 128, ("return sum(items)", 'Courier')),
+            _line(72, 156, ("total([])", 'Courier')),
+        )
+        blocks = page_code_blocks(page)
+        self.assertEqual(len(blocks), 1)
+        self.assertEqual(blocks[0]['code'], "def total(items):\n    return sum(items)\n\ntotal([])")
+        self.assertEqual(blocks[0]['language'], 'python')
+
+    def test_inline_monospace_and_single_lines_stay_prose(self):
+        page = _page(
+            _line(72, 100, ("Pass ", 'Helvetica'), ("items", 'Courier'), (" to it.", 'Helvetica')),
+            _line(72, 114, ("config.yaml", 'Courier')),
+            _line(72, 128, ("More prose.", 'Helvetica')),
+        )
+        self.assertEqual(page_code_blocks(page), [])
+
+
+class TestApplyCodeBlocks(unittest.TestCase):
+    """Test replacing the code lines in page text"""
+
+    def test_lines_replaced_by_fence(self):
+        text = "Intro\ndef f():\nreturn 1\nOutro\n"
+        block = {'lines': ["def f():", "return 1"], 'code': "def f():\n    return 1", 'language': 'python'}
+        fenced, applied = apply_code_blocks(text, [block])
+        self.assertEqual(applied, 1)
+        self.assertEqual(fenced, "Intro\n```python\ndef f():\n    return 1\n```\nOutro\n")
+
+    def test_missing_lines_skipped(self):
+        block = {'lines': ["x = 1", "y = 2"], 'code': "x = 1\ny = 2", 'language': ''}
+        self.assertEqual(apply_code_blocks("x = 1\nz = 3\n", [block]), ("x = 1\nz = 3\n", 0))
+
+    def test_fence_longer_than_backticks_in_code(self):
+        self.assertEqual(fence_code("echo ```x```"), "````\necho ```x```\n````")
+
+    def test_split_fenced(self):
+        pieces = split_fenced("- a\n```\n- b\n```\n- c\n")
+        self.assertEqual([is_code for _, is_code in pieces], [False, True, False])
+        self.assertEqual(''.join(part for part, _ in pieces), "- a\n```\n- b\n```\n- c\n")
+
+
+class TestGuessLanguage(unittest.TestCase):
+    """Test fence info strings"""
+
+    def test_clear_languages(self):
+        self.assertEqual(guess_language('{"id": 1, "tags": []}'), 'json')
+        self.assertEqual(guess_language("$ pip install requests\n$ python app.py"), 'bash')
+        self.assertEqual(guess_language("SELECT id, name\nFROM users\nWHERE active = 1;"), 'sql')
+        self.assertEqual(guess_language("package main\n\nfunc main() {\n}"), 'go')
+        self.assertEqual(guess_language("const total = items.reduce((a, b) => a + b, 0);"), 'javascript')
+
+    def test_unclear_falls_back_to_none(self):
+        self.assertEqual(guess_language("Total: 42\nCount: 7"), '')
+        self.assertEqual(guess_language("import os"), '')
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestCodeBlockFixture(unittest.TestCase):
+    """Test the Courier snippet in the fixture page"""
+
+    def test_snippet_fenced_with_indentation(self):
+        from processors.pdf_extractor import extract_pdf
+        result = extract_pdf(str(CODE_BLOCK_PDF), {'detect_code_blocks': True})
+        self.assertEqual(result['metadata']['code_block_count'], 1)
+        self.assertIn("```python\ndef total(items):\n    result = 0\n    for item in items:\n"
+                      "        result += item.price\n\n    return result\n```", result['processed_text'])
+        self.assertNotIn("```", result['processed_text'].split("```\n")[-1])
+
+    def test_off_leaves_plain_text(self):
+        from processors.pdf_extractor import extract_pdf
+        result = extract_pdf(str(CODE_BLOCK_PDF))
+        self.assertEqual(result['metadata']['code_block_count'], 0)
+        self.assertNotIn("```", result['processed_text'])
+
+
+if __name__ == '__main__':
+    unittest.main()
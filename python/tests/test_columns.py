@@ -0,0 +1,111 @@
+"""
+Test reading two-column pages column by column
+"""
+import unittest
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.columns import detect_columns, order_blocks, page_blocks_in_order, validate_column_layout
+
+try:
+    from processors.pdf_extractor import PDFExtractor
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+TWO_COLUMN_PDF = Path(__file__).parent / "fixtures" / "two_column.pdf"
+
+
+def block(x0, y0, x1, y1, text):
+    return (x0, y0, x1, y1, text)
+
+
+# A paper page in the PDF's interleaved order: title, left and right paragraphs, a table row, a footer
+PAPER = [
+    block(150, 40, 460, 60, "Title"),
+    block(72, 80, 290, 140, "L1"),
+    block(320, 80, 540, 120, "R1"),
+    block(72, 150, 290, 210, "L2"),
+    block(320, 130, 540, 210, "R2"),
+    block(72, 230, 150, 242, "Metric"),
+    block(250, 230, 330, 242, "Baseline"),
+    block(460, 230, 540, 242, "Ours"),
+    block(72, 260, 290, 300, "L3"),
+    block(320, 260, 540, 300, "R3"),
+    block(72, 320, 540, 340, "Footer"),
+]
+
+
+def texts(blocks):
+    return [b[4] for b in blocks]
+
+
+class TestDetectColumns(unittest.TestCase):
+    """Test telling two-column pages from single-column ones"""
+
+    def test_paper_is_two_columns(self):
+        self.assertEqual(detect_columns(PAPER), 2)
+
+    def test_single_column_page(self):
+        page = [block(72, 80 + 40 * i, 540, 110 + 40 * i, f"P{i}") for i in range(5)]
+        self.assertEqual(detect_columns(page), 1)
+
+    def test_stacked_narrow_blocks_are_not_columns(self):
+        # A left block above a right block: indented layout, not side-by-side columns
+        page = [block(72, 80, 290, 120, "A"), block(72, 130, 290, 170, "B"),
+                block(320, 200, 540, 240, "C"), block(320, 250, 540, 290, "D")]
+        self.assertEqual(detect_columns(page), 1)
+
+
+class TestOrderBlocks(unittest.TestCase):
+    """Test reading order around full-width blocks"""
+
+    def test_columns_between_full_width_blocks(self):
+        self.assertEqual(texts(order_blocks(PAPER, 2)),
+                         ["Title", "L1", "L2", "R1", "R2", "Metric", "Baseline", "Ours", "L3", "R3", "Footer"])
+
+    def test_table_region_kept_whole(self):
+        table = [block(72, 100, 200, 112, "a1"), block(340, 100, 540, 112, "b1"),
+                 block(72, 116, 200, 128, "a2"), block(340, 116, 540, 128, "b2")]
+        page = [block(72, 40, 290, 90, "L"), block(320, 40, 540, 90, "R")] + table
+        self.assertEqual(texts(order_blocks(page, 2)), ["L", "a1", "a2", "R", "b1", "b2"])
+        self.assertEqual(texts(order_blocks(page, 2, regions=[(72, 100, 540, 128)])),
+                         ["L", "R", "a1", "b1", "a2", "b2"])
+
+    def test_layouts(self):
+        self.assertEqual(page_blocks_in_order(PAPER, 'single'), (PAPER, 1))
+        self.assertEqual(page_blocks_in_order(PAPER[1:3], 'double')[1], 2)
+        self.assertEqual(validate_column_layout(None), 'auto')
+        with self.assertRaises(ValueError):
+            validate_column_layout('triple')
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestTwoColumnFixture(unittest.TestCase):
+    """Test the extracted text of two_column.pdf"""
+
+    def page_text(self, column_layout):
+        extractor = PDFExtractor({'column_layout': column_layout})
+        return extractor, list(extractor.iter_page_texts(str(TWO_COLUMN_PDF)))[0][1]
+
+    def test_auto_reads_left_column_first(self):
+        extractor, text = self.page_text('auto')
+        self.assertEqual(extractor.column_pages, [1])
+        self.assertLess(text.index("Column Ordering"), text.index("Left one first"))
+        self.assertLess(text.index("Left three second"), text.index("Right one first"))
+        self.assertLess(text.index("Right three second"), text.index("Metric"))
+        self.assertLess(text.index("Metric"), text.index("Ours"))
+        self.assertLess(text.index("Ours"), text.index("Closing remarks"))
+
+    def test_single_keeps_interleaved_order(self):
+        extractor, text = self.page_text('single')
+        self.assertEqual(extractor.column_pages, [])
+        self.assertLess(text.index("Right one first"), text.index("Left one second"))
+
+
+if __name__ == '__main__':
+    unittest.main()
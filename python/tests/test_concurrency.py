@@ -0,0 +1,84 @@
+"""
+Test the limit on conversions running at once
+"""
+import asyncio
+import os
+import sys
+import unittest
+from unittest.mock import patch
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.concurrency import (
+    CONCURRENCY_ENV, DEFAULT_CONCURRENCY, DEFAULT_PROCESSES, PROCESSES_ENV, WorkerLimit, max_concurrency, max_processes
+)
+
+
+class TestMaxConcurrency(unittest.TestCase):
+    """Test reading MAX_CONCURRENCY"""
+
+    def test_env_and_fallback(self):
+        with patch.dict(os.environ, {CONCURRENCY_ENV: "3"}):
+            self.assertEqual(max_concurrency(), 3)
+        for value in ("", "0", "-2", "many"):
+            with patch.dict(os.environ, {CONCURRENCY_ENV: value}):
+                self.assertEqual(max_concurrency(), DEFAULT_CONCURRENCY)
+        with patch.dict(os.environ, {}, clear=True):
+            self.assertEqual(max_concurrency(), 1)
+
+    def test_max_processes(self):
+        with patch.dict(os.environ, {PROCESSES_ENV: "6"}):
+            self.assertEqual(max_processes(), 6)
+        with patch.dict(os.environ, {PROCESSES_ENV: "0"}):
+            self.assertEqual(max_processes(), DEFAULT_PROCESSES)
+
+
+class TestWorkerLimit(unittest.TestCase):
+    """Test queueing calls beyond the limit"""
+
+    def test_calls_queue_and_never_exceed_the_limit(self):
+        limit = WorkerLimit(2)
+        running, peak, finished = [0], [0], []
+
+        async def call(number):
+            await limit.acquire()
+            try:
+                running[0] += 1
+                peak[0] = max(peak[0], running[0])
+                await asyncio.sleep(0.01)
+                running[0] -= 1
+                finished.append(number)
+            finally:
+                limit.release()
+
+        async def main():
+            await asyncio.gather(*(call(number) for number in range(6)))
+
+        asyncio.run(main())
+        self.assertEqual(peak[0], 2)
+        self.assertEqual(sorted(finished), list(range(6)))
+        self.assertEqual((limit.active, limit.waiting), (0, 0))
+
+    def test_cancelled_waiter_takes_no_slot(self):
+        limit = WorkerLimit(1)
+
+        async def main():
+            await limit.acquire()
+            waiter = asyncio.ensure_future(limit.acquire())
+            await asyncio.sleep(0)
+            self.assertEqual(limit.waiting, 1)
+            waiter.cancel()
+            with self.assertRaises(asyncio.CancelledError):
+                await waiter
+            self.assertEqual((limit.active, limit.waiting), (1, 0))
+            limit.release()
+            # The slot is free again for the next call
+            await asyncio.wait_for(limit.acquire(), 1)
+            limit.release()
+
+        asyncio.run(main())
+
+
+if __name__ == '__main__':
+    unittest.main()
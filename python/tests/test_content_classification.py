@@ -0,0 +1,76 @@
+"""
+Test section content-type classification and front-matter round-tripping
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.text_utils import TextUtils
+from utils.frontmatter import render_front_matter, split_front_matter
+
+
+class TestContentClassification(unittest.TestCase):
+    """Test rule-based content-type classification"""
+
+    def test_prose(self):
+        content = "\n".join([
+            "The authentication service issues short-lived tokens to every client that registers.",
+            "Clients must refresh these tokens before they expire to avoid interrupted sessions.",
+            "Each token is bound to a single device and cannot be transferred between devices.",
+        ])
+        self.assertEqual(TextUtils.classify_content_type(content), 'prose')
+
+    def test_tabular(self):
+        content = "\n".join([
+            "| Code | Meaning |",
+            "|------|---------|",
+            "| 200 | OK |",
+            "| 404 | Not Found |",
+        ])
+        self.assertEqual(TextUtils.classify_content_type(content), 'tabular')
+
+    def test_code_heavy(self):
+        content = "\n".join([
+            "Example request:",
+            "```python",
+            "import requests",
+            "response = requests.get(url)",
+            "print(response.json())",
+            "```",
+        ])
+        self.assertEqual(TextUtils.classify_content_type(content), 'code-heavy')
+
+    def test_reference(self):
+        content = "\n".join([
+            "Timeout: 30 seconds",
+            "Retries: 3",
+            "- Region: us-east-1",
+            "- Region: eu-west-1",
+        ])
+        self.assertEqual(TextUtils.classify_content_type(content), 'reference')
+
+    def test_empty_is_mixed(self):
+        self.assertEqual(TextUtils.classify_content_type(""), 'mixed')
+
+
+class TestFrontMatter(unittest.TestCase):
+    """Test front-matter rendering and parsing"""
+
+    def test_round_trip(self):
+        fields = {'title': 'Intro: "Quoted"', 'section_id': 3, 'content_type': 'prose', 'prev': None}
+        text = render_front_matter(fields) + "# Intro\n\nBody"
+        parsed, body = split_front_matter(text)
+        self.assertEqual(parsed, fields)
+        self.assertEqual(body, "# Intro\n\nBody")
+
+    def test_no_front_matter(self):
+        parsed, body = split_front_matter("# Title\n\n---\n\nBody")
+        self.assertEqual(parsed, {})
+        self.assertEqual(body, "# Title\n\n---\n\nBody")
+
+
+if __name__ == '__main__':
+    unittest.main()
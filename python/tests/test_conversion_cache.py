@@ -0,0 +1,68 @@
+"""
+Test the cache key that lets use_cache skip unchanged conversions
+"""
+import unittest
+import tempfile
+import shutil
+import json
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.conversion_cache import conversion_cache_key, find_cached_conversion, options_hash
+from utils.output_layout import OutputLayout
+
+
+class TestOptionsHash(unittest.TestCase):
+    """Test which option changes invalidate the cache"""
+
+    def test_key_order_ignored(self):
+        self.assertEqual(options_hash({'a': 1, 'b': [1, 2]}), options_hash({'b': [1, 2], 'a': 1}))
+
+    def test_shaping_option_changes_hash(self):
+        self.assertNotEqual(options_hash({'page_range': "1-5"}), options_hash({'page_range': "1-6"}))
+
+    def test_non_shaping_options_ignored(self):
+        self.assertEqual(options_hash({'page_range': "1-5"}),
+                         options_hash({'page_range': "1-5", 'password': "secret", 'timeout_seconds': 30}))
+
+
+class TestFindCachedConversion(unittest.TestCase):
+    """Test matching the manifest.json of an earlier conversion"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+        self.pdf_path = self.temp_dir / "spec.pdf"
+        self.pdf_path.write_bytes(b"%PDF-1.4 first revision")
+        self.layout = OutputLayout(None, str(self.temp_dir / "docs"), "spec", doc_type='pdf')
+        self.layout.document_root().mkdir(parents=True)
+        self.options = {'output_format': 'markdown', 'page_range': None}
+        self.key = conversion_cache_key(str(self.pdf_path), self.options)
+        manifest = {'document_id': "spec", 'cache_key': self.key}
+        (self.layout.document_root() / "manifest.json").write_text(json.dumps(manifest))
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_hit(self):
+        manifest = find_cached_conversion(self.layout, conversion_cache_key(str(self.pdf_path), dict(self.options)))
+        self.assertEqual(manifest['document_id'], "spec")
+
+    def test_changed_pdf_misses(self):
+        self.pdf_path.write_bytes(b"%PDF-1.4 second revision")
+        self.assertIsNone(find_cached_conversion(self.layout, conversion_cache_key(str(self.pdf_path), self.options)))
+
+    def test_changed_options_miss(self):
+        options = dict(self.options, output_format='asciidoc')
+        self.assertIsNone(find_cached_conversion(self.layout, conversion_cache_key(str(self.pdf_path), options)))
+
+    def test_no_manifest_misses(self):
+        (self.layout.document_root() / "manifest.json").unlink()
+        self.assertIsNone(find_cached_conversion(self.layout, self.key))
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,129 @@
+"""
+Test the typed list of files a conversion produced
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.output_layout import OutputLayout
+from utils.conversion_manifest import build_conversion_manifest
+from utils.file_utils import FileUtils
+
+try:
+    import fitz  # noqa: F401
+    from modular_pdf_converter import ModularPDFConverter
+    HAS_CONVERTER = True
+except ImportError:
+    HAS_CONVERTER = False
+
+SPLIT_WORDS_PDF = Path(__file__).parent / "fixtures" / "split_words.pdf"
+
+
+class FixedCounter:
+    """One token per word"""
+
+    def count_tokens(self, text):
+        return len(text.split())
+
+
+class TestConversionManifest(unittest.TestCase):
+    """Test walking and classifying the output directories"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+        self.base = Path(self.temp_dir)
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def write_conversion(self, layout):
+        for artifact_type, filename, content in (('root', 'README.md', 'one two three'),
+                                                 ('root', 'manifest.json', '{}'),
+                                                 ('sections', '01-overview.md', 'four words in here'),
+                                                 ('chunked', 'chunk-001.md', 'chunk'),
+                                                 ('images', 'img-001.png', 'PNG')):
+            path = layout.path_for(artifact_type, filename)
+            path.parent.mkdir(parents=True, exist_ok=True)
+            path.write_text(content)
+
+    def test_nested_layout(self):
+        layout = OutputLayout(None, str(self.base), "my_doc")
+        self.write_conversion(layout)
+        result = build_conversion_manifest(layout, token_counter=FixedCounter()).to_dict()
+
+        files = {entry['path']: entry for entry in result['files']}
+        self.assertEqual(set(files), {'README.md', 'manifest.json', 'sections/01-overview.md',
+                                      'chunked/chunk-001.md', 'images/img-001.png'})
+        self.assertEqual(files['README.md']['kind'], 'index')
+        self.assertEqual(files['sections/01-overview.md'], {'path': 'sections/01-overview.md', 'kind': 'section',
+                                                             'bytes': 18, 'tokens': 4})
+        self.assertEqual(files['chunked/chunk-001.md']['kind'], 'chunk')
+        self.assertEqual(files['images/img-001.png']['kind'], 'image')
+        self.assertIsNone(files['images/img-001.png']['tokens'])
+        self.assertEqual(result['kinds'], {'index': 2, 'section': 1, 'chunk': 1, 'image': 1})
+        self.assertEqual(result['file_count'], 5)
+        self.assertEqual(result['total_tokens'], 3 + 1 + 4 + 1)
+
+    def test_nested_chunk_layouts(self):
+        layout = OutputLayout(None, str(self.base), "my_doc")
+        for name in ("01-overview/chunk-001.md", "small/01-overview-chunk-001.md"):
+            path = layout.path_for('chunked', name)
+            path.parent.mkdir(parents=True, exist_ok=True)
+            path.write_text("chunk")
+
+        kinds = {entry.path: entry.kind for entry in build_conversion_manifest(layout).files}
+        self.assertEqual(kinds, {'chunked/01-overview/chunk-001.md': 'chunk',
+                                 'chunked/small/01-overview-chunk-001.md': 'chunk'})
+
+    def test_flat_layout_still_finds_sections(self):
+        layout = OutputLayout('flat', str(self.base), "my_doc")
+        path = layout.path_for('sections', '01-overview.md')
+        path.parent.mkdir(parents=True)
+        path.write_text("text")
+        layout.path_for('root', 'README.md').write_text("map")
+
+        kinds = {entry.path: entry.kind for entry in build_conversion_manifest(layout).files}
+        self.assertEqual(kinds, {'01-overview.md': 'section', 'README.md': 'index'})
+
+    def test_artifacts_outside_document_root(self):
+        layout = OutputLayout('by_artifact', str(self.base), "my_doc")
+        self.write_conversion(layout)
+
+        paths = [entry.path for entry in build_conversion_manifest(layout).files]
+        self.assertIn('../images/my_doc/img-001.png', paths)
+        self.assertEqual(len(paths), len(set(paths)))
+
+
+@unittest.skipUnless(HAS_CONVERTER, "PyMuPDF and converter dependencies are required")
+class TestConverterManifest(unittest.TestCase):
+    """Test that a conversion lists only the files it wrote"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_files_already_there_are_not_listed(self):
+        layout = OutputLayout(None, self.temp_dir, FileUtils.sanitize_folder_name(SPLIT_WORDS_PDF.name))
+        stale = layout.path_for('sections', "99-stale.md")
+        stale.parent.mkdir(parents=True)
+        stale.write_text("left by an earlier conversion")
+
+        result = ModularPDFConverter(str(SPLIT_WORDS_PDF), self.temp_dir, {}).convert()
+
+        paths = [entry['path'] for entry in result['conversion_manifest']['files']]
+        self.assertIn('README.md', paths)
+        self.assertNotIn('sections/99-stale.md', paths)
+        self.assertTrue(stale.exists())
+        self.assertEqual(result['conversion_manifest']['output_directory'], result['output_directory'])
+
+
+if __name__ == '__main__':
+    unittest.main()
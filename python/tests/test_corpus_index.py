@@ -0,0 +1,69 @@
+"""
+Test the shared corpus index under concurrent writers
+"""
+import unittest
+import tempfile
+import shutil
+import json
+from concurrent.futures import ProcessPoolExecutor
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.corpus_index import corpus_document_id, update_corpus_index
+
+
+def _write_entry(args):
+    index_path, n = args
+    update_corpus_index(index_path, {'document_id': f"doc{n:03d}", 'source_file': f"doc{n:03d}.pdf"})
+
+
+class TestCorpusIndex(unittest.TestCase):
+    """Test corpus index updates"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+        self.index_path = str(Path(self.temp_dir) / "corpus-index.json")
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def read_index(self):
+        with open(self.index_path, encoding='utf-8') as f:
+            return json.load(f)
+
+    def test_reconversion_replaces_entry(self):
+        update_corpus_index(self.index_path, {'document_id': 'a', 'source_file': 'a.pdf', 'section_count': 1})
+        update_corpus_index(self.index_path, {'document_id': 'a', 'source_file': 'a.pdf', 'section_count': 2})
+        index = self.read_index()
+        self.assertEqual(index['document_count'], 1)
+        self.assertEqual(index['documents'][0]['section_count'], 2)
+
+    def test_same_name_in_different_folders_keeps_both(self):
+        for folder in ("q1", "q2"):
+            (Path(self.temp_dir) / folder).mkdir()
+            source = Path(self.temp_dir) / folder / "report.pdf"
+            update_corpus_index(self.index_path, {'document_id': corpus_document_id(str(source)),
+                                                  'source_file': source.name})
+        self.assertEqual(self.read_index()['document_count'], 2)
+        source = Path(self.temp_dir) / "q1" / "report.pdf"
+        self.assertEqual(corpus_document_id(str(source)),
+                         corpus_document_id(str(Path(self.temp_dir) / "q2" / ".." / "q1" / "report.pdf")))
+
+    def test_entry_requires_document_id(self):
+        with self.assertRaises(ValueError):
+            update_corpus_index(self.index_path, {'source_file': 'a.pdf'})
+
+    def test_concurrent_writers_keep_every_entry(self):
+        with ProcessPoolExecutor(max_workers=4) as pool:
+            list(pool.map(_write_entry, [(self.index_path, n) for n in range(20)]))
+        index = self.read_index()
+        self.assertEqual(index['document_count'], 20)
+        self.assertEqual(len({d['document_id'] for d in index['documents']}), 20)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,79 @@
+"""
+Test strikethrough and underline detection
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.decorations import (
+    apply_decorations, decorated_runs, horizontal_rules, underline_markers, word_decorations
+)
+
+# "The fee is ten twelve dollars" on one line: 11-point-high word boxes from y=100 to y=111
+WORDS = [
+    (72, 100, 90, 111, "The", 0, 0, 0),
+    (93, 100, 108, 111, "fee", 0, 0, 1),
+    (111, 100, 119, 111, "is", 0, 0, 2),
+    (122, 100, 138, 111, "ten", 0, 0, 3),
+    (141, 100, 170, 111, "twelve", 0, 0, 4),
+    (173, 100, 210, 111, "dollars", 0, 0, 5),
+]
+TEXT = "The fee is ten twelve dollars"
+
+
+def _marked(rules, annotations=(), marker='<u>'):
+    runs = decorated_runs(WORDS, word_decorations(WORDS, rules, annotations))
+    return apply_decorations(TEXT, runs, marker)
+
+
+class TestRules(unittest.TestCase):
+    """Test finding drawn lines"""
+
+    def test_lines_and_hairline_rectangles(self):
+        drawings = [
+            {'items': [('l', (122, 106), (138, 106))]},
+            {'items': [('re', (141, 109.5, 170, 110.3))]},
+            {'items': [('l', (50, 50), (50, 90))]},  # vertical
+            {'items': [('re', (72, 200, 200, 260))]},  # a filled box
+        ]
+        self.assertEqual(horizontal_rules(drawings), [(122, 138, 106), (141, 170, 109.9)])
+
+
+class TestDecorations(unittest.TestCase):
+    """Test marking decorated words in page text"""
+
+    def test_strike_and_underline(self):
+        text, counts = _marked([(122, 138, 106.5), (141, 170, 110)])
+        self.assertEqual(text, "The fee is ~~ten~~ <u>twelve</u> dollars")
+        self.assertEqual(counts, {'strikethrough': 1, 'underline': 1})
+
+    def test_adjacent_words_form_one_run(self):
+        text, _ = _marked([(93, 138, 106.5)])
+        self.assertEqual(text, "The ~~fee is ten~~ twelve dollars")
+
+    def test_table_border_is_not_an_underline(self):
+        text, counts = _marked([(60, 230, 110)])
+        self.assertEqual(text, TEXT)
+        self.assertEqual(counts, {'strikethrough': 0, 'underline': 0})
+
+    def test_annotations(self):
+        text, _ = _marked([], [('StrikeOut', (121, 100, 139, 111)), ('Highlight', (72, 100, 90, 111))])
+        self.assertEqual(text, "The fee is ~~ten~~ twelve dollars")
+
+    def test_underline_markers(self):
+        self.assertEqual(underline_markers('<ins class="added">'), ('<ins class="added">', '</ins>'))
+        self.assertEqual(underline_markers('++'), ('++', '++'))
+        text, counts = _marked([(141, 170, 110)], marker='')
+        self.assertEqual((text, counts['underline']), (TEXT, 0))
+
+    def test_fenced_code_left_unmarked(self):
+        runs = decorated_runs(WORDS, word_decorations(WORDS, [(122, 138, 106.5)]))
+        fenced = f"```\n{TEXT}\n```\n"
+        self.assertEqual(apply_decorations(fenced, runs), (fenced, {'strikethrough': 0, 'underline': 0}))
+
+
+if __name__ == '__main__':
+    unittest.main()
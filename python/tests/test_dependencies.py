@@ -0,0 +1,80 @@
+"""
+Test the core dependency report
+"""
+import unittest
+from unittest.mock import patch
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.dependencies import check_dependencies, check_package, parse_version, version_at_least
+
+
+def fake_probe(installed, version):
+    return lambda module, distribution: {'module': module, 'distribution': distribution,
+                                         'installed': installed, 'version': version}
+
+
+class TestVersions(unittest.TestCase):
+    """Test version parsing and comparison"""
+
+    def test_parse(self):
+        self.assertEqual(parse_version('1.24.10'), (1, 24, 10))
+        self.assertEqual(parse_version('0.11.4rc1'), (0, 11, 4))
+        self.assertEqual(parse_version('2.0.dev3'), (2, 0))
+
+    def test_compare(self):
+        self.assertTrue(version_at_least('0.10.0', '0.10'))
+        self.assertTrue(version_at_least('0.11.4', '0.10.0'))
+        self.assertFalse(version_at_least('0.9.0', '0.10.0'))
+        self.assertFalse(version_at_least('1.22.5', '1.23.0'))
+
+
+class TestPackageStatus(unittest.TestCase):
+    """Test per-package status and messages"""
+
+    def test_ok(self):
+        with patch('utils.dependencies.probe_library', fake_probe(True, '0.11.0')):
+            package = check_package('pdfplumber', 'pdfplumber', '0.10.0')
+        self.assertEqual(package.status, 'ok')
+        self.assertIsNone(package.message)
+
+    def test_outdated_has_upgrade_command(self):
+        with patch('utils.dependencies.probe_library', fake_probe(True, '0.9.0')):
+            package = check_package('pdfplumber', 'pdfplumber', '0.10.0')
+        self.assertEqual(package.status, 'outdated')
+        self.assertIn("0.9.0 is older than the required 0.10.0", package.message)
+        self.assertIn("pip install --upgrade 'pdfplumber>=0.10.0'", package.message)
+
+    def test_missing(self):
+        with patch('utils.dependencies.probe_library', fake_probe(False, None)):
+            package = check_package('fitz', 'PyMuPDF', '1.23.0')
+        self.assertEqual(package.status, 'missing')
+        self.assertIn("pip install 'PyMuPDF>=1.23.0'", package.message)
+
+    def test_unknown_version_is_not_a_problem(self):
+        with patch('utils.dependencies.probe_library', fake_probe(True, None)):
+            package = check_package('PIL', 'pillow', '10.0.0')
+        self.assertEqual(package.status, 'unknown_version')
+
+
+class TestDependencyReport(unittest.TestCase):
+    """Test the machine-readable report"""
+
+    def test_report_shape(self):
+        report = check_dependencies([('json', 'json', '0.0'), ('no_such_module_xyz', 'no-such', '1.0')])
+        self.assertFalse(report.ok)
+        self.assertEqual(set(report.versions()), {'json', 'no-such'})
+        result = report.to_dict()
+        self.assertEqual(result['packages']['no-such']['status'], 'missing')
+        self.assertEqual(len(result['problems']), 1)
+
+    def test_default_packages(self):
+        report = check_dependencies()
+        self.assertEqual(set(report.packages), {'pypdf', 'pdfplumber', 'PyMuPDF', 'pandas', 'pillow'})
+
+
+if __name__ == '__main__':
+    unittest.main()
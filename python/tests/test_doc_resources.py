@@ -0,0 +1,97 @@
+"""
+Test listing and reading converted files as MCP resources
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.doc_resources import list_resources, read_resource, remember_output, remembered_files
+
+
+class TestDocResources(unittest.TestCase):
+    """Test resources over an output directory"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+        self.root = (self.temp_dir / "docs").resolve()
+        sections = self.root / "spec" / "sections"
+        sections.mkdir(parents=True)
+        (sections / "03-intro.md").write_text("# Intro\n", encoding="utf-8")
+        (self.root / "spec" / "manifest.json").write_text("{}", encoding="utf-8")
+        (self.root / "spec" / "images").mkdir()
+        (self.root / "spec" / "images" / "logo.png").write_bytes(b"\x89PNG")
+        (self.temp_dir / "secret.md").write_text("outside", encoding="utf-8")
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_list_generated_text_files(self):
+        entries = list_resources([self.root])
+        self.assertEqual([entry['name'] for entry in entries],
+                         [os.path.join("spec", "manifest.json"), os.path.join("spec", "sections", "03-intro.md")])
+        intro = entries[1]
+        self.assertEqual(intro['uri'], (self.root / "spec" / "sections" / "03-intro.md").as_uri())
+        self.assertEqual(intro['mime_type'], "text/markdown")
+        self.assertEqual(intro['size'], 8)
+
+    def test_read_listed_file(self):
+        uri = (self.root / "spec" / "sections" / "03-intro.md").as_uri()
+        self.assertEqual(read_resource(uri, [self.root]), ("# Intro\n", "text/markdown"))
+
+    def test_read_refuses_outside_and_other_types(self):
+        with self.assertRaisesRegex(ValueError, "outside"):
+            read_resource((self.temp_dir / "secret.md").as_uri(), [self.root])
+        with self.assertRaisesRegex(ValueError, "outside"):
+            read_resource((self.root / ".." / "secret.md").as_uri(), [self.root])
+        with self.assertRaisesRegex(ValueError, "Not a generated text file"):
+            read_resource((self.root / "spec" / "images" / "logo.png").as_uri(), [self.root])
+        with self.assertRaisesRegex(ValueError, "file://"):
+            read_resource("https://example.com/a.md", [self.root])
+        with self.assertRaises(FileNotFoundError):
+            read_resource((self.root / "spec" / "missing.md").as_uri(), [self.root])
+
+    def test_staging_folder_not_listed_or_read(self):
+        staged = self.root / ".staging-abc123" / "spec" / "sections"
+        staged.mkdir(parents=True)
+        (staged / "03-intro.md").write_text("# Half written\n", encoding="utf-8")
+        self.assertEqual(len(list_resources([self.root])), 2)
+        with self.assertRaisesRegex(ValueError, "outside"):
+            read_resource((staged / "03-intro.md").as_uri(), [self.root])
+
+
+class TestRememberedOutput(unittest.TestCase):
+    """Test that a conversion elsewhere exposes only the files it wrote"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp()).resolve()
+        self.home = self.temp_dir / "home"
+        (self.home / "spec" / "sections").mkdir(parents=True)
+        (self.home / "spec" / "sections" / "01-a.md").write_text("# A\n", encoding="utf-8")
+        (self.home / "spec" / "manifest.json").write_text("{}", encoding="utf-8")
+        (self.home / "notes.md").write_text("private", encoding="utf-8")
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_only_manifest_files_served(self):
+        manifest = {'output_directory': str(self.home / "spec"),
+                    'files': [{'path': "sections/01-a.md"}, {'path': "manifest.json"}]}
+        remember_output(str(self.home), {'success': True, 'conversion_manifest': manifest})
+        files = remembered_files()
+
+        # Other tests' conversions may be remembered too
+        names = [entry['name'] for entry in list_resources([], files) if entry['uri'].startswith(self.home.as_uri())]
+        self.assertEqual(names, [os.path.join("spec", "manifest.json"), os.path.join("spec", "sections", "01-a.md")])
+        self.assertEqual(read_resource((self.home / "spec" / "sections" / "01-a.md").as_uri(), [], files)[0], "# A\n")
+        with self.assertRaisesRegex(ValueError, "outside"):
+            read_resource((self.home / "notes.md").as_uri(), [], files)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,111 @@
+"""
+Test Word conversion output matches the PDF layout
+"""
+import unittest
+from unittest.mock import patch
+import base64
+import json
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.embedded_images import save_data_uri_images
+from utils.frontmatter import split_front_matter
+
+try:
+    from modular_docx_converter import ModularDocxConverter
+    HAS_DOCX_CONVERTER = True
+except ImportError:
+    HAS_DOCX_CONVERTER = False
+
+PNG_BYTES = b'\x89PNG\r\n\x1a\nnot-a-real-png'
+PNG_URI = f"data:image/png;base64,{base64.b64encode(PNG_BYTES).decode('ascii')}"
+
+
+class TestDataUriImages(unittest.TestCase):
+    """Test embedded images are written to files and relinked"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_image_written_and_linked(self):
+        markdown, images = save_data_uri_images(f"Intro\n\n![Logo]({PNG_URI})\n", self.temp_dir / "images",
+                                                "../images", name_prefix="section1_img")
+        self.assertEqual(markdown, "Intro\n\n![Logo](../images/section1_img1.png)\n")
+        self.assertEqual(len(images), 1)
+        self.assertEqual(images[0]['alt_text'], "Logo")
+        self.assertEqual(images[0]['mime_type'], "image/png")
+        self.assertEqual(Path(images[0]['file']).read_bytes(), PNG_BYTES)
+
+    def test_identical_images_share_a_file(self):
+        markdown, images = save_data_uri_images(f"![a]({PNG_URI}) ![b]({PNG_URI})", self.temp_dir, "img")
+        self.assertEqual(len(images), 1)
+        self.assertEqual(markdown, "![a](img/img1.png) ![b](img/img1.png)")
+
+    def test_jpeg_extension(self):
+        _, images = save_data_uri_images(f"![x](data:image/jpeg;base64,{base64.b64encode(b'jpg').decode()})",
+                                         self.temp_dir, "img")
+        self.assertTrue(images[0]['file'].endswith("img1.jpg"))
+
+    def test_undecodable_and_linked_images_left_alone(self):
+        text = "![broken](data:image/png;base64,abc) ![remote](https://example.com/a.png)"
+        markdown, images = save_data_uri_images(text, self.temp_dir / "images", "../images")
+        self.assertEqual(markdown, text)
+        self.assertEqual(images, [])
+        self.assertFalse((self.temp_dir / "images").exists())
+
+
+@unittest.skipUnless(HAS_DOCX_CONVERTER, "markitdown and converter dependencies are required")
+class TestDocxLayout(unittest.TestCase):
+    """Test the converter writes README.md, manifest.json, sections/, and images/"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+        self.docx = self.temp_dir / "Quarterly Report.docx"
+        self.docx.write_bytes(b"placeholder")
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def extraction(self):
+        sections = [
+            {'title': 'Overview', 'level': 1, 'section_type': 'introduction',
+             'content': f"The quarter went well.\n\n![Chart]({PNG_URI})"},
+            {'title': 'Revenue', 'level': 2, 'section_type': 'content', 'content': "Revenue grew."},
+        ]
+        return {'success': True, 'sections': sections, 'tables': [], 'images': [],
+                'stats': {'total_words': 6, 'total_tables': 0, 'total_images': 1}}
+
+    def test_same_layout_as_pdf(self):
+        converter = ModularDocxConverter(str(self.docx), str(self.temp_dir / "docs"))
+        with patch.object(converter.docx_extractor, 'extract_from_file', return_value=self.extraction()):
+            result = converter.convert()
+
+        self.assertTrue(result['success'], result.get('error'))
+        root = Path(result['output_directory'])
+        self.assertTrue((root / "README.md").exists())
+
+        manifest = json.loads((root / "manifest.json").read_text())
+        self.assertEqual(manifest['source_file'], "Quarterly Report.docx")
+        self.assertEqual([s['file'] for s in manifest['sections']],
+                         ["sections/01-Overview.md", "sections/02-Revenue.md"])
+        self.assertEqual(manifest['images'][0]['file'], "images/section1_img1.png")
+        self.assertTrue((root / "images" / "section1_img1.png").exists())
+
+        fields, body = split_front_matter((root / "sections" / "02-Revenue.md").read_text())
+        self.assertEqual(fields['section_id'], 2)
+        self.assertEqual(fields['prev'], "01-Overview.md")
+        self.assertEqual(fields['parent'], "01-Overview.md")
+        self.assertIn("](../images/section1_img1.png)", (root / "sections" / "01-Overview.md").read_text())
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,81 @@
+"""
+Test dry-run conversion plans
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.chunking_engine import estimate_bucket_chunks
+
+try:
+    import fitz  # noqa: F401
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+try:
+    from modular_pdf_converter import plan_conversion
+    HAS_CONVERTER = True
+except ImportError:
+    HAS_CONVERTER = False
+
+SPLIT_WORDS_PDF = Path(__file__).parent / "fixtures" / "split_words.pdf"
+
+
+class TestBucketEstimate(unittest.TestCase):
+    """Test chunks per size bucket"""
+
+    def test_ceiling_per_section(self):
+        chunks = estimate_bucket_chunks([100, 8000, 0])
+        self.assertEqual(chunks, {'small': 1 + 3 + 1, 'medium': 1 + 2 + 1, 'large': 3, 'xlarge': 3})
+
+    def test_no_sections(self):
+        self.assertEqual(estimate_bucket_chunks([]), {'small': 0, 'medium': 0, 'large': 0, 'xlarge': 0})
+
+
+@unittest.skipUnless(HAS_PYMUPDF and HAS_CONVERTER, "PyMuPDF and converter dependencies are required")
+class TestPlanConversion(unittest.TestCase):
+    """Test that a dry run reports the plan and writes nothing"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_plan_writes_nothing(self):
+        output_dir = self.temp_dir / "docs"
+        plan = plan_conversion(str(SPLIT_WORDS_PDF), str(output_dir), {'corpus_index_path': str(self.temp_dir / "index.json")})
+
+        self.assertTrue(plan['success'])
+        self.assertTrue(plan['dry_run'])
+        self.assertGreater(plan['sections'], 0)
+        self.assertGreater(plan['total_bytes'], 0)
+        self.assertEqual(set(plan['chunks']), {'small', 'medium', 'large', 'xlarge'})
+        self.assertEqual(Path(plan['output_directory']).parent, output_dir)
+        self.assertFalse(plan['output_exists'])
+        self.assertEqual(list(self.temp_dir.iterdir()), [])
+
+    def test_plan_for_a_page_range_with_chunks(self):
+        plan = plan_conversion(str(SPLIT_WORDS_PDF), str(self.temp_dir), {'page_range': "1", 'chunk_tokens': 500})
+
+        self.assertTrue(plan['estimated'])
+        self.assertEqual((plan['pages'], plan['text_pages_read']), (1, 1))
+        self.assertEqual(plan['page_range']['pages'], [1])
+        self.assertEqual(plan['kinds']['chunk'], plan['chunking']['total_chunks'])
+        self.assertEqual(plan['file_count'], sum(plan['kinds'].values()))
+
+    def test_page_range_past_the_end(self):
+        plan = plan_conversion(str(SPLIT_WORDS_PDF), str(self.temp_dir), {'page_range': "900-901"})
+        self.assertFalse(plan['success'])
+        self.assertIn("past the end", plan['error'])
+
+
+if __name__ == '__main__':
+    unittest.main()
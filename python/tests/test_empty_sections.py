@@ -0,0 +1,59 @@
+"""
+Test trimming and merging of heading-only sections
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.section_cleanup import is_heading_only, merge_empty_sections
+
+
+class TestEmptySections(unittest.TestCase):
+    """Test heading-only sections merge forward and content is trimmed"""
+
+    def test_heading_only_detection(self):
+        self.assertTrue(is_heading_only({'title': 'Part II', 'content': '\n\n   \n'}))
+        self.assertTrue(is_heading_only({'title': 'Part II', 'content': '\n  Part  II \n\n'}))
+        self.assertFalse(is_heading_only({'title': 'Part II', 'content': 'Part II\nThe second part.'}))
+
+    def test_images_and_tables_are_body(self):
+        self.assertFalse(is_heading_only({'title': 'Figure 2', 'content': 'Figure 2\n![Chart](images/p3.png)'}))
+        self.assertFalse(is_heading_only({'title': 'Rates', 'content': '| Tier | Rate |\n|---|---|\n| A | 1% |'}))
+        # Nothing in the text yet, but an extracted image sits on the section's page
+        self.assertFalse(is_heading_only({'title': 'Figure 2', 'content': '', 'pages': [3]}, media_pages={3}))
+        self.assertTrue(is_heading_only({'title': 'Part II', 'content': '', 'pages': [4]}, media_pages={3}))
+
+    def test_untitled_empty_section_is_not_heading_only(self):
+        self.assertFalse(is_heading_only({'title': '', 'content': ''}))
+
+    def test_merged_pages_join_the_receiving_section(self):
+        kept, _ = merge_empty_sections([{'title': 'Part II', 'content': '', 'pages': [4]},
+                                        {'title': 'Chapter 3', 'content': 'Body.', 'pages': [5, 6]}])
+        self.assertEqual(kept[0]['pages'], [4, 5, 6])
+
+    def test_heading_only_section_merges_forward(self):
+        sections = [
+            {'title': 'Introduction', 'content': '\n\n\nWelcome.\n\n'},
+            {'title': 'Part II', 'content': 'Part II'},
+            {'title': 'Chapter 3', 'content': 'Chapter body.'},
+        ]
+        kept, report = merge_empty_sections(sections)
+        self.assertEqual([s['title'] for s in kept], ['Introduction', 'Chapter 3'])
+        self.assertEqual(kept[0]['content'], 'Welcome.')
+        self.assertEqual(kept[1]['merged_headings'], ['Part II'])
+        self.assertEqual(report, [{'title': 'Part II', 'action': 'merged', 'into': 'Chapter 3'}])
+
+    def test_trailing_heading_only_section_is_dropped(self):
+        kept, report = merge_empty_sections([
+            {'title': 'Body', 'content': 'Text.'},
+            {'title': 'Index', 'content': ''},
+        ])
+        self.assertEqual([s['title'] for s in kept], ['Body'])
+        self.assertEqual(report, [{'title': 'Index', 'action': 'dropped', 'into': None}])
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,69 @@
+"""
+Test resolving the converter interpreter and the environment report
+"""
+import unittest
+from unittest import mock
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils import environment
+from utils.environment import (InterpreterNotFound, check_environment, converter_interpreter, format_environment,
+                               probe_interpreter)
+
+
+class TestInterpreter(unittest.TestCase):
+    """Test finding a Python interpreter for converter processes"""
+
+    def test_server_interpreter_first(self):
+        self.assertEqual(converter_interpreter(), sys.executable)
+
+    def test_falls_back_to_path(self):
+        with mock.patch.object(environment.sys, 'executable', ''), \
+                mock.patch.object(environment.shutil, 'which', lambda name: f"/usr/bin/{name}"):
+            self.assertEqual(converter_interpreter(), "/usr/bin/python3")
+
+    def test_fallback_is_probed(self):
+        with mock.patch.object(environment.sys, 'executable', ''), \
+                mock.patch.object(environment.shutil, 'which', lambda name: f"/usr/bin/{name}"), \
+                mock.patch.object(environment, 'probe_interpreter', lambda interpreter: ['fitz']):
+            report = check_environment()
+            self.assertFalse(report['usable'])
+            self.assertEqual(report['converter_missing'], ['fitz'])
+            self.assertIn("/usr/bin/python3, which can't import fitz", report['problems'][-1])
+
+    def test_probe_imports(self):
+        self.assertEqual(probe_interpreter(sys.executable, ['json', 'no_such_module_for_probe']),
+                         ['no_such_module_for_probe'])
+        self.assertEqual(probe_interpreter('/removed/python', ['json']), ['json'])
+
+    def test_no_interpreter_is_a_clear_error(self):
+        with mock.patch.object(environment.sys, 'executable', '/removed/python'), \
+                mock.patch.object(environment.shutil, 'which', lambda name: None):
+            with self.assertRaises(InterpreterNotFound) as raised:
+                converter_interpreter()
+            self.assertIn("python3, python", str(raised.exception))
+
+            report = check_environment()
+            self.assertFalse(report['usable'])
+            self.assertIsNone(report['converter_interpreter'])
+            self.assertIn("No Python interpreter found", report['problems'][0])
+            self.assertIn("no interpreter found", format_environment(report))
+
+
+class TestReport(unittest.TestCase):
+    """Test the environment report"""
+
+    def test_report_lists_packages(self):
+        report = check_environment()
+        self.assertEqual(report['python']['executable'], sys.executable)
+        self.assertIn('pypdf', report['packages'])
+        missing = [name for name, package in report['packages'].items() if package['status'] == 'missing']
+        self.assertEqual(report['missing'], missing)
+        self.assertEqual(report['usable'], not missing and not report['outdated'] and not report['problems'])
+
+
+if __name__ == '__main__':
+    unittest.main()
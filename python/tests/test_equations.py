@@ -0,0 +1,120 @@
+"""
+Test display equation detection and replacement
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.equations import (
+    LOW_CONFIDENCE_NOTE, apply_equations, equation_markdown, latex_looks_valid, line_score, page_equations,
+    validate_math_mode
+)
+
+
+def _line(y, spans, x0=100, x1=300):
+    return {'bbox': (x0, y, x1, y + 12), 'spans': [{'text': text, 'font': font} for text, font in spans]}
+
+
+def _page(*lines):
+    return {'blocks': [{'type': 0, 'lines': list(lines)}]}
+
+
+PROSE = _line(100, [("The energy of a body at rest follows from its mass:", 'Times-Roman')], 72, 500)
+NUMERATOR = _line(130, [("a", 'CMMI10'), ("+", 'CMR10'), ("b", 'CMMI10')], 180, 220)
+DENOMINATOR = _line(146, [("c", 'CMMI10'), ("2", 'CMR7')], 190, 210)
+AFTER = _line(180, [("where the constants are defined in the next section.", 'Times-Roman')], 72, 500)
+
+
+class TestDetection(unittest.TestCase):
+    """Test finding equation regions"""
+
+    def test_math_font_lines_score_high(self):
+        self.assertGreater(line_score(NUMERATOR['spans']), 0.5)
+        self.assertEqual(line_score(PROSE['spans']), 0.0)
+
+    def test_symbol_density_without_math_fonts(self):
+        spans = [{'text': "∑ x² ≤ ∞", 'font': 'ArialMT'}]
+        self.assertEqual(line_score(spans), 1.0)
+
+    def test_prose_with_an_equals_sign_is_text(self):
+        spans = [{'text': "Set the timeout value = 30 seconds before retrying", 'font': 'ArialMT'}]
+        self.assertEqual(line_score(spans), 0.0)
+
+    def test_fraction_lines_form_one_region(self):
+        equations = page_equations(_page(PROSE, NUMERATOR, DENOMINATOR, AFTER))
+        self.assertEqual(len(equations), 1)
+        self.assertEqual(equations[0]['lines'], ["a+b", "c2"])
+        self.assertEqual(equations[0]['bbox'], (180, 130, 220, 158))
+        self.assertGreaterEqual(equations[0]['confidence'], 0.75)
+
+    def test_distant_lines_are_separate_equations(self):
+        far = _line(300, [("x", 'CMMI10'), ("=", 'CMR10'), ("y", 'CMMI10')], 180, 220)
+        self.assertEqual(len(page_equations(_page(NUMERATOR, far))), 2)
+
+    def test_tiny_regions_are_ignored(self):
+        self.assertEqual(page_equations(_page(_line(100, [("x", 'CMMI10')]))), [])
+
+    def test_image_blocks_are_skipped(self):
+        self.assertEqual(page_equations({'blocks': [{'type': 1, 'bbox': (0, 0, 10, 10)}]}), [])
+
+
+class TestMarkdown(unittest.TestCase):
+    """Test the $$ blocks written in place of equations"""
+
+    def test_image_placeholder_links_the_crop(self):
+        lines = equation_markdown({'page': 3, 'index': 1, 'latex': None, 'low_confidence': False},
+                                  "../images/page003-equation01.png")
+        self.assertEqual(lines, ['$$', '\\text{Equation 1 on page 3 (see image)}', '$$',
+                                 '![Equation 1 on page 3](../images/page003-equation01.png)'])
+
+    def test_latex_is_written_between_delimiters(self):
+        lines = equation_markdown({'page': 3, 'index': 1, 'latex': 'E = mc^{2}', 'low_confidence': False}, "x.png")
+        self.assertEqual(lines, ['$$', 'E = mc^{2}', '$$'])
+
+    def test_low_confidence_is_marked_with_the_crop(self):
+        lines = equation_markdown({'page': 3, 'index': 2, 'latex': '\\frac{a}{b}', 'low_confidence': True}, "x.png")
+        self.assertEqual(lines[-1], f"{LOW_CONFIDENCE_NOTE} ![Equation 2 on page 3](x.png)")
+
+    def test_replaces_region_lines_in_page_text(self):
+        text = "Mass and energy:\na+b\nc2\nwhere the constants"
+        equations = page_equations(_page(NUMERATOR, DENOMINATOR))
+        text, applied = apply_equations(text, equations, lambda equation: ['$$', 'X', '$$'])
+        self.assertEqual(applied, 1)
+        self.assertEqual(text, "Mass and energy:\n\n$$\nX\n$$\n\nwhere the constants")
+
+    def test_render_only_called_for_equations_found(self):
+        rendered = []
+        equations = [{'lines': ['not in text'], 'bbox': (0, 0, 1, 1), 'confidence': 1.0}]
+        text, applied = apply_equations("plain page", equations, lambda equation: rendered.append(equation) or [])
+        self.assertEqual((text, applied, rendered), ("plain page", 0, []))
+
+
+class TestLatexCheck(unittest.TestCase):
+    """Test the sanity check on model output"""
+
+    def test_plausible_latex(self):
+        self.assertTrue(latex_looks_valid('\\frac{a+b}{c^{2}}'))
+        self.assertTrue(latex_looks_valid('\\left\\{ x \\right\\}'))
+
+    def test_malformed_latex(self):
+        self.assertFalse(latex_looks_valid(''))
+        self.assertFalse(latex_looks_valid('\\frac{a}{b'))
+        self.assertFalse(latex_looks_valid('}{'))
+        self.assertFalse(latex_looks_valid('x ' + '\\quad ' * 12))
+
+
+class TestMathMode(unittest.TestCase):
+    """Test math_mode validation"""
+
+    def test_modes(self):
+        self.assertEqual(validate_math_mode(None), 'off')
+        self.assertEqual(validate_math_mode('latex'), 'latex')
+        with self.assertRaises(ValueError):
+            validate_math_mode('mathml')
+
+
+if __name__ == '__main__':
+    unittest.main()
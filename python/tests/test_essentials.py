@@ -211,6 +211,20 @@ class TestEssentialFunctionality(unittest.TestCase):
         except Exception as e:
             self.fail(f"MCP analyze handler failed: {e}")
     
+    def test_mcp_failure_is_tool_error(self):
+        """Test that expected failures come back as isError tool results, not protocol errors"""
+        try:
+            from mcp_document_markdown import call_tool
+            
+            missing = str(self.temp_path / "missing.pdf")
+            for name in ("convert_pdf", "analyze_pdf_structure"):
+                result = asyncio.run(call_tool(name, {'pdf_path': missing}))
+                self.assertTrue(result.isError)
+                self.assertIn('PDF file not found', result.content[0].text)
+            
+        except Exception as e:
+            self.fail(f"MCP tool error handling failed: {e}")
+    
     @patch('pdf_to_rag.PDFToRAGProcessor')
     def test_mcp_rag_handler_works(self, mock_processor_class):
         """Test that the MCP RAG handler works with mocked processor"""
@@ -0,0 +1,61 @@
+"""
+Test feature availability probing
+"""
+import unittest
+from unittest.mock import patch
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.features import features_status, probe_feature, feature_by_name
+
+
+class TestFeaturesStatus(unittest.TestCase):
+    """Test status classification and remediation hints"""
+
+    def test_installed_library_is_available(self):
+        report = probe_feature({'name': 'stdlib', 'description': 'x', 'libraries': [('json', 'json')]})
+        self.assertEqual(report['status'], 'available')
+        self.assertIsNone(report['remediation'])
+
+    def test_missing_library_with_fallback_is_degraded(self):
+        feature = {'name': 'f', 'description': 'x', 'libraries': [('no_such_module_xyz', 'no-such')],
+                   'fallback': 'approximate', 'remediation': 'pip install no-such'}
+        report = probe_feature(feature)
+        self.assertEqual(report['status'], 'degraded')
+        self.assertEqual(report['fallback'], 'approximate')
+        self.assertEqual(report['remediation'], 'pip install no-such')
+
+    def test_docx_conversion_checks_markitdown_and_mammoth(self):
+        feature = feature_by_name('docx_conversion')
+        self.assertEqual([module for module, _ in feature['libraries']], ['markitdown', 'mammoth'])
+        self.assertIn('markitdown[all]', feature['remediation'])
+        with self.assertRaises(KeyError):
+            feature_by_name('no_such_feature')
+
+    def test_missing_binary_is_unavailable(self):
+        report = probe_feature({'name': 'f', 'description': 'x', 'binaries': ['no-such-binary-xyz']})
+        self.assertEqual(report['status'], 'unavailable')
+
+    def test_unset_and_unreachable_endpoints(self):
+        feature = {'name': 'f', 'description': 'x', 'endpoint_env': 'TEST_FEATURE_ENDPOINT'}
+        with patch.dict(os.environ, {}, clear=False):
+            os.environ.pop('TEST_FEATURE_ENDPOINT', None)
+            self.assertEqual(probe_feature(feature)['status'], 'not_configured')
+        with patch.dict(os.environ, {'TEST_FEATURE_ENDPOINT': 'http://127.0.0.1:9/'}):
+            report = probe_feature(feature, timeout=1)
+            self.assertEqual(report['status'], 'unavailable')
+            self.assertFalse(report['endpoint']['reachable'])
+
+    def test_summary_counts(self):
+        status = features_status(features=[
+            {'name': 'a', 'description': 'x', 'libraries': [('json', 'json')]},
+            {'name': 'b', 'description': 'x', 'binaries': ['no-such-binary-xyz']},
+        ])
+        self.assertEqual(status['summary'], {'available': 1, 'unavailable': 1})
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -45,11 +45,11 @@ class TestFileSanitization(unittest.TestCase):
             self.assertEqual(result, expected, f"Failed for input: {input_name}")
     
     def test_non_ascii_character_removal(self):
-        """Test removal of non-ASCII characters"""
+        """Test transliteration of accented letters and removal of other non-ASCII characters"""
         test_cases = [
-            ("Café_Menu_2024.pdf", "caf_menu_2024"),
+            ("Café_Menu_2024.pdf", "cafe_menu_2024"),
             ("日本語ドキュメント.pdf", ""),  # Should default to "converted_pdf"
-            ("Résumé_Template.pdf", "rsum_template"),
+            ("Résumé_Template.pdf", "resume_template"),
             ("Document™_©2024.pdf", "document_2024"),
         ]
         
@@ -125,5 +125,24 @@ class TestFileSanitization(unittest.TestCase):
             result = FileUtils.sanitize_folder_name(input_name)
             self.assertEqual(result, expected, f"Failed for input: {input_name}")
 
+class TestSectionFileNames(unittest.TestCase):
+    """Test names generated for section files"""
+    
+    def test_unicode_titles_transliterated(self):
+        self.assertEqual(FileUtils.safe_filename("Über uns"), "Uber-uns")
+        self.assertEqual(FileUtils.safe_filename("Straße der Æsir"), "Strasse-der-AEsir")
+        self.assertEqual(FileUtils.safe_filename("日本語 ガイド"), "日本語-ガイド")
+    
+    def test_punctuation_only_title_is_empty(self):
+        for title in ("", "!!!", "?*/", "—"):
+            self.assertEqual(FileUtils.safe_filename(title), "", title)
+    
+    def test_duplicate_names_get_suffixes(self):
+        taken = set()
+        names = [FileUtils.unique_filename(name, taken)
+                 for name in ("01-Uber-uns.md", "01-Uber-uns.md", "01-uber-uns.md", "README")]
+        self.assertEqual(names, ["01-Uber-uns.md", "01-Uber-uns-2.md", "01-uber-uns-3.md", "README"])
+        self.assertEqual(FileUtils.unique_filename("README", taken), "README-2")
+
 if __name__ == '__main__':
     unittest.main()
\ No newline at end of file
@@ -0,0 +1,58 @@
+"""
+Test document fingerprint comparison
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.fingerprint import compute_fingerprint, compare_fingerprints, hash_page_text
+
+
+class TestFingerprint(unittest.TestCase):
+    """Test fingerprint computation and page-level change detection"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+        self.pdf = Path(self.temp_dir) / "doc.pdf"
+        self.pdf.write_bytes(b"%PDF-1.4 fake")
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def fingerprint(self, pages):
+        return compute_fingerprint(str(self.pdf), [hash_page_text(p) for p in pages])
+
+    def test_whitespace_does_not_change_hash(self):
+        self.assertEqual(hash_page_text("Hello   world\n"), hash_page_text("Hello world"))
+
+    def test_unchanged(self):
+        result = compare_fingerprints(self.fingerprint(["a", "b"]), self.fingerprint(["a", "b"]))
+        self.assertFalse(result['changed'])
+        self.assertTrue(result['identical_file'])
+        self.assertEqual(result['unchanged_page_count'], 2)
+
+    def test_changed_added_removed(self):
+        prior = self.fingerprint(["a", "b", "c"])
+        result = compare_fingerprints(prior, self.fingerprint(["a", "B", "c", "d"]))
+        self.assertTrue(result['changed'])
+        self.assertEqual(result['changed_pages'], [2])
+        self.assertEqual(result['added_pages'], [4])
+        self.assertEqual(result['removed_pages'], [])
+
+        result = compare_fingerprints(prior, self.fingerprint(["a"]))
+        self.assertEqual(result['removed_pages'], [2, 3])
+
+    def test_version_mismatch_rejected(self):
+        prior = dict(self.fingerprint(["a"]), version=0)
+        with self.assertRaises(ValueError):
+            compare_fingerprints(prior, self.fingerprint(["a"]))
+
+
+if __name__ == '__main__':
+    unittest.main()
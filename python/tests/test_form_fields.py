@@ -0,0 +1,112 @@
+"""
+Test reading interactive form fields and rendering them as a section
+"""
+import unittest
+import tempfile
+import shutil
+import json
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.form_fields import (extract_form_fields, field_type, field_value, form_fields_markdown,
+                                    form_fields_summary)
+
+try:
+    import pypdf  # noqa: F401
+    HAS_PYPDF = True
+except ImportError:
+    HAS_PYPDF = False
+
+try:
+    import fitz  # noqa: F401
+    from modular_pdf_converter import ModularPDFConverter
+    HAS_CONVERTER = True
+except ImportError:
+    HAS_CONVERTER = False
+
+FORM_PDF = Path(__file__).parent / "fixtures" / "filled_form.pdf"
+SPLIT_WORDS_PDF = Path(__file__).parent / "fixtures" / "split_words.pdf"
+
+
+class TestFieldValues(unittest.TestCase):
+    """Test field types and values as read from the field dictionaries"""
+
+    def test_types_from_flags(self):
+        self.assertEqual(field_type('/Tx'), 'text')
+        self.assertEqual(field_type('/Btn'), 'checkbox')
+        self.assertEqual(field_type('/Btn', 49152), 'radio')
+        self.assertEqual(field_type('/Btn', 65536), 'button')
+        self.assertEqual(field_type('/Ch', 131072), 'dropdown')
+        self.assertEqual(field_type('/Ch'), 'list')
+        self.assertEqual(field_type('/Sig'), 'signature')
+        self.assertIsNone(field_type(None))
+
+    def test_values(self):
+        self.assertTrue(field_value('checkbox', '/Yes'))
+        self.assertFalse(field_value('checkbox', '/Off'))
+        self.assertFalse(field_value('checkbox', None))
+        self.assertEqual(field_value('radio', '/married'), 'married')
+        self.assertIsNone(field_value('radio', '/Off'))
+        self.assertEqual(field_value('list', ['CA', 'NY']), ['CA', 'NY'])
+        self.assertIsNone(field_value('text', None))
+
+    def test_markdown_table(self):
+        fields = [{'name': "applicant.name", 'type': 'text', 'value': "Jane | Doe\nJr.", 'label': "Full name",
+                   'page': 1},
+                  {'name': "agree", 'type': 'checkbox', 'value': False, 'label': None, 'page': None}]
+        markdown = form_fields_markdown(fields)
+        self.assertIn("| applicant.name | Full name | text | Jane \\| Doe Jr. | 1 |", markdown)
+        self.assertIn("| agree |  | checkbox | unchecked |  |", markdown)
+        self.assertEqual(form_fields_summary(fields), {'fields': 2, 'filled': 1,
+                                                       'types': {'text': 1, 'checkbox': 1}})
+
+
+@unittest.skipUnless(HAS_PYPDF, "pypdf not installed")
+class TestFormFixture(unittest.TestCase):
+    """Test the fields of filled_form.pdf"""
+
+    def test_fields(self):
+        fields = {field['name']: field for field in extract_form_fields(str(FORM_PDF))}
+        self.assertEqual(list(fields), ["applicant.name", "agree", "status", "state", "notes"])
+        self.assertEqual((fields["applicant.name"]['value'], fields["applicant.name"]['label']),
+                         ("Jane Doe", "Full name"))
+        self.assertTrue(fields["agree"]['value'])
+        self.assertEqual((fields["status"]['value'], fields["status"]['options']), ("married", ["single", "married"]))
+        self.assertEqual((fields["state"]['type'], fields["state"]['options']), ('dropdown', ["California", "New York"]))
+        self.assertEqual((fields["notes"]['value'], fields["notes"]['page']), (None, 2))
+
+    def test_pdf_without_form(self):
+        self.assertEqual(extract_form_fields(str(SPLIT_WORDS_PDF)), [])
+
+
+@unittest.skipUnless(HAS_PYPDF and HAS_CONVERTER, "pypdf, PyMuPDF, and the converter are required")
+class TestFormFieldsSection(unittest.TestCase):
+    """Test the Form Fields section and conversion-metadata.json"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_section_and_metadata(self):
+        result = ModularPDFConverter(str(FORM_PDF), str(self.temp_dir), {'extract_form_fields': True}).convert()
+        self.assertEqual(result['form_fields']['fields'], 5)
+        section_files = list(Path(result['sections_directory']).glob("*form-fields*.md"))
+        self.assertEqual(len(section_files), 1)
+        self.assertIn("| applicant.name | Full name | text | Jane Doe | 1 |", section_files[0].read_text(encoding='utf-8'))
+        metadata = json.loads((Path(result['output_directory']) / "conversion-metadata.json").read_text(encoding='utf-8'))
+        self.assertEqual(len(metadata['form_fields']), 5)
+
+    def test_no_section_without_fields(self):
+        result = ModularPDFConverter(str(SPLIT_WORDS_PDF), str(self.temp_dir), {'extract_form_fields': True}).convert()
+        self.assertEqual(result['form_fields']['fields'], 0)
+        self.assertEqual(list(Path(result['sections_directory']).glob("*form-fields*.md")), [])
+
+
+if __name__ == '__main__':
+    unittest.main()
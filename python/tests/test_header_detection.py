@@ -0,0 +1,100 @@
+"""
+Test heading detection from font size and weight
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.header_detection import (
+    detect_font_headers, header_confidence, score_header_lines, validate_header_confidence
+)
+
+try:
+    import fitz  # noqa: F401
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+
+def line(text, size, bold=False, page=1):
+    return {'page': page, 'text': text, 'size': size, 'bold': bold}
+
+
+BODY = "Transactions are authorized by the issuer before the terminal completes them."
+
+
+class TestHeaderConfidence(unittest.TestCase):
+    """Test scoring against the page body size"""
+
+    def test_body_size_caps_line_is_not_a_heading(self):
+        self.assertEqual(header_confidence(line("EMV ARQC TVR CVM", 10), 10), 0.0)
+
+    def test_larger_bold_short_line_is_confident(self):
+        self.assertEqual(header_confidence(line("Card Authentication", 15, bold=True), 10), 1.0)
+
+    def test_slightly_larger_sentence_is_weak(self):
+        self.assertLess(header_confidence(line(BODY, 11.5), 10), 0.3)
+
+
+class TestScoreHeaderLines(unittest.TestCase):
+    """Test candidates and levels across pages"""
+
+    def test_levels_follow_sizes(self):
+        pages = [
+            [line("1 Overview", 18, True), line("EMV ARQC TVR", 10), line(BODY, 10), line(BODY, 10)],
+            [line("1.1 Scope", 14, True, page=2), line(BODY, 10, page=2), line("1.2 Terms", 14.2, True, page=2)],
+        ]
+        headers = score_header_lines(pages)
+        self.assertEqual(sorted(headers), ["1 Overview", "1.1 Scope", "1.2 Terms"])
+        self.assertEqual([headers[key]['level'] for key in ("1 Overview", "1.1 Scope", "1.2 Terms")], [1, 2, 2])
+        self.assertEqual(headers["1.1 Scope"]['page'], 2)
+
+    def test_empty_page(self):
+        self.assertEqual(score_header_lines([[]]), {})
+
+
+class TestValidation(unittest.TestCase):
+    """Test min_header_confidence checks"""
+
+    def test_valid(self):
+        self.assertEqual(validate_header_confidence("0.5"), 0.5)
+
+    def test_invalid(self):
+        for value in (-0.1, 1.5, "high", None):
+            with self.assertRaises(ValueError):
+                validate_header_confidence(value)
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestDetectFontHeaders(unittest.TestCase):
+    """Test detection on a generated PDF"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+        self.pdf_path = str(Path(self.temp_dir) / "headings.pdf")
+        doc = fitz.open()
+        page = doc.new_page()
+        page.insert_text((72, 72), "Card Authentication", fontsize=18, fontname="helvetica-bold")
+        page.insert_text((72, 100), "API URL HTTP JSON", fontsize=10)
+        for row in range(5):
+            page.insert_text((72, 120 + row * 14), BODY, fontsize=10)
+        doc.save(self.pdf_path)
+        doc.close()
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_only_the_large_line_is_a_heading(self):
+        headers = detect_font_headers(self.pdf_path)
+        self.assertEqual(list(headers), ["Card Authentication"])
+        self.assertGreaterEqual(headers["Card Authentication"]['confidence'], 0.9)
+
+
+if __name__ == '__main__':
+    unittest.main()
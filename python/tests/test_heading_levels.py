@@ -0,0 +1,63 @@
+"""
+Test normalizing heading levels in section files
+"""
+import unittest
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.heading_levels import normalize_headings, validate_heading_offset
+
+NESTED_HEADINGS_MD = Path(__file__).parent / "fixtures" / "nested_headings.md"
+
+
+def headings(markdown):
+    """Heading lines outside the fixture's code block, in order"""
+    body = markdown.split("```python")[0] + markdown.split("```\n")[-1]
+    return [line for line in body.split("\n") if line.startswith("#")]
+
+
+class TestNormalizeHeadings(unittest.TestCase):
+    """Test single H1 and consistent demotion on the nested-headings fixture"""
+
+    def setUp(self):
+        self.markdown = NESTED_HEADINGS_MD.read_text(encoding="utf-8")
+
+    def test_single_h1_and_nesting_kept(self):
+        normalized = normalize_headings(self.markdown)
+        self.assertEqual(headings(normalized), [
+            "# Card Authentication",
+            "## Payments Specification v2",
+            "## 3 Card Authentication",
+            "### 3.1 Offline Data Authentication",
+            "#### 3.1.1 Static Data Authentication",
+            "### 3.2 Online Authentication",
+        ])
+
+    def test_front_matter_and_code_untouched(self):
+        normalized = normalize_headings(self.markdown, 1)
+        self.assertTrue(normalized.startswith("---\ntitle: Card Authentication\nsection_id: 3\n---\n## Card"))
+        self.assertIn("```python\n# not a heading\n", normalized)
+
+    def test_offset_shifts_everything(self):
+        self.assertEqual(headings(normalize_headings(self.markdown, 2))[:2],
+                         ["### Card Authentication", "#### Payments Specification v2"])
+        self.assertEqual(normalize_headings("# A\n## B\n### C", 5), "###### A\n###### B\n###### C")
+
+    def test_already_normal_unchanged(self):
+        markdown = "# Title\n\nText\n\n## Part\n\n### Detail\n"
+        self.assertEqual(normalize_headings(markdown), markdown)
+        self.assertEqual(normalize_headings("No headings #here"), "No headings #here")
+
+    def test_offset_validation(self):
+        self.assertEqual(validate_heading_offset(3), 3)
+        for bad in (-1, 6, 1.5, True, "2"):
+            with self.assertRaises(ValueError):
+                validate_heading_offset(bad)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,80 @@
+"""
+Test pairing extracted images with the document's own captions
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.image_extractor import find_document_caption, caption_alt_text
+
+try:
+    import fitz  # noqa: F401
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+CAPTIONED_PDF = Path(__file__).parent / "fixtures" / "captioned_figures.pdf"
+
+IMAGE = (72, 100, 272, 200)
+
+
+class TestDocumentCaptions(unittest.TestCase):
+    """Test caption matching by label pattern and position"""
+
+    def test_caption_below(self):
+        blocks = [(72, 206, 300, 218, "Figure 3: Token refresh flow")]
+        caption = find_document_caption(IMAGE, blocks)
+        self.assertEqual(caption['text'], "Figure 3: Token refresh flow")
+        self.assertEqual(caption['position'], 'below')
+
+    def test_caption_above(self):
+        blocks = [(72, 80, 300, 92, "Fig. 2.1 - Deployment\ntopology")]
+        caption = find_document_caption(IMAGE, blocks)
+        self.assertEqual(caption['text'], "Fig. 2.1 - Deployment topology")
+        self.assertEqual(caption['position'], 'above')
+
+    def test_closest_caption_wins(self):
+        blocks = [
+            (72, 240, 300, 252, "Figure 4: Too far below"),
+            (72, 84, 300, 96, "Figure 3: Just above"),
+        ]
+        self.assertEqual(find_document_caption(IMAGE, blocks)['text'], "Figure 3: Just above")
+
+    def test_ignores_plain_text_distant_and_offset_blocks(self):
+        blocks = [
+            (72, 206, 300, 218, "The figure below shows the flow."),
+            (72, 400, 300, 412, "Figure 9: Elsewhere on the page"),
+            (400, 206, 550, 218, "Figure 5: Next column"),
+        ]
+        self.assertIsNone(find_document_caption(IMAGE, blocks))
+
+    def test_alt_text_drops_label(self):
+        self.assertEqual(caption_alt_text("Figure 3: Token refresh flow"), "Token refresh flow")
+        self.assertEqual(caption_alt_text("Exhibit IV"), "Exhibit IV")
+
+    @unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF not installed")
+    def test_captioned_fixture(self):
+        from processors.image_extractor import ImageExtractor
+        temp_dir = tempfile.mkdtemp()
+        try:
+            images = ImageExtractor(Path(temp_dir) / "images").extract(str(CAPTIONED_PDF))
+            self.assertEqual([image['caption'] for image in images],
+                             ["Figure 1: System architecture overview", "Figure 2 - Request latency by region"])
+            self.assertEqual(images[0]['alt_text'], "System architecture overview")
+            self.assertEqual(images[1]['caption_position'], 'above')
+            self.assertTrue(all(Path(image['file']).exists() for image in images))
+
+            uncaptioned = ImageExtractor(Path(temp_dir) / "plain", use_document_captions=False).extract(str(CAPTIONED_PDF))
+            self.assertTrue(all(image['caption'] is None for image in uncaptioned))
+        finally:
+            shutil.rmtree(temp_dir, ignore_errors=True)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,102 @@
+"""
+Test returning images as MCP image content
+"""
+import unittest
+import tempfile
+import shutil
+import io
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.image_content import extract_inline_images, fit_image, validate_image_limits
+
+try:
+    import fitz  # noqa: F401
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+try:
+    from PIL import Image
+    HAS_PIL = True
+except ImportError:
+    HAS_PIL = False
+
+
+class TestLimits(unittest.TestCase):
+    """Test max_images / max_dimension checks"""
+
+    def test_valid(self):
+        self.assertEqual(validate_image_limits("5", 512), (5, 512))
+
+    def test_invalid(self):
+        for max_images, max_dimension in ((0, 512), (51, 512), (5, 8), (5, 5000), ("all", 512)):
+            with self.assertRaises(ValueError):
+                validate_image_limits(max_images, max_dimension)
+
+
+@unittest.skipUnless(HAS_PIL, "Pillow is required")
+class TestFitImage(unittest.TestCase):
+    """Test downscaling and re-encoding"""
+
+    def encode(self, mode, size, format):
+        output = io.BytesIO()
+        Image.new(mode, size).save(output, format=format)
+        return output.getvalue()
+
+    def test_jpeg_downscaled_keeps_format_and_aspect(self):
+        data, mime_type, width, height = fit_image(self.encode('RGB', (800, 400), 'JPEG'), 'jpeg', 200)
+        self.assertEqual((mime_type, width, height), ('image/jpeg', 200, 100))
+        self.assertEqual(Image.open(io.BytesIO(data)).size, (200, 100))
+
+    def test_cmyk_becomes_png(self):
+        _, mime_type, width, _ = fit_image(self.encode('CMYK', (64, 64), 'JPEG'), 'jpeg', 1024)
+        self.assertEqual((mime_type, width), ('image/png', 64))
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestExtractInlineImages(unittest.TestCase):
+    """Test image selection from a generated PDF"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+        self.pdf_path = self.temp_dir / "figures.pdf"
+        doc = fitz.open()
+        for shade in (60, 120, 180):
+            pixmap = fitz.Pixmap(fitz.csRGB, fitz.IRect(0, 0, 48, 32), False)
+            pixmap.clear_with(shade)
+            page = doc.new_page()
+            page.insert_image(fitz.Rect(72, 72, 168, 136), stream=pixmap.tobytes("png"))
+        doc.save(str(self.pdf_path))
+        doc.close()
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_max_images_counts_omitted(self):
+        result = extract_inline_images(str(self.pdf_path), max_images=2)
+        self.assertEqual([image['page'] for image in result['images']], [1, 2])
+        self.assertEqual(result['omitted'], 1)
+        self.assertEqual(result['images'][0]['mime_type'], 'image/png')
+        self.assertFalse(result['images'][0]['downscaled'])
+
+    def test_page_range(self):
+        result = extract_inline_images(str(self.pdf_path), pages=[3])
+        self.assertEqual([image['page'] for image in result['images']], [3])
+        with self.assertRaises(ValueError):
+            extract_inline_images(str(self.pdf_path), pages=[4])
+
+    def test_page_range_spec_checked_against_page_count(self):
+        result = extract_inline_images(str(self.pdf_path), page_range="2-3")
+        self.assertEqual([image['page'] for image in result['images']], [2, 3])
+        with self.assertRaises(ValueError) as raised:
+            extract_inline_images(str(self.pdf_path), page_range="1-2000000000")
+        self.assertIn("past the end of the document (3 pages)", str(raised.exception))
+
+
+if __name__ == '__main__':
+    unittest.main()
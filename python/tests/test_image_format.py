@@ -0,0 +1,89 @@
+"""
+Test re-encoding extracted images in the requested format
+"""
+import unittest
+import unittest.mock
+import io
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.image_format import DEFAULT_IMAGE_QUALITY, convert_image, encode_image, validate_image_format
+
+try:
+    from PIL import Image
+    HAS_PIL = True
+except ImportError:
+    HAS_PIL = False
+
+
+def encoded(mode, color, image_format='PNG'):
+    """A 32x32 image of one color, encoded"""
+    output = io.BytesIO()
+    Image.new(mode, (32, 32), color).save(output, format=image_format)
+    return output.getvalue()
+
+
+class TestValidateImageFormat(unittest.TestCase):
+    """Test image_format / image_quality checks"""
+
+    def test_defaults(self):
+        self.assertEqual(validate_image_format(None), ('original', DEFAULT_IMAGE_QUALITY))
+        self.assertEqual(validate_image_format('webp', "60"), ('webp', 60))
+
+    def test_invalid(self):
+        for image_format, image_quality in (('gif', None), ('jpeg', 0), ('jpeg', 101), ('jpeg', "high")):
+            with self.assertRaises(ValueError):
+                validate_image_format(image_format, image_quality)
+
+
+class TestConvertImage(unittest.TestCase):
+    """Test re-encoding and the safe fallbacks"""
+
+    def test_original_unchanged(self):
+        self.assertEqual(convert_image(b"raw", 'jpx', 'original'), (b"raw", 'jpx'))
+
+    def test_undecodable_kept(self):
+        self.assertEqual(encode_image(b"not an image", 'jb2', 'jpeg'), (b"not an image", 'jb2', 'undecodable'))
+
+    @unittest.skipUnless(HAS_PIL, "Pillow is required")
+    def test_original_kept_when_reencoding_is_larger(self):
+        # A flat-color PNG compresses far better than any JPEG of it
+        source = encoded('RGB', (0, 128, 255))
+        self.assertEqual(encode_image(source, 'png', 'jpeg', 95), (source, 'png', 'larger'))
+
+    def test_non_web_format_converted_even_when_larger(self):
+        with unittest.mock.patch('processors.image_format._reencode', return_value=b"x" * 100):
+            self.assertEqual(encode_image(b"jpx", 'jpx', 'png'), (b"x" * 100, 'png', 'converted'))
+
+    @unittest.skipUnless(HAS_PIL, "Pillow is required")
+    def test_jpeg_flattens_alpha(self):
+        # Reported as JPEG 2000 so the re-encoding is kept whatever its size
+        data, ext = convert_image(encoded('RGBA', (255, 0, 0, 0)), 'jpx', 'jpeg')
+        self.assertEqual(ext, 'jpg')
+        with Image.open(io.BytesIO(data)) as image:
+            self.assertEqual((image.format, image.mode), ('JPEG', 'RGB'))
+            # Fully transparent pixels become the white background
+            self.assertTrue(all(channel > 240 for channel in image.getpixel((16, 16))))
+
+    @unittest.skipUnless(HAS_PIL, "Pillow is required")
+    def test_cmyk_converted_to_rgb(self):
+        data, ext = convert_image(encoded('CMYK', (0, 255, 255, 0), 'JPEG'), 'jpx', 'png')
+        self.assertEqual(ext, 'png')
+        with Image.open(io.BytesIO(data)) as image:
+            self.assertEqual((image.format, image.mode), ('PNG', 'RGB'))
+
+    @unittest.skipUnless(HAS_PIL, "Pillow is required")
+    def test_quality_shrinks_output(self):
+        output = io.BytesIO()
+        Image.linear_gradient('L').convert('RGB').save(output, format='PNG')
+        source = output.getvalue()
+        high, _ = convert_image(source, 'jpx', 'jpeg', 95)
+        low, _ = convert_image(source, 'jpx', 'jpeg', 10)
+        self.assertLess(len(low), len(high))
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,156 @@
+"""
+Test consolidation of the same image embedded at several resolutions
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.image_variants import (
+    difference_hash, hash_distance, group_image_variants, consolidate_image_variants,
+    remove_replaced_files, DEFAULT_MAX_HASH_DISTANCE
+)
+from tests.fixtures.make_dual_resolution_pdf import sample, chart, rings
+
+try:
+    import fitz  # noqa: F401
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+DUAL_RESOLUTION_PDF = Path(__file__).parent / "fixtures" / "dual_resolution.pdf"
+
+
+def image(file, page, width, height, function):
+    return {'file': file, 'page': page, 'width': width, 'height': height,
+            'caption': None, 'alt_text': f"Image from page {page}",
+            'phash': f"{difference_hash(sample(function, width, height), width, height):016x}"}
+
+
+class TestDifferenceHash(unittest.TestCase):
+    """Test hash stability across resolutions"""
+
+    def test_resolutions_hash_alike(self):
+        small = difference_hash(sample(chart, 32, 24), 32, 24)
+        large = difference_hash(sample(chart, 256, 192), 256, 192)
+        self.assertLessEqual(hash_distance(small, large), DEFAULT_MAX_HASH_DISTANCE)
+
+    def test_different_pictures_hash_apart(self):
+        a = difference_hash(sample(chart, 64, 48), 64, 48)
+        b = difference_hash(sample(rings, 64, 48), 64, 48)
+        self.assertGreater(hash_distance(a, b), DEFAULT_MAX_HASH_DISTANCE * 2)
+
+
+class TestConsolidation(unittest.TestCase):
+    """Test which variant is kept and how references are rewritten"""
+
+    def setUp(self):
+        self.images = [
+            image("img/p1-preview.png", 1, 32, 24, chart),
+            image("img/p2-chart.png", 2, 128, 96, chart),
+            image("img/p2-rings.png", 2, 64, 48, rings),
+        ]
+
+    def test_largest_variant_kept_and_referenced(self):
+        images, consolidations = consolidate_image_variants(self.images)
+        self.assertEqual([i['file'] for i in images], ["img/p2-chart.png", "img/p2-chart.png", "img/p2-rings.png"])
+        self.assertEqual(images[0]['page'], 1)
+        self.assertEqual(images[0]['replaced_file'], "img/p1-preview.png")
+        self.assertEqual((images[0]['width'], images[0]['height']), (128, 96))
+        self.assertEqual(consolidations, [{
+            'kept': {'file': "img/p2-chart.png", 'width': 128, 'height': 96},
+            'replaced': [{'file': "img/p1-preview.png", 'page': 1, 'width': 32, 'height': 24}]
+        }])
+
+    def test_same_page_variant_dropped(self):
+        self.images[0]['page'] = 2
+        images, _ = consolidate_image_variants(self.images)
+        self.assertEqual([i['file'] for i in images], ["img/p2-chart.png", "img/p2-rings.png"])
+
+    def test_aspect_ratio_must_match(self):
+        stretched = image("img/wide.png", 3, 128, 48, chart)
+        self.assertEqual(group_image_variants([self.images[1], stretched]), [])
+
+    def test_images_without_hash_are_kept(self):
+        for entry in self.images:
+            entry['phash'] = None
+        images, consolidations = consolidate_image_variants(self.images)
+        self.assertEqual(images, self.images)
+        self.assertEqual(consolidations, [])
+
+    def test_deduplicated_copies_not_consolidated(self):
+        logo = image("img/p1-logo.png", 1, 64, 48, rings)
+        repeats = [logo, dict(logo, page=2), dict(logo, page=3)]
+        images, consolidations = consolidate_image_variants(repeats)
+        self.assertEqual([i['file'] for i in images], ["img/p1-logo.png"] * 3)
+        self.assertEqual(consolidations, [])
+
+    def test_shared_replaced_file_listed_once(self):
+        repeat = dict(self.images[0], page=3)
+        images, consolidations = consolidate_image_variants(self.images + [repeat])
+        self.assertEqual(images[3]['file'], "img/p2-chart.png")
+        self.assertEqual(consolidations[0]['replaced'],
+                         [{'file': "img/p1-preview.png", 'page': 1, 'width': 32, 'height': 24}])
+
+    def test_replaced_files_removed(self):
+        temp_dir = Path(tempfile.mkdtemp())
+        try:
+            kept, small = temp_dir / "kept.png", temp_dir / "small.png"
+            kept.write_bytes(b"large")
+            small.write_bytes(b"small")
+            removed = remove_replaced_files([{'kept': {'file': str(kept)}, 'replaced': [{'file': str(small)}]}])
+            self.assertEqual(removed, 1)
+            self.assertTrue(kept.exists())
+            self.assertFalse(small.exists())
+        finally:
+            shutil.rmtree(temp_dir, ignore_errors=True)
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestDualResolutionFixture(unittest.TestCase):
+    """Test hashing of the images embedded in the fixture"""
+
+    def test_preview_and_original_consolidate(self):
+        from processors.image_extractor import ImageExtractor
+        temp_dir = tempfile.mkdtemp()
+        try:
+            extracted = ImageExtractor(Path(temp_dir), use_document_captions=False).extract(str(DUAL_RESOLUTION_PDF))
+            self.assertEqual(len(extracted), 3)
+            images, consolidations = consolidate_image_variants(extracted)
+            self.assertEqual(len(consolidations), 1)
+            self.assertEqual(consolidations[0]['kept']['width'], 128)
+            self.assertEqual(images[0]['page'], 1)
+            self.assertEqual(images[0]['width'], 128)
+        finally:
+            shutil.rmtree(temp_dir, ignore_errors=True)
+
+    def test_repeated_image_written_once(self):
+        from processors.image_extractor import ImageExtractor
+        temp_dir = Path(tempfile.mkdtemp())
+        try:
+            pdf_path = temp_dir / "logo.pdf"
+            doc = fitz.open()
+            pixmap = fitz.Pixmap(fitz.csRGB, fitz.IRect(0, 0, 32, 32), False)
+            pixmap.clear_with(128)
+            logo = pixmap.tobytes("png")
+            for _ in range(3):
+                doc.new_page().insert_image(fitz.Rect(72, 72, 136, 136), stream=logo)
+            doc.save(str(pdf_path))
+            doc.close()
+
+            extracted = ImageExtractor(temp_dir / "images", use_document_captions=False).extract(str(pdf_path))
+            self.assertEqual([i['page'] for i in extracted], [1, 2, 3])
+            self.assertEqual(len({i['file'] for i in extracted}), 1)
+            self.assertEqual([i['duplicate'] for i in extracted], [False, True, True])
+            self.assertEqual(len(list((temp_dir / "images").iterdir())), 1)
+        finally:
+            shutil.rmtree(temp_dir, ignore_errors=True)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,127 @@
+"""
+Test the self-contained JSON bundle returned by convert_pdf_inline
+"""
+import unittest
+import tempfile
+import shutil
+import base64
+import json
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.inline_bundle import build_inline_bundle, chunk_markdown, TRUNCATION_MARKER
+from utils.frontmatter import render_front_matter
+
+PNG_BYTES = b"\x89PNG\r\n\x1a\n" + b"\x00" * 3000
+
+
+class TestInlineBundle(unittest.TestCase):
+    """Test bundle contents and the size cap"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+        self.output = Path(self.temp_dir)
+        (self.output / "sections").mkdir()
+        (self.output / "images").mkdir()
+        sections = []
+        for n in range(1, 4):
+            name = f"sections/{n:02d}-part.md"
+            body = "\n\n".join(f"Paragraph {p} of section {n}. " + "Text " * 60 for p in range(6))
+            (self.output / name).write_text(render_front_matter({'title': f"Part {n}", 'section_id': n}) + body)
+            sections.append({'file': name, 'section_id': n, 'title': f"Part {n}", 'token_count': len(body) // 4})
+        (self.output / "images" / "page001-img01.png").write_bytes(PNG_BYTES)
+        manifest = {
+            'document_id': 'doc',
+            'source_file': 'doc.pdf',
+            'fingerprint': None,
+            'sections': sections,
+            'images': [{'file': 'images/page001-img01.png', 'page': 1, 'section_id': 1,
+                        'width': 20, 'height': 20, 'caption': None, 'alt_text': 'Image from page 1'}]
+        }
+        (self.output / "manifest.json").write_text(json.dumps(manifest))
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_everything_fits(self):
+        bundle = build_inline_bundle(self.output, chunk_tokens=200)
+        self.assertFalse(bundle['truncated'])
+        self.assertEqual(bundle['notices'], [])
+        self.assertEqual([s['title'] for s in bundle['sections']], ["Part 1", "Part 2", "Part 3"])
+        self.assertEqual(bundle['sections'][0]['front_matter']['section_id'], 1)
+        self.assertTrue(bundle['sections'][0]['content'].startswith("Paragraph 0 of section 1."))
+        self.assertEqual(base64.b64decode(bundle['images'][0]['data']), PNG_BYTES)
+        self.assertEqual(bundle['images'][0]['mime_type'], 'image/png')
+        self.assertGreater(len(bundle['chunks']), 3)
+        self.assertEqual(bundle['size_bytes'], len(json.dumps(bundle, ensure_ascii=False).encode('utf-8')))
+
+    def test_cap_truncates_and_omits(self):
+        bundle = build_inline_bundle(self.output, max_bytes=10_000)
+        self.assertTrue(bundle['truncated'])
+        self.assertLessEqual(len(json.dumps(bundle, ensure_ascii=False).encode('utf-8')), 10_000)
+        self.assertEqual(len(bundle['sections']), 3)
+        self.assertTrue(any(s['content'].endswith(TRUNCATION_MARKER) for s in bundle['sections']))
+        self.assertEqual(bundle['images'], [])
+        self.assertTrue(any('image(s) omitted' in notice for notice in bundle['notices']))
+        self.assertTrue(any('chunk(s) omitted' in notice for notice in bundle['notices']))
+
+    def test_warnings_count_against_the_cap(self):
+        warnings = [f"Warning {index}: " + "w" * 200 for index in range(20)]
+        bundle = build_inline_bundle(self.output, max_bytes=10_000, warnings=warnings)
+        self.assertEqual(bundle['warnings'], warnings)
+        self.assertEqual(bundle['size_bytes'], len(json.dumps(bundle, ensure_ascii=False).encode('utf-8')))
+        self.assertLessEqual(bundle['size_bytes'], 10_000)
+
+    def test_many_sections_stay_under_the_cap(self):
+        manifest = json.loads((self.output / "manifest.json").read_text())
+        for n in range(4, 401):
+            name = f"sections/{n:03d}-part.md"
+            (self.output / name).write_text(render_front_matter({'title': f"Part {n}", 'section_id': n}) + "Short text.")
+            manifest['sections'].append({'file': name, 'section_id': n, 'title': f"Part {n}", 'token_count': 3})
+        (self.output / "manifest.json").write_text(json.dumps(manifest))
+
+        bundle = build_inline_bundle(self.output, max_bytes=20_000)
+        self.assertLessEqual(bundle['size_bytes'], 20_000)
+        self.assertEqual(bundle['size_bytes'], len(json.dumps(bundle, ensure_ascii=False).encode('utf-8')))
+        omitted = [notice for notice in bundle['notices'] if 'section(s) omitted' in notice]
+        self.assertEqual(len(omitted), 1)
+        self.assertTrue(omitted[0].startswith(f"{400 - len(bundle['sections'])} section(s) omitted"))
+        self.assertLessEqual(len(bundle['notices']), 4)
+
+    def test_oversized_warnings_are_cut(self):
+        warnings = [f"Warning {index}: " + "w" * 200 for index in range(200)]
+        bundle = build_inline_bundle(self.output, max_bytes=20_000, warnings=warnings)
+        self.assertLessEqual(bundle['size_bytes'], 20_000)
+        self.assertEqual(bundle['warnings'], warnings[:len(bundle['warnings'])])
+        self.assertIn(f"{200 - len(bundle['warnings'])} of 200 warning(s) omitted to fit max_inline_bytes",
+                      bundle['notices'])
+        self.assertTrue(bundle['truncated'])
+
+    def test_images_can_be_left_out(self):
+        bundle = build_inline_bundle(self.output, include_images=False)
+        self.assertEqual(bundle['images'], [])
+        self.assertIn("1 image(s) not embedded (include_images is false)", bundle['notices'])
+        self.assertFalse(bundle['truncated'])
+
+
+class TestChunkMarkdown(unittest.TestCase):
+    """Test paragraph chunking"""
+
+    def test_chunks_respect_token_limit(self):
+        text = "\n\n".join("word " * 100 for _ in range(5))
+        chunks = chunk_markdown(text, max_tokens=300)
+        self.assertEqual("\n\n".join(chunks).split(), text.split())
+        self.assertGreater(len(chunks), 1)
+
+    def test_oversized_paragraph_is_kept_whole(self):
+        text = "short\n\n" + "long " * 500
+        chunks = chunk_markdown(text, max_tokens=50)
+        self.assertEqual(chunks, ["short", ("long " * 500).strip()])
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,42 @@
+"""
+Test keyword aggregation from emphasized text
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.keyword_extractor import KeywordExtractor
+
+
+class TestKeywordExtraction(unittest.TestCase):
+    """Test emphasized-term filtering and aggregation"""
+
+    def setUp(self):
+        self.extractor = KeywordExtractor()
+
+    def test_aggregates_counts_and_first_page(self):
+        spans = [
+            (2, "Access Token", 'bold'),
+            (3, "access token:", 'bold'),
+            (3, "Refresh Token", 'italic'),
+            (5, "Access Token", 'italic'),
+        ]
+        keywords = self.extractor.aggregate(spans)
+        self.assertEqual(keywords[0]['term'], "Access Token")
+        self.assertEqual(keywords[0]['count'], 3)
+        self.assertEqual(keywords[0]['first_page'], 2)
+        self.assertEqual(keywords[0]['pages'], [2, 3, 5])
+        self.assertEqual(keywords[0]['styles'], ['bold', 'italic'])
+        self.assertEqual(keywords[1]['term'], "Refresh Token")
+
+    def test_filters_common_and_noise(self):
+        for text in ["Note:", "the", "must not", "Figure 3", "42", "a", "one two three four five six"]:
+            self.assertIsNone(self.extractor.normalize_term(text), msg=text)
+        self.assertEqual(self.extractor.normalize_term("  (Idempotency Key) "), "Idempotency Key")
+
+
+if __name__ == '__main__':
+    unittest.main()
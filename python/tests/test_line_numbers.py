@@ -0,0 +1,91 @@
+"""
+Test detection of margin line numbers in legal documents
+"""
+import unittest
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.line_numbers import detect_margin_line_numbers, anchor_lines
+
+try:
+    import fitz  # noqa: F401
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+LINE_NUMBERED_PDF = Path(__file__).parent / "fixtures" / "line_numbered.pdf"
+
+
+def pleading_words(count=8):
+    """Words of a pleading page: right-aligned numbers at x1=60, body from x=90"""
+    words = []
+    for index in range(count):
+        y0 = 80 + index * 24
+        number = str(index + 1)
+        words.append((60 - 6.7 * len(number), y0, 60.0, y0 + 12, number))
+        words.append((90.0, y0, 130.0, y0 + 12, "Text"))
+        words.append((134.0, y0, 170.0, y0 + 12, "line"))
+    return words
+
+
+class TestLineNumberDetection(unittest.TestCase):
+    """Test the margin column heuristics"""
+
+    def test_left_margin_column(self):
+        detection = detect_margin_line_numbers(pleading_words())
+        self.assertEqual(detection['side'], 'left')
+        self.assertEqual(detection['boundary'], 60.0)
+        self.assertEqual([value for value, _ in detection['numbers']], list(range(1, 9)))
+
+    def test_too_few_numbers(self):
+        self.assertIsNone(detect_margin_line_numbers(pleading_words(3)))
+
+    def test_numbers_inside_the_text_are_not_a_column(self):
+        # A column of numbers with body text to its left (e.g. a table column)
+        words = [(200.0, 80 + i * 24, 210.0, 92 + i * 24, str(i + 1)) for i in range(6)]
+        words += [(72.0, 80 + i * 24, 180.0, 92 + i * 24, "Item") for i in range(6)]
+        words += [(250.0, 80 + i * 24, 300.0, 92 + i * 24, "note") for i in range(6)]
+        self.assertIsNone(detect_margin_line_numbers(words))
+
+    def test_descending_numbers_rejected(self):
+        words = [(w[0], w[1], w[2], w[3], str(20 - i)) if w[4].isdigit() else w
+                 for i, w in enumerate(pleading_words())]
+        self.assertIsNone(detect_margin_line_numbers(words))
+
+    def test_anchor_lines(self):
+        lines = [(80, 92, "First line"), (104, 116, "Second line"), (128, 140, "")]
+        numbers = [(11, 86.0), (12, 110.0), (13, 134.0)]
+        self.assertEqual(anchor_lines(lines, numbers), ["[L11] First line", "[L12] Second line", ""])
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestLineNumberedFixture(unittest.TestCase):
+    """Test stripping and anchoring against the pleading fixture"""
+
+    def page_text(self, mode):
+        from processors.pdf_extractor import extract_pdf
+        result = extract_pdf(str(LINE_NUMBERED_PDF), {'line_numbers': mode})
+        return result['page_texts'][0]
+
+    def test_numbers_stripped_by_default(self):
+        page = self.page_text('strip')
+        lines = [line.strip() for line in page['text'].splitlines()]
+        self.assertNotIn("1", lines)
+        self.assertIn("section 12", page['text'])
+        self.assertEqual(page['line_numbers'], {'first': 1, 'last': 8, 'side': 'left'})
+
+    def test_numbers_preserved_as_anchors(self):
+        text = self.page_text('preserve')['text']
+        self.assertIn("[L6] The agreement is governed by section 12 of the code.", text)
+
+    def test_keep_leaves_text_untouched(self):
+        page = self.page_text('keep')
+        self.assertNotIn('line_numbers', page)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,87 @@
+"""
+Test keeping PDF link annotations as markdown links
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.links import LINK_GOTO, LINK_URI, apply_links, link_target, page_links, resolve_page_links
+
+
+def word(x0, y0, x1, text, line, number, block=0):
+    """A get_text("words") tuple on a 10pt-high line"""
+    return (x0, y0, x1, y0 + 10, text, block, line, number)
+
+
+WORDS = [
+    word(10, 10, 40, "See", 0, 0), word(45, 10, 80, "section", 0, 1), word(85, 10, 95, "4", 0, 2),
+    word(100, 10, 130, "or", 0, 3), word(135, 10, 200, "https://example.", 0, 4),
+    word(10, 25, 60, "com/spec(v2)", 1, 0), word(65, 25, 100, "today.", 1, 1),
+]
+TEXT = "See section 4 or https://example.\ncom/spec(v2) today.\n"
+
+
+class TestPageLinks(unittest.TestCase):
+    """Test matching link rectangles to the words they cover"""
+
+    def test_rect_over_several_words(self):
+        links = page_links([{'kind': LINK_GOTO, 'page': 11, 'from': (44, 9, 96, 20)}], WORDS)
+        self.assertEqual([(link['text'], link['target']) for link in links], [("section 4", "#page-12")])
+
+    def test_url_split_over_two_lines_is_one_link(self):
+        uri = "https://example.com/spec(v2)"
+        links = page_links([{'kind': LINK_URI, 'uri': uri, 'from': (134, 9, 201, 20)},
+                            {'kind': LINK_URI, 'uri': uri, 'from': (9, 24, 61, 35)}], WORDS)
+        self.assertEqual(len(links), 1)
+        self.assertEqual(links[0]['text'], "https://example. com/spec(v2)")
+        self.assertEqual(links[0]['target'], "https://example.com/spec%28v2%29")
+
+    def test_ignored_links(self):
+        self.assertIsNone(link_target({'kind': LINK_URI, 'uri': "javascript:print()"}))
+        self.assertIsNone(link_target({'kind': LINK_GOTO, 'page': -1}))
+        self.assertEqual(page_links([{'kind': LINK_URI, 'uri': "https://x.org", 'from': (300, 300, 310, 310)}], WORDS), [])
+
+
+class TestApplyLinks(unittest.TestCase):
+    """Test wrapping anchor text in the page text"""
+
+    def test_links_wrapped_in_order(self):
+        links = page_links([{'kind': LINK_GOTO, 'page': 3, 'from': (44, 9, 96, 20)},
+                            {'kind': LINK_URI, 'uri': "https://example.com/spec", 'from': (134, 9, 201, 20)},
+                            {'kind': LINK_URI, 'uri': "https://example.com/spec", 'from': (9, 24, 61, 35)}], WORDS)
+        text, applied = apply_links(TEXT, links)
+        self.assertEqual(applied, 2)
+        self.assertEqual(text, "See [section 4](#page-4) or [https://example. com/spec(v2)](https://example.com/spec) today.\n")
+
+    def test_repeated_text_links_the_right_occurrence(self):
+        words = [word(10, 10, 30, "here", 0, 0), word(35, 10, 55, "and", 0, 1), word(60, 10, 80, "here", 0, 2)]
+        links = page_links([{'kind': LINK_URI, 'uri': "https://b.org", 'from': (59, 9, 81, 20)}], words)
+        self.assertEqual(apply_links("here and here", links)[0], "here and [here](https://b.org)")
+
+    def test_fenced_code_not_linked(self):
+        words = [word(10, 10, 30, "here", 0, 0), word(60, 10, 80, "here", 1, 0)]
+        links = page_links([{'kind': LINK_URI, 'uri': "https://b.org", 'from': (9, 9, 31, 20)}], words)
+        text = "```\nhere\n```\nhere"
+        self.assertEqual(apply_links(text, links), ("```\nhere\n```\n[here](https://b.org)", 1))
+        self.assertEqual(apply_links("```\nhere\n```\n", links), ("```\nhere\n```\n", 0))
+
+    def test_missing_text_skipped(self):
+        links = page_links([{'kind': LINK_URI, 'uri': "https://x.org", 'from': (9, 9, 41, 20)}], WORDS)
+        self.assertEqual(apply_links("different text", links), ("different text", 0))
+
+
+class TestResolvePageLinks(unittest.TestCase):
+    """Test pointing internal links at section files"""
+
+    def test_resolved_and_unwrapped(self):
+        content = "See [section 4](#page-4) and [\\[1\\]](#page-90)."
+        text, resolved, unwrapped = resolve_page_links(content, {4: "04-scope.md"})
+        self.assertEqual(text, "See [section 4](04-scope.md) and [1].")
+        self.assertEqual((resolved, unwrapped), (1, 1))
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,78 @@
+"""
+Test bulleted and numbered lists as markdown lists
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.lists import apply_lists, format_lists, list_item
+
+
+def _page(*lines):
+    """A get_text("dict") page from (x, text) lines, 14 points apart"""
+    return {'blocks': [{'type': 0, 'lines': [
+        {'bbox': (x, 100 + 14 * index, x + 5 * len(text), 111 + 14 * index),
+         'spans': [{'text': text, 'font': 'Helvetica', 'flags': 0}]}
+        for index, (x, text) in enumerate(lines)]}]}
+
+
+def _apply(*lines):
+    """apply_lists over page text made of the lines"""
+    return apply_lists('\n'.join(text for _, text in lines), _page(*lines))
+
+
+class TestListItems(unittest.TestCase):
+    """Test recognizing list item lines"""
+
+    def test_markers(self):
+        self.assertEqual(list_item("• First point"), ('-', 'First point'))
+        self.assertEqual(list_item("* Second"), ('-', 'Second'))
+        self.assertEqual(list_item("  12. Twelfth"), ('12.', 'Twelfth'))
+        self.assertEqual(list_item("3) Third"), ('3.', 'Third'))
+        self.assertIsNone(list_item("2019. A year"))
+        self.assertIsNone(list_item("-5 degrees"))
+        self.assertIsNone(list_item("Plain sentence."))
+
+
+class TestFormatLists(unittest.TestCase):
+    """Test rewriting page text"""
+
+    def test_bullets_become_a_separated_list(self):
+        text, lists = _apply((72, "Supported formats:"), (72, "• PDF"), (72, "• Word"), (72, "Both convert."))
+        self.assertEqual(lists, 1)
+        self.assertEqual(text, "Supported formats:\n\n- PDF\n- Word\n\nBoth convert.")
+
+    def test_nesting_follows_indent(self):
+        text, _ = _apply((72, "1. Install"), (90, "• Download the package"), (90, "• Unpack it"),
+                         (72, "2. Configure"))
+        self.assertEqual(text, "1. Install\n    - Download the package\n    - Unpack it\n2. Configure")
+
+    def test_wrapped_items_are_joined(self):
+        text, _ = _apply((72, "• The first item is long and"), (82, "Continues on a hanging indent"),
+                         (72, "• The second item wraps"), (72, "onto a lower-case line"),
+                         (72, "• A well-"), (72, "known word"))
+        self.assertEqual(text, "- The first item is long and Continues on a hanging indent\n"
+                               "- The second item wraps onto a lower-case line\n"
+                               "- A well-known word")
+
+    def test_bullet_glyph_on_its_own_line(self):
+        text, _ = _apply((72, "•"), (82, "First"), (72, "•"), (82, "Second"))
+        self.assertEqual(text, "- First\n- Second")
+
+    def test_single_numbered_heading_is_left_alone(self):
+        lines = [(72, "1. Introduction"), (72, "This document describes the API.")]
+        text, lists = _apply(*lines)
+        self.assertEqual(lists, 0)
+        self.assertEqual(text, "1. Introduction\nThis document describes the API.")
+
+    def test_fenced_code_is_not_a_list(self):
+        lines = ["```yaml", "- name: a", "- name: b", "```"]
+        output, lists = format_lists(lines, [None] * 4, [True] * 4)
+        self.assertEqual((output, lists), (lines, 0))
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,127 @@
+"""
+Test AsciiDoc and reStructuredText rendering of generated markdown
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.markup_formats import output_filename, render_document, render_inline
+from utils.frontmatter import render_front_matter
+
+SECTION = """# Authentication
+
+Requests are signed with `HMAC`, see [Tokens](02-tokens.md#expiry).
+
+| Field | Type |
+|-------|------|
+| id | int |
+| a \\| b | str |
+
+![Figure 1: Flow](../images/img-001.png)
+
+```python
+sign(request)
+```
+"""
+
+
+class TestFileNames(unittest.TestCase):
+    """Test extensions follow the output format"""
+
+    def test_extensions(self):
+        self.assertEqual(output_filename('README.md', 'asciidoc'), 'README.adoc')
+        self.assertEqual(output_filename('03-setup-part01.md', 'rst'), '03-setup-part01.rst')
+        self.assertEqual(output_filename('README.md', 'markdown'), 'README.md')
+        self.assertEqual(output_filename('manifest.json', 'rst'), 'manifest.json')
+
+
+class TestAsciiDoc(unittest.TestCase):
+    """Test AsciiDoc block and inline rendering"""
+
+    def setUp(self):
+        self.output = render_document(SECTION, 'asciidoc')
+
+    def test_heading_and_inline(self):
+        self.assertTrue(self.output.startswith("= Authentication\n"))
+        self.assertIn("`+HMAC+`", self.output)
+        self.assertIn("link:02-tokens.adoc#expiry[Tokens]", self.output)
+
+    def test_table(self):
+        self.assertIn('[cols="2*",options="header"]\n|===\n| Field | Type\n\n| id | int\n| a {vbar} b | str\n|===',
+                      self.output)
+
+    def test_image_and_code(self):
+        self.assertIn("image::../images/img-001.png[Figure 1: Flow]", self.output)
+        self.assertIn("[source,python]\n----\nsign(request)\n----", self.output)
+
+    def test_front_matter_under_title(self):
+        markdown = render_front_matter({'title': 'Authentication', 'next': '02-tokens.md'}) + SECTION
+        lines = render_document(markdown, 'asciidoc').split('\n')
+        self.assertEqual(lines[:3], ["= Authentication", ":title: Authentication", ":next: 02-tokens.adoc"])
+
+
+class TestRestructuredText(unittest.TestCase):
+    """Test reStructuredText block and inline rendering"""
+
+    def setUp(self):
+        self.output = render_document(SECTION, 'rst')
+
+    def test_heading_and_inline(self):
+        self.assertTrue(self.output.startswith("Authentication\n==============\n"))
+        self.assertIn("``HMAC``", self.output)
+        self.assertIn("`Tokens <02-tokens.rst#expiry>`__", self.output)
+
+    def test_table(self):
+        self.assertIn(".. list-table::\n   :header-rows: 1\n\n   * - Field\n     - Type\n   * - id\n     - int\n"
+                      "   * - a | b\n     - str", self.output)
+
+    def test_image_and_code(self):
+        self.assertIn(".. image:: ../images/img-001.png\n   :alt: Figure 1: Flow", self.output)
+        self.assertIn(".. code-block:: python\n\n   sign(request)", self.output)
+
+    def test_nested_list_separated(self):
+        output = render_document("- one\n  - nested\n- two\n", 'rst')
+        self.assertEqual(output, "- one\n\n  - nested\n\n- two\n")
+
+    def test_code_span_not_reformatted(self):
+        self.assertEqual(render_inline("`**kwargs**` and **bold**", 'rst'), "``**kwargs**`` and **bold**")
+
+
+class TestPageMarkers(unittest.TestCase):
+    """Test page markers come out in each format's own syntax"""
+
+    MARKDOWN = "<!-- Page 3 -->\n\nFirst page text.\n\n<a id=\"page-4\"></a>\n\nSecond page text.\n"
+    LABELLED = "<a id=\"page-5\"></a><sup>p. 5</sup>\n\nText.\n"
+
+    def test_asciidoc(self):
+        output = render_document(self.MARKDOWN, 'asciidoc')
+        self.assertEqual(output, "// Page 3\n\nFirst page text.\n\n[[page-4]]\n\nSecond page text.\n")
+        self.assertIn("[[page-5]]^p. 5^\n", render_document(self.LABELLED, 'asciidoc'))
+
+    def test_rst(self):
+        output = render_document(self.MARKDOWN, 'rst')
+        self.assertEqual(output, ".. Page 3\n\nFirst page text.\n\n.. _page-4:\n\nSecond page text.\n")
+        self.assertIn(".. _page-5:\n\n:sup:`p. 5`\n", render_document(self.LABELLED, 'rst'))
+
+    def test_markers_in_code_left_alone(self):
+        markdown = "```\n<!-- Page 3 -->\n```\n"
+        self.assertIn("<!-- Page 3 -->", render_document(markdown, 'rst'))
+
+
+class TestFormats(unittest.TestCase):
+    """Test markdown passthrough and unknown formats"""
+
+    def test_markdown_unchanged(self):
+        self.assertEqual(render_document(SECTION, 'markdown'), SECTION)
+
+    def test_unknown_format_rejected(self):
+        with self.assertRaises(ValueError) as context:
+            render_document(SECTION, 'latex')
+        self.assertIn("expected one of: markdown, asciidoc, rst", str(context.exception))
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,37 @@
+"""
+Test the conversion memory estimate
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+try:
+    from processors.pdf_extractor import HELD_BYTES_PER_PAGE, MEMORY_EXPANSION_FACTOR, estimate_conversion_mb
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+MB = 1024 * 1024
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestMemoryEstimate(unittest.TestCase):
+    """Test the estimate grows with page count and page size separately"""
+
+    def test_same_size_more_pages_costs_more(self):
+        self.assertGreater(estimate_conversion_mb(10000, 50 * MB), estimate_conversion_mb(100, 50 * MB))
+
+    def test_heavier_pages_cost_more(self):
+        self.assertGreater(estimate_conversion_mb(100, 500 * MB), estimate_conversion_mb(100, 5 * MB))
+
+    def test_formula(self):
+        self.assertAlmostEqual(estimate_conversion_mb(100, 100 * MB),
+                               (MB * MEMORY_EXPANSION_FACTOR + 100 * HELD_BYTES_PER_PAGE) / MB)
+        self.assertEqual(estimate_conversion_mb(0, 0), 0)
+
+
+if __name__ == '__main__':
+    unittest.main()
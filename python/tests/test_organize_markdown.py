@@ -0,0 +1,132 @@
+"""
+Test organizing already-extracted markdown into the conversion layout
+"""
+import unittest
+import json
+import tempfile
+import shutil
+import threading
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.markdown_sections import section_type_for_title, split_markdown_sections
+from utils.frontmatter import split_front_matter
+
+try:
+    from modular_markdown_converter import ModularMarkdownConverter
+    HAS_MARKDOWN_CONVERTER = True
+except ImportError:
+    HAS_MARKDOWN_CONVERTER = False
+
+HANDBOOK = """Written for new starters.
+
+# Onboarding
+
+Day one covers accounts and equipment.
+
+## Accounts
+
+```bash
+# create the account
+make account
+```
+
+# Results
+
+Everyone ships in week one.
+"""
+
+
+class TestSplitMarkdownSections(unittest.TestCase):
+    """Test sections from headings"""
+
+    def test_sections_and_introduction(self):
+        sections = split_markdown_sections(HANDBOOK)
+        self.assertEqual([(s['title'], s['level']) for s in sections],
+                         [('Introduction', 1), ('Onboarding', 1), ('Accounts', 2), ('Results', 1)])
+        self.assertEqual(sections[0]['content'], "Written for new starters.")
+
+    def test_heading_in_code_block_stays_content(self):
+        accounts = split_markdown_sections(HANDBOOK)[2]
+        self.assertIn("# create the account", accounts['content'])
+
+    def test_split_level_keeps_deeper_headings(self):
+        sections = split_markdown_sections(HANDBOOK, split_level=1)
+        self.assertEqual([s['title'] for s in sections], ['Introduction', 'Onboarding', 'Results'])
+        self.assertIn("## Accounts", sections[1]['content'])
+
+    def test_section_types(self):
+        self.assertEqual(section_type_for_title("Appendix B"), 'appendix')
+        self.assertEqual(section_type_for_title("Key Results"), 'results')
+
+
+@unittest.skipUnless(HAS_MARKDOWN_CONVERTER, "Markdown organizer dependencies are required")
+class TestOrganizeMarkdown(unittest.TestCase):
+    """Test the organized output matches the PDF layout"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_file_organized(self):
+        source = self.temp_dir / "handbook.md"
+        source.write_text(HANDBOOK, encoding='utf-8')
+        result = ModularMarkdownConverter(str(source), str(self.temp_dir / "out")).convert()
+        self.assertTrue(result['success'], result.get('error'))
+
+        root = Path(result['output_directory'])
+        self.assertEqual(sorted(p.name for p in (root / "sections").iterdir()),
+                         ['01-Introduction.md', '02-Onboarding.md', '03-Accounts.md', '04-Results.md'])
+        front_matter, body = split_front_matter((root / "sections" / "03-Accounts.md").read_text())
+        self.assertEqual(front_matter['title'], 'Accounts')
+        self.assertTrue(body.startswith("# Accounts\n"))
+
+        manifest = json.loads((root / "manifest.json").read_text())
+        self.assertEqual(manifest['source_file'], 'handbook.md')
+        self.assertEqual(len(manifest['sections']), 4)
+        self.assertTrue((root / "README.md").read_text().startswith("# handbook\n"))
+        self.assertEqual(result['processing_stats']['markdown_extraction']['total_sections'], 4)
+
+    def test_inline_content_as_one_section(self):
+        options = {'split_by_chapters': False, 'document_name': 'notes.md'}
+        result = ModularMarkdownConverter(None, str(self.temp_dir), options,
+                                          content="# Notes\n\nFirst.\n\n## Detail\n\nSecond.\n").convert()
+        self.assertTrue(result['success'], result.get('error'))
+        sections = list((Path(result['output_directory']) / "sections").iterdir())
+        self.assertEqual([p.name for p in sections], ['01-Notes.md'])
+        self.assertIn("## Detail", sections[0].read_text())
+
+    def test_budget_chunks(self):
+        result = ModularMarkdownConverter(None, str(self.temp_dir), {'chunk_tokens': 50},
+                                          content=HANDBOOK).convert()
+        self.assertTrue(result['success'], result.get('error'))
+        self.assertEqual(result['chunking']['chunk_tokens'], 50)
+        self.assertTrue((Path(result['output_directory']) / result['chunking']['manifest']).exists())
+
+    def test_bad_chunk_budget_fails(self):
+        result = ModularMarkdownConverter(None, str(self.temp_dir), {'chunk_overlap': 10}, content=HANDBOOK).convert()
+        self.assertFalse(result['success'])
+        self.assertIn("chunk_overlap requires chunk_tokens", result['error'])
+
+    def test_cancelled_run_removes_its_files(self):
+        cancel_event = threading.Event()
+        cancel_event.set()
+        result = ModularMarkdownConverter(None, str(self.temp_dir / "out"), content=HANDBOOK,
+                                          cancel_event=cancel_event).convert()
+        self.assertTrue(result['cancelled'])
+        self.assertEqual(list(self.temp_dir.rglob('*.md')), [])
+
+    def test_missing_file_fails(self):
+        result = ModularMarkdownConverter(str(self.temp_dir / "missing.md"), str(self.temp_dir)).convert()
+        self.assertFalse(result['success'])
+        self.assertIn("not found", result['error'])
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,57 @@
+"""
+Test the nested outline returned by extract_outline
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.outline import heading_from_line, nest_outline, synthesize_outline
+
+
+class TestNestOutline(unittest.TestCase):
+    """Test nesting flat entries by level"""
+
+    def test_nesting(self):
+        chapters = [
+            {'title': "One", 'level': 0, 'page': 1},
+            {'title': "One.A", 'level': 1, 'page': 2},
+            {'title': "Deep", 'level': 2, 'page': 2},
+            {'title': "One.B", 'level': 1, 'page': 3},
+            {'title': "Two", 'level': 0, 'page': None},
+        ]
+        tree = nest_outline(chapters)
+        self.assertEqual([node['title'] for node in tree], ["One", "Two"])
+        self.assertEqual([node['title'] for node in tree[0]['children']], ["One.A", "One.B"])
+        self.assertEqual(tree[0]['children'][0]['children'][0]['title'], "Deep")
+        self.assertEqual(tree[1], {'title': "Two", 'level': 0, 'page': None, 'children': []})
+
+    def test_skipped_level_hangs_from_nearest_shallower(self):
+        tree = nest_outline([{'title': "A", 'level': 0}, {'title': "C", 'level': 2}])
+        self.assertEqual(tree[0]['children'][0]['title'], "C")
+
+
+class TestSynthesizedOutline(unittest.TestCase):
+    """Test headings found in page text"""
+
+    def test_heading_lines(self):
+        self.assertEqual(heading_from_line("2.3.1 Card Authentication"), ("2.3.1 Card Authentication", 2))
+        self.assertEqual(heading_from_line("4. Results"), ("4. Results", 0))
+        self.assertEqual(heading_from_line("Chapter 3"), ("Chapter 3", 0))
+
+    def test_non_headings(self):
+        for line in ("Overview", "3 apples were eaten.", "1.2 Scope ....... 5", "2024 Annual Report", ""):
+            self.assertIsNone(heading_from_line(line), line)
+
+    def test_first_occurrence_per_page(self):
+        pages = ["Contents\n1 Introduction .... 2\n", "1 Introduction\nBody text.\n1.1 Scope", "1 Introduction"]
+        self.assertEqual(synthesize_outline(pages), [
+            {'title': "1 Introduction", 'level': 0, 'page': 2},
+            {'title': "1.1 Scope", 'level': 1, 'page': 2},
+        ])
+
+
+if __name__ == '__main__':
+    unittest.main()
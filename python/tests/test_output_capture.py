@@ -0,0 +1,89 @@
+"""
+Test bounded error output capture
+"""
+import unittest
+import contextvars
+import tempfile
+import threading
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.output_capture import OutputCapture, truncate_middle
+
+
+class TestOutputCapture(unittest.TestCase):
+    """Test error truncation and full-log files"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_short_text_unchanged(self):
+        self.assertEqual(truncate_middle("boom", 1024), "boom")
+
+    def test_keeps_head_and_tail(self):
+        text = "HEAD" + ("x" * 10000) + "TAIL"
+        result = truncate_middle(text, 512)
+        self.assertLessEqual(len(result.encode('utf-8')), 512)
+        self.assertTrue(result.startswith("HEAD"))
+        self.assertTrue(result.endswith("TAIL"))
+        self.assertIn("bytes omitted", result)
+
+    def test_format_error_writes_full_log(self):
+        with OutputCapture(mirror_to_stderr=False) as capture:
+            print("y" * 50000)
+        message = capture.format_error("Conversion exploded", Path(self.temp_dir))
+
+        self.assertIn("Conversion exploded", message)
+        self.assertIn("Full log:", message)
+        self.assertLess(len(message), 10000)
+
+        log_files = list(Path(self.temp_dir).glob("conversion-error-*.log"))
+        self.assertEqual(len(log_files), 1)
+        self.assertIn("y" * 50000, log_files[0].read_text(encoding='utf-8'))
+
+    def test_concurrent_captures_keep_their_own_output(self):
+        original = (sys.stdout, sys.stderr)
+        entered = threading.Barrier(2)
+        first_exited = threading.Event()
+        captured = {}
+
+        def convert(name, exit_first):
+            with OutputCapture(mirror_to_stderr=False) as capture:
+                entered.wait()
+                print(f"{name} page 1")
+                if not exit_first:
+                    first_exited.wait()
+                    print(f"{name} page 2")
+            if exit_first:
+                first_exited.set()
+            captured[name] = capture.getvalue()
+
+        workers = [threading.Thread(target=contextvars.copy_context().run, args=(convert, name, name == "a"))
+                   for name in ("a", "b")]
+        for worker in workers:
+            worker.start()
+        for worker in workers:
+            worker.join()
+
+        self.assertEqual(captured, {"a": "a page 1\n", "b": "b page 1\nb page 2\n"})
+        self.assertEqual((sys.stdout, sys.stderr), original)
+
+    def test_other_context_is_not_captured(self):
+        with OutputCapture(mirror_to_stderr=False) as capture:
+            worker = threading.Thread(target=lambda: print("unrelated", file=sys.stderr))
+            worker.start()
+            worker.join()
+            print("mine")
+        self.assertEqual(capture.getvalue(), "mine\n")
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,122 @@
+"""
+Test detection of output directories holding another document's conversion
+"""
+import unittest
+import tempfile
+import shutil
+import json
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.output_layout import OutputLayout
+from utils.output_conflict import clean_managed_output, resolve_output_conflict, OutputConflictError
+
+
+class TestOutputConflict(unittest.TestCase):
+    """Test on_conflict handling"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+        self.layout = OutputLayout(None, self.temp_dir, "my_doc")
+        root = self.layout.document_root()
+        (root / "sections").mkdir(parents=True)
+        (root / "sections" / "01-intro.md").write_text("old")
+        (root / "README.md").write_text("old")
+        (root / "notes.txt").write_text("user file")
+        (root / "manifest.json").write_text(json.dumps({
+            'document_id': 'previous',
+            'source_file': 'My Doc.pdf',
+            'sections': [{'file': 'sections/01-intro.md'}, {'file': '../../escape.md'}]
+        }))
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_same_document_is_not_a_conflict(self):
+        self.assertIsNone(resolve_output_conflict(self.layout, 'previous', 'My Doc.pdf'))
+
+    def test_error_is_default(self):
+        with self.assertRaises(OutputConflictError) as ctx:
+            resolve_output_conflict(self.layout, 'current', 'my_doc.pdf', None)
+        self.assertIn("My Doc.pdf", str(ctx.exception))
+        self.assertTrue((self.layout.document_root() / "README.md").exists())
+
+    def test_overwrite_removes_only_listed_files(self):
+        message = resolve_output_conflict(self.layout, 'current', 'my_doc.pdf', 'overwrite')
+        root = self.layout.document_root()
+        self.assertIn("removed 3", message)
+        self.assertFalse((root / "sections").exists())
+        self.assertFalse((root / "manifest.json").exists())
+        self.assertTrue((root / "notes.txt").exists())
+
+    def test_overwrite_can_leave_removal_to_the_caller(self):
+        message = resolve_output_conflict(self.layout, 'current', 'my_doc.pdf', 'overwrite', remove=False)
+        self.assertIn("once this conversion succeeds", message)
+        self.assertTrue((self.layout.document_root() / "sections" / "01-intro.md").exists())
+
+    def test_overwrite_removes_rendered_pages(self):
+        root = self.layout.document_root()
+        (root / "images").mkdir()
+        (root / "images" / "page-001.png").write_bytes(b"png")
+        manifest = json.loads((root / "manifest.json").read_text())
+        manifest['page_images'] = [{'file': 'images/page-001.png', 'page': 1}]
+        (root / "manifest.json").write_text(json.dumps(manifest))
+
+        resolve_output_conflict(self.layout, 'current', 'my_doc.pdf', 'overwrite')
+        self.assertFalse((root / "images").exists())
+
+    def test_merge_keeps_files(self):
+        message = resolve_output_conflict(self.layout, 'current', 'my_doc.pdf', 'merge')
+        self.assertIn("merge", message)
+        self.assertTrue((self.layout.document_root() / "sections" / "01-intro.md").exists())
+
+    def test_unknown_mode_rejected(self):
+        with self.assertRaises(ValueError):
+            resolve_output_conflict(self.layout, 'current', 'my_doc.pdf', 'replace')
+
+
+class TestCleanOutput(unittest.TestCase):
+    """Test clean_output removing a previous run's generated directories"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_nested_layout_removes_artifact_directories_only(self):
+        layout = OutputLayout(None, self.temp_dir, "my_doc")
+        root = layout.document_root()
+        for name in ("sections/50-appendix.md", "chunked/01-a-chunk-001.md", "images/page1_img1.png"):
+            (root / name).parent.mkdir(parents=True, exist_ok=True)
+            (root / name).write_text("old")
+        (root / "notes.txt").write_text("user file")
+        (Path(self.temp_dir) / "other_doc" / "sections").mkdir(parents=True)
+
+        cleaned = clean_managed_output(layout)
+        self.assertEqual(len(cleaned['directories']), 3)
+        self.assertFalse((root / "sections").exists())
+        self.assertFalse((root / "images").exists())
+        self.assertTrue((root / "notes.txt").exists())
+        self.assertTrue((Path(self.temp_dir) / "other_doc" / "sections").exists())
+
+    def test_flat_layout_removes_listed_files_only(self):
+        layout = OutputLayout('flat', self.temp_dir, "my_doc")
+        root = layout.document_root()
+        root.mkdir(parents=True)
+        (root / "01-intro.md").write_text("old")
+        (root / "notes.md").write_text("user file")
+        (root / "manifest.json").write_text(json.dumps({'document_id': 'my_doc', 'sections': [{'file': '01-intro.md'}]}))
+
+        cleaned = clean_managed_output(layout)
+        self.assertEqual(cleaned['directories'], [])
+        self.assertFalse((root / "01-intro.md").exists())
+        self.assertTrue((root / "notes.md").exists())
+
+
+if __name__ == '__main__':
+    unittest.main()
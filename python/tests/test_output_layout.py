@@ -0,0 +1,97 @@
+"""
+Test output layout templates
+"""
+import unittest
+import tempfile
+import shutil
+from datetime import datetime
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.output_layout import OutputLayout
+
+try:
+    import fitz  # noqa: F401
+    from modular_pdf_converter import ModularPDFConverter
+    HAS_CONVERTER = True
+except ImportError:
+    HAS_CONVERTER = False
+
+CAPTIONED_PDF = Path(__file__).parent / "fixtures" / "captioned_figures.pdf"
+
+
+class TestOutputLayout(unittest.TestCase):
+    """Test output layout resolution and validation"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+        self.base = Path(self.temp_dir)
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_default_layout_matches_legacy_structure(self):
+        """Default layout keeps README at doc root and sections in sections/"""
+        layout = OutputLayout(None, str(self.base), "my_doc")
+        self.assertEqual(layout.document_root(), self.base / "my_doc")
+        self.assertEqual(layout.path_for('sections', '01-overview.md'),
+                         self.base / "my_doc" / "sections" / "01-overview.md")
+
+    def test_presets(self):
+        """Preset names expand to their templates"""
+        date = datetime(2024, 3, 9)
+        layout = OutputLayout('by_date', str(self.base), "my_doc", date=date)
+        self.assertEqual(layout.directory_for('images'), self.base / "2024-03-09" / "my_doc" / "images")
+
+        flat = OutputLayout('flat', str(self.base), "my_doc")
+        self.assertEqual(flat.directory_for('sections'), flat.document_root())
+
+        by_artifact = OutputLayout('by_artifact', str(self.base), "my_doc")
+        self.assertEqual(by_artifact.directory_for('chunked'), self.base / "chunked" / "my_doc")
+        self.assertEqual(by_artifact.document_root(), self.base / "my_doc")
+
+    def test_invalid_templates_rejected(self):
+        """Unsafe or ambiguous templates raise ValueError"""
+        invalid = [
+            "/tmp/{doc_id}",
+            "{output_dir}/{artifact_type}",
+            "{output_dir}/../{doc_id}",
+            "{output_dir}/{doc_id}/{unknown}",
+            "{output_dir}/~{doc_id}",
+        ]
+        for template in invalid:
+            with self.assertRaises(ValueError, msg=f"Template accepted: {template!r}"):
+                OutputLayout(template, str(self.base), "my_doc")
+
+    def test_colliding_paths_rejected(self):
+        """Two artifacts cannot claim the same file in a flat layout"""
+        layout = OutputLayout('flat', str(self.base), "my_doc")
+        layout.path_for('root', 'README.md')
+        with self.assertRaises(ValueError):
+            layout.path_for('sections', 'README.md')
+
+    def test_relative_links(self):
+        """Links from the document root follow the layout"""
+        layout = OutputLayout('by_artifact', str(self.base), "my_doc")
+        self.assertEqual(layout.relative_path(layout.directory_for('sections')), "../sections/my_doc")
+
+    @unittest.skipUnless(HAS_CONVERTER, "PyMuPDF and the converter are required")
+    def test_images_follow_the_layout(self):
+        """Extracted images land in the layout's images directory and section links reach them"""
+        result = ModularPDFConverter(str(CAPTIONED_PDF), self.temp_dir, {'output_layout': 'by_artifact'}).convert()
+        doc_id = Path(result['output_directory']).name
+        images = sorted((self.base / "images" / doc_id).iterdir())
+        self.assertEqual(len(images), 2)
+        self.assertFalse((Path(result['output_directory']) / "images").exists())
+
+        sections_dir = self.base / "sections" / doc_id
+        text = "".join(path.read_text(encoding='utf-8') for path in sections_dir.glob("*.md"))
+        self.assertIn(f"(../../images/{doc_id}/{images[0].name})", text)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,80 @@
+"""
+Test output size limits
+"""
+import unittest
+import tempfile
+import shutil
+import time
+from pathlib import Path
+from unittest import mock
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.output_limits import (
+    MAX_IMAGE_BYTES, MAX_IMAGES_BYTES, MAX_OUTPUT_BYTES, OutputLimitExceeded, bytes_written_since, check_limit,
+    resolve_limit
+)
+
+
+class TestResolveLimit(unittest.TestCase):
+    """Test limit values from options and the environment"""
+
+    def test_option_in_bytes_wins(self):
+        with mock.patch.dict(os.environ, {'MAX_OUTPUT_MB': '1'}):
+            self.assertEqual(resolve_limit(MAX_OUTPUT_BYTES, 5000), 5000)
+
+    def test_environment_in_megabytes_then_default(self):
+        with mock.patch.dict(os.environ, {'MAX_IMAGE_MB': '2.5'}):
+            self.assertEqual(resolve_limit(MAX_IMAGE_BYTES), int(2.5 * 1024 * 1024))
+        with mock.patch.dict(os.environ, clear=True):
+            self.assertEqual(resolve_limit(MAX_IMAGES_BYTES), 1024 * 1024 * 1024)
+
+    def test_zero_turns_off(self):
+        self.assertIsNone(resolve_limit(MAX_OUTPUT_BYTES, 0))
+
+    def test_bad_values(self):
+        for bad in (-1, "lots", True):
+            with self.assertRaises(ValueError):
+                resolve_limit(MAX_OUTPUT_BYTES, bad)
+
+
+class TestCheckLimit(unittest.TestCase):
+    """Test the error naming the limit"""
+
+    def test_error_names_limit_and_env(self):
+        check_limit(MAX_OUTPUT_BYTES, None, 10 ** 12)
+        check_limit(MAX_OUTPUT_BYTES, 100, 100)
+        with self.assertRaises(OutputLimitExceeded) as raised:
+            check_limit(MAX_IMAGES_BYTES, 100, 150)
+        self.assertEqual(raised.exception.limit, MAX_IMAGES_BYTES)
+        self.assertEqual(raised.exception.code, 'output_limit_exceeded')
+        self.assertIn("max_images_bytes", str(raised.exception))
+        self.assertIn("MAX_IMAGES_MB", str(raised.exception))
+
+
+class TestBytesWritten(unittest.TestCase):
+    """Test measuring what a conversion wrote"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_counts_recent_files_once(self):
+        old = self.temp_dir / "old.md"
+        old.write_text("x" * 50)
+        os.utime(old, (time.time() - 3600, time.time() - 3600))
+        started = time.time()
+        (self.temp_dir / "sections").mkdir()
+        (self.temp_dir / "sections" / "01.md").write_text("y" * 20)
+        (self.temp_dir / "README.md").write_text("z" * 5)
+        # The sections directory is also passed on its own (an artifact directory)
+        self.assertEqual(bytes_written_since([self.temp_dir, self.temp_dir / "sections"], started), 25)
+
+
+if __name__ == '__main__':
+    unittest.main()
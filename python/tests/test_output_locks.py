@@ -0,0 +1,91 @@
+"""
+Test one conversion at a time per output directory
+"""
+import asyncio
+import os
+import sys
+import tempfile
+import time
+import unittest
+from pathlib import Path
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.output_locks import OutputLocks
+
+SECTIONS = 5
+
+
+def _convert(output_dir: Path, run: str, log: list) -> None:
+    """Stand-in conversion: rewrite every section file, slowly enough to interleave with another run"""
+    log.append(('start', run))
+    for index in range(SECTIONS):
+        (output_dir / f"section-{index}.md").write_text(run, encoding='utf-8')
+        time.sleep(0.005)
+    log.append(('end', run))
+
+
+class TestOutputLocks(unittest.TestCase):
+    """Test serializing conversions that share an output directory"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.base = Path(self.temp_dir.name)
+
+    def tearDown(self):
+        self.temp_dir.cleanup()
+
+    def _run_two(self, first_dir: Path, second_dir: Path) -> list:
+        locks = OutputLocks()
+        log = []
+
+        async def conversion(output_dir: Path, run: str):
+            async with locks.hold(output_dir):
+                await asyncio.get_running_loop().run_in_executor(None, _convert, output_dir, run, log)
+
+        async def main():
+            await asyncio.gather(conversion(first_dir, 'first'), conversion(second_dir, 'second'))
+            self.assertEqual(locks.keys(), [])
+
+        asyncio.run(main())
+        return log
+
+    def test_same_directory_conversions_serialize(self):
+        log = self._run_two(self.base, self.base)
+        self.assertEqual(log, [('start', 'first'), ('end', 'first'), ('start', 'second'), ('end', 'second')])
+        # Every file is from the same (last) run, none mixed
+        contents = {path.read_text(encoding='utf-8') for path in self.base.glob("section-*.md")}
+        self.assertEqual(contents, {'second'})
+
+    def test_same_directory_by_any_spelling(self):
+        (self.base / "docs").mkdir()
+        log = self._run_two(self.base / "docs", self.base / "docs" / ".." / "docs")
+        self.assertEqual([event for event, _ in log], ['start', 'end', 'start', 'end'])
+
+    def test_different_directories_run_in_parallel(self):
+        (self.base / "a").mkdir()
+        (self.base / "b").mkdir()
+        log = self._run_two(self.base / "a", self.base / "b")
+        self.assertEqual([event for event, _ in log[:2]], ['start', 'start'])
+
+    def test_cancelled_waiter_holds_nothing(self):
+        locks = OutputLocks()
+
+        async def main():
+            key = await locks.acquire(self.base)
+            waiter = asyncio.ensure_future(locks.acquire(self.base))
+            await asyncio.sleep(0)
+            waiter.cancel()
+            with self.assertRaises(asyncio.CancelledError):
+                await waiter
+            self.assertTrue(locks.locked(self.base))
+            locks.release(key)
+            self.assertFalse(locks.locked(self.base))
+            self.assertEqual(locks.keys(), [])
+
+        asyncio.run(main())
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,75 @@
+"""
+Test packaging a conversion as a ZIP archive
+"""
+import json
+import threading
+import unittest
+import tempfile
+import shutil
+import zipfile
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.output_package import package_output
+from utils.cancellation import ConversionCancelled
+
+
+class TestPackageOutput(unittest.TestCase):
+    """Test writing a conversion's folder to <folder>.zip"""
+
+    def setUp(self):
+        self.output_dir = Path(tempfile.mkdtemp())
+        self.document_dir = self.output_dir / "manual"
+        for name, content in (("README.md", "# Manual\n"), ("sections/01-overview.md", "# Overview\n"),
+                              ("chunked/chunks.jsonl", "{}\n"), ("images/page-1.png", "\x89PNG")):
+            path = self.document_dir / name
+            path.parent.mkdir(parents=True, exist_ok=True)
+            path.write_text(content, encoding='utf-8')
+        (self.document_dir / "manifest.json").write_text(json.dumps({'sections': []}), encoding='utf-8')
+
+    def tearDown(self):
+        shutil.rmtree(self.output_dir, ignore_errors=True)
+
+    def test_archive_keeps_layout(self):
+        result = package_output(str(self.output_dir))
+
+        archive = self.output_dir / "manual.zip"
+        self.assertEqual(result['archive'], str(archive))
+        self.assertEqual(result['files'], 5)
+        self.assertEqual(result['size'], archive.stat().st_size)
+        with zipfile.ZipFile(archive) as bundle:
+            self.assertEqual(sorted(bundle.namelist()), [
+                "manual/README.md", "manual/chunked/chunks.jsonl", "manual/images/page-1.png",
+                "manual/manifest.json", "manual/sections/01-overview.md"])
+            self.assertEqual(bundle.read("manual/sections/01-overview.md"), b"# Overview\n")
+            self.assertEqual(bundle.getinfo("manual/images/page-1.png").compress_type, zipfile.ZIP_STORED)
+
+            extracted = self.output_dir / "extracted"
+            bundle.extractall(extracted)
+        self.assertEqual((extracted / "manual" / "README.md").read_text(encoding='utf-8'), "# Manual\n")
+
+    def test_archive_path(self):
+        result = package_output(str(self.document_dir), archive_path=str(self.output_dir / "out" / "bundle"))
+        self.assertEqual(result['archive'], str(self.output_dir / "out" / "bundle.zip"))
+
+        with self.assertRaises(ValueError):
+            package_output(str(self.document_dir), archive_path=str(self.document_dir / "self.zip"))
+
+    def test_cancelled_leaves_no_archive(self):
+        cancel_event = threading.Event()
+        cancel_event.set()
+        with self.assertRaises(ConversionCancelled):
+            package_output(str(self.output_dir), cancel_event=cancel_event)
+        self.assertEqual(sorted(path.name for path in self.output_dir.iterdir()), ["manual"])
+
+    def test_missing_conversion(self):
+        with self.assertRaises(FileNotFoundError):
+            package_output(str(self.output_dir / "missing"))
+
+
+if __name__ == '__main__':
+    unittest.main()
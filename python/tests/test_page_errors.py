@@ -0,0 +1,87 @@
+"""
+Test that a page whose extraction fails doesn't stop the rest of the document
+"""
+import unittest
+from unittest.mock import patch
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+try:
+    from processors import pdf_extractor
+    from processors.pdf_extractor import PAGE_ERROR_MARKER, PDFExtractor
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+
+class FakePage:
+    """A page whose layout queries ("words", "dict") fail when it is broken"""
+
+    def __init__(self, number, broken):
+        self.number = number
+        self.broken = broken
+
+    def get_text(self, kind="text", **kwargs):
+        if kind != "text" and self.broken:
+            raise RuntimeError(f"bad content stream on page {self.number}")
+        if kind == "words":
+            return []
+        if kind == "dict":
+            return {'blocks': []}
+        return f"Text of page {self.number}.\n"
+
+
+class FakeDocument:
+    """Pages 1..page_count; corrupt pages fail to load, broken pages fail layout queries"""
+
+    def __init__(self, page_count, corrupt=(), broken=()):
+        self.page_count = page_count
+        self.corrupt = set(corrupt)
+        self.broken = set(broken)
+
+    def load_page(self, index):
+        if index + 1 in self.corrupt:
+            raise ValueError("cannot load page")
+        return FakePage(index + 1, index + 1 in self.broken)
+
+    def close(self):
+        pass
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestPageErrors(unittest.TestCase):
+    """Test per-page error isolation and the plain-text fallback"""
+
+    def extract(self, document, config=None):
+        with patch.object(pdf_extractor.fitz, 'open', return_value=document, create=True):
+            return PDFExtractor(config or {}).extract_from_pdf("fake.pdf")
+
+    def test_bad_page_marked_and_others_kept(self):
+        result = self.extract(FakeDocument(3, corrupt=[2]))
+        texts = [page['text'] for page in result['page_texts']]
+        self.assertEqual(texts[0], "Text of page 1.\n")
+        self.assertEqual(texts[1], PAGE_ERROR_MARKER.format(page=2) + "\n")
+        self.assertEqual(texts[2], "Text of page 3.\n")
+        self.assertEqual([error['page'] for error in result['metadata']['page_errors']], [2])
+        self.assertIn("ValueError", result['metadata']['page_errors'][0]['error'])
+        self.assertEqual(result['metadata']['extraction_method'], 'pymupdf')
+
+    def test_majority_failing_falls_back_to_plain_text(self):
+        result = self.extract(FakeDocument(3, broken=[1, 2]), {'detect_code_blocks': True})
+        self.assertEqual(result['metadata']['extraction_method'], 'basic')
+        self.assertEqual(result['metadata']['page_errors'], [])
+        self.assertEqual([page['text'] for page in result['page_texts']],
+                         ["Text of page 1.\n", "Text of page 2.\n", "Text of page 3.\n"])
+
+    def test_fallback_keeps_markers_for_unreadable_pages(self):
+        result = self.extract(FakeDocument(3, corrupt=[1, 2]))
+        self.assertEqual(result['metadata']['extraction_method'], 'basic')
+        self.assertEqual([error['page'] for error in result['metadata']['page_errors']], [1, 2])
+        self.assertIn("<!-- extraction error on page 1 -->", result['processed_text'])
+
+
+if __name__ == '__main__':
+    unittest.main()
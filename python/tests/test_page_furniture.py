@@ -0,0 +1,114 @@
+"""
+Test finding and stripping running headers and footers
+"""
+import unittest
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.page_furniture import band_lines, detect_furniture, furniture_key, page_lines, strip_furniture
+
+try:
+    from processors.pdf_extractor import extract_pdf
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+RUNNING_HEADERS_PDF = Path(__file__).parent / "fixtures" / "running_headers.pdf"
+
+HEIGHT = 792
+
+
+def page(page_num, *lines):
+    """(page number, height, lines) with (y0, text) lines 10 points tall"""
+    return page_num, HEIGHT, [(y0, y0 + 10, text) for y0, text in lines]
+
+
+class TestDetectFurniture(unittest.TestCase):
+    """Test which lines count as headers and footers"""
+
+    def test_header_and_page_numbers(self):
+        pages = [page(n, (30, "Annual Report 2024"), (300, f"Body {n}"), (750, f"Page {n} of 4")) for n in range(1, 5)]
+        furniture = detect_furniture(pages)
+        self.assertEqual(furniture[2], [('top', "Annual Report 2024"), ('bottom', "Page 2 of 4")])
+        self.assertEqual(set(furniture), {1, 2, 3, 4})
+
+    def test_body_text_is_never_furniture(self):
+        # Repeated, but in the middle of the page
+        pages = [page(n, (400, "Continued on next page")) for n in range(1, 5)]
+        self.assertEqual(detect_furniture(pages), {})
+
+    def test_needs_half_the_pages_and_at_least_three(self):
+        header = (30, "Draft")
+        self.assertEqual(detect_furniture([page(1, header), page(2, header)]), {})
+        pages = [page(n, header) if n <= 3 else page(n) for n in range(1, 9)]
+        self.assertEqual(detect_furniture(pages), {})
+        pages = [page(n, header) if n <= 4 else page(n) for n in range(1, 9)]
+        self.assertEqual(sorted(detect_furniture(pages)), [1, 2, 3, 4])
+
+    def test_text_that_moves_is_kept(self):
+        pages = [page(n, (20 + 15 * n, "Chapter 3")) for n in range(1, 5)]
+        self.assertEqual(detect_furniture(pages), {})
+
+    def test_long_lines_are_kept(self):
+        sentence = "This agreement is governed by the laws of the State of Delaware " * 2
+        self.assertEqual(detect_furniture([page(n, (30, sentence)) for n in range(1, 5)]), {})
+
+    def test_digits_are_ignored(self):
+        self.assertEqual(furniture_key("Page  12 of 40 "), "page # of #")
+
+
+class TestStripFurniture(unittest.TestCase):
+    """Test removing furniture lines from page text"""
+
+    def test_header_first_and_footer_last(self):
+        text = "Draft\nIntro\nDraft\nBody\n7\nTable 7\n7\n"
+        stripped, removed = strip_furniture(text, [('top', "Draft"), ('bottom', "7")])
+        self.assertEqual(removed, 2)
+        self.assertEqual(stripped, "Intro\nDraft\nBody\n7\nTable 7\n")
+
+    def test_missing_line_removes_nothing(self):
+        self.assertEqual(strip_furniture("Body\n", [('top', "Draft")]), ("Body\n", 0))
+
+    def test_page_lines(self):
+        page_dict = {'blocks': [
+            {'type': 0, 'lines': [{'bbox': (72, 30, 300, 40), 'spans': [{'text': "ACME "}, {'text': "Corp"}]},
+                                  {'bbox': (72, 50, 300, 60), 'spans': [{'text': " "}]}]},
+            {'type': 1, 'bbox': (0, 0, 10, 10)},
+        ]}
+        self.assertEqual(page_lines(page_dict), [(30, 40, "ACME Corp")])
+
+    def test_band_lines_drop_body_text(self):
+        _, _, lines = page(1, (30, "Annual Report 2024"), (300, "Body"), (600, "More body"), (750, "Page 1"),
+                           (760, "x" * 200))
+        self.assertEqual(band_lines(lines, HEIGHT), [(30, 40, "Annual Report 2024"), (750, 760, "Page 1")])
+        # The same furniture is found from the band lines alone
+        pages = [page(n, (30, "Annual Report 2024"), (300, f"Body {n}")) for n in range(1, 5)]
+        banded = [(n, height, band_lines(lines, height)) for n, height, lines in pages]
+        self.assertEqual(detect_furniture(banded), detect_furniture(pages))
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestRunningHeadersFixture(unittest.TestCase):
+    """Test extraction of running_headers.pdf"""
+
+    def test_strips_header_and_footer(self):
+        results = extract_pdf(str(RUNNING_HEADERS_PDF), {'strip_headers_footers': True})
+        self.assertEqual(results['metadata']['furniture_lines'], 8)
+        text = results['processed_text']
+        self.assertNotIn("Page 2 of 4", text)
+        # Page 3 repeats the header in its body, which stays
+        self.assertEqual(text.count("ACME Corp - Confidential"), 1)
+        self.assertIn("Body text of page 3", text)
+
+    def test_off_by_default(self):
+        results = extract_pdf(str(RUNNING_HEADERS_PDF))
+        self.assertEqual(results['metadata']['furniture_lines'], 0)
+        self.assertIn("Page 2 of 4", results['processed_text'])
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,142 @@
+"""
+Test page markers and how chunking keeps them
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.chunking_engine import ChunkingEngine, pack_token_chunks
+from processors.page_markers import (carry_trailing_markers, chunk_page_spans, mark_pages, marker_page,
+                                     page_marker, split_page_markers, validate_page_markers)
+from utils.frontmatter import split_front_matter
+from utils.section_stats import word_count
+
+try:
+    import fitz  # noqa: F401
+    from modular_pdf_converter import ModularPDFConverter
+    HAS_CONVERTER = True
+except ImportError:
+    HAS_CONVERTER = False
+
+ANCHOR_3 = '<a id="page-3"></a>'
+ANCHOR_4 = '<a id="page-4"></a>'
+
+
+def words(text):
+    return len(text.split())
+
+
+class WordCounter:
+    """One token per word"""
+
+    def count_tokens(self, text):
+        return words(text)
+
+    def tokenizer_name(self):
+        return "words"
+
+
+class TestMarkers(unittest.TestCase):
+    """Test marker lines in each style"""
+
+    def test_styles(self):
+        self.assertEqual(page_marker(3, 'comment'), "<!-- Page 3 -->")
+        self.assertEqual(page_marker(3, 'anchor'), ANCHOR_3)
+        self.assertEqual(page_marker(3, 'anchor', labels=True), ANCHOR_3 + "<sup>p. 3</sup>")
+        self.assertEqual(page_marker(3, 'none'), '')
+        self.assertEqual(validate_page_markers(None), 'comment')
+        with self.assertRaises(ValueError):
+            validate_page_markers('footnote')
+
+    def test_marker_page(self):
+        for line in ("<!-- Page 12 -->", '<a id="page-12"></a>', '<a id="page-12"></a><sup>p. 12</sup>'):
+            self.assertEqual(marker_page(line), 12)
+        self.assertIsNone(marker_page("Page 12"))
+        self.assertIsNone(marker_page(f"See {ANCHOR_3} here"))
+
+    def test_blank_pages_unmarked(self):
+        pages = mark_pages([{'page_num': 3, 'text': "Text."}, {'page_num': 4, 'text': "  \n"}], 'anchor')
+        self.assertEqual([page['text'] for page in pages], [f"{ANCHOR_3}\n\nText.", "  \n"])
+
+    def test_anchor_is_not_counted_as_words(self):
+        self.assertEqual(word_count(f'{ANCHOR_3}<sup>p. 3</sup>\n\nTwo words.'), 2)
+
+    def test_split(self):
+        text = f"Before.\n\n{ANCHOR_3}\n\n{ANCHOR_4}\n\nAfter.\n\n<!-- Page 5 -->\n"
+        self.assertEqual(split_page_markers(text), [('', "Before."), (f"{ANCHOR_3}\n{ANCHOR_4}", "After."),
+                                                    ("<!-- Page 5 -->", '')])
+
+    def test_trailing_markers_move_to_next_section(self):
+        sections = [{'content': f"Intro text.\n\n{ANCHOR_4}\n\n"}, {'content': "Setup text.\n"}]
+        self.assertEqual(carry_trailing_markers(sections), 1)
+        self.assertEqual(sections[0]['content'], "Intro text.\n")
+        self.assertEqual(sections[1]['content'], f"{ANCHOR_4}\n\nSetup text.\n")
+
+
+class TestChunkingMarkers(unittest.TestCase):
+    """Test that chunks keep markers whole and report the pages they cover"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_marker_stays_with_following_text(self):
+        text = (f"{ANCHOR_3}<sup>p. 3</sup>\n\nOne two three four five six.\n\n"
+                f"{ANCHOR_4}<sup>p. 4</sup>\n\nSeven eight nine ten eleven twelve.")
+        chunks = [chunk['text'] for chunk in pack_token_chunks(text, 10, 0, words)]
+        self.assertEqual(chunks, [f"{ANCHOR_3}<sup>p. 3</sup>\nOne two three four five six.",
+                                  f"{ANCHOR_4}<sup>p. 4</sup>\nSeven eight nine ten eleven twelve."])
+
+    def test_marker_kept_whole_in_long_sentence(self):
+        text = f"{ANCHOR_4}\n\n" + " ".join(f"w{index}" for index in range(12))
+        chunks = [chunk['text'] for chunk in pack_token_chunks(text, 6, 0, words)]
+        self.assertTrue(chunks[0].startswith(ANCHOR_4 + " w0"))
+        self.assertFalse(any('<a' in chunk for chunk in chunks[1:]))
+
+    def test_chunk_page_spans(self):
+        chunks = ["Carried over.", f"{ANCHOR_3}\nMore.\n\n{ANCHOR_4}\nEnd.", "Still page four."]
+        self.assertEqual(chunk_page_spans(chunks, 2, 4), [(2, 2), (3, 4), (4, 4)])
+        self.assertEqual(chunk_page_spans(["A.", "B."], 2, 4), [(2, 4), (2, 4)])
+
+    def test_chunk_front_matter_pages(self):
+        content = f"{ANCHOR_3}\n\nOne two three four five six.\n\n{ANCHOR_4}\n\nSeven eight nine ten eleven."
+        engine = ChunkingEngine(str(self.temp_dir), WordCounter())
+        result = engine.create_budget_chunks([{'title': "Setup", 'content': content, 'page_start': 3,
+                                               'page_end': 4}], 50)
+        self.assertEqual(result['total_chunks'], 1)
+        fields, _ = split_front_matter(Path(result['chunk_files'][0]).read_text(encoding='utf-8'))
+        self.assertEqual((fields['page_start'], fields['page_end']), (3, 4))
+        result = engine.create_budget_chunks([{'title': "Setup", 'content': content, 'page_start': 3,
+                                               'page_end': 4}], 10)
+        spans = [split_front_matter(Path(path).read_text(encoding='utf-8'))[0] for path in result['chunk_files']]
+        self.assertEqual([(fields['page_start'], fields['page_end']) for fields in spans], [(3, 3), (4, 4)])
+
+
+@unittest.skipUnless(HAS_CONVERTER, "PyMuPDF and the converter are required")
+class TestSplitSectionPages(unittest.TestCase):
+    """Test each part of a split section covers only its own pages"""
+
+    def test_parts_get_their_own_pages(self):
+        converter = ModularPDFConverter.__new__(ModularPDFConverter)
+        converter.token_counter = WordCounter()
+        page = ' '.join(["word"] * 20000)
+        section_md = (f"---\ntitle: Setup\npage_start: 3\npage_end: 4\n---\n# Setup\n---\n"
+                      f"{ANCHOR_3}\n\n{page}\n\n{ANCHOR_4}\n\n{page}\n")
+        parts = converter.split_large_section(section_md, "Setup", {'pages': [3, 4], 'page_start': 3, 'page_end': 4})
+        self.assertEqual([pages for _, pages in parts], [[3], [4]])
+        fields = [split_front_matter(part)[0] for part, _ in parts]
+        self.assertEqual([(field['page_start'], field['page_end']) for field in fields], [(3, 3), (4, 4)])
+        self.assertNotIn(ANCHOR_4, parts[0][0])
+        self.assertIn(ANCHOR_4, parts[1][0])
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,50 @@
+"""
+Test page range parsing and validation
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.page_range import page_range_spans, parse_page_range
+
+
+class TestPageRange(unittest.TestCase):
+    """Test page range specs"""
+
+    def test_single_range(self):
+        self.assertEqual(parse_page_range("100-140"), list(range(100, 141)))
+
+    def test_pages_and_ranges(self):
+        self.assertEqual(parse_page_range("1,5,9-12"), [1, 5, 9, 10, 11, 12])
+        self.assertEqual(parse_page_range(" 9 - 10 , 3, 9 "), [3, 9, 10])
+
+    def test_malformed(self):
+        for spec in ("", "1-", "a-b", "1,,2", "1-2-3", "5:9"):
+            with self.assertRaises(ValueError, msg=spec):
+                parse_page_range(spec)
+
+    def test_backwards_and_zero(self):
+        with self.assertRaises(ValueError) as context:
+            parse_page_range("12-9")
+        self.assertIn("ends before it starts", str(context.exception))
+        with self.assertRaises(ValueError):
+            parse_page_range("0-3")
+
+    def test_past_end_of_document(self):
+        self.assertEqual(parse_page_range("899-900", page_count=900), [899, 900])
+        with self.assertRaises(ValueError) as context:
+            parse_page_range("890-910", page_count=900)
+        self.assertIn("page 910 is past the end of the document (900 pages)", str(context.exception))
+
+    def test_huge_range_rejected_before_expanding(self):
+        self.assertEqual(page_range_spans("1-2000000000, 7"), [(1, 2000000000), (7, 7)])
+        with self.assertRaises(ValueError) as context:
+            parse_page_range("3, 1-2000000000", page_count=900)
+        self.assertIn("page 2000000000 is past the end", str(context.exception))
+
+
+if __name__ == '__main__':
+    unittest.main()
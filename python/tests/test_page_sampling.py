@@ -0,0 +1,43 @@
+"""
+Test seeded, stratified page sampling
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.sampling import parse_sample_spec, select_sample_pages
+
+
+class TestPageSampling(unittest.TestCase):
+    """Test sample size parsing and page selection"""
+
+    def test_spec_parsing(self):
+        self.assertEqual(parse_sample_spec(12), ('count', 12))
+        self.assertEqual(parse_sample_spec("12"), ('count', 12))
+        self.assertEqual(parse_sample_spec("10%"), ('percent', 10.0))
+        for bad in (0, "-3", "0%", "150%", "ten"):
+            with self.assertRaises(ValueError):
+                parse_sample_spec(bad)
+
+    def test_same_seed_same_pages(self):
+        self.assertEqual(select_sample_pages(500, 25, seed=7), select_sample_pages(500, 25, seed=7))
+        self.assertNotEqual(select_sample_pages(500, 25, seed=7), select_sample_pages(500, 25, seed=8))
+
+    def test_sample_spreads_across_document(self):
+        pages = select_sample_pages(1000, 10, seed=3)
+        self.assertEqual(len(pages), 10)
+        self.assertEqual(pages, sorted(pages))
+        # One page from each tenth of the document
+        self.assertEqual([(page - 1) // 100 for page in pages], list(range(10)))
+
+    def test_percentage_and_bounds(self):
+        self.assertEqual(len(select_sample_pages(200, "5%")), 10)
+        self.assertEqual(select_sample_pages(3, 10), [1, 2, 3])
+        self.assertEqual(len(select_sample_pages(7, "1%")), 1)
+
+
+if __name__ == '__main__':
+    unittest.main()
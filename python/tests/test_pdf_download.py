@@ -0,0 +1,165 @@
+"""
+Test downloading PDFs given as URLs
+"""
+import unittest
+import tempfile
+import shutil
+import threading
+import time
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+from pathlib import Path
+from unittest.mock import patch
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.pdf_download import PDFDownloadError, check_download_url, download_filename, download_pdf, is_pdf_url
+
+PDF_BYTES = b"%PDF-1.4\n1 0 obj <<>> endobj\n%%EOF\n"
+
+
+class FixtureHandler(BaseHTTPRequestHandler):
+    """Serves a PDF, an HTML page, redirects, an octet-stream PDF, and a slow PDF"""
+
+    REDIRECTS = {"/moved": "/files/Spec%20v2.pdf", "/to-ftp": "ftp://example.com/spec.pdf",
+                 "/to-metadata": "http://169.254.169.254/latest/spec.pdf"}
+
+    def do_GET(self):
+        if self.path in self.REDIRECTS:
+            self.send_response(302)
+            self.send_header("Location", self.REDIRECTS[self.path])
+            self.end_headers()
+            return
+        if self.path == "/slow.pdf":
+            self.send_response(200)
+            self.send_header("Content-Type", "application/pdf")
+            self.send_header("Content-Length", "1000")
+            self.end_headers()
+            try:
+                for byte in PDF_BYTES[:20]:
+                    self.wfile.write(bytes([byte]))
+                    self.wfile.flush()
+                    time.sleep(0.1)
+            except OSError:
+                pass
+            return
+        if self.path == "/missing.pdf":
+            self.send_error(404)
+            return
+        body, content_type = PDF_BYTES, "application/pdf"
+        if self.path == "/page.html":
+            body, content_type = b"<html>Sign in</html>", "text/html; charset=utf-8"
+        elif self.path == "/download":
+            content_type = "application/octet-stream"
+        self.send_response(200)
+        self.send_header("Content-Type", content_type)
+        self.send_header("Content-Length", str(len(body)))
+        if self.path == "/download":
+            self.send_header("Content-Disposition", 'attachment; filename="Annual Report.pdf"')
+        self.end_headers()
+        self.wfile.write(body)
+
+    def log_message(self, *args):
+        pass
+
+
+class TestDownloadPdf(unittest.TestCase):
+    """Test downloads from a local HTTP server"""
+
+    @classmethod
+    def setUpClass(cls):
+        cls.server = ThreadingHTTPServer(("127.0.0.1", 0), FixtureHandler)
+        cls.server.daemon_threads = True
+        cls.base_url = f"http://127.0.0.1:{cls.server.server_address[1]}"
+        threading.Thread(target=cls.server.serve_forever, daemon=True).start()
+
+    @classmethod
+    def tearDownClass(cls):
+        cls.server.shutdown()
+        cls.server.server_close()
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def download(self, path, **kwargs):
+        # The fixture server is on loopback, which only an intranet setup allows
+        return download_pdf(f"{self.base_url}{path}", self.temp_dir, allow_private=True, **kwargs)
+
+    def test_redirect_followed_and_named_from_final_url(self):
+        pdf_file = self.download("/moved")
+        self.assertEqual(pdf_file.name, "Spec v2.pdf")
+        self.assertEqual(pdf_file.read_bytes(), PDF_BYTES)
+
+    def test_octet_stream_named_from_content_disposition(self):
+        self.assertEqual(self.download("/download").name, "Annual Report.pdf")
+
+    def test_html_rejected(self):
+        with self.assertRaisesRegex(PDFDownloadError, "content type text/html"):
+            self.download("/page.html")
+
+    def test_http_error(self):
+        with self.assertRaisesRegex(PDFDownloadError, "HTTP 404"):
+            self.download("/missing.pdf")
+
+    def test_size_limit(self):
+        with self.assertRaisesRegex(PDFDownloadError, "too large"):
+            self.download("/spec.pdf", max_bytes=10)
+
+    def test_loopback_refused_by_default(self):
+        with self.assertRaisesRegex(PDFDownloadError, "not a public address"):
+            download_pdf(f"{self.base_url}/spec.pdf", self.temp_dir, allow_private=False)
+        self.assertEqual(list(self.temp_dir.iterdir()), [])
+
+    def test_redirect_to_other_scheme_refused(self):
+        with self.assertRaisesRegex(PDFDownloadError, "only http"):
+            self.download("/to-ftp")
+
+    def test_redirect_to_private_address_refused(self):
+        real_check = check_download_url
+
+        def check(url, allow_private=False):
+            # Let the loopback fixture through as if it were public; check the redirect target for real
+            real_check(url, url.startswith(self.base_url))
+
+        with patch('utils.pdf_download.check_download_url', side_effect=check):
+            with self.assertRaisesRegex(PDFDownloadError, "169.254.169.254"):
+                download_pdf(f"{self.base_url}/to-metadata", self.temp_dir, allow_private=False)
+
+    def test_slow_server_stopped_at_deadline(self):
+        started = time.monotonic()
+        with self.assertRaisesRegex(PDFDownloadError, "longer than"):
+            self.download("/slow.pdf", timeout=5, max_seconds=0.5)
+        self.assertLess(time.monotonic() - started, 1.5)
+
+
+class TestNames(unittest.TestCase):
+    """Test URL detection and file names"""
+
+    def test_is_pdf_url(self):
+        self.assertTrue(is_pdf_url("HTTPS://example.com/a.pdf"))
+        self.assertFalse(is_pdf_url("/tmp/a.pdf"))
+        self.assertFalse(is_pdf_url("ftp://example.com/a.pdf"))
+
+    def test_non_public_addresses(self):
+        for url in ("http://127.0.0.1/a.pdf", "http://[::1]/a.pdf", "http://169.254.169.254/a.pdf",
+                    "http://10.1.2.3/a.pdf", "http://192.168.0.1/a.pdf", "http://[::ffff:127.0.0.1]/a.pdf"):
+            with self.assertRaisesRegex(PDFDownloadError, "not a public address"):
+                check_download_url(url)
+        check_download_url("http://8.8.8.8/a.pdf")
+        check_download_url("http://10.1.2.3/a.pdf", allow_private=True)
+        with self.assertRaisesRegex(PDFDownloadError, "only http"):
+            check_download_url("file:///etc/passwd", allow_private=True)
+
+    def test_filename_fallbacks(self):
+        self.assertEqual(download_filename("https://example.com/"), "document.pdf")
+        self.assertEqual(download_filename("https://example.com/get?id=3"), "get.pdf")
+        self.assertEqual(download_filename("https://example.com/x", "inline; filename=../../etc.pdf"), "etc.pdf")
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,79 @@
+"""
+Test combining several PDFs into one document
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.pdf_merge import merge_pdfs, source_file_for_page
+
+try:
+    import fitz
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+PARTS = [
+    {'file': 'vol1.pdf', 'page_start': 1, 'page_end': 3, 'page_count': 3},
+    {'file': 'vol2.pdf', 'page_start': 4, 'page_end': 4, 'page_count': 1},
+]
+
+
+class TestSourceFileForPage(unittest.TestCase):
+    """Test mapping combined pages back to their files"""
+
+    def test_pages_map_to_files(self):
+        self.assertEqual(source_file_for_page(PARTS, 3)['file'], 'vol1.pdf')
+        self.assertEqual(source_file_for_page(PARTS, 4)['file'], 'vol2.pdf')
+
+    def test_unknown_page(self):
+        self.assertIsNone(source_file_for_page(PARTS, None))
+        self.assertIsNone(source_file_for_page(PARTS, 9))
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestMergePdfs(unittest.TestCase):
+    """Test page offsets and bookmarks in the combined PDF"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def make_pdf(self, name, pages, chapter):
+        doc = fitz.open()
+        for page in range(pages):
+            doc.new_page().insert_text((72, 72), f"# {chapter} page {page + 1}")
+        doc.set_toc([[1, chapter, 1], [2, f"{chapter} details", pages]])
+        path = self.temp_dir / name
+        doc.save(str(path))
+        doc.close()
+        return str(path)
+
+    def test_pages_and_bookmarks_offset(self):
+        paths = [self.make_pdf("vol1.pdf", 3, "Chapter 1: Intro"), self.make_pdf("vol2.pdf", 2, "Chapter 2: Setup")]
+        merged = self.temp_dir / "manual.pdf"
+        parts = merge_pdfs(paths, str(merged))
+
+        self.assertEqual([(p['file'], p['page_start'], p['page_end']) for p in parts],
+                         [('vol1.pdf', 1, 3), ('vol2.pdf', 4, 5)])
+        doc = fitz.open(str(merged))
+        try:
+            self.assertEqual(doc.page_count, 5)
+            self.assertEqual(doc.get_toc(simple=True), [
+                [1, 'Chapter 1: Intro', 1], [2, 'Chapter 1: Intro details', 3],
+                [1, 'Chapter 2: Setup', 4], [2, 'Chapter 2: Setup details', 5]])
+            self.assertIn("Chapter 2: Setup page 1", doc.load_page(3).get_text())
+        finally:
+            doc.close()
+
+
+if __name__ == '__main__':
+    unittest.main()
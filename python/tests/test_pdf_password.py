@@ -0,0 +1,97 @@
+"""
+Test opening password-protected PDFs
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.pdf_password import (PDFPasswordError, PASSWORD_REQUIRED, WRONG_PASSWORD,
+                                check_pdf_password, decrypt_pdf)
+
+try:
+    import fitz
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+try:
+    import pypdf  # noqa: F401
+    HAS_PYPDF = True
+except ImportError:
+    HAS_PYPDF = False
+
+
+class TestPasswordError(unittest.TestCase):
+    """Test the error reported for encrypted PDFs"""
+
+    def test_message_names_file_and_code(self):
+        error = PDFPasswordError("/docs/secret.pdf", WRONG_PASSWORD)
+        self.assertEqual(error.code, WRONG_PASSWORD)
+        self.assertIn("secret.pdf", str(error))
+        self.assertIn("error_code: wrong_password", str(error))
+        self.assertNotIn("/docs", str(error))
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestEncryptedPdf(unittest.TestCase):
+    """Test checking and decrypting a PDF with an open password"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+        self.encrypted = self.make_pdf("secret.pdf", user_pw="letmein")
+        self.plain = self.make_pdf("plain.pdf")
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def make_pdf(self, name, user_pw=None):
+        doc = fitz.open()
+        doc.new_page().insert_text((72, 72), "Quarterly results")
+        path = self.temp_dir / name
+        if user_pw:
+            doc.save(str(path), encryption=fitz.PDF_ENCRYPT_AES_256, user_pw=user_pw, owner_pw="owner")
+        else:
+            doc.save(str(path))
+        doc.close()
+        return str(path)
+
+    def test_decrypt_requires_password(self):
+        copy = str(self.temp_dir / "copy.pdf")
+        for password, code in ((None, PASSWORD_REQUIRED), ("guess", WRONG_PASSWORD)):
+            with self.assertRaises(PDFPasswordError) as raised:
+                decrypt_pdf(self.encrypted, password, copy)
+            self.assertEqual(raised.exception.code, code)
+        self.assertFalse(Path(copy).exists())
+
+    def test_decrypted_copy_opens(self):
+        copy = str(self.temp_dir / "copy.pdf")
+        self.assertTrue(decrypt_pdf(self.encrypted, "letmein", copy))
+        doc = fitz.open(copy)
+        try:
+            self.assertFalse(doc.needs_pass)
+            self.assertIn("Quarterly results", doc.load_page(0).get_text())
+        finally:
+            doc.close()
+
+    def test_plain_pdf_needs_no_copy(self):
+        copy = str(self.temp_dir / "copy.pdf")
+        self.assertFalse(decrypt_pdf(self.plain, None, copy))
+        self.assertFalse(Path(copy).exists())
+
+    @unittest.skipUnless(HAS_PYPDF, "pypdf is required")
+    def test_check_password(self):
+        self.assertFalse(check_pdf_password(self.plain))
+        self.assertTrue(check_pdf_password(self.encrypted, "letmein"))
+        with self.assertRaises(PDFPasswordError) as raised:
+            check_pdf_password(self.encrypted)
+        self.assertEqual(raised.exception.code, PASSWORD_REQUIRED)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,127 @@
+"""
+Test the validate_pdf pre-flight check
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.preflight import read_signature, sample_page_indexes, text_layer_summary, time_bucket, validate_pdf
+
+try:
+    import fitz
+    import pypdf  # noqa: F401
+    HAS_PDF_LIBS = True
+except ImportError:
+    HAS_PDF_LIBS = False
+
+
+class TestSignature(unittest.TestCase):
+    """Test the %PDF- signature and version"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def write(self, name, data):
+        path = self.temp_dir / name
+        path.write_bytes(data)
+        return str(path)
+
+    def test_version_from_header(self):
+        self.assertEqual(read_signature(self.write("a.pdf", b"%PDF-1.7\n%%EOF")), "1.7")
+        # Leading junk before the signature is tolerated
+        self.assertEqual(read_signature(self.write("b.pdf", b"\xef\xbb\xbf%PDF-2.0\n")), "2.0")
+
+    def test_not_a_pdf_is_reported_not_raised(self):
+        result = validate_pdf(self.write("report.pdf", b"PK\x03\x04 a zip file"))
+        self.assertFalse(result['valid'])
+        self.assertFalse(result['openable'])
+        self.assertEqual(result['error_code'], 'not_a_pdf')
+        self.assertIn("report.pdf", result['error'])
+        self.assertEqual(result['file_size_bytes'], 15)
+
+
+class TestEstimates(unittest.TestCase):
+    """Test text layer sampling and the time bucket"""
+
+    def test_sample_spreads_over_document(self):
+        self.assertEqual(sample_page_indexes(3), [0, 1, 2])
+        pages = sample_page_indexes(100)
+        self.assertEqual(len(pages), 10)
+        self.assertEqual((pages[0], pages[-1]), (0, 99))
+
+    def test_scanned_when_almost_no_page_has_text(self):
+        self.assertTrue(text_layer_summary([0, 3, 0, 0, 0, 0, 0, 0, 0, 0])['scanned'])
+        summary = text_layer_summary([500, 0, 800])
+        self.assertFalse(summary['scanned'])
+        self.assertEqual((summary['pages_with_text'], summary['coverage']), (2, 0.67))
+        self.assertFalse(text_layer_summary([])['scanned'])
+
+    def test_time_buckets(self):
+        self.assertEqual(time_bucket(12, 12 * 50_000), 'under_10_seconds')
+        self.assertEqual(time_bucket(150, 150 * 50_000), 'under_1_minute')
+        # Image-heavy pages count double
+        self.assertEqual(time_bucket(150, 150 * 500_000), 'under_5_minutes')
+        self.assertEqual(time_bucket(5000, 5000 * 50_000), 'over_5_minutes')
+
+
+@unittest.skipUnless(HAS_PDF_LIBS, "PyMuPDF and pypdf are required")
+class TestValidatePdf(unittest.TestCase):
+    """Test checking real PDFs"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def make_pdf(self, name, pages, text="Quarterly results for the northern region", user_pw=None):
+        doc = fitz.open()
+        for _ in range(pages):
+            page = doc.new_page()
+            if text:
+                page.insert_text((72, 72), text)
+        path = self.temp_dir / name
+        if user_pw:
+            doc.save(str(path), encryption=fitz.PDF_ENCRYPT_AES_256, user_pw=user_pw, owner_pw="owner")
+        else:
+            doc.save(str(path))
+        doc.close()
+        return str(path)
+
+    def test_text_pdf(self):
+        result = validate_pdf(self.make_pdf("report.pdf", 3))
+        self.assertTrue(result['valid'] and result['openable'])
+        self.assertEqual(result['page_count'], 3)
+        self.assertFalse(result['encrypted'])
+        self.assertFalse(result['scanned'])
+        self.assertEqual(result['estimated_time'], 'under_10_seconds')
+        self.assertEqual(result['warnings'], [])
+
+    def test_pages_without_text_look_scanned(self):
+        result = validate_pdf(self.make_pdf("scan.pdf", 4, text=None))
+        self.assertTrue(result['scanned'])
+        self.assertIn("scanned", result['warnings'][0])
+
+    def test_encrypted_pdf(self):
+        path = self.make_pdf("secret.pdf", 2, user_pw="letmein")
+        result = validate_pdf(path)
+        self.assertTrue(result['valid'] and result['encrypted'] and result['needs_password'])
+        self.assertFalse(result['openable'])
+        self.assertEqual(result['error_code'], 'password_required')
+
+        result = validate_pdf(path, "letmein")
+        self.assertTrue(result['openable'])
+        self.assertEqual(result['page_count'], 2)
+
+
+if __name__ == '__main__':
+    unittest.main()
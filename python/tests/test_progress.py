@@ -0,0 +1,79 @@
+"""
+Test structured progress lines and their capture while a converter runs
+"""
+import unittest
+import contextvars
+import threading
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.progress import format_progress_line, parse_progress_line, parse_log_line
+from utils.output_capture import OutputCapture
+
+
+class TestProgressLines(unittest.TestCase):
+    """Test the PROGRESS line format"""
+
+    def test_round_trip(self):
+        line = format_progress_line(42, 120, "extracting page 42")
+        self.assertEqual(line, "PROGRESS 42/120 extracting page 42")
+        self.assertEqual(parse_progress_line(line), {'progress': 42, 'total': 120, 'message': "extracting page 42"})
+
+    def test_without_message(self):
+        self.assertEqual(parse_progress_line("PROGRESS 1/3"), {'progress': 1, 'total': 3, 'message': ''})
+
+    def test_ordinary_output_ignored(self):
+        self.assertIsNone(parse_progress_line("Step 1: Extracting PDF content..."))
+        self.assertIsNone(parse_progress_line("PROGRESS report follows"))
+
+
+class TestLogLines(unittest.TestCase):
+    """Test the level given to ordinary output lines"""
+
+    def test_levels_from_prefix(self):
+        self.assertEqual(parse_log_line("Step 1: Extracting PDF content..."),
+                         {'level': 'info', 'message': "Step 1: Extracting PDF content..."})
+        self.assertEqual(parse_log_line("  ⚠️ Large document\n")['level'], 'warning')
+        self.assertEqual(parse_log_line("Conversion failed after 1.00 seconds: boom")['level'], 'error')
+
+    def test_progress_and_blank_lines_skipped(self):
+        self.assertIsNone(parse_log_line("PROGRESS 1/3 extracting page 1"))
+        self.assertIsNone(parse_log_line("   "))
+
+
+class TestLineCapture(unittest.TestCase):
+    """Test that captured output reports complete lines as they are printed"""
+
+    def test_lines_reported_while_capturing(self):
+        lines = []
+        with OutputCapture(mirror_to_stderr=False, on_line=lines.append) as capture:
+            print("Step 1", flush=True)
+            sys.stdout.write("PROGRESS 1/2 ext")
+            sys.stdout.write("racting page 1\nPROGRESS 2/2 extracting page 2\n")
+            print("partial", end="")
+        self.assertEqual(lines, ["Step 1", "PROGRESS 1/2 extracting page 1", "PROGRESS 2/2 extracting page 2"])
+        self.assertIn("partial", capture.getvalue())
+
+    def test_lines_from_worker_thread(self):
+        lines = []
+        with OutputCapture(mirror_to_stderr=False, on_line=lines.append):
+            # Started with the capturing context, as run_cancellable starts converter workers
+            context = contextvars.copy_context()
+            worker = threading.Thread(target=context.run, args=(lambda: print(format_progress_line(1, 1, "done")),))
+            worker.start()
+            worker.join()
+        self.assertEqual([parse_progress_line(line)['progress'] for line in lines], [1])
+
+    def test_failing_listener_does_not_break_output(self):
+        def listener(line):
+            raise RuntimeError("listener failed")
+        with OutputCapture(mirror_to_stderr=False, on_line=listener) as capture:
+            print("still captured")
+        self.assertIn("still captured", capture.getvalue())
+
+
+if __name__ == '__main__':
+    unittest.main()
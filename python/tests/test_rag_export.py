@@ -0,0 +1,118 @@
+"""
+Test chunk records for vector stores (chunks.jsonl)
+"""
+import json
+import tempfile
+import unittest
+import sys
+import os
+from pathlib import Path
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.rag_export import chunk_heading_paths, chunk_records, validate_vector_db_format, write_chunks_jsonl
+
+try:
+    import pypdf  # noqa: F401
+    import pdfplumber  # noqa: F401
+    from pdf_to_rag import PDFToRAGProcessor
+    HAS_RAG_PIPELINE = True
+except ImportError:
+    HAS_RAG_PIPELINE = False
+
+
+def _chunk(section_id, title, chunk, chunks, text):
+    return {'file': f"{section_id:02d}-chunk-{chunk:03d}.md", 'section_id': section_id, 'section_title': title,
+            'chunk': chunk, 'chunks': chunks, 'tokens': len(text) // 4, 'page_start': 3, 'page_end': 5,
+            'text': text}
+
+
+class TestHeadingPaths(unittest.TestCase):
+    """Test the heading path where each chunk starts"""
+
+    def test_path_follows_headings_across_chunks(self):
+        paths = chunk_heading_paths("Authentication", [
+            "# Authentication\n\nIntro text.\n\n## Tokens\n\nTokens expire.",
+            "More about tokens.\n\n### Refresh\n\nRefresh them.",
+            "## Errors\n\nError codes.",
+        ])
+        self.assertEqual(paths, [
+            ["Authentication"],
+            ["Authentication", "Tokens"],
+            ["Authentication", "Errors"],
+        ])
+
+    def test_headings_in_code_fences_are_ignored(self):
+        paths = chunk_heading_paths("Setup", ["```bash\n# install\npip install x\n```", "Done."])
+        self.assertEqual(paths, [["Setup"], ["Setup"]])
+
+    def test_parents_prefix_every_path(self):
+        paths = chunk_heading_paths("3.2 Authentication", ["Sign in.\n\n## Tokens\n\nText.", "### Refresh\n\nMore."],
+                                    ["Chapter 3"])
+        self.assertEqual(paths, [["Chapter 3", "3.2 Authentication"],
+                                 ["Chapter 3", "3.2 Authentication", "Tokens", "Refresh"]])
+
+    def test_chunk_of_only_headings(self):
+        self.assertEqual(chunk_heading_paths("Setup", ["## Linux"]), [["Setup", "Linux"]])
+
+
+class TestRecords(unittest.TestCase):
+    """Test the JSON Lines records"""
+
+    def test_records_carry_source_section_and_pages(self):
+        chunks = [
+            _chunk(1, "Overview", 1, 1, "The overview."),
+            _chunk(2, "Authentication", 1, 2, "## Tokens\n\nTokens expire."),
+            _chunk(2, "Authentication", 2, 2, "Refresh them."),
+        ]
+        records = chunk_records(chunks, "manual.pdf", "abc123")
+        self.assertEqual([record['id'] for record in records], ["abc123:1:1", "abc123:2:1", "abc123:2:2"])
+        metadata = records[2]['metadata']
+        self.assertEqual(metadata['source'], "manual.pdf")
+        self.assertEqual(metadata['section'], "Authentication")
+        self.assertEqual(metadata['heading_path'], ["Authentication", "Tokens"])
+        self.assertEqual((metadata['page_start'], metadata['page_end']), (3, 5))
+        self.assertEqual((metadata['chunk'], metadata['chunks']), (2, 2))
+        self.assertEqual(records[2]['text'], "Refresh them.")
+
+    def test_one_json_object_per_line(self):
+        records = chunk_records([_chunk(1, "Überblick", 1, 1, "Zeile eins\nZeile zwei")], "handbuch.pdf", "d1")
+        with tempfile.TemporaryDirectory() as temp_dir:
+            path = Path(temp_dir) / "chunked" / "chunks.jsonl"
+            self.assertEqual(write_chunks_jsonl(records, path), 1)
+            lines = path.read_text(encoding='utf-8').splitlines()
+        self.assertEqual(len(lines), 1)
+        self.assertIn("Überblick", lines[0])
+        self.assertEqual(json.loads(lines[0]), records[0])
+
+    def test_vector_db_format_validation(self):
+        self.assertIsNone(validate_vector_db_format(None))
+        self.assertEqual(validate_vector_db_format('chromadb'), 'chromadb')
+        with self.assertRaises(ValueError):
+            validate_vector_db_format('faiss')
+
+
+@unittest.skipUnless(HAS_RAG_PIPELINE, "pypdf and pdfplumber are required")
+class TestVectorDBFormat(unittest.TestCase):
+    """Test records written through the prepare_pdf_for_rag pipeline"""
+
+    def test_records_keep_ids_and_pages(self):
+        records = chunk_records([_chunk(2, "Authentication", 1, 1, "## Tokens\n\nTokens expire.")],
+                                "manual.pdf", "abc123")
+        with tempfile.TemporaryDirectory() as temp_dir:
+            processor = PDFToRAGProcessor("manual.pdf", temp_dir, vector_db_format='chromadb')
+            processor.doc_id = "abc123"
+            chunks = processor.chunks_from_records(records)
+            self.assertEqual(chunks[0]['chunk_id'], "abc123:2:1")
+            self.assertEqual(chunks[0]['metadata']['source_pages'], [3, 4, 5])
+            self.assertEqual(chunks[0]['metadata']['heading_path'], ["Authentication", "Tokens"])
+            vector_file = processor.write_vector_db_format(chunks)
+            self.assertEqual(vector_file.name, "chromadb_format.json")
+            exported = json.loads(vector_file.read_text(encoding='utf-8'))
+        self.assertEqual(exported['ids'], ["abc123:2:1"])
+        self.assertEqual(exported['collection_name'], "pdf_abc123")
+
+
+if __name__ == '__main__':
+    unittest.main()
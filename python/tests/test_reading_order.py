@@ -0,0 +1,124 @@
+"""
+Test reading order correction and right-to-left lines
+"""
+import unittest
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.columns import order_blocks
+from processors.reading_order import (out_of_order, page_direction, rtl_blocks, sort_blocks,
+                                      validate_reading_order, visual_to_logical)
+
+try:
+    from processors.pdf_extractor import PDFExtractor
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+SIDEBAR_FIRST_PDF = Path(__file__).parent / "fixtures" / "sidebar_first.pdf"
+
+# "Shalom ABC 2024 olam (tov)." in logical order, and as drawn left to right on the page
+LOGICAL = "שלום ABC 2024 עולם (טוב)."
+VISUAL = ".(בוט) םלוע ABC 2024 םולש"
+
+
+def block(x0, y0, x1, y1, text):
+    return (x0, y0, x1, y1, text)
+
+
+def texts(blocks):
+    return [b[4] for b in blocks]
+
+
+def rawdict_line(text, x0=100.0, width=6.0):
+    """A rawdict line holding text's characters, given left to right, in shuffled drawing order"""
+    chars = [{'c': char, 'bbox': (x0 + width * index, 100, x0 + width * (index + 1), 112)}
+             for index, char in enumerate(text)]
+    return {'spans': [{'chars': chars[1::2] + chars[::2]}]}
+
+
+class TestVisualToLogical(unittest.TestCase):
+    """Test turning visual right-to-left lines into logical order"""
+
+    def test_embedded_ltr_runs_keep_their_order(self):
+        self.assertEqual(visual_to_logical(VISUAL), LOGICAL)
+
+    def test_number_at_the_end(self):
+        self.assertEqual(visual_to_logical("2024 םולש"), "שלום 2024")
+
+    def test_ltr_line_unchanged(self):
+        self.assertEqual(visual_to_logical("Hello (world) 42."), "Hello (world) 42.")
+
+    def test_direction(self):
+        self.assertEqual(page_direction('auto', LOGICAL), 'rtl')
+        self.assertEqual(page_direction('auto', "An English page with one שלום word"), 'ltr')
+        self.assertIsNone(page_direction('raw', LOGICAL))
+        self.assertEqual(validate_reading_order(None), 'auto')
+        with self.assertRaises(ValueError):
+            validate_reading_order('ttb')
+
+
+class TestBlockOrder(unittest.TestCase):
+    """Test sorting blocks into reading order"""
+
+    # Drawn sidebar first: it sits at the foot of the page, under the title and body
+    PAGE = [block(72, 620, 540, 640, "Sidebar"), block(72, 60, 300, 80, "Title"),
+            block(72, 120, 540, 160, "Body")]
+
+    def test_sidebar_drawn_first_is_out_of_order(self):
+        self.assertTrue(out_of_order(self.PAGE))
+        self.assertEqual(texts(sort_blocks(self.PAGE)), ["Title", "Body", "Sidebar"])
+
+    def test_next_column_is_not_out_of_order(self):
+        # The right column's top sits above the left column's end, but not over it
+        page = [block(72, 80, 290, 300, "Left"), block(320, 80, 540, 300, "Right")]
+        self.assertFalse(out_of_order(page))
+
+    def test_rows_by_direction(self):
+        row = [block(72, 80, 200, 100, "A"), block(400, 82, 540, 100, "B"), block(72, 140, 540, 160, "C")]
+        self.assertEqual(texts(sort_blocks(row, 'ltr')), ["A", "B", "C"])
+        self.assertEqual(texts(sort_blocks(row, 'rtl')), ["B", "A", "C"])
+
+    def test_columns_not_interleaved_by_rows(self):
+        page = [block(72, 80, 290, 140, "L1"), block(320, 80, 540, 140, "R1"),
+                block(72, 150, 290, 210, "L2"), block(320, 150, 540, 210, "R2")]
+        self.assertEqual(texts(sort_blocks(page, 'ltr')), ["L1", "L2", "R1", "R2"])
+        self.assertEqual(texts(sort_blocks(page, 'rtl')), ["R1", "R2", "L1", "L2"])
+
+    def test_rtl_columns_read_right_first(self):
+        page = [block(72, 80, 290, 140, "L1"), block(320, 80, 540, 140, "R1"),
+                block(72, 150, 290, 210, "L2"), block(320, 150, 540, 210, "R2")]
+        self.assertEqual(texts(order_blocks(page, 2, right_to_left=True)), ["R1", "R2", "L1", "L2"])
+
+    def test_rtl_blocks_placed_by_position(self):
+        page = {'blocks': [{'type': 0, 'bbox': (100, 100, 400, 112), 'lines': [rawdict_line(VISUAL)]},
+                           {'type': 1, 'bbox': (0, 0, 10, 10)}]}
+        self.assertEqual(rtl_blocks(page), [(100, 100, 400, 112, LOGICAL + "\n")])
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestSidebarFixture(unittest.TestCase):
+    """Test the extracted text of sidebar_first.pdf"""
+
+    def page_text(self, reading_order):
+        extractor = PDFExtractor({'reading_order': reading_order})
+        return extractor, list(extractor.iter_page_texts(str(SIDEBAR_FIRST_PDF)))[0][1]
+
+    def test_auto_reads_sidebar_last(self):
+        extractor, text = self.page_text('auto')
+        self.assertEqual(extractor.reordered_pages, [1])
+        self.assertLess(text.index("Records Policy"), text.index("seven years"))
+        self.assertLess(text.index("no longer needed"), text.index("Sidebar"))
+
+    def test_raw_keeps_drawing_order(self):
+        extractor, text = self.page_text('raw')
+        self.assertEqual(extractor.reordered_pages, [])
+        self.assertLess(text.index("Sidebar"), text.index("Records Policy"))
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,93 @@
+"""
+Test bibliography extraction and citation parsing
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.reference_extractor import (
+    find_references_section, extract_references_from_text, parse_reference, format_references_markdown
+)
+
+NUMBERED = """Contents
+1 Introduction
+References
+5 Conclusion
+We conclude.
+References
+[1] A. Smith, B. Jones, and C. Lee, "Deep learning for document layout," in Proc. IEEE Conf. Computer Vision, 2019, pp. 1-10, doi: 10.1109/CVPR.2019.00001.
+[2] J. Doe, "A very long title that wraps across
+two lines," Journal of Things, vol. 3, no. 2, pp. 5-9, 2020.
+[3] R. Roe, Book Title. New York: Publisher, 2001. [Online]. Available: https://example.com/book
+Appendix A
+Extra material.
+"""
+
+AUTHOR_YEAR = """Bibliography
+
+Anderson, P., & Baker, Q. (2018). Structured extraction of references. Journal of Document Engineering, 12(3), 45-67. https://doi.org/10.1234/jde.2018.045
+
+Chen, L. (2020a). Another study on citation parsing in scanned
+documents. In Proceedings of the Digital Libraries Conference (pp. 1-8).
+"""
+
+
+class TestReferenceExtraction(unittest.TestCase):
+    """Test section detection, entry splitting, and field parsing"""
+
+    def test_last_heading_wins_and_appendix_ends_section(self):
+        section = find_references_section(NUMBERED)
+        self.assertTrue(section.startswith("[1]"))
+        self.assertNotIn("Extra material", section)
+        self.assertIsNone(find_references_section("No bibliography here."))
+
+    def test_numbered_style(self):
+        result = extract_references_from_text(NUMBERED)
+        self.assertEqual(result['style'], 'numbered')
+        self.assertEqual(result['reference_count'], 3)
+
+        first, second, third = result['references']
+        self.assertEqual(first['number'], 1)
+        self.assertEqual(first['authors'], ["A. Smith", "B. Jones", "C. Lee"])
+        self.assertEqual(first['title'], "Deep learning for document layout")
+        self.assertEqual(first['year'], "2019")
+        self.assertEqual(first['venue'], "Proc. IEEE Conf. Computer Vision")
+        self.assertEqual(first['doi'], "10.1109/CVPR.2019.00001")
+
+        # Wrapped lines are joined into one entry
+        self.assertEqual(second['title'], "A very long title that wraps across two lines")
+        self.assertEqual(second['venue'], "Journal of Things")
+
+        self.assertEqual(third['authors'], ["R. Roe"])
+        self.assertEqual(third['title'], "Book Title")
+        self.assertEqual(third['url'], "https://example.com/book")
+
+    def test_author_year_style(self):
+        result = extract_references_from_text(AUTHOR_YEAR)
+        self.assertEqual(result['style'], 'author_year')
+        anderson, chen = result['references']
+        self.assertEqual(anderson['authors'], ["Anderson, P.", "Baker, Q."])
+        self.assertEqual(anderson['year'], "2018")
+        self.assertEqual(anderson['title'], "Structured extraction of references")
+        self.assertEqual(anderson['venue'], "Journal of Document Engineering")
+        self.assertEqual(anderson['doi'], "10.1234/jde.2018.045")
+        self.assertIsNone(anderson['url'])
+        self.assertEqual(chen['year'], "2020a")
+        self.assertEqual(chen['venue'], "Proceedings of the Digital Libraries Conference")
+
+    def test_unparsed_entries_keep_raw_text(self):
+        ref = parse_reference("Untitled internal memo")
+        self.assertEqual(ref['raw'], "Untitled internal memo")
+        self.assertIsNone(ref['year'])
+
+    def test_markdown_list(self):
+        markdown = format_references_markdown(extract_references_from_text(NUMBERED))
+        self.assertIn("1. A. Smith, B. Jones, C. Lee (2019). *Deep learning for document layout*", markdown)
+        self.assertIn("[doi:10.1109/CVPR.2019.00001](https://doi.org/10.1109/CVPR.2019.00001)", markdown)
+
+
+if __name__ == '__main__':
+    unittest.main()
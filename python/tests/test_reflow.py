@@ -0,0 +1,122 @@
+"""
+Test joining words split across column and page breaks
+"""
+import unittest
+from collections import Counter
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.reflow import (
+    COLUMN_BREAK, find_column_breaks, page_text_with_column_breaks, join_split_word, reflow_split_words
+)
+
+try:
+    import fitz  # noqa: F401
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+SPLIT_WORDS_PDF = Path(__file__).parent / "fixtures" / "split_words.pdf"
+
+
+class TestColumnBreaks(unittest.TestCase):
+    """Test column detection from block geometry"""
+
+    def test_jump_to_next_column(self):
+        blocks = [
+            (72, 72, 280, 140, "Left top"),
+            (72, 150, 280, 300, "Left bottom"),
+            (320, 72, 540, 300, "Right column"),
+            (300, 750, 310, 760, "1"),
+        ]
+        self.assertEqual(find_column_breaks(blocks), {1})
+        self.assertEqual(page_text_with_column_breaks(blocks),
+                         "Left top\nLeft bottom" + COLUMN_BREAK + "Right column\n1\n")
+
+    def test_single_column_has_no_breaks(self):
+        blocks = [(72, 72 + i * 40, 540, 100 + i * 40, "Paragraph") for i in range(4)]
+        self.assertEqual(find_column_breaks(blocks), set())
+
+
+class TestJoinDecision(unittest.TestCase):
+    """Test the vocabulary check for a pair of fragments"""
+
+    vocabulary = Counter({'international': 2, 'internatio': 1, 'nal': 1, 'the': 5, 'in': 3, 'to': 3, 'into': 1})
+
+    def test_join_when_joined_word_is_known(self):
+        self.assertEqual(join_split_word("internatio", "nal", self.vocabulary), 'join')
+
+    def test_known_fragments_are_left_alone(self):
+        # "in" / "to" are both words in their own right
+        self.assertIsNone(join_split_word("in", "to", self.vocabulary))
+
+    def test_unknown_joined_word_is_left_alone(self):
+        self.assertIsNone(join_split_word("the", "nal", self.vocabulary))
+
+    def test_repeated_fragment_is_dropped(self):
+        self.assertEqual(join_split_word("internatio", "international", self.vocabulary), 'drop_left')
+
+    def test_capitalized_continuation_is_left_alone(self):
+        self.assertIsNone(join_split_word("internatio", "Nal", self.vocabulary))
+
+    def test_dictionary_confirms_join(self):
+        self.assertEqual(join_split_word("gover", "nance", Counter(), {'governance'}), 'join')
+
+
+class TestReflow(unittest.TestCase):
+    """Test joins at column and page breaks"""
+
+    def test_column_and_page_joins(self):
+        pages = [
+            "rules for international\nreferred to the internatio" + COLUMN_BREAK
+            + "nal tribunal met.\nthe organization kept records for the organiza\n\n1\n",
+            "2\ntion in proportion to trade.\n",
+        ]
+        texts, joins = reflow_split_words(pages, dictionary=set())
+        self.assertIn("referred to the international tribunal met.", texts[0])
+        self.assertIn("for the organization\n", texts[0])
+        self.assertTrue(texts[0].rstrip().endswith("1"))
+        self.assertEqual(texts[1], "2\nin proportion to trade.\n")
+        self.assertEqual(joins, [
+            {'page': 1, 'boundary': 'column', 'word': 'international'},
+            {'page': 1, 'boundary': 'page', 'word': 'organization'},
+        ])
+        self.assertNotIn(COLUMN_BREAK, texts[0])
+
+    def test_unrelated_breaks_become_newlines(self):
+        texts, joins = reflow_split_words(["End of column." + COLUMN_BREAK + "Next column."], dictionary=set())
+        self.assertEqual(texts, ["End of column.\nNext column."])
+        self.assertEqual(joins, [])
+
+    def test_repeated_fragment_at_column_break(self):
+        text = "the international court and the internatio" + COLUMN_BREAK + "international tribunal"
+        texts, joins = reflow_split_words([text], dictionary=set())
+        self.assertEqual(texts, ["the international court and the international tribunal"])
+        self.assertEqual(joins[0]['word'], 'international')
+
+
+@unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF is required")
+class TestSplitWordsFixture(unittest.TestCase):
+    """Test column and page continuations in the two-column fixture"""
+
+    def test_reflow_joins_fixture_words(self):
+        from processors.pdf_extractor import extract_pdf
+        result = extract_pdf(str(SPLIT_WORDS_PDF), {'reflow_paragraphs': True})
+        words = {(join['boundary'], join['word']) for join in result['metadata']['reflow_joins']}
+        self.assertEqual(words, {('column', 'international'), ('page', 'organization')})
+        self.assertIn("international tribunal", result['processed_text'])
+        self.assertNotIn("internatio\n", result['raw_text'])
+
+    def test_reflow_is_off_by_default(self):
+        from processors.pdf_extractor import extract_pdf
+        result = extract_pdf(str(SPLIT_WORDS_PDF))
+        self.assertEqual(result['metadata']['reflow_joins'], [])
+        self.assertIn("internatio", result['raw_text'])
+
+
+if __name__ == '__main__':
+    unittest.main()
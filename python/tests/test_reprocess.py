@@ -0,0 +1,161 @@
+"""
+Test re-chunking an existing conversion
+"""
+import json
+import threading
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+from unittest.mock import patch
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.reprocess import reprocess_chunks
+from utils.cancellation import ConversionCancelled
+from utils.doc_resources import staging
+from utils.frontmatter import render_front_matter, split_front_matter
+from utils.temp_files import TEMP_FILES
+
+PARAGRAPH = "Tokens are issued by the authorization server and expire after one hour of inactivity."
+
+
+class TestReprocess(unittest.TestCase):
+    """Test regenerating chunked/ from the section files"""
+
+    def setUp(self):
+        self.output_dir = Path(tempfile.mkdtemp())
+        self.document_dir = self.output_dir / "manual"
+        self.write_conversion(self.document_dir)
+
+    def tearDown(self):
+        shutil.rmtree(self.output_dir, ignore_errors=True)
+
+    def write_conversion(self, document_dir, jsonl=True):
+        """A converted document: two section files, old chunks, and manifest.json"""
+        sections_dir = document_dir / "sections"
+        chunked_dir = document_dir / "chunked"
+        sections_dir.mkdir(parents=True)
+        chunked_dir.mkdir()
+        entries = []
+        for section_id, title in ((1, "Overview"), (2, "Authentication")):
+            name = f"{section_id:02d}-{title.lower()}.md"
+            body = f"# {title}\n\n" + "\n\n".join([PARAGRAPH] * 6)
+            fields = {'title': title, 'page_start': section_id * 3, 'page_end': section_id * 3 + 2,
+                      'section_id': section_id}
+            (sections_dir / name).write_text(render_front_matter(fields) + body + "\n", encoding='utf-8')
+            entries.append({'file': f"sections/{name}", 'section_id': section_id, 'title': title})
+        (chunked_dir / "01-Overview-chunk-001.md").write_text("old", encoding='utf-8')
+        (chunked_dir / "chunk-manifest.json").write_text(json.dumps({'chunks': [{'file': "01-Overview-chunk-001.md"}]}))
+        chunking = {'chunk_tokens': 2000, 'manifest': "chunked/chunk-manifest.json"}
+        if jsonl:
+            (chunked_dir / "chunks.jsonl").write_text("{}\n", encoding='utf-8')
+            chunking['jsonl'] = "chunked/chunks.jsonl"
+        manifest = {'document_id': "abc123", 'source_file': "manual.pdf", 'tokenizer': "claude",
+                    'sections': entries, 'chunking': chunking}
+        (document_dir / "manifest.json").write_text(json.dumps(manifest), encoding='utf-8')
+
+    def test_rechunks_with_new_budget(self):
+        result = reprocess_chunks(str(self.output_dir), 60)
+
+        self.assertEqual(result['document_dir'], str(self.document_dir))
+        self.assertEqual((result['sections'], result['removed']), (2, 3))
+        self.assertGreater(result['total_chunks'], 2)
+        chunked_dir = self.document_dir / "chunked"
+        self.assertFalse((chunked_dir / "01-Overview-chunk-001.md").read_text().startswith("old"))
+        fields, body = split_front_matter((chunked_dir / "02-Authentication-chunk-001.md").read_text())
+        self.assertEqual((fields['section_id'], fields['page_start'], fields['heading_path']), (2, 6, ["Authentication"]))
+        self.assertLessEqual(fields['tokens'], 60)
+
+        manifest = json.loads((self.document_dir / "manifest.json").read_text())
+        self.assertEqual(manifest['chunking']['chunk_tokens'], 60)
+        self.assertEqual(manifest['chunking']['manifest'], "chunked/chunk-manifest.json")
+        # chunks.jsonl is rewritten because the conversion had one
+        lines = (chunked_dir / "chunks.jsonl").read_text().splitlines()
+        self.assertEqual(len(lines), result['total_chunks'])
+        self.assertEqual(json.loads(lines[0])['metadata']['source'], "manual.pdf")
+
+    def test_document_folder_directly(self):
+        result = reprocess_chunks(str(self.document_dir), 500, chunks_jsonl=False)
+        self.assertEqual(result['total_chunks'], 2)
+        self.assertNotIn('jsonl', result)
+        self.assertFalse((self.document_dir / "chunked" / "chunks.jsonl").exists())
+
+    def test_several_conversions_need_document(self):
+        self.write_conversion(self.output_dir / "guide", jsonl=False)
+        with self.assertRaises(ValueError) as raised:
+            reprocess_chunks(str(self.output_dir), 500)
+        self.assertIn("pass document", str(raised.exception))
+        self.assertEqual(reprocess_chunks(str(self.output_dir), 500, document="guide")['document_dir'],
+                         str(self.output_dir / "guide"))
+        with self.assertRaises(ValueError):
+            reprocess_chunks(str(self.output_dir), 500, document="../guide")
+
+    def test_missing_sources_fail_clearly(self):
+        with self.assertRaises(FileNotFoundError) as raised:
+            reprocess_chunks(str(self.output_dir / "manual" / "sections"), 500)
+        self.assertIn("manifest.json", str(raised.exception))
+
+        (self.document_dir / "sections" / "02-authentication.md").unlink()
+        with self.assertRaises(FileNotFoundError) as raised:
+            reprocess_chunks(str(self.document_dir), 500)
+        self.assertIn("02-authentication.md", str(raised.exception))
+
+    def test_layout_kept_or_changed(self):
+        result = reprocess_chunks(str(self.document_dir), 500, chunk_layout="by_section")
+        chunked_dir = self.document_dir / "chunked"
+        self.assertEqual(result['layout'], "by_section")
+        self.assertTrue((chunked_dir / "02-Authentication" / "chunk-001.md").is_file())
+        records = [json.loads(line) for line in (chunked_dir / "chunks.jsonl").read_text().splitlines()]
+        self.assertEqual(records[0]['metadata']['file'], "01-Overview/chunk-001.md")
+
+        # Without chunk_layout the conversion's layout is kept; emptied folders of the old one go
+        result = reprocess_chunks(str(self.document_dir), 500)
+        self.assertEqual(result['layout'], "by_section")
+        result = reprocess_chunks(str(self.document_dir), 500, chunk_layout="flat")
+        self.assertTrue((chunked_dir / "01-Overview-chunk-001.md").is_file())
+        self.assertFalse((chunked_dir / "01-Overview").exists())
+
+    def test_section_links_work_from_chunk_files(self):
+        section_file = self.document_dir / "sections" / "01-overview.md"
+        section_file.write_text(section_file.read_text() + "\nSee [sign-in](02-authentication.md#page-6).\n")
+
+        reprocess_chunks(str(self.document_dir), 500, chunk_layout="flat")
+        text = (self.document_dir / "chunked" / "01-Overview-chunk-001.md").read_text()
+        self.assertIn("[sign-in](../sections/02-authentication.md#page-6)", text)
+        reprocess_chunks(str(self.document_dir), 500, chunk_layout="by_section")
+        text = (self.document_dir / "chunked" / "01-Overview" / "chunk-001.md").read_text()
+        self.assertIn("[sign-in](../../sections/02-authentication.md#page-6)", text)
+
+    def test_cancelled_run_keeps_old_chunks(self):
+        cancel_event = threading.Event()
+        cancel_event.set()
+        with self.assertRaises(ConversionCancelled):
+            reprocess_chunks(str(self.document_dir), 500, cancel_event=cancel_event)
+        self.assertEqual((self.document_dir / "chunked" / "01-Overview-chunk-001.md").read_text(), "old")
+        self.assertEqual((self.document_dir / "chunked" / "chunks.jsonl").read_text(), "{}\n")
+        # No staging folder is left behind
+        self.assertEqual(sorted(path.name for path in self.document_dir.iterdir()),
+                         ["chunked", "manifest.json", "sections"])
+
+    def test_staging_folder_tracked_and_hidden(self):
+        with patch.object(TEMP_FILES, 'register', wraps=TEMP_FILES.register) as register:
+            reprocess_chunks(str(self.document_dir), 500)
+        staging_dir = Path(register.call_args[0][0])
+        self.assertEqual(staging_dir.parent, self.document_dir)
+        # Kept out of resources while it exists, and no longer tracked once removed
+        self.assertTrue(staging(staging_dir, self.output_dir))
+        self.assertFalse(staging_dir.exists())
+        self.assertNotIn(staging_dir, TEMP_FILES.paths())
+
+    def test_budget_is_validated_first(self):
+        with self.assertRaises(ValueError):
+            reprocess_chunks(str(self.document_dir), 10)
+        self.assertEqual((self.document_dir / "chunked" / "01-Overview-chunk-001.md").read_text(), "old")
+
+
+if __name__ == '__main__':
+    unittest.main()
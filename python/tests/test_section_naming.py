@@ -0,0 +1,43 @@
+"""
+Test section file naming schemes
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.section_naming import section_filename, section_filenames, validate_section_naming
+
+
+class TestSectionNaming(unittest.TestCase):
+    """Test each section_naming scheme"""
+
+    def test_schemes(self):
+        self.assertEqual(section_filename('numbered_slug', 3, 12, 'overview'), '03-overview.md')
+        self.assertEqual(section_filename('numbered', 3, 12, 'overview'), '03.md')
+        self.assertEqual(section_filename('slug', 3, 12, 'overview'), 'overview.md')
+        self.assertEqual(section_filename('slug', 3, 12, ''), 'section-3.md')
+        hashed = section_filename('hashed', 3, 12, 'overview', 'Overview', 'Text')
+        self.assertRegex(hashed, r'^[0-9a-f]{12}\.md$')
+        self.assertEqual(hashed, section_filename('hashed', 7, 40, 'other', 'Overview', 'Text'))
+        self.assertNotEqual(hashed, section_filename('hashed', 3, 12, 'overview', 'Overview', 'Changed'))
+
+    def test_numbers_sort_alphabetically(self):
+        names = section_filenames('numbered', ['s'] * 120, [''] * 120, [''] * 120)
+        self.assertEqual(names[0], '001.md')
+        self.assertEqual(sorted(names), names)
+
+    def test_names_are_unique(self):
+        names = section_filenames('slug', ['intro', 'Intro', 'intro'], ['a', 'b', 'c'], ['', '', ''])
+        self.assertEqual(names, ['intro.md', 'Intro-2.md', 'intro-3.md'])
+
+    def test_validation(self):
+        self.assertEqual(validate_section_naming(None), 'numbered_slug')
+        with self.assertRaises(ValueError):
+            validate_section_naming('alphabetical')
+
+
+if __name__ == '__main__':
+    unittest.main()
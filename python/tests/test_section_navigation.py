@@ -0,0 +1,44 @@
+"""
+Test prev/next/parent links between section files
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.navigation import section_parents, link_section_files
+
+
+class TestSectionNavigation(unittest.TestCase):
+    """Test navigation links computed from section order and heading levels"""
+
+    def test_first_and_last_sections_have_null_prev_and_next(self):
+        links = link_section_files([["01-a.md"], ["02-b.md"], ["03-c.md"]], [1, 1, 1])
+        self.assertIsNone(links[0][0]['prev'])
+        self.assertEqual(links[0][0]['next'], "02-b.md")
+        self.assertEqual(links[1][0], {'prev': "01-a.md", 'next': "03-c.md", 'parent': None})
+        self.assertEqual(links[2][0]['prev'], "02-b.md")
+        self.assertIsNone(links[2][0]['next'])
+
+    def test_single_section_has_no_neighbours(self):
+        links = link_section_files([["01-only.md"]], [1])
+        self.assertEqual(links[0][0], {'prev': None, 'next': None, 'parent': None})
+
+    def test_parents_follow_heading_stack(self):
+        self.assertEqual(section_parents([1, 2, 3, 2, 1, 2]), [None, 0, 1, 0, None, 4])
+
+    def test_split_parts_chain_and_share_parent(self):
+        links = link_section_files(
+            [["01-guide.md"], ["02-api-part01.md", "02-api-part02.md"], ["03-errors.md"]],
+            [1, 2, 2]
+        )
+        self.assertEqual(links[1][0]['next'], "02-api-part02.md")
+        self.assertEqual(links[1][1]['prev'], "02-api-part01.md")
+        self.assertEqual(links[2][0]['prev'], "02-api-part02.md")
+        self.assertEqual([link['parent'] for link in links[1]], ["01-guide.md", "01-guide.md"])
+
+
+if __name__ == '__main__':
+    unittest.main()
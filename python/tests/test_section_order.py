@@ -0,0 +1,78 @@
+"""
+Test ordering sections by the document outline
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.section_order import map_section_to_outline, order_sections_by_outline, section_page_spans
+
+
+class TestSectionOrder(unittest.TestCase):
+    """Test outline mapping and the appearance fallback for unmapped sections"""
+
+    def setUp(self):
+        # Annex bound in front of the main body, outline lists it last
+        self.outline = [
+            {'title': 'Introduction', 'level': 1, 'page': 3},
+            {'title': 'Requirements', 'level': 1, 'page': 5},
+            {'title': 'Annex A', 'level': 1, 'page': 1},
+        ]
+
+    def test_title_match_wins_over_page(self):
+        section = {'title': 'REQUIREMENTS', 'pages': [1]}
+        self.assertEqual(map_section_to_outline(section, self.outline), 1)
+
+    def test_page_match_for_page_grouped_sections(self):
+        section = {'title': 'Section 2 (Pages 3-4)', 'pages': [3, 4]}
+        self.assertEqual(map_section_to_outline(section, self.outline), 0)
+
+    def test_sections_follow_outline(self):
+        sections = [
+            {'title': 'Section 1 (Pages 1-2)', 'pages': [1, 2]},
+            {'title': 'Section 2 (Pages 3-4)', 'pages': [3, 4]},
+            {'title': 'Section 3 (Pages 5-6)', 'pages': [5, 6]},
+        ]
+        ordered, unmapped = order_sections_by_outline(sections, self.outline)
+        self.assertEqual([s['pages'][0] for s in ordered], [3, 5, 1])
+        self.assertEqual(unmapped, [])
+
+    def test_unmapped_sections_stay_after_their_predecessor(self):
+        sections = [
+            {'title': 'Annex A', 'pages': [1]},
+            {'title': 'Errata', 'pages': [2]},
+            {'title': 'Introduction', 'pages': [3]},
+            {'title': 'Requirements', 'pages': [5]},
+        ]
+        ordered, unmapped = order_sections_by_outline(sections, self.outline)
+        self.assertEqual([s['title'] for s in ordered], ['Introduction', 'Requirements', 'Annex A', 'Errata'])
+        self.assertEqual([s['title'] for s in unmapped], ['Errata'])
+
+    def test_leading_unmapped_section_stays_first(self):
+        sections = [{'title': 'Cover', 'pages': [9]}, {'title': 'Annex A', 'pages': [1]}, {'title': 'Introduction', 'pages': [3]}]
+        ordered, _ = order_sections_by_outline(sections, self.outline)
+        self.assertEqual([s['title'] for s in ordered], ['Cover', 'Introduction', 'Annex A'])
+
+
+class TestSectionPageSpans(unittest.TestCase):
+    """Test first and last pages for front-matter"""
+
+    def test_start_pages_run_to_next_section(self):
+        sections = [{'page': 1}, {'page': 4}, {'page': 4}, {'page': 9}]
+        self.assertEqual(section_page_spans(sections, last_page=12), [(1, 3), (4, 4), (4, 8), (9, 12)])
+
+    def test_page_lists_and_unknown_pages(self):
+        sections = [{'pages': [2, 3, 5]}, {'title': 'No page'}, {'page': 7}]
+        self.assertEqual(section_page_spans(sections), [(2, 5), (None, None), (7, 7)])
+
+    def test_out_of_order_sections(self):
+        # Outline order: an annex at the front of the file listed last
+        sections = [{'page': 3}, {'page': 10}, {'page': 1}]
+        self.assertEqual(section_page_spans(sections, last_page=12), [(3, 9), (10, 12), (1, 2)])
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,82 @@
+"""
+Test per-section token, word, and reading-time figures
+"""
+import unittest
+import tempfile
+import shutil
+import json
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.section_stats import format_section_size, reading_minutes, section_stats, word_count
+from utils.token_counter import TokenCounter
+
+try:
+    import fitz  # noqa: F401
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+try:
+    from modular_pdf_converter import ModularPDFConverter
+    HAS_CONVERTER = True
+except ImportError:
+    HAS_CONVERTER = False
+
+SPLIT_WORDS_PDF = Path(__file__).parent / "fixtures" / "split_words.pdf"
+
+
+class TestSectionStats(unittest.TestCase):
+    """Test word counts and reading time"""
+
+    def test_word_count_skips_markup(self):
+        content = ("The card's PIN is checked.\n\n![Figure 1](../images/page001-img01.png)\n\n"
+                   "See [the spec](https://example.com/spec-v2.pdf) for e.g. details.\n\n```\ncode here\n```")
+        self.assertEqual(word_count(content), 13)
+
+    def test_reading_minutes(self):
+        self.assertEqual(reading_minutes(0), 0)
+        self.assertEqual(reading_minutes(1), 1)
+        self.assertEqual(reading_minutes(200), 1)
+        self.assertEqual(reading_minutes(201), 2)
+
+    def test_section_stats_and_format(self):
+        counter = TokenCounter()
+        stats = section_stats("word " * 450, counter)
+        self.assertEqual(stats['word_count'], 450)
+        self.assertEqual(stats['reading_minutes'], 3)
+        self.assertEqual(stats['token_count'], counter.count_tokens("word " * 450))
+        self.assertEqual(format_section_size({'token_count': 1204, 'word_count': 930, 'reading_minutes': 5}),
+                         "1,204 tokens, 930 words, ~5 min")
+
+
+@unittest.skipUnless(HAS_PYMUPDF and HAS_CONVERTER, "PyMuPDF and the converter are required")
+class TestSectionStatsOutput(unittest.TestCase):
+    """Test that the figures reach README.md and manifest.json"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_readme_and_manifest(self):
+        result = ModularPDFConverter(str(SPLIT_WORDS_PDF), str(self.temp_dir), {}).convert()
+        self.assertTrue(result['success'])
+        output = Path(result['output_directory'])
+        manifest = json.loads((output / "manifest.json").read_text(encoding="utf-8"))
+        readme = (output / "README.md").read_text(encoding="utf-8")
+
+        for entry in manifest['sections']:
+            stats = manifest['section_stats'][entry['file']]
+            self.assertEqual(stats['token_count'], entry['token_count'])
+            self.assertGreater(stats['word_count'], 0)
+            self.assertIn(format_section_size(stats), readme)
+
+
+if __name__ == '__main__':
+    unittest.main()
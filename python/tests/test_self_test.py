@@ -0,0 +1,67 @@
+"""
+Test the self-test sample PDF and stage report
+"""
+import unittest
+import re
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.self_test import STAGES, build_sample_pdf, format_self_test, run_self_test
+
+
+class TestSamplePdf(unittest.TestCase):
+    """Test the generated PDF is well formed"""
+
+    def test_xref_offsets_point_at_objects(self):
+        data = build_sample_pdf()
+        self.assertTrue(data.startswith(b"%PDF-1.7\n"))
+        xref = int(re.search(rb"startxref\n(\d+)", data).group(1))
+        self.assertTrue(data[xref:].startswith(b"xref\n"))
+        offsets = [int(offset) for offset in re.findall(rb"(\d{10}) 00000 n", data)]
+        for number, offset in enumerate(offsets, 1):
+            self.assertTrue(data[offset:].startswith(f"{number} 0 obj".encode()))
+        self.assertIn(b"/Count 2", data)
+
+
+class TestRunSelfTest(unittest.TestCase):
+    """Test the report whatever is installed"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_every_stage_reported_and_cleaned_up(self):
+        report = run_self_test(work_dir=self.temp_dir)
+        self.assertEqual(tuple(stage['name'] for stage in report['stages']), STAGES)
+        self.assertEqual(report['passed'], all(stage['status'] == 'passed' for stage in report['stages']))
+        self.assertEqual(report['stages'][-1]['status'], 'passed')
+        self.assertEqual(list(Path(self.temp_dir).iterdir()), [])
+
+    def test_failed_extraction_skips_dependent_stages(self):
+        report = run_self_test(work_dir=self.temp_dir)
+        stages = {stage['name']: stage for stage in report['stages']}
+        if stages['extraction']['status'] == 'passed':
+            self.skipTest("conversion works in this environment")
+        self.assertEqual(stages['tables']['status'], 'skipped')
+        self.assertEqual(stages['chunking']['detail'], "needs extraction")
+
+    def test_format(self):
+        report = {'passed': False, 'stages': [
+            {'name': 'dependencies', 'status': 'failed', 'detail': "pypdf is not installed"},
+            {'name': 'tables', 'status': 'skipped', 'detail': "needs extraction"},
+            {'name': 'cleanup', 'status': 'passed', 'detail': ''},
+        ]}
+        self.assertEqual(format_self_test(report),
+                         "❌ dependencies: pypdf is not installed\n⏭️ tables: needs extraction\n✅ cleanup: passed\n")
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,102 @@
+"""
+Test default tool arguments from the server config file
+"""
+import unittest
+import json
+import tempfile
+import shutil
+from pathlib import Path
+from unittest.mock import patch
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.server_config import CONFIG_ENV, ServerConfig, ServerConfigError, load_server_config, parse_server_config
+
+TOOL_PROPERTIES = {
+    'convert_pdf': {
+        'pdf_path': {'type': 'string'},
+        'chunk_tokens': {'type': 'integer'},
+        'image_format': {'type': 'string', 'enum': ['original', 'png', 'jpeg', 'webp']},
+        'output_dir': {'type': 'string'},
+    },
+    'convert_docx': {
+        'docx_path': {'type': 'string'},
+        'output_dir': {'type': 'string'},
+    },
+}
+
+
+class TestApply(unittest.TestCase):
+    """Test how defaults, per-tool values, and call arguments combine"""
+
+    def test_precedence(self):
+        config = ServerConfig(defaults={'output_dir': './docs', 'chunk_tokens': 800},
+                              tools={'convert_docx': {'output_dir': './word'}})
+        self.assertEqual(config.apply('convert_docx', {'docx_path': 'a.docx'}, TOOL_PROPERTIES['convert_docx']),
+                         {'output_dir': './word', 'docx_path': 'a.docx'})
+        self.assertEqual(config.apply('convert_pdf', {'chunk_tokens': 400}, TOOL_PROPERTIES['convert_pdf']),
+                         {'output_dir': './docs', 'chunk_tokens': 400})
+
+    def test_empty_config_changes_nothing(self):
+        self.assertEqual(ServerConfig().apply('convert_pdf', {'pdf_path': 'a.pdf'}), {'pdf_path': 'a.pdf'})
+
+
+class TestValidation(unittest.TestCase):
+    """Test that bad files are rejected with the key named"""
+
+    def assert_rejected(self, data, fragment):
+        with self.assertRaises(ServerConfigError) as caught:
+            parse_server_config(data, TOOL_PROPERTIES, "team.json")
+        self.assertIn(fragment, str(caught.exception))
+
+    def test_valid(self):
+        config = parse_server_config({'defaults': {'image_format': 'webp'},
+                                      'tools': {'convert_pdf': {'chunk_tokens': 500}}}, TOOL_PROPERTIES)
+        self.assertEqual(config.defaults, {'image_format': 'webp'})
+
+    def test_rejected(self):
+        self.assert_rejected([], "expected a JSON object")
+        self.assert_rejected({'default': {}}, "unknown key(s) default")
+        self.assert_rejected({'defaults': {'chunk_size': 5}}, "no tool accepts the argument 'chunk_size'")
+        self.assert_rejected({'defaults': {'chunk_tokens': "800"}}, "'chunk_tokens' must be integer")
+        self.assert_rejected({'defaults': {'chunk_tokens': True}}, "'chunk_tokens' must be integer")
+        self.assert_rejected({'defaults': {'image_format': 'gif'}}, "must be one of original, png, jpeg, webp")
+        self.assert_rejected({'tools': {'convert_xlsx': {}}}, "unknown tool 'convert_xlsx'")
+        self.assert_rejected({'tools': {'convert_docx': {'chunk_tokens': 5}}}, "convert_docx has no argument")
+
+
+class TestLoad(unittest.TestCase):
+    """Test finding and reading the file"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_env_file(self):
+        path = self.temp_dir / "team.json"
+        path.write_text(json.dumps({'defaults': {'chunk_tokens': 800}}))
+        with patch.dict(os.environ, {CONFIG_ENV: str(path)}):
+            config = load_server_config(TOOL_PROPERTIES)
+        self.assertEqual((config.path, config.defaults), (str(path), {'chunk_tokens': 800}))
+
+    def test_no_file_is_empty(self):
+        with patch.dict(os.environ, {CONFIG_ENV: ''}), patch('utils.server_config.Path.cwd', return_value=self.temp_dir):
+            self.assertEqual(load_server_config(TOOL_PROPERTIES), ServerConfig())
+
+    def test_malformed_and_missing(self):
+        path = self.temp_dir / "mcp-markdown.json"
+        path.write_text('{"defaults": {"chunk_tokens": 800,}}')
+        with self.assertRaises(ServerConfigError) as caught:
+            load_server_config(TOOL_PROPERTIES, path)
+        self.assertIn("invalid JSON at line 1", str(caught.exception))
+        with self.assertRaises(ServerConfigError):
+            load_server_config(TOOL_PROPERTIES, self.temp_dir / "missing.json")
+
+
+if __name__ == '__main__':
+    unittest.main()
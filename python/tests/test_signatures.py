@@ -0,0 +1,63 @@
+"""
+Test signature metadata extraction against the signed-PDF fixture
+"""
+import unittest
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.signature_extractor import extract_signatures, parse_pdf_date, count_revisions
+
+try:
+    import pypdf  # noqa: F401
+    HAS_PYPDF = True
+except ImportError:
+    HAS_PYPDF = False
+
+try:
+    import fitz  # noqa: F401
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+SIGNED_PDF = Path(__file__).parent / "fixtures" / "signed_incremental.pdf"
+
+
+class TestSignatures(unittest.TestCase):
+    """Test signed PDFs are read at their latest revision"""
+
+    def test_parse_pdf_date(self):
+        self.assertEqual(parse_pdf_date("D:20240315103000+01'00'"), "2024-03-15T10:30:00+01:00")
+        self.assertEqual(parse_pdf_date("D:20240315103000Z"), "2024-03-15T10:30:00+00:00")
+        self.assertEqual(parse_pdf_date("D:2024"), "2024-01-01T00:00:00")
+        self.assertIsNone(parse_pdf_date("yesterday"))
+
+    def test_fixture_has_incremental_update(self):
+        self.assertEqual(count_revisions(str(SIGNED_PDF)), 2)
+
+    @unittest.skipUnless(HAS_PYPDF, "pypdf not installed")
+    def test_signature_metadata(self):
+        result = extract_signatures(str(SIGNED_PDF))
+        self.assertTrue(result['signed'])
+        self.assertEqual(result['signature_count'], 1)
+
+        signature = result['signatures'][0]
+        self.assertEqual(signature['field_name'], "Signature1")
+        self.assertEqual(signature['signer'], "Jane Signer")
+        self.assertEqual(signature['signed_at'], "2024-03-15T10:30:00+01:00")
+        self.assertEqual(signature['sub_filter'], "adbe.pkcs7.detached")
+        self.assertTrue(signature['covers_whole_file'])
+        self.assertFalse(signature['verified'])
+
+    @unittest.skipUnless(HAS_PYMUPDF, "PyMuPDF not installed")
+    def test_signed_pdf_text_extraction(self):
+        from processors.pdf_extractor import extract_pdf
+        result = extract_pdf(str(SIGNED_PDF))
+        self.assertIn("Signed contract body text", result['processed_text'])
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,67 @@
+"""
+Test soft wrapping of long markdown lines
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.text_utils import TextUtils
+
+
+class TestSoftWrap(unittest.TestCase):
+    """Test wrapping prose without changing rendered output"""
+
+    def setUp(self):
+        # One extracted paragraph with no line breaks, as some PDFs produce
+        self.paragraph = " ".join(
+            f"The settlement file for batch {n} is transmitted to the acquirer before the cutoff time."
+            for n in range(40)
+        )
+
+    def test_long_paragraph_wraps_at_word_boundaries(self):
+        wrapped = TextUtils.soft_wrap_markdown(self.paragraph, 80)
+        lines = wrapped.split("\n")
+        self.assertGreater(len(lines), 40)
+        self.assertTrue(all(len(line) <= 80 for line in lines))
+        # Rendered as a paragraph, single newlines are spaces: the words are unchanged
+        self.assertEqual(wrapped.replace("\n", " "), self.paragraph)
+
+    def test_zero_width_is_off(self):
+        self.assertEqual(TextUtils.soft_wrap_markdown(self.paragraph, 0), self.paragraph)
+
+    def test_code_tables_and_headings_untouched(self):
+        code = "    " + "x = compute(value) " * 10
+        fenced = "```\n" + "call(arg) " * 20 + "\n```"
+        table = "| " + " | ".join(f"column {n}" for n in range(20)) + " |"
+        heading = "## " + "Long heading " * 10
+        text = "\n".join([code, fenced, table, heading])
+        self.assertEqual(TextUtils.soft_wrap_markdown(text, 40), text)
+
+    def test_urls_are_never_split(self):
+        url = "https://example.com/" + "segment/" * 15
+        wrapped = TextUtils.soft_wrap_markdown(f"See the specification at {url} for the full list of codes.", 30)
+        self.assertIn(url, wrapped.split("\n"))
+
+    def test_list_items_keep_indentation(self):
+        wrapped = TextUtils.soft_wrap_markdown("- " + "item text " * 12, 30)
+        lines = wrapped.split("\n")
+        self.assertTrue(lines[0].startswith("- "))
+        self.assertTrue(all(line.startswith("  ") for line in lines[1:]))
+
+    def test_no_line_starts_with_a_block_marker(self):
+        text = "Totals were computed as shown - see the note. Steps 1. and 2. follow # 3 in the >quoted list"
+        wrapped = TextUtils.soft_wrap_markdown(text, 28)
+        self.assertEqual(wrapped.replace("\n", " "), text)
+        for line in wrapped.split("\n")[1:]:
+            self.assertNotRegex(line, r'^(?:[-*+]\s|\d+[.)]\s|#|>)')
+
+    def test_hard_break_kept(self):
+        wrapped = TextUtils.soft_wrap_markdown("word " * 20 + " ", 30)
+        self.assertTrue(wrapped.endswith("  "))
+
+
+if __name__ == '__main__':
+    unittest.main()
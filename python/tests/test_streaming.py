@@ -0,0 +1,189 @@
+"""
+Test streaming conversion: section files written while pages are extracted
+"""
+import json
+import unittest
+import tempfile
+import shutil
+import tracemalloc
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from processors.streaming import (STREAM_PAGES_PER_SECTION, SectionStreamWriter, stream_index, stream_sections,
+                                  validate_streaming_options)
+from processors.reprocess import reprocess_chunks
+from utils.frontmatter import SOURCE_FIELDS, drop_front_matter_fields, split_front_matter
+
+try:
+    import fitz  # noqa: F401
+    HAS_PYMUPDF = True
+except ImportError:
+    HAS_PYMUPDF = False
+
+try:
+    from modular_pdf_converter import ModularPDFConverter
+    HAS_CONVERTER = True
+except ImportError:
+    HAS_CONVERTER = False
+
+RUNNING_HEADERS_PDF = Path(__file__).parent / "fixtures" / "running_headers.pdf"
+
+# About 3 KB of text per page
+PAGE_TEXT = "Settlement files are delivered nightly and reconciled against the ledger. " * 40
+
+
+class TestStreamSections(unittest.TestCase):
+    """Test fixing sections before extraction"""
+
+    def test_top_level_bookmarks(self):
+        outline = [{'title': "Introduction", 'level': 1, 'page': 3},
+                   {'title': "Scope", 'level': 2, 'page': 4},
+                   {'title': "Design", 'level': 1, 'page': 10},
+                   {'title': "Design again", 'level': 1, 'page': 10},
+                   {'title': "Appendix", 'level': 1, 'page': 90}]
+        self.assertEqual(stream_sections(outline, list(range(1, 51))), [
+            {'title': "Front Matter", 'page_start': 1},
+            {'title': "Introduction", 'page_start': 3},
+            {'title': "Design", 'page_start': 10},
+        ])
+
+    def test_page_groups_without_bookmarks(self):
+        sections = stream_sections([], list(range(1, 2 * STREAM_PAGES_PER_SECTION + 2)))
+        self.assertEqual(len(sections), 3)
+        self.assertEqual(sections[1], {'title': "Pages 26-50", 'page_start': 26})
+        self.assertEqual(sections[2], {'title': "Page 51", 'page_start': 51})
+
+    def test_options_that_need_the_whole_document(self):
+        validate_streaming_options({'output_format': 'markdown'})
+        for options in ({'chunk_tokens': 500}, {'output_format': 'rst'}, {'output_mode': 'canonical'},
+                        {'math_mode': 'image'}):
+            with self.assertRaises(ValueError):
+                validate_streaming_options(options)
+
+
+class TestSectionStreamWriter(unittest.TestCase):
+    """Test writing section files as their pages complete"""
+
+    def setUp(self):
+        self.output_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.output_dir, ignore_errors=True)
+
+    def writer(self, sections, **kwargs):
+        return SectionStreamWriter(self.output_dir / "sections", self.output_dir / "sections.jsonl", sections,
+                                   'numbered-slug', "manual.pdf",
+                                   lambda path: path.relative_to(self.output_dir).as_posix(), **kwargs)
+
+    def test_files_land_as_sections_complete(self):
+        landed = []
+        writer = self.writer([{'title': "Overview", 'page_start': 1}, {'title': "Overview", 'page_start': 3}],
+                             on_file=lambda entry: landed.append(entry['file']))
+        writer.add_page(1, "First page.\n")
+        writer.add_page(2, "Second page.\n")
+        self.assertEqual(landed, [])
+        writer.add_page(3, "Third page.\n")
+        # Written when page 3 opened the next section
+        self.assertEqual(landed, ["sections/01-Overview.md"])
+        entries = writer.close()
+
+        self.assertEqual(landed, ["sections/01-Overview.md", "sections/02-Overview.md"])
+        self.assertEqual([(entry['page_start'], entry['page_end']) for entry in entries], [(1, 2), (3, 3)])
+        fields, body = split_front_matter((self.output_dir / "sections" / "01-Overview.md").read_text())
+        self.assertEqual((fields['title'], fields['page_end'], fields['section_id']), ("Overview", 2, 1))
+        self.assertEqual(body, "# Overview\n\nFirst page.\n\nSecond page.\n")
+
+        records = [json.loads(line) for line in (self.output_dir / "sections.jsonl").read_text().splitlines()]
+        self.assertEqual([record['section_id'] for record in records], [1, 2])
+        self.assertEqual(records[1]['text'], "Third page.")
+
+        index = stream_index("manual", entries)
+        self.assertIn("- [Overview](sections/01-Overview.md) - pages 1-2", index)
+
+    def test_front_matter_off_keeps_section_fields(self):
+        writer = self.writer([{'title': "Overview", 'page_start': 1}], front_matter=False)
+        writer.add_page(1, "First page.\n")
+        writer.close()
+        fields, _ = split_front_matter((self.output_dir / "sections" / "01-Overview.md").read_text())
+        self.assertEqual(fields, {'title': "Overview", 'section_id': 1})
+
+    def test_drop_front_matter_fields(self):
+        text = '---\ntitle: "A"\nsource_pdf: "a.pdf"\npage_start: 1\n---\n\nBody\n'
+        self.assertEqual(drop_front_matter_fields(text, SOURCE_FIELDS), '---\ntitle: "A"\n---\n\nBody\n')
+        self.assertEqual(drop_front_matter_fields(text, ('title',) + SOURCE_FIELDS), "Body\n")
+
+    def test_sections_without_converted_pages_are_skipped(self):
+        writer = self.writer([{'title': "A", 'page_start': 1}, {'title': "B", 'page_start': 5},
+                              {'title': "C", 'page_start': 9}])
+        writer.add_page(2, "a")
+        writer.add_page(9, "c")
+        self.assertEqual([entry['title'] for entry in writer.close()], ["A", "C"])
+
+    def test_reprocess_chunks_a_streamed_conversion(self):
+        writer = self.writer([{'title': "Overview", 'page_start': 1}], front_matter=True)
+        for page_num in range(1, 4):
+            writer.add_page(page_num, PAGE_TEXT)
+        entries = writer.close()
+        manifest = {'source_file': "manual.pdf", 'sections': entries}
+        (self.output_dir / "manifest.json").write_text(json.dumps(manifest), encoding='utf-8')
+        result = reprocess_chunks(str(self.output_dir), 500)
+        self.assertGreater(result['total_chunks'], 1)
+
+    def stream_pages(self, page_count):
+        """(peak traced bytes, entries) for streaming page_count synthetic pages"""
+        pages = list(range(1, page_count + 1))
+        writer = SectionStreamWriter(self.output_dir / f"sections-{page_count}", self.output_dir / f"{page_count}.jsonl",
+                                     stream_sections([], pages), 'numbered-slug', "large.pdf", str)
+        tracemalloc.start()
+        try:
+            for page_num in pages:
+                writer.add_page(page_num, f"Page {page_num}. {PAGE_TEXT}")
+            entries = writer.close()
+            _, peak = tracemalloc.get_traced_memory()
+        finally:
+            tracemalloc.stop()
+        return peak, entries
+
+    def test_memory_stays_bounded(self):
+        # 2,000 pages (~6 MB of text) peak no higher than 200 pages: one section is held at a time
+        small_peak, _ = self.stream_pages(200)
+        peak, entries = self.stream_pages(2000)
+
+        self.assertEqual(len(entries), 2000 // STREAM_PAGES_PER_SECTION)
+        self.assertLess(peak, small_peak * 1.5)
+        self.assertLess(peak, len(PAGE_TEXT) * 2000 / 5)
+        self.assertEqual(len((self.output_dir / "2000.jsonl").read_text().splitlines()), len(entries))
+
+
+@unittest.skipUnless(HAS_PYMUPDF and HAS_CONVERTER, "PyMuPDF and converter dependencies are required")
+class TestStreamingConversion(unittest.TestCase):
+    """Test convert_pdf with streaming on a small PDF"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_streaming_conversion(self):
+        result = ModularPDFConverter(str(RUNNING_HEADERS_PDF), str(self.temp_dir),
+                                     {'streaming': True, 'strip_headers_footers': True}).convert()
+
+        self.assertTrue(result['success'], result.get('error'))
+        self.assertEqual(result['streaming']['sections'], 1)
+        document_dir = Path(result['output_directory'])
+        records = [json.loads(line) for line in (document_dir / "sections.jsonl").read_text().splitlines()]
+        self.assertEqual((records[0]['title'], records[0]['page_start'], records[0]['page_end']), ("Pages 1-4", 1, 4))
+        self.assertNotIn("Page 2 of 4", records[0]['text'])
+        manifest = json.loads((document_dir / "manifest.json").read_text())
+        self.assertEqual(manifest['sections'][0]['file'], records[0]['file'])
+        self.assertEqual(manifest['streaming']['jsonl'], "sections.jsonl")
+        self.assertTrue((document_dir / "README.md").is_file())
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,85 @@
+"""
+Test the structural diff between two revisions of a document
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.structure_diff import diff_structures, diff_titles, format_structure_diff, title_key
+
+
+def outline(*entries):
+    """Outline entries from (title, level, page) tuples"""
+    return [{'title': title, 'level': level, 'page': page} for title, level, page in entries]
+
+
+REVISION_A = {
+    'pages': 40, 'table_count': 6, 'image_count': 2,
+    'chapters': outline(("1 Overview", 1, 1), ("1.1 Scope", 2, 2), ("2 Card Authentication", 1, 3),
+                        ("3 Legacy Mode", 1, 8), ("4 Errors", 1, 10)),
+}
+REVISION_B = {
+    'pages': 44, 'table_count': 8, 'image_count': 2,
+    'chapters': outline(("1 Overview", 1, 1), ("1.1 Scope", 2, 2), ("2 Tokenization", 1, 3),
+                        ("3 Card Authentication", 1, 5), ("4 Errors and Retries", 1, 12)),
+}
+
+
+class TestDiffTitles(unittest.TestCase):
+    """Test added, removed, renamed, and moved titles"""
+
+    def test_revision_diff(self):
+        diff = diff_titles(REVISION_A['chapters'], REVISION_B['chapters'])
+        self.assertEqual([item['title'] for item in diff['added']], ["2 Tokenization"])
+        self.assertEqual([item['title'] for item in diff['removed']], ["3 Legacy Mode"])
+        self.assertEqual([(pair['from']['title'], pair['to']['title']) for pair in diff['renamed']],
+                         [("4 Errors", "4 Errors and Retries")])
+        self.assertEqual(diff['moved'], [{'title': "3 Card Authentication", 'page_a': 3, 'page_b': 5}])
+        self.assertEqual(diff['unchanged'], 2)
+
+    def test_renumbered_title_is_unchanged(self):
+        self.assertEqual(title_key("2.3 Scope"), title_key("3.1  scope"))
+        diff = diff_titles(outline(("2.3 Scope", 2, 4)), outline(("3.1 Scope", 2, 4)))
+        self.assertEqual(diff['unchanged'], 1)
+        self.assertFalse(diff['renamed'])
+
+    def test_unrelated_replacement_is_removed_and_added(self):
+        diff = diff_titles(outline(("Glossary", 1, 9)), outline(("Security Considerations", 1, 9)))
+        self.assertEqual([item['title'] for item in diff['removed']], ["Glossary"])
+        self.assertEqual([item['title'] for item in diff['added']], ["Security Considerations"])
+        self.assertFalse(diff['renamed'])
+
+
+class TestDiffStructures(unittest.TestCase):
+    """Test count deltas, chapter-level diff, and the report"""
+
+    def test_counts_and_levels(self):
+        diff = diff_structures(REVISION_A, REVISION_B)
+        self.assertTrue(diff['changed'])
+        self.assertEqual(diff['counts']['pages'], {'a': 40, 'b': 44, 'delta': 4})
+        self.assertEqual(diff['counts']['table_count']['delta'], 2)
+        self.assertEqual(diff['counts']['image_count']['delta'], 0)
+        # The chapter diff leaves out the level 2 "1.1 Scope"
+        self.assertEqual(diff['chapters']['unchanged'], 1)
+        self.assertEqual(diff['sections']['unchanged'], 2)
+
+    def test_report(self):
+        report = format_structure_diff(diff_structures(REVISION_A, REVISION_B), "v1.pdf", "v2.pdf")
+        self.assertTrue(report.startswith("# Structure comparison: v1.pdf → v2.pdf\n"))
+        self.assertIn("| Pages | 40 | 44 | +4 |", report)
+        self.assertIn("- Renamed: 4 Errors → 4 Errors and Retries (p. 12)", report)
+        self.assertIn("- Moved: 3 Card Authentication (p. 3 → p. 5)", report)
+
+    def test_identical_documents(self):
+        diff = diff_structures(REVISION_A, dict(REVISION_A))
+        self.assertFalse(diff['changed'])
+        report = format_structure_diff(diff, "a.pdf", "b.pdf")
+        self.assertIn("No structural changes.", report)
+        self.assertIn("| Tables | 6 | 6 | +0 |", report)
+
+
+if __name__ == '__main__':
+    unittest.main()
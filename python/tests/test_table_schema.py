@@ -0,0 +1,199 @@
+"""
+Test relational schema inference for extracted tables
+"""
+import unittest
+import tempfile
+import shutil
+import csv
+import sys
+import os
+from pathlib import Path
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+import pandas as pd
+
+from processors.table_processor import TableProcessor
+
+
+class TestTableSchema(unittest.TestCase):
+    """Test header detection, column typing, and typed rows"""
+
+    def test_column_types(self):
+        self.assertEqual(TableProcessor.infer_column_type(['1', '2,000', '']), 'int')
+        self.assertEqual(TableProcessor.infer_column_type(['1.5', '$2', '(3.25)']), 'float')
+        self.assertEqual(TableProcessor.infer_column_type(['2024-01-31', '02/15/2024']), 'date')
+        self.assertEqual(TableProcessor.infer_column_type(['1', 'n/a']), 'string')
+
+    def test_leading_zeros_stay_strings(self):
+        self.assertEqual(TableProcessor.infer_column_type(['00123', '02134']), 'string')
+        self.assertEqual(TableProcessor.infer_column_type(['0', '0.5', '10']), 'float')
+        with self.assertRaises(ValueError):
+            TableProcessor.coerce_typed_value('00123', 'int')
+
+    def test_header_detection(self):
+        self.assertTrue(TableProcessor.detect_header_row([['Name', 'Count'], ['a', '1']]))
+        self.assertFalse(TableProcessor.detect_header_row([['1', '2'], ['3', '4']]))
+
+    def test_schema_and_typed_rows(self):
+        table = {'page': 3, 'index': 0, 'data': [
+            ['Region', 'Units Sold', 'Revenue', 'Ship Date'],
+            ['North', '1,200', '$4,500.50', '2024-01-31'],
+            ['South', '800', '', '2024-02-15'],
+        ]}
+        result = TableProcessor.build_relational_schema(table)
+
+        self.assertTrue(result['has_header'])
+        self.assertEqual(result['table_id'], 'page3_table1')
+        self.assertEqual([c['name'] for c in result['columns']],
+                         ['region', 'units_sold', 'revenue', 'ship_date'])
+
+        props = result['json_schema']['properties']
+        self.assertEqual(props['units_sold']['type'], 'integer')
+        self.assertEqual(props['revenue']['type'], ['number', 'null'])
+        self.assertEqual(props['ship_date']['format'], 'date')
+        self.assertNotIn('revenue', result['json_schema']['required'])
+
+        self.assertEqual(result['rows'][0],
+                         {'region': 'North', 'units_sold': 1200, 'revenue': 4500.5, 'ship_date': '2024-01-31'})
+        self.assertIsNone(result['rows'][1]['revenue'])
+
+    def test_headerless_table_gets_generated_names(self):
+        result = TableProcessor.build_relational_schema({'data': [['1', 'a'], ['2', 'b']]})
+        self.assertFalse(result['has_header'])
+        self.assertEqual([c['name'] for c in result['columns']], ['column_1', 'column_2'])
+
+
+    def test_tiny_tables_are_discarded(self):
+        tables = [
+            {'page': 1, 'index': 0, 'data': [['Total']]},
+            {'page': 1, 'index': 1, 'data': [['a'], ['b'], ['c']]},
+            {'page': 2, 'index': 0, 'data': [['Name', 'Qty'], ['bolt', '4'], ['nut', '9']]},
+        ]
+        kept, discarded = TableProcessor.filter_table_candidates(tables)
+        self.assertEqual([(t['page'], t['index']) for t in kept], [(2, 0)])
+        self.assertEqual([d['reason'] for d in discarded],
+                         ["1 row(s), minimum is 2", "1 column(s), minimum is 2"])
+        self.assertEqual(kept[0]['confidence']['confidence'], 1.0)
+
+    def test_sparse_aligned_text_has_low_confidence(self):
+        prose = {'page': 4, 'index': 0, 'data': [
+            ['The quick brown fox jumps over', '', '', ''],
+            ['the lazy dog while the', '', 'cat', ''],
+            ['watches from a distance', '', '', ''],
+            ['', '', '', 'and naps'],
+        ]}
+        score = TableProcessor.score_table_confidence(prose['data'])
+        self.assertLess(score['confidence'], TableProcessor.DEFAULT_TABLE_MIN_CONFIDENCE)
+        kept, discarded = TableProcessor.filter_table_candidates([prose])
+        self.assertEqual(kept, [])
+        self.assertIn("confidence", discarded[0]['reason'])
+
+        kept, _ = TableProcessor.filter_table_candidates([prose], min_confidence=0)
+        self.assertEqual(len(kept), 1)
+
+    def test_thresholds_from_options(self):
+        self.assertEqual(TableProcessor.table_thresholds({}), (2, 2, 0.5))
+        self.assertEqual(TableProcessor.table_thresholds({'min_table_rows': 3, 'min_table_cols': None,
+                                                          'table_min_confidence': 0}), (3, 2, 0))
+        for options in ({'min_table_rows': 0}, {'min_table_cols': 1.5}, {'min_table_rows': True},
+                        {'table_min_confidence': 1.5}, {'table_min_confidence': '0.5'}):
+            with self.assertRaises(ValueError):
+                TableProcessor.table_thresholds(options)
+
+
+# pdfplumber output for a table with a merged header cell, wrapped cells, and a pipe
+MESSY_TABLE = [
+    ['Option', 'Behavior'],
+    ['retry', 'Retries the request\nup to 3 times', 'default'],
+    ['mode', 'a|b', None],
+    [None, 'Continued from\r\nthe row above'],
+]
+
+
+class TestTableMarkdown(unittest.TestCase):
+    """Test pipe-table rendering of multi-line, merged, and ragged cells"""
+
+    def test_messy_table_renders_aligned(self):
+        markdown = TableProcessor.table_to_markdown(MESSY_TABLE)
+        self.assertEqual(markdown.split('\n'), [
+            '| Option | Behavior |  |',
+            '|:---|:---|:---|',
+            '| retry | Retries the request<br>up to 3 times | default |',
+            '| mode | a\\|b |  |',
+            '|  | Continued from<br>the row above |  |',
+        ])
+
+    def test_already_escaped_pipe_kept(self):
+        self.assertEqual(TableProcessor.markdown_table_cell('a\\|b'), 'a\\|b')
+
+    def test_merged_header_does_not_throw(self):
+        rows = TableProcessor.normalize_table_rows(MESSY_TABLE)
+        self.assertEqual({len(row) for row in rows}, {3})
+        self.assertEqual(rows[2], ['mode', 'a|b', ''])
+        result = TableProcessor.build_relational_schema({'page': 1, 'index': 0, 'data': MESSY_TABLE})
+        self.assertEqual(len(result['columns']), 3)
+
+    def test_padded_and_repeated_header_labels_are_unique(self):
+        self.assertEqual(TableProcessor.header_labels(['Option', 'Behavior', '']),
+                         ['Option', 'Behavior', 'column_3'])
+        self.assertEqual(TableProcessor.header_labels(['', 'Qty', 'Qty', '']),
+                         ['column_1', 'Qty', 'Qty_2', 'column_4'])
+
+    def test_empty_table(self):
+        self.assertEqual(TableProcessor.table_to_markdown([]), '')
+
+    def test_numeric_columns_align_right(self):
+        markdown = TableProcessor.table_to_markdown([
+            ['Region', 'Units', 'Revenue', 'Ship Date', 'Notes'],
+            ['North', '1,200', '$4,500.50', '2024-01-31', ''],
+            ['South', '800', '(12.5)', '2024-02-15', ''],
+        ])
+        self.assertEqual(markdown.split('\n')[1], '|:---|---:|---:|:---|---|')
+
+    def test_dataframe_alignments(self):
+        table = [['Name', 'Count', 'Ratio', 'Enabled', 'Empty'],
+                 ['alpha', '12', '0.5', 'yes', ''],
+                 ['beta', '0', '1.25', 'no', '']]
+        # Values as process_table_for_structure converts them ('0' and 'yes' become booleans)
+        processor = TableProcessor.__new__(TableProcessor)
+        df = pd.DataFrame([[processor.detect_and_convert_cell_value(cell) for cell in row] for row in table[1:]],
+                          columns=table[0])
+        alignments = TableProcessor.dataframe_alignments(df)
+        self.assertEqual(alignments, ['left', 'right', 'right', 'left', None])
+        self.assertEqual(TableProcessor.table_to_markdown(table, alignments).split('\n')[1],
+                         '|:---|---:|---:|:---|---|')
+
+
+class TestTableCsv(unittest.TestCase):
+    """Test CSV sidecar files"""
+
+    def setUp(self):
+        self.temp_dir = tempfile.mkdtemp()
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_filename_counts_from_one(self):
+        self.assertEqual(TableProcessor.csv_filename({'page': 4, 'index': 1}), 'table_p4_2.csv')
+
+    def test_rows_written_and_padded(self):
+        table = {'page': 2, 'index': 0, 'data': [
+            ['Code', 'Meaning', 'Notes'],
+            ['200', 'OK, done'],
+            ['404', None, 'Not "found"'],
+        ]}
+        csv_path = TableProcessor.write_table_csv(table, Path(self.temp_dir) / 'table_p2_1.csv')
+        with open(csv_path, encoding='utf-8', newline='') as f:
+            rows = list(csv.reader(f))
+        self.assertEqual(rows, [['Code', 'Meaning', 'Notes'], ['200', 'OK, done', ''], ['404', '', 'Not "found"']])
+
+    def test_short_header_padded_with_named_columns(self):
+        table = {'page': 2, 'index': 0, 'data': [['Code', 'Meaning'], ['200', 'OK', '1'], ['404', 'Missing', '2']]}
+        csv_path = TableProcessor.write_table_csv(table, Path(self.temp_dir) / 'table_p2_1.csv')
+        with open(csv_path, encoding='utf-8', newline='') as f:
+            self.assertEqual(next(csv.reader(f)), ['Code', 'Meaning', 'column_3'])
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,107 @@
+"""
+Test tracking temporary files and removing them on shutdown
+"""
+import asyncio
+import os
+import shutil
+import signal
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.temp_files import TempTracker, install_shutdown_handlers
+
+
+class TestTempTracker(unittest.TestCase):
+    """Test registering and removing temporary paths"""
+
+    def setUp(self):
+        self.base = Path(tempfile.mkdtemp())
+        self.addCleanup(shutil.rmtree, self.base, ignore_errors=True)
+        self.tracker = TempTracker()
+
+    def _paths(self):
+        file_path = self.base / "temp_converted.md"
+        file_path.write_text("# Draft")
+        dir_path = self.base / "download"
+        (dir_path / "nested").mkdir(parents=True)
+        (dir_path / "nested" / "file.pdf").write_bytes(b"%PDF")
+        return file_path, dir_path
+
+    def test_cleanup_removes_registered_paths(self):
+        file_path, dir_path = self._paths()
+        self.tracker.register(file_path)
+        self.tracker.register(str(dir_path))
+        self.tracker.register(self.base / "already-gone")
+        self.assertEqual(self.tracker.cleanup(), 2)
+        self.assertFalse(file_path.exists())
+        self.assertFalse(dir_path.exists())
+        self.assertEqual(self.tracker.paths(), [])
+
+    def test_directory_is_released_on_exit(self):
+        with self.tracker.directory(prefix="pdf-test-") as temp_dir:
+            self.assertTrue(Path(temp_dir).is_dir())
+            self.assertEqual(self.tracker.paths(), [Path(temp_dir)])
+        self.assertFalse(Path(temp_dir).exists())
+        self.assertEqual(self.tracker.paths(), [])
+
+    def test_forget_keeps_the_path(self):
+        file_path, _ = self._paths()
+        self.tracker.register(file_path)
+        self.tracker.forget(file_path)
+        self.assertEqual(self.tracker.cleanup(), 0)
+        self.assertTrue(file_path.exists())
+
+    @unittest.skipIf(sys.platform == 'win32', "asyncio signal handlers need a Unix event loop")
+    def test_sigterm_cancels_before_paths_are_removed(self):
+        file_path, dir_path = self._paths()
+        received = []
+        exits = []
+
+        async def server():
+            installed = install_shutdown_handlers(self.tracker, on_signal=received.append, force_exit=exits.append)
+            self.assertIn(signal.SIGTERM, installed)
+            self.tracker.register(file_path)
+            self.tracker.register(dir_path)
+            os.kill(os.getpid(), signal.SIGTERM)
+            try:
+                await asyncio.sleep(5)
+            except asyncio.CancelledError:
+                # A worker still writing into the paths must find them in place
+                return 'cancelled' if file_path.exists() and dir_path.exists() else 'removed too early'
+            finally:
+                self.tracker.cleanup()
+            return 'not cancelled'
+
+        self.assertEqual(asyncio.run(server()), 'cancelled')
+        self.assertEqual(received, [signal.SIGTERM])
+        self.assertEqual(exits, [])
+        self.assertFalse(file_path.exists())
+        self.assertFalse(dir_path.exists())
+
+    @unittest.skipIf(sys.platform == 'win32', "asyncio signal handlers need a Unix event loop")
+    def test_shutdown_is_bounded(self):
+        file_path, _ = self._paths()
+        exits = []
+
+        async def server():
+            install_shutdown_handlers(self.tracker, grace_seconds=0.05, force_exit=exits.append)
+            self.tracker.register(file_path)
+            os.kill(os.getpid(), signal.SIGTERM)
+            try:
+                await asyncio.sleep(5)
+            except asyncio.CancelledError:
+                # A tool call that won't stop: the grace period runs out while it waits
+                await asyncio.sleep(0.2)
+                return exits
+
+        self.assertEqual(asyncio.run(server()), [128 + signal.SIGTERM])
+        self.assertFalse(file_path.exists())
+
+
+if __name__ == '__main__':
+    unittest.main()
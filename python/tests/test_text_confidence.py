@@ -0,0 +1,91 @@
+"""
+Test per-page text layer confidence and suspect (likely scanned) pages
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.text_confidence import font_embedded, page_fonts, page_text_confidence, readable_chars
+
+try:
+    import pypdf  # noqa: F401
+    import pdfplumber  # noqa: F401
+    HAS_ANALYZER_DEPS = True
+except ImportError:
+    HAS_ANALYZER_DEPS = False
+
+if HAS_ANALYZER_DEPS:
+    from pdf_analyzer import analyze_pdf
+
+FIXTURE = os.path.join(os.path.dirname(os.path.abspath(__file__)), "fixtures", "scanned_page.pdf")
+
+LETTER = (612, 792)
+EMBEDDED_FONT = {'/Subtype': '/TrueType', '/FontDescriptor': {'/FontFile2': b'...'}}
+STANDARD_FONT = {'/Subtype': '/Type1', '/BaseFont': '/Helvetica'}
+
+
+class TestScoring(unittest.TestCase):
+    """Test the confidence heuristic"""
+
+    def test_text_page_is_confident(self):
+        text = page_text_confidence(1, 1800, *LETTER, fonts=2, embedded_fonts=2, images=0)
+        self.assertEqual(text['confidence'], 1.0)
+        self.assertFalse(text['suspect'])
+
+    def test_image_only_page_is_suspect(self):
+        scan = page_text_confidence(2, 0, *LETTER, fonts=0, embedded_fonts=0, images=1)
+        self.assertEqual(scan['confidence'], 0.0)
+        self.assertTrue(scan['suspect'])
+
+    def test_sparse_text_over_an_image_is_suspect(self):
+        # A scan with only a stamped page number as text
+        self.assertTrue(page_text_confidence(3, 12, *LETTER, fonts=1, embedded_fonts=1, images=1)['suspect'])
+
+    def test_blank_page_is_not_suspect(self):
+        self.assertFalse(page_text_confidence(4, 0, *LETTER, fonts=0, embedded_fonts=0, images=0)['suspect'])
+
+    def test_text_without_page_fonts_is_not_penalized(self):
+        text = page_text_confidence(1, 1800, *LETTER, fonts=0, embedded_fonts=0, images=1)
+        self.assertEqual(text['confidence'], 1.0)
+        self.assertFalse(text['suspect'])
+
+    def test_unembedded_fonts_lower_confidence(self):
+        text = page_text_confidence(1, 1800, *LETTER, fonts=1, embedded_fonts=0, images=0)
+        self.assertEqual(text['confidence'], 0.8)
+
+    def test_unmapped_glyphs_are_not_readable(self):
+        self.assertEqual(readable_chars(["A", " ", "(cid:12)", "�", "b", "\n"]), 2)
+
+
+class TestFonts(unittest.TestCase):
+    """Test counting embedded fonts in page resources"""
+
+    def test_embedded_and_standard_fonts(self):
+        self.assertEqual(page_fonts({'/Font': {'/F1': EMBEDDED_FONT, '/F2': STANDARD_FONT}}), (2, 1))
+        self.assertEqual(page_fonts({'/XObject': {}}), (0, 0))
+        self.assertEqual(page_fonts(None), (0, 0))
+
+    def test_composite_and_type3_fonts(self):
+        self.assertTrue(font_embedded({'/Subtype': '/Type0', '/DescendantFonts': [EMBEDDED_FONT]}))
+        self.assertFalse(font_embedded({'/Subtype': '/Type0', '/DescendantFonts': [STANDARD_FONT]}))
+        self.assertTrue(font_embedded({'/Subtype': '/Type3'}))
+
+
+@unittest.skipUnless(HAS_ANALYZER_DEPS, "pypdf and pdfplumber are required")
+class TestAnalysis(unittest.TestCase):
+    """Test analyze_pdf on a text page and an image-only page"""
+
+    def test_image_only_page_is_listed(self):
+        analysis = analyze_pdf(FIXTURE)
+        self.assertEqual(analysis['suspect_pages'], [2])
+        text_page, scan_page = analysis['text_confidence']
+        self.assertGreaterEqual(text_page['confidence'], 0.8)
+        self.assertGreater(text_page['chars'], 300)
+        self.assertEqual((scan_page['chars'], scan_page['fonts'], scan_page['images']), (0, 0, 1))
+
+
+if __name__ == '__main__':
+    unittest.main()
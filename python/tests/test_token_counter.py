@@ -0,0 +1,42 @@
+"""
+Test tokenizer selection for token counts
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.token_counter import TokenCounter, TIKTOKEN_AVAILABLE, TOKENIZERS, DEFAULT_TOKENIZER
+
+
+class TestTokenizerSelection(unittest.TestCase):
+    """Test encodings and characters-per-token fallbacks"""
+
+    def test_claude_uses_char_ratio(self):
+        counter = TokenCounter(tokenizer='claude')
+        self.assertTrue(counter.approximate)
+        self.assertEqual(counter.count_tokens("x" * 35), 10)
+        self.assertEqual(counter.tokenizer_name(), "claude (approximate, 3.5 characters per token)")
+
+    def test_unknown_name_falls_back(self):
+        counter = TokenCounter(tokenizer='mystery')
+        self.assertTrue(counter.approximate)
+        self.assertEqual(counter.count_tokens("x" * 40), 10)
+
+    def test_default_name(self):
+        self.assertEqual(TokenCounter().name, DEFAULT_TOKENIZER)
+        self.assertIn(DEFAULT_TOKENIZER, TOKENIZERS)
+
+    @unittest.skipUnless(TIKTOKEN_AVAILABLE, "tiktoken is required")
+    def test_tiktoken_encoding(self):
+        counter = TokenCounter(tokenizer='o200k_base')
+        if counter.approximate:
+            self.skipTest("o200k_base encoding could not be loaded")
+        self.assertEqual(counter.tokenizer_name(), 'o200k_base')
+        self.assertGreater(counter.count_tokens("hello world"), 0)
+
+
+if __name__ == '__main__':
+    unittest.main()
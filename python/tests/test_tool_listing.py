@@ -0,0 +1,116 @@
+"""
+Test tools/list paging and brief descriptions
+"""
+import unittest
+import asyncio
+from unittest.mock import patch
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.tool_listing import (
+    DETAIL_ENV, PAGE_SIZE_ENV, brief_schema, brief_text, encode_cursor, paginate, tools_list_detail, tools_page_size
+)
+
+try:
+    from mcp.types import ListToolsRequest
+    from mcp_document_markdown import app, list_tools
+    HAS_SERVER = True
+except ImportError:
+    HAS_SERVER = False
+
+TOOLS = [f"tool_{index}" for index in range(7)]
+
+
+class TestPaginate(unittest.TestCase):
+    """Test cursor paging"""
+
+    def test_pages_are_disjoint_and_reassemble(self):
+        pages, cursor = [], None
+        while True:
+            page, cursor = paginate(TOOLS, cursor, 3)
+            pages.append(page)
+            if cursor is None:
+                break
+        self.assertEqual([len(page) for page in pages], [3, 3, 1])
+        self.assertEqual(sum(pages, []), TOOLS)
+        self.assertEqual(len(set(sum(pages, []))), len(TOOLS))
+
+    def test_no_page_size_returns_everything(self):
+        self.assertEqual(paginate(TOOLS, None, 0), (TOOLS, None))
+
+    def test_bad_cursors(self):
+        for cursor in ("not-a-cursor", encode_cursor(7), encode_cursor(99)):
+            with self.assertRaises(ValueError):
+                paginate(TOOLS, cursor, 3)
+
+
+class TestBrief(unittest.TestCase):
+    """Test trimmed descriptions keep the schema usable"""
+
+    def test_first_sentence(self):
+        self.assertEqual(brief_text("Path to the PDF. Relative paths use the server's directory."), "Path to the PDF")
+        self.assertEqual(brief_text("Compare two PDFs (e.g. two revisions). Slow."), "Compare two PDFs (e.g. two revisions)")
+        # A sentence break inside parentheses drops the unclosed parenthetical
+        self.assertEqual(brief_text("Timeout in seconds (default: 300. See below) here"), "Timeout in seconds")
+
+    def test_schema_keeps_everything_but_long_descriptions(self):
+        schema = {
+            "type": "object",
+            "properties": {
+                "mode": {"type": "string", "enum": ["a", "b"], "default": "a",
+                         "description": "Output mode. The a mode is faster."},
+                "pages": {"type": "array", "items": {"type": "integer", "description": "A page. 1-based."}},
+            },
+            "required": ["mode"],
+        }
+        brief = brief_schema(schema)
+        self.assertEqual(brief["properties"]["mode"],
+                         {"type": "string", "enum": ["a", "b"], "default": "a", "description": "Output mode"})
+        self.assertEqual(brief["properties"]["pages"]["items"]["description"], "A page")
+        self.assertEqual(brief["required"], ["mode"])
+        self.assertEqual(schema["properties"]["mode"]["description"], "Output mode. The a mode is faster.")
+
+    def test_settings(self):
+        with patch.dict(os.environ, {DETAIL_ENV: "FULL", PAGE_SIZE_ENV: "oops"}):
+            self.assertEqual(tools_list_detail(), "full")
+            self.assertEqual(tools_list_detail("brief"), "brief")
+            self.assertEqual(tools_page_size(), 0)
+        with patch.dict(os.environ, {DETAIL_ENV: "", PAGE_SIZE_ENV: "5"}):
+            self.assertEqual(tools_list_detail(), "brief")
+            self.assertEqual(tools_page_size(), 5)
+
+
+@unittest.skipUnless(HAS_SERVER, "mcp and the server dependencies are required")
+class TestRegisteredHandler(unittest.TestCase):
+    """Test the tools/list handler registered on the server"""
+
+    def handle(self, request):
+        return asyncio.run(app.request_handlers[ListToolsRequest](request)).root
+
+    def test_cache_refill_without_request(self):
+        # The SDK calls the handler with None to refill its tool cache on a miss
+        with patch.dict(os.environ, {DETAIL_ENV: "brief", PAGE_SIZE_ENV: "2"}):
+            result = self.handle(None)
+        full = asyncio.run(list_tools())
+        self.assertEqual(result.tools, full)
+        if hasattr(app, '_tool_cache'):
+            self.assertEqual(set(app._tool_cache), {tool.name for tool in full})
+
+    def test_pages_with_brief_descriptions(self):
+        full = asyncio.run(list_tools())
+        with patch.dict(os.environ, {DETAIL_ENV: "brief", PAGE_SIZE_ENV: "2"}):
+            first = self.handle(ListToolsRequest(method="tools/list"))
+            second = self.handle(ListToolsRequest.model_validate(
+                {"method": "tools/list", "params": {"cursor": first.nextCursor}}))
+        self.assertEqual([tool.name for tool in first.tools + second.tools], [tool.name for tool in full[:4]])
+        self.assertEqual(first.tools[0].description, brief_text(full[0].description))
+        if hasattr(app, '_tool_cache'):
+            # The cache keeps the full schemas that tools/call arguments are validated against
+            self.assertEqual(app._tool_cache[full[0].name].inputSchema, full[0].inputSchema)
+
+
+if __name__ == '__main__':
+    unittest.main()
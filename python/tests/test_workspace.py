@@ -0,0 +1,86 @@
+"""
+Test resolving path arguments against WORKSPACE_ROOT
+"""
+import unittest
+import tempfile
+import shutil
+from pathlib import Path
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.workspace import Workspace, WorkspacePathError, load_workspace
+
+
+class TestWorkspace(unittest.TestCase):
+    """Test the path traversal guard"""
+
+    def setUp(self):
+        self.temp_dir = Path(tempfile.mkdtemp()).resolve()
+        self.root = self.temp_dir / "workspace"
+        self.root.mkdir()
+        self.workspace = load_workspace(str(self.root))
+
+    def tearDown(self):
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def test_relative_paths_resolve_against_root(self):
+        arguments = self.workspace.resolve_arguments({'pdf_path': "specs/api.pdf", 'output_dir': "./docs",
+                                                      'chunk_tokens': 500})
+        self.assertEqual(arguments, {'pdf_path': str(self.root / "specs" / "api.pdf"),
+                                     'output_dir': str(self.root / "docs"), 'chunk_tokens': 500})
+
+    def test_paths_inside_root_pass(self):
+        inside = str(self.root / "a" / ".." / "b.pdf")
+        self.assertEqual(self.workspace.resolve(inside, 'pdf_path'), str(self.root / "b.pdf"))
+        self.assertEqual(self.workspace.resolve(".", 'output_dir'), str(self.root))
+
+    def test_escaping_paths_are_refused(self):
+        for value in ("../secrets.pdf", "docs/../../etc", "/etc/passwd", str(self.temp_dir / "workspace2")):
+            with self.assertRaises(WorkspacePathError) as raised:
+                self.workspace.resolve_arguments({'output_dir': value})
+            self.assertIn("outside the workspace root", str(raised.exception))
+
+    def test_symlink_out_of_root_is_refused(self):
+        outside = self.temp_dir / "outside"
+        outside.mkdir()
+        (self.root / "link").symlink_to(outside, target_is_directory=True)
+        with self.assertRaises(WorkspacePathError):
+            self.workspace.resolve("link/report.pdf", 'pdf_path')
+
+    def test_lists_options_and_fingerprint_paths(self):
+        arguments = self.workspace.resolve_arguments({
+            'pdf_paths': ["a.pdf", "https://example.com/b.pdf"],
+            'options': {'corpus_index_path': "index/corpus-index.json", 'page_range': "1-5"},
+            'fingerprint': "docs/manifest.json"
+        })
+        self.assertEqual(arguments['pdf_paths'], [str(self.root / "a.pdf"), "https://example.com/b.pdf"])
+        self.assertEqual(arguments['options'], {'corpus_index_path': str(self.root / "index" / "corpus-index.json"),
+                                                'page_range': "1-5"})
+        self.assertEqual(arguments['fingerprint'], str(self.root / "docs" / "manifest.json"))
+        # Fingerprint JSON text is not a path
+        self.assertEqual(self.workspace.resolve_arguments({'fingerprint': '{"page_hashes": []}'}),
+                         {'fingerprint': '{"page_hashes": []}'})
+        with self.assertRaises(WorkspacePathError):
+            self.workspace.resolve_arguments({'options': {'corpus_index_path': "/tmp/corpus-index.json"}})
+
+    def test_batch_pattern_cannot_climb(self):
+        self.workspace.resolve_arguments({'directory': "pdfs", 'pattern': "**/*.pdf"})
+        for pattern in ("../*.pdf", "/etc/*.pdf"):
+            with self.assertRaises(WorkspacePathError):
+                self.workspace.resolve_arguments({'directory': "pdfs", 'pattern': pattern})
+
+    def test_without_root_arguments_are_unchanged(self):
+        arguments = {'pdf_path': "../elsewhere.pdf"}
+        self.assertIs(Workspace().resolve_arguments(arguments), arguments)
+        self.assertIsNone(load_workspace("").root)
+
+    def test_root_must_be_a_directory(self):
+        with self.assertRaises(WorkspacePathError):
+            load_workspace(str(self.temp_dir / "missing"))
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,71 @@
+"""
+Test XMP metadata parsing
+"""
+import unittest
+import sys
+import os
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.dirname(os.path.dirname(os.path.abspath(__file__))))
+
+from utils.xmp import parse_xmp, format_xmp
+
+PACKET = b"""<?xpacket begin="\xef\xbb\xbf" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about=""
+    xmlns:dc="http://purl.org/dc/elements/1.1/"
+    xmlns:pdf="http://ns.adobe.com/pdf/1.3/"
+    xmlns:xmp="http://ns.adobe.com/xap/1.0/"
+    xmlns:xmpRights="http://ns.adobe.com/xap/1.0/rights/"
+    xmlns:lib="http://example.org/library/1.0/"
+    pdf:Producer="Typesetter 2.1"
+    lib:CallNumber="QA76.9 .D3">
+   <dc:title><rdf:Alt><rdf:li xml:lang="fr">Rapport annuel</rdf:li><rdf:li xml:lang="x-default">Annual Report</rdf:li></rdf:Alt></dc:title>
+   <dc:creator><rdf:Seq><rdf:li>A. Author</rdf:li><rdf:li>B. Author</rdf:li></rdf:Seq></dc:creator>
+   <dc:subject><rdf:Bag><rdf:li>finance</rdf:li><rdf:li>2024</rdf:li></rdf:Bag></dc:subject>
+   <dc:rights><rdf:Alt><rdf:li xml:lang="x-default">CC BY 4.0</rdf:li></rdf:Alt></dc:rights>
+   <xmp:CreateDate>2024-03-01T10:00:00Z</xmp:CreateDate>
+   <xmpRights:Marked>True</xmpRights:Marked>
+   <lib:Collection>Special Collections</lib:Collection>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>"""
+
+
+class TestXmpMetadata(unittest.TestCase):
+    """Test Dublin Core, XMP basic, and custom namespace fields"""
+
+    def setUp(self):
+        self.xmp = parse_xmp(PACKET)
+
+    def test_dublin_core(self):
+        dc = self.xmp['dublin_core']
+        self.assertEqual(dc['title'], 'Annual Report')
+        self.assertEqual(dc['creator'], ['A. Author', 'B. Author'])
+        self.assertEqual(dc['subject'], ['finance', '2024'])
+        self.assertEqual(dc['rights'], 'CC BY 4.0')
+
+    def test_known_schemas_and_attributes(self):
+        self.assertEqual(self.xmp['pdf']['Producer'], 'Typesetter 2.1')
+        self.assertEqual(self.xmp['xmp']['CreateDate'], '2024-03-01T10:00:00Z')
+        self.assertEqual(self.xmp['rights']['Marked'], 'True')
+
+    def test_custom_fields_keep_their_prefix(self):
+        self.assertEqual(self.xmp['custom'], {'lib:CallNumber': 'QA76.9 .D3', 'lib:Collection': 'Special Collections'})
+
+    def test_missing_or_invalid_packet(self):
+        self.assertIsNone(parse_xmp(None))
+        self.assertIsNone(parse_xmp(b"<x:xmpmeta><broken"))
+        self.assertEqual(format_xmp(None), "No XMP metadata")
+
+    def test_format_lists_groups(self):
+        text = format_xmp(self.xmp)
+        self.assertIn("Dublin Core:", text)
+        self.assertIn("  subject: finance, 2024", text)
+        self.assertIn("  lib:Collection: Special Collections", text)
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,100 @@
+"""
+Structured result of a PDF structure analysis
+
+pdf_analyzer.py prints a human-readable report followed by a JSON_MARKER
+line and the analysis as JSON. Scripts that run the analyzer as a command
+split the output here instead of re-parsing the report; the MCP server
+returns the same fields as the second content item of analyze_pdf.
+"""
+import json
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional
+
+# Line separating the human-readable report from the JSON analysis
+JSON_MARKER = '---JSON---'
+
+
+@dataclass
+class AnalysisResult:
+    """PDF structure analysis with typed fields"""
+    pages: int = 0
+    has_toc: bool = False
+    has_tables: bool = False
+    has_images: bool = False
+    table_count: int = 0
+    image_count: int = 0
+    chapters: List[Dict[str, Any]] = field(default_factory=list)
+    metadata: Dict[str, Any] = field(default_factory=dict)
+    xmp: Optional[Dict[str, Any]] = None
+    # Per page text layer confidence (utils.text_confidence), and pages likely needing OCR
+    text_confidence: List[Dict[str, Any]] = field(default_factory=list)
+    suspect_pages: List[int] = field(default_factory=list)
+    # Human-readable report preceding the JSON (not part of to_dict)
+    report: str = ''
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any], report: str = '') -> 'AnalysisResult':
+        """
+        Build a result from the analyzer's JSON
+
+        Raises:
+            ValueError: If data is not an object or a field has the wrong type
+        """
+        if not isinstance(data, dict):
+            raise ValueError(f"Analysis JSON must be an object, got {type(data).__name__}")
+        try:
+            return cls(
+                pages=int(data.get('pages') or 0),
+                has_toc=bool(data.get('has_toc', False)),
+                has_tables=bool(data.get('has_tables', False)),
+                has_images=bool(data.get('has_images', False)),
+                table_count=int(data.get('table_count') or 0),
+                image_count=int(data.get('image_count') or 0),
+                chapters=list(data.get('chapters') or []),
+                metadata=dict(data.get('metadata') or {}),
+                xmp=data.get('xmp'),
+                text_confidence=list(data.get('text_confidence') or []),
+                suspect_pages=[int(page) for page in data.get('suspect_pages') or []],
+                report=report
+            )
+        except (TypeError, ValueError) as e:
+            raise ValueError(f"Malformed analysis JSON: {e}")
+
+    def to_dict(self) -> Dict[str, Any]:
+        """The analysis fields as a JSON-serializable dictionary"""
+        return {
+            'pages': self.pages,
+            'has_toc': self.has_toc,
+            'has_tables': self.has_tables,
+            'has_images': self.has_images,
+            'table_count': self.table_count,
+            'image_count': self.image_count,
+            'chapters': self.chapters,
+            'metadata': self.metadata,
+            'xmp': self.xmp,
+            'text_confidence': self.text_confidence,
+            'suspect_pages': self.suspect_pages
+        }
+
+
+def parse_analysis_output(raw: str) -> AnalysisResult:
+    """
+    Split pdf_analyzer.py output into the report and the structured analysis
+
+    Raises:
+        ValueError: If the JSON marker is missing or the JSON after it is invalid
+    """
+    lines = raw.replace('\r\n', '\n').split('\n')
+    try:
+        marker = max(index for index, line in enumerate(lines) if line.strip() == JSON_MARKER)
+    except ValueError:
+        raise ValueError(f"Analyzer output has no {JSON_MARKER} block")
+
+    payload = '\n'.join(lines[marker + 1:]).strip()
+    if not payload:
+        raise ValueError(f"Analyzer output has an empty {JSON_MARKER} block")
+    try:
+        data = json.loads(payload)
+    except json.JSONDecodeError as e:
+        raise ValueError(f"Invalid JSON after {JSON_MARKER}: {e}")
+    return AnalysisResult.from_dict(data, report='\n'.join(lines[:marker]).strip())
@@ -0,0 +1,268 @@
+"""
+Converting many PDFs in one tool call
+
+convert_pdf_batch converts each PDF in its own converter process (PyMuPDF
+is not safe to drive from several threads at once), at most concurrency
+at a time. A PDF that fails is reported in its result entry and the rest
+keep going; the batch itself only fails on bad arguments.
+
+On a loaded machine, starting a converter process can fail for a moment
+(fork or exec short of processes, memory, or file descriptors). Such a
+start is retried up to CONVERTER_START_RETRIES times with exponential
+backoff before it counts as a ConverterStartError. A converter that did
+start and then failed (ConverterFailed) is never retried: running the
+same conversion again would fail the same way.
+
+Options reach the converter process on stdin, not its command line: they
+can include a PDF password, and command lines are visible to every user
+of the machine (ps, /proc/*/cmdline).
+"""
+import asyncio
+import errno
+import json
+import os
+from pathlib import Path
+from typing import Any, Awaitable, Callable, Dict, List, Optional, TypeVar
+
+from .concurrency import WorkerLimit
+from .environment import converter_interpreter
+from .file_utils import FileUtils
+
+DEFAULT_BATCH_CONCURRENCY = 2
+MAX_BATCH_CONCURRENCY = 8
+
+# Line the converter prints before its results as JSON (modular_pdf_converter.main)
+RESULTS_JSON_MARKER = '=== CONVERSION_RESULTS_JSON ==='
+
+CONVERTER_SCRIPT = Path(__file__).resolve().parent.parent / 'modular_pdf_converter.py'
+
+# Retries after a transient failure to start the converter (CONVERTER_START_RETRIES env)
+DEFAULT_START_RETRIES = 2
+MAX_START_RETRIES = 10
+
+# Seconds before the first retry; doubled for each one after it
+START_BACKOFF_SECONDS = 0.5
+
+# errno values of a start that may succeed if tried again shortly
+TRANSIENT_START_ERRNOS = {errno.EAGAIN, errno.ENOMEM, errno.EMFILE, errno.ENFILE, errno.ETXTBSY}
+
+T = TypeVar('T')
+
+
+class ConverterStartError(RuntimeError):
+    """The converter process could not be started"""
+
+
+class ConverterFailed(ValueError):
+    """The converter process ran but exited without results"""
+
+
+def validate_concurrency(value: Any) -> int:
+    """
+    Check the concurrency limit
+
+    Raises:
+        ValueError: If it is not an integer from 1 to MAX_BATCH_CONCURRENCY
+    """
+    if isinstance(value, bool) or not isinstance(value, int) or not 1 <= value <= MAX_BATCH_CONCURRENCY:
+        raise ValueError(f"concurrency must be an integer from 1 to {MAX_BATCH_CONCURRENCY}")
+    return value
+
+
+def resolve_batch_pdfs(pdf_paths: Optional[List[str]] = None, directory: Optional[str] = None,
+                       pattern: str = '*.pdf') -> List[str]:
+    """
+    PDFs to convert: the given paths, or the files in directory matching pattern
+
+    Raises:
+        ValueError: If neither or both are given, or nothing matches
+        FileNotFoundError: If a path or the directory does not exist
+    """
+    if bool(pdf_paths) == bool(directory):
+        raise ValueError("Give either pdf_paths or directory")
+
+    if directory:
+        root = Path(directory)
+        if not root.is_dir():
+            raise FileNotFoundError(f"Directory not found: {directory}")
+        paths = sorted(str(path) for path in root.glob(pattern or '*.pdf') if path.is_file())
+        if not paths:
+            raise ValueError(f"No files in {directory} match {pattern}")
+        return paths
+
+    if not isinstance(pdf_paths, list):
+        raise ValueError("pdf_paths must be a list of PDF files")
+    paths = []
+    for pdf_path in pdf_paths:
+        if not Path(pdf_path).exists():
+            raise FileNotFoundError(f"PDF file not found: {pdf_path}")
+        if pdf_path not in paths:
+            paths.append(pdf_path)
+    return paths
+
+
+def batch_output_dirs(pdf_paths: List[str], output_dir: str, directory: Optional[str] = None) -> Dict[str, str]:
+    """
+    Output directory for each PDF of a batch
+
+    Each document's folder is named after its file name, so two PDFs with
+    the same name converted into one output_dir would overwrite each other.
+    PDFs found under directory keep their subfolder (directory/q1/report.pdf
+    is converted into output_dir/q1); pdf_paths all go into output_dir.
+
+    Raises:
+        ValueError: If two PDFs would still share an output folder
+    """
+    output_dirs: Dict[str, str] = {}
+    claimed: Dict[Path, str] = {}
+    for pdf_path in pdf_paths:
+        target = output_dir
+        if directory:
+            subfolder = Path(pdf_path).parent.relative_to(directory)
+            if subfolder.parts:
+                target = str(Path(output_dir) / subfolder)
+        folder = Path(target) / FileUtils.sanitize_folder_name(Path(pdf_path).name)
+        if folder in claimed:
+            raise ValueError(f"{claimed[folder]} and {pdf_path} would both be converted into {folder}; "
+                             f"rename one or convert them in separate batches with different output_dir")
+        claimed[folder] = pdf_path
+        output_dirs[pdf_path] = target
+    return output_dirs
+
+
+def start_retries() -> int:
+    """Configured retries for starting the converter (CONVERTER_START_RETRIES env, 0 to MAX_START_RETRIES)"""
+    try:
+        return min(max(int(os.environ.get('CONVERTER_START_RETRIES', DEFAULT_START_RETRIES)), 0), MAX_START_RETRIES)
+    except ValueError:
+        return DEFAULT_START_RETRIES
+
+
+def is_transient_start_error(error: OSError) -> bool:
+    """Whether a failed process start is worth retrying (not a missing or non-executable interpreter)"""
+    return error.errno in TRANSIENT_START_ERRNOS
+
+
+async def start_converter(start: Callable[[], Awaitable[T]], retries: Optional[int] = None,
+                          backoff: float = START_BACKOFF_SECONDS,
+                          sleep: Callable[[float], Awaitable[Any]] = asyncio.sleep,
+                          on_retry: Optional[Callable[[OSError, float], None]] = None) -> T:
+    """
+    Start the converter process, retrying transient start failures
+
+    Args:
+        start: Starts the process (e.g. asyncio.create_subprocess_exec) and returns it
+        retries: Retries after the first attempt (default: start_retries())
+        backoff: Seconds before the first retry, doubled for each one after it
+        sleep: Waits between attempts (cancellable)
+        on_retry: Called with the error and the delay before each retry
+
+    Raises:
+        ConverterStartError: The start failed for good, or still failed after every retry
+    """
+    retries = start_retries() if retries is None else retries
+    for attempt in range(retries + 1):
+        try:
+            return await start()
+        except OSError as e:
+            if not is_transient_start_error(e):
+                raise ConverterStartError(f"Could not start the converter: {e}") from e
+            if attempt == retries:
+                raise ConverterStartError(f"Could not start the converter after {attempt + 1} attempt(s): {e}") from e
+            if on_retry:
+                on_retry(e, backoff * 2 ** attempt)
+            await sleep(backoff * 2 ** attempt)
+
+
+def converter_command(pdf_path: str, output_dir: str) -> List[str]:
+    """
+    Command line running the converter on one PDF in a separate process
+
+    The options are read from stdin ("-"); write converter_input(options) to it.
+
+    Raises:
+        InterpreterNotFound: No Python interpreter to run it with
+    """
+    return [converter_interpreter(), str(CONVERTER_SCRIPT), pdf_path, output_dir, '-']
+
+
+def converter_input(options: Dict[str, Any]) -> bytes:
+    """The options as the converter process reads them from stdin"""
+    return json.dumps(options).encode('utf-8')
+
+
+def parse_converter_output(output: str) -> Dict[str, Any]:
+    """
+    The results dictionary from the converter's command-line output
+
+    Raises:
+        ValueError: If the output has no results JSON (the process crashed)
+    """
+    _, marker, results = output.rpartition(RESULTS_JSON_MARKER)
+    if not marker:
+        raise ValueError("Converter exited without reporting results")
+    try:
+        return json.loads(results)
+    except json.JSONDecodeError as e:
+        raise ValueError(f"Converter reported malformed results: {e}")
+
+
+def batch_file_result(pdf_path: str, results: Optional[Dict[str, Any]] = None,
+                      error: Optional[str] = None) -> Dict[str, Any]:
+    """
+    Result entry for one PDF of a batch
+
+    A successful conversion carries its conversion manifest summary (file
+    count, bytes, tokens, and files per kind; not the file list).
+    """
+    if results is not None and not results.get('success'):
+        error = results.get('error') or 'Unknown error'
+    entry: Dict[str, Any] = {'pdf_path': pdf_path, 'success': error is None}
+    if error is not None:
+        entry['error'] = error
+        if results and results.get('error_code'):
+            entry['error_code'] = results['error_code']
+        return entry
+
+    manifest = results.get('conversion_manifest') or {}
+    entry['output_directory'] = results.get('output_directory')
+    entry['processing_time_seconds'] = results.get('processing_time_seconds')
+    entry['warnings'] = len(results.get('warnings') or [])
+    entry['manifest'] = {key: manifest.get(key) for key in ('file_count', 'total_bytes', 'total_tokens', 'kinds')}
+    return entry
+
+
+async def run_batch(pdf_paths: List[str], convert_one: Callable[[str], Awaitable[Dict[str, Any]]],
+                    concurrency: int = DEFAULT_BATCH_CONCURRENCY,
+                    process_limit: Optional[WorkerLimit] = None) -> List[Dict[str, Any]]:
+    """
+    Run convert_one on every PDF, at most concurrency at once
+
+    With process_limit, each convert_one also holds one of its slots, so
+    batches sharing it never run more than its limit between them.
+
+    convert_one returns a batch_file_result entry; an exception it raises
+    becomes a failed entry instead of stopping the batch. Cancellation still
+    propagates. Entries come back in pdf_paths order.
+    """
+    semaphore = asyncio.Semaphore(concurrency)
+
+    async def convert(pdf_path: str) -> Dict[str, Any]:
+        async with semaphore:
+            if process_limit is not None:
+                await process_limit.acquire()
+            try:
+                return await convert_one(pdf_path)
+            except Exception as e:
+                return batch_file_result(pdf_path, error=str(e) or type(e).__name__)
+            finally:
+                if process_limit is not None:
+                    process_limit.release()
+
+    return list(await asyncio.gather(*(convert(pdf_path) for pdf_path in pdf_paths)))
+
+
+def summarize_batch(entries: List[Dict[str, Any]]) -> Dict[str, int]:
+    """Counts of converted and failed PDFs"""
+    succeeded = sum(1 for entry in entries if entry['success'])
+    return {'total': len(entries), 'succeeded': succeeded, 'failed': len(entries) - succeeded}
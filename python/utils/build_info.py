@@ -0,0 +1,81 @@
+"""
+Version and build details of the running server
+
+Bug reports need to say which build was running. SERVER_VERSION is the
+release version; the commit and build date come from build_info.json next
+to the python/ scripts, which `make build-info` writes from git when the
+server is installed. Without that file the server is running straight from
+a checkout ('dev' mode) and the commit is read from git at startup, marked
+dirty when the checkout has uncommitted changes. serverInfo on initialize
+carries the version with the commit as build metadata (1.0.0+1a2b3c4d5e6f).
+"""
+import json
+import subprocess
+from pathlib import Path
+from typing import Any, Dict, Optional
+
+SERVER_VERSION = '1.0.0'
+
+# Written by `make build-info`: version, commit, build_date
+BUILD_INFO_FILE = 'build_info.json'
+
+# Scripts the server and converter processes run (python/)
+SCRIPTS_DIR = Path(__file__).resolve().parent.parent
+
+# Seconds allowed for each git command in dev mode
+GIT_TIMEOUT = 2.0
+
+
+def _git(repo: Path, *args: str) -> Optional[str]:
+    """Output of a git command in repo (None: git missing, not a checkout, or it failed)"""
+    try:
+        result = subprocess.run(['git', '-C', str(repo), *args], capture_output=True, text=True,
+                                timeout=GIT_TIMEOUT)
+    except (OSError, subprocess.SubprocessError):
+        return None
+    return result.stdout.strip() if result.returncode == 0 else None
+
+
+def build_info(scripts_dir: Path = SCRIPTS_DIR) -> Dict[str, Any]:
+    """
+    Version and build details
+
+    Returns:
+        Dictionary with version, commit and build_date (None: unknown),
+        dirty (dev mode only), mode ('release' from build_info.json, 'dev'
+        from a git checkout, or 'unknown'), and scripts_dir
+    """
+    info = {'version': SERVER_VERSION, 'commit': None, 'build_date': None, 'dirty': False,
+            'mode': 'unknown', 'scripts_dir': str(scripts_dir)}
+    stamp = scripts_dir / BUILD_INFO_FILE
+    if stamp.is_file():
+        try:
+            recorded = json.loads(stamp.read_text(encoding='utf-8'))
+        except (OSError, ValueError):
+            recorded = None
+        if isinstance(recorded, dict):
+            info.update({key: recorded.get(key) or info[key] for key in ('version', 'commit', 'build_date')})
+            info['mode'] = 'release'
+            return info
+    commit = _git(scripts_dir, 'rev-parse', 'HEAD')
+    if commit:
+        info['commit'] = commit
+        info['dirty'] = bool(_git(scripts_dir, 'status', '--porcelain', '--untracked-files=no'))
+        info['mode'] = 'dev'
+    return info
+
+
+def server_version(info: Dict[str, Any]) -> str:
+    """serverInfo version: the version, with the short commit as build metadata when known"""
+    if not info.get('commit'):
+        return info['version']
+    return f"{info['version']}+{info['commit'][:12]}{'.dirty' if info.get('dirty') else ''}"
+
+
+def format_build_info(info: Dict[str, Any]) -> str:
+    """Human-readable build details"""
+    lines = [f"Version: {server_version(info)}",
+             f"Commit: {info['commit'] or 'unknown'}{' (uncommitted changes)' if info.get('dirty') else ''}",
+             f"Built: {info['build_date'] or 'unknown'}",
+             f"Mode: {info['mode']} (scripts in {info['scripts_dir']})"]
+    return "\n".join(lines)
@@ -0,0 +1,62 @@
+"""
+Cancelling a conversion that is already running
+
+A client that disconnects or cancels a tools/call should not leave a
+conversion burning CPU in a worker thread. Python threads can't be killed,
+so the server sets a threading.Event and long-running loops call
+check_cancelled() between units of work (one page, one section file). The
+converter writes into a staging directory (see utils.staging) and deletes
+it when cancelled, so cancelling a re-conversion never touches the
+previous output it was about to replace.
+
+A timeout works the same way: once it passes, the event is set so the
+worker stops and cleans up, and the caller gets ConversionTimeout instead
+of waiting forever on a corrupt PDF.
+"""
+import os
+import threading
+from typing import Any, Optional
+
+# Seconds a conversion or analysis may run (CONVERSION_TIMEOUT overrides; 0 disables)
+DEFAULT_CONVERSION_TIMEOUT = 300
+
+# Seconds a timed-out worker is expected to stop within; past this it is logged as stuck (and still waited for)
+TIMEOUT_GRACE_SECONDS = 10
+
+
+class ConversionCancelled(Exception):
+    """Raised inside a conversion once its cancel event is set"""
+
+
+class ConversionTimeout(TimeoutError):
+    """Raised to the caller when a conversion runs past its timeout"""
+
+
+def conversion_timeout(timeout_seconds: Any = None) -> Optional[float]:
+    """
+    Resolve the timeout: the tool argument, else CONVERSION_TIMEOUT, else 5 minutes
+
+    Returns:
+        Seconds, or None when the timeout is disabled (0)
+
+    Raises:
+        ValueError: If the value is not a non-negative number
+    """
+    source = 'timeout_seconds'
+    if timeout_seconds is None:
+        timeout_seconds = os.environ.get('CONVERSION_TIMEOUT', DEFAULT_CONVERSION_TIMEOUT)
+        source = 'CONVERSION_TIMEOUT'
+    try:
+        seconds = float(timeout_seconds)
+    except (TypeError, ValueError):
+        raise ValueError(f"{source} must be a number of seconds, got {timeout_seconds!r}")
+    if seconds < 0:
+        raise ValueError(f"{source} must not be negative, got {timeout_seconds!r}")
+    return seconds or None
+
+
+def check_cancelled(cancel_event: Optional[threading.Event]) -> None:
+    """Raise ConversionCancelled if the event is set (no-op without an event)"""
+    if cancel_event is not None and cancel_event.is_set():
+        raise ConversionCancelled("Conversion cancelled")
+
@@ -0,0 +1,210 @@
+"""
+Canonical, diff-friendly output
+
+Converted markdown kept in git should only change where the document
+changed. Canonical output normalizes everything that varies between runs
+or between PDF producers:
+
+1. Line endings are LF, text is Unicode NFC, non-breaking and other
+   exotic spaces become plain spaces, and trailing whitespace is removed,
+   except that a hard line break (two or more spaces before a line that
+   continues the paragraph) is kept as exactly two spaces.
+2. ATX headings have exactly one space after the hashes, no closing
+   hashes, and exactly one blank line before and after them.
+3. Runs of blank lines collapse to one; the file ends with one newline.
+4. Bullet markers ``*`` and ``+`` become ``-``.
+5. Running page markers ("Page 3", "Page 3 of 10", "3 of 10", "- 3 -"
+   alone on a line) and HTML comments are removed. A bare number on its
+   own line is kept: it is as likely to be list or table data.
+6. Code fences are copied verbatim (only line endings are normalized).
+7. Images are named by content hash (``img-<sha256 prefix>.<ext>``), so
+   inserting a page does not rename every later figure, and identical
+   images share one file.
+8. Generated-at timestamps are left out of manifest.json and keywords.json.
+
+Front-matter is left as written; its fields are already stable.
+
+canonicalize_conversion applies the markdown rules to an existing
+conversion in place, touching only the files its manifest lists.
+"""
+import hashlib
+import json
+import re
+import unicodedata
+from pathlib import Path
+from typing import Any, Dict, List
+
+from .frontmatter import split_front_matter
+
+OUTPUT_MODES = ('standard', 'canonical')
+
+# Spaces that render like a normal space but diff differently
+EXOTIC_SPACES = re.compile('[\u00a0\u2000-\u200a\u202f\u205f\u3000]')
+
+HEADING = re.compile(r'^(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$')
+BULLET = re.compile(r'^(\s*)[*+](\s+)')
+PAGE_MARKER_LINE = re.compile(r'^\s*(?:page\s+\d+(?:\s*(?:of|/)\s*\d+)?|\d+\s+of\s+\d+|[-\u2013\u2014]\s*\d+\s*[-\u2013\u2014])\s*$',
+                              re.IGNORECASE)
+HARD_BREAK = re.compile(r'\S {2,}$')
+# Lines that start a new block, so a hard break before them does nothing
+BLOCK_START = re.compile(r'^\s*(?:#{1,6}\s|[-*+]\s|\d+[.)]\s|>|\||```|~~~)')
+HTML_COMMENT = re.compile(r'<!--.*?-->', re.DOTALL)
+FENCE = re.compile(r'^\s*(```|~~~)')
+
+IMAGE_HASH_LENGTH = 16
+
+
+def canonicalize_markdown(text: str) -> str:
+    """
+    Apply the canonical normalization rules (see module docstring) to a markdown document
+
+    Args:
+        text: Markdown, optionally with front-matter
+
+    Returns:
+        Normalized markdown ending with exactly one newline
+    """
+    text = unicodedata.normalize('NFC', text.replace('\r\n', '\n').replace('\r', '\n'))
+    front_matter = ''
+    fields, body = split_front_matter(text)
+    if fields:
+        front_matter = text[:len(text) - len(body)].rstrip('\n') + '\n\n'
+        text = body
+
+    # Comments are removed before line processing so multi-line comments go too,
+    # but never inside code fences
+    parts = re.split(r'(^\s*(?:```|~~~).*?^\s*(?:```|~~~)[^\n]*$)', text, flags=re.MULTILINE | re.DOTALL)
+    text = ''.join(part if index % 2 else HTML_COMMENT.sub('', part) for index, part in enumerate(parts))
+
+    source = text.split('\n')
+    lines: List[str] = []
+    in_fence = False
+    for index, line in enumerate(source):
+        if FENCE.match(line):
+            in_fence = not in_fence
+            lines.append(line.rstrip())
+            continue
+        if in_fence:
+            lines.append(line)
+            continue
+
+        line = EXOTIC_SPACES.sub(' ', line)
+        following = source[index + 1] if index + 1 < len(source) else ''
+        hard_break = HARD_BREAK.search(line) and following.strip() and not BLOCK_START.match(following)
+        line = line.rstrip() + ('  ' if hard_break else '')
+        if PAGE_MARKER_LINE.match(line):
+            continue
+        heading = HEADING.match(line)
+        if heading and heading.group(2):
+            if lines and lines[-1] != '':
+                lines.append('')
+            lines.append(f"{heading.group(1)} {heading.group(2)}")
+            lines.append('')
+            continue
+        lines.append(BULLET.sub(r'\1-\2', line))
+
+    # Collapse blank runs outside fences
+    result: List[str] = []
+    in_fence = False
+    for line in lines:
+        if FENCE.match(line):
+            in_fence = not in_fence
+        if not in_fence and line == '' and (not result or result[-1] == ''):
+            continue
+        result.append(line)
+    while result and result[-1] == '':
+        result.pop()
+
+    return front_matter + '\n'.join(result) + '\n'
+
+
+def content_hash_name(path: Path) -> str:
+    """File name derived from the file's content: img-<sha256 prefix><suffix>"""
+    digest = hashlib.sha256(Path(path).read_bytes()).hexdigest()[:IMAGE_HASH_LENGTH]
+    return f"img-{digest}{Path(path).suffix.lower()}"
+
+
+def rename_images_by_content(images: List[Dict[str, Any]]) -> Dict[str, str]:
+    """
+    Rename extracted image files to their content-hash names
+
+    Entries are updated in place; identical images end up sharing one file.
+
+    Returns:
+        Mapping of old file path to new file path
+    """
+    renamed: Dict[str, str] = {}
+    for image in images:
+        old = image['file']
+        if old in renamed:
+            image['file'] = renamed[old]
+            continue
+        old_path = Path(old)
+        if not old_path.exists():
+            continue
+        new_path = old_path.with_name(content_hash_name(old_path))
+        if new_path != old_path:
+            if new_path.exists():
+                old_path.unlink()
+            else:
+                old_path.rename(new_path)
+        renamed[old] = str(new_path)
+        image['file'] = str(new_path)
+    return renamed
+
+
+def conversion_markdown_files(directory: Path) -> List[Path]:
+    """
+    Markdown files a conversion wrote, from the manifest.json in its output folder
+
+    README.md, the sections, and the chunks listed in the chunk manifest;
+    nothing else under the folder (notes kept next to a conversion are not
+    the converter's to rewrite).
+
+    Raises:
+        FileNotFoundError: If directory has no manifest.json
+        ValueError: If manifest.json can't be read
+    """
+    directory = Path(directory)
+    manifest_file = directory / 'manifest.json'
+    if not manifest_file.is_file():
+        raise FileNotFoundError(f"No manifest.json in {directory}: give the output folder of a conversion")
+    try:
+        manifest = json.loads(manifest_file.read_text(encoding='utf-8'))
+    except (OSError, ValueError) as e:
+        raise ValueError(f"Could not read {manifest_file}: {e}")
+
+    paths = [directory / 'README.md']
+    paths += [directory / entry['file'] for entry in manifest.get('sections', []) if entry.get('file')]
+    chunk_manifest = (manifest.get('chunking') or {}).get('manifest')
+    if chunk_manifest:
+        chunk_manifest = directory / chunk_manifest
+        try:
+            chunks = json.loads(chunk_manifest.read_text(encoding='utf-8')).get('chunks', [])
+        except (OSError, ValueError):
+            chunks = []
+        paths += [chunk_manifest.parent / entry['file'] for entry in chunks if entry.get('file')]
+
+    files: Dict[Path, Path] = {}
+    for path in paths:
+        if path.suffix.lower() == '.md' and path.is_file():
+            files.setdefault(path.resolve(), path)
+    return list(files.values())
+
+
+def canonicalize_conversion(directory: Path) -> Dict[str, int]:
+    """
+    Normalize the markdown files of a conversion in place (see conversion_markdown_files)
+
+    Returns:
+        Dictionary with files (markdown files seen) and changed (rewritten)
+    """
+    files = changed = 0
+    for path in conversion_markdown_files(directory):
+        files += 1
+        text = path.read_text(encoding='utf-8')
+        canonical = canonicalize_markdown(text)
+        if canonical != text:
+            path.write_text(canonical, encoding='utf-8')
+            changed += 1
+    return {'files': files, 'changed': changed}
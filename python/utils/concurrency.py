@@ -0,0 +1,92 @@
+"""
+Limit on conversions running at once
+
+Each conversion or analysis runs in a worker thread and can hold a whole
+document's text, tables, and images in memory. An agent firing many
+tools/call requests in parallel over one connection would start them all
+at once. WorkerLimit admits at most MAX_CONCURRENCY workers (default: 1);
+further calls wait in arrival order for a free slot. A call cancelled
+while waiting leaves the queue without taking a slot.
+
+The default is one because workers are threads of the server process:
+PyMuPDF is not thread-safe, and conversions share the process's memory.
+Raising MAX_CONCURRENCY runs that many conversions side by side in threads;
+do so only for workloads known to tolerate it.
+
+convert_pdf_batch runs each file in a converter process of its own, which
+is safe to run in parallel but costs a whole interpreter's memory per file.
+Those processes take slots of a second WorkerLimit, MAX_PROCESSES (default:
+4), shared by every batch: parallel batch calls, each with its own
+concurrency, never run more converter processes than that between them.
+
+A slot is freed when the worker thread finishes, not when the tool call
+returns: a timed-out call returns while its worker is still stopping, and
+that worker keeps its slot until it is done.
+"""
+import asyncio
+import os
+from typing import Optional
+
+CONCURRENCY_ENV = 'MAX_CONCURRENCY'
+PROCESSES_ENV = 'MAX_PROCESSES'
+
+# Workers at once when MAX_CONCURRENCY is unset or invalid
+DEFAULT_CONCURRENCY = 1
+
+# Converter processes at once when MAX_PROCESSES is unset or invalid
+DEFAULT_PROCESSES = 4
+
+
+def _env_limit(name: str, default: int) -> int:
+    """An environment variable as a positive integer (unset, invalid, or below 1: default)"""
+    try:
+        value = int(os.environ.get(name, '') or default)
+    except ValueError:
+        return default
+    return value if value >= 1 else default
+
+
+def max_concurrency() -> int:
+    """MAX_CONCURRENCY as a positive integer (unset, invalid, or below 1: DEFAULT_CONCURRENCY)"""
+    return _env_limit(CONCURRENCY_ENV, DEFAULT_CONCURRENCY)
+
+
+def max_processes() -> int:
+    """MAX_PROCESSES as a positive integer (unset, invalid, or below 1: DEFAULT_PROCESSES)"""
+    return _env_limit(PROCESSES_ENV, DEFAULT_PROCESSES)
+
+
+class WorkerLimit:
+    """Counting semaphore for workers (threads or processes), created on first use in the running event loop"""
+
+    def __init__(self, limit: Optional[int] = None):
+        """
+        Args:
+            limit: Workers allowed at once (default: max_concurrency() when first used)
+        """
+        self._limit = limit
+        self._semaphore: Optional[asyncio.Semaphore] = None
+        self.active = 0
+        self.waiting = 0
+
+    @property
+    def limit(self) -> int:
+        if self._limit is None:
+            self._limit = max_concurrency()
+        return self._limit
+
+    async def acquire(self) -> None:
+        """Wait for a free slot (raises CancelledError, holding no slot, if cancelled while waiting)"""
+        if self._semaphore is None:
+            self._semaphore = asyncio.Semaphore(self.limit)
+        self.waiting += 1
+        try:
+            await self._semaphore.acquire()
+        finally:
+            self.waiting -= 1
+        self.active += 1
+
+    def release(self) -> None:
+        """Free a slot (call from the event loop thread, e.g. a future's done callback)"""
+        self.active -= 1
+        self._semaphore.release()
@@ -0,0 +1,54 @@
+"""
+Skipping conversions whose output is already up to date
+
+Every conversion records a cache key in manifest.json: the SHA-256 of the
+PDF's bytes and a SHA-256 of the options that shape the output. A later
+convert_pdf with use_cache finds the existing manifest for the same output
+location and, when both hashes match, returns the files already on disk
+instead of converting again. A changed PDF or any changed option (page
+range, output format, chunking, ...) misses the cache.
+"""
+import hashlib
+import json
+from pathlib import Path
+from typing import Any, Dict, Optional
+
+from .fingerprint import hash_file
+from .output_conflict import read_existing_manifest
+from .output_layout import OutputLayout
+
+CACHE_KEY_VERSION = 1
+
+# Options that do not change what is written (the password opens the PDF, it shapes nothing)
+UNCACHED_OPTIONS = ('password', 'use_cache', 'dry_run', 'timeout_seconds', 'on_conflict', 'corpus_index_path',
+                    'clean_output', 'max_output_bytes', 'max_images_bytes')
+
+
+def options_hash(options: Dict[str, Any]) -> str:
+    """SHA-256 of the output-shaping options, independent of key order"""
+    shaping = {key: value for key, value in (options or {}).items() if key not in UNCACHED_OPTIONS}
+    encoded = json.dumps(shaping, sort_keys=True, ensure_ascii=False, default=str)
+    return hashlib.sha256(encoded.encode('utf-8')).hexdigest()
+
+
+def conversion_cache_key(pdf_path: str, options: Dict[str, Any]) -> Dict[str, Any]:
+    """Cache key recorded in manifest.json: version, pdf_sha256, options_sha256"""
+    return {
+        'version': CACHE_KEY_VERSION,
+        'pdf_sha256': hash_file(Path(pdf_path)),
+        'options_sha256': options_hash(options)
+    }
+
+
+def find_cached_conversion(layout: OutputLayout, cache_key: Dict[str, Any]) -> Optional[Dict[str, Any]]:
+    """
+    The existing manifest when it was written for this PDF and these options
+
+    Returns:
+        The manifest.json contents, or None when there is no manifest, it
+        predates cache keys, or the PDF or options differ
+    """
+    manifest = read_existing_manifest(layout)
+    if not manifest or manifest.get('cache_key') != cache_key:
+        return None
+    return manifest
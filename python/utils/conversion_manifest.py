@@ -0,0 +1,111 @@
+"""
+Typed list of the files a conversion produced
+
+Agents driving convert_pdf need the files it wrote, not a sentence to
+regex. After conversion the output directories are walked and every file
+is listed with its kind, size, and a token estimate; the server returns
+this as JSON next to the human-readable summary. The converter walks its
+staging directory (see utils.staging) before moving the files into place,
+so only the files the run wrote are listed, not earlier conversions' or
+other documents' sharing a folder.
+"""
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional
+
+from .output_layout import OutputLayout
+from .token_counter import TokenCounter
+
+FILE_KINDS = ('index', 'section', 'chunk', 'image', 'table', 'api_endpoint', 'other')
+
+# Document-level navigation and metadata files
+INDEX_FILES = ('README.md', 'README.adoc', 'README.rst', 'manifest.json', 'keywords.json')
+
+IMAGE_SUFFIXES = ('.png', '.jpg', '.jpeg', '.gif', '.bmp', '.tif', '.tiff', '.webp', '.svg')
+
+# Files whose tokens are worth estimating (text an agent might load into context)
+TEXT_SUFFIXES = ('.md', '.adoc', '.rst', '.txt', '.json', '.csv')
+
+
+@dataclass
+class ManifestFile:
+    """One generated file"""
+    path: str
+    kind: str
+    bytes: int
+    tokens: Optional[int] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {'path': self.path, 'kind': self.kind, 'bytes': self.bytes, 'tokens': self.tokens}
+
+
+@dataclass
+class ConversionManifest:
+    """Every file a conversion produced, with paths relative to output_directory"""
+    output_directory: str
+    files: List[ManifestFile] = field(default_factory=list)
+
+    def by_kind(self, kind: str) -> List[ManifestFile]:
+        return [entry for entry in self.files if entry.kind == kind]
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            'output_directory': self.output_directory,
+            'file_count': len(self.files),
+            'total_bytes': sum(entry.bytes for entry in self.files),
+            'total_tokens': sum(entry.tokens or 0 for entry in self.files),
+            'kinds': {kind: len(self.by_kind(kind)) for kind in FILE_KINDS if self.by_kind(kind)},
+            'files': [entry.to_dict() for entry in self.files]
+        }
+
+
+def classify_file(path: Path, layout: OutputLayout) -> str:
+    """Kind of a generated file, from its name and the layout directory holding it"""
+    if path.name in INDEX_FILES:
+        return 'index'
+    if path.suffix.lower() in IMAGE_SUFFIXES:
+        return 'image'
+    # Checked by directory, so layouts that put everything in one folder still classify sections
+    for artifact_type, kind in (('sections', 'section'), ('chunked', 'chunk'), ('tables', 'table'),
+                                ('api-endpoints', 'api_endpoint')):
+        if path.parent == layout.directory_for(artifact_type):
+            return kind
+    # Chunk layouts by_section and by_size nest chunk files one folder down
+    chunked = layout.directory_for('chunked')
+    if chunked != layout.document_root() and path.parent.parent == chunked:
+        return 'chunk'
+    return 'other'
+
+
+def build_conversion_manifest(layout: OutputLayout, extra_files: Iterable[str] = (),
+                              token_counter: Optional[TokenCounter] = None) -> ConversionManifest:
+    """
+    Walk the document root and artifact directories and list every file
+
+    Args:
+        layout: Output layout of the conversion
+        extra_files: Generated files that may live outside those directories
+        token_counter: Counter for token estimates (default: a new TokenCounter)
+    """
+    counter = token_counter or TokenCounter()
+    directories = [layout.document_root()] + [layout.directory_for(artifact) for artifact in layout.ARTIFACT_TYPES]
+
+    found: Dict[Path, Path] = {}
+    for directory in directories:
+        if directory.is_dir():
+            for path in directory.rglob('*'):
+                if path.is_file():
+                    found.setdefault(path.resolve(), path)
+    for name in extra_files:
+        path = Path(name)
+        if path.is_file():
+            found.setdefault(path.resolve(), path)
+
+    manifest = ConversionManifest(output_directory=str(layout.document_root()))
+    for path in sorted(found.values(), key=lambda p: layout.relative_path(p)):
+        tokens = None
+        if path.suffix.lower() in TEXT_SUFFIXES:
+            tokens = counter.count_tokens(path.read_text(encoding='utf-8', errors='replace'))
+        manifest.files.append(ManifestFile(path=layout.relative_path(path), kind=classify_file(path, layout),
+                                           bytes=path.stat().st_size, tokens=tokens))
+    return manifest
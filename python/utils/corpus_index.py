@@ -0,0 +1,146 @@
+"""
+Shared corpus index across conversions
+
+Batch jobs convert many documents into sibling directories. Each conversion
+records a summary entry in one top-level corpus-index.json so the whole
+corpus can be queried from a single file. Writers serialize on an advisory
+lock file and replace the index atomically, so concurrent conversions never
+lose each other's entries and readers never see a half-written file.
+
+Entries are keyed by the source file's resolved path (corpus_document_id),
+not its name: two different report.pdf files from different folders each
+keep an entry, while re-converting the same file replaces its own.
+"""
+import hashlib
+import json
+import os
+import tempfile
+import time
+from contextlib import contextmanager
+from datetime import datetime
+from pathlib import Path
+from typing import Any, Dict
+
+INDEX_VERSION = 1
+LOCK_TIMEOUT_SECONDS = 60
+
+
+@contextmanager
+def file_lock(lock_path: Path, timeout: float = LOCK_TIMEOUT_SECONDS):
+    """
+    Hold an exclusive advisory lock on lock_path
+
+    Raises:
+        TimeoutError: If the lock can't be acquired within timeout seconds
+    """
+    lock_path.parent.mkdir(parents=True, exist_ok=True)
+    handle = open(lock_path, 'a+')
+    deadline = time.monotonic() + timeout
+    try:
+        while True:
+            try:
+                _lock(handle)
+                break
+            except OSError:
+                if time.monotonic() >= deadline:
+                    raise TimeoutError(f"Timed out waiting for lock: {lock_path}")
+                time.sleep(0.05)
+        try:
+            yield
+        finally:
+            _unlock(handle)
+    finally:
+        handle.close()
+
+
+if os.name == 'nt':
+    import msvcrt
+
+    def _lock(handle):
+        handle.seek(0)
+        msvcrt.locking(handle.fileno(), msvcrt.LK_NBLCK, 1)
+
+    def _unlock(handle):
+        handle.seek(0)
+        msvcrt.locking(handle.fileno(), msvcrt.LK_UNLCK, 1)
+else:
+    import fcntl
+
+    def _lock(handle):
+        fcntl.flock(handle.fileno(), fcntl.LOCK_EX | fcntl.LOCK_NB)
+
+    def _unlock(handle):
+        fcntl.flock(handle.fileno(), fcntl.LOCK_UN)
+
+
+def corpus_document_id(source_path: str) -> str:
+    """Index key of a source file: a hash of its resolved path"""
+    resolved = str(Path(source_path).resolve())
+    return hashlib.sha256(resolved.encode('utf-8')).hexdigest()[:16]
+
+
+def _read_index(index_path: Path) -> Dict[str, Any]:
+    if not index_path.exists():
+        return {'version': INDEX_VERSION, 'documents': []}
+    with open(index_path, 'r', encoding='utf-8') as f:
+        index = json.load(f)
+    index.setdefault('documents', [])
+    return index
+
+
+def _write_atomic(data: Dict[str, Any], path: Path) -> None:
+    """Write JSON to a temp file beside path, then rename it into place"""
+    fd, temp_name = tempfile.mkstemp(prefix=f".{path.name}.", suffix=".tmp", dir=str(path.parent))
+    try:
+        with os.fdopen(fd, 'w', encoding='utf-8') as f:
+            json.dump(data, f, indent=2, ensure_ascii=False, default=str)
+            f.flush()
+            os.fsync(f.fileno())
+        os.replace(temp_name, path)
+    except BaseException:
+        try:
+            os.unlink(temp_name)
+        except OSError:
+            pass
+        raise
+
+
+def update_corpus_index(index_path: str, entry: Dict[str, Any]) -> Path:
+    """
+    Add or replace a document's entry in the corpus index
+
+    Entries are keyed by document_id (corpus_document_id for conversions),
+    so re-converting a document updates its entry instead of duplicating it. Relative paths in the entry are
+    resolved against the index's directory by readers.
+
+    Args:
+        index_path: Path to corpus-index.json (created if missing)
+        entry: Summary entry; must contain document_id
+
+    Returns:
+        Path to the index file
+    """
+    if not entry.get('document_id'):
+        raise ValueError("corpus index entry requires a document_id")
+
+    path = Path(index_path)
+    path.parent.mkdir(parents=True, exist_ok=True)
+
+    with file_lock(path.with_name(path.name + '.lock')):
+        index = _read_index(path)
+        documents = [d for d in index['documents'] if d.get('document_id') != entry['document_id']]
+        documents.append(entry)
+        index['documents'] = sorted(documents, key=lambda d: str(d.get('source_file', '')))
+        index['document_count'] = len(index['documents'])
+        index['updated_at'] = datetime.now().isoformat()
+        _write_atomic(index, path)
+
+    return path
+
+
+def relative_to_index(index_path: str, target: Path) -> str:
+    """Path of target relative to the index directory (absolute if on another drive)"""
+    try:
+        return Path(os.path.relpath(Path(target).resolve(), Path(index_path).resolve().parent)).as_posix()
+    except ValueError:
+        return str(Path(target).resolve())
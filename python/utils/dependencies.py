@@ -0,0 +1,123 @@
+"""
+Core dependency report with exact versions
+
+The server needs a handful of libraries to do anything useful. Rather than
+failing on the first import, each one is probed for its installed version
+and checked against a minimum; the report is logged at startup and
+attached to the server capabilities during initialize so clients (and CI
+logs) show exactly what is missing or too old and how to fix it.
+"""
+import re
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional, Tuple
+
+from .features import probe_library
+
+# (import name, distribution name, minimum version) - keep in step with requirements.txt
+CORE_PACKAGES: List[Tuple[str, str, str]] = [
+    ('pypdf', 'pypdf', '3.0.0'),
+    ('pdfplumber', 'pdfplumber', '0.10.0'),
+    ('fitz', 'PyMuPDF', '1.23.0'),
+    ('pandas', 'pandas', '2.0.0'),
+    ('PIL', 'pillow', '10.0.0'),
+]
+
+# Only checked when a conversion asks for it (math_mode='latex')
+MATH_PACKAGES: List[Tuple[str, str, str]] = [
+    ('pix2tex', 'pix2tex', '0.1.2'),
+]
+
+PACKAGE_STATUSES = ('ok', 'missing', 'outdated', 'unknown_version')
+
+
+def parse_version(version: str) -> Tuple[int, ...]:
+    """Numeric release components of a version string ('1.24.10rc1' -> (1, 24, 10))"""
+    parts = []
+    for part in version.split('.'):
+        match = re.match(r'\d+', part)
+        if not match:
+            break
+        parts.append(int(match.group(0)))
+    return tuple(parts)
+
+
+def version_at_least(version: str, minimum: str) -> bool:
+    """Compare release numbers, padding the shorter version with zeros"""
+    have, need = parse_version(version), parse_version(minimum)
+    length = max(len(have), len(need))
+    return have + (0,) * (length - len(have)) >= need + (0,) * (length - len(need))
+
+
+@dataclass
+class PackageStatus:
+    """One package's installed version against its minimum"""
+    module: str
+    distribution: str
+    minimum: str
+    version: Optional[str] = None
+    status: str = 'missing'
+    message: Optional[str] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            'module': self.module,
+            'distribution': self.distribution,
+            'version': self.version,
+            'minimum': self.minimum,
+            'status': self.status,
+            'message': self.message
+        }
+
+
+@dataclass
+class DependencyReport:
+    """Status of every core package"""
+    packages: Dict[str, PackageStatus] = field(default_factory=dict)
+
+    @property
+    def ok(self) -> bool:
+        """True when every package is installed at or above its minimum"""
+        return all(package.status in ('ok', 'unknown_version') for package in self.packages.values())
+
+    @property
+    def problems(self) -> List[str]:
+        """Actionable messages for missing and outdated packages"""
+        return [package.message for package in self.packages.values()
+                if package.status in ('missing', 'outdated') and package.message]
+
+    def versions(self) -> Dict[str, Optional[str]]:
+        """{package: installed version or None}"""
+        return {name: package.version for name, package in self.packages.items()}
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            'ok': self.ok,
+            'packages': {name: package.to_dict() for name, package in self.packages.items()},
+            'problems': self.problems
+        }
+
+
+def check_package(module: str, distribution: str, minimum: str) -> PackageStatus:
+    """Probe one package and compare its version with the minimum"""
+    probe = probe_library(module, distribution)
+    package = PackageStatus(module=module, distribution=distribution, minimum=minimum, version=probe['version'])
+    if not probe['installed']:
+        package.message = f"{distribution} is not installed: pip install '{distribution}>={minimum}'"
+    elif probe['version'] is None:
+        # Importable but without package metadata (e.g. vendored); can't compare
+        package.status = 'unknown_version'
+    elif not version_at_least(probe['version'], minimum):
+        package.status = 'outdated'
+        package.message = (f"{distribution} {probe['version']} is older than the required {minimum}: "
+                           f"pip install --upgrade '{distribution}>={minimum}'")
+    else:
+        package.status = 'ok'
+    return package
+
+
+def check_dependencies(packages: Optional[List[Tuple[str, str, str]]] = None) -> DependencyReport:
+    """Report on every core package (keyed by distribution name)"""
+    report = DependencyReport()
+    for module, distribution, minimum in packages or CORE_PACKAGES:
+        report.packages[distribution] = check_package(module, distribution, minimum)
+    return report
@@ -0,0 +1,142 @@
+"""
+Converted documents as MCP resources
+
+Tools write markdown to disk, but a client that only speaks MCP cannot see
+it. resources/list enumerates the generated files under the configured output
+directory (DOCS_OUTPUT_DIR, default ./docs), plus the files conversions
+elsewhere wrote since the server started, as file:// resources, and
+resources/read returns a file's text, so an agent can open one section
+without another tool call. Only those files with a generated-text suffix
+can be read.
+
+For an output_dir a tool call named, only the files the conversion wrote
+are served, never the directory itself: output_dir=~ would otherwise put
+every .md and .json in the home directory up for reading. Conversions
+still in progress (their .staging-* folders) are not listed.
+"""
+import os
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+from urllib.parse import unquote, urlparse
+
+from .staging import STAGING_PREFIX
+
+DEFAULT_OUTPUT_DIR = './docs'
+
+# Most resources listed; a huge output tree would swamp the client
+MAX_RESOURCES = 1000
+
+# Generated text files and their MIME types
+RESOURCE_TYPES = {
+    '.md': 'text/markdown',
+    '.adoc': 'text/asciidoc',
+    '.rst': 'text/x-rst',
+    '.json': 'application/json',
+    '.csv': 'text/csv',
+}
+
+# Files conversions wrote, mapped to the output_dir their names are relative to
+_output_files: Dict[Path, Path] = {}
+
+
+def remember_output(output_dir: str, result: Dict[str, Any]) -> None:
+    """
+    Serve the files a successful conversion wrote from now on
+
+    Taken from the result's conversion manifest, else its generated_files.
+    """
+    manifest = result.get('conversion_manifest')
+    if manifest:
+        paths = [Path(manifest['output_directory']) / entry['path'] for entry in manifest.get('files', [])]
+    else:
+        paths = [Path(path) for path in result.get('generated_files') or []]
+    base = Path(output_dir).resolve()
+    for path in paths:
+        if path.suffix.lower() in RESOURCE_TYPES:
+            _output_files[path.resolve()] = base
+
+
+def remembered_files() -> Dict[Path, Path]:
+    """Files registered by remember_output, each with the output_dir it was written to"""
+    return dict(_output_files)
+
+
+def inside(path: Path, root: Path) -> bool:
+    """Whether path is root or lies under it (both resolved)"""
+    return path == root or root in path.parents
+
+
+def staging(path: Path, root: Path) -> bool:
+    """Whether path lies in a conversion's staging folder under root"""
+    return any(part.startswith(STAGING_PREFIX) for part in path.relative_to(root).parts)
+
+
+def resource_roots() -> List[Path]:
+    """The configured output directory, if it exists, whose files are all resources"""
+    configured = Path(os.environ.get('DOCS_OUTPUT_DIR') or DEFAULT_OUTPUT_DIR).resolve()
+    return [configured] if configured.is_dir() else []
+
+
+def list_resources(roots: Iterable[Path], files: Optional[Dict[Path, Path]] = None) -> List[Dict[str, Any]]:
+    """
+    Generated files under roots, then the remembered files outside them, as resource entries
+
+    Args:
+        roots: Directories whose generated files are all listed
+        files: Further files (resolved), each with the output_dir naming it (see remembered_files)
+
+    Returns:
+        Dictionaries with uri, name (path relative to its root or output_dir),
+        mime_type, and size, sorted by name; at most MAX_RESOURCES
+    """
+    roots = list(roots)
+    candidates = []
+    for root in roots:
+        for path in sorted(root.rglob('*')):
+            # Symlinks out of the directory would not be readable, so skip them
+            if path.suffix.lower() not in RESOURCE_TYPES or not path.is_file() or not inside(path.resolve(), root) \
+                    or staging(path, root):
+                continue
+            candidates.append((path, str(path.relative_to(root))))
+    extra = []
+    for path, output_dir in (files or {}).items():
+        if path.is_file() and not any(inside(path, root) for root in roots):
+            extra.append((path, str(path.relative_to(output_dir)) if inside(path, output_dir) else path.name))
+    candidates += sorted(extra, key=lambda candidate: candidate[1])
+
+    entries = []
+    for path, name in candidates[:MAX_RESOURCES]:
+        entries.append({
+            'uri': path.resolve().as_uri(),
+            'name': name,
+            'mime_type': RESOURCE_TYPES[path.suffix.lower()],
+            'size': path.stat().st_size
+        })
+    return entries
+
+
+def read_resource(uri: str, roots: Iterable[Path], files: Iterable[Path] = ()) -> Tuple[str, str]:
+    """
+    Text and MIME type of a file:// resource
+
+    Args:
+        uri: file:// URI of the file
+        roots: Directories whose generated files may all be read
+        files: Further readable files (resolved), e.g. remembered_files()
+
+    Raises:
+        ValueError: Not a file:// URI, not a listed file, or not a generated text file
+        FileNotFoundError: The file does not exist
+    """
+    parsed = urlparse(uri)
+    if parsed.scheme != 'file':
+        raise ValueError(f"Unsupported resource URI: {uri} (expected file://)")
+    path = Path(unquote(parsed.path)).resolve()
+    if path not in set(files) and not any(inside(path, root) and not staging(path, root) for root in roots):
+        raise ValueError(f"Resource is outside the output directories: {uri}")
+    mime_type = RESOURCE_TYPES.get(path.suffix.lower())
+    if not mime_type:
+        raise ValueError(f"Not a generated text file: {uri}")
+    if not path.is_file():
+        raise FileNotFoundError(f"Resource not found: {uri}")
+    return path.read_text(encoding='utf-8'), mime_type
@@ -0,0 +1,59 @@
+"""
+Images embedded in markdown as data URIs
+
+Word documents converted with markitdown carry their pictures inline as
+``![alt](data:image/png;base64,...)``. Written out as-is they bloat every
+section file, so each one is saved to the images directory and the link
+is pointed at the file, matching what PDF conversions produce.
+"""
+import base64
+import binascii
+import hashlib
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Tuple
+
+DATA_URI_IMAGE = re.compile(r'!\[([^\]]*)\]\(data:image/([A-Za-z0-9.+-]+);base64,([A-Za-z0-9+/=\s]+)\)')
+
+# MIME subtypes whose usual extension differs from the subtype
+EXTENSIONS = {'jpeg': 'jpg', 'svg+xml': 'svg', 'x-emf': 'emf', 'x-wmf': 'wmf'}
+
+
+def save_data_uri_images(markdown: str, images_dir: Path, link_prefix: str,
+                         name_prefix: str = 'img') -> Tuple[str, List[Dict[str, Any]]]:
+    """
+    Write data-URI images to files and link to them instead
+
+    Identical images (same bytes) share one file. Data URIs that don't
+    decode are left in place.
+
+    Args:
+        markdown: Markdown possibly containing data-URI images
+        images_dir: Directory to write image files to (created when needed)
+        link_prefix: Relative path from the markdown file to images_dir
+        name_prefix: File name prefix; files are <prefix><n>.<ext>
+
+    Returns:
+        (markdown with file links, images) - each image has file (path
+        written), alt_text, and mime_type
+    """
+    images: List[Dict[str, Any]] = []
+    by_digest: Dict[str, str] = {}
+
+    def replace(match: 're.Match') -> str:
+        alt_text, subtype, payload = match.group(1), match.group(2).lower(), match.group(3)
+        try:
+            data = base64.b64decode(re.sub(r'\s+', '', payload), validate=True)
+        except (binascii.Error, ValueError):
+            return match.group(0)
+
+        digest = hashlib.sha256(data).hexdigest()
+        if digest not in by_digest:
+            images_dir.mkdir(parents=True, exist_ok=True)
+            image_file = images_dir / f"{name_prefix}{len(by_digest) + 1}.{EXTENSIONS.get(subtype, subtype)}"
+            image_file.write_bytes(data)
+            by_digest[digest] = image_file.name
+            images.append({'file': str(image_file), 'alt_text': alt_text, 'mime_type': f"image/{subtype}"})
+        return f"![{alt_text}]({link_prefix.rstrip('/')}/{by_digest[digest]})"
+
+    return DATA_URI_IMAGE.sub(replace, markdown), images
@@ -0,0 +1,153 @@
+"""
+Runtime environment check: the Python interpreter and core packages
+
+convert_pdf_batch runs each conversion in a separate Python process. When
+the server's own interpreter can't be located (sys.executable is empty
+under some embedding launchers, or points at a file that was removed by an
+upgrade), that would fail only on the first batch with an opaque "file not
+found". The interpreter is instead resolved when the server starts -
+sys.executable, else python3 or python on PATH - and reported together with
+the core package check, so a client sees at initialize (and any time with
+check_environment) whether the server is usable and what to fix. A fallback
+interpreter is its own installation: it is only reported usable once it
+has been run and shown to import the core packages.
+"""
+import json
+import os
+import platform
+import shutil
+import subprocess
+import sys
+from typing import Any, Dict, List, Optional, Sequence
+
+from .dependencies import CORE_PACKAGES, check_dependencies
+
+# Interpreters tried on PATH when sys.executable is unusable, in order
+FALLBACK_INTERPRETERS = ('python3', 'python')
+
+# Seconds a fallback interpreter gets to import the core packages
+PROBE_TIMEOUT = 60
+
+# Run by the probed interpreter: prints the modules (argv) it fails to import as JSON
+PROBE_SCRIPT = (
+    "import json, sys\n"
+    "missing = []\n"
+    "for name in sys.argv[1:]:\n"
+    "    try:\n"
+    "        __import__(name)\n"
+    "    except Exception:\n"
+    "        missing.append(name)\n"
+    "print(json.dumps(missing))\n"
+)
+
+
+class InterpreterNotFound(RuntimeError):
+    """No Python interpreter to run converter processes with"""
+
+    def __init__(self):
+        super().__init__("No Python interpreter found to run converter processes: sys.executable is not usable "
+                         f"and none of {', '.join(FALLBACK_INTERPRETERS)} is on PATH. Start the server with "
+                         "the full path of the Python interpreter it was installed for (see check_environment)")
+
+
+def resolve_interpreter() -> Optional[str]:
+    """Interpreter for converter processes: sys.executable when it exists, else the first on PATH (None: none)"""
+    if sys.executable and os.path.isfile(sys.executable) and os.access(sys.executable, os.X_OK):
+        return sys.executable
+    for name in FALLBACK_INTERPRETERS:
+        path = shutil.which(name)
+        if path:
+            return path
+    return None
+
+
+def converter_interpreter() -> str:
+    """
+    Interpreter for converter processes
+
+    Raises:
+        InterpreterNotFound: There is none
+    """
+    interpreter = resolve_interpreter()
+    if interpreter is None:
+        raise InterpreterNotFound()
+    return interpreter
+
+
+def probe_interpreter(interpreter: str, modules: Optional[Sequence[str]] = None) -> List[str]:
+    """
+    Modules an interpreter fails to import, by running it
+
+    Args:
+        interpreter: Path of the Python interpreter
+        modules: Import names (default: the core packages)
+
+    Returns:
+        The modules it can't import; all of them when it doesn't run
+    """
+    modules = list(modules) if modules is not None else [module for module, _, _ in CORE_PACKAGES]
+    try:
+        result = subprocess.run([interpreter, '-c', PROBE_SCRIPT, *modules], capture_output=True, text=True,
+                                timeout=PROBE_TIMEOUT)
+        if result.returncode != 0:
+            return modules
+        return [module for module in json.loads(result.stdout.strip().splitlines()[-1]) if module in modules]
+    except (OSError, subprocess.SubprocessError, ValueError, IndexError):
+        return modules
+
+
+def check_environment() -> Dict[str, Any]:
+    """
+    Interpreter and core package report
+
+    Returns:
+        Dictionary with usable, python (the server's executable, version,
+        and implementation), converter_interpreter (None: not found),
+        converter_missing (core modules a fallback interpreter can't
+        import), platform, missing and outdated package names, packages
+        (utils.dependencies report), and problems
+    """
+    interpreter = resolve_interpreter()
+    dependencies = check_dependencies()
+    packages = dependencies.to_dict()['packages']
+    problems = list(dependencies.problems)
+    converter_missing: List[str] = []
+    if interpreter is None:
+        problems.insert(0, str(InterpreterNotFound()))
+    elif interpreter != sys.executable:
+        converter_missing = probe_interpreter(interpreter)
+        if converter_missing:
+            problems.append(f"sys.executable is {sys.executable or 'not set'}; converter processes use "
+                            f"{interpreter}, which can't import {', '.join(converter_missing)}. Install "
+                            "requirements.txt for that interpreter, or start the server with the full path of "
+                            "the one it was installed for")
+    return {
+        'usable': interpreter is not None and not converter_missing and dependencies.ok,
+        'python': {
+            'executable': sys.executable or None,
+            'version': platform.python_version(),
+            'implementation': platform.python_implementation()
+        },
+        'converter_interpreter': interpreter,
+        'converter_missing': converter_missing,
+        'platform': platform.platform(),
+        'missing': [name for name, package in packages.items() if package['status'] == 'missing'],
+        'outdated': [name for name, package in packages.items() if package['status'] == 'outdated'],
+        'packages': packages,
+        'problems': problems
+    }
+
+
+def format_environment(report: Dict[str, Any]) -> str:
+    """Human-readable environment report"""
+    python = report['python']
+    lines = [f"Usable: {'yes' if report['usable'] else 'no'}",
+             f"Python: {python['version']} ({python['implementation']}) at {python['executable'] or 'unknown path'}",
+             f"Converter processes: {report['converter_interpreter'] or 'no interpreter found'}"]
+    for name, package in report['packages'].items():
+        lines.append(f"  {name}: {package['version'] or 'missing'} (needs ≥{package['minimum']}, {package['status']})")
+    if report['problems']:
+        lines.append("")
+        lines.append("To fix:")
+        lines.extend(f"• {problem}" for problem in report['problems'])
+    return "\n".join(lines)
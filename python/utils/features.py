@@ -0,0 +1,221 @@
+"""
+Feature availability diagnostics
+
+Optional features fail quietly when a prerequisite is missing - a missing
+library skips a step, an unset endpoint disables an enrichment. This module
+probes each feature's prerequisites (Python packages, executables, and
+configured HTTP endpoints) and reports what is usable right now, with a
+remediation hint for anything that isn't.
+"""
+import importlib.util
+import os
+import shutil
+import sys
+import urllib.error
+import urllib.request
+from datetime import datetime
+from importlib.metadata import version as dist_version, PackageNotFoundError
+from typing import Any, Dict, List, Optional
+
+DEFAULT_PROBE_TIMEOUT = 3.0
+
+# Each feature lists (import name, distribution name) pairs, and may list
+# executables on PATH and an env var naming an HTTP endpoint. "fallback"
+# describes what happens when prerequisites are missing if the feature
+# degrades instead of turning off. Only features the server actually uses
+# are listed.
+FEATURES: List[Dict[str, Any]] = [
+    {
+        'name': 'pdf_conversion',
+        'description': 'PDF text extraction and markdown conversion',
+        'libraries': [('fitz', 'PyMuPDF')],
+        'remediation': 'pip install PyMuPDF',
+    },
+    {
+        'name': 'pdf_analysis',
+        'description': 'Outline, metadata, and table/image counts (analyze_pdf_structure, extract_outline)',
+        'libraries': [('pypdf', 'pypdf'), ('pdfplumber', 'pdfplumber')],
+        'remediation': 'pip install pypdf pdfplumber',
+    },
+    {
+        'name': 'table_extraction',
+        'description': 'Table extraction and typed schemas (extract_tables_schema)',
+        'libraries': [('pdfplumber', 'pdfplumber'), ('pandas', 'pandas')],
+        'remediation': 'pip install pdfplumber pandas',
+    },
+    {
+        'name': 'docx_conversion',
+        'description': 'Word document conversion (convert_docx)',
+        # markitdown reads .docx through mammoth, which only its [docx]/[all] extras install
+        'libraries': [('markitdown', 'markitdown'), ('mammoth', 'mammoth')],
+        'remediation': "pip install 'markitdown[all]'",
+    },
+    {
+        'name': 'token_counting',
+        'description': 'Exact token counts for section sizing',
+        'libraries': [('tiktoken', 'tiktoken')],
+        'fallback': 'Token counts are approximated as characters / 4',
+        'remediation': 'pip install tiktoken',
+    },
+    {
+        'name': 'thumbnails',
+        'description': 'Page thumbnails (get_thumbnail)',
+        'libraries': [('fitz', 'PyMuPDF')],
+        'remediation': 'pip install PyMuPDF',
+    },
+    {
+        'name': 'image_content',
+        'description': 'Images returned as image content (extract_images)',
+        'libraries': [('fitz', 'PyMuPDF'), ('PIL', 'pillow')],
+        'remediation': 'pip install PyMuPDF pillow',
+    },
+    {
+        'name': 'signatures',
+        'description': 'Digital signature metadata (extract_signatures)',
+        'libraries': [('pypdf', 'pypdf')],
+        'remediation': 'pip install pypdf',
+    },
+    {
+        'name': 'form_fields',
+        'description': 'Interactive form field names and values (extract_form_fields)',
+        'libraries': [('pypdf', 'pypdf')],
+        'remediation': 'pip install pypdf',
+    },
+    {
+        'name': 'equation_latex',
+        'description': "Equations converted to LaTeX (math_mode='latex')",
+        'libraries': [('pix2tex', 'pix2tex')],
+        'fallback': 'Equations are cropped as images behind $$ placeholders',
+        'remediation': "pip install 'pix2tex>=0.1.2'",
+    },
+]
+
+
+def feature_by_name(name: str) -> Dict[str, Any]:
+    """The FEATURES entry with this name"""
+    for feature in FEATURES:
+        if feature['name'] == name:
+            return feature
+    raise KeyError(f"Unknown feature: {name}")
+
+
+def probe_library(module: str, distribution: str) -> Dict[str, Any]:
+    """Check a package is importable without importing it, and report its version"""
+    installed = importlib.util.find_spec(module) is not None
+    version = None
+    if installed:
+        try:
+            version = dist_version(distribution)
+        except PackageNotFoundError:
+            version = None
+    return {'module': module, 'distribution': distribution, 'installed': installed, 'version': version}
+
+
+def probe_binary(name: str) -> Dict[str, Any]:
+    """Check an executable is on PATH"""
+    path = shutil.which(name)
+    return {'name': name, 'found': path is not None, 'path': path}
+
+
+def probe_endpoint(env_var: str, timeout: float = DEFAULT_PROBE_TIMEOUT) -> Dict[str, Any]:
+    """
+    Check an endpoint named by an env var is configured and answers HTTP
+
+    Any HTTP response (even 4xx/5xx) counts as reachable; only connection
+    failures and timeouts don't.
+    """
+    url = os.environ.get(env_var, '').strip()
+    result: Dict[str, Any] = {'env': env_var, 'url': url or None, 'configured': bool(url),
+                              'reachable': False, 'detail': None}
+    if not url:
+        return result
+
+    request = urllib.request.Request(url, method='HEAD')
+    try:
+        with urllib.request.urlopen(request, timeout=timeout) as response:
+            result['reachable'] = True
+            result['detail'] = f"HTTP {response.status}"
+    except urllib.error.HTTPError as e:
+        result['reachable'] = True
+        result['detail'] = f"HTTP {e.code}"
+    except (urllib.error.URLError, OSError, ValueError) as e:
+        result['detail'] = str(getattr(e, 'reason', e))
+    return result
+
+
+def probe_feature(feature: Dict[str, Any], timeout: float = DEFAULT_PROBE_TIMEOUT) -> Dict[str, Any]:
+    """
+    Probe one feature's prerequisites
+
+    Status is one of: available, degraded (works with a fallback),
+    not_configured (endpoint env var unset), unavailable
+    """
+    libraries = [probe_library(module, dist) for module, dist in feature.get('libraries', [])]
+    binaries = [probe_binary(name) for name in feature.get('binaries', [])]
+    endpoint = probe_endpoint(feature['endpoint_env'], timeout) if feature.get('endpoint_env') else None
+
+    problems: List[str] = []
+    problems += [f"Python package '{lib['distribution']}' is not installed" for lib in libraries if not lib['installed']]
+    problems += [f"Executable '{b['name']}' is not on PATH" for b in binaries if not b['found']]
+
+    status = 'available'
+    if endpoint and not endpoint['configured']:
+        status = 'not_configured'
+        problems.append(f"{endpoint['env']} is not set")
+    elif endpoint and not endpoint['reachable']:
+        problems.append(f"{endpoint['url']} is not reachable ({endpoint['detail']})")
+
+    if problems and status == 'available':
+        status = 'degraded' if feature.get('fallback') else 'unavailable'
+
+    report = {
+        'name': feature['name'],
+        'description': feature['description'],
+        'status': status,
+        'problems': problems,
+        'remediation': feature.get('remediation') if problems else None,
+        'fallback': feature.get('fallback') if status == 'degraded' else None,
+        'libraries': libraries,
+    }
+    if binaries:
+        report['binaries'] = binaries
+    if endpoint:
+        report['endpoint'] = endpoint
+    return report
+
+
+def features_status(timeout: float = DEFAULT_PROBE_TIMEOUT,
+                    features: Optional[List[Dict[str, Any]]] = None) -> Dict[str, Any]:
+    """
+    Availability report for every known feature
+
+    Returns:
+        Dictionary with generated_at, python version, per-status counts, and
+        a report per feature
+    """
+    reports = [probe_feature(feature, timeout) for feature in (features or FEATURES)]
+    counts: Dict[str, int] = {}
+    for report in reports:
+        counts[report['status']] = counts.get(report['status'], 0) + 1
+
+    return {
+        'generated_at': datetime.now().isoformat(),
+        'python': sys.version.split()[0],
+        'summary': counts,
+        'features': reports
+    }
+
+
+def format_features_status(status: Dict[str, Any]) -> str:
+    """Human-readable summary of a features_status report"""
+    icons = {'available': '✅', 'degraded': '⚠️', 'not_configured': '⚪', 'unavailable': '❌'}
+    lines = []
+    for report in status['features']:
+        lines.append(f"{icons.get(report['status'], '•')} {report['name']}: {report['status']}")
+        for problem in report['problems']:
+            lines.append(f"    - {problem}")
+        if report['fallback']:
+            lines.append(f"    Fallback: {report['fallback']}")
+        if report['remediation']:
+            lines.append(f"    Fix: {report['remediation']}")
+    return "\n".join(lines)
@@ -4,9 +4,15 @@ File I/O and path utilities
 import json
 import numpy as np
 from pathlib import Path
-from typing import Dict, List, Any, Optional
+from typing import Dict, List, Any, Optional, Set
 from datetime import datetime
 
+# Latin letters that don't decompose into an ASCII letter plus accents
+TRANSLITERATIONS = {
+    'ß': 'ss', 'æ': 'ae', 'Æ': 'AE', 'œ': 'oe', 'Œ': 'OE', 'ø': 'o', 'Ø': 'O',
+    'đ': 'd', 'Đ': 'D', 'ł': 'l', 'Ł': 'L', 'þ': 'th', 'Þ': 'Th', 'ð': 'd', 'Ð': 'D', 'ı': 'i'
+}
+
 class NumpyEncoder(json.JSONEncoder):
     """JSON encoder that handles numpy types"""
     def default(self, obj):
@@ -29,12 +35,56 @@ class FileUtils:
         path.mkdir(parents=True, exist_ok=True)
         return path
     
+    @staticmethod
+    def transliterate(text: str) -> str:
+        """
+        Replace accented and special Latin letters with ASCII ("Über" -> "Uber")
+        
+        Characters without an ASCII spelling (CJK, symbols) are left as they are.
+        """
+        import unicodedata
+        result = []
+        for char in text:
+            if ord(char) < 128:
+                result.append(char)
+            elif char in TRANSLITERATIONS:
+                result.append(TRANSLITERATIONS[char])
+            else:
+                decomposed = unicodedata.normalize('NFD', char)
+                base = ''.join(c for c in decomposed if not unicodedata.combining(c))
+                result.append(base if base.isascii() and base else char)
+        return ''.join(result)
+    
+    @staticmethod
+    def unique_filename(filename: str, taken: Set[str]) -> str:
+        """
+        filename, or filename with a -2, -3, ... suffix before the extension
+        if it is already in taken; the result is added to taken
+        
+        Names are compared case-insensitively, since "Intro.md" and "intro.md"
+        are the same file on macOS and Windows.
+        """
+        stem, dot, extension = filename.rpartition('.')
+        if not dot:
+            stem, extension = filename, ''
+        candidate, number = filename, 1
+        while candidate.lower() in taken:
+            number += 1
+            candidate = f"{stem}-{number}{dot}{extension}"
+        taken.add(candidate.lower())
+        return candidate
+    
     @staticmethod
     def safe_filename(text: str, max_length: int = 100) -> str:
-        """Create a safe filename from text"""
+        """
+        Create a safe filename from text
+        
+        Accented letters are transliterated rather than dropped; the result
+        is empty for text that is all punctuation, so callers supply a fallback.
+        """
         import re
         # Remove/replace unsafe characters
-        safe = re.sub(r'[<>:"/\\|?*]', '_', text)
+        safe = re.sub(r'[<>:"/\\|?*]', '_', FileUtils.transliterate(text))
         safe = re.sub(r'[^\w\s-]', '', safe)
         safe = re.sub(r'[-\s]+', '-', safe)
         
@@ -42,7 +92,7 @@ class FileUtils:
         if len(safe) > max_length:
             safe = safe[:max_length].rsplit('-', 1)[0]
         
-        return safe.strip('-')
+        return safe.strip('-_')
     
     @staticmethod
     def sanitize_folder_name(filename: str) -> str:
@@ -90,8 +140,8 @@ class FileUtils:
         # Remove leading/trailing underscores and dots
         filename = filename.strip('._-')
         
-        # Remove non-ASCII characters (optional, but safer for Unix systems)
-        filename = ''.join(char for char in filename if ord(char) < 128)
+        # Transliterate accented letters, then remove remaining non-ASCII characters (safer for Unix systems)
+        filename = ''.join(char for char in FileUtils.transliterate(filename) if ord(char) < 128)
         
         # Ensure the name is not empty
         if not filename:
@@ -106,6 +156,17 @@ class FileUtils:
         
         return filename
     
+    @staticmethod
+    def document_id(source_name: str) -> str:
+        """
+        Stable identifier for a source document, derived from its original file name
+        
+        Unlike the sanitized folder name, two different source names never share
+        an ID (e.g. "My Doc.pdf" and "my_doc.pdf").
+        """
+        import hashlib
+        return hashlib.sha256(source_name.encode('utf-8')).hexdigest()[:16]
+    
     @staticmethod
     def write_json(data: Any, file_path: Path, indent: int = 2) -> None:
         """Write data to JSON file with proper formatting"""
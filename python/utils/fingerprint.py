@@ -0,0 +1,94 @@
+"""
+Document fingerprints for change detection
+
+A fingerprint combines a hash of the file bytes, the page count, and a hash
+of each page's text. Comparing two fingerprints tells an incremental pipeline
+whether a re-uploaded document changed and which pages need re-converting.
+Page hashes ignore whitespace differences so re-saving a PDF without editing
+it does not mark every page as changed.
+"""
+import hashlib
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+FINGERPRINT_VERSION = 1
+
+
+def hash_page_text(text: str) -> str:
+    """Hash page text with whitespace normalized"""
+    normalized = re.sub(r'\s+', ' ', text or '').strip()
+    return hashlib.sha256(normalized.encode('utf-8')).hexdigest()[:16]
+
+
+def hash_file(path: Path, chunk_size: int = 1024 * 1024) -> str:
+    """SHA-256 of a file's bytes, read in chunks"""
+    digest = hashlib.sha256()
+    with open(path, 'rb') as f:
+        for chunk in iter(lambda: f.read(chunk_size), b''):
+            digest.update(chunk)
+    return digest.hexdigest()
+
+
+def compute_fingerprint(pdf_path: str, page_hashes: Optional[List[str]] = None) -> Dict[str, Any]:
+    """
+    Compute a document fingerprint
+
+    Args:
+        pdf_path: Path to the PDF
+        page_hashes: Per-page text hashes already computed during extraction
+            (read from the PDF when omitted)
+
+    Returns:
+        Fingerprint dictionary (JSON-serializable)
+    """
+    if page_hashes is None:
+        import fitz
+        page_hashes = []
+        doc = fitz.open(pdf_path)
+        try:
+            for page_index in range(doc.page_count):
+                page_hashes.append(hash_page_text(doc.load_page(page_index).get_text()))
+        finally:
+            doc.close()
+
+    return {
+        'version': FINGERPRINT_VERSION,
+        'content_hash': hash_file(Path(pdf_path)),
+        'page_count': len(page_hashes),
+        'page_hashes': list(page_hashes)
+    }
+
+
+def compare_fingerprints(prior: Dict[str, Any], current: Dict[str, Any]) -> Dict[str, Any]:
+    """
+    Compare a prior fingerprint against the current one
+
+    Returns:
+        Dictionary with changed (bool), identical_file, changed_pages,
+        added_pages, removed_pages (1-based page numbers) and page counts
+    """
+    if prior.get('version', FINGERPRINT_VERSION) != current.get('version'):
+        raise ValueError(
+            f"Fingerprint version {prior.get('version')} is not comparable with version {current.get('version')}"
+        )
+
+    prior_pages = prior.get('page_hashes', [])
+    current_pages = current.get('page_hashes', [])
+    shared = min(len(prior_pages), len(current_pages))
+
+    changed_pages = [i + 1 for i in range(shared) if prior_pages[i] != current_pages[i]]
+    added_pages = list(range(shared + 1, len(current_pages) + 1))
+    removed_pages = list(range(shared + 1, len(prior_pages) + 1))
+    identical_file = prior.get('content_hash') == current.get('content_hash')
+
+    return {
+        'changed': bool(changed_pages or added_pages or removed_pages),
+        'identical_file': identical_file,
+        'prior_page_count': len(prior_pages),
+        'current_page_count': len(current_pages),
+        'changed_pages': changed_pages,
+        'added_pages': added_pages,
+        'removed_pages': removed_pages,
+        'unchanged_page_count': shared - len(changed_pages)
+    }
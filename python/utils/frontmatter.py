@@ -0,0 +1,69 @@
+"""
+YAML front-matter utilities
+
+Front-matter is written without a YAML dependency: every value is emitted
+as a JSON scalar or flow sequence, which is also valid YAML, so files can be
+read back with either a YAML parser or split_front_matter().
+"""
+import json
+from typing import Any, Dict, Tuple
+
+DELIMITER = '---'
+
+# Source provenance fields the frontmatter option controls
+SOURCE_FIELDS = ('source_pdf', 'source_pdfs', 'page_start', 'page_end')
+
+
+def render_front_matter(fields: Dict[str, Any]) -> str:
+    """
+    Render a ``---``-delimited front-matter block
+
+    Args:
+        fields: Ordered mapping of keys to JSON-serializable values
+
+    Returns:
+        Front-matter block ending with a blank line
+    """
+    lines = [DELIMITER]
+    for key, value in fields.items():
+        lines.append(f"{key}: {json.dumps(value, ensure_ascii=False, default=str)}")
+    lines.append(DELIMITER)
+    return '\n'.join(lines) + '\n\n'
+
+
+def split_front_matter(text: str) -> Tuple[Dict[str, Any], str]:
+    """
+    Split a markdown document into its front-matter fields and body
+
+    Returns:
+        (fields, body) - fields is empty when the text has no front-matter
+    """
+    if not text.startswith(DELIMITER + '\n'):
+        return {}, text
+
+    end = text.find('\n' + DELIMITER + '\n', len(DELIMITER))
+    if end == -1:
+        return {}, text
+
+    fields: Dict[str, Any] = {}
+    for line in text[len(DELIMITER) + 1:end].split('\n'):
+        if ':' not in line:
+            continue
+        key, raw_value = line.split(':', 1)
+        raw_value = raw_value.strip()
+        try:
+            fields[key.strip()] = json.loads(raw_value)
+        except (ValueError, TypeError):
+            fields[key.strip()] = raw_value
+
+    body = text[end + len(DELIMITER) + 2:]
+    return fields, body.lstrip('\n')
+
+
+def drop_front_matter_fields(text: str, keys) -> str:
+    """Text with keys removed from its front-matter (and no front-matter block left when none remain)"""
+    fields, body = split_front_matter(text)
+    if not fields:
+        return text
+    kept = {key: value for key, value in fields.items() if key not in keys}
+    return render_front_matter(kept) + body if kept else body
@@ -0,0 +1,76 @@
+"""
+One H1 per section file
+
+A section file starts with its title as "# Title", but the page text under
+it can carry more "#" headings (the chapter and document titles repeated),
+so many files end up with several H1s, which renderers and static-site
+generators treat as separate documents. normalize_headings keeps the first
+heading as the file's only H1 and demotes the rest: the heading levels used
+after it are ranked and renumbered from 2 in the same order, so nesting is
+kept and gaps (# then ###) close. heading_offset then shifts every heading
+down, for files embedded under another document's headings.
+
+Front-matter and fenced code blocks are left alone.
+"""
+import re
+from typing import List, Tuple
+
+MAX_HEADING_OFFSET = 5
+
+HEADING = re.compile(r'^(#{1,6})([ \t]+.*)?$')
+FENCE = re.compile(r'^\s*(`{3,}|~{3,})')
+FRONT_MATTER = re.compile(r'\A---\n.*?\n---\n', re.S)
+
+
+def validate_heading_offset(offset) -> int:
+    """
+    Check a heading_offset value
+
+    Raises:
+        ValueError: If it is not an integer from 0 to MAX_HEADING_OFFSET
+    """
+    if isinstance(offset, bool) or not isinstance(offset, int) or not 0 <= offset <= MAX_HEADING_OFFSET:
+        raise ValueError(f"heading_offset must be an integer from 0 to {MAX_HEADING_OFFSET}")
+    return offset
+
+
+def heading_lines(lines: List[str]) -> List[Tuple[int, int]]:
+    """(line index, level) of each ATX heading outside fenced code"""
+    headings = []
+    fence = None
+    for index, line in enumerate(lines):
+        match = FENCE.match(line)
+        if match:
+            marker = match.group(1)
+            if fence is None:
+                fence = marker
+            elif marker[0] == fence[0] and len(marker) >= len(fence):
+                fence = None
+            continue
+        if fence is None:
+            heading = HEADING.match(line)
+            if heading:
+                headings.append((index, len(heading.group(1))))
+    return headings
+
+
+def normalize_headings(markdown: str, offset: int = 0) -> str:
+    """
+    The file's first heading as its only H1, later headings demoted under it
+
+    Args:
+        markdown: A section file, with or without front-matter
+        offset: Levels added to every heading afterwards (capped at H6)
+    """
+    front_matter = FRONT_MATTER.match(markdown)
+    head = front_matter.group(0) if front_matter else ''
+    lines = markdown[len(head):].split('\n')
+    headings = heading_lines(lines)
+    if not headings:
+        return markdown
+
+    ranks = {level: rank for rank, level in enumerate(sorted({level for _, level in headings[1:]}), 2)}
+    for position, (index, level) in enumerate(headings):
+        new_level = min(6, (1 if position == 0 else ranks[level]) + offset)
+        lines[index] = '#' * new_level + lines[index][level:]
+    return head + '\n'.join(lines)
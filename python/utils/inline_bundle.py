@@ -0,0 +1,234 @@
+"""
+Self-contained JSON bundle of a conversion
+
+Stateless clients that can't read files or resources get the whole
+conversion in one tool response: sections (front-matter and markdown),
+token-bounded chunks, and images as base64, read back from a finished
+conversion's manifest.json. A size cap keeps the response bounded; what
+doesn't fit is truncated or omitted and every cut is listed in notices.
+The conversion's warnings are part of the bundle and count against the cap.
+
+Notices are one per kind of cut ("12 section(s) omitted ..."), never one
+per file, so a document with thousands of sections still fits.
+"""
+import base64
+import json
+import mimetypes
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+from .frontmatter import split_front_matter
+from .token_counter import TokenCounter
+
+# Total serialized size of the bundle (bytes)
+DEFAULT_MAX_INLINE_BYTES = 4_000_000
+MIN_INLINE_BYTES = 10_000
+
+DEFAULT_CHUNK_TOKENS = 768
+
+# Room kept for the bundle's own fields and notices, and the short summary sent alongside it
+ENVELOPE_RESERVE = 4_000
+
+TRUNCATION_MARKER = "\n\n[... truncated: size cap reached ...]"
+
+# Below this many bytes of room a section's content is omitted rather than truncated
+MIN_TRUNCATED_CONTENT = 500
+
+# File names listed in a notice about omitted files; the rest are counted
+NOTICE_FILE_NAMES = 10
+
+
+def _size(value: Any) -> int:
+    """Serialized size in bytes"""
+    return len(json.dumps(value, ensure_ascii=False).encode('utf-8'))
+
+
+def _truncate_to(text: str, limit: int) -> str:
+    """Cut text (plus TRUNCATION_MARKER) so its serialized size, without quotes, fits limit bytes"""
+    keep = len(text)
+    while keep > 0:
+        keep = min(keep - 1, int(keep * limit / max(_size(text[:keep] + TRUNCATION_MARKER) - 2, 1)))
+        candidate = text[:max(keep, 0)] + TRUNCATION_MARKER
+        if _size(candidate) - 2 <= limit:
+            return candidate
+    return TRUNCATION_MARKER.strip()
+
+
+def _omitted_notice(count: int, what: str, files: List[str]) -> str:
+    """One notice for every file of a kind left out, naming the first few"""
+    notice = f"{count} {what}(s) omitted to fit max_inline_bytes"
+    if files:
+        notice += f": {', '.join(files[:NOTICE_FILE_NAMES])}{' ...' if len(files) > NOTICE_FILE_NAMES else ''}"
+    return notice
+
+
+def chunk_markdown(text: str, max_tokens: int = DEFAULT_CHUNK_TOKENS,
+                   token_counter: Optional[TokenCounter] = None) -> List[str]:
+    """
+    Split markdown into chunks of at most max_tokens, at blank lines
+
+    A single paragraph larger than max_tokens becomes a chunk of its own
+    rather than being cut mid-sentence.
+    """
+    counter = token_counter or TokenCounter()
+    chunks: List[str] = []
+    current: List[str] = []
+    for paragraph in (p.strip() for p in text.split('\n\n')):
+        if not paragraph:
+            continue
+        candidate = '\n\n'.join(current + [paragraph])
+        if current and counter.count_tokens(candidate) > max_tokens:
+            chunks.append('\n\n'.join(current))
+            current = [paragraph]
+        else:
+            current.append(paragraph)
+    if current:
+        chunks.append('\n\n'.join(current))
+    return chunks
+
+
+def build_inline_bundle(output_path: Path, max_bytes: int = DEFAULT_MAX_INLINE_BYTES,
+                        chunk_tokens: int = DEFAULT_CHUNK_TOKENS,
+                        include_images: bool = True, warnings: Optional[List[str]] = None) -> Dict[str, Any]:
+    """
+    Read a finished conversion into one JSON-serializable document
+
+    Warnings are added first, then sections, images, and chunks, each only
+    while the running size stays under max_bytes: the section that doesn't
+    fit is truncated, and once room is gone the remaining sections are
+    omitted, as are warnings, images, and chunks that don't fit.
+    Notices are counted against the cap too.
+
+    Args:
+        output_path: Conversion output directory (holds manifest.json)
+        max_bytes: Cap on the serialized bundle size
+        chunk_tokens: Maximum tokens per chunk
+        include_images: Embed images as base64
+        warnings: The conversion's warnings, included (and counted) while they fit
+
+    Returns:
+        Dictionary with document fields, sections, chunks, images,
+        truncated, notices, warnings, max_bytes, and size_bytes
+    """
+    output_path = Path(output_path)
+    max_bytes = max(int(max_bytes), MIN_INLINE_BYTES)
+    manifest = json.loads((output_path / "manifest.json").read_text(encoding='utf-8'))
+
+    bundle: Dict[str, Any] = {
+        'document_id': manifest.get('document_id'),
+        'source_file': manifest.get('source_file'),
+        'fingerprint': manifest.get('fingerprint'),
+        'sections': [],
+        'chunks': [],
+        'images': [],
+        'truncated': False,
+        'notices': [],
+        'warnings': []
+    }
+    if manifest.get('sample'):
+        bundle['sample'] = manifest['sample']
+    budget = max_bytes - _size(bundle) - ENVELOPE_RESERVE
+
+    def add_notice(notice: str) -> None:
+        nonlocal budget
+        bundle['notices'].append(notice)
+        budget -= _size(notice) + 2
+
+    # Warnings: kept in order while they fit
+    warnings = list(warnings or [])
+    for index, warning in enumerate(warnings):
+        size = _size(warning) + 2
+        if size > budget:
+            add_notice(f"{len(warnings) - index} of {len(warnings)} warning(s) omitted to fit max_inline_bytes")
+            break
+        budget -= size
+        bundle['warnings'].append(warning)
+
+    # Sections: in order, the last one that fits truncated, the rest omitted
+    bodies = []
+    omitted_sections = []
+    for entry in manifest.get('sections', []):
+        if omitted_sections:
+            omitted_sections.append(entry['file'])
+            continue
+        text = (output_path / entry['file']).read_text(encoding='utf-8')
+        front_matter, body = split_front_matter(text)
+        section = {
+            'file': entry['file'],
+            'section_id': entry.get('section_id'),
+            'title': entry.get('title', ''),
+            'token_count': entry.get('token_count', 0),
+            'front_matter': front_matter,
+            'content': ''
+        }
+        size = _size(section) + 2  # plus the list separator
+        if size + _size(body) - 2 <= budget:
+            section['content'] = body
+        elif budget - size >= MIN_TRUNCATED_CONTENT:
+            section['content'] = _truncate_to(body, budget - size)
+            add_notice(f"Section {entry['file']} truncated to fit max_inline_bytes")
+        else:
+            omitted_sections.append(entry['file'])
+            continue
+        budget -= size + _size(section['content']) - 2
+        bundle['sections'].append(section)
+        bodies.append((section, body))
+    if omitted_sections:
+        add_notice(_omitted_notice(len(omitted_sections), 'section', omitted_sections))
+
+    # Images: whole or not at all
+    omitted_images = []
+    for image in manifest.get('images', []) if include_images else []:
+        image_file = output_path / image['file']
+        if not image_file.exists():
+            continue
+        entry = {
+            'file': image['file'],
+            'page': image.get('page'),
+            'section_id': image.get('section_id'),
+            'width': image.get('width'),
+            'height': image.get('height'),
+            'caption': image.get('caption'),
+            'alt_text': image.get('alt_text'),
+            'mime_type': mimetypes.guess_type(image_file.name)[0] or 'application/octet-stream',
+            'data': base64.b64encode(image_file.read_bytes()).decode('ascii')
+        }
+        size = _size(entry) + 2
+        if size > budget:
+            omitted_images.append(image['file'])
+            continue
+        budget -= size
+        bundle['images'].append(entry)
+    if omitted_images:
+        add_notice(_omitted_notice(len(omitted_images), 'image', omitted_images))
+    if not include_images and manifest.get('images'):
+        add_notice(f"{len(manifest['images'])} image(s) not embedded (include_images is false)")
+
+    # Chunks: from the full section bodies, dropped once the budget runs out
+    counter = TokenCounter(tokenizer=manifest.get('tokenizer'))
+    omitted_chunks = 0
+    for section, body in bodies:
+        for index, text in enumerate(chunk_markdown(body, chunk_tokens, counter), 1):
+            entry = {
+                'section_id': section['section_id'],
+                'file': section['file'],
+                'index': index,
+                'token_count': counter.count_tokens(text),
+                'text': text
+            }
+            size = _size(entry) + 2
+            if size > budget:
+                omitted_chunks += 1
+                continue
+            budget -= size
+            bundle['chunks'].append(entry)
+    if omitted_chunks:
+        add_notice(_omitted_notice(omitted_chunks, 'chunk', []))
+
+    bundle['truncated'] = any('max_inline_bytes' in notice for notice in bundle['notices'])
+    bundle['max_bytes'] = max_bytes
+    # The size field counts its own digits: settle it in two passes
+    bundle['size_bytes'] = 0
+    for _ in range(2):
+        bundle['size_bytes'] = _size(bundle)
+    return bundle
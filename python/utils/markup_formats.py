@@ -0,0 +1,313 @@
+"""
+AsciiDoc and reStructuredText output
+
+Conversions are built as markdown; with output_format 'asciidoc' or 'rst'
+each file is rendered into the target markup as it is written, so toolchains
+that ingest those formats get native tables, images, and code blocks rather
+than a lossy markdown round trip:
+
+- Front-matter becomes AsciiDoc attribute entries / an RST field list.
+- Pipe tables become ``|===`` tables / ``list-table`` directives.
+- Standalone images become ``image::`` block macros / ``image`` directives.
+- Code fences become ``[source]`` listing blocks / ``code-block`` directives.
+- Page markers become ``// Page N`` / ``.. Page N`` comments, or
+  ``[[page-N]]`` / ``.. _page-N:`` anchors (see processors.page_markers).
+- Links to generated ``.md`` files point at the renamed files.
+"""
+import re
+from typing import Any, Dict, List, Optional
+
+from processors.page_markers import marker_page
+
+from .frontmatter import split_front_matter
+
+OUTPUT_FORMATS = ('markdown', 'asciidoc', 'rst')
+
+FILE_EXTENSIONS = {'markdown': '.md', 'asciidoc': '.adoc', 'rst': '.rst'}
+
+# RST heading underline characters by level
+RST_UNDERLINES = ('=', '-', '~', '^', '"', "'")
+
+HEADING = re.compile(r'^(#{1,6})\s+(.*?)\s*#*\s*$')
+FENCE = re.compile(r'^\s*(```|~~~)\s*([\w+-]*)')
+IMAGE_LINE = re.compile(r'^\s*!\[([^\]]*)\]\(([^)\s]+)\)\s*$')
+TABLE_SEPARATOR = re.compile(r'^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$')
+BULLET = re.compile(r'^(\s*)[-*+]\s+(.*)$')
+NUMBERED = re.compile(r'^(\s*)\d+[.)]\s+(.*)$')
+RULE = re.compile(r'^\s*(-{3,}|\*{3,}|_{3,})\s*$')
+QUOTE = re.compile(r'^\s*>\s?(.*)$')
+
+INLINE_CODE = re.compile(r'`([^`]+)`')
+IMAGE = re.compile(r'!\[([^\]]*)\]\(([^)\s]+)\)')
+LINK = re.compile(r'(?<!!)\[([^\]]+)\]\(([^)\s]+)\)')
+BOLD = re.compile(r'\*\*(.+?)\*\*')
+ITALIC = re.compile(r'(?<![*\w])[*_](?![*_\s])(.+?)(?<![*_\s])[*_](?![*\w])')
+
+
+def output_filename(filename: str, output_format: str) -> str:
+    """File name with the extension for the output format (.md names only)"""
+    if output_format == 'markdown' or not filename.endswith('.md'):
+        return filename
+    return filename[:-len('.md')] + FILE_EXTENSIONS[output_format]
+
+
+def _relink(target: str, output_format: str) -> str:
+    """Point relative links at renamed .md files (anchors kept)"""
+    if re.match(r'^[a-z][a-z0-9+.-]*:', target, re.IGNORECASE):
+        return target
+    path, _, anchor = target.partition('#')
+    path = output_filename(path, output_format)
+    return f"{path}#{anchor}" if anchor else path
+
+
+def render_inline(text: str, output_format: str) -> str:
+    """Inline markup: code, images, links, bold, italic"""
+    if output_format == 'markdown':
+        return text
+
+    # Protect code spans from the other rules
+    spans: List[str] = []
+
+    def keep(value: str) -> str:
+        spans.append(value)
+        return f"\x00{len(spans) - 1}\x00"
+
+    if output_format == 'asciidoc':
+        text = INLINE_CODE.sub(lambda m: keep(f"`+{m.group(1)}+`"), text)
+        text = IMAGE.sub(lambda m: keep(f"image:{_relink(m.group(2), output_format)}[{m.group(1)}]"), text)
+        text = LINK.sub(lambda m: keep(f"link:{_relink(m.group(2), output_format)}[{m.group(1)}]"), text)
+        text = BOLD.sub(lambda m: keep(f"*{m.group(1)}*"), text)
+        text = ITALIC.sub(r'_\1_', text)
+    else:
+        text = INLINE_CODE.sub(lambda m: keep(f"``{m.group(1)}``"), text)
+        text = IMAGE.sub(lambda m: keep(f"`{m.group(1) or 'image'} <{_relink(m.group(2), output_format)}>`__"), text)
+        text = LINK.sub(lambda m: keep(f"`{m.group(1)} <{_relink(m.group(2), output_format)}>`__"), text)
+        text = BOLD.sub(lambda m: keep(f"**{m.group(1)}**"), text)
+        text = ITALIC.sub(r'*\1*', text)
+
+    return re.sub(r'\x00(\d+)\x00', lambda m: spans[int(m.group(1))], text)
+
+
+def _table_cells(line: str) -> List[str]:
+    """Cells of one pipe-table row (escaped pipes kept in the cell)"""
+    line = line.strip()
+    if line.startswith('|'):
+        line = line[1:]
+    if line.endswith('|') and not line.endswith('\\|'):
+        line = line[:-1]
+    return [cell.strip().replace('\\|', '|') for cell in re.split(r'(?<!\\)\|', line)]
+
+
+def render_table(rows: List[List[str]], output_format: str) -> List[str]:
+    """A table (first row is the header) as AsciiDoc or RST lines"""
+    width = max(len(row) for row in rows)
+    rows = [row + [''] * (width - len(row)) for row in rows]
+    if output_format == 'asciidoc':
+        lines = [f'[cols="{width}*",options="header"]', '|===']
+        for index, row in enumerate(rows):
+            lines.append(' '.join(f"| {render_inline(cell, output_format).replace('|', '{vbar}')}"
+                                  for cell in row).rstrip())
+            if index == 0:
+                lines.append('')
+        lines.append('|===')
+        return lines
+
+    lines = ['.. list-table::', '   :header-rows: 1', '']
+    for row in rows:
+        for index, cell in enumerate(row):
+            prefix = '   * - ' if index == 0 else '     - '
+            lines.append((prefix + render_inline(cell, output_format)).rstrip())
+    return lines
+
+
+def render_fields(fields: Dict[str, Any], output_format: str) -> List[str]:
+    """Front-matter as AsciiDoc attribute entries or an RST field list"""
+    lines = []
+    for key, value in fields.items():
+        if isinstance(value, list):
+            value = ', '.join(str(item) for item in value)
+        elif isinstance(value, bool):
+            value = 'true' if value else 'false'
+        elif value is None:
+            value = ''
+        elif isinstance(value, str):
+            value = output_filename(value, output_format)
+        lines.append(f":{key}: {value}".rstrip())
+    return lines
+
+
+def render_page_marker(line: str, page: int, output_format: str) -> List[str]:
+    """A page marker line in the format's own syntax: a comment, or an anchor with its optional label"""
+    if line.strip().startswith('<!--'):
+        return [f"// Page {page}" if output_format == 'asciidoc' else f".. Page {page}"]
+    labelled = '<sup>' in line
+    if output_format == 'asciidoc':
+        return [f"[[page-{page}]]" + (f"^p. {page}^" if labelled else '')]
+    return [f".. _page-{page}:"] + (['', f":sup:`p. {page}`"] if labelled else [])
+
+
+def render_body(markdown: str, output_format: str) -> str:
+    """Block-level conversion of markdown (without front-matter)"""
+    lines = markdown.replace('\r\n', '\n').split('\n')
+    out: List[str] = []
+    index = 0
+    list_indent: Optional[int] = None
+
+    def blank() -> None:
+        if out and out[-1] != '':
+            out.append('')
+
+    while index < len(lines):
+        line = lines[index]
+
+        fence = FENCE.match(line)
+        if fence:
+            language = fence.group(2)
+            body = []
+            index += 1
+            while index < len(lines) and not lines[index].strip().startswith(fence.group(1)):
+                body.append(lines[index])
+                index += 1
+            index += 1
+            blank()
+            if output_format == 'asciidoc':
+                out.extend([f"[source,{language}]" if language else '[source]', '----', *body, '----'])
+            else:
+                out.append(f".. code-block:: {language}" if language else '::')
+                out.append('')
+                out.extend(f"   {code}".rstrip() for code in body)
+            out.append('')
+            list_indent = None
+            continue
+
+        page = marker_page(line)
+        if page is not None:
+            blank()
+            out.extend(render_page_marker(line, page, output_format))
+            out.append('')
+            list_indent = None
+            index += 1
+            continue
+
+        # Pipe table: header row followed by a separator row
+        if '|' in line and index + 1 < len(lines) and TABLE_SEPARATOR.match(lines[index + 1]):
+            rows = [_table_cells(line)]
+            index += 2
+            while index < len(lines) and lines[index].strip().startswith('|'):
+                rows.append(_table_cells(lines[index]))
+                index += 1
+            blank()
+            out.extend(render_table(rows, output_format))
+            out.append('')
+            list_indent = None
+            continue
+
+        heading = HEADING.match(line)
+        if heading:
+            level = len(heading.group(1))
+            title = render_inline(heading.group(2), output_format)
+            blank()
+            if output_format == 'asciidoc':
+                out.append(f"{'=' * level} {title}")
+            else:
+                underline = RST_UNDERLINES[min(level, len(RST_UNDERLINES)) - 1] * max(len(title), 4)
+                out.extend([title, underline])
+            out.append('')
+            list_indent = None
+            index += 1
+            continue
+
+        image = IMAGE_LINE.match(line)
+        if image:
+            alt, target = image.group(1), _relink(image.group(2), output_format)
+            blank()
+            if output_format == 'asciidoc':
+                out.append(f"image::{target}[{alt}]")
+            else:
+                out.append(f".. image:: {target}")
+                if alt:
+                    out.append(f"   :alt: {alt}")
+            out.append('')
+            index += 1
+            continue
+
+        if RULE.match(line):
+            blank()
+            out.extend(["'''" if output_format == 'asciidoc' else '----', ''])
+            list_indent = None
+            index += 1
+            continue
+
+        item = BULLET.match(line) or NUMBERED.match(line)
+        if item:
+            indent = len(item.group(1).expandtabs(4))
+            depth = indent // 2 + 1
+            text = render_inline(item.group(2), output_format)
+            numbered = BULLET.match(line) is None
+            if output_format == 'asciidoc':
+                out.append(f"{('.' if numbered else '*') * depth} {text}")
+            else:
+                # Nested RST lists must be separated from their parent by blank lines
+                if list_indent is not None and indent != list_indent:
+                    blank()
+                elif list_indent is None:
+                    blank()
+                out.append(f"{'  ' * (depth - 1)}{'#.' if numbered else '-'} {text}")
+            list_indent = indent
+            index += 1
+            continue
+
+        quote = QUOTE.match(line)
+        if quote:
+            if output_format == 'asciidoc':
+                out.append(f"> {render_inline(quote.group(1), output_format)}".rstrip())
+            else:
+                if not out or not out[-1].startswith('   '):
+                    blank()
+                out.append(f"   {render_inline(quote.group(1), output_format)}".rstrip())
+            index += 1
+            continue
+
+        if not line.strip():
+            blank()
+        else:
+            if list_indent is not None:
+                # Paragraph after a list ends the list
+                blank()
+            out.append(render_inline(line, output_format))
+        list_indent = None
+        index += 1
+
+    while out and out[-1] == '':
+        out.pop()
+    return '\n'.join(out) + '\n'
+
+
+def render_document(markdown: str, output_format: str) -> str:
+    """
+    Render a generated markdown file (front-matter optional) into the output format
+
+    Markdown is returned unchanged.
+    """
+    if output_format == 'markdown':
+        return markdown
+    if output_format not in OUTPUT_FORMATS:
+        raise ValueError(f"Unknown output_format '{output_format}' (expected one of: {', '.join(OUTPUT_FORMATS)})")
+
+    fields, body = split_front_matter(markdown)
+    rendered = render_body(body, output_format)
+    if not fields:
+        return rendered
+
+    # Fields go directly under the document title, where both formats read them as document metadata
+    lines = rendered.split('\n')
+    title_lines = 0
+    if output_format == 'asciidoc' and lines[0].startswith('= '):
+        title_lines = 1
+    elif output_format == 'rst' and len(lines) > 1 and lines[1] and set(lines[1]) == {RST_UNDERLINES[0]}:
+        title_lines = 2
+    title, rest = lines[:title_lines], lines[title_lines:]
+    while rest and rest[0] == '':
+        rest.pop(0)
+    header = title + ([''] if title and output_format == 'rst' else []) + render_fields(fields, output_format)
+    return '\n'.join(header + [''] + rest)
@@ -0,0 +1,65 @@
+"""
+Section navigation links
+
+Computes previous/next/parent links between generated section files so
+static site generators can build navigation from front-matter alone.
+"""
+from typing import Dict, List, Optional
+
+
+def section_parents(levels: List[int]) -> List[Optional[int]]:
+    """
+    Parent section index for each section, from the heading hierarchy
+
+    Walks the sections in order with a heading stack: a section's parent is
+    the closest earlier section with a smaller level.
+
+    Args:
+        levels: Heading level per section, in document order
+
+    Returns:
+        Index of each section's parent, or None for top-level sections
+    """
+    parents: List[Optional[int]] = []
+    stack: List[int] = []
+    for index, level in enumerate(levels):
+        while stack and levels[stack[-1]] >= level:
+            stack.pop()
+        parents.append(stack[-1] if stack else None)
+        stack.append(index)
+    return parents
+
+
+def link_section_files(section_files: List[List[str]], levels: List[int]) -> List[List[Dict[str, Optional[str]]]]:
+    """
+    Navigation links for every generated section file
+
+    A section split into parts produces several files; prev/next follow the
+    flattened file order (so parts chain together), and parent points to the
+    first file of the parent section.
+
+    Args:
+        section_files: File names per section, in document order
+        levels: Heading level per section
+
+    Returns:
+        For each section, a {prev, next, parent} dict per file
+    """
+    ordered = [name for files in section_files for name in files]
+    parents = section_parents(levels)
+
+    links: List[List[Dict[str, Optional[str]]]] = []
+    position = 0
+    for index, files in enumerate(section_files):
+        parent_index = parents[index]
+        parent = section_files[parent_index][0] if parent_index is not None and section_files[parent_index] else None
+        section_links = []
+        for _ in files:
+            section_links.append({
+                'prev': ordered[position - 1] if position > 0 else None,
+                'next': ordered[position + 1] if position + 1 < len(ordered) else None,
+                'parent': parent
+            })
+            position += 1
+        links.append(section_links)
+    return links
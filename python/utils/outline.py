@@ -0,0 +1,82 @@
+"""
+Document outline as a nested tree
+
+The analyzer lists outline entries flat, in document order, with their
+nesting level. extract_outline returns them nested instead, so a client can
+see the shape of a document before deciding to convert it. PDFs without
+bookmarks get an outline synthesized from numbered and "Chapter N" heading
+lines found in a quick text pass; those are flagged synthesized.
+"""
+import re
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+
+# Longest line (words) still taken for a heading in a synthesized outline
+MAX_HEADING_WORDS = 12
+
+# "2.3.1 Card Authentication", "4. Results"
+NUMBERED_HEADING = re.compile(r'^(\d{1,3}(?:\.\d{1,3})*)\.?\s+([A-Z][^\n]*)$')
+
+# "Chapter 3 Results", "PART II", "Appendix A: Glossary"
+NAMED_HEADING = re.compile(r'^(?:Chapter|CHAPTER|Part|PART|Appendix|APPENDIX)\s+[0-9IVXLCA-Z]+\b')
+
+# Table of contents entries: dot leaders or a trailing page number
+TOC_ENTRY = re.compile(r'(?:\.{3,}|\s\.\s\.|\s{2,})\s*\d+$|\s\d+$')
+
+
+def nest_outline(chapters: Iterable[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """
+    Nest flat outline entries (title, level, page) by level
+
+    Each entry gets a children list. An entry more than one level deeper
+    than its predecessor hangs from the nearest shallower entry.
+    """
+    tree: List[Dict[str, Any]] = []
+    stack: List[Dict[str, Any]] = []
+    for chapter in chapters:
+        node = {'title': chapter['title'], 'level': chapter['level'], 'page': chapter.get('page'), 'children': []}
+        while stack and stack[-1]['level'] >= node['level']:
+            stack.pop()
+        (stack[-1]['children'] if stack else tree).append(node)
+        stack.append(node)
+    return tree
+
+
+def heading_from_line(line: str) -> Optional[Tuple[str, int]]:
+    """
+    Title and 0-based level of a heading line, or None
+
+    Only numbered sections ("2.3 Scope", level from the number depth) and
+    Chapter/Part/Appendix lines (level 0) count; table of contents entries
+    and sentences are skipped.
+    """
+    line = re.sub(r'\s+', ' ', line).strip()
+    if not line or len(line.split()) > MAX_HEADING_WORDS or line.endswith(('.', ',', ';', ':')):
+        return None
+    match = NUMBERED_HEADING.match(line)
+    if match and not TOC_ENTRY.search(match.group(2)):
+        return line, match.group(1).count('.')
+    match = NAMED_HEADING.match(line)
+    if match and not TOC_ENTRY.search(line[match.end():]):
+        return line, 0
+    return None
+
+
+def synthesize_outline(page_texts: Iterable[str]) -> List[Dict[str, Any]]:
+    """
+    Flat outline entries from the heading lines of each page's text
+
+    Args:
+        page_texts: Text of pages 1, 2, ... in order
+
+    Returns:
+        title, level, and page of each distinct heading's first occurrence
+    """
+    chapters = []
+    seen = set()
+    for page_num, text in enumerate(page_texts, 1):
+        for line in (text or '').splitlines():
+            heading = heading_from_line(line)
+            if heading and heading[0].lower() not in seen:
+                seen.add(heading[0].lower())
+                chapters.append({'title': heading[0], 'level': heading[1], 'page': page_num})
+    return chapters
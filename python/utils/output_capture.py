@@ -0,0 +1,211 @@
+"""
+Capture converter output and keep error messages bounded
+
+Converters print progress and tracebacks freely. On a large document that
+output can reach megabytes, which must not be wrapped wholesale into a
+JSON-RPC error response. Output is captured, truncated (keeping head and
+tail) for the response, and written in full to a log file.
+
+Conversions run concurrently in worker threads, so a capture can't swap
+sys.stdout/sys.stderr for itself: two captures would restore each other's
+streams out of order. Instead, while any capture is active, one routing
+stream is installed as both, and each write goes to the capture of the
+context it was made in (a contextvar; run the worker with
+contextvars.copy_context().run to carry it into the thread). Writes from
+any other context go to the stderr that was replaced, never to stdout.
+"""
+import contextvars
+import io
+import os
+import sys
+import threading
+from datetime import datetime
+from pathlib import Path
+from typing import Callable, Optional
+
+DEFAULT_MAX_ERROR_BYTES = 8192
+
+
+def max_error_bytes() -> int:
+    """Configured cap for error text returned to clients (MAX_ERROR_BYTES env)"""
+    try:
+        return max(256, int(os.environ.get('MAX_ERROR_BYTES', DEFAULT_MAX_ERROR_BYTES)))
+    except ValueError:
+        return DEFAULT_MAX_ERROR_BYTES
+
+
+def truncate_middle(text: str, limit_bytes: Optional[int] = None) -> str:
+    """
+    Cap text to limit_bytes (UTF-8), keeping the head and tail
+
+    The head usually names the failing step and the tail holds the final
+    exception, so the middle is the least useful part to drop.
+    """
+    limit_bytes = limit_bytes or max_error_bytes()
+    encoded = text.encode('utf-8')
+    if len(encoded) <= limit_bytes:
+        return text
+
+    omitted = len(encoded) - limit_bytes
+    marker = f"\n... [{omitted:,} bytes omitted] ...\n".encode('utf-8')
+    keep = max(0, limit_bytes - len(marker))
+    head = encoded[:keep // 2].decode('utf-8', errors='ignore')
+    tail = encoded[len(encoded) - (keep - keep // 2):].decode('utf-8', errors='ignore')
+    return head + marker.decode('utf-8') + tail
+
+
+def write_error_log(text: str, directory: Path, prefix: str = "conversion-error") -> Optional[Path]:
+    """Write the full captured output to a timestamped log file; None if it can't be written"""
+    try:
+        directory.mkdir(parents=True, exist_ok=True)
+        log_file = directory / f"{prefix}-{datetime.now().strftime('%Y%m%d-%H%M%S')}.log"
+        log_file.write_text(text, encoding='utf-8')
+        return log_file
+    except OSError:
+        return None
+
+
+class _Tee(io.TextIOBase):
+    """Write to a capture buffer and a passthrough stream, reporting complete lines"""
+
+    def __init__(self, buffer: io.StringIO, passthrough, on_line: Optional[Callable[[str], None]] = None):
+        self.buffer_ = buffer
+        self.passthrough = passthrough
+        self.on_line = on_line
+        self._partial = ''
+
+    def write(self, text):
+        self.buffer_.write(text)
+        if self.on_line is not None:
+            self._partial += text
+            *lines, self._partial = self._partial.split('\n')
+            for line in lines:
+                try:
+                    self.on_line(line)
+                except Exception:
+                    pass  # A failing listener must not break the converter
+        if self.passthrough is not None:
+            try:
+                self.passthrough.write(text)
+            except (OSError, ValueError):
+                pass
+        return len(text)
+
+    def flush(self):
+        if self.passthrough is not None:
+            try:
+                self.passthrough.flush()
+            except (OSError, ValueError):
+                pass
+
+
+_current_capture: contextvars.ContextVar = contextvars.ContextVar('output_capture', default=None)
+
+
+class _Router(io.TextIOBase):
+    """Installed as sys.stdout/sys.stderr: writes go to the current context's capture"""
+
+    def __init__(self, fallback):
+        self.fallback = fallback
+
+    def _target(self):
+        capture = _current_capture.get()
+        return capture._tee if capture is not None else self.fallback
+
+    def write(self, text):
+        try:
+            return self._target().write(text)
+        except (OSError, ValueError):
+            return len(text)
+
+    def flush(self):
+        try:
+            self._target().flush()
+        except (OSError, ValueError):
+            pass
+
+
+class _Routing:
+    """Installs the routing streams for the first active capture and restores the originals after the last"""
+
+    def __init__(self):
+        self.lock = threading.Lock()
+        self.active = 0
+        self.saved = None
+
+    def acquire(self):
+        with self.lock:
+            if self.active == 0:
+                self.saved = (sys.stdout, sys.stderr)
+                # Unattributed stdout goes to stderr too: stdout carries the protocol
+                router = _Router(sys.stderr)
+                sys.stdout = sys.stderr = router
+            self.active += 1
+
+    def release(self):
+        with self.lock:
+            self.active -= 1
+            if self.active == 0:
+                stdout, stderr = self.saved
+                if isinstance(sys.stdout, _Router):
+                    sys.stdout = stdout
+                if isinstance(sys.stderr, _Router):
+                    sys.stderr = stderr
+                self.saved = None
+
+
+_ROUTING = _Routing()
+
+
+class OutputCapture:
+    """
+    Context manager capturing stdout/stderr printed by a converter
+
+    Output is mirrored to the real stderr so server logs still show progress,
+    and never reaches stdout (which carries the MCP protocol in stdio mode).
+    on_line, if given, is called with each complete line as it is printed
+    (used to forward PROGRESS lines while the converter runs).
+
+    Only output printed in this context is captured, including worker
+    threads started with a copy of it (see the module docstring), so
+    concurrent captures each see just their own converter's output.
+    """
+
+    def __init__(self, mirror_to_stderr: bool = True, on_line: Optional[Callable[[str], None]] = None):
+        self._buffer = io.StringIO()
+        self._token = None
+        self.mirror_to_stderr = mirror_to_stderr
+        self.on_line = on_line
+        self._tee = _Tee(self._buffer, sys.__stderr__ if mirror_to_stderr else None, on_line)
+
+    def __enter__(self) -> 'OutputCapture':
+        _ROUTING.acquire()
+        self._token = _current_capture.set(self)
+        return self
+
+    def __exit__(self, exc_type, exc, tb):
+        _current_capture.reset(self._token)
+        _ROUTING.release()
+        return False
+
+    def getvalue(self) -> str:
+        return self._buffer.getvalue()
+
+    def format_error(self, error: str, log_dir: Path) -> str:
+        """
+        Build a bounded error message from an error and the captured output
+
+        The full output is written to a log file under log_dir and referenced
+        in the message.
+        """
+        captured = self.getvalue()
+        full_log = f"Error: {error}\n\n--- Captured output ---\n{captured}"
+        log_file = write_error_log(full_log, log_dir)
+
+        message = error
+        if captured.strip():
+            message += "\n\nCaptured output:\n" + captured
+        message = truncate_middle(message)
+        if log_file:
+            message += f"\n\nFull log: {log_file}"
+        return message
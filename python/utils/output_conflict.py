@@ -0,0 +1,160 @@
+"""
+Detect output directories already holding a different document's conversion
+
+Two source files can resolve to the same output folder (for example
+"My Doc.pdf" and "my_doc.pdf"), and callers sometimes reuse an output_dir
+by mistake. The existing manifest.json records which document produced the
+folder, so a mismatch is caught before any artifact is written.
+"""
+import json
+import shutil
+from pathlib import Path
+from typing import Any, Dict, Optional
+
+from .output_layout import OutputLayout
+
+CONFLICT_MODES = ('error', 'overwrite', 'merge')
+
+# Document-level files written at the document root
+ROOT_ARTIFACTS = ('README.md', 'README.adoc', 'README.rst', 'manifest.json', 'keywords.json')
+
+
+class OutputConflictError(ValueError):
+    """Raised when the output directory holds a different document and on_conflict is 'error'"""
+
+
+def read_existing_manifest(layout: OutputLayout) -> Optional[dict]:
+    """The manifest already in the document root, or None"""
+    manifest_file = layout.document_root() / "manifest.json"
+    if not manifest_file.exists():
+        return None
+    try:
+        return json.loads(manifest_file.read_text(encoding='utf-8'))
+    except (OSError, ValueError):
+        return None
+
+
+def remove_previous_output(layout: OutputLayout, manifest: dict) -> int:
+    """
+    Delete the files a previous conversion listed in its manifest
+
+    Only files the manifest names (plus the document-level artifacts) are
+    removed, so layouts sharing directories between documents keep the
+    other documents intact. Directories left empty are removed.
+
+    Returns:
+        Number of files removed
+    """
+    root = layout.document_root()
+    base = layout.output_dir.resolve()
+    listed = manifest.get('sections', []) + manifest.get('images', []) + manifest.get('page_images', [])
+    candidates = [root / entry.get('file', '') for entry in listed if entry.get('file')]
+    candidates += [root / name for name in ROOT_ARTIFACTS]
+
+    removed = 0
+    parents = set()
+    for path in candidates:
+        resolved = path.resolve()
+        # Never follow a manifest entry outside the output directory
+        if base not in resolved.parents or not resolved.is_file():
+            continue
+        resolved.unlink()
+        parents.add(resolved.parent)
+        removed += 1
+
+    for directory in sorted(parents, key=lambda p: len(p.parts), reverse=True):
+        while directory != base and base in directory.parents:
+            try:
+                directory.rmdir()
+            except OSError:
+                break
+            directory = directory.parent
+
+    return removed
+
+
+def clean_managed_output(layout: OutputLayout) -> Dict[str, Any]:
+    """
+    Remove what a previous conversion generated before writing again (clean_output)
+
+    A 30-section conversion into a folder that held 50 sections would
+    otherwise leave 20 stale section files behind. Each artifact directory
+    (sections, chunked, images, tables) is deleted when it belongs to this
+    document alone. Where the layout puts artifacts in the document root
+    or another shared directory (the flat preset), only the files the
+    previous manifest lists are removed. Other files are never touched.
+
+    Returns:
+        Dictionary with directories (removed paths) and files (listed files removed)
+    """
+    root = layout.document_root().resolve()
+    base = layout.output_dir.resolve()
+    doc_id = layout.values['doc_id']
+
+    removed = {'directories': [], 'files': 0}
+    shared = False
+    for artifact_type in OutputLayout.ARTIFACT_TYPES:
+        directory = layout.directory_for(artifact_type).resolve()
+        if directory in (root, base) or not any(doc_id in part for part in directory.relative_to(base).parts):
+            shared = True
+            continue
+        if directory.is_dir() and not directory.is_symlink():
+            shutil.rmtree(directory)
+            removed['directories'].append(str(directory))
+
+    manifest = read_existing_manifest(layout)
+    if shared and manifest:
+        removed['files'] = remove_previous_output(layout, manifest)
+    return removed
+
+
+def resolve_output_conflict(layout: OutputLayout, document_id: str, source_file: str,
+                            on_conflict: Optional[str] = 'error', remove: bool = True) -> Optional[str]:
+    """
+    Check the output location for another document's conversion and apply on_conflict
+
+    Args:
+        layout: Output layout of the current conversion
+        document_id: Document ID of the current conversion
+        source_file: Source file name of the current conversion
+        on_conflict: 'error' (default), 'overwrite' (remove the previous
+            output first), or 'merge' (keep previous files alongside)
+        remove: Remove the previous output now on 'overwrite'; False leaves
+            it to the caller (a staged conversion removes it with
+            remove_previous_output once its own output is ready)
+
+    Returns:
+        A message describing the conflict and how it was handled, or None
+        when there is no conflict
+
+    Raises:
+        OutputConflictError: On a conflict with on_conflict='error'
+        ValueError: If on_conflict is not a known mode
+    """
+    on_conflict = on_conflict or 'error'
+    if on_conflict not in CONFLICT_MODES:
+        raise ValueError(f"on_conflict must be one of: {', '.join(CONFLICT_MODES)}")
+
+    manifest = read_existing_manifest(layout)
+    if not manifest or not manifest.get('document_id') or manifest['document_id'] == document_id:
+        return None
+
+    description = (
+        f"{layout.document_root()} already contains the conversion of "
+        f"'{manifest.get('source_file', 'unknown')}' (document_id {manifest['document_id']}), "
+        f"not '{source_file}' (document_id {document_id})"
+    )
+
+    if on_conflict == 'error':
+        raise OutputConflictError(
+            f"{description}. Choose a different output_dir, or set on_conflict to "
+            f"'overwrite' to replace it or 'merge' to keep both sets of files."
+        )
+
+    if on_conflict == 'overwrite':
+        if not remove:
+            return f"{description}; previous files are replaced once this conversion succeeds (on_conflict=overwrite)"
+        removed = remove_previous_output(layout, manifest)
+        return f"{description}; removed {removed} previous file(s) (on_conflict=overwrite)"
+
+    return f"{description}; previous files kept alongside the new ones (on_conflict=merge)"
@@ -0,0 +1,141 @@
+"""
+Output layout templating
+
+Controls where generated artifacts (sections, chunks, images, tables, API
+endpoints) land on disk using a small placeholder template such as
+``{output_dir}/{doc_id}/{artifact_type}``.
+"""
+import os
+import re
+from datetime import datetime
+from pathlib import Path
+from typing import Dict, Optional
+
+
+class OutputLayout:
+    """Resolves artifact directories from an output layout template"""
+
+    # Matches the layout that existed before templates were configurable
+    DEFAULT_TEMPLATE = "{output_dir}/{doc_id}/{artifact_type}"
+
+    # Named presets for common team layouts
+    PRESETS = {
+        'nested': "{output_dir}/{doc_id}/{artifact_type}",
+        'flat': "{output_dir}/{doc_id}",
+        'by_date': "{output_dir}/{date}/{doc_id}/{artifact_type}",
+        'by_type': "{output_dir}/{doc_type}/{doc_id}/{artifact_type}",
+        'by_artifact': "{output_dir}/{artifact_type}/{doc_id}",
+    }
+
+    PLACEHOLDERS = {'output_dir', 'doc_id', 'artifact_type', 'doc_type', 'date', 'year', 'month'}
+
+    ARTIFACT_TYPES = ('sections', 'chunked', 'images', 'tables', 'api-endpoints')
+
+    def __init__(self, template: Optional[str], output_dir: str, doc_id: str,
+                 doc_type: str = 'pdf', date: Optional[datetime] = None):
+        """
+        Initialize the layout
+
+        Args:
+            template: Layout template or preset name (None for the default layout)
+            output_dir: Base output directory supplied by the caller
+            doc_id: Sanitized document identifier
+            doc_type: Source document type (pdf, docx, markdown)
+            date: Date used for date-partitioned layouts (default: now)
+        """
+        template = template or self.DEFAULT_TEMPLATE
+        self.template = self.PRESETS.get(template, template)
+        self.validate_template(self.template)
+
+        self.output_dir = Path(output_dir)
+        date = date or datetime.now()
+        self.values = {
+            'output_dir': str(self.output_dir),
+            'doc_id': doc_id,
+            'doc_type': doc_type,
+            'date': date.strftime('%Y-%m-%d'),
+            'year': date.strftime('%Y'),
+            'month': date.strftime('%m'),
+        }
+
+        # Every resolved file path mapped to the artifact that claimed it
+        self._claimed: Dict[Path, str] = {}
+
+    @classmethod
+    def validate_template(cls, template: str) -> None:
+        """
+        Ensure a template only produces paths inside the output directory
+        that cannot collide across documents
+
+        Raises:
+            ValueError: If the template is unsafe or ambiguous
+        """
+        if not template or not template.strip():
+            raise ValueError("output_layout template must not be empty")
+
+        placeholders = re.findall(r'\{([^{}]*)\}', template)
+        unknown = sorted(set(placeholders) - cls.PLACEHOLDERS)
+        if unknown:
+            raise ValueError(
+                f"output_layout uses unknown placeholder(s): {', '.join(unknown)}. "
+                f"Supported: {', '.join(sorted(cls.PLACEHOLDERS))}"
+            )
+
+        if not template.startswith('{output_dir}'):
+            raise ValueError("output_layout must start with {output_dir}")
+
+        if '{doc_id}' not in template:
+            raise ValueError("output_layout must include {doc_id} so documents cannot overwrite each other")
+
+        literal = re.sub(r'\{[^{}]*\}', 'x', template[len('{output_dir}'):])
+        segments = [s for s in re.split(r'[\\/]', literal) if s]
+        if any(s in ('.', '..') or s.startswith('~') for s in segments):
+            raise ValueError("output_layout must not contain '.', '..' or '~' path segments")
+        if re.search(r'[<>:"|?*]', literal):
+            raise ValueError("output_layout contains characters that are unsafe in paths")
+
+    def _render(self, artifact_type: str) -> Path:
+        """Render the template for one artifact type and confirm it stays in output_dir"""
+        rendered = self.template.format(artifact_type=artifact_type, **self.values)
+        path = Path(rendered)
+
+        base = self.output_dir.resolve()
+        resolved = path.resolve()
+        if resolved != base and base not in resolved.parents:
+            raise ValueError(f"output_layout resolved outside the output directory: {path}")
+
+        return path
+
+    def document_root(self) -> Path:
+        """Directory holding document-level files (README.md, manifest)"""
+        return self._render('')
+
+    def directory_for(self, artifact_type: str) -> Path:
+        """Directory for a given artifact type"""
+        if artifact_type not in self.ARTIFACT_TYPES:
+            raise ValueError(f"Unknown artifact type: {artifact_type}")
+        return self._render(artifact_type)
+
+    def path_for(self, artifact_type: str, filename: str) -> Path:
+        """
+        Claim a file path for an artifact
+
+        Raises:
+            ValueError: If another artifact already claimed the same path
+        """
+        if artifact_type == 'root':
+            path = self.document_root() / filename
+        else:
+            path = self.directory_for(artifact_type) / filename
+
+        key = path.resolve()
+        owner = self._claimed.get(key)
+        if owner is not None and owner != f"{artifact_type}:{filename}":
+            raise ValueError(f"output_layout produces colliding paths: {path} ({owner} and {artifact_type}:{filename})")
+        self._claimed[key] = f"{artifact_type}:{filename}"
+        return path
+
+    def relative_path(self, path: Path, start: Optional[Path] = None) -> str:
+        """Relative link from a directory (default: document root) to a generated file"""
+        start = start or self.document_root()
+        return Path(os.path.relpath(path, start)).as_posix()
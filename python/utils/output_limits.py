@@ -0,0 +1,99 @@
+"""
+Limits on how much a conversion may write
+
+A pathological PDF (thousands of full-page scans, or one embedded image
+of several gigabytes) can fill a disk. Three limits stop that:
+
+- max_output_bytes: everything one conversion writes (MAX_OUTPUT_MB, default 2 GB)
+- max_images_bytes: all extracted images together (MAX_IMAGES_MB, default 1 GB)
+- max_image_bytes: one extracted image (MAX_IMAGE_MB, default 50 MB)
+
+Passing the first two aborts the conversion, which removes what it wrote,
+and the error names the limit so it can be raised deliberately. An image
+over max_image_bytes is skipped with a warning instead: one oversized
+picture should not cost the rest of the document. Tool arguments are in
+bytes, environment variables in megabytes; 0 turns a limit off.
+"""
+import os
+from pathlib import Path
+from typing import Any, Iterable, Optional, Set
+
+MAX_OUTPUT_BYTES = 'max_output_bytes'
+MAX_IMAGES_BYTES = 'max_images_bytes'
+MAX_IMAGE_BYTES = 'max_image_bytes'
+
+# Environment variable (megabytes) and default for each limit
+LIMIT_DEFAULTS = {
+    MAX_OUTPUT_BYTES: ('MAX_OUTPUT_MB', 2048),
+    MAX_IMAGES_BYTES: ('MAX_IMAGES_MB', 1024),
+    MAX_IMAGE_BYTES: ('MAX_IMAGE_MB', 50),
+}
+
+OUTPUT_LIMIT_EXCEEDED = 'output_limit_exceeded'
+
+# Seconds a file's timestamp may trail the clock (coarse filesystem timestamps)
+MTIME_SLACK = 2.0
+
+
+class OutputLimitExceeded(Exception):
+    """A conversion wrote more than one of its limits allows"""
+
+    def __init__(self, limit: str, limit_bytes: int, written: int):
+        self.limit = limit
+        self.limit_bytes = limit_bytes
+        self.written = written
+        self.code = OUTPUT_LIMIT_EXCEEDED
+        env_var = LIMIT_DEFAULTS[limit][0]
+        super().__init__(f"Output limit {limit} exceeded: {written:,} bytes written, limit {limit_bytes:,} "
+                         f"(pass a larger {limit} or set {env_var} to raise it; error_code: {self.code})")
+
+
+def resolve_limit(name: str, value: Any = None) -> Optional[int]:
+    """
+    A limit in bytes: the option value, else its environment variable, else the default
+
+    Returns:
+        Bytes, or None when the limit is turned off (0)
+
+    Raises:
+        ValueError: If the value is not a non-negative number
+    """
+    env_var, default_mb = LIMIT_DEFAULTS[name]
+    source, multiplier = name, 1
+    if value is None:
+        value = os.environ.get(env_var, default_mb)
+        source, multiplier = env_var, 1024 * 1024
+    try:
+        if isinstance(value, bool):
+            raise TypeError
+        limit = float(value)
+    except (TypeError, ValueError):
+        raise ValueError(f"{source} must be a number, got {value!r}")
+    if limit < 0:
+        raise ValueError(f"{source} must not be negative, got {value!r}")
+    return int(limit * multiplier) or None
+
+
+def check_limit(name: str, limit: Optional[int], written: int) -> None:
+    """Raise OutputLimitExceeded if written passed the limit (no-op when it is off)"""
+    if limit is not None and written > limit:
+        raise OutputLimitExceeded(name, limit, written)
+
+
+def bytes_written_since(directories: Iterable[Path], since: float) -> int:
+    """Total size of the files in directories modified at or after since (a timestamp)"""
+    seen: Set[Path] = set()
+    total = 0
+    for directory in directories:
+        directory = Path(directory)
+        if not directory.is_dir():
+            continue
+        for path in directory.rglob('*'):
+            resolved = path.resolve()
+            if resolved in seen or not resolved.is_file():
+                continue
+            seen.add(resolved)
+            stat = resolved.stat()
+            if stat.st_mtime >= since - MTIME_SLACK:
+                total += stat.st_size
+    return total
@@ -0,0 +1,73 @@
+"""
+One conversion at a time per output directory
+
+Two conversions writing to the same output_dir at once interleave their
+writes: one run's sections overwrite the other's half-way through, and
+clean_output or on_conflict can delete files the other run just wrote.
+OutputLocks keeps one lock per output directory (resolved, so ./docs and
+docs are the same directory); a conversion waits for the one already
+writing there, in arrival order, while conversions to other directories
+run in parallel.
+
+A lock lives only while someone holds or waits for it, so the map does not
+grow with every directory ever used.
+"""
+import asyncio
+import os
+from contextlib import asynccontextmanager
+from pathlib import Path
+from typing import AsyncIterator, Dict, Union
+
+
+class OutputLocks:
+    """Keyed asyncio locks, one per output directory (use from the event loop thread)"""
+
+    def __init__(self):
+        self._locks: Dict[str, asyncio.Lock] = {}
+        # Holders and waiters per key; the lock is dropped when this reaches 0
+        self._users: Dict[str, int] = {}
+
+    @staticmethod
+    def key(output_dir: Union[str, Path]) -> str:
+        return os.path.normcase(str(Path(output_dir).resolve()))
+
+    def locked(self, output_dir: Union[str, Path]) -> bool:
+        """Whether a conversion is writing to output_dir now"""
+        lock = self._locks.get(self.key(output_dir))
+        return bool(lock and lock.locked())
+
+    def keys(self):
+        """Directories being written to or waited on now"""
+        return list(self._locks)
+
+    async def acquire(self, output_dir: Union[str, Path]) -> str:
+        """Wait for output_dir's lock; returns the key to release (nothing held if cancelled while waiting)"""
+        key = self.key(output_dir)
+        lock = self._locks.setdefault(key, asyncio.Lock())
+        self._users[key] = self._users.get(key, 0) + 1
+        try:
+            await lock.acquire()
+        except BaseException:
+            self._forget(key)
+            raise
+        return key
+
+    def release(self, key: str) -> None:
+        """Release a lock taken with acquire (e.g. from a future's done callback)"""
+        self._locks[key].release()
+        self._forget(key)
+
+    def _forget(self, key: str) -> None:
+        self._users[key] -= 1
+        if not self._users[key]:
+            del self._users[key]
+            del self._locks[key]
+
+    @asynccontextmanager
+    async def hold(self, output_dir: Union[str, Path]) -> AsyncIterator[None]:
+        """Hold output_dir's lock for the body of an async with"""
+        key = await self.acquire(output_dir)
+        try:
+            yield
+        finally:
+            self.release(key)
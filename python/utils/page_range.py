@@ -0,0 +1,67 @@
+"""
+Page range selection
+
+Converting pages 100-140 of a 900-page manual shouldn't read the other 860.
+A page range names the pages to process as comma-separated pages and
+inclusive ranges ("100-140", "1,5,9-12"); extraction then iterates only
+those pages.
+
+A spec can name far more pages than any document has ("1-2000000000"), so
+it is expanded into page numbers only once the page count is known and
+every range has been checked against it; before the PDF is opened only its
+syntax is validated (page_range_spans).
+"""
+import re
+from typing import List, Optional, Tuple
+
+RANGE_PART = re.compile(r'^(\d+)(?:\s*-\s*(\d+))?$')
+
+
+def page_range_spans(spec: str) -> List[Tuple[int, int]]:
+    """
+    Parse a page range into its (start, end) ranges without expanding them
+
+    Raises:
+        ValueError: If the spec is malformed or a range runs backwards
+    """
+    text = str(spec).strip()
+    if not text:
+        raise ValueError("page_range is empty (expected pages like '100-140' or '1,5,9-12')")
+
+    spans = []
+    for part in text.split(','):
+        match = RANGE_PART.match(part.strip())
+        if not match:
+            raise ValueError(f"page_range: '{part.strip()}' is not a page or range "
+                             f"(expected pages like '100-140' or '1,5,9-12')")
+        start = int(match.group(1))
+        end = int(match.group(2)) if match.group(2) else start
+        if start < 1:
+            raise ValueError("page_range: pages are numbered from 1")
+        if end < start:
+            raise ValueError(f"page_range: '{part.strip()}' ends before it starts")
+        spans.append((start, end))
+    return spans
+
+
+def parse_page_range(spec: str, page_count: Optional[int] = None) -> List[int]:
+    """
+    Parse a page range into sorted, de-duplicated 1-based page numbers
+
+    Args:
+        spec: Pages and inclusive ranges, e.g. "100-140" or "1,5,9-12"
+        page_count: Pages in the document; pages past the end are rejected
+            before any range is expanded. Pass it whenever the spec comes from
+            a client, or an oversized range is expanded in full
+
+    Raises:
+        ValueError: If the spec is malformed, a range runs backwards, or a page is out of range
+    """
+    spans = page_range_spans(spec)
+    last = max(end for _, end in spans)
+    if page_count is not None and last > page_count:
+        raise ValueError(f"page_range: page {last} is past the end of the document ({page_count} pages)")
+    pages = set()
+    for start, end in spans:
+        pages.update(range(start, end + 1))
+    return sorted(pages)
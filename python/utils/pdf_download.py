@@ -0,0 +1,211 @@
+"""
+Downloading PDFs given as http(s) URLs
+
+Agents often receive links rather than files. A pdf_path that is an
+http(s) URL is downloaded to a temporary file before conversion, and the
+file is removed afterwards. Redirects are followed; the response must be a
+PDF (by content type, falling back to the %PDF- signature for servers that
+send application/octet-stream) and no larger than MAX_DOWNLOAD_MB.
+
+The server fetches whatever URL a client names, so hosts that resolve to
+loopback, private, link-local, or other non-public addresses are refused,
+at the first request and at every redirect, and redirects may only go to
+http(s). ALLOW_PRIVATE_DOWNLOADS=1 lifts the address check for servers
+that are meant to fetch from an intranet. A download that takes longer than
+MAX_DOWNLOAD_SECONDS in total is abandoned, however steadily the server
+keeps sending.
+"""
+import ipaddress
+import os
+import re
+import socket
+import time
+import urllib.error
+import urllib.request
+from pathlib import Path
+from typing import Optional
+from urllib.parse import unquote, urlparse
+
+DEFAULT_MAX_DOWNLOAD_MB = 100
+DEFAULT_DOWNLOAD_TIMEOUT = 60
+DEFAULT_MAX_DOWNLOAD_SECONDS = 300
+
+# Content types servers use for PDFs; anything else is rejected
+PDF_CONTENT_TYPES = ('application/pdf', 'application/x-pdf', 'application/octet-stream', 'binary/octet-stream')
+
+CHUNK_SIZE = 64 * 1024
+
+
+class PDFDownloadError(ValueError):
+    """The URL could not be downloaded as a PDF"""
+
+
+def is_pdf_url(value: str) -> bool:
+    """Whether a pdf_path is an http(s) URL"""
+    return isinstance(value, str) and value.lower().startswith(('http://', 'https://'))
+
+
+def max_download_bytes() -> int:
+    """Largest download accepted (MAX_DOWNLOAD_MB env, default 100 MB)"""
+    try:
+        megabytes = float(os.environ.get('MAX_DOWNLOAD_MB', DEFAULT_MAX_DOWNLOAD_MB))
+    except ValueError:
+        megabytes = DEFAULT_MAX_DOWNLOAD_MB
+    return int(max(1, megabytes) * 1024 * 1024)
+
+
+def download_timeout() -> float:
+    """Seconds to wait for the server before giving up (DOWNLOAD_TIMEOUT env, default 60)"""
+    try:
+        return max(1.0, float(os.environ.get('DOWNLOAD_TIMEOUT', DEFAULT_DOWNLOAD_TIMEOUT)))
+    except ValueError:
+        return float(DEFAULT_DOWNLOAD_TIMEOUT)
+
+
+def max_download_seconds() -> float:
+    """Seconds a whole download may take (MAX_DOWNLOAD_SECONDS env, default 300)"""
+    try:
+        return max(1.0, float(os.environ.get('MAX_DOWNLOAD_SECONDS', DEFAULT_MAX_DOWNLOAD_SECONDS)))
+    except ValueError:
+        return float(DEFAULT_MAX_DOWNLOAD_SECONDS)
+
+
+def private_downloads_allowed() -> bool:
+    """Whether URLs may resolve to non-public addresses (ALLOW_PRIVATE_DOWNLOADS env)"""
+    return os.environ.get('ALLOW_PRIVATE_DOWNLOADS', '').strip().lower() in ('1', 'true', 'yes')
+
+
+def check_download_url(url: str, allow_private: bool = False) -> None:
+    """
+    Refuse a URL that isn't http(s) or whose host resolves to a non-public address
+
+    Every address the host resolves to must be public: a name with one
+    public and one loopback address could be connected to either.
+
+    Raises:
+        PDFDownloadError: Wrong scheme, unresolvable host, or non-public address
+    """
+    parsed = urlparse(url)
+    if parsed.scheme.lower() not in ('http', 'https') or not parsed.hostname:
+        raise PDFDownloadError(f"Download refused: only http(s) URLs can be downloaded, not {url}")
+    if allow_private:
+        return
+    try:
+        addresses = {info[4][0] for info in socket.getaddrinfo(parsed.hostname, parsed.port or None)}
+    except (socket.gaierror, UnicodeError) as e:
+        raise PDFDownloadError(f"Download failed: could not resolve {parsed.hostname} ({e})")
+    for text in sorted(addresses):
+        address = ipaddress.ip_address(text.split('%')[0])
+        if address.version == 6 and address.ipv4_mapped:
+            address = address.ipv4_mapped
+        if not address.is_global or address.is_multicast:
+            raise PDFDownloadError(f"Download refused: {parsed.hostname} resolves to {address}, which is not a "
+                                   f"public address (set ALLOW_PRIVATE_DOWNLOADS=1 to allow intranet hosts)")
+
+
+class _CheckedRedirectHandler(urllib.request.HTTPRedirectHandler):
+    """Follows redirects only to http(s) URLs that pass check_download_url"""
+
+    def __init__(self, allow_private: bool):
+        super().__init__()
+        self.allow_private = allow_private
+
+    def redirect_request(self, req, fp, code, msg, headers, newurl):
+        check_download_url(newurl, self.allow_private)
+        return super().redirect_request(req, fp, code, msg, headers, newurl)
+
+
+def download_filename(url: str, content_disposition: Optional[str] = None) -> str:
+    """
+    File name for a downloaded PDF (it names the conversion's output folder)
+
+    Taken from Content-Disposition, else the last path segment of the URL,
+    else "document.pdf"; always ending in .pdf.
+    """
+    name = ''
+    if content_disposition:
+        match = re.search(r'filename\*?=(?:UTF-8\'\')?"?([^";]+)"?', content_disposition, re.IGNORECASE)
+        if match:
+            name = unquote(match.group(1))
+    if not name:
+        name = unquote(urlparse(url).path.rstrip('/').rsplit('/', 1)[-1])
+    name = re.sub(r'[<>:"/\\|?*\x00-\x1f]', '_', Path(name).name).strip(' .')
+    if not name:
+        name = 'document'
+    if not name.lower().endswith('.pdf'):
+        name += '.pdf'
+    return name
+
+
+def download_pdf(url: str, directory: Path, max_bytes: Optional[int] = None,
+                 timeout: Optional[float] = None, max_seconds: Optional[float] = None,
+                 allow_private: Optional[bool] = None) -> Path:
+    """
+    Download a PDF into directory
+
+    Args:
+        url: http(s) URL of the PDF
+        directory: Where to save it (normally a temporary directory)
+        max_bytes: Size limit (default: max_download_bytes())
+        timeout: Socket timeout in seconds (default: download_timeout())
+        max_seconds: Limit on the whole download (default: max_download_seconds())
+        allow_private: Allow non-public addresses (default: private_downloads_allowed())
+
+    Returns:
+        Path of the downloaded file
+
+    Raises:
+        PDFDownloadError: Refused URL, HTTP error, unreachable host, too slow, too large, or not a PDF
+    """
+    max_bytes = max_bytes or max_download_bytes()
+    max_seconds = max_seconds or max_download_seconds()
+    deadline = time.monotonic() + max_seconds
+    if allow_private is None:
+        allow_private = private_downloads_allowed()
+    check_download_url(url, allow_private)
+    opener = urllib.request.build_opener(_CheckedRedirectHandler(allow_private))
+    request = urllib.request.Request(url, headers={'User-Agent': 'mcp-document-markdown', 'Accept': 'application/pdf'})
+    try:
+        # No single wait may outlast the whole download either
+        response = opener.open(request, timeout=min(timeout or download_timeout(), max_seconds))
+    except urllib.error.HTTPError as e:
+        raise PDFDownloadError(f"Download failed: {url} returned HTTP {e.code} {e.reason}")
+    except (urllib.error.URLError, OSError) as e:
+        reason = getattr(e, 'reason', e)
+        raise PDFDownloadError(f"Download failed: could not reach {url} ({reason})")
+
+    with response:
+        final_url = response.geturl()
+        content_type = (response.headers.get('Content-Type') or '').split(';')[0].strip().lower()
+        if content_type and content_type not in PDF_CONTENT_TYPES:
+            raise PDFDownloadError(f"Not a PDF: {final_url} returned content type {content_type}")
+        length = response.headers.get('Content-Length')
+        if length and length.isdigit() and int(length) > max_bytes:
+            raise PDFDownloadError(f"Download too large: {int(length):,} bytes (limit {max_bytes:,}; "
+                                   f"set MAX_DOWNLOAD_MB to raise it)")
+
+        pdf_file = Path(directory) / download_filename(final_url, response.headers.get('Content-Disposition'))
+        received = 0
+        with open(pdf_file, 'wb') as f:
+            while True:
+                try:
+                    # read1 returns what has arrived, so a slow server can't hold one read open
+                    chunk = response.read1(CHUNK_SIZE)
+                except OSError as e:
+                    raise PDFDownloadError(f"Download failed: {final_url} stopped sending ({e})")
+                if not chunk:
+                    break
+                if time.monotonic() > deadline:
+                    raise PDFDownloadError(f"Download took longer than {max_seconds:g}s: {final_url} "
+                                           f"(set MAX_DOWNLOAD_SECONDS to raise it)")
+                received += len(chunk)
+                if received > max_bytes:
+                    raise PDFDownloadError(f"Download too large: more than {max_bytes:,} bytes "
+                                           f"(set MAX_DOWNLOAD_MB to raise it)")
+                f.write(chunk)
+
+    with open(pdf_file, 'rb') as f:
+        if b'%PDF-' not in f.read(1024):
+            raise PDFDownloadError(f"Not a PDF: {final_url} did not return a PDF file"
+                                   f"{f' (content type {content_type})' if content_type else ''}")
+    return pdf_file
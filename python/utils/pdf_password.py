@@ -0,0 +1,86 @@
+"""
+Opening password-protected PDFs
+
+An encrypted PDF opened without its password reads as empty pages rather
+than failing, so encryption is checked before extraction and reported with
+an error code: password_required when no password was given, and
+wrong_password when it does not open the document. PDFs encrypted only
+against editing (no open password) need nothing.
+
+Passwords are never included in messages or logs.
+"""
+from pathlib import Path
+from typing import Optional
+
+PASSWORD_REQUIRED = 'password_required'
+WRONG_PASSWORD = 'wrong_password'
+
+
+class PDFPasswordError(ValueError):
+    """Encrypted PDF without the password that opens it"""
+
+    def __init__(self, pdf_path: str, code: str):
+        self.code = code
+        name = Path(pdf_path).name
+        if code == PASSWORD_REQUIRED:
+            reason = f"{name} is encrypted; pass its password to open it"
+        else:
+            reason = f"{name} is encrypted and the password does not open it"
+        super().__init__(f"{reason} (error_code: {code})")
+
+
+def check_pdf_password(pdf_path: str, password: Optional[str] = None) -> bool:
+    """
+    Check that a PDF can be read with the given password
+
+    Returns:
+        True when the PDF needs a password and this one opens it, False when
+        it needs none
+
+    Raises:
+        PDFPasswordError: Password missing or wrong
+    """
+    import pypdf
+
+    reader = pypdf.PdfReader(pdf_path, strict=False)
+    if not reader.is_encrypted:
+        return False
+    # An empty user password (edit restrictions only) opens without asking
+    if reader.decrypt('') != pypdf.PasswordType.NOT_DECRYPTED:
+        return False
+    if not password:
+        raise PDFPasswordError(pdf_path, PASSWORD_REQUIRED)
+    if reader.decrypt(password) == pypdf.PasswordType.NOT_DECRYPTED:
+        raise PDFPasswordError(pdf_path, WRONG_PASSWORD)
+    return True
+
+
+def decrypt_pdf(pdf_path: str, password: Optional[str], output_path: str) -> bool:
+    """
+    Write an unencrypted copy of a password-protected PDF
+
+    Args:
+        pdf_path: PDF to open
+        password: User or owner password (None when not supplied)
+        output_path: Where to save the copy
+
+    Returns:
+        True when a copy was written, False when the PDF opens without a password
+
+    Raises:
+        PDFPasswordError: Password missing or wrong
+    """
+    import fitz
+
+    doc = fitz.open(pdf_path)
+    try:
+        if not doc.needs_pass:
+            return False
+        if not password:
+            raise PDFPasswordError(pdf_path, PASSWORD_REQUIRED)
+        if not doc.authenticate(password):
+            raise PDFPasswordError(pdf_path, WRONG_PASSWORD)
+        doc.save(output_path, encryption=fitz.PDF_ENCRYPT_NONE)
+        return True
+    finally:
+        doc.close()
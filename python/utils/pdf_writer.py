@@ -0,0 +1,32 @@
+"""
+Minimal PDF writer for generated documents
+
+The self-test sample and the test fixtures (tests/fixtures/make_*_pdf.py)
+are small hand-built PDFs. Each is a list of object bodies, numbered from 1
+in order with object 1 the catalog; write_pdf serializes them behind a
+PDF 1.7 header and adds the cross-reference table and trailer.
+"""
+from typing import List
+
+PDF_HEADER = b"%PDF-1.7\n%\xe2\xe3\xcf\xd3\n"
+
+
+def stream(content: bytes, entries: str = "") -> bytes:
+    """A stream object body holding content; entries go in its dictionary ahead of /Length"""
+    prefix = f"{entries} " if entries else ""
+    return f"<< {prefix}/Length {len(content)} >>\nstream\n".encode() + content + b"\nendstream"
+
+
+def write_pdf(objects: List[bytes]) -> bytes:
+    """A complete PDF of objects, numbered from 1, with object 1 as the root"""
+    data = bytearray(PDF_HEADER)
+    offsets = []
+    for number, body in enumerate(objects, 1):
+        offsets.append(len(data))
+        data += f"{number} 0 obj\n".encode() + body + b"\nendobj\n"
+    xref = len(data)
+    data += f"xref\n0 {len(objects) + 1}\n0000000000 65535 f \n".encode()
+    data += b"".join(f"{offset:010d} 00000 n \n".encode() for offset in offsets)
+    data += f"trailer\n<< /Size {len(objects) + 1} /Root 1 0 R >>\n".encode()
+    data += f"startxref\n{xref}\n%%EOF\n".encode()
+    return bytes(data)
@@ -0,0 +1,180 @@
+"""
+Pre-flight checks before converting a PDF
+
+validate_pdf answers "can this be converted, and how big a job is it?"
+without extracting anything: the %PDF- signature, whether pypdf (or, for a
+damaged cross-reference table, PyMuPDF) opens the file, page count,
+encryption, and whether a sample of pages has a text layer. Pages without
+one are usually scans and convert to little or no text.
+
+The time estimate is a rough bucket, not a prediction: pages, weighted up
+for image-heavy files, against fixed thresholds.
+"""
+import re
+import threading
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+from .cancellation import check_cancelled
+from .pdf_password import PDFPasswordError, PASSWORD_REQUIRED, check_pdf_password
+
+PDF_SIGNATURE = re.compile(rb'%PDF-(\d+\.\d+)?')
+# Readers accept leading junk before the signature within the first KB
+SIGNATURE_WINDOW = 1024
+
+# Pages checked for a text layer, spread over the document
+TEXT_SAMPLE_PAGES = 10
+# Fewer characters than this and a page counts as having no text layer
+MIN_PAGE_CHARS = 20
+# Below this share of sampled pages with text, the document counts as scanned
+SCANNED_COVERAGE = 0.1
+
+# Average page size above which pages count double in the time estimate
+HEAVY_PAGE_BYTES = 200 * 1024
+# (bucket, most weighted pages), smallest first; the last bucket is open-ended
+TIME_BUCKETS = [
+    ('under_10_seconds', 30),
+    ('under_1_minute', 200),
+    ('under_5_minutes', 1000),
+    ('over_5_minutes', None),
+]
+
+
+def read_signature(pdf_path: str) -> Optional[str]:
+    """The PDF version from the %PDF-x.y header ('1.7'), or None when there is no signature"""
+    with open(pdf_path, 'rb') as f:
+        head = f.read(SIGNATURE_WINDOW)
+    match = PDF_SIGNATURE.search(head)
+    if not match:
+        return None
+    return (match.group(1) or b'').decode('ascii')
+
+
+def sample_page_indexes(page_count: int, limit: int = TEXT_SAMPLE_PAGES) -> List[int]:
+    """Up to limit 0-based page indexes evenly spread over the document, first and last included"""
+    if page_count <= limit:
+        return list(range(page_count))
+    step = (page_count - 1) / (limit - 1)
+    return sorted({round(i * step) for i in range(limit)})
+
+
+def text_layer_summary(page_chars: List[int]) -> Dict[str, Any]:
+    """Text layer coverage from the character counts of sampled pages"""
+    with_text = sum(1 for chars in page_chars if chars >= MIN_PAGE_CHARS)
+    coverage = with_text / len(page_chars) if page_chars else 0.0
+    return {
+        'sampled_pages': len(page_chars),
+        'pages_with_text': with_text,
+        'coverage': round(coverage, 2),
+        'scanned': bool(page_chars) and coverage < SCANNED_COVERAGE
+    }
+
+
+def time_bucket(page_count: int, file_size: int) -> str:
+    """Rough conversion time bucket for a document"""
+    avg_page_bytes = file_size / page_count if page_count else 0
+    weighted = page_count * (2 if avg_page_bytes > HEAVY_PAGE_BYTES else 1)
+    for bucket, most in TIME_BUCKETS:
+        if most is None or weighted <= most:
+            return bucket
+    return TIME_BUCKETS[-1][0]
+
+
+def validate_pdf(pdf_path: str, password: Optional[str] = None,
+                 cancel_event: Optional[threading.Event] = None) -> Dict[str, Any]:
+    """
+    Check a PDF can be opened and size up its conversion
+
+    Problems with the file are reported in the result (valid False, error,
+    error_code) rather than raised, so callers always get the same fields.
+
+    Returns:
+        Dictionary with file, file_size_bytes, valid, pdf_version,
+        page_count, encrypted, needs_password, openable, text_layer,
+        scanned, estimated_time, warnings, error, and error_code
+    """
+    path = Path(pdf_path)
+    result: Dict[str, Any] = {
+        'file': path.name,
+        'file_size_bytes': path.stat().st_size,
+        'valid': False,
+        'pdf_version': None,
+        'page_count': None,
+        'encrypted': False,
+        'needs_password': False,
+        'openable': False,
+        'text_layer': None,
+        'scanned': None,
+        'estimated_time': None,
+        'warnings': [],
+        'error': None,
+        'error_code': None
+    }
+
+    result['pdf_version'] = read_signature(pdf_path)
+    if result['pdf_version'] is None:
+        result['error'] = f"{path.name} is not a PDF (no %PDF- signature in the first {SIGNATURE_WINDOW} bytes)"
+        result['error_code'] = 'not_a_pdf'
+        return result
+
+    import fitz
+    import pypdf
+
+    try:
+        reader = pypdf.PdfReader(pdf_path, strict=False)
+        result['encrypted'] = reader.is_encrypted
+        result['valid'] = True
+    except Exception as e:
+        result['warnings'].append(f"pypdf could not parse the file ({e}); PyMuPDF will try to repair it")
+
+    try:
+        doc = fitz.open(pdf_path)
+    except Exception as e:
+        result['valid'] = False
+        result['error'] = f"{path.name} could not be opened: {e}"
+        result['error_code'] = 'unreadable'
+        return result
+
+    try:
+        result['valid'] = True
+        result['encrypted'] = result['encrypted'] or bool(doc.is_encrypted)
+        if result['encrypted']:
+            try:
+                result['needs_password'] = check_pdf_password(pdf_path, password)
+            except PDFPasswordError as e:
+                result['needs_password'] = True
+                result['error'] = str(e)
+                result['error_code'] = e.code
+                if e.code == PASSWORD_REQUIRED:
+                    result['warnings'].append("Pass the password to convert this PDF; without it pages read as empty")
+                return result
+            if doc.needs_pass and not doc.authenticate(password or ''):
+                result['error'] = f"{path.name} is encrypted and could not be opened"
+                result['error_code'] = 'unreadable'
+                return result
+        result['openable'] = True
+        result['page_count'] = doc.page_count
+        if not doc.page_count:
+            result['warnings'].append("The PDF has no pages")
+            return result
+
+        page_chars = []
+        for index in sample_page_indexes(doc.page_count):
+            check_cancelled(cancel_event)
+            page_chars.append(len(doc[index].get_text().strip()))
+    finally:
+        doc.close()
+
+    result['text_layer'] = text_layer_summary(page_chars)
+    result['scanned'] = result['text_layer']['scanned']
+    if result['scanned']:
+        result['warnings'].append(
+            f"{result['text_layer']['sampled_pages'] - result['text_layer']['pages_with_text']} of "
+            f"{result['text_layer']['sampled_pages']} sampled pages have no text layer; the PDF looks scanned "
+            "and will convert to little text (OCR is not part of the conversion yet, see features_status)")
+    elif result['text_layer']['coverage'] < 1:
+        result['warnings'].append(
+            f"Only {result['text_layer']['pages_with_text']} of {result['text_layer']['sampled_pages']} sampled "
+            "pages have a text layer; some pages may be scanned images")
+    result['estimated_time'] = time_bucket(result['page_count'], result['file_size_bytes'])
+    return result
@@ -0,0 +1,68 @@
+"""
+Structured progress lines
+
+Converters print one line per unit of work in a fixed format,
+``PROGRESS <done>/<total> <message>``, alongside their free-form output.
+The MCP server picks these lines out of the captured output and forwards
+them to the client as ``notifications/progress`` while the call runs.
+
+Every other line is forwarded as an MCP log message
+(``notifications/message``). Its level comes from a prefix: lines starting
+with ``⚠️`` or ``Warning:`` are warnings, lines starting with ``❌``,
+``Error:``, ``Conversion failed``, or ``Traceback`` are errors, and the rest
+are info.
+"""
+import re
+from typing import Any, Dict, Optional
+
+PROGRESS_LINE = re.compile(r'^PROGRESS (\d+)/(\d+)(?: (.*))?$')
+
+# Line prefixes marking diagnostics, checked in order
+LOG_LEVEL_PREFIXES = (
+    ('⚠️', 'warning'),
+    ('Warning:', 'warning'),
+    ('❌', 'error'),
+    ('Error:', 'error'),
+    ('Conversion failed', 'error'),
+    ('Traceback', 'error'),
+)
+
+
+def format_progress_line(done: int, total: int, message: str = '') -> str:
+    """A progress line as printed by converters"""
+    return f"PROGRESS {done}/{total} {message}".rstrip()
+
+
+def parse_progress_line(line: str) -> Optional[Dict[str, Any]]:
+    """
+    Parse a progress line
+
+    Returns:
+        Dictionary with progress, total, and message, or None if the line
+        is ordinary output
+    """
+    match = PROGRESS_LINE.match(line.strip())
+    if not match:
+        return None
+    return {
+        'progress': int(match.group(1)),
+        'total': int(match.group(2)),
+        'message': match.group(3) or ''
+    }
+
+
+def parse_log_line(line: str) -> Optional[Dict[str, str]]:
+    """
+    Level and text of an output line for MCP logging
+
+    Returns:
+        Dictionary with level (info, warning, or error) and message, or None
+        for blank lines and progress lines (those are sent as progress)
+    """
+    text = line.strip()
+    if not text or parse_progress_line(text):
+        return None
+    for prefix, level in LOG_LEVEL_PREFIXES:
+        if text.startswith(prefix):
+            return {'level': level, 'message': text}
+    return {'level': 'info', 'message': text}
@@ -0,0 +1,81 @@
+"""
+Section whitespace trimming and empty section handling
+
+Heading-dense documents produce sections whose body is empty, or is just
+the heading line repeated from the page text. Those sections are merged
+forward: the next section records the heading so nothing is lost, and a
+heading-only section at the very end is dropped.
+
+Only a section with a heading (title) and no body at all qualifies. An
+image or table reference in the content is body, and so is an image or
+table on one of the section's pages (media_pages) - it is attached to the
+section later, so a text-empty figure or table page keeps its section.
+"""
+import re
+from typing import Any, Collection, Dict, List, Tuple
+
+from .section_order import section_pages
+
+# Markdown image and table lines count as body
+MEDIA_REFERENCE = re.compile(r'!\[[^\]]*\]\(|^\s*\|', re.M)
+
+
+def trim_section_content(content: str) -> str:
+    """Strip leading and trailing whitespace (blank lines left by page markers included)"""
+    return (content or '').strip()
+
+
+def is_heading_only(section: Dict[str, Any], media_pages: Collection[int] = ()) -> bool:
+    """True if a section has a heading and no body beyond it (no text, image, or table)"""
+    title = re.sub(r'\s+', ' ', section.get('title') or '').strip().lower()
+    if not title:
+        return False
+    if any(page in media_pages for page in section_pages(section)):
+        return False
+    content = trim_section_content(section.get('content', ''))
+    if not content:
+        return True
+    if MEDIA_REFERENCE.search(content):
+        return False
+    first, _, rest = content.partition('\n')
+    first = re.sub(r'^#+\s*', '', re.sub(r'\s+', ' ', first)).strip().lower()
+    return first == title and not rest.strip()
+
+
+def merge_empty_sections(sections: List[Dict[str, Any]],
+                         media_pages: Collection[int] = ()) -> Tuple[List[Dict[str, Any]], List[Dict[str, Any]]]:
+    """
+    Trim every section and fold heading-only sections into the next one
+
+    Merged headings are listed on the receiving section under
+    'merged_headings' (in document order), and when both list their
+    'pages', the merged pages join the receiving section's.
+
+    Args:
+        sections: Sections in document order
+        media_pages: Pages holding an image or table (see is_heading_only)
+
+    Returns:
+        (kept sections, report) - report entries have title, action
+        ('merged' or 'dropped'), and into (receiving section title or None)
+    """
+    for section in sections:
+        section['content'] = trim_section_content(section.get('content', ''))
+
+    kept: List[Dict[str, Any]] = []
+    report: List[Dict[str, Any]] = []
+    pending: List[Dict[str, Any]] = []
+    for section in sections:
+        if is_heading_only(section, media_pages):
+            pending.append(section)
+            continue
+        if pending:
+            section['merged_headings'] = [p.get('title', '') for p in pending] + section.get('merged_headings', [])
+            if section.get('pages') and all(p.get('pages') for p in pending):
+                section['pages'] = sorted(set(section['pages']).union(*(p['pages'] for p in pending)))
+            report += [{'title': p.get('title', ''), 'action': 'merged', 'into': section.get('title', '')} for p in pending]
+            pending = []
+        kept.append(section)
+
+    report += [{'title': p.get('title', ''), 'action': 'dropped', 'into': None} for p in pending]
+    return kept, report
@@ -0,0 +1,73 @@
+"""
+Section file names
+
+PDF, Word, and markdown conversions all name their section files here, so
+one section_naming option means the same thing for every document type:
+
+- numbered_slug (default): 01-overview.md, 02-authentication.md
+- numbered: 01.md, 02.md
+- slug: overview.md, authentication.md
+- hashed: 3f9a0c1d2e4b.md, from the section's title and content, so a
+  section keeps its name across reconversions while it is unchanged
+
+Numbers are zero-padded to the width of the section count (at least two
+digits), so the files sort in document order alphabetically even past 99
+sections. The slug is the caller's: the PDF converter uses the section's
+type (overview, authentication, ...) or title, the others the title.
+Names are unique within the sections directory (-2, -3, ... suffixes).
+"""
+import hashlib
+from typing import List, Optional, Sequence
+
+from .file_utils import FileUtils
+
+SECTION_NAMINGS = ('numbered_slug', 'numbered', 'slug', 'hashed')
+
+DEFAULT_SECTION_NAMING = 'numbered_slug'
+
+# Hex digits of the SHA-256 kept in hashed names
+HASH_LENGTH = 12
+
+
+def validate_section_naming(naming: Optional[str]) -> str:
+    """section_naming, defaulting to numbered_slug (raises ValueError for an unknown one)"""
+    naming = naming or DEFAULT_SECTION_NAMING
+    if naming not in SECTION_NAMINGS:
+        raise ValueError(f"Unknown section_naming '{naming}' (expected one of: {', '.join(SECTION_NAMINGS)})")
+    return naming
+
+
+def number_width(total: int) -> int:
+    """Digits in section numbers for total sections"""
+    return max(2, len(str(total)))
+
+
+def section_filename(naming: str, index: int, total: int, slug: str, title: str = '', content: str = '') -> str:
+    """
+    File name of one section
+
+    Args:
+        naming: One of SECTION_NAMINGS
+        index: 1-based position of the section
+        total: Number of sections (sets the number width)
+        slug: Safe name for the section ('' falls back to section-N)
+        title, content: What a hashed name is computed from
+    """
+    slug = slug or f"section-{index}"
+    number = f"{index:0{number_width(total)}d}"
+    if naming == 'numbered':
+        return f"{number}.md"
+    if naming == 'slug':
+        return f"{slug}.md"
+    if naming == 'hashed':
+        digest = hashlib.sha256(f"{title}\n{content}".encode('utf-8')).hexdigest()
+        return f"{digest[:HASH_LENGTH]}.md"
+    return f"{number}-{slug}.md"
+
+
+def section_filenames(naming: str, slugs: Sequence[str], titles: Sequence[str],
+                      contents: Sequence[str]) -> List[str]:
+    """File names for every section in order, unique (case-insensitively) within the list"""
+    taken = set()
+    return [FileUtils.unique_filename(section_filename(naming, index, len(slugs), slug, title, content), taken)
+            for index, (slug, title, content) in enumerate(zip(slugs, titles, contents), 1)]
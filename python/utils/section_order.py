@@ -0,0 +1,112 @@
+"""
+Section ordering by the document outline
+
+Assembled documents (annexes bound in front, reordered chapters) can have a
+physical page order that differs from the logical order of their bookmark
+tree. These helpers map sections to outline entries and reorder them to
+follow the outline; sections without an entry keep their place relative to
+their predecessor in appearance order.
+"""
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+ORDER_MODES = ('appearance', 'outline')
+
+
+def _normalize_title(title: str) -> str:
+    return re.sub(r'[^0-9a-z]+', ' ', (title or '').lower()).strip()
+
+
+def section_pages(section: Dict[str, Any]) -> List[int]:
+    """Pages a section covers ('pages' list or single 'page')"""
+    if section.get('pages'):
+        return list(section['pages'])
+    return [section['page']] if section.get('page') else []
+
+
+def section_page_spans(sections: List[Dict[str, Any]],
+                       last_page: Optional[int] = None) -> List[Tuple[Optional[int], Optional[int]]]:
+    """
+    First and last page of each section
+
+    Sections that only know their start page run until the page before the
+    nearest later start page of any section (or to last_page); a section
+    followed by another starting on the same page ends on that page.
+
+    Returns:
+        (page_start, page_end) per section; (None, None) when unknown
+    """
+    starts = [min(section_pages(section)) if section_pages(section) else None for section in sections]
+    spans: List[Tuple[Optional[int], Optional[int]]] = []
+    for index, section in enumerate(sections):
+        start = starts[index]
+        if start is None:
+            spans.append((None, None))
+        elif section.get('pages'):
+            spans.append((start, max(section['pages'])))
+        elif index + 1 < len(starts) and starts[index + 1] == start:
+            spans.append((start, start))
+        else:
+            later = [page for page in starts if page is not None and page > start]
+            if later:
+                end = min(later) - 1
+            else:
+                end = max(start, last_page) if last_page else start
+            spans.append((start, end))
+    return spans
+
+
+def map_section_to_outline(section: Dict[str, Any], outline: List[Dict[str, Any]]) -> Optional[int]:
+    """
+    Index of the outline entry a section belongs to
+
+    A title match wins (sections built from the outline or from detected
+    headers); otherwise the first outline entry pointing into the section's
+    pages.
+
+    Returns:
+        Outline index, or None if the section maps to no entry
+    """
+    title = _normalize_title(section.get('title', ''))
+    if title:
+        for index, entry in enumerate(outline):
+            if _normalize_title(entry.get('title', '')) == title:
+                return index
+
+    pages = set(section_pages(section))
+    if pages:
+        for index, entry in enumerate(outline):
+            if entry.get('page') in pages:
+                return index
+    return None
+
+
+def order_sections_by_outline(sections: List[Dict[str, Any]],
+                              outline: List[Dict[str, Any]]) -> Tuple[List[Dict[str, Any]], List[Dict[str, Any]]]:
+    """
+    Reorder sections to follow the outline
+
+    Unmapped sections fall back to appearance order: they stay directly
+    after the section that preceded them (or at the front if none did).
+
+    Args:
+        sections: Sections in appearance order
+        outline: Outline entries (title, level, page) in bookmark order
+
+    Returns:
+        (ordered sections, unmapped sections)
+    """
+    keys = []
+    unmapped = []
+    previous_key = -1
+    for position, section in enumerate(sections):
+        index = map_section_to_outline(section, outline)
+        if index is None:
+            unmapped.append(section)
+            keys.append((previous_key, 1, position))
+        else:
+            previous_key = index
+            keys.append((index, 0, position))
+
+    ordered = [section for _, section in sorted(zip(keys, sections), key=lambda pair: pair[0])]
+    return ordered, unmapped
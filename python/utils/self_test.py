@@ -0,0 +1,183 @@
+"""
+Self-test: the whole pipeline on a generated sample PDF
+
+A missing library or a broken install usually shows up only when a real
+document fails to convert. self_test writes a two-page PDF (a numbered
+heading and prose on page 1, a ruled price table on page 2) to a temporary
+directory, analyzes and converts it with table export and chunking on, and
+reports each stage as passed, failed, or skipped (when an earlier stage it
+depends on failed). The temporary directory is removed at the end, and
+removing it is the last stage.
+"""
+import shutil
+import tempfile
+import time
+from contextlib import contextmanager
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Optional
+
+from .cancellation import ConversionCancelled
+from .pdf_writer import stream, write_pdf
+from .temp_files import TEMP_FILES
+
+STAGES = ('dependencies', 'analysis', 'extraction', 'tables', 'chunking', 'cleanup')
+
+# Text the extraction stage looks for in the converted sections
+SAMPLE_MARKER = "Self-test marker sentence"
+
+SAMPLE_TABLE = [
+    ["Item", "Quantity", "Price"],
+    ["Widget", "4", "2.50"],
+    ["Gadget", "1", "9.75"],
+]
+
+SELF_TEST_CHUNK_TOKENS = 200
+
+
+class StageFailed(Exception):
+    """A self-test check did not hold"""
+
+
+def _text(size: int, x: float, y: float, text: str) -> bytes:
+    escaped = text.replace("\\", "\\\\").replace("(", "\\(").replace(")", "\\)")
+    return f"BT /F1 {size} Tf {x} {y} Td ({escaped}) Tj ET".encode()
+
+
+def _table(left: float, top: float, width: float = 100, height: float = 20) -> List[bytes]:
+    """A ruled table of SAMPLE_TABLE with its top-left corner at (left, top)"""
+    rows, cols = len(SAMPLE_TABLE), len(SAMPLE_TABLE[0])
+    right, bottom = left + cols * width, top - rows * height
+    content = [b"0.5 w"]
+    content += [f"{left} {top - r * height} m {right} {top - r * height} l S".encode() for r in range(rows + 1)]
+    content += [f"{left + c * width} {top} m {left + c * width} {bottom} l S".encode() for c in range(cols + 1)]
+    for r, row in enumerate(SAMPLE_TABLE):
+        for c, cell in enumerate(row):
+            content.append(_text(10, left + c * width + 6, top - (r + 1) * height + 6, cell))
+    return content
+
+
+def build_sample_pdf() -> bytes:
+    """The self-test PDF: two Letter pages in Helvetica"""
+    pages = [
+        [_text(18, 72, 720, "1 Introduction"),
+         _text(11, 72, 690, f"{SAMPLE_MARKER} for the conversion pipeline."),
+         _text(11, 72, 676, "The next page lists prices in a table.")],
+        [_text(18, 72, 720, "2 Pricing"),
+         _text(11, 72, 690, "Prices per item:")] + _table(72, 670),
+    ]
+    page_refs = " ".join(f"{4 + 2 * i} 0 R" for i in range(len(pages)))
+    objects = [
+        b"<< /Type /Catalog /Pages 2 0 R >>",
+        f"<< /Type /Pages /Kids [{page_refs}] /Count {len(pages)} >>".encode(),
+        b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+    ]
+    for i, content in enumerate(pages):
+        objects.append(f"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "
+                       f"/Resources << /Font << /F1 3 0 R >> >> /Contents {5 + 2 * i} 0 R >>".encode())
+        objects.append(stream(b"\n".join(content)))
+    return write_pdf(objects)
+
+
+@contextmanager
+def _stage(stages: List[Dict[str, Any]], name: str, *requires: str) -> Iterator[Dict[str, Any]]:
+    """Record one stage; it is skipped (status set before the body) unless every stage in requires passed"""
+    stage = {'name': name, 'status': 'passed', 'detail': '', 'seconds': 0.0}
+    stages.append(stage)
+    failed = [s['name'] for s in stages if s['name'] in requires and s['status'] != 'passed']
+    if failed:
+        stage['status'] = 'skipped'
+        stage['detail'] = f"needs {', '.join(failed)}"
+    start = time.monotonic()
+    try:
+        yield stage
+    except ConversionCancelled:
+        raise
+    except Exception as e:
+        stage['status'] = 'failed'
+        stage['detail'] = str(e) if isinstance(e, StageFailed) else f"{type(e).__name__}: {e}"
+    stage['seconds'] = round(time.monotonic() - start, 2)
+
+
+def run_self_test(cancel_event=None, work_dir: Optional[str] = None) -> Dict[str, Any]:
+    """
+    Run every stage on the sample PDF
+
+    Args:
+        cancel_event: threading.Event passed to the analysis and conversion
+        work_dir: Parent of the temporary directory (default: system temp)
+
+    Returns:
+        Dictionary with passed (every stage passed), stages (name, status,
+        detail, seconds), and seconds
+    """
+    start = time.monotonic()
+    stages: List[Dict[str, Any]] = []
+    temp_dir = TEMP_FILES.register(tempfile.mkdtemp(prefix="self-test-", dir=work_dir))
+    pdf_path = temp_dir / "self-test.pdf"
+    result: Dict[str, Any] = {}
+    try:
+        pdf_path.write_bytes(build_sample_pdf())
+
+        with _stage(stages, 'dependencies') as stage:
+            from .dependencies import check_dependencies
+            report = check_dependencies()
+            if not report.ok:
+                raise StageFailed("; ".join(report.problems))
+            stage['detail'] = ", ".join(f"{name} {version}" for name, version in report.versions().items())
+
+        with _stage(stages, 'analysis') as stage:
+            # Top-level modules next to utils/, importable where the server runs
+            from pdf_analyzer import analyze_pdf
+            analysis = analyze_pdf(str(pdf_path), cancel_event)
+            if analysis['pages'] != 2:
+                raise StageFailed(f"expected 2 pages, found {analysis['pages']}")
+            stage['detail'] = f"{analysis['pages']} pages, {analysis.get('table_count', 0)} table(s)"
+
+        with _stage(stages, 'extraction') as stage:
+            from modular_pdf_converter import ModularPDFConverter
+            options = {'export_tables_csv': True, 'chunk_tokens': SELF_TEST_CHUNK_TOKENS, 'extract_images': False}
+            result = ModularPDFConverter(str(pdf_path), str(temp_dir / "out"), options, cancel_event).convert()
+            if not result.get('success'):
+                raise StageFailed(f"conversion failed: {result.get('error', 'unknown error')}")
+            sections = list(Path(result['sections_directory']).glob("*.md"))
+            if not any(SAMPLE_MARKER in section.read_text(encoding='utf-8') for section in sections):
+                raise StageFailed(f"sample text missing from the {len(sections)} section file(s)")
+            stage['detail'] = f"{len(sections)} section(s)"
+
+        with _stage(stages, 'tables', 'extraction') as stage:
+            if stage['status'] == 'passed':
+                exported = result.get('processing_stats', {}).get('tables_csv', {}).get('exported', 0)
+                if not exported:
+                    raise StageFailed("the price table was not detected")
+                stage['detail'] = f"{exported} table(s) exported as CSV"
+
+        with _stage(stages, 'chunking', 'extraction') as stage:
+            if stage['status'] == 'passed':
+                chunking = result.get('chunking')
+                if not chunking or not chunking.get('total_chunks'):
+                    raise StageFailed("no chunks were written")
+                if not (Path(result['output_directory']) / chunking['manifest']).exists():
+                    raise StageFailed(f"chunk manifest {chunking['manifest']} is missing")
+                stage['detail'] = f"{chunking['total_chunks']} chunk(s) of ≤{chunking['chunk_tokens']} tokens"
+    finally:
+        with _stage(stages, 'cleanup') as stage:
+            shutil.rmtree(temp_dir)
+            if temp_dir.exists():
+                raise StageFailed(f"{temp_dir} was not removed")
+            stage['detail'] = "temporary files removed"
+        shutil.rmtree(temp_dir, ignore_errors=True)
+        TEMP_FILES.forget(temp_dir)
+
+    return {
+        'passed': all(stage['status'] == 'passed' for stage in stages),
+        'stages': stages,
+        'seconds': round(time.monotonic() - start, 2)
+    }
+
+
+def format_self_test(report: Dict[str, Any]) -> str:
+    """One line per stage: icon, name, and detail"""
+    icons = {'passed': '✅', 'failed': '❌', 'skipped': '⏭️'}
+    lines = [f"{icons[stage['status']]} {stage['name']}: {stage['detail'] or stage['status']}"
+             for stage in report['stages']]
+    return '\n'.join(lines) + '\n'
@@ -0,0 +1,165 @@
+"""
+Server-wide default tool arguments from a JSON config file
+
+Teams converting many documents pass the same options on every call. At
+startup the server reads MCP_CONFIG, or ./mcp-markdown.json when that
+variable is unset, and fills in any tool argument the call leaves out:
+
+    {
+      "defaults": {"chunk_tokens": 800, "image_format": "webp"},
+      "tools": {"convert_docx": {"output_dir": "./word-docs"}}
+    }
+
+"defaults" applies to every tool that declares the argument, "tools"
+to one tool (and wins over "defaults"); arguments in the call win over
+both. Without a file the config is empty and nothing changes.
+
+The file is checked against the tools' input schemas when it is loaded:
+unknown arguments, wrong JSON types, and values outside an enum raise
+ServerConfigError, so a typo stops the server at startup instead of
+being ignored on every call.
+"""
+import json
+import os
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Optional
+
+CONFIG_ENV = 'MCP_CONFIG'
+DEFAULT_CONFIG_FILE = 'mcp-markdown.json'
+
+# Python types accepted for each JSON schema type (bool is not a number here)
+JSON_TYPES = {
+    'string': (str,),
+    'integer': (int,),
+    'number': (int, float),
+    'boolean': (bool,),
+    'array': (list,),
+    'object': (dict,),
+}
+
+
+class ServerConfigError(ValueError):
+    """The config file is missing, malformed, or names arguments no tool accepts"""
+
+
+@dataclass
+class ServerConfig:
+    """Default tool arguments: for every tool (defaults) and per tool (tools)"""
+    defaults: Dict[str, Any] = field(default_factory=dict)
+    tools: Dict[str, Dict[str, Any]] = field(default_factory=dict)
+    # File the config was read from (None: no file)
+    path: Optional[str] = None
+
+    def apply(self, tool: str, arguments: Optional[Dict[str, Any]],
+              properties: Optional[Dict[str, Any]] = None) -> Dict[str, Any]:
+        """
+        Arguments for one call with the configured defaults filled in
+
+        Args:
+            tool: Tool name
+            arguments: The call's arguments (always kept)
+            properties: The tool's input schema properties; "defaults"
+                entries the tool doesn't declare are left out
+        """
+        merged = {key: value for key, value in self.defaults.items()
+                  if properties is not None and key in properties}
+        merged.update(self.tools.get(tool, {}))
+        merged.update(arguments or {})
+        return merged
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {'path': self.path, 'defaults': self.defaults, 'tools': self.tools}
+
+
+def config_path() -> Optional[Path]:
+    """MCP_CONFIG, else ./mcp-markdown.json if it exists, else None"""
+    configured = os.environ.get(CONFIG_ENV, '').strip()
+    if configured:
+        return Path(configured).expanduser()
+    default = Path.cwd() / DEFAULT_CONFIG_FILE
+    return default if default.is_file() else None
+
+
+def check_value(name: str, value: Any, schema: Dict[str, Any], where: str) -> None:
+    """Raise ServerConfigError unless value has the schema's type and is in its enum"""
+    types = schema.get('type')
+    if types:
+        allowed = tuple(python_type for kind in ([types] if isinstance(types, str) else types)
+                        for python_type in JSON_TYPES.get(kind, ()))
+        if isinstance(value, bool) and bool not in allowed or not isinstance(value, allowed):
+            expected = types if isinstance(types, str) else ' or '.join(types)
+            raise ServerConfigError(f"{where}: '{name}' must be {expected}, got {json.dumps(value)}")
+    if 'enum' in schema and value not in schema['enum']:
+        raise ServerConfigError(f"{where}: '{name}' must be one of {', '.join(map(str, schema['enum']))}, "
+                                f"got {json.dumps(value)}")
+
+
+def parse_server_config(data: Any, tool_properties: Dict[str, Dict[str, Any]],
+                        path: Optional[str] = None) -> ServerConfig:
+    """
+    Validate a parsed config file against the tools' input schemas
+
+    Args:
+        data: The file's JSON value
+        tool_properties: Input schema properties by tool name
+
+    Raises:
+        ServerConfigError: Naming the file, the key, and what is wrong
+    """
+    where = path or 'config'
+    if not isinstance(data, dict):
+        raise ServerConfigError(f"{where}: expected a JSON object with \"defaults\" and/or \"tools\"")
+    unknown = sorted(set(data) - {'defaults', 'tools'})
+    if unknown:
+        raise ServerConfigError(f"{where}: unknown key(s) {', '.join(unknown)} (expected \"defaults\" and/or \"tools\")")
+
+    defaults = data.get('defaults') or {}
+    if not isinstance(defaults, dict):
+        raise ServerConfigError(f"{where}: \"defaults\" must be an object")
+    for name, value in defaults.items():
+        schemas = [properties[name] for properties in tool_properties.values() if name in properties]
+        if not schemas:
+            raise ServerConfigError(f"{where}: no tool accepts the argument '{name}' in \"defaults\"")
+        for schema in schemas:
+            check_value(name, value, schema, f"{where} defaults")
+
+    tools = data.get('tools') or {}
+    if not isinstance(tools, dict):
+        raise ServerConfigError(f"{where}: \"tools\" must be an object of tool name to arguments")
+    for tool, arguments in tools.items():
+        if tool not in tool_properties:
+            raise ServerConfigError(f"{where}: unknown tool '{tool}' in \"tools\"")
+        if not isinstance(arguments, dict):
+            raise ServerConfigError(f"{where}: \"tools\".{tool} must be an object")
+        for name, value in arguments.items():
+            if name not in tool_properties[tool]:
+                raise ServerConfigError(f"{where}: {tool} has no argument '{name}'")
+            check_value(name, value, tool_properties[tool][name], f"{where} tools.{tool}")
+
+    return ServerConfig(defaults=dict(defaults), tools={tool: dict(arguments) for tool, arguments in tools.items()},
+                        path=path)
+
+
+def load_server_config(tool_properties: Dict[str, Dict[str, Any]], path: Optional[Path] = None) -> ServerConfig:
+    """
+    Read and validate the config file (config_path() unless path is given)
+
+    Returns:
+        The config, or an empty ServerConfig when there is no file
+
+    Raises:
+        ServerConfigError: If MCP_CONFIG names a missing file or the file is invalid
+    """
+    path = path or config_path()
+    if path is None:
+        return ServerConfig()
+    if not path.is_file():
+        raise ServerConfigError(f"{path}: config file not found")
+    try:
+        data = json.loads(path.read_text(encoding='utf-8'))
+    except json.JSONDecodeError as e:
+        raise ServerConfigError(f"{path}: invalid JSON at line {e.lineno}, column {e.colno}: {e.msg}")
+    except (OSError, UnicodeDecodeError) as e:
+        raise ServerConfigError(f"{path}: cannot read config file: {e}")
+    return parse_server_config(data, tool_properties, str(path))
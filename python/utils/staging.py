@@ -0,0 +1,94 @@
+"""
+Staged conversion output
+
+Conversions used to write straight into their output folders, so a
+cancelled or failed run left its partial files mixed in with the previous
+conversion's, and clean_output (or on_conflict='overwrite') deleted the
+previous output before the new one existed. A conversion now writes into
+a hidden staging directory inside output_dir, laid out by the same
+OutputLayout template, so every file has the same path relative to the
+staging directory as it will have relative to output_dir. Only once the
+conversion has succeeded is the previous output cleaned as asked and the
+staged files moved into place; otherwise the staging directory is
+deleted and the previous output is left exactly as it was.
+
+The staging directory sits inside output_dir, so moving files into place
+is a rename on the same filesystem. It is registered with TEMP_FILES, so
+a server stopped mid-conversion removes it too.
+"""
+import os
+import tempfile
+from pathlib import Path
+from typing import Any, List
+
+from .output_layout import OutputLayout
+from .temp_files import TEMP_FILES
+
+STAGING_PREFIX = '.staging-'
+
+
+def staging_layout(layout: OutputLayout) -> OutputLayout:
+    """A new, empty staging directory inside layout's output_dir, laid out like layout"""
+    layout.output_dir.mkdir(parents=True, exist_ok=True)
+    staging_dir = TEMP_FILES.register(tempfile.mkdtemp(prefix=STAGING_PREFIX, dir=layout.output_dir))
+    staged = OutputLayout(layout.template, str(staging_dir), layout.values['doc_id'],
+                          doc_type=layout.values['doc_type'])
+    # Same date partition as the real layout, even if the run crosses midnight
+    staged.values.update({key: layout.values[key] for key in ('date', 'year', 'month')})
+    return staged
+
+
+def staged_files(staged: OutputLayout) -> List[Path]:
+    """Every file written to the staging directory so far"""
+    return sorted(path for path in staged.output_dir.rglob('*') if path.is_file())
+
+
+def install_staged_output(staged: OutputLayout, layout: OutputLayout) -> List[Path]:
+    """
+    Move the staged files to the same paths under layout's output_dir and remove the staging directory
+
+    Files already there with the same name are replaced; others are left alone.
+
+    Returns:
+        Installed file paths
+    """
+    installed = []
+    for path in staged_files(staged):
+        target = layout.output_dir / path.relative_to(staged.output_dir)
+        target.parent.mkdir(parents=True, exist_ok=True)
+        os.replace(path, target)
+        installed.append(target)
+    TEMP_FILES.release(staged.output_dir)
+    return installed
+
+
+def discard_staged_output(staged: OutputLayout) -> List[str]:
+    """
+    Delete the staging directory, leaving the real output untouched
+
+    Returns:
+        The staged file paths that were removed
+    """
+    removed = [str(path) for path in staged_files(staged)] if staged.output_dir.exists() else []
+    TEMP_FILES.release(staged.output_dir)
+    return removed
+
+
+def rebase_staged_paths(value: Any, staged: OutputLayout, layout: OutputLayout) -> Any:
+    """
+    A copy of a result (dicts, lists, strings) with staging paths pointing at their installed location
+
+    Relative paths (manifest entries, links) are the same in both places
+    and are left as they are.
+    """
+    if isinstance(value, dict):
+        return {key: rebase_staged_paths(item, staged, layout) for key, item in value.items()}
+    if isinstance(value, (list, tuple)):
+        return type(value)(rebase_staged_paths(item, staged, layout) for item in value)
+    if isinstance(value, (str, Path)):
+        staging_dir = str(staged.output_dir)
+        text = str(value)
+        if text == staging_dir or text.startswith(staging_dir + os.sep):
+            rebased = str(layout.output_dir) + text[len(staging_dir):]
+            return Path(rebased) if isinstance(value, Path) else rebased
+    return value
@@ -0,0 +1,152 @@
+"""
+Structural diff of two revisions of a document
+
+compare_pdfs analyzes both PDFs and reports what changed between them:
+page, table, and image counts, and the outline twice over, once for the
+chapters (the top outline level) and once for every section title. Titles
+are compared without their numbering ("2.3 Scope" and "3.1 Scope" are the
+same section, renumbered), and entries are lined up in document order, so
+a section inserted early does not make everything after it look changed.
+Where one title takes the place of another and the two are similar enough
+(or keep the same number), the section was renamed; otherwise it counts as
+removed and added.
+"""
+import difflib
+import re
+from typing import Any, Dict, List, Optional, Sequence
+
+# Title similarity (0-1) from which a replaced title counts as renamed
+RENAME_SIMILARITY = 0.6
+
+# Leading numbering and "Chapter 3:" style labels, ignored when matching titles
+NUMBERING = re.compile(r'^\s*(?:(?:chapter|part|section|appendix)\s+[0-9ivxlc]+[.:]?\s*|[0-9]+(?:\.[0-9]+)*\.?\s+|[A-Z]\.\s+)',
+                       re.I)
+
+# Counts compared between the two analyses
+COUNT_FIELDS = ('pages', 'table_count', 'image_count')
+
+
+def title_key(title: str) -> str:
+    """A title for matching: numbering dropped, case and whitespace folded"""
+    return ' '.join(NUMBERING.sub('', title or '').casefold().split())
+
+
+def title_number(title: str) -> Optional[str]:
+    """The title's leading numbering ("2.3"), or None"""
+    match = re.match(r'^\s*(\d+(?:\.\d+)*)\b', title or '')
+    return match.group(1) if match else None
+
+
+def is_rename(old: Dict[str, Any], new: Dict[str, Any]) -> bool:
+    """Whether new, in old's place, is old renamed rather than a different section"""
+    if title_number(old['title']) and title_number(old['title']) == title_number(new['title']):
+        return True
+    return difflib.SequenceMatcher(None, title_key(old['title']), title_key(new['title'])).ratio() >= RENAME_SIMILARITY
+
+
+def entry(item: Dict[str, Any]) -> Dict[str, Any]:
+    """The fields of an outline entry the diff reports"""
+    return {'title': item['title'], 'level': item.get('level', 0), 'page': item.get('page')}
+
+
+def diff_titles(entries_a: Sequence[Dict[str, Any]], entries_b: Sequence[Dict[str, Any]]) -> Dict[str, Any]:
+    """
+    Title-level diff of two outline entry lists (title, level, page)
+
+    Returns:
+        Dictionary with added, removed, renamed (from, to), moved (same
+        title, different page: title, page_a, page_b), and unchanged (count)
+    """
+    keys_a = [title_key(item['title']) for item in entries_a]
+    keys_b = [title_key(item['title']) for item in entries_b]
+    result: Dict[str, Any] = {'added': [], 'removed': [], 'renamed': [], 'moved': [], 'unchanged': 0}
+
+    matcher = difflib.SequenceMatcher(None, keys_a, keys_b, autojunk=False)
+    for tag, a_start, a_end, b_start, b_end in matcher.get_opcodes():
+        old, new = list(entries_a[a_start:a_end]), list(entries_b[b_start:b_end])
+        if tag == 'equal':
+            for item_a, item_b in zip(old, new):
+                if item_a.get('page') != item_b.get('page'):
+                    result['moved'].append({'title': item_b['title'], 'page_a': item_a.get('page'),
+                                            'page_b': item_b.get('page')})
+                else:
+                    result['unchanged'] += 1
+            continue
+        # Each new title renames the next old one that looks like it; old titles skipped over are removed
+        position = 0
+        for item_b in new:
+            match = next((index for index in range(position, len(old)) if is_rename(old[index], item_b)), None)
+            if match is None:
+                result['added'].append(entry(item_b))
+                continue
+            result['removed'].extend(entry(item) for item in old[position:match])
+            result['renamed'].append({'from': entry(old[match]), 'to': entry(item_b)})
+            position = match + 1
+        result['removed'].extend(entry(item) for item in old[position:])
+    return result
+
+
+def top_level(entries: Sequence[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """Entries at the outline's top level (its chapters)"""
+    if not entries:
+        return []
+    level = min(item.get('level', 0) for item in entries)
+    return [item for item in entries if item.get('level', 0) == level]
+
+
+def diff_structures(analysis_a: Dict[str, Any], analysis_b: Dict[str, Any]) -> Dict[str, Any]:
+    """
+    Structural diff of two analyses (analyze_pdf dictionaries with chapters)
+
+    Returns:
+        Dictionary with changed, counts (per COUNT_FIELDS: a, b, delta),
+        chapters and sections (diff_titles results)
+    """
+    chapters_a, chapters_b = analysis_a.get('chapters') or [], analysis_b.get('chapters') or []
+    counts = {field: {'a': analysis_a.get(field, 0) or 0, 'b': analysis_b.get(field, 0) or 0}
+              for field in COUNT_FIELDS}
+    for values in counts.values():
+        values['delta'] = values['b'] - values['a']
+    chapters = diff_titles(top_level(chapters_a), top_level(chapters_b))
+    sections = diff_titles(chapters_a, chapters_b)
+    changed = any(values['delta'] for values in counts.values()) or any(
+        sections[kind] for kind in ('added', 'removed', 'renamed', 'moved'))
+    return {'changed': bool(changed), 'counts': counts, 'chapters': chapters, 'sections': sections}
+
+
+def _page(item: Dict[str, Any]) -> str:
+    """Page suffix " (p. N)" for an entry that has a page"""
+    return f" (p. {item['page']})" if item.get('page') else ""
+
+
+def format_title_diff(diff: Dict[str, Any], heading: str) -> str:
+    """Markdown for one diff_titles result"""
+    lines = [f"## {heading}", ""]
+    for item in diff['added']:
+        lines.append(f"- Added: {item['title']}{_page(item)}")
+    for item in diff['removed']:
+        lines.append(f"- Removed: {item['title']}{_page(item)}")
+    for pair in diff['renamed']:
+        lines.append(f"- Renamed: {pair['from']['title']} → {pair['to']['title']}{_page(pair['to'])}")
+    for item in diff['moved']:
+        lines.append(f"- Moved: {item['title']} (p. {item['page_a']} → p. {item['page_b']})")
+    if len(lines) == 2:
+        lines.append("- No changes")
+    lines.append(f"- Unchanged: {diff['unchanged']}")
+    return '\n'.join(lines)
+
+
+def format_structure_diff(diff: Dict[str, Any], name_a: str, name_b: str) -> str:
+    """Markdown report of a diff_structures result"""
+    labels = {'pages': 'Pages', 'table_count': 'Tables', 'image_count': 'Images'}
+    lines = [f"# Structure comparison: {name_a} → {name_b}", ""]
+    if not diff['changed']:
+        lines += ["No structural changes.", ""]
+    lines += ["| | A | B | Change |", "|---|---|---|---|"]
+    for field, values in diff['counts'].items():
+        lines.append(f"| {labels[field]} | {values['a']} | {values['b']} | {values['delta']:+d} |")
+    lines.append("")
+    lines.append(format_title_diff(diff['chapters'], "Chapters"))
+    lines.append("")
+    lines.append(format_title_diff(diff['sections'], "Sections"))
+    return '\n'.join(lines) + '\n'
@@ -0,0 +1,149 @@
+"""
+Temporary files and directories the server creates
+
+Downloads, merged PDF sets, decrypted copies, and inline
+conversions all work in temporary directories. Each one removes its own
+when it finishes, but nothing did when the server itself was stopped
+mid-call. Every temporary path is registered with TEMP_FILES instead, and
+the server removes whatever is still registered when the client
+disconnects (stdin closes) or on SIGINT/SIGTERM.
+
+A signal cancels the server first: running tool calls set their workers'
+cancel events and wait for them to stop, and only then are the paths
+removed, so nothing is deleted while a worker is still writing into it.
+Workers stuck inside a library call get SHUTDOWN_GRACE_SECONDS (or a
+second signal); after that the paths are removed and the process exits.
+"""
+import asyncio
+import os
+import shutil
+import signal
+import tempfile
+import threading
+from contextlib import contextmanager
+from pathlib import Path
+from typing import Callable, Iterator, List, Optional, Sequence, Union
+
+SHUTDOWN_SIGNALS = (signal.SIGINT, signal.SIGTERM)
+
+# Seconds running tool calls get to stop after a shutdown signal before the process exits regardless
+SHUTDOWN_GRACE_SECONDS = 30
+
+
+def remove_path(path: Path) -> None:
+    """Delete a file or directory tree (missing is fine)"""
+    if path.is_dir() and not path.is_symlink():
+        shutil.rmtree(path, ignore_errors=True)
+    else:
+        path.unlink(missing_ok=True)
+
+
+class TempTracker:
+    """Temporary paths that must not outlive the process (thread-safe: conversions run in worker threads)"""
+
+    def __init__(self):
+        self._paths: List[Path] = []
+        self._lock = threading.Lock()
+
+    def register(self, path: Union[str, Path]) -> Path:
+        """Track a temporary file or directory until it is released"""
+        path = Path(path)
+        with self._lock:
+            if path not in self._paths:
+                self._paths.append(path)
+        return path
+
+    def forget(self, path: Union[str, Path]) -> None:
+        """Stop tracking a path its owner has removed"""
+        with self._lock:
+            if Path(path) in self._paths:
+                self._paths.remove(Path(path))
+
+    def release(self, path: Union[str, Path]) -> None:
+        """Remove a tracked path and stop tracking it"""
+        remove_path(Path(path))
+        self.forget(path)
+
+    def paths(self) -> List[Path]:
+        with self._lock:
+            return list(self._paths)
+
+    def cleanup(self) -> int:
+        """Remove every tracked path; returns how many still existed"""
+        with self._lock:
+            paths, self._paths = self._paths, []
+        removed = 0
+        for path in reversed(paths):
+            if path.exists() or path.is_symlink():
+                remove_path(path)
+                removed += 1
+        return removed
+
+    def mkdtemp(self, prefix: str) -> str:
+        """tempfile.mkdtemp, tracked; the caller releases it"""
+        return str(self.register(tempfile.mkdtemp(prefix=prefix)))
+
+    @contextmanager
+    def directory(self, prefix: str) -> Iterator[str]:
+        """A tracked temporary directory, removed on exit (tempfile.TemporaryDirectory's replacement)"""
+        path = self.mkdtemp(prefix)
+        try:
+            yield path
+        finally:
+            self.release(path)
+
+
+# The server's tracker
+TEMP_FILES = TempTracker()
+
+
+def install_shutdown_handlers(tracker: TempTracker, task: Optional[asyncio.Task] = None,
+                              on_signal: Optional[Callable[[signal.Signals], None]] = None,
+                              signals: Sequence[signal.Signals] = SHUTDOWN_SIGNALS,
+                              grace_seconds: float = SHUTDOWN_GRACE_SECONDS,
+                              force_exit: Callable[[int], None] = os._exit) -> List[signal.Signals]:
+    """
+    On SIGINT/SIGTERM cancel task; the task removes the tracker's paths once
+    its tool calls have stopped (TEMP_FILES.cleanup() in the server's finally)
+
+    If the task hasn't finished grace_seconds later, or a second signal
+    arrives, the paths are removed here and force_exit is called with
+    128 + the signal number. Must be called from the event loop's thread.
+
+    Args:
+        task: Task to stop (default: the current task)
+        on_signal: Called first with the signal (for logging)
+        grace_seconds: How long running tool calls get to stop
+        force_exit: Ends the process once the grace period is over
+
+    Returns:
+        The signals handled (none where the loop can't add handlers, e.g. Windows)
+    """
+    loop = asyncio.get_running_loop()
+    task = task or asyncio.current_task()
+    received: List[signal.Signals] = []
+
+    def force(sig: signal.Signals) -> None:
+        if task.done():
+            return
+        tracker.cleanup()
+        force_exit(128 + int(sig))
+
+    def shutdown(sig: signal.Signals) -> None:
+        if on_signal:
+            on_signal(sig)
+        if received:
+            force(sig)
+            return
+        received.append(sig)
+        task.cancel()
+        loop.call_later(grace_seconds, force, sig)
+
+    installed = []
+    for sig in signals:
+        try:
+            loop.add_signal_handler(sig, shutdown, sig)
+            installed.append(sig)
+        except (NotImplementedError, RuntimeError, ValueError):
+            pass
+    return installed
@@ -0,0 +1,106 @@
+"""
+Per-page text layer confidence
+
+A scanned page is an image with no text layer (or an OCR layer of
+garbage), so converting it yields little or no text. Each page gets a
+confidence in [0, 1] that its text layer is usable:
+
+    confidence = density score x font factor
+
+- density score: readable characters against what a sparse page of that
+  size holds (TEXT_DENSITY_FULL characters per square inch), capped at 1.
+  Unmapped glyphs ("(cid:12)") and U+FFFD don't count as readable.
+- font factor: 1 when the page uses an embedded font, NO_EMBEDDED_FONT_FACTOR
+  when its fonts are all unembedded (standard fonts usually extract fine,
+  others may not), 0 without fonts and without text (nothing can be
+  text). A page with readable characters but no fonts of its own takes
+  them from elsewhere (inherited resources, form XObjects), so the factor
+  is neutral (1) and density alone decides.
+
+A page below SUSPECT_CONFIDENCE that has images is "suspect": it likely
+needs OCR. Blank pages (no text, no images) are not suspect.
+"""
+from typing import Any, Dict, Iterable, Tuple
+
+# Square inch in PDF points
+POINTS_PER_SQUARE_INCH = 72 * 72
+# Readable characters per square inch that count as a full text layer
+# (a letter page: ~280 characters; dense body text runs ~25 per square inch)
+TEXT_DENSITY_FULL = 3.0
+NO_EMBEDDED_FONT_FACTOR = 0.8
+SUSPECT_CONFIDENCE = 0.5
+
+# Font descriptor keys holding an embedded font program
+FONT_FILE_KEYS = ('/FontFile', '/FontFile2', '/FontFile3')
+
+
+def _resolve(obj: Any) -> Any:
+    """Follow a pypdf indirect reference (plain values pass through)"""
+    return obj.get_object() if hasattr(obj, 'get_object') else obj
+
+
+def font_embedded(font: Any) -> bool:
+    """Whether a font dictionary carries its font program (Type3 glyphs are always inline)"""
+    font = _resolve(font)
+    if font.get('/Subtype') == '/Type3':
+        return True
+    descendants = font.get('/DescendantFonts')
+    if descendants is not None:
+        return any(font_embedded(descendant) for descendant in _resolve(descendants))
+    descriptor = font.get('/FontDescriptor')
+    descriptor = _resolve(descriptor) if descriptor is not None else {}
+    return any(key in descriptor for key in FONT_FILE_KEYS)
+
+
+def page_fonts(resources: Any) -> Tuple[int, int]:
+    """(fonts, embedded fonts) in a page's /Resources"""
+    resources = _resolve(resources) if resources is not None else {}
+    fonts = resources.get('/Font')
+    if fonts is None:
+        return 0, 0
+    fonts = _resolve(fonts)
+    embedded = sum(1 for name in fonts if font_embedded(fonts[name]))
+    return len(fonts), embedded
+
+
+def readable_chars(texts: Iterable[str]) -> int:
+    """Characters of a page's text that are real glyphs (not whitespace, unmapped, or U+FFFD)"""
+    return sum(1 for text in texts
+               if text and not text.isspace() and not text.startswith('(cid:') and text != '�')
+
+
+def page_text_confidence(page: int, chars: int, width: float, height: float, fonts: int,
+                         embedded_fonts: int, images: int) -> Dict[str, Any]:
+    """
+    Text layer confidence for one page
+
+    Args:
+        page: 1-based page number
+        chars: Readable characters (see readable_chars)
+        width, height: Page size in points
+        fonts, embedded_fonts: Fonts in the page resources, and how many are embedded
+        images: Image XObjects on the page
+
+    Returns:
+        Dictionary with page, confidence, chars, fonts, embedded_fonts,
+        images, and suspect
+    """
+    expected = max(1.0, (width * height) / POINTS_PER_SQUARE_INCH * TEXT_DENSITY_FULL)
+    density = min(1.0, chars / expected)
+    if not fonts:
+        # Text without page-level fonts: the fonts sit in inherited resources or form XObjects
+        font_factor = 1.0 if chars else 0.0
+    elif embedded_fonts:
+        font_factor = 1.0
+    else:
+        font_factor = NO_EMBEDDED_FONT_FACTOR
+    confidence = round(density * font_factor, 2)
+    return {
+        'page': page,
+        'confidence': confidence,
+        'chars': chars,
+        'fonts': fonts,
+        'embedded_fonts': embedded_fonts,
+        'images': images,
+        'suspect': confidence < SUSPECT_CONFIDENCE and images > 0
+    }
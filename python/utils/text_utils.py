@@ -174,6 +174,53 @@ class TextUtils:
         
         return f"| {line} |"
     
+    @staticmethod
+    def classify_content_type(content: str) -> str:
+        """
+        Classify content for routing: prose, reference, tabular, code-heavy, or mixed
+        
+        Rule-based on line densities: table rows, code (fenced or indented),
+        prose (sentence-like lines), and reference material (lists, key/value
+        pairs, and short entries).
+        """
+        lines = [line for line in content.split('\n') if line.strip()]
+        if not lines:
+            return 'mixed'
+        
+        table_lines = code_lines = prose_lines = reference_lines = 0
+        in_code_block = False
+        
+        for line in lines:
+            stripped = line.strip()
+            
+            if stripped.startswith('```'):
+                in_code_block = not in_code_block
+                code_lines += 1
+                continue
+            if in_code_block or line.startswith(('    ', '\t')):
+                code_lines += 1
+            elif TextUtils.is_table_row(stripped):
+                table_lines += 1
+            elif stripped.startswith('#'):
+                continue
+            elif (re.match(r'^([-*•]|\d+[.)])\s+', stripped) or
+                  re.match(r'^[^:]{1,40}:\s+\S', stripped) or
+                  len(stripped.split()) < 6):
+                reference_lines += 1
+            else:
+                prose_lines += 1
+        
+        total = len(lines)
+        if table_lines / total >= 0.5:
+            return 'tabular'
+        if code_lines / total >= 0.4:
+            return 'code-heavy'
+        if prose_lines / total >= 0.6:
+            return 'prose'
+        if reference_lines / total >= 0.5:
+            return 'reference'
+        return 'mixed'
+    
     @staticmethod
     def clean_text(text: str) -> str:
         """Clean and normalize text"""
@@ -212,6 +259,14 @@ class TextUtils:
         sentences = re.split(r'[.!?]+\s+', text)
         return [s.strip() for s in sentences if s.strip()]
     
+    # Common words excluded from keyword lists
+    STOP_WORDS = {
+        'the', 'and', 'for', 'are', 'but', 'not', 'you', 'all', 'can', 'had', 
+        'her', 'was', 'one', 'our', 'out', 'day', 'get', 'has', 'him', 'his',
+        'how', 'man', 'new', 'now', 'old', 'see', 'two', 'way', 'who', 'boy',
+        'did', 'its', 'let', 'put', 'say', 'she', 'too', 'use'
+    }
+    
     @staticmethod
     def extract_keywords(text: str, min_length: int = 3) -> List[str]:
         """Extract potential keywords from text"""
@@ -219,14 +274,87 @@ class TextUtils:
         words = re.findall(r'\b[A-Za-z]{%d,}\b' % min_length, text)
         
         # Filter out common stop words
-        stop_words = {
-            'the', 'and', 'for', 'are', 'but', 'not', 'you', 'all', 'can', 'had', 
-            'her', 'was', 'one', 'our', 'out', 'day', 'get', 'has', 'him', 'his',
-            'how', 'man', 'new', 'now', 'old', 'see', 'two', 'way', 'who', 'boy',
-            'did', 'its', 'let', 'put', 'say', 'she', 'too', 'use'
-        }
-        
-        keywords = [word.lower() for word in words if word.lower() not in stop_words]
+        keywords = [word.lower() for word in words if word.lower() not in TextUtils.STOP_WORDS]
         
         # Return unique keywords
-        return list(set(keywords))
\ No newline at end of file
+        return list(set(keywords))
+    
+    # Lines soft wrapping never touches: headings, rules, HTML, images, link definitions
+    NO_WRAP_LINE = re.compile(r'^\s*(#{1,6}\s|([-*_]\s*){3,}$|<|!\[|\[[^\]]+\]:\s)')
+    LIST_MARKER = re.compile(r'^(\s*(?:[-*+]|\d+[.)])\s+)')
+    # Words that would turn a wrapped line into a list item, heading, quote, or setext underline
+    BLOCK_START_WORD = re.compile(r'^(?:[-*+]|\d{1,9}[.)]|#{1,6}|=+|-+|>.*)$')
+    
+    @staticmethod
+    def soft_wrap_markdown(text: str, width: int) -> str:
+        """
+        Soft-wrap long prose lines at word boundaries
+        
+        Markdown renders a single newline inside a paragraph as a space, so
+        this only changes the source layout. Lines in fenced or indented code
+        blocks, table rows, headings, and HTML are left alone, words (URLs
+        included) are never split, and hard breaks (two trailing spaces)
+        are kept. List items and block quotes keep their marker/prefix. A
+        line never starts with a word markdown would read as a block marker
+        ("-", "1.", "#", ">...") - that word stays on the line before, even
+        past width.
+        
+        Args:
+            text: Markdown text
+            width: Target column width; 0 or less disables wrapping
+        """
+        if width <= 0:
+            return text
+        
+        wrapped = []
+        fence = None
+        for line in text.split('\n'):
+            stripped = line.lstrip()
+            if fence:
+                wrapped.append(line)
+                if stripped.startswith(fence):
+                    fence = None
+                continue
+            if stripped.startswith('```') or stripped.startswith('~~~'):
+                fence = stripped[:3]
+                wrapped.append(line)
+                continue
+            
+            if (len(line) <= width or line.startswith(('    ', '\t')) or TextUtils.is_table_row(line)
+                    or TextUtils.NO_WRAP_LINE.match(line)):
+                wrapped.append(line)
+                continue
+            
+            hard_break = '  ' if line.endswith('  ') else ''
+            quote = re.match(r'^(\s*(?:>\s?)+)', line)
+            prefix = quote.group(1) if quote else ''
+            body = line[len(prefix):]
+            marker = TextUtils.LIST_MARKER.match(body)
+            first_indent = prefix + (marker.group(1) if marker else '')
+            later_indent = prefix + (' ' * len(marker.group(1)) if marker else '')
+            
+            lines = TextUtils.wrap_words((body[len(marker.group(1)):] if marker else body).split(), width,
+                                         first_indent, later_indent)
+            if lines:
+                lines[-1] += hard_break
+            wrapped.extend(lines or [line])
+        
+        return '\n'.join(wrapped)
+    
+    @staticmethod
+    def wrap_words(words: List[str], width: int, first_indent: str, later_indent: str) -> List[str]:
+        """Greedy word wrap that never starts a line with a block marker word (see BLOCK_START_WORD)"""
+        lines: List[str] = []
+        current = first_indent
+        has_words = False
+        for word in words:
+            if (has_words and len(current) + 1 + len(word) > width
+                    and not TextUtils.BLOCK_START_WORD.match(word)):
+                lines.append(current)
+                current = later_indent + word
+            else:
+                current += (' ' if has_words else '') + word
+            has_words = True
+        if has_words:
+            lines.append(current)
+        return lines
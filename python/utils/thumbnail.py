@@ -0,0 +1,135 @@
+"""
+Page thumbnails and full page renders
+
+Renders a single page to PNG with PyMuPDF, scaled so the longer side fits a
+maximum dimension. Renders are cached on disk by the file's content hash,
+page, and size, so repeated previews of the same document are free and a
+re-uploaded, changed file never serves a stale image.
+
+Full page renders (for "view original" links next to converted sections)
+are written once per page into the conversion output instead.
+"""
+import os
+import tempfile
+from pathlib import Path
+from typing import Any, Dict, Iterable, Optional, Tuple
+
+from .fingerprint import hash_file
+
+DEFAULT_MAX_DIMENSION = 256
+MIN_DIMENSION = 16
+MAX_DIMENSION = 2048
+
+# Full page renders: readable text without multi-megabyte files
+DEFAULT_PAGE_DPI = 110
+
+
+def thumbnail_cache_dir() -> Path:
+    """Cache directory (THUMBNAIL_CACHE_DIR env, default: system temp)"""
+    configured = os.environ.get('THUMBNAIL_CACHE_DIR')
+    if configured:
+        return Path(configured)
+    return Path(tempfile.gettempdir()) / "mcp-document-markdown-thumbnails"
+
+
+def render_thumbnail(pdf_path: str, page: int = 1, max_dimension: int = DEFAULT_MAX_DIMENSION,
+                     cache_dir: Optional[Path] = None) -> Tuple[bytes, Dict[str, Any]]:
+    """
+    Render a page to a PNG thumbnail
+
+    Args:
+        pdf_path: Path to the PDF
+        page: 1-based page number
+        max_dimension: Longest side of the thumbnail in pixels
+        cache_dir: Cache directory (default: thumbnail_cache_dir())
+
+    Returns:
+        (png_bytes, info) - info has page, page_count, width, height,
+        content_hash, and cached
+
+    Raises:
+        ValueError: If page or max_dimension is out of range
+    """
+    if not MIN_DIMENSION <= max_dimension <= MAX_DIMENSION:
+        raise ValueError(f"max_dimension must be between {MIN_DIMENSION} and {MAX_DIMENSION}")
+
+    content_hash = hash_file(Path(pdf_path))
+    cache_dir = cache_dir or thumbnail_cache_dir()
+    cache_file = cache_dir / f"{content_hash[:32]}-p{page}-{max_dimension}.png"
+
+    import fitz
+    doc = fitz.open(pdf_path)
+    try:
+        page_count = doc.page_count
+        if not 1 <= page <= page_count:
+            raise ValueError(f"page must be between 1 and {page_count}")
+
+        if cache_file.exists():
+            png = cache_file.read_bytes()
+            pixmap = fitz.Pixmap(png)
+            width, height = pixmap.width, pixmap.height
+            cached = True
+        else:
+            pdf_page = doc.load_page(page - 1)
+            zoom = max_dimension / max(pdf_page.rect.width, pdf_page.rect.height)
+            pixmap = pdf_page.get_pixmap(matrix=fitz.Matrix(zoom, zoom), alpha=False)
+            png = pixmap.tobytes("png")
+            width, height = pixmap.width, pixmap.height
+            cached = False
+            try:
+                cache_dir.mkdir(parents=True, exist_ok=True)
+                # A name of its own per render: threads of one process share a pid
+                fd, temp_name = tempfile.mkstemp(prefix=f".{cache_file.name}.", suffix=".tmp", dir=str(cache_dir))
+                try:
+                    with os.fdopen(fd, 'wb') as f:
+                        f.write(png)
+                    os.replace(temp_name, cache_file)
+                except OSError:
+                    Path(temp_name).unlink(missing_ok=True)
+                    raise
+            except OSError:
+                pass  # Caching is best effort
+    finally:
+        doc.close()
+
+    return png, {
+        'page': page,
+        'page_count': page_count,
+        'width': width,
+        'height': height,
+        'content_hash': content_hash,
+        'cached': cached
+    }
+
+
+def render_page_images(pdf_path: str, pages: Iterable[int], output_dir: Path,
+                       dpi: int = DEFAULT_PAGE_DPI) -> Dict[int, Path]:
+    """
+    Render whole pages to page-NNN.png files
+
+    Args:
+        pdf_path: Path to the PDF
+        pages: 1-based page numbers; out-of-range pages are skipped
+        output_dir: Directory for the PNG files (created if needed)
+        dpi: Render resolution
+
+    Returns:
+        Mapping of page number to the written file
+    """
+    import fitz
+    rendered: Dict[int, Path] = {}
+    doc = fitz.open(pdf_path)
+    try:
+        zoom = dpi / 72.0
+        for page in sorted(set(pages)):
+            if not 1 <= page <= doc.page_count:
+                continue
+            pixmap = doc.load_page(page - 1).get_pixmap(matrix=fitz.Matrix(zoom, zoom), alpha=False)
+            output_dir.mkdir(parents=True, exist_ok=True)
+            page_file = output_dir / f"page-{page:03d}.png"
+            pixmap.save(str(page_file))
+            rendered[page] = page_file
+            pixmap = None  # Release the render before the next page
+    finally:
+        doc.close()
+    return rendered
@@ -11,32 +11,68 @@ try:
 except ImportError:
     TIKTOKEN_AVAILABLE = False
 
+# Encodings loaded through tiktoken
+TIKTOKEN_ENCODINGS = ('cl100k_base', 'o200k_base', 'p50k_base')
+
+# Characters per token for tokenizers tiktoken does not ship
+# (Claude's tokenizer is not public; ~3.5 characters per token for English prose)
+CHAR_RATIOS = {'claude': 3.5}
+
+# Characters per token when an encoding can't be loaded
+FALLBACK_CHAR_RATIO = 4.0
+
+TOKENIZERS = TIKTOKEN_ENCODINGS + tuple(CHAR_RATIOS)
+DEFAULT_TOKENIZER = 'cl100k_base'
+
 class TokenCounter:
     """Handles token counting for various LLM models"""
     
-    def __init__(self, model: str = "gpt-3.5-turbo"):
+    def __init__(self, model: str = "gpt-3.5-turbo", tokenizer: Optional[str] = None):
         """
         Initialize token counter
         
         Args:
             model: Target LLM model for token counting
+            tokenizer: One of TOKENIZERS; overrides model. Unknown names, and
+                tiktoken encodings when tiktoken is missing, fall back to
+                a characters-per-token ratio
         """
         self.model = model
         self.tokenizer = None
+        self.name = tokenizer
+        self.char_ratio = CHAR_RATIOS.get(tokenizer, FALLBACK_CHAR_RATIO)
         
-        if TIKTOKEN_AVAILABLE:
+        if TIKTOKEN_AVAILABLE and tokenizer in TIKTOKEN_ENCODINGS:
+            try:
+                self.tokenizer = tiktoken.get_encoding(tokenizer)
+            except Exception:
+                self.tokenizer = None
+        elif TIKTOKEN_AVAILABLE and tokenizer is None:
             try:
                 self.tokenizer = tiktoken.encoding_for_model(model)
             except:
                 self.tokenizer = tiktoken.get_encoding("cl100k_base")
+        if tokenizer is None:
+            self.name = self.tokenizer.name if self.tokenizer else DEFAULT_TOKENIZER
     
     def count_tokens(self, text: str) -> int:
         """Count tokens in text"""
         if self.tokenizer:
             return len(self.tokenizer.encode(text))
         else:
-            # Approximation: ~4 characters per token
-            return len(text) // 4
+            # Approximation: ~4 characters per token (3.5 for claude)
+            return int(len(text) / self.char_ratio)
+    
+    @property
+    def approximate(self) -> bool:
+        """True when counts come from a characters-per-token ratio"""
+        return self.tokenizer is None
+    
+    def tokenizer_name(self) -> str:
+        """Tokenizer used by count_tokens, noting when counts are approximate"""
+        if self.tokenizer:
+            return self.tokenizer.name
+        return f"{self.name} (approximate, {self.char_ratio:g} characters per token)"
     
     def recommend_model_for_tokens(self, token_count: int) -> str:
         """Recommend appropriate LLM model based on token count"""
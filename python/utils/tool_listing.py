@@ -0,0 +1,120 @@
+"""
+Paging and trimming the tools/list response
+
+Every tool's input schema, with a description for each of its options,
+makes tools/list large for clients that only need names and parameters.
+Two server options keep the handshake light:
+
+- TOOLS_LIST_DETAIL: 'brief' (default) cuts each tool description and each
+  parameter description to its first sentence, keeping every parameter,
+  type, enum, default, and required list, so tools stay callable; 'full'
+  sends everything. A client can ask for full detail on one request with
+  "_meta": {"detail": "full"} in the tools/list params.
+- TOOLS_PAGE_SIZE: tools per tools/list page (default 0: all in one page).
+  Later pages are fetched with the nextCursor of the previous response;
+  cursors are opaque to clients.
+"""
+import base64
+import copy
+import os
+import re
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+DETAIL_ENV = 'TOOLS_LIST_DETAIL'
+PAGE_SIZE_ENV = 'TOOLS_PAGE_SIZE'
+
+TOOL_DETAILS = ('brief', 'full')
+
+# Longest brief description; a first sentence past this is cut with an ellipsis
+BRIEF_MAX_CHARS = 160
+
+CURSOR_PREFIX = 'tools:'
+
+# End of a sentence: . ! or ? then space and a capital or digit, not after "e.g." or "i.e."
+SENTENCE_END = re.compile(r'(?<!e\.g\.)(?<!i\.e\.)(?<=[.!?])\s+(?=[A-Z0-9])')
+
+
+def tools_list_detail(requested: Optional[str] = None) -> str:
+    """Detail for one tools/list: the request's _meta detail, else TOOLS_LIST_DETAIL, else 'brief'"""
+    for detail in (requested, os.environ.get(DETAIL_ENV, '').strip().lower()):
+        if detail in TOOL_DETAILS:
+            return detail
+    return 'brief'
+
+
+def tools_page_size() -> int:
+    """TOOLS_PAGE_SIZE as a non-negative integer (0: no paging; unset or invalid: 0)"""
+    try:
+        return max(int(os.environ.get(PAGE_SIZE_ENV, '0')), 0)
+    except ValueError:
+        return 0
+
+
+def brief_text(text: Optional[str]) -> Optional[str]:
+    """First sentence of a description, at most BRIEF_MAX_CHARS"""
+    if not text:
+        return text
+    sentence = SENTENCE_END.split(text.strip(), maxsplit=1)[0].rstrip('.')
+    if sentence.count('(') > sentence.count(')'):
+        # The sentence ended inside a parenthetical; drop the unclosed part
+        sentence = sentence[:sentence.rfind('(')].rstrip(' ,')
+    if len(sentence) > BRIEF_MAX_CHARS:
+        sentence = sentence[:BRIEF_MAX_CHARS - 1].rsplit(' ', 1)[0] + '…'
+    return sentence
+
+
+def brief_schema(schema: Dict[str, Any]) -> Dict[str, Any]:
+    """Copy of a JSON schema with every description cut to brief_text"""
+    schema = copy.deepcopy(schema)
+
+    def trim(node: Any) -> None:
+        if isinstance(node, dict):
+            if isinstance(node.get('description'), str):
+                node['description'] = brief_text(node['description'])
+            for key, value in node.items():
+                if key == 'properties' and isinstance(value, dict):
+                    for prop in value.values():
+                        trim(prop)
+                elif key in ('items', 'anyOf', 'oneOf', 'allOf'):
+                    for item in (value if isinstance(value, list) else [value]):
+                        trim(item)
+
+    trim(schema)
+    return schema
+
+
+def encode_cursor(offset: int) -> str:
+    """Opaque cursor for the page starting at offset"""
+    return base64.urlsafe_b64encode(f"{CURSOR_PREFIX}{offset}".encode()).decode()
+
+
+def decode_cursor(cursor: str) -> int:
+    """Offset of a cursor from encode_cursor (raises ValueError for anything else)"""
+    try:
+        decoded = base64.urlsafe_b64decode(cursor.encode()).decode()
+    except (ValueError, UnicodeDecodeError):
+        raise ValueError(f"Invalid cursor: {cursor}")
+    if not decoded.startswith(CURSOR_PREFIX) or not decoded[len(CURSOR_PREFIX):].isdigit():
+        raise ValueError(f"Invalid cursor: {cursor}")
+    return int(decoded[len(CURSOR_PREFIX):])
+
+
+def paginate(items: Sequence[Any], cursor: Optional[str], page_size: int) -> Tuple[List[Any], Optional[str]]:
+    """
+    One page of items
+
+    Args:
+        cursor: nextCursor of the previous page (None: first page)
+        page_size: Items per page (0: everything from the cursor on)
+
+    Returns:
+        (page, next_cursor) - next_cursor is None on the last page
+
+    Raises:
+        ValueError: For a cursor this server did not issue or past the end
+    """
+    offset = decode_cursor(cursor) if cursor else 0
+    if offset > len(items) or (cursor and offset == len(items)):
+        raise ValueError(f"Invalid cursor: {cursor}")
+    end = offset + page_size if page_size else len(items)
+    return list(items[offset:end]), encode_cursor(end) if end < len(items) else None
@@ -0,0 +1,92 @@
+"""
+Workspace root for the paths tools read and write
+
+Without configuration, pdf_path, output_dir and the other path arguments
+are used as given, so relative paths resolve against whatever directory the
+server happened to be launched from. With WORKSPACE_ROOT set, the server
+works from that directory: relative paths resolve against it, and any path
+that ends up outside it (an absolute path elsewhere, ../ segments, or a
+symlink leading out) is refused with WorkspacePathError before the tool
+runs. That keeps an untrusted agent from reading or overwriting files
+elsewhere on the machine.
+
+http(s) URLs in pdf_path are not paths and pass through unchanged.
+"""
+import os
+from dataclasses import dataclass
+from pathlib import Path, PurePath
+from typing import Any, Dict, Optional
+
+from .pdf_download import is_pdf_url
+
+WORKSPACE_ENV = 'WORKSPACE_ROOT'
+
+# Tool arguments (also inside an "options" object) holding a file or directory path
+PATH_ARGUMENTS = (
+    'pdf_path', 'pdf_path_a', 'pdf_path_b', 'pdf_paths', 'docx_path', 'markdown_path',
+    'output_dir', 'output_path', 'directory', 'corpus_index_path',
+)
+
+
+class WorkspacePathError(ValueError):
+    """A path argument resolves outside the workspace root"""
+
+
+@dataclass
+class Workspace:
+    """Where path arguments resolve (root None: as given, no restriction)"""
+    root: Optional[Path] = None
+
+    def resolve(self, value: str, argument: str) -> str:
+        """
+        Absolute path for a path argument, inside the root
+
+        Raises:
+            WorkspacePathError: The path is outside the root
+        """
+        if self.root is None or is_pdf_url(value):
+            return value
+        path = Path(value).expanduser()
+        resolved = (path if path.is_absolute() else self.root / path).resolve()
+        if resolved != self.root and self.root not in resolved.parents:
+            raise WorkspacePathError(f"{argument} '{value}' is outside the workspace root {self.root} "
+                                     f"({WORKSPACE_ENV}); use a path inside it")
+        return str(resolved)
+
+    def resolve_arguments(self, arguments: Dict[str, Any]) -> Dict[str, Any]:
+        """Tool arguments with every path argument resolved (a new dict; the input is not changed)"""
+        if self.root is None:
+            return arguments
+        resolved = dict(arguments)
+        for name, value in arguments.items():
+            if name in PATH_ARGUMENTS and isinstance(value, str):
+                resolved[name] = self.resolve(value, name)
+            elif name in PATH_ARGUMENTS and isinstance(value, list):
+                resolved[name] = [self.resolve(item, name) if isinstance(item, str) else item for item in value]
+            elif name == 'options' and isinstance(value, dict):
+                resolved[name] = self.resolve_arguments(value)
+            elif name == 'fingerprint' and isinstance(value, str) and not value.lstrip().startswith('{'):
+                # A path to a manifest or fingerprint file rather than JSON text
+                resolved[name] = self.resolve(value, name)
+            elif name == 'pattern' and isinstance(value, str):
+                pattern = PurePath(value)
+                if pattern.is_absolute() or '..' in pattern.parts:
+                    raise WorkspacePathError(f"pattern '{value}' must be relative to directory, without '..'")
+        return resolved
+
+
+def load_workspace(root: Optional[str] = None) -> Workspace:
+    """
+    Workspace from root, or the WORKSPACE_ROOT environment variable
+
+    Raises:
+        WorkspacePathError: The root is set but is not an existing directory
+    """
+    if root is None:
+        root = os.environ.get(WORKSPACE_ENV, '').strip()
+    if not root:
+        return Workspace()
+    path = Path(root).expanduser().resolve()
+    if not path.is_dir():
+        raise WorkspacePathError(f"{WORKSPACE_ENV} {root} is not a directory")
+    return Workspace(path)
@@ -0,0 +1,149 @@
+"""
+XMP metadata parsing
+
+PDFs can carry an XMP packet (RDF/XML) next to the Info dictionary with
+richer cataloging data: Dublin Core keywords and rights, XMP basic dates,
+and fields in custom namespaces. This module turns a packet into plain
+JSON-friendly dictionaries grouped by schema.
+"""
+import io
+import xml.etree.ElementTree as ET
+from typing import Any, Dict, List, Optional, Union
+
+RDF_NS = 'http://www.w3.org/1999/02/22-rdf-syntax-ns#'
+XML_LANG = '{http://www.w3.org/XML/1998/namespace}lang'
+
+# Namespace URI -> group name in the parsed result; anything else is "custom"
+KNOWN_SCHEMAS = {
+    'http://purl.org/dc/elements/1.1/': 'dublin_core',
+    'http://ns.adobe.com/xap/1.0/': 'xmp',
+    'http://ns.adobe.com/pdf/1.3/': 'pdf',
+    'http://ns.adobe.com/xap/1.0/rights/': 'rights',
+    'http://ns.adobe.com/xap/1.0/mm/': 'media_management',
+}
+
+# Bookkeeping namespaces that carry no document metadata
+IGNORED_NAMESPACES = {
+    'adobe:ns:meta/',
+    RDF_NS,
+    'http://ns.adobe.com/xap/1.0/sType/ResourceRef#',
+    'http://ns.adobe.com/xap/1.0/sType/ResourceEvent#',
+}
+
+XmpValue = Union[str, List[str], Dict[str, Any], None]
+
+
+def _split_tag(tag: str):
+    """'{uri}local' -> (uri, local)"""
+    if tag.startswith('{'):
+        uri, local = tag[1:].split('}', 1)
+        return uri, local
+    return '', tag
+
+
+def _element_value(element: ET.Element) -> XmpValue:
+    """Value of a property element: text, list (Seq/Bag), default-language text (Alt), or struct"""
+    for container in element:
+        uri, local = _split_tag(container.tag)
+        if uri != RDF_NS:
+            continue
+        items = [item for item in container if item.tag == f'{{{RDF_NS}}}li']
+        if local in ('Seq', 'Bag'):
+            return [(item.text or '').strip() for item in items if (item.text or '').strip()]
+        if local == 'Alt':
+            default = next((item for item in items if item.get(XML_LANG) == 'x-default'), None)
+            chosen = default if default is not None else (items[0] if items else None)
+            return (chosen.text or '').strip() if chosen is not None else None
+        if local == 'Description':
+            return {_split_tag(child.tag)[1]: _element_value(child) for child in container}
+
+    if element.get(f'{{{RDF_NS}}}resource'):
+        return element.get(f'{{{RDF_NS}}}resource')
+    if len(element):
+        return {_split_tag(child.tag)[1]: _element_value(child) for child in element}
+    return (element.text or '').strip() or None
+
+
+def _prefix_map(xml_text: str) -> Dict[str, str]:
+    """Namespace URI -> prefix as declared in the packet"""
+    prefixes = {}
+    try:
+        for _, (prefix, uri) in ET.iterparse(io.BytesIO(xml_text.encode('utf-8')), events=('start-ns',)):
+            prefixes.setdefault(uri, prefix)
+    except ET.ParseError:
+        pass
+    return prefixes
+
+
+def strip_xpacket(xml_text: str) -> str:
+    """Drop the <?xpacket?> wrapper and padding around the XMP root element"""
+    for open_tag, close_tag in (('<x:xmpmeta', '</x:xmpmeta>'), ('<rdf:RDF', '</rdf:RDF>')):
+        start, end = xml_text.find(open_tag), xml_text.rfind(close_tag)
+        if start >= 0 and end > start:
+            return xml_text[start:end + len(close_tag)]
+    return xml_text.strip()
+
+
+def parse_xmp(packet: Union[bytes, str, None]) -> Optional[Dict[str, Any]]:
+    """
+    Parse an XMP packet
+
+    Returns:
+        Dictionary with dublin_core, xmp, pdf, rights, media_management, and
+        custom groups (custom keys are "prefix:name"); empty groups are
+        omitted. None if there is no packet or it isn't valid XML.
+    """
+    if not packet:
+        return None
+    xml_text = packet.decode('utf-8', errors='replace') if isinstance(packet, bytes) else packet
+    xml_text = strip_xpacket(xml_text)
+
+    try:
+        root = ET.fromstring(xml_text)
+    except ET.ParseError:
+        return None
+    prefixes = _prefix_map(xml_text)
+
+    result: Dict[str, Dict[str, Any]] = {}
+    # Top-level descriptions only; nested ones are struct values
+    descriptions = [d for rdf in root.iter(f'{{{RDF_NS}}}RDF') for d in rdf.findall(f'{{{RDF_NS}}}Description')]
+    for description in descriptions:
+        properties = [(name, value) for name, value in description.attrib.items() if name.startswith('{')]
+        properties += [(child.tag, child) for child in description]
+        for tag, value in properties:
+            uri, local = _split_tag(tag)
+            if uri in IGNORED_NAMESPACES:
+                continue
+            group = KNOWN_SCHEMAS.get(uri, 'custom')
+            key = local if group != 'custom' else f"{prefixes.get(uri, uri)}:{local}"
+            parsed = _element_value(value) if isinstance(value, ET.Element) else (value.strip() or None)
+            if parsed not in (None, [], {}):
+                result.setdefault(group, {})[key] = parsed
+
+    return result
+
+
+def format_xmp(xmp: Optional[Dict[str, Any]]) -> str:
+    """Human-readable listing of parsed XMP metadata"""
+    if not xmp:
+        return "No XMP metadata"
+    titles = {
+        'dublin_core': 'Dublin Core', 'xmp': 'XMP Basic', 'pdf': 'PDF',
+        'rights': 'Rights', 'media_management': 'Media Management', 'custom': 'Custom'
+    }
+    lines = []
+    for group, fields in xmp.items():
+        lines.append(f"{titles.get(group, group)}:")
+        for key, value in fields.items():
+            if isinstance(value, list):
+                value = ", ".join(value)
+            lines.append(f"  {key}: {value}")
+    return "\n".join(lines)
+
+
+def read_pdf_xmp(reader) -> Optional[Dict[str, Any]]:
+    """Parse the catalog's XMP stream from a pypdf PdfReader (None if absent)"""
+    metadata_ref = reader.trailer['/Root'].get_object().get('/Metadata')
+    if metadata_ref is None:
+        return None
+    return parse_xmp(metadata_ref.get_object().get_data())